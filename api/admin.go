@@ -0,0 +1,123 @@
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// invalidateRequest is the body of POST /admin/v1/invalidate.
+type invalidateRequest struct {
+	Root string `json:"root"`
+}
+
+// invalidateResponse lists the roots removed from fork choice and the head
+// selected afterwards.
+type invalidateResponse struct {
+	Invalidated []string `json:"invalidated"`
+	headResponse
+}
+
+type headResponse struct {
+	Head     string `json:"head"`
+	HeadSlot uint64 `json:"head_slot"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func (s *Service) handleInvalidate(w http.ResponseWriter, r *http.Request) {
+	var req invalidateRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<10)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("decode request: %v", err))
+		return
+	}
+	root, err := parseRoot(req.Root)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	removed, err := s.fc.InvalidateBlock(root)
+	if err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	resp := invalidateResponse{
+		Invalidated:  make([]string, len(removed)),
+		headResponse: s.head(),
+	}
+	for i, h := range removed {
+		resp.Invalidated[i] = formatRoot(h)
+	}
+	s.log.Warn("admin invalidated block",
+		"root", req.Root,
+		"count", len(removed),
+		"head", resp.Head,
+	)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Service) handleRecomputeHead(w http.ResponseWriter, r *http.Request) {
+	s.fc.RecomputeHead()
+	resp := s.head()
+	s.log.Info("admin recomputed head", "head", resp.Head, "head_slot", resp.HeadSlot)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+type disabledResponse struct {
+	Disabled []uint64 `json:"disabled"`
+}
+
+type enableResponse struct {
+	Validator uint64 `json:"validator"`
+	Enabled   bool   `json:"enabled"` // false if the validator was not disabled
+}
+
+func (s *Service) handleDisabledValidators(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, disabledResponse{Disabled: s.duties.DisabledValidators()})
+}
+
+func (s *Service) handleEnableValidator(w http.ResponseWriter, r *http.Request) {
+	idx, err := strconv.ParseUint(r.PathValue("index"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid validator index %q", r.PathValue("index")))
+		return
+	}
+	enabled := s.duties.EnableDuties(idx)
+	s.log.Info("admin enable validator duties", "validator", idx, "was_disabled", enabled)
+	writeJSON(w, http.StatusOK, enableResponse{Validator: idx, Enabled: enabled})
+}
+
+func (s *Service) head() headResponse {
+	status := s.fc.GetStatus()
+	return headResponse{Head: formatRoot(status.Head), HeadSlot: status.HeadSlot}
+}
+
+func parseRoot(s string) ([32]byte, error) {
+	var root [32]byte
+	b, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil || len(b) != len(root) {
+		return root, fmt.Errorf("invalid root %q: want 32 hex-encoded bytes", s)
+	}
+	copy(root[:], b)
+	return root, nil
+}
+
+func formatRoot(root [32]byte) string {
+	return "0x" + hex.EncodeToString(root[:])
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, msg string) {
+	writeJSON(w, code, errorResponse{Error: msg})
+}
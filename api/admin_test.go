@@ -0,0 +1,297 @@
+package api_test
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/geanlabs/gean/api"
+	"github.com/geanlabs/gean/chain/forkchoice"
+	"github.com/geanlabs/gean/chain/statetransition"
+	"github.com/geanlabs/gean/storage/checkpoint"
+	"github.com/geanlabs/gean/storage/memory"
+	"github.com/geanlabs/gean/types"
+)
+
+const testToken = "secret"
+
+type zeroSigner struct{}
+
+func (zeroSigner) Sign(epoch uint32, message [32]byte) ([]byte, error) {
+	return make([]byte, types.XMSSSignatureSize), nil
+}
+
+// newTestChain returns a store with genesis and one block at slot 1 on top.
+func newTestChain(t *testing.T) (*forkchoice.Store, [32]byte, [32]byte) {
+	t.Helper()
+	validators := make([]*types.Validator, 3)
+	for i := range validators {
+		validators[i] = &types.Validator{Index: uint64(i)}
+	}
+	state := statetransition.GenerateGenesis(1000, validators)
+	genesis := &types.Block{Body: &types.BlockBody{Attestations: []*types.Attestation{}}}
+	genesis.StateRoot, _ = state.HashTreeRoot()
+	genesisRoot, _ := genesis.HashTreeRoot()
+
+	fc := forkchoice.NewStore(state, genesis, memory.New())
+	envelope, err := fc.ProduceBlock(1, 1, zeroSigner{})
+	if err != nil {
+		t.Fatalf("ProduceBlock: %v", err)
+	}
+	blockRoot, _ := envelope.Message.Block.HashTreeRoot()
+	if head := fc.RecomputeHead(); head != blockRoot {
+		t.Fatalf("head = %x, want produced block %x", head, blockRoot)
+	}
+	return fc, genesisRoot, blockRoot
+}
+
+func adminRequest(path, body, remote, token string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	r.RemoteAddr = remote
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	return r
+}
+
+func rootBody(root [32]byte) string {
+	return `{"root":"0x` + hex.EncodeToString(root[:]) + `"}`
+}
+
+func TestAdminGuard(t *testing.T) {
+	fc, _, blockRoot := newTestChain(t)
+	svc, err := api.New(fc, nil, nil, testToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		remote string
+		token  string
+		want   int
+	}{
+		{"remote peer", "192.0.2.1:4000", testToken, http.StatusForbidden},
+		{"missing token", "127.0.0.1:4000", "", http.StatusUnauthorized},
+		{"wrong token", "127.0.0.1:4000", "guess", http.StatusUnauthorized},
+	} {
+		w := httptest.NewRecorder()
+		svc.Handler().ServeHTTP(w, adminRequest("/admin/v1/invalidate", rootBody(blockRoot), tc.remote, tc.token))
+		if w.Code != tc.want {
+			t.Errorf("%s: status %d, want %d", tc.name, w.Code, tc.want)
+		}
+	}
+	if fc.IsInvalidated(blockRoot) {
+		t.Fatal("rejected request invalidated a block")
+	}
+}
+
+func TestAdminInvalidateBlock(t *testing.T) {
+	fc, genesisRoot, blockRoot := newTestChain(t)
+	svc, err := api.New(fc, nil, nil, testToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	svc.Handler().ServeHTTP(w, adminRequest("/admin/v1/invalidate", rootBody(blockRoot), "127.0.0.1:4000", testToken))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Invalidated []string `json:"invalidated"`
+		Head        string   `json:"head"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Invalidated) != 1 {
+		t.Fatalf("invalidated %v, want the slot 1 block", resp.Invalidated)
+	}
+	if want := "0x" + hex.EncodeToString(genesisRoot[:]); resp.Head != want {
+		t.Fatalf("head = %s, want genesis %s", resp.Head, want)
+	}
+	if head := fc.RecomputeHead(); head != genesisRoot {
+		t.Fatalf("recomputed head = %x, want genesis", head)
+	}
+
+	// The justified checkpoint cannot be invalidated.
+	w = httptest.NewRecorder()
+	svc.Handler().ServeHTTP(w, adminRequest("/admin/v1/invalidate", rootBody(genesisRoot), "127.0.0.1:4000", testToken))
+	if w.Code != http.StatusConflict {
+		t.Fatalf("invalidating genesis: status %d, want %d", w.Code, http.StatusConflict)
+	}
+}
+
+func TestAdminRecomputeHead(t *testing.T) {
+	fc, _, blockRoot := newTestChain(t)
+	svc, err := api.New(fc, nil, nil, testToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	svc.Handler().ServeHTTP(w, adminRequest("/admin/v1/recompute_head", "", "[::1]:4000", testToken))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body.String())
+	}
+	if want := hex.EncodeToString(blockRoot[:]); !strings.Contains(w.Body.String(), want) {
+		t.Fatalf("response %s does not name head %s", w.Body.String(), want)
+	}
+}
+
+func TestStartRejectsNonLoopback(t *testing.T) {
+	fc, _, _ := newTestChain(t)
+	svc, err := api.New(fc, nil, nil, testToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Start("0.0.0.0:0"); err == nil {
+		svc.Close()
+		t.Fatal("Start accepted a wildcard address")
+	}
+}
+
+type fakeDuties struct {
+	disabled map[uint64]bool
+}
+
+func (d *fakeDuties) DisabledValidators() []uint64 {
+	var out []uint64
+	for idx := range d.disabled {
+		out = append(out, idx)
+	}
+	return out
+}
+
+func (d *fakeDuties) EnableDuties(idx uint64) bool {
+	was := d.disabled[idx]
+	delete(d.disabled, idx)
+	return was
+}
+
+func TestAdminEnableValidator(t *testing.T) {
+	fc, _, _ := newTestChain(t)
+	duties := &fakeDuties{disabled: map[uint64]bool{2: true}}
+	svc, err := api.New(fc, duties, nil, testToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	r := adminRequest("/admin/v1/validators/disabled", "", "127.0.0.1:4000", testToken)
+	r.Method = http.MethodGet
+	svc.Handler().ServeHTTP(w, r)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"disabled":[2]`) {
+		t.Fatalf("disabled: status %d body %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	svc.Handler().ServeHTTP(w, adminRequest("/admin/v1/validators/2/enable", "", "127.0.0.1:4000", testToken))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"enabled":true`) {
+		t.Fatalf("enable: status %d body %s", w.Code, w.Body.String())
+	}
+	if duties.disabled[2] {
+		t.Fatal("validator 2 still disabled")
+	}
+}
+
+func TestAdminCheckpointBundle(t *testing.T) {
+	fc, genesisRoot, _ := newTestChain(t)
+	svc, err := api.New(fc, nil, nil, testToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	r := adminRequest("/admin/v1/checkpoint", "", "127.0.0.1:4000", testToken)
+	r.Method = http.MethodGet
+	svc.Handler().ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body.String())
+	}
+	bundle, err := checkpoint.Read(w.Body)
+	if err != nil {
+		t.Fatalf("read bundle: %v", err)
+	}
+	if root, _ := bundle.Root(); root != genesisRoot {
+		t.Fatalf("bundle root = %x, want finalized genesis %x", root, genesisRoot)
+	}
+}
+
+// importBlock builds and imports an unsigned block on parent, which needs no
+// signatures since it carries no attestations.
+func importBlock(t *testing.T, fc *forkchoice.Store, slot uint64, parent [32]byte) [32]byte {
+	t.Helper()
+	pre, ok := fc.GetState(parent)
+	if !ok {
+		t.Fatalf("no state for parent %x", parent)
+	}
+	block := &types.Block{
+		Slot:          slot,
+		ProposerIndex: slot % fc.NumValidators(),
+		ParentRoot:    parent,
+		Body:          &types.BlockBody{Attestations: []*types.Attestation{}},
+	}
+	st, err := statetransition.ProcessSlots(pre, slot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st, err = statetransition.ProcessBlock(st, block); err != nil {
+		t.Fatal(err)
+	}
+	block.StateRoot, _ = st.HashTreeRoot()
+	if err := fc.ProcessBlock(&types.SignedBlockWithAttestation{Message: &types.BlockWithAttestation{Block: block}}); err != nil {
+		t.Fatalf("import block: %v", err)
+	}
+	root, _ := block.HashTreeRoot()
+	return root
+}
+
+func TestEquivocationsReportConflictingProposals(t *testing.T) {
+	fc, genesisRoot, blockRoot := newTestChain(t)
+	svc, err := api.New(fc, nil, nil, testToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := importBlock(t, fc, 2, genesisRoot)
+	second := importBlock(t, fc, 2, blockRoot)
+
+	w := httptest.NewRecorder()
+	svc.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/equivocations", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Equivocations []struct {
+			Kind      string    `json:"kind"`
+			Validator uint64    `json:"validator"`
+			Slot      uint64    `json:"slot"`
+			Roots     [2]string `json:"roots"`
+			Messages  [2]string `json:"messages"`
+		} `json:"equivocations"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Equivocations) != 1 {
+		t.Fatalf("got %d equivocations, want 1: %s", len(resp.Equivocations), w.Body.String())
+	}
+	ev := resp.Equivocations[0]
+	if ev.Kind != "proposer" || ev.Validator != 2 || ev.Slot != 2 {
+		t.Fatalf("evidence = %+v", ev)
+	}
+	if ev.Roots[0] != "0x"+hex.EncodeToString(first[:]) || ev.Roots[1] != "0x"+hex.EncodeToString(second[:]) {
+		t.Fatalf("roots = %v, want first then second", ev.Roots)
+	}
+	if ev.Messages[0] == "" || ev.Messages[1] == "" {
+		t.Fatal("evidence messages missing")
+	}
+
+	// Seeing the second block again does not add evidence.
+	importBlock(t, fc, 2, blockRoot)
+	if n := len(fc.Equivocations()); n != 1 {
+		t.Fatalf("got %d equivocations after a duplicate, want 1", n)
+	}
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/geanlabs/gean/storage/checkpoint"
+)
+
+// handleCheckpoint serves the latest finalized block and state as an
+// unsigned checkpoint bundle. Operators sign it offline with
+// `gean checkpoint sign` before handing it out.
+func (s *Service) handleCheckpoint(w http.ResponseWriter, r *http.Request) {
+	status := s.fc.GetStatus()
+	block, ok := s.fc.GetBlock(status.FinalizedRoot)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("finalized block %s not found", formatRoot(status.FinalizedRoot)))
+		return
+	}
+	state, ok := s.fc.GetState(status.FinalizedRoot)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("finalized state %s not found", formatRoot(status.FinalizedRoot)))
+		return
+	}
+	bundle, err := checkpoint.New(block, state)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var buf bytes.Buffer
+	if err := bundle.Write(&buf, nil); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.log.Info("admin exported checkpoint bundle",
+		"root", formatRoot(status.FinalizedRoot),
+		"slot", status.FinalizedSlot,
+		"bytes", buf.Len(),
+	)
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="checkpoint-%d.bin"`, status.FinalizedSlot))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buf.Bytes())
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"encoding/hex"
+	"net/http"
+
+	"github.com/geanlabs/gean/chain/forkchoice"
+)
+
+// equivocationsResponse is the body of GET /v1/equivocations.
+type equivocationsResponse struct {
+	Equivocations []equivocationJSON `json:"equivocations"`
+}
+
+// equivocationJSON is one piece of evidence. Messages holds the two
+// conflicting signed blocks or attestations, SSZ encoded, in the order they
+// were seen.
+type equivocationJSON struct {
+	Kind      string    `json:"kind"`
+	Validator uint64    `json:"validator"`
+	Slot      uint64    `json:"slot"`
+	Roots     [2]string `json:"roots"`
+	Messages  [2]string `json:"messages"`
+}
+
+func (s *Service) handleEquivocations(w http.ResponseWriter, r *http.Request) {
+	evidence := s.fc.Equivocations()
+	resp := equivocationsResponse{Equivocations: make([]equivocationJSON, 0, len(evidence))}
+	for _, ev := range evidence {
+		e := equivocationJSON{
+			Kind:      string(ev.Kind),
+			Validator: ev.Validator,
+			Slot:      ev.Slot,
+		}
+		for i := range ev.Roots {
+			e.Roots[i] = formatRoot(ev.Roots[i])
+			var (
+				enc []byte
+				err error
+			)
+			if ev.Kind == forkchoice.ProposerEquivocation {
+				enc, err = ev.Blocks[i].MarshalSSZ()
+			} else {
+				enc, err = ev.Attestations[i].MarshalSSZ()
+			}
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			e.Messages[i] = "0x" + hex.EncodeToString(enc)
+		}
+		resp.Equivocations = append(resp.Equivocations, e)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Keymanager adds and removes validator keys while the node runs. Duties
+// that implement it get the /admin/v1/keys endpoints.
+type Keymanager interface {
+	ValidatorKeys() map[uint64][]byte
+	ImportKeystore(idx uint64, pubkey, seckey []byte) (bool, error)
+	RemoveKey(idx uint64) bool
+}
+
+// maxKeystoreRequest bounds the body of POST /admin/v1/keys. An XMSS secret
+// key carries its prepared trees, so keystores run to megabytes.
+const maxKeystoreRequest = 128 << 20
+
+// keysResponse is the body of GET /admin/v1/keys.
+type keysResponse struct {
+	Keys []keyJSON `json:"keys"`
+}
+
+type keyJSON struct {
+	Validator uint64 `json:"validator"`
+	Pubkey    string `json:"pubkey"`
+}
+
+// importKeyRequest is the body of POST /admin/v1/keys: a keystore as written
+// by keygen, the validator_<idx>.pk and .sk files, hex encoded.
+type importKeyRequest struct {
+	Validator uint64 `json:"validator"`
+	Pubkey    string `json:"pubkey"`
+	SecretKey string `json:"secret_key"`
+}
+
+type importKeyResponse struct {
+	Validator uint64 `json:"validator"`
+	Imported  bool   `json:"imported"` // false if the validator already had a key
+}
+
+type removeKeyResponse struct {
+	Validator uint64 `json:"validator"`
+	Removed   bool   `json:"removed"`
+}
+
+func (s *Service) handleListKeys(w http.ResponseWriter, r *http.Request) {
+	keys := s.keys.ValidatorKeys()
+	resp := keysResponse{Keys: make([]keyJSON, 0, len(keys))}
+	for idx, pub := range keys {
+		resp.Keys = append(resp.Keys, keyJSON{Validator: idx, Pubkey: "0x" + hex.EncodeToString(pub)})
+	}
+	sort.Slice(resp.Keys, func(i, j int) bool { return resp.Keys[i].Validator < resp.Keys[j].Validator })
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Service) handleImportKey(w http.ResponseWriter, r *http.Request) {
+	var req importKeyRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxKeystoreRequest)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("decode request: %v", err))
+		return
+	}
+	pubkey, err := hex.DecodeString(strings.TrimPrefix(req.Pubkey, "0x"))
+	if err != nil || len(pubkey) == 0 {
+		writeError(w, http.StatusBadRequest, "invalid pubkey: want hex-encoded bytes")
+		return
+	}
+	seckey, err := hex.DecodeString(strings.TrimPrefix(req.SecretKey, "0x"))
+	if err != nil || len(seckey) == 0 {
+		writeError(w, http.StatusBadRequest, "invalid secret_key: want hex-encoded bytes")
+		return
+	}
+
+	imported, err := s.keys.ImportKeystore(req.Validator, pubkey, seckey)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.log.Info("admin imported validator key", "validator", req.Validator, "imported", imported)
+	code := http.StatusOK
+	if !imported {
+		code = http.StatusConflict
+	}
+	writeJSON(w, code, importKeyResponse{Validator: req.Validator, Imported: imported})
+}
+
+func (s *Service) handleRemoveKey(w http.ResponseWriter, r *http.Request) {
+	idx, err := strconv.ParseUint(r.PathValue("index"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid validator index %q", r.PathValue("index")))
+		return
+	}
+	removed := s.keys.RemoveKey(idx)
+	s.log.Info("admin removed validator key", "validator", idx, "removed", removed)
+	code := http.StatusOK
+	if !removed {
+		code = http.StatusNotFound
+	}
+	writeJSON(w, code, removeKeyResponse{Validator: idx, Removed: removed})
+}
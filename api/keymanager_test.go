@@ -0,0 +1,84 @@
+package api_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/geanlabs/gean/api"
+)
+
+type fakeKeymanager struct {
+	fakeDuties
+	keys map[uint64][]byte
+}
+
+func (k *fakeKeymanager) ValidatorKeys() map[uint64][]byte { return k.keys }
+
+func (k *fakeKeymanager) ImportKeystore(idx uint64, pubkey, seckey []byte) (bool, error) {
+	if string(seckey) != "sk" {
+		return false, errors.New("bad secret key")
+	}
+	if _, ok := k.keys[idx]; ok {
+		return false, nil
+	}
+	k.keys[idx] = pubkey
+	return true, nil
+}
+
+func (k *fakeKeymanager) RemoveKey(idx uint64) bool {
+	_, ok := k.keys[idx]
+	delete(k.keys, idx)
+	return ok
+}
+
+func TestKeymanager(t *testing.T) {
+	fc, _, _ := newTestChain(t)
+	km := &fakeKeymanager{keys: map[uint64][]byte{2: {0x02}}}
+	svc, err := api.New(fc, km, nil, testToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		r := adminRequest(path, body, "127.0.0.1:4000", testToken)
+		r.Method = method
+		w := httptest.NewRecorder()
+		svc.Handler().ServeHTTP(w, r)
+		return w
+	}
+
+	// Secret key "sk", hex encoded.
+	w := do(http.MethodPost, "/admin/v1/keys", `{"validator":1,"pubkey":"0x01","secret_key":"0x736b"}`)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"imported":true`) {
+		t.Fatalf("import: status %d body %s", w.Code, w.Body.String())
+	}
+	if w = do(http.MethodPost, "/admin/v1/keys", `{"validator":1,"pubkey":"0x01","secret_key":"0x736b"}`); w.Code != http.StatusConflict {
+		t.Fatalf("duplicate import: status %d, want %d", w.Code, http.StatusConflict)
+	}
+	if w = do(http.MethodPost, "/admin/v1/keys", `{"validator":3,"pubkey":"0x03","secret_key":"0x00"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("bad keystore: status %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	w = do(http.MethodGet, "/admin/v1/keys", "")
+	if want := `{"keys":[{"validator":1,"pubkey":"0x01"},{"validator":2,"pubkey":"0x02"}]}`; w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != want {
+		t.Fatalf("list: status %d body %s, want %s", w.Code, w.Body.String(), want)
+	}
+
+	if w = do(http.MethodDelete, "/admin/v1/keys/2", ""); w.Code != http.StatusOK {
+		t.Fatalf("remove: status %d body %s", w.Code, w.Body.String())
+	}
+	if w = do(http.MethodDelete, "/admin/v1/keys/2", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("second remove: status %d, want %d", w.Code, http.StatusNotFound)
+	}
+
+	// The keymanager is admin-only.
+	r := httptest.NewRequest(http.MethodGet, "/admin/v1/keys", nil)
+	r.RemoteAddr = "127.0.0.1:4000"
+	w = httptest.NewRecorder()
+	svc.Handler().ServeHTTP(w, r)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("unauthenticated list: status %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
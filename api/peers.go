@@ -0,0 +1,21 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/geanlabs/gean/network/peers"
+)
+
+// clientsResponse is the body of GET /v1/peers/clients.
+type clientsResponse struct {
+	Total   int                 `json:"total"`
+	Clients []peers.ClientCount `json:"clients"`
+}
+
+func (s *Service) handlePeerClients(w http.ResponseWriter, r *http.Request) {
+	resp := clientsResponse{Clients: s.peers.ClientCounts()}
+	for _, c := range resp.Clients {
+		resp.Total += c.Peers
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,42 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/geanlabs/gean/api"
+	"github.com/geanlabs/gean/network/peers"
+)
+
+func TestPeerClients(t *testing.T) {
+	fc, _, _ := newTestChain(t)
+	pm := peers.NewManager()
+	pm.SetAgent(peer.ID("a"), "zeam/v0.3.0")
+	pm.SetAgent(peer.ID("b"), "zeam/v0.3.0")
+	pm.SetAgent(peer.ID("c"), "ream/v0.1.0")
+	svc, err := api.New(fc, nil, pm, testToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The status endpoint needs no token.
+	w := httptest.NewRecorder()
+	svc.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/peers/clients", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d body %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Total   int                 `json:"total"`
+		Clients []peers.ClientCount `json:"clients"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Total != 3 || len(resp.Clients) != 2 || resp.Clients[0] != (peers.ClientCount{Client: "zeam", Version: "v0.3.0", Peers: 2}) {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+}
@@ -0,0 +1,147 @@
+// Package api serves the node's local HTTP API.
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/geanlabs/gean/chain/forkchoice"
+	"github.com/geanlabs/gean/network/peers"
+	"github.com/geanlabs/gean/observability/logging"
+)
+
+// Service is the node API server. It only listens on loopback addresses;
+// admin endpoints additionally require a bearer token.
+type Service struct {
+	fc     *forkchoice.Store
+	duties Duties
+	keys   Keymanager
+	peers  Peers
+	token  string
+	log    *slog.Logger
+	server *http.Server
+	ln     net.Listener
+}
+
+// Duties is the validator duty control exposed by the admin API.
+type Duties interface {
+	DisabledValidators() []uint64
+	EnableDuties(idx uint64) bool
+}
+
+// Peers reports the client implementations of connected peers.
+type Peers interface {
+	ClientCounts() []peers.ClientCount
+}
+
+// New returns a Service for fc and, if non-nil, duties and pm. token must
+// be non-empty. Duties that also implement Keymanager enable the keymanager
+// endpoints.
+func New(fc *forkchoice.Store, duties Duties, pm Peers, token string) (*Service, error) {
+	if token == "" {
+		return nil, errors.New("api token is required")
+	}
+	s := &Service{
+		fc:     fc,
+		duties: duties,
+		peers:  pm,
+		token:  token,
+		log:    logging.NewComponentLogger(logging.CompAPI),
+	}
+	mux := http.NewServeMux()
+	mux.Handle("POST /admin/v1/invalidate", s.guard(http.HandlerFunc(s.handleInvalidate)))
+	mux.Handle("POST /admin/v1/recompute_head", s.guard(http.HandlerFunc(s.handleRecomputeHead)))
+	mux.Handle("GET /admin/v1/checkpoint", s.guard(http.HandlerFunc(s.handleCheckpoint)))
+	mux.Handle("POST /v1/blocks/validate", s.guard(http.HandlerFunc(s.handleValidateBlock)))
+	mux.HandleFunc("GET /v1/equivocations", s.handleEquivocations)
+	mux.HandleFunc("GET /v1/fork_choice/tree", s.handleTree)
+	if pm != nil {
+		mux.HandleFunc("GET /v1/peers/clients", s.handlePeerClients)
+	}
+	if duties != nil {
+		mux.Handle("GET /admin/v1/validators/disabled", s.guard(http.HandlerFunc(s.handleDisabledValidators)))
+		mux.Handle("POST /admin/v1/validators/{index}/enable", s.guard(http.HandlerFunc(s.handleEnableValidator)))
+	}
+	if km, ok := duties.(Keymanager); ok {
+		s.keys = km
+		mux.Handle("GET /admin/v1/keys", s.guard(http.HandlerFunc(s.handleListKeys)))
+		mux.Handle("POST /admin/v1/keys", s.guard(http.HandlerFunc(s.handleImportKey)))
+		mux.Handle("DELETE /admin/v1/keys/{index}", s.guard(http.HandlerFunc(s.handleRemoveKey)))
+	}
+	s.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	return s, nil
+}
+
+// Handler returns the HTTP handler serving the API.
+func (s *Service) Handler() http.Handler {
+	return s.server.Handler
+}
+
+// Start listens on addr, which must be a loopback address, and serves the API
+// in the background.
+func (s *Service) Start(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("parse api addr: %w", err)
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return fmt.Errorf("api addr %s is not a loopback address", addr)
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+	s.ln = ln
+	go func() {
+		if err := s.server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.log.Error("api server error", "err", err)
+		}
+	}()
+	s.log.Info("api server started", "addr", ln.Addr().String())
+	return nil
+}
+
+// Addr returns the listening address, or nil before Start.
+func (s *Service) Addr() net.Addr {
+	if s.ln == nil {
+		return nil
+	}
+	return s.ln.Addr()
+}
+
+// Close stops the server.
+func (s *Service) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return s.server.Shutdown(ctx)
+}
+
+// guard rejects requests that are not from a loopback address or do not
+// carry the bearer token.
+func (s *Service) guard(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if ip := net.ParseIP(host); err != nil || ip == nil || !ip.IsLoopback() {
+			s.log.Warn("rejected non-local admin request", "remote", r.RemoteAddr, "path", r.URL.Path)
+			writeError(w, http.StatusForbidden, "admin API is local-only")
+			return
+		}
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			s.log.Warn("rejected unauthenticated admin request", "path", r.URL.Path)
+			writeError(w, http.StatusUnauthorized, "missing or invalid token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/geanlabs/gean/types"
+)
+
+// treeResponse is the body of GET /v1/fork_choice/tree.
+type treeResponse struct {
+	Head       string         `json:"head"`
+	Justified  checkpointJSON `json:"justified"`
+	Finalized  checkpointJSON `json:"finalized"`
+	SafeTarget string         `json:"safe_target"`
+	Nodes      []treeNodeJSON `json:"nodes"`
+}
+
+type checkpointJSON struct {
+	Root string `json:"root"`
+	Slot uint64 `json:"slot"`
+}
+
+// treeNodeJSON is one block of the tree. Weight is the weight of the latest
+// known votes for the block and its descendants, votes that of the votes for
+// the block alone.
+type treeNodeJSON struct {
+	Root       string `json:"root"`
+	ParentRoot string `json:"parent_root"`
+	Slot       uint64 `json:"slot"`
+	Weight     int    `json:"weight"`
+	Votes      int    `json:"votes"`
+	Canonical  bool   `json:"canonical"`
+	Viable     bool   `json:"viable"`
+	Invalid    bool   `json:"invalid"`
+}
+
+func (s *Service) handleTree(w http.ResponseWriter, r *http.Request) {
+	tree := s.fc.DumpTree()
+	resp := treeResponse{
+		Head:       formatRoot(tree.Head),
+		Justified:  formatCheckpoint(tree.Justified),
+		Finalized:  formatCheckpoint(tree.Finalized),
+		SafeTarget: formatRoot(tree.SafeTarget),
+		Nodes:      make([]treeNodeJSON, len(tree.Nodes)),
+	}
+	for i, n := range tree.Nodes {
+		resp.Nodes[i] = treeNodeJSON{
+			Root:       formatRoot(n.Root),
+			ParentRoot: formatRoot(n.ParentRoot),
+			Slot:       n.Slot,
+			Weight:     n.Weight,
+			Votes:      n.Votes,
+			Canonical:  n.Canonical,
+			Viable:     n.Viable,
+			Invalid:    n.Invalid,
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func formatCheckpoint(cp types.Checkpoint) checkpointJSON {
+	return checkpointJSON{Root: formatRoot(cp.Root), Slot: cp.Slot}
+}
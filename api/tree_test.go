@@ -0,0 +1,85 @@
+package api_test
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/geanlabs/gean/api"
+	"github.com/geanlabs/gean/types"
+)
+
+func TestForkChoiceTreeShowsForkWeights(t *testing.T) {
+	fc, genesisRoot, blockRoot := newTestChain(t)
+	svc, err := api.New(fc, nil, nil, testToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fork := importBlock(t, fc, 2, genesisRoot)
+	fc.AdvanceTime(1000+3*types.SecondsPerSlot, false)
+	for _, v := range []uint64{0, 1} {
+		fc.ProcessAttestation(&types.SignedAttestation{
+			ValidatorID: v,
+			Message: &types.AttestationData{
+				Slot:   2,
+				Head:   &types.Checkpoint{Root: fork, Slot: 2},
+				Target: &types.Checkpoint{Root: fork, Slot: 2},
+				Source: &types.Checkpoint{Root: genesisRoot, Slot: 0},
+			},
+		})
+	}
+	fc.AcceptNewAttestations()
+	if head := fc.RecomputeHead(); head != fork {
+		t.Fatalf("head = %x, want fork %x", head, fork)
+	}
+
+	w := httptest.NewRecorder()
+	svc.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/fork_choice/tree", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Head  string `json:"head"`
+		Nodes []struct {
+			Root       string `json:"root"`
+			ParentRoot string `json:"parent_root"`
+			Slot       uint64 `json:"slot"`
+			Weight     int    `json:"weight"`
+			Votes      int    `json:"votes"`
+			Canonical  bool   `json:"canonical"`
+			Viable     bool   `json:"viable"`
+		} `json:"nodes"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	hexRoot := func(root [32]byte) string { return "0x" + hex.EncodeToString(root[:]) }
+	if resp.Head != hexRoot(fork) {
+		t.Fatalf("head = %s, want %s", resp.Head, hexRoot(fork))
+	}
+	if len(resp.Nodes) != 3 {
+		t.Fatalf("got %d nodes, want 3: %s", len(resp.Nodes), w.Body.String())
+	}
+	want := map[string]struct {
+		parent    string
+		slot      uint64
+		weight    int
+		votes     int
+		canonical bool
+	}{
+		hexRoot(genesisRoot): {hexRoot(types.ZeroHash), 0, 2, 0, true},
+		hexRoot(blockRoot):   {hexRoot(genesisRoot), 1, 0, 0, false},
+		hexRoot(fork):        {hexRoot(genesisRoot), 2, 2, 2, true},
+	}
+	for _, n := range resp.Nodes {
+		exp, ok := want[n.Root]
+		if !ok {
+			t.Fatalf("unexpected node %s", n.Root)
+		}
+		if n.ParentRoot != exp.parent || n.Slot != exp.slot || n.Weight != exp.weight || n.Votes != exp.votes || n.Canonical != exp.canonical || !n.Viable {
+			t.Fatalf("node %+v, want %+v", n, exp)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/geanlabs/gean/chain/forkchoice"
+	"github.com/geanlabs/gean/chain/statetransition"
+	"github.com/geanlabs/gean/types"
+)
+
+// maxValidateRequest bounds the body of POST /v1/blocks/validate: a
+// hex-encoded block with full attestation and slashing lists.
+const maxValidateRequest = 4 << 20
+
+// codeUnknownParent is reported for a block whose parent the node does not
+// hold; the block cannot be judged either way.
+const codeUnknownParent = "unknown_parent"
+
+// validateRequest is the body of POST /v1/blocks/validate.
+type validateRequest struct {
+	Block string `json:"block"` // SSZ-encoded Block, 0x-prefixed hex
+}
+
+// validateResponse reports whether the block passes the state transition on
+// its parent's post-state. Code is one of the statetransition error codes,
+// or unknown_parent.
+type validateResponse struct {
+	Root  string `json:"root"`
+	Valid bool   `json:"valid"`
+	Code  string `json:"code,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+func (s *Service) handleValidateBlock(w http.ResponseWriter, r *http.Request) {
+	var req validateRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxValidateRequest)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("decode request: %v", err))
+		return
+	}
+	enc, err := hex.DecodeString(strings.TrimPrefix(req.Block, "0x"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("decode block hex: %v", err))
+		return
+	}
+	block := new(types.Block)
+	if err := block.UnmarshalSSZ(enc); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("decode block: %v", err))
+		return
+	}
+	root, _ := block.HashTreeRoot()
+
+	resp := validateResponse{Root: formatRoot(root), Valid: true}
+	if err := s.fc.ValidateBlock(block); err != nil {
+		resp.Valid = false
+		resp.Error = err.Error()
+		if errors.Is(err, forkchoice.ErrUnknownParent) {
+			resp.Code = codeUnknownParent
+		} else {
+			resp.Code = string(statetransition.Code(err))
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
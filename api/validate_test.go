@@ -0,0 +1,105 @@
+package api_test
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/geanlabs/gean/api"
+	"github.com/geanlabs/gean/chain/statetransition"
+	"github.com/geanlabs/gean/types"
+)
+
+func TestValidateBlockReportsErrorCodes(t *testing.T) {
+	fc, _, blockRoot := newTestChain(t)
+	svc, err := api.New(fc, nil, nil, testToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pre, _ := fc.GetState(blockRoot)
+	valid := &types.Block{Slot: 2, ProposerIndex: 2, ParentRoot: blockRoot, Body: &types.BlockBody{Attestations: []*types.Attestation{}}}
+	st, err := statetransition.ProcessSlots(pre, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st, err = statetransition.ProcessBlock(st, valid); err != nil {
+		t.Fatal(err)
+	}
+	valid.StateRoot, _ = st.HashTreeRoot()
+
+	wrongProposer := *valid
+	wrongProposer.ProposerIndex = 0
+	badRoot := *valid
+	badRoot.StateRoot = [32]byte{1}
+	orphan := *valid
+	orphan.ParentRoot = [32]byte{2}
+
+	for _, tc := range []struct {
+		name  string
+		block *types.Block
+		code  string
+	}{
+		{"valid", valid, ""},
+		{"wrong proposer", &wrongProposer, string(statetransition.CodeWrongProposer)},
+		{"bad state root", &badRoot, string(statetransition.CodeStateRootMismatch)},
+		{"unknown parent", &orphan, "unknown_parent"},
+	} {
+		enc, err := tc.block.MarshalSSZ()
+		if err != nil {
+			t.Fatal(err)
+		}
+		body := `{"block":"0x` + hex.EncodeToString(enc) + `"}`
+		w := httptest.NewRecorder()
+		svc.Handler().ServeHTTP(w, adminRequest("/v1/blocks/validate", body, "127.0.0.1:4000", testToken))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status %d: %s", tc.name, w.Code, w.Body.String())
+		}
+		var resp struct {
+			Root  string `json:"root"`
+			Valid bool   `json:"valid"`
+			Code  string `json:"code"`
+			Error string `json:"error"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		root, _ := tc.block.HashTreeRoot()
+		if resp.Root != "0x"+hex.EncodeToString(root[:]) || resp.Valid != (tc.code == "") || resp.Code != tc.code {
+			t.Errorf("%s: response %+v, want code %q", tc.name, resp, tc.code)
+		}
+	}
+
+	// Validation does not import the block.
+	validRoot, _ := valid.HashTreeRoot()
+	if _, ok := fc.GetBlock(validRoot); ok {
+		t.Fatal("validated block was imported")
+	}
+
+	w := httptest.NewRecorder()
+	svc.Handler().ServeHTTP(w, adminRequest("/v1/blocks/validate", `{"block":"0x00"}`, "127.0.0.1:4000", testToken))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("undecodable block: status %d, want 400", w.Code)
+	}
+}
+
+func TestValidateBlockRequiresToken(t *testing.T) {
+	fc, _, blockRoot := newTestChain(t)
+	svc, err := api.New(fc, nil, nil, testToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := &types.Block{Slot: 2, ProposerIndex: 2, ParentRoot: blockRoot, Body: &types.BlockBody{Attestations: []*types.Attestation{}}}
+	enc, err := block.MarshalSSZ()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	svc.Handler().ServeHTTP(w, adminRequest("/v1/blocks/validate", `{"block":"0x`+hex.EncodeToString(enc)+`"}`, "127.0.0.1:4000", ""))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("unauthenticated validate: status %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
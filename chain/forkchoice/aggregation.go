@@ -6,7 +6,6 @@ import (
 
 	"github.com/geanlabs/gean/chain/statetransition"
 	"github.com/geanlabs/gean/types"
-	"github.com/geanlabs/gean/xmss/leansig"
 )
 
 // AggregateAttestations collects attestations for the same data and
@@ -67,90 +66,110 @@ func DisaggregateAttestation(agg *types.AggregatedAttestation) ([]uint64, [][typ
 	return validatorIDs, sigs, nil
 }
 
-// VerifyAggregatedAttestation disaggregates and verifies each XMSS signature.
-// Returns the count of valid signatures.
-func VerifyAggregatedAttestation(state *types.State, agg *types.AggregatedAttestation) (int, error) {
+// AggregateVote is the verification result of one validator's signature in
+// an aggregated attestation.
+type AggregateVote struct {
+	ValidatorID uint64
+	Signature   [types.XMSSSignatureSize]byte
+	Err         error // nil if the signature is valid
+}
+
+// VerifyAggregatedAttestation disaggregates agg and verifies its XMSS
+// signatures against state's validators in one batch, which leansig checks
+// in parallel. It returns one result per signature so callers can accept
+// the valid part of a partially valid aggregate.
+func VerifyAggregatedAttestation(state *types.State, agg *types.AggregatedAttestation) ([]AggregateVote, error) {
+	return verifyAggregate(state, agg, verifyChecks)
+}
+
+// verifyAggregate verifies the signatures of agg with verify, which returns
+// an error per check.
+func verifyAggregate(state *types.State, agg *types.AggregatedAttestation, verify func([]sigCheck) []error) ([]AggregateVote, error) {
 	validatorIDs, sigs, err := DisaggregateAttestation(agg)
 	if err != nil {
-		return 0, fmt.Errorf("disaggregate: %w", err)
+		return nil, fmt.Errorf("disaggregate: %w", err)
 	}
-	verified := 0
 
+	votes := make([]AggregateVote, len(validatorIDs))
+	checks := make([]sigCheck, 0, len(validatorIDs))
+	index := make([]int, 0, len(validatorIDs))
 	for i, valID := range validatorIDs {
-		if valID >= uint64(len(state.Validators)) {
-			log.Warn("aggregated attestation: invalid validator index", "validator", valID)
+		votes[i] = AggregateVote{ValidatorID: valID, Signature: sigs[i]}
+		pubkey, err := attesterPubkey(state, valID, agg.Data.Slot)
+		if err != nil {
+			votes[i].Err = err
 			continue
 		}
-		pubkey := state.Validators[valID].Pubkey
-		att := &types.Attestation{ValidatorID: valID, Data: agg.Data}
-		messageRoot, err := att.HashTreeRoot()
+		messageRoot, err := (&types.Attestation{ValidatorID: valID, Data: agg.Data}).HashTreeRoot()
 		if err != nil {
-			return 0, fmt.Errorf("hash attestation: %w", err)
+			return nil, fmt.Errorf("hash attestation: %w", err)
 		}
-		if err := leansig.Verify(pubkey[:], uint32(agg.Data.Slot), messageRoot, sigs[i][:]); err != nil {
-			log.Warn("aggregated attestation: signature invalid",
-				"validator", valID, "slot", agg.Data.Slot, "err", err,
-			)
-			continue
+		checks = append(checks, sigCheck{
+			pubkey:  pubkey,
+			slot:    uint32(agg.Data.Slot),
+			message: messageRoot,
+			sig:     votes[i].Signature[:],
+		})
+		index = append(index, i)
+	}
+	for j, err := range verify(checks) {
+		if err != nil {
+			votes[index[j]].Err = fmt.Errorf("signature verification failed: %w", err)
 		}
-		verified++
 	}
-
-	return verified, nil
+	return votes, nil
 }
 
 // ProcessAggregatedAttestation validates and counts votes from an aggregate.
+// The signatures are verified without holding the store lock.
 func (c *Store) ProcessAggregatedAttestation(agg *types.AggregatedAttestation) {
 	c.mu.Lock()
-	defer c.mu.Unlock()
-
 	if c.NowFn != nil {
 		c.advanceTimeLocked(c.NowFn(), false)
 	}
-
-	if reason := c.validateAttestationData(agg.Data); reason != "" {
-		log.Debug("aggregated attestation rejected", "reason", reason, "slot", agg.Data.Slot)
+	reason := c.validateGossipAttestationLocked(agg.Data)
+	head := c.head
+	c.mu.Unlock()
+	if reason != AttestationAccepted {
+		log.Debug("aggregated attestation rejected", "reason", reason, "result", reason.Result(), "slot", agg.Data.Slot)
 		return
 	}
 
-	headState, ok := c.storage.GetState(c.head)
+	headState, ok := c.getState(head)
 	if !ok {
 		return
 	}
-
-	validatorIDs, sigs, err := DisaggregateAttestation(agg)
+	votes, err := verifyAggregate(headState, agg, c.sigs.verifyBatch)
 	if err != nil {
-		log.Warn("disaggregate failed", "err", err)
+		log.Warn("aggregated attestation verification failed", "err", err)
 		return
 	}
-
-	currentSlot := c.time / types.IntervalsPerSlot
-
-	for i, valID := range validatorIDs {
-		if valID >= uint64(len(headState.Validators)) {
-			continue
-		}
-		pubkey := headState.Validators[valID].Pubkey
-		att := &types.Attestation{ValidatorID: valID, Data: agg.Data}
-		messageRoot, err := att.HashTreeRoot()
-		if err != nil {
-			return
-		}
-		if err := leansig.Verify(pubkey[:], uint32(agg.Data.Slot), messageRoot, sigs[i][:]); err != nil {
-			continue
-		}
-		if agg.Data.Slot > currentSlot {
+	var verified []*types.SignedAttestation
+	for _, vote := range votes {
+		if vote.Err != nil {
+			log.Debug("aggregated attestation: signature invalid",
+				"validator", vote.ValidatorID, "slot", agg.Data.Slot, "err", vote.Err,
+			)
 			continue
 		}
-
-		sa := &types.SignedAttestation{
-			ValidatorID: valID,
+		verified = append(verified, &types.SignedAttestation{
+			ValidatorID: vote.ValidatorID,
 			Message:     agg.Data,
-			Signature:   sigs[i],
-		}
-		existing, ok := c.latestNewAttestations[valID]
+			Signature:   vote.Signature,
+		})
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	currentSlot := c.time / types.IntervalsPerSlot
+	if agg.Data.Slot > currentSlot {
+		return
+	}
+	for _, sa := range verified {
+		c.checkAttestationLocked(sa)
+		existing, ok := c.latestNewAttestations[sa.ValidatorID]
 		if !ok || existing.Message.Slot < agg.Data.Slot {
-			c.latestNewAttestations[valID] = sa
+			c.setNewLocked(sa)
 		}
 	}
 }
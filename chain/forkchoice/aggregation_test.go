@@ -0,0 +1,49 @@
+package forkchoice_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/geanlabs/gean/chain/forkchoice"
+	"github.com/geanlabs/gean/chain/statetransition"
+	"github.com/geanlabs/gean/types"
+)
+
+func TestVerifyAggregatedAttestationPerValidator(t *testing.T) {
+	validators := make([]*types.Validator, 3)
+	for i := range validators {
+		validators[i] = &types.Validator{Index: uint64(i)}
+	}
+	state := statetransition.GenerateGenesis(1000, validators)
+
+	data := &types.AttestationData{
+		Slot:   1,
+		Head:   &types.Checkpoint{},
+		Target: &types.Checkpoint{},
+		Source: &types.Checkpoint{},
+	}
+	var atts []*types.SignedAttestation
+	for _, idx := range []uint64{5, 0, 2} {
+		atts = append(atts, &types.SignedAttestation{ValidatorID: idx, Message: data})
+	}
+	agg, err := forkchoice.AggregateAttestations(atts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	votes, err := forkchoice.VerifyAggregatedAttestation(state, agg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(votes) != 3 || votes[0].ValidatorID != 0 || votes[1].ValidatorID != 2 || votes[2].ValidatorID != 5 {
+		t.Fatalf("votes = %+v, want one per validator in index order", votes)
+	}
+	if votes[2].Err == nil || !strings.Contains(votes[2].Err.Error(), "invalid validator index") {
+		t.Fatalf("vote of unknown validator 5: err = %v", votes[2].Err)
+	}
+
+	agg.AggregatedSignature = agg.AggregatedSignature[1:]
+	if _, err := forkchoice.VerifyAggregatedAttestation(state, agg); err == nil {
+		t.Fatal("truncated aggregate signature accepted")
+	}
+}
@@ -1,26 +1,78 @@
 package forkchoice
 
 import (
-	"fmt"
 	"time"
 
 	"github.com/geanlabs/gean/observability/metrics"
 	"github.com/geanlabs/gean/types"
 )
 
-// ProcessAttestation processes an attestation from the network.
-func (c *Store) ProcessAttestation(sa *types.SignedAttestation) {
+// ProcessAttestation processes an attestation from the network and reports
+// whether it was applied or why not.
+func (c *Store) ProcessAttestation(sa *types.SignedAttestation) AttestationReason {
+	return c.ProcessAttestations([]*types.SignedAttestation{sa})[0]
+}
+
+// ProcessAttestations processes a batch of attestations from the network and
+// returns the outcome of each. It verifies their signatures without holding
+// the store lock, then takes the lock once to apply the valid ones in order.
+func (c *Store) ProcessAttestations(atts []*types.SignedAttestation) []AttestationReason {
+	if len(atts) == 0 {
+		return nil
+	}
+	reasons := c.verifyAttestationSignatures(atts)
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
-
 	if c.NowFn != nil {
 		c.advanceTimeLocked(c.NowFn(), false)
 	}
+	for i, sa := range atts {
+		if reasons[i] == AttestationAccepted {
+			reasons[i] = c.processAttestationLocked(sa, false)
+		}
+	}
+	return reasons
+}
 
-	c.processAttestationLocked(sa, false)
+// verifyAttestationSignatures checks the signature of each attestation,
+// counting those that fail. It does not take the store lock.
+func (c *Store) verifyAttestationSignatures(atts []*types.SignedAttestation) []AttestationReason {
+	reasons := make([]AttestationReason, len(atts))
+	if !c.shouldVerifySignatures() {
+		return reasons
+	}
+	headState, ok := c.headState()
+	if !ok {
+		for i := range reasons {
+			reasons[i] = recordAttestation(AttestationNoHeadState)
+		}
+		return reasons
+	}
+	for i, sa := range atts {
+		att := &types.Attestation{ValidatorID: sa.ValidatorID, Data: sa.Message}
+		if err := c.verifyAttestationSignatureWithState(headState, att, sa.Signature); err != nil {
+			reasons[i] = recordAttestation(AttestationBadSignature)
+		}
+	}
+	return reasons
+}
+
+// headState returns the post-state of the current head, whose registry
+// gossip attestations are checked against. It holds the store lock only to
+// read the head.
+func (c *Store) headState() (*types.State, bool) {
+	c.mu.Lock()
+	head := c.head
+	c.mu.Unlock()
+	return c.getState(head)
 }
 
-func (c *Store) processAttestationLocked(sa *types.SignedAttestation, isFromBlock bool) {
+// processAttestationLocked validates and records an attestation whose
+// signature was already verified: by the caller for gossip, or with its
+// block for on-chain attestations. Each origin has its own validation
+// pipeline.
+func (c *Store) processAttestationLocked(sa *types.SignedAttestation, isFromBlock bool) AttestationReason {
 	start := time.Now()
 	defer func() {
 		metrics.AttestationValidationTime.Observe(time.Since(start).Seconds())
@@ -29,100 +81,63 @@ func (c *Store) processAttestationLocked(sa *types.SignedAttestation, isFromBloc
 	data := sa.Message
 	validatorID := sa.ValidatorID
 
-	if reason := c.validateAttestationData(data); reason != "" {
-		log.Debug("attestation rejected", "reason", reason, "slot", data.Slot, "validator", validatorID)
-		metrics.AttestationsInvalid.Inc()
-		return
+	// Votes can arrive before the blocks they reference; defer them instead
+	// of dropping, unless they are too far in the future to matter.
+	if data.Slot <= c.time/types.IntervalsPerSlot+1 {
+		if root, missing := c.missingAttestationBlockLocked(data); missing {
+			c.deferAttestationLocked(root, sa, isFromBlock)
+			return AttestationPending
+		}
 	}
 
-	// Verify signature (skip for on-chain attestations; already verified in ProcessBlock).
-	if !isFromBlock && c.shouldVerifySignatures() {
-		if err := c.verifyAttestationSignature(sa); err != nil {
-			metrics.AttestationsInvalid.Inc()
-			return
-		}
+	var reason AttestationReason
+	if isFromBlock {
+		reason = c.validateBlockAttestationLocked(data)
+	} else {
+		reason = c.validateGossipAttestationLocked(data)
 	}
+	if reason != AttestationAccepted {
+		log.Debug("attestation rejected",
+			"reason", reason,
+			"result", reason.Result(),
+			"from_block", isFromBlock,
+			"slot", data.Slot,
+			"validator", validatorID,
+		)
+		return recordAttestation(reason)
+	}
+
+	c.checkAttestationLocked(sa)
 
 	if isFromBlock {
 		// On-chain: update known attestations if this is newer.
 		existing, ok := c.latestKnownAttestations[validatorID]
 		if !ok || existing.Message.Slot < data.Slot {
-			c.latestKnownAttestations[validatorID] = sa
+			c.setKnownLocked(sa)
 		}
 		// Remove from new attestations if superseded.
 		newAtt, ok := c.latestNewAttestations[validatorID]
 		if ok && newAtt.Message.Slot <= data.Slot {
-			delete(c.latestNewAttestations, validatorID)
+			c.deleteNewLocked(validatorID)
 		}
 	} else {
 		// Network gossip attestation processing.
 		currentSlot := c.time / types.IntervalsPerSlot
 		if data.Slot > currentSlot {
-			metrics.AttestationsInvalid.Inc()
-			return
+			// Early for a slot whose start this store has not ticked
+			// past yet: apply it when the slot begins.
+			if !c.queueFutureAttestationLocked(sa) {
+				return recordAttestation(AttestationFutureQueueFull)
+			}
+			return AttestationPending
 		}
 
 		// Network gossip: update new attestations if this is newer.
 		existing, ok := c.latestNewAttestations[validatorID]
 		if !ok || existing.Message.Slot < data.Slot {
-			c.latestNewAttestations[validatorID] = sa
+			c.setNewLocked(sa)
 		}
 	}
 
-	metrics.AttestationsValid.Inc()
-}
-
-// verifyAttestationSignature verifies the XMSS signature on the attestation.
-func (c *Store) verifyAttestationSignature(sa *types.SignedAttestation) error {
-	headState, ok := c.storage.GetState(c.head)
-	if !ok {
-		return fmt.Errorf("head state not found")
-	}
-
-	att := &types.Attestation{
-		ValidatorID: sa.ValidatorID,
-		Data:        sa.Message,
-	}
-	return c.verifyAttestationSignatureWithState(headState, att, sa.Signature)
-}
-
-// validateAttestationData performs attestation validation checks.
-// Returns an empty string if valid, or a rejection reason.
-func (c *Store) validateAttestationData(data *types.AttestationData) string {
-	// Availability check: source, target, and head blocks must exist.
-	sourceBlock, ok := c.storage.GetBlock(data.Source.Root)
-	if !ok {
-		return "source block unknown"
-	}
-	targetBlock, ok := c.storage.GetBlock(data.Target.Root)
-	if !ok {
-		return "target block unknown"
-	}
-	if _, ok := c.storage.GetBlock(data.Head.Root); !ok {
-		return "head block unknown"
-	}
-
-	// Topology check.
-	if sourceBlock.Slot > targetBlock.Slot {
-		return "source slot > target slot"
-	}
-	if data.Source.Slot > data.Target.Slot {
-		return "source slot > target slot"
-	}
-
-	// Consistency check.
-	if sourceBlock.Slot != data.Source.Slot {
-		return "source checkpoint slot mismatch"
-	}
-	if targetBlock.Slot != data.Target.Slot {
-		return "target checkpoint slot mismatch"
-	}
-
-	// Time check.
-	currentSlot := c.time / types.IntervalsPerSlot
-	if data.Slot > currentSlot+1 {
-		return "attestation too far in future"
-	}
-
-	return ""
+	return recordAttestation(AttestationAccepted)
 }
@@ -0,0 +1,149 @@
+package forkchoice
+
+import (
+	"sort"
+
+	"github.com/geanlabs/gean/types"
+)
+
+// slotIndex maps an attestation slot to the set of validators whose latest
+// vote in the corresponding attestation map is for that slot. It lets
+// slot-based queries and pruning avoid scanning every validator.
+type slotIndex map[uint64]map[uint64]struct{}
+
+func (s slotIndex) add(slot, validatorID uint64) {
+	ids, ok := s[slot]
+	if !ok {
+		ids = make(map[uint64]struct{})
+		s[slot] = ids
+	}
+	ids[validatorID] = struct{}{}
+}
+
+func (s slotIndex) remove(slot, validatorID uint64) {
+	ids, ok := s[slot]
+	if !ok {
+		return
+	}
+	delete(ids, validatorID)
+	if len(ids) == 0 {
+		delete(s, slot)
+	}
+}
+
+// setKnownLocked records sa as the latest known attestation for its validator.
+func (c *Store) setKnownLocked(sa *types.SignedAttestation) {
+	prev, hadPrev := c.latestKnownAttestations[sa.ValidatorID]
+	if hadPrev {
+		c.knownBySlot.remove(prev.Message.Slot, sa.ValidatorID)
+	}
+	c.latestKnownAttestations[sa.ValidatorID] = sa
+	c.knownBySlot.add(sa.Message.Slot, sa.ValidatorID)
+	c.proto.vote(knownVotes, sa.ValidatorID, sa.Message.Head.Root, c.voteWeightLocked(sa.ValidatorID))
+	c.knownVersion++
+
+	if c.packing != nil {
+		if hadPrev {
+			c.packing.remove(prev)
+		}
+		c.packing.add(sa)
+	}
+}
+
+// setNewLocked records sa as the latest new attestation for its validator.
+func (c *Store) setNewLocked(sa *types.SignedAttestation) {
+	if prev, ok := c.latestNewAttestations[sa.ValidatorID]; ok {
+		c.newBySlot.remove(prev.Message.Slot, sa.ValidatorID)
+	}
+	c.latestNewAttestations[sa.ValidatorID] = sa
+	c.newBySlot.add(sa.Message.Slot, sa.ValidatorID)
+	c.proto.vote(newVotes, sa.ValidatorID, sa.Message.Head.Root, c.voteWeightLocked(sa.ValidatorID))
+}
+
+func (c *Store) deleteNewLocked(validatorID uint64) {
+	if prev, ok := c.latestNewAttestations[validatorID]; ok {
+		c.newBySlot.remove(prev.Message.Slot, validatorID)
+		delete(c.latestNewAttestations, validatorID)
+		c.proto.unvote(newVotes, validatorID)
+	}
+}
+
+// AttestationsAtSlot returns the latest attestations, known or new, whose
+// attestation slot equals slot, ordered by validator index. When a validator
+// has both a known and a new attestation at the slot, the new one is returned.
+func (c *Store) AttestationsAtSlot(slot uint64) []*types.SignedAttestation {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	byValidator := make(map[uint64]*types.SignedAttestation)
+	for id := range c.knownBySlot[slot] {
+		byValidator[id] = c.latestKnownAttestations[id]
+	}
+	for id := range c.newBySlot[slot] {
+		byValidator[id] = c.latestNewAttestations[id]
+	}
+
+	out := make([]*types.SignedAttestation, 0, len(byValidator))
+	for _, sa := range byValidator {
+		out = append(out, sa)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ValidatorID < out[j].ValidatorID })
+	return out
+}
+
+// AttestationCountsBySlot returns, for every slot with at least one tracked
+// attestation, the number of distinct validators that attested at that slot.
+func (c *Store) AttestationCountsBySlot() map[uint64]int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	counts := make(map[uint64]int, len(c.knownBySlot)+len(c.newBySlot))
+	for slot, ids := range c.knownBySlot {
+		counts[slot] = len(ids)
+	}
+	for slot, ids := range c.newBySlot {
+		for id := range ids {
+			if _, dup := c.knownBySlot[slot][id]; !dup {
+				counts[slot]++
+			}
+		}
+	}
+	return counts
+}
+
+// DropAttestationsBefore removes all known and new attestations whose slot is
+// strictly less than slot and returns how many entries were removed.
+func (c *Store) DropAttestationsBefore(slot uint64) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.dropAttestationsBeforeLocked(slot)
+}
+
+func (c *Store) dropAttestationsBeforeLocked(slot uint64) int {
+	dropped := 0
+	for s, ids := range c.knownBySlot {
+		if s >= slot {
+			continue
+		}
+		for id := range ids {
+			delete(c.latestKnownAttestations, id)
+			c.proto.unvote(knownVotes, id)
+			dropped++
+		}
+		delete(c.knownBySlot, s)
+		c.knownVersion++
+		c.packing = nil
+	}
+	for s, ids := range c.newBySlot {
+		if s >= slot {
+			continue
+		}
+		for id := range ids {
+			delete(c.latestNewAttestations, id)
+			c.proto.unvote(newVotes, id)
+			dropped++
+		}
+		delete(c.newBySlot, s)
+	}
+	return dropped
+}
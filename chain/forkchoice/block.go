@@ -1,21 +1,35 @@
 package forkchoice
 
 import (
+	"errors"
 	"fmt"
+	"runtime"
 	"time"
 
 	"github.com/geanlabs/gean/chain/statetransition"
 	"github.com/geanlabs/gean/observability/metrics"
 	"github.com/geanlabs/gean/types"
-	"github.com/geanlabs/gean/xmss/leansig"
 )
 
-func (c *Store) verifyAttestationSignatureWithState(state *types.State, att *types.Attestation, sig [3112]byte) error {
+// attesterPubkey returns the key of validator id of state, which must be
+// active at slot to attest.
+func attesterPubkey(state *types.State, id, slot uint64) ([]byte, error) {
+	if id >= uint64(len(state.Validators)) {
+		return nil, fmt.Errorf("invalid validator index %d", id)
+	}
+	v := state.Validators[id]
+	if !v.IsActive(types.EpochAt(slot)) {
+		return nil, fmt.Errorf("validator %d is not active at slot %d", id, slot)
+	}
+	return v.Pubkey[:], nil
+}
+
+func (c *Store) verifyAttestationSignatureWithState(state *types.State, att *types.Attestation, sig [types.XMSSSignatureSize]byte) error {
 	valID := att.ValidatorID
-	if valID >= uint64(len(state.Validators)) {
-		return fmt.Errorf("invalid validator index %d", valID)
+	pubkey, err := attesterPubkey(state, valID, att.Data.Slot)
+	if err != nil {
+		return err
 	}
-	pubkey := state.Validators[valID].Pubkey
 
 	messageRoot, err := att.HashTreeRoot()
 	if err != nil {
@@ -24,7 +38,7 @@ func (c *Store) verifyAttestationSignatureWithState(state *types.State, att *typ
 
 	signingSlot := uint32(att.Data.Slot)
 
-	if err := leansig.Verify(pubkey[:], signingSlot, messageRoot, sig[:]); err != nil {
+	if err := c.sigs.verify(pubkey, signingSlot, messageRoot, sig[:]); err != nil {
 		log.Warn("attestation signature invalid", "slot", att.Data.Slot, "validator", valID, "err", err)
 		return fmt.Errorf("signature verification failed: %w", err)
 	}
@@ -32,6 +46,25 @@ func (c *Store) verifyAttestationSignatureWithState(state *types.State, att *typ
 	return nil
 }
 
+// ErrConflictsWithAnchor is returned by ProcessBlock for a block at or before
+// the anchor slot that is not the anchor block itself, such as a competing
+// genesis block. Such a block can never join the store's chain, and its
+// parent is not worth fetching.
+var ErrConflictsWithAnchor = errors.New("block conflicts with anchor")
+
+// ErrUnknownParent is returned for a block whose parent state the store does
+// not hold.
+var ErrUnknownParent = errors.New("parent state not found")
+
+// ImportTimings breaks down the time ProcessBlock spent on a block.
+type ImportTimings struct {
+	StateTransition time.Duration
+	SignatureVerify time.Duration
+	VoteProcessing  time.Duration // body, proposer and replayed attestations
+	HeadUpdate      time.Duration
+	Total           time.Duration
+}
+
 // ProcessBlock processes a new signed block envelope and updates chain state.
 // Attestation processing follows leanSpec on_block ordering:
 //  1. State transition on the bare block.
@@ -39,71 +72,93 @@ func (c *Store) verifyAttestationSignatureWithState(state *types.State, att *typ
 //  3. Update head.
 //  4. Process proposer attestation as gossip vote (is_from_block=false).
 func (c *Store) ProcessBlock(envelope *types.SignedBlockWithAttestation) error {
+	_, err := c.ProcessBlockTimed(envelope)
+	return err
+}
+
+// ValidateBlock runs the state transition for block on its parent's
+// post-state without importing it. Signatures are not checked. A rejected
+// block's error wraps a state transition error, which
+// statetransition.Code classifies, or ErrUnknownParent.
+func (c *Store) ValidateBlock(block *types.Block) error {
+	parentState, ok := c.getState(block.ParentRoot)
+	if !ok {
+		return fmt.Errorf("%w: %x", ErrUnknownParent, block.ParentRoot)
+	}
+	if _, err := statetransition.StateTransition(parentState, block); err != nil {
+		return fmt.Errorf("state_transition: %w", err)
+	}
+	return nil
+}
+
+// ProcessBlockTimed is ProcessBlock, also returning where the time went. The
+// timings are zero if the block was already known.
+func (c *Store) ProcessBlockTimed(envelope *types.SignedBlockWithAttestation) (ImportTimings, error) {
+	var t ImportTimings
 	start := time.Now()
+	block := envelope.Message.Block
+	blockHash, _ := block.HashTreeRoot()
+
+	// Signatures are verified before taking the lock, so other callers are
+	// not held up by XMSS. Known and invalid blocks are turned away first
+	// so they are not verified for nothing.
+	c.mu.Lock()
+	known, err := c.precheckBlockLocked(blockHash, block)
+	c.mu.Unlock()
+	if known || err != nil {
+		return t, err
+	}
+	if err := checkSignatureCount(envelope); err != nil {
+		return t, err
+	}
+	if c.shouldVerifySignatures() {
+		parentState, ok := c.getState(block.ParentRoot)
+		if !ok {
+			return t, fmt.Errorf("%w: %x", ErrUnknownParent, block.ParentRoot)
+		}
+		sigStart := time.Now()
+		if err := c.verifyBlockSignatures(parentState, envelope); err != nil {
+			return t, err
+		}
+		t.SignatureVerify = time.Since(sigStart)
+	}
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	if c.NowFn != nil {
 		c.advanceTimeLocked(c.NowFn(), false)
 	}
-
-	block := envelope.Message.Block
-	blockHash, _ := block.HashTreeRoot()
-
-	if _, ok := c.storage.GetBlock(blockHash); ok {
-		return nil // already known
+	// The store may have changed while the lock was released.
+	if known, err := c.precheckBlockLocked(blockHash, block); known || err != nil {
+		return ImportTimings{}, err
 	}
 
-	parentState, ok := c.storage.GetState(block.ParentRoot)
+	parentState, ok := c.getState(block.ParentRoot)
 	if !ok {
-		return fmt.Errorf("parent state not found for %x", block.ParentRoot)
+		return t, fmt.Errorf("%w: %x", ErrUnknownParent, block.ParentRoot)
 	}
 
 	stStart := time.Now()
 	state, err := statetransition.StateTransition(parentState, block)
-	metrics.StateTransitionTime.Observe(time.Since(stStart).Seconds())
+	t.StateTransition = time.Since(stStart)
+	metrics.StateTransitionTime.Observe(t.StateTransition.Seconds())
 	if err != nil {
-		return fmt.Errorf("state_transition: %w", err)
-	}
-
-	// Validate signature list shape.
-	numBodyAtts := len(block.Body.Attestations)
-	if envelope.Message.ProposerAttestation != nil {
-		// With proposer attestation: exactly len(body_attestations) + 1 signatures.
-		if len(envelope.Signature) != numBodyAtts+1 {
-			return fmt.Errorf("signature count mismatch: got %d, want %d (body=%d + proposer=1)",
-				len(envelope.Signature), numBodyAtts+1, numBodyAtts)
-		}
-	} else {
-		// Without proposer attestation: exactly len(body_attestations) signatures.
-		if len(envelope.Signature) != numBodyAtts {
-			return fmt.Errorf("signature count mismatch: got %d, want %d (body=%d, no proposer)",
-				len(envelope.Signature), numBodyAtts, numBodyAtts)
-		}
+		return t, fmt.Errorf("state_transition: %w", err)
 	}
-
-	// Step 1b: Verify signatures (skipped when skip_sig_verify build tag is set).
-	if c.shouldVerifySignatures() {
-		// Verify Body Attestations.
-		for i, att := range block.Body.Attestations {
-			// Use parent state to get validator keys (static validators).
-			if err := c.verifyAttestationSignatureWithState(parentState, att, envelope.Signature[i]); err != nil {
-				return fmt.Errorf("invalid body attestation signature at index %d: %w", i, err)
-			}
-		}
-
-		// Verify proposer attestation signature (only when a proposer attestation is present).
-		if envelope.Message.ProposerAttestation != nil {
-			proposerSig := envelope.Signature[numBodyAtts] // Last signature
-			if err := c.verifyAttestationSignatureWithState(parentState, envelope.Message.ProposerAttestation, proposerSig); err != nil {
-				return fmt.Errorf("invalid proposer attestation signature: %w", err)
+	if c.CrossValidate {
+		if err := crossValidate(parentState, block, state); err != nil {
+			if c.OnDivergence == nil {
+				panic(err.Error())
 			}
+			c.OnDivergence(err)
 		}
 	}
 
-	c.storage.PutBlock(blockHash, block)
-	c.storage.PutSignedBlock(blockHash, envelope)
-	c.storage.PutState(blockHash, state)
+	if err := c.commitBlockLocked(blockHash, block, envelope, state); err != nil {
+		return t, err
+	}
+	c.checkProposalLocked(blockHash, envelope)
 
 	// Update justified checkpoint from this block's post-state (monotonic).
 	if state.LatestJustified.Slot > c.latestJustified.Slot {
@@ -112,10 +167,16 @@ func (c *Store) ProcessBlock(envelope *types.SignedBlockWithAttestation) error {
 	// Update finalized checkpoint from this block's post-state (monotonic).
 	if state.LatestFinalized.Slot > c.latestFinalized.Slot {
 		c.latestFinalized = state.LatestFinalized
+		// Votes older than the finalized slot can no longer add weight to
+		// any block in the justified subtree.
+		c.dropAttestationsBeforeLocked(c.latestFinalized.Slot)
+		c.prunePendingAttestationsLocked()
+		c.pruneLocked()
 	}
 
 	// Step 2: Process body attestations as on-chain votes.
 	// Pair each body attestation with its signature from the envelope.
+	voteStart := time.Now()
 	for i, att := range block.Body.Attestations {
 		sa := &types.SignedAttestation{
 			ValidatorID: att.ValidatorID,
@@ -125,8 +186,13 @@ func (c *Store) ProcessBlock(envelope *types.SignedBlockWithAttestation) error {
 		c.processAttestationLocked(sa, true)
 	}
 
+	t.VoteProcessing = time.Since(voteStart)
+
 	// Step 3: Update head.
+	headStart := time.Now()
 	c.updateHeadLocked()
+	t.HeadUpdate = time.Since(headStart)
+	voteStart = time.Now()
 
 	// Step 4: Process proposer attestation as gossip vote (is_from_block=false).
 	if envelope.Message.ProposerAttestation != nil {
@@ -134,11 +200,153 @@ func (c *Store) ProcessBlock(envelope *types.SignedBlockWithAttestation) error {
 		proposerSA := &types.SignedAttestation{
 			ValidatorID: proposerAtt.ValidatorID,
 			Message:     proposerAtt.Data,
-			Signature:   envelope.Signature[numBodyAtts], // always last
+			Signature:   envelope.Signature[len(block.Body.Attestations)], // always last
 		}
 		c.processAttestationLocked(proposerSA, false)
 	}
 
-	metrics.ForkChoiceBlockProcessingTime.Observe(time.Since(start).Seconds())
+	// Step 5: Replay attestations that were waiting for this block.
+	c.replayPendingAttestationsLocked(blockHash)
+	t.VoteProcessing += time.Since(voteStart)
+	c.checkCheckpointsLocked()
+
+	t.Total = time.Since(start)
+	metrics.ForkChoiceBlockProcessingTime.Observe(t.Total.Seconds())
+	return t, nil
+}
+
+// precheckBlockLocked reports whether the block is already known, and turns
+// away blocks that were invalidated or conflict with the anchor.
+func (c *Store) precheckBlockLocked(blockHash [32]byte, block *types.Block) (known bool, err error) {
+	if c.invalid[blockHash] {
+		return false, fmt.Errorf("block %x was invalidated", blockHash)
+	}
+	if c.invalid[block.ParentRoot] {
+		c.invalid[blockHash] = true
+		return false, fmt.Errorf("parent %x was invalidated", block.ParentRoot)
+	}
+	if _, ok := c.storage.GetBlock(blockHash); ok {
+		return true, nil // already known, including a re-gossiped anchor
+	}
+	if block.Slot <= c.anchor.Slot {
+		return false, fmt.Errorf("%w: block %x at slot %d, anchor %x at slot %d",
+			ErrConflictsWithAnchor, blockHash, block.Slot, c.anchor.Root, c.anchor.Slot)
+	}
+	return false, nil
+}
+
+// checkSignatureCount checks that an envelope carries one signature per body
+// attestation, plus one for the proposer attestation if present.
+func checkSignatureCount(envelope *types.SignedBlockWithAttestation) error {
+	numBodyAtts := len(envelope.Message.Block.Body.Attestations)
+	if envelope.Message.ProposerAttestation != nil {
+		// With proposer attestation: exactly len(body_attestations) + 1 signatures.
+		if len(envelope.Signature) != numBodyAtts+1 {
+			return fmt.Errorf("signature count mismatch: got %d, want %d (body=%d + proposer=1)",
+				len(envelope.Signature), numBodyAtts+1, numBodyAtts)
+		}
+	} else if len(envelope.Signature) != numBodyAtts {
+		// Without proposer attestation: exactly len(body_attestations) signatures.
+		return fmt.Errorf("signature count mismatch: got %d, want %d (body=%d, no proposer)",
+			len(envelope.Signature), numBodyAtts, numBodyAtts)
+	}
+	return nil
+}
+
+// verifyBlockSignatures verifies the body and proposer attestation signatures
+// of an envelope whose signature count was checked. It does not need the
+// store lock.
+func (c *Store) verifyBlockSignatures(state *types.State, envelope *types.SignedBlockWithAttestation) error {
+	block := envelope.Message.Block
+	if err := c.verifyBodySignatures(state, block.Body.Attestations, envelope.Signature); err != nil {
+		return err
+	}
+	if envelope.Message.ProposerAttestation != nil {
+		proposerSig := envelope.Signature[len(block.Body.Attestations)] // Last signature
+		if err := c.verifyAttestationSignatureWithState(state, envelope.Message.ProposerAttestation, proposerSig); err != nil {
+			return fmt.Errorf("invalid proposer attestation signature: %w", err)
+		}
+	}
+	for _, ps := range block.Body.ProposerSlashings {
+		if err := c.verifySlashingSignatures(state, ps.Attestation1, ps.Attestation2); err != nil {
+			return fmt.Errorf("invalid proposer slashing signature: %w", err)
+		}
+	}
+	for _, as := range block.Body.AttesterSlashings {
+		if err := c.verifySlashingSignatures(state, as.Attestation1, as.Attestation2); err != nil {
+			return fmt.Errorf("invalid attester slashing signature: %w", err)
+		}
+	}
+	return nil
+}
+
+// verifySlashingSignatures verifies the signed attestations carried as
+// slashing evidence.
+func (c *Store) verifySlashingSignatures(state *types.State, atts ...*types.SignedAttestation) error {
+	for _, sa := range atts {
+		if sa == nil || sa.Message == nil {
+			return fmt.Errorf("missing attestation")
+		}
+		att := &types.Attestation{ValidatorID: sa.ValidatorID, Data: sa.Message}
+		if err := c.verifyAttestationSignatureWithState(state, att, sa.Signature); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyBodySignatures verifies body attestation signatures. With more than
+// one SignatureWorkers they are checked in a single batch that leansig
+// spreads over the cores, otherwise one by one up to the first failure. The
+// failure at the lowest index is returned.
+func (c *Store) verifyBodySignatures(state *types.State, atts []*types.Attestation, sigs [][types.XMSSSignatureSize]byte) error {
+	workers := c.SignatureWorkers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	errs := make([]error, len(atts))
+	if workers <= 1 || len(atts) <= 1 {
+		for i, att := range atts {
+			if errs[i] = c.verifyAttestationSignatureWithState(state, att, sigs[i]); errs[i] != nil {
+				break
+			}
+		}
+	} else {
+		checks := make([]sigCheck, 0, len(atts))
+		index := make([]int, 0, len(atts))
+		for i, att := range atts {
+			pubkey, err := attesterPubkey(state, att.ValidatorID, att.Data.Slot)
+			if err != nil {
+				errs[i] = err
+				continue
+			}
+			messageRoot, err := att.HashTreeRoot()
+			if err != nil {
+				errs[i] = fmt.Errorf("failed to hash attestation message: %w", err)
+				continue
+			}
+			checks = append(checks, sigCheck{
+				pubkey:  pubkey,
+				slot:    uint32(att.Data.Slot),
+				message: messageRoot,
+				sig:     sigs[i][:],
+			})
+			index = append(index, i)
+		}
+		for j, err := range c.sigs.verifyBatch(checks) {
+			if err != nil {
+				i := index[j]
+				log.Warn("attestation signature invalid", "slot", atts[i].Data.Slot, "validator", atts[i].ValidatorID, "err", err)
+				errs[i] = fmt.Errorf("signature verification failed: %w", err)
+			}
+		}
+		log.Debug("body attestation signatures verified in batch", "count", len(checks))
+	}
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("invalid body attestation signature at index %d: %w", i, err)
+		}
+	}
 	return nil
 }
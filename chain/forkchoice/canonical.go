@@ -0,0 +1,56 @@
+package forkchoice
+
+import "github.com/geanlabs/gean/types"
+
+// CanonicalRoot returns the root of the block at slot on the chain ending at
+// the current head, or false if that slot is empty or beyond the head.
+func (c *Store) CanonicalRoot(slot uint64) ([32]byte, bool) {
+	return c.storage.GetCanonicalRoot(slot)
+}
+
+// updateCanonicalLocked points the storage slot index at the chain ending at
+// the current head. It walks back from the head only until it meets a block
+// already indexed at its slot, so a head that extends the chain rewrites one
+// entry and a reorg rewrites the slots back to the common ancestor. If a
+// write fails the index is rewritten in full at the next head update.
+func (c *Store) updateCanonicalLocked() {
+	if c.head == c.indexedHead {
+		return
+	}
+	head, ok := c.storage.GetBlock(c.head)
+	if !ok {
+		return
+	}
+	if err := c.writeCanonicalLocked(head); err != nil {
+		log.Error("failed to update canonical index", "head_slot", head.Slot, "err", err)
+		return
+	}
+	c.indexedHead, c.indexedSlot = c.head, head.Slot
+}
+
+func (c *Store) writeCanonicalLocked(head *types.Block) error {
+	for s := head.Slot + 1; s <= c.indexedSlot; s++ {
+		if err := c.storage.DeleteCanonicalRoot(s); err != nil {
+			return err
+		}
+	}
+	root, b := c.head, head
+	for {
+		if r, ok := c.storage.GetCanonicalRoot(b.Slot); ok && r == root {
+			return nil
+		}
+		if err := c.storage.PutCanonicalRoot(b.Slot, root); err != nil {
+			return err
+		}
+		parent, ok := c.storage.GetBlock(b.ParentRoot)
+		if !ok {
+			return nil
+		}
+		for s := parent.Slot + 1; s < b.Slot; s++ {
+			if err := c.storage.DeleteCanonicalRoot(s); err != nil {
+				return err
+			}
+		}
+		root, b = b.ParentRoot, parent
+	}
+}
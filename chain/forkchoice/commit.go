@@ -0,0 +1,63 @@
+package forkchoice
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/geanlabs/gean/chain/statetransition"
+	"github.com/geanlabs/gean/types"
+)
+
+// commitBlockLocked writes a block, its signed envelope and its post-state to
+// storage in one batch. With CheckInvariants set it first asserts that the
+// state hashes to the block's StateRoot and panics on mismatch, so a
+// divergence between the stored objects is caught where it is introduced. If
+// the write fails the block is not added to fork choice.
+func (c *Store) commitBlockLocked(root [32]byte, block *types.Block, envelope *types.SignedBlockWithAttestation, state *types.State) error {
+	if c.CheckInvariants {
+		stateRoot, err := state.HashTreeRoot()
+		if err != nil {
+			panic(fmt.Sprintf("invariant: hash state for block %x: %v", root, err))
+		}
+		if stateRoot != block.StateRoot {
+			panic(fmt.Sprintf("invariant: state root mismatch for block %x at slot %d: block=%x state=%x",
+				root, block.Slot, block.StateRoot, stateRoot))
+		}
+	}
+
+	batch := c.storage.Batch()
+	batch.PutBlock(root, block)
+	batch.PutSignedBlock(root, envelope)
+	batch.PutState(root, state)
+	if err := batch.Commit(); err != nil {
+		return fmt.Errorf("store block %x: %w", root, err)
+	}
+	c.states.Add(root, state)
+	c.proto.insert(root, block, state)
+	return nil
+}
+
+// crossValidate re-runs the transition from parent to block through
+// statetransition.ReferenceStateTransition and returns an error with a
+// field-level diff if its post-state differs from state, the result of the
+// optimized path. It catches aliasing and copy bugs in the fast path as they
+// happen.
+func crossValidate(parent *types.State, block *types.Block, state *types.State) error {
+	ref, err := statetransition.ReferenceStateTransition(parent, block)
+	if err != nil {
+		return fmt.Errorf("cross-validate: reference transition rejected block at slot %d: %w", block.Slot, err)
+	}
+	refRoot, err := ref.HashTreeRoot()
+	if err != nil {
+		return fmt.Errorf("cross-validate: hash reference state at slot %d: %w", block.Slot, err)
+	}
+	stateRoot, err := state.HashTreeRoot()
+	if err != nil {
+		return fmt.Errorf("cross-validate: hash state at slot %d: %w", block.Slot, err)
+	}
+	if refRoot == stateRoot {
+		return nil
+	}
+	return fmt.Errorf("cross-validate: state root divergence at slot %d: optimized=%x reference=%x\n  %s",
+		block.Slot, stateRoot, refRoot, strings.Join(statetransition.DiffStates(state, ref), "\n  "))
+}
@@ -0,0 +1,67 @@
+package forkchoice
+
+import (
+	"fmt"
+
+	"github.com/geanlabs/gean/observability/logging"
+	"github.com/geanlabs/gean/observability/metrics"
+	"github.com/geanlabs/gean/types"
+)
+
+// checkCheckpointsLocked asserts that the justified and finalized
+// checkpoints name stored blocks at their slots on the head's chain. The
+// head walk starts at the justified root and pruning keeps the finalized
+// one, so a violation means a consensus bug. It is logged and counted, or
+// with StrictCheckpoints set the store panics so the node halts where the
+// bug shows instead of building on it.
+func (c *Store) checkCheckpointsLocked() {
+	for _, cp := range []struct {
+		name string
+		cp   *types.Checkpoint
+	}{
+		{"justified", c.latestJustified},
+		{"finalized", c.latestFinalized},
+	} {
+		problem := c.checkpointProblemLocked(cp.cp)
+		if problem == "" {
+			continue
+		}
+		metrics.CheckpointViolations.WithLabelValues(cp.name).Inc()
+		if c.StrictCheckpoints {
+			panic(fmt.Sprintf("invariant: %s checkpoint %x at slot %d: %s (head %x)",
+				cp.name, cp.cp.Root, cp.cp.Slot, problem, c.head))
+		}
+		log.Error("checkpoint inconsistent with block tree",
+			"checkpoint", cp.name,
+			"root", logging.ShortHash(cp.cp.Root),
+			"slot", cp.cp.Slot,
+			"problem", problem,
+			"head", logging.ShortHash(c.head),
+		)
+	}
+}
+
+// checkpointProblemLocked returns what is wrong with cp, or "" if it is a
+// stored block at its slot and an ancestor of the head, or the head itself.
+// The ancestry is read from the proto-array, which holds every block under
+// the anchor.
+func (c *Store) checkpointProblemLocked(cp *types.Checkpoint) string {
+	block, ok := c.storage.GetBlock(cp.Root)
+	if !ok {
+		return "block not in storage"
+	}
+	if block.Slot != cp.Slot {
+		return fmt.Sprintf("block is at slot %d", block.Slot)
+	}
+	p := c.proto
+	i, ok := p.indices[c.head]
+	if !ok {
+		return "head not in the block tree"
+	}
+	for ; i >= 0 && p.nodes[i].slot >= cp.Slot; i = p.nodes[i].parent {
+		if p.nodes[i].root == cp.Root {
+			return ""
+		}
+	}
+	return "not an ancestor of the head"
+}
@@ -0,0 +1,72 @@
+package forkchoice
+
+import "github.com/geanlabs/gean/types"
+
+// TreeNode is a block of the fork choice tree.
+type TreeNode struct {
+	Root       [32]byte
+	ParentRoot [32]byte
+	Slot       uint64
+	// Weight is the weight of the latest known votes for the block and its
+	// descendants; Votes only that of the votes for the block itself.
+	Weight int
+	Votes  int
+	// Canonical is set for blocks on the chain ending at the head.
+	Canonical bool
+	// Viable is set for blocks the head walk may pick, Invalid for blocks
+	// invalidated through the admin API and their descendants.
+	Viable  bool
+	Invalid bool
+}
+
+// BlockTree is a snapshot of the fork choice tree, from the finalized block
+// down to every leaf, in insertion order so parents come before children.
+type BlockTree struct {
+	Head       [32]byte
+	Justified  types.Checkpoint
+	Finalized  types.Checkpoint
+	SafeTarget [32]byte
+	Nodes      []TreeNode
+}
+
+// DumpTree returns the block tree fork choice is choosing the head from,
+// with the vote weights the head walk sees, for inspecting forks.
+func (c *Store) DumpTree() *BlockTree {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p := c.proto
+	viable := p.viableFor(*c.latestJustified, c.getState)
+	canonical := make([]bool, len(p.nodes))
+	if i, ok := p.indices[c.head]; ok {
+		for ; i >= 0; i = p.nodes[i].parent {
+			canonical[i] = true
+		}
+	}
+
+	tree := &BlockTree{
+		Head:       c.head,
+		Justified:  *c.latestJustified,
+		Finalized:  *c.latestFinalized,
+		SafeTarget: c.safeTarget,
+		Nodes:      make([]TreeNode, len(p.nodes)),
+	}
+	for i, n := range p.nodes {
+		node := TreeNode{
+			Root:      n.root,
+			Slot:      n.slot,
+			Weight:    n.weight[knownVotes],
+			Votes:     p.direct[knownVotes][n.root],
+			Canonical: canonical[i],
+			Viable:    viable[i],
+			Invalid:   n.invalid,
+		}
+		if n.parent >= 0 {
+			node.ParentRoot = p.nodes[n.parent].root
+		} else if block, ok := c.storage.GetBlock(n.root); ok {
+			node.ParentRoot = block.ParentRoot
+		}
+		tree.Nodes[i] = node
+	}
+	return tree
+}
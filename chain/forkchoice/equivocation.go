@@ -0,0 +1,251 @@
+package forkchoice
+
+import (
+	"github.com/geanlabs/gean/chain/statetransition"
+	"github.com/geanlabs/gean/observability/logging"
+	"github.com/geanlabs/gean/observability/metrics"
+	"github.com/geanlabs/gean/types"
+)
+
+// maxEquivocations bounds the evidence kept in memory; the oldest is dropped
+// first.
+const maxEquivocations = 1024
+
+// EquivocationKind names the misbehavior an EquivocationEvidence proves.
+type EquivocationKind string
+
+const (
+	// ProposerEquivocation is two different blocks from one proposer at one
+	// slot.
+	ProposerEquivocation EquivocationKind = "proposer"
+	// AttestationEquivocation is two different attestations from one
+	// validator at one slot.
+	AttestationEquivocation EquivocationKind = "attestation"
+)
+
+// EquivocationEvidence is a pair of conflicting signed messages from one
+// validator. Only messages that passed signature verification are recorded.
+type EquivocationEvidence struct {
+	Kind      EquivocationKind
+	Validator uint64
+	Slot      uint64
+	// Roots are the block roots of a proposer equivocation, or the
+	// attestation data roots of an attestation equivocation, first seen
+	// first.
+	Roots [2][32]byte
+	// Blocks is set for a proposer equivocation.
+	Blocks [2]*types.SignedBlockWithAttestation
+	// Attestations is set for an attestation equivocation.
+	Attestations [2]*types.SignedAttestation
+}
+
+// equivocationKey identifies a validator's message at a slot.
+type equivocationKey struct {
+	slot      uint64
+	validator uint64
+}
+
+type reportKey struct {
+	equivocationKey
+	kind EquivocationKind
+}
+
+// equivocations tracks the first block per proposer and slot and the first
+// attestation per validator and slot, and the evidence found so far.
+type equivocations struct {
+	proposals    map[equivocationKey][32]byte
+	attestations map[equivocationKey]*types.SignedAttestation
+	reported     map[reportKey]bool
+	evidence     []EquivocationEvidence
+}
+
+func newEquivocations() *equivocations {
+	return &equivocations{
+		proposals:    make(map[equivocationKey][32]byte),
+		attestations: make(map[equivocationKey]*types.SignedAttestation),
+		reported:     make(map[reportKey]bool),
+	}
+}
+
+// Equivocations returns the equivocation evidence found so far, oldest
+// first.
+func (c *Store) Equivocations() []EquivocationEvidence {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]EquivocationEvidence(nil), c.equivocations.evidence...)
+}
+
+// checkProposalLocked records a verified block and reports a proposer
+// equivocation if the proposer already had a different block at its slot.
+func (c *Store) checkProposalLocked(root [32]byte, envelope *types.SignedBlockWithAttestation) {
+	block := envelope.Message.Block
+	key := equivocationKey{slot: block.Slot, validator: block.ProposerIndex}
+	first, ok := c.equivocations.proposals[key]
+	if !ok {
+		c.equivocations.proposals[key] = root
+		return
+	}
+	if first == root {
+		return
+	}
+	prev, ok := c.storage.GetSignedBlock(first)
+	if !ok {
+		return
+	}
+	c.reportEquivocationLocked(key, EquivocationEvidence{
+		Kind:      ProposerEquivocation,
+		Validator: block.ProposerIndex,
+		Slot:      block.Slot,
+		Roots:     [2][32]byte{first, root},
+		Blocks:    [2]*types.SignedBlockWithAttestation{prev, envelope},
+	})
+}
+
+// checkAttestationLocked records a verified attestation and reports an
+// attestation equivocation if the validator already attested differently at
+// its slot.
+func (c *Store) checkAttestationLocked(sa *types.SignedAttestation) {
+	key := equivocationKey{slot: sa.Message.Slot, validator: sa.ValidatorID}
+	prev, ok := c.equivocations.attestations[key]
+	if !ok {
+		c.equivocations.attestations[key] = sa
+		return
+	}
+	prevRoot, err := prev.Message.HashTreeRoot()
+	if err != nil {
+		return
+	}
+	root, err := sa.Message.HashTreeRoot()
+	if err != nil || root == prevRoot {
+		return
+	}
+	c.reportEquivocationLocked(key, EquivocationEvidence{
+		Kind:         AttestationEquivocation,
+		Validator:    sa.ValidatorID,
+		Slot:         sa.Message.Slot,
+		Roots:        [2][32]byte{prevRoot, root},
+		Attestations: [2]*types.SignedAttestation{prev, sa},
+	})
+}
+
+// reportEquivocationLocked keeps ev unless the same kind of equivocation was
+// already reported for the validator and slot.
+func (c *Store) reportEquivocationLocked(key equivocationKey, ev EquivocationEvidence) {
+	e := c.equivocations
+	rk := reportKey{key, ev.Kind}
+	if e.reported[rk] {
+		return
+	}
+	e.reported[rk] = true
+	if len(e.evidence) == maxEquivocations {
+		e.evidence = append(e.evidence[:0], e.evidence[1:]...)
+	}
+	e.evidence = append(e.evidence, ev)
+	metrics.Equivocations.WithLabelValues(string(ev.Kind)).Inc()
+	log.Warn("equivocation detected",
+		"kind", ev.Kind,
+		"validator", ev.Validator,
+		"slot", ev.Slot,
+		"first", logging.ShortHash(ev.Roots[0]),
+		"second", logging.ShortHash(ev.Roots[1]),
+	)
+}
+
+// slashingsLocked turns the evidence found so far into the slashings a block
+// built on state can carry: evidence the state transition rejects, such as
+// against an already slashed validator, is left out, as is evidence past the
+// per-block limits. A chain without dynamic validators takes no slashings;
+// the evidence is only reported.
+func (c *Store) slashingsLocked(state *types.State) ([]*types.ProposerSlashing, []*types.AttesterSlashing) {
+	var proposer []*types.ProposerSlashing
+	var attester []*types.AttesterSlashing
+	if !state.Config.Preset().DynamicValidators {
+		return nil, nil
+	}
+	for _, ev := range c.equivocations.evidence {
+		var err error
+		var next *types.State
+		switch ev.Kind {
+		case ProposerEquivocation:
+			if len(proposer) == types.MaxProposerSlashingsPerBlock {
+				continue
+			}
+			ps, ok := proposerSlashing(ev)
+			if !ok {
+				continue
+			}
+			if next, err = statetransition.ProcessProposerSlashings(state, []*types.ProposerSlashing{ps}); err == nil {
+				proposer = append(proposer, ps)
+			}
+		case AttestationEquivocation:
+			if len(attester) == types.MaxAttesterSlashingsPerBlock {
+				continue
+			}
+			as := &types.AttesterSlashing{Attestation1: ev.Attestations[0], Attestation2: ev.Attestations[1]}
+			if next, err = statetransition.ProcessAttesterSlashings(state, []*types.AttesterSlashing{as}); err == nil {
+				attester = append(attester, as)
+			}
+		}
+		if next != nil {
+			state = next
+		}
+	}
+	return proposer, attester
+}
+
+// proposerSlashing builds a proposer slashing from the headers of the two
+// blocks and their signed proposer attestations.
+func proposerSlashing(ev EquivocationEvidence) (*types.ProposerSlashing, bool) {
+	var headers [2]*types.BlockHeader
+	var atts [2]*types.SignedAttestation
+	for i, envelope := range ev.Blocks {
+		block := envelope.Message.Block
+		proposerAtt := envelope.Message.ProposerAttestation
+		if proposerAtt == nil || len(envelope.Signature) != len(block.Body.Attestations)+1 {
+			return nil, false
+		}
+		bodyRoot, err := block.Body.HashTreeRoot()
+		if err != nil {
+			return nil, false
+		}
+		headers[i] = &types.BlockHeader{
+			Slot:          block.Slot,
+			ProposerIndex: block.ProposerIndex,
+			ParentRoot:    block.ParentRoot,
+			StateRoot:     block.StateRoot,
+			BodyRoot:      bodyRoot,
+		}
+		atts[i] = &types.SignedAttestation{
+			ValidatorID: proposerAtt.ValidatorID,
+			Message:     proposerAtt.Data,
+			Signature:   envelope.Signature[len(block.Body.Attestations)],
+		}
+	}
+	return &types.ProposerSlashing{
+		Header1:      headers[0],
+		Header2:      headers[1],
+		Attestation1: atts[0],
+		Attestation2: atts[1],
+	}, true
+}
+
+// pruneEquivocationsLocked forgets the messages seen before slot. Evidence
+// already found is kept.
+func (c *Store) pruneEquivocationsLocked(slot uint64) {
+	e := c.equivocations
+	for k := range e.proposals {
+		if k.slot < slot {
+			delete(e.proposals, k)
+		}
+	}
+	for k := range e.attestations {
+		if k.slot < slot {
+			delete(e.attestations, k)
+		}
+	}
+	for k := range e.reported {
+		if k.slot < slot {
+			delete(e.reported, k)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package forkchoice
+
+import "github.com/geanlabs/gean/types"
+
+// headEventBuffer is the number of events a subscriber may fall behind by
+// before further events are dropped for it.
+const headEventBuffer = 64
+
+// HeadEvent reports a change of the head or of the justified or finalized
+// checkpoint.
+type HeadEvent struct {
+	OldHead  [32]byte
+	NewHead  [32]byte
+	HeadSlot uint64
+
+	// ReorgDepth is the number of blocks of the old head's chain that are
+	// no longer on the new head's chain; 0 when the new head descends from
+	// the old one. ReorgDistance is the number of blocks of the new head's
+	// chain that were not on the old one.
+	ReorgDepth    uint64
+	ReorgDistance uint64
+
+	Justified types.Checkpoint
+	Finalized types.Checkpoint
+	// JustifiedChanged and FinalizedChanged are set when the checkpoint
+	// advanced since the previous event.
+	JustifiedChanged bool
+	FinalizedChanged bool
+}
+
+// SubscribeHead returns a channel receiving an event whenever the head,
+// justified or finalized checkpoint changes. Events are sent without
+// blocking the store: a subscriber more than headEventBuffer events behind
+// misses the newer ones. Release the channel with UnsubscribeHead.
+func (c *Store) SubscribeHead() <-chan HeadEvent {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan HeadEvent, headEventBuffer)
+	c.headSubs = append(c.headSubs, ch)
+	return ch
+}
+
+// UnsubscribeHead stops events to a channel returned by SubscribeHead and
+// closes it.
+func (c *Store) UnsubscribeHead(sub <-chan HeadEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, ch := range c.headSubs {
+		if ch == sub {
+			c.headSubs = append(c.headSubs[:i], c.headSubs[i+1:]...)
+			close(ch)
+			return
+		}
+	}
+}
+
+// publishHeadLocked sends an event to the subscribers if the head or a
+// checkpoint changed since the last one. r relates the old head to the new.
+func (c *Store) publishHeadLocked(oldHead [32]byte, r reorg) {
+	justified := *c.latestJustified != c.eventJustified
+	finalized := *c.latestFinalized != c.eventFinalized
+	if oldHead == c.head && !justified && !finalized {
+		return
+	}
+	c.eventJustified, c.eventFinalized = *c.latestJustified, *c.latestFinalized
+	if len(c.headSubs) == 0 {
+		return
+	}
+	ev := HeadEvent{
+		OldHead:          oldHead,
+		NewHead:          c.head,
+		ReorgDepth:       r.depth,
+		ReorgDistance:    r.distance,
+		Justified:        c.eventJustified,
+		Finalized:        c.eventFinalized,
+		JustifiedChanged: justified,
+		FinalizedChanged: finalized,
+	}
+	if block, ok := c.storage.GetBlock(c.head); ok {
+		ev.HeadSlot = block.Slot
+	}
+	for _, ch := range c.headSubs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package forkchoice_test
+
+import (
+	"testing"
+
+	"github.com/geanlabs/gean/chain/forkchoice"
+	"github.com/geanlabs/gean/internal/testutil"
+	"github.com/geanlabs/gean/observability/metrics"
+	"github.com/geanlabs/gean/types"
+)
+
+func TestHeadEventsReportHeadChangesAndReorgs(t *testing.T) {
+	fc, _, genesisRoot := testutil.AnchoredStore(t, testutil.Genesis(testutil.Validators(3)))
+	signer := &testutil.Signer{}
+	events := fc.SubscribeHead()
+	reorgs := testutil.CounterValue(t, metrics.Reorgs)
+
+	next := func() forkchoice.HeadEvent {
+		t.Helper()
+		select {
+		case ev := <-events:
+			return ev
+		default:
+			t.Fatal("no head event")
+			return forkchoice.HeadEvent{}
+		}
+	}
+
+	roots := map[uint64][32]byte{}
+	prev := genesisRoot
+	for _, slot := range []uint64{1, 2} {
+		fc.AdvanceTime(1000+slot*types.SecondsPerSlot, true)
+		envelope, err := fc.ProduceBlock(slot, slot%3, signer)
+		if err != nil {
+			t.Fatalf("produce block at slot %d: %v", slot, err)
+		}
+		roots[slot], _ = envelope.Message.Block.HashTreeRoot()
+		fc.RecomputeHead()
+		ev := next()
+		if ev.OldHead != prev || ev.NewHead != roots[slot] || ev.HeadSlot != slot || ev.ReorgDepth != 0 {
+			t.Fatalf("slot %d event = %+v", slot, ev)
+		}
+		if got := testutil.CounterValue(t, metrics.Reorgs); got != reorgs {
+			t.Fatalf("extending the head counted as a reorg")
+		}
+		prev = roots[slot]
+	}
+
+	// Recomputing an unchanged head sends nothing.
+	fc.RecomputeHead()
+	select {
+	case ev := <-events:
+		t.Fatalf("unexpected event %+v", ev)
+	default:
+	}
+
+	if _, err := fc.InvalidateBlock(roots[2]); err != nil {
+		t.Fatalf("invalidate: %v", err)
+	}
+	ev := next()
+	if ev.OldHead != roots[2] || ev.NewHead != roots[1] || ev.ReorgDepth != 1 || ev.ReorgDistance != 0 {
+		t.Fatalf("reorg event = %+v", ev)
+	}
+	if got := testutil.CounterValue(t, metrics.Reorgs); got != reorgs+1 {
+		t.Fatalf("reorgs counter = %v, want %v", got, reorgs+1)
+	}
+
+	fc.UnsubscribeHead(events)
+	if _, ok := <-events; ok {
+		t.Fatal("channel still open after unsubscribe")
+	}
+}
@@ -0,0 +1,77 @@
+package forkchoice
+
+import "sort"
+
+// HeadCandidate is a block considered by the head walk with the weight it
+// received and the validators whose latest votes produced that weight.
+type HeadCandidate struct {
+	Root   [32]byte
+	Slot   uint64
+	Weight int
+	Voters []uint64
+}
+
+// HeadStep is one level of the head walk: the child that won and the
+// siblings it beat.
+type HeadStep struct {
+	Chosen   HeadCandidate
+	Siblings []HeadCandidate
+}
+
+// HeadExplanation describes how the current head was selected, starting
+// from the justified root.
+type HeadExplanation struct {
+	Root [32]byte
+	Head [32]byte
+	Path []HeadStep
+}
+
+// ExplainHead re-runs the head computation over the latest known
+// attestations and reports the weights along the winning path and of every
+// losing sibling, for diagnosing head disagreements between clients.
+// Siblings only include blocks the walk considered, so non-viable branches
+// are left out.
+func (c *Store) ExplainHead() *HeadExplanation {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	exp := &HeadExplanation{Root: c.latestJustified.Root, Head: c.latestJustified.Root}
+	t := newGhostTree(c.headViewLocked(), c.latestJustified.Root, c.latestJustified, c.latestKnownAttestations, c.voteWeightLocked, 0, true)
+	if t == nil {
+		return exp
+	}
+	exp.Root = t.root
+
+	current := t.root
+	for {
+		children := t.children[current]
+		if len(children) == 0 {
+			break
+		}
+		best := t.bestChild(children)
+		step := HeadStep{Chosen: t.candidate(best)}
+		for _, child := range children {
+			if child != best {
+				step.Siblings = append(step.Siblings, t.candidate(child))
+			}
+		}
+		sort.Slice(step.Siblings, func(i, j int) bool {
+			return step.Siblings[i].Weight > step.Siblings[j].Weight
+		})
+		exp.Path = append(exp.Path, step)
+		current = best
+	}
+	exp.Head = current
+	return exp
+}
+
+func (t *ghostTree) candidate(root [32]byte) HeadCandidate {
+	voters := append([]uint64(nil), t.voters[root]...)
+	sort.Slice(voters, func(i, j int) bool { return voters[i] < voters[j] })
+	return HeadCandidate{
+		Root:   root,
+		Slot:   t.blocks[root].Slot,
+		Weight: t.weights[root],
+		Voters: voters,
+	}
+}
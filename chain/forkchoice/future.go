@@ -0,0 +1,54 @@
+package forkchoice
+
+import "github.com/geanlabs/gean/types"
+
+// maxFutureAttestations bounds the gossip attestations held for a slot that
+// has not started yet.
+const maxFutureAttestations = 1024
+
+// futureAttestations holds verified gossip attestations for slots after the
+// current one, by slot, until the store's clock reaches them. Only the next
+// slot is ever held, since validation rejects anything later.
+type futureAttestations struct {
+	bySlot map[uint64][]*types.SignedAttestation
+	n      int
+}
+
+// queueFutureAttestationLocked holds an attestation for a slot that has not
+// started yet. It reports false if the queue is full.
+func (c *Store) queueFutureAttestationLocked(sa *types.SignedAttestation) bool {
+	f := &c.future
+	if f.n >= maxFutureAttestations {
+		return false
+	}
+	if f.bySlot == nil {
+		f.bySlot = make(map[uint64][]*types.SignedAttestation)
+	}
+	f.bySlot[sa.Message.Slot] = append(f.bySlot[sa.Message.Slot], sa)
+	f.n++
+	log.Debug("attestation queued until its slot",
+		"slot", sa.Message.Slot,
+		"validator", sa.ValidatorID,
+	)
+	return true
+}
+
+// releaseFutureAttestationsLocked processes the queued attestations whose
+// slot has started. Their signatures were already verified.
+func (c *Store) releaseFutureAttestationsLocked() {
+	f := &c.future
+	if f.n == 0 {
+		return
+	}
+	currentSlot := c.time / types.IntervalsPerSlot
+	for slot, atts := range f.bySlot {
+		if slot > currentSlot {
+			continue
+		}
+		delete(f.bySlot, slot)
+		f.n -= len(atts)
+		for _, sa := range atts {
+			c.processAttestationLocked(sa, false)
+		}
+	}
+}
@@ -6,84 +6,163 @@ import (
 )
 
 // GetForkChoiceHead uses LMD GHOST to find the head block from a given root.
+// If justified is non-nil the walk only descends into viable branches, those
+// whose leaf state agrees with the justified checkpoint (see viableBlocks).
+// It recounts every vote; Store keeps a protoArray up to date instead and
+// only falls back to this when the justified root is not in it.
 func GetForkChoiceHead(
 	store storage.Store,
 	root [32]byte,
+	justified *types.Checkpoint,
 	latestAttestations map[uint64]*types.SignedAttestation,
 	minScore int,
 ) [32]byte {
-	blocks := store.GetAllBlocks()
+	return newGhostTree(store, root, justified, latestAttestations, nil, minScore, false).head(root)
+}
+
+// head walks down the tree, choosing the child with the most weight. A nil
+// tree, for an unknown root, returns root.
+func (t *ghostTree) head(root [32]byte) [32]byte {
+	if t == nil {
+		return root
+	}
+	current := t.root
+	for {
+		children := t.children[current]
+		if len(children) == 0 {
+			return current
+		}
+		current = t.bestChild(children)
+	}
+}
 
+// ghostTree is the vote-weighted block tree LMD GHOST walks.
+type ghostTree struct {
+	blocks   map[[32]byte]*types.Block
+	root     [32]byte
+	weights  map[[32]byte]int
+	voters   map[[32]byte][]uint64 // only populated when requested
+	children map[[32]byte][][32]byte
+}
+
+// newGhostTree weighs every block under root by the latest attestations,
+// each counting weight(validator) or one if weight is nil, and links the
+// viable blocks with at least minScore weight. The subtree is
+// found through the storage child index rather than a scan of all blocks. A
+// zero root starts at the earliest block. It returns nil if root is unknown.
+func newGhostTree(
+	store storage.Store,
+	root [32]byte,
+	justified *types.Checkpoint,
+	latestAttestations map[uint64]*types.SignedAttestation,
+	weight func(uint64) int,
+	minScore int,
+	trackVoters bool,
+) *ghostTree {
 	// Start at earliest block if root is zero hash.
 	if root == types.ZeroHash {
-		var earliest [32]byte
-		minSlot := uint64(^uint64(0))
-		for h, b := range blocks {
+		minSlot := ^uint64(0)
+		store.ForEachBlock(storage.SlotRange{}, func(h [32]byte, b *types.Block) bool {
 			if b.Slot < minSlot {
-				minSlot = b.Slot
-				earliest = h
+				minSlot, root = b.Slot, h
 			}
-		}
-		root = earliest
+			return true
+		})
 	}
 
-	rootBlock, ok := blocks[root]
+	rootBlock, ok := store.GetBlock(root)
 	if !ok {
-		return root
+		return nil
 	}
 	rootSlot := rootBlock.Slot
 
+	// Collect the subtree under root by following the storage child index.
+	blocks := map[[32]byte]*types.Block{root: rootBlock}
+	subtree := make(map[[32]byte][][32]byte)
+	queue := [][32]byte{root}
+	for len(queue) > 0 {
+		h := queue[0]
+		queue = queue[1:]
+		for _, k := range store.GetChildren(h) {
+			b, ok := store.GetBlock(k)
+			if !ok {
+				continue
+			}
+			blocks[k] = b
+			subtree[h] = append(subtree[h], k)
+			queue = append(queue, k)
+		}
+	}
+
+	t := &ghostTree{
+		blocks:   blocks,
+		root:     root,
+		weights:  make(map[[32]byte]int),
+		children: make(map[[32]byte][][32]byte),
+	}
+	if trackVoters {
+		t.voters = make(map[[32]byte][]uint64)
+	}
+
+	var viable map[[32]byte]bool
+	if justified != nil {
+		viable = viableBlocks(store, subtree, root, justified)
+	}
+
 	// Count votes for each block. Votes for descendants count toward ancestors.
-	voteWeights := make(map[[32]byte]int)
-	for _, sa := range latestAttestations {
+	for validatorID, sa := range latestAttestations {
 		headRoot := sa.Message.Head.Root
 		if _, ok := blocks[headRoot]; !ok {
 			continue
 		}
+		w := 1
+		if weight != nil {
+			w = weight(validatorID)
+		}
 		blockHash := headRoot
 		for {
 			b, exists := blocks[blockHash]
 			if !exists || b.Slot <= rootSlot {
 				break
 			}
-			voteWeights[blockHash]++
+			t.weights[blockHash] += w
+			if trackVoters {
+				t.voters[blockHash] = append(t.voters[blockHash], validatorID)
+			}
 			blockHash = b.ParentRoot
 		}
 	}
 
-	// Build children mapping for blocks above min score.
-	childrenMap := make(map[[32]byte][][32]byte)
-	for blockHash := range blocks {
-		block := blocks[blockHash]
-		if voteWeights[blockHash] >= minScore {
-			childrenMap[block.ParentRoot] = append(childrenMap[block.ParentRoot], blockHash)
+	// Build children mapping for viable blocks above min score.
+	for blockHash, block := range blocks {
+		if viable != nil && !viable[blockHash] {
+			continue
 		}
-	}
-
-	// Walk down tree, choosing child with most votes.
-	// Tiebreak: highest slot, then largest hash (lexicographic).
-	current := root
-	for {
-		children := childrenMap[current]
-		if len(children) == 0 {
-			return current
+		if t.weights[blockHash] >= minScore {
+			t.children[block.ParentRoot] = append(t.children[block.ParentRoot], blockHash)
 		}
+	}
+	return t
+}
 
-		best := children[0]
-		bestWeight := voteWeights[best]
-		bestSlot := blocks[best].Slot
-		for _, c := range children[1:] {
-			w := voteWeights[c]
-			s := blocks[c].Slot
-			if w > bestWeight || (w == bestWeight && s > bestSlot) || (w == bestWeight && s == bestSlot && hashGreater(c, best)) {
-				best = c
-				bestWeight = w
-				bestSlot = s
-			}
+// bestChild picks the child with the most votes.
+// Tiebreak: highest slot, then largest hash (lexicographic).
+func (t *ghostTree) bestChild(children [][32]byte) [32]byte {
+	best := children[0]
+	bestWeight := t.weights[best]
+	bestSlot := t.blocks[best].Slot
+	for _, c := range children[1:] {
+		w := t.weights[c]
+		s := t.blocks[c].Slot
+		if w > bestWeight || (w == bestWeight && s > bestSlot) || (w == bestWeight && s == bestSlot && hashGreater(c, best)) {
+			best = c
+			bestWeight = w
+			bestSlot = s
 		}
-		current = best
 	}
+	return best
 }
+
 func hashGreater(a, b [32]byte) bool {
 	for i := 0; i < 32; i++ {
 		if a[i] > b[i] {
@@ -0,0 +1,119 @@
+package forkchoice
+
+import (
+	"fmt"
+
+	"github.com/geanlabs/gean/observability/logging"
+	"github.com/geanlabs/gean/storage"
+	"github.com/geanlabs/gean/types"
+)
+
+// InvalidateBlock removes the block with the given root and all of its
+// descendants from fork choice and re-runs head selection. Invalidated blocks
+// stay in storage but are never chosen as head again, and blocks built on
+// them are rejected. The justified checkpoint and its ancestors cannot be
+// invalidated. It returns the invalidated roots.
+func (c *Store) InvalidateBlock(root [32]byte) ([][32]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	block, ok := c.storage.GetBlock(root)
+	if !ok {
+		return nil, fmt.Errorf("unknown block %x", root)
+	}
+	for h := c.latestJustified.Root; ; {
+		if h == root {
+			return nil, fmt.Errorf("block %x is the justified checkpoint or one of its ancestors", root)
+		}
+		b, ok := c.storage.GetBlock(h)
+		if !ok || b.Slot == 0 {
+			break
+		}
+		h = b.ParentRoot
+	}
+
+	children := make(map[[32]byte][][32]byte)
+	c.storage.ForEachBlock(storage.SlotRange{From: block.Slot}, func(h [32]byte, b *types.Block) bool {
+		children[b.ParentRoot] = append(children[b.ParentRoot], h)
+		return true
+	})
+	var removed [][32]byte
+	queue := [][32]byte{root}
+	for len(queue) > 0 {
+		h := queue[0]
+		queue = queue[1:]
+		if c.invalid[h] {
+			continue
+		}
+		c.invalid[h] = true
+		c.proto.invalidate(h)
+		removed = append(removed, h)
+		queue = append(queue, children[h]...)
+	}
+
+	oldHead := c.head
+	c.updateHeadLocked()
+	c.updateSafeTargetLocked()
+	log.Warn("blocks invalidated by operator",
+		"root", logging.ShortHash(root),
+		"count", len(removed),
+		"old_head", logging.ShortHash(oldHead),
+		"new_head", logging.ShortHash(c.head),
+	)
+	return removed, nil
+}
+
+// RecomputeHead re-runs head and safe target selection from the current votes
+// and returns the new head.
+func (c *Store) RecomputeHead() [32]byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.updateHeadLocked()
+	c.updateSafeTargetLocked()
+	return c.head
+}
+
+// IsInvalidated reports whether root was removed by InvalidateBlock.
+func (c *Store) IsInvalidated(root [32]byte) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.invalid[root]
+}
+
+// headViewLocked returns the storage seen by head selection: c.storage with
+// states read through the state cache and invalidated blocks hidden.
+func (c *Store) headViewLocked() storage.Store {
+	view := storage.Store(&cachedStateView{Store: c.storage, c: c})
+	if len(c.invalid) == 0 {
+		return view
+	}
+	return &invalidatedView{Store: view, invalid: c.invalid}
+}
+
+// invalidatedView hides invalidated blocks from ForEachBlock and
+// GetChildren, which is what the head walk follows.
+type invalidatedView struct {
+	storage.Store
+	invalid map[[32]byte]bool
+}
+
+func (v *invalidatedView) ForEachBlock(r storage.SlotRange, fn func(root [32]byte, block *types.Block) bool) {
+	v.Store.ForEachBlock(r, func(root [32]byte, block *types.Block) bool {
+		if v.invalid[root] {
+			return true
+		}
+		return fn(root, block)
+	})
+}
+
+func (v *invalidatedView) GetChildren(root [32]byte) [][32]byte {
+	kids := v.Store.GetChildren(root)
+	n := 0
+	for _, k := range kids {
+		if !v.invalid[k] {
+			kids[n] = k
+			n++
+		}
+	}
+	return kids[:n]
+}
@@ -0,0 +1,71 @@
+package forkchoice
+
+import "github.com/geanlabs/gean/types"
+
+// packingCache holds the known attestations grouped by source checkpoint,
+// prepared ahead of a local proposal so ProduceBlock only visits attestations
+// whose source can match the post-state justified checkpoint.
+type packingCache struct {
+	slot     uint64
+	bySource map[types.Checkpoint]map[uint64]*types.SignedAttestation
+}
+
+func (p *packingCache) add(sa *types.SignedAttestation) {
+	source := *sa.Message.Source
+	group, ok := p.bySource[source]
+	if !ok {
+		group = make(map[uint64]*types.SignedAttestation)
+		p.bySource[source] = group
+	}
+	group[sa.ValidatorID] = sa
+}
+
+func (p *packingCache) remove(sa *types.SignedAttestation) {
+	source := *sa.Message.Source
+	if group, ok := p.bySource[source]; ok {
+		delete(group, sa.ValidatorID)
+		if len(group) == 0 {
+			delete(p.bySource, source)
+		}
+	}
+}
+
+// PreparePacking builds the packing cache for a proposal at slot. Only a
+// pointer snapshot of the known attestations is taken under the lock; the
+// grouping runs outside it. Intended to be called from a goroutine once the
+// head settles before a local proposal.
+func (c *Store) PreparePacking(slot uint64) {
+	c.mu.Lock()
+	snapshot := make([]*types.SignedAttestation, 0, len(c.latestKnownAttestations))
+	for _, sa := range c.latestKnownAttestations {
+		snapshot = append(snapshot, sa)
+	}
+	version := c.knownVersion
+	c.mu.Unlock()
+
+	cache := &packingCache{
+		slot:     slot,
+		bySource: make(map[types.Checkpoint]map[uint64]*types.SignedAttestation),
+	}
+	for _, sa := range snapshot {
+		cache.add(sa)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// Known attestations changed while grouping; leave the cache cold rather
+	// than install a stale view.
+	if c.knownVersion != version {
+		return
+	}
+	c.packing = cache
+}
+
+// packingCandidatesLocked returns the known attestations that may be packed
+// into a block at slot with the given justified source.
+func (c *Store) packingCandidatesLocked(slot uint64, source *types.Checkpoint) map[uint64]*types.SignedAttestation {
+	if c.packing != nil && c.packing.slot == slot {
+		return c.packing.bySource[*source]
+	}
+	return c.latestKnownAttestations
+}
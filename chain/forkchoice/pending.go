@@ -0,0 +1,107 @@
+package forkchoice
+
+import (
+	"github.com/geanlabs/gean/observability/logging"
+	"github.com/geanlabs/gean/types"
+)
+
+// maxPendingAttestations is the default bound on attestations buffered while
+// waiting for the blocks they reference.
+const maxPendingAttestations = 1024
+
+// pendingAttestation is an attestation deferred until a referenced block
+// arrives.
+type pendingAttestation struct {
+	sa          *types.SignedAttestation
+	isFromBlock bool
+}
+
+// missingAttestationBlockLocked returns the first of the source, target and
+// head roots that is not yet in storage.
+func (c *Store) missingAttestationBlockLocked(data *types.AttestationData) ([32]byte, bool) {
+	for _, root := range [][32]byte{data.Source.Root, data.Target.Root, data.Head.Root} {
+		if _, ok := c.storage.GetBlock(root); !ok {
+			return root, true
+		}
+	}
+	return [32]byte{}, false
+}
+
+// deferAttestationLocked buffers an attestation until the block with the given
+// root is processed. OnMissingBlock is called the first time a root is seen.
+func (c *Store) deferAttestationLocked(root [32]byte, sa *types.SignedAttestation, isFromBlock bool) {
+	if c.numPending >= c.maxPending {
+		log.Debug("pending attestation buffer full, dropping",
+			"slot", sa.Message.Slot,
+			"validator", sa.ValidatorID,
+		)
+		return
+	}
+
+	_, known := c.pendingByRoot[root]
+	c.pendingByRoot[root] = append(c.pendingByRoot[root], pendingAttestation{sa: sa, isFromBlock: isFromBlock})
+	c.numPending++
+	log.Debug("attestation deferred until block arrives",
+		"slot", sa.Message.Slot,
+		"validator", sa.ValidatorID,
+		"missing_root", logging.ShortHash(root),
+	)
+
+	if !known && c.OnMissingBlock != nil {
+		c.OnMissingBlock(root)
+	}
+}
+
+// SetPendingLimit changes how many attestations may wait for missing blocks.
+// Already buffered attestations are kept until replayed or pruned.
+func (c *Store) SetPendingLimit(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.maxPending = n
+}
+
+// ShedPendingAttestations drops every attestation waiting for a missing block
+// and returns how many were dropped. Their blocks are still fetched if a later
+// attestation or block references them.
+func (c *Store) ShedPendingAttestations() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := c.numPending
+	clear(c.pendingByRoot)
+	c.numPending = 0
+	return n
+}
+
+// replayPendingAttestationsLocked re-processes attestations that were waiting
+// for root. Attestations still missing another block are deferred again.
+func (c *Store) replayPendingAttestationsLocked(root [32]byte) {
+	pending, ok := c.pendingByRoot[root]
+	if !ok {
+		return
+	}
+	delete(c.pendingByRoot, root)
+	c.numPending -= len(pending)
+
+	for _, p := range pending {
+		c.processAttestationLocked(p.sa, p.isFromBlock)
+	}
+}
+
+// prunePendingAttestationsLocked drops buffered attestations older than the
+// finalized slot; their blocks can no longer affect fork choice.
+func (c *Store) prunePendingAttestationsLocked() {
+	for root, pending := range c.pendingByRoot {
+		kept := pending[:0]
+		for _, p := range pending {
+			if p.sa.Message.Slot >= c.latestFinalized.Slot {
+				kept = append(kept, p)
+			}
+		}
+		c.numPending -= len(pending) - len(kept)
+		if len(kept) == 0 {
+			delete(c.pendingByRoot, root)
+		} else {
+			c.pendingByRoot[root] = kept
+		}
+	}
+}
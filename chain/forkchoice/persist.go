@@ -0,0 +1,218 @@
+package forkchoice
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/geanlabs/gean/observability/logging"
+	"github.com/geanlabs/gean/types"
+)
+
+// persistMagic starts a fork choice state file.
+var persistMagic = []byte("geanfc01")
+
+// maxPersistedRecord bounds a record read from a state file.
+const maxPersistedRecord = 1 << 16
+
+// Save writes what fork choice holds outside storage, the store time,
+// checkpoints, safe target and latest votes, to path, replacing it
+// atomically. Together with a persistent storage backend it lets Load
+// resume fork choice where it stopped.
+//
+// The file is the magic, the store time, the justified and finalized
+// checkpoints, the head and safe target roots, then the known and the new
+// votes, each a uint32 count of length-prefixed SSZ signed attestations, and
+// the SHA-256 of everything before it.
+func (c *Store) Save(path string) error {
+	c.mu.Lock()
+	var buf bytes.Buffer
+	buf.Write(persistMagic)
+	buf.Write(binary.LittleEndian.AppendUint64(nil, c.time))
+	for _, cp := range []*types.Checkpoint{c.latestJustified, c.latestFinalized} {
+		if err := writeRecord(&buf, cp); err != nil {
+			c.mu.Unlock()
+			return err
+		}
+	}
+	buf.Write(c.head[:])
+	buf.Write(c.safeTarget[:])
+	for _, votes := range []map[uint64]*types.SignedAttestation{c.latestKnownAttestations, c.latestNewAttestations} {
+		buf.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(votes))))
+		for _, sa := range votes {
+			if err := writeRecord(&buf, sa); err != nil {
+				c.mu.Unlock()
+				return err
+			}
+		}
+	}
+	c.mu.Unlock()
+	sum := sha256.Sum256(buf.Bytes())
+	buf.Write(sum[:])
+
+	return writeFileAtomic(path, buf.Bytes())
+}
+
+// writeFileAtomic replaces path with data so that a crash leaves either the
+// old file or the new one: the data is synced before the rename, and the
+// directory after it so the rename itself is durable.
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	dir, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
+
+// Load restores a state file written by Save into a store built over the
+// same storage, after Resume. Checkpoints are adopted only when they are
+// ahead of the store's, and votes only when every block they reference is
+// still stored. The votes were verified before they were saved and are not
+// verified again. A missing file is not an error; Load reports whether one
+// was read.
+func (c *Store) Load(path string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if len(data) < len(persistMagic)+sha256.Size || !bytes.Equal(data[:len(persistMagic)], persistMagic) {
+		return false, errors.New("not a fork choice state file")
+	}
+	body := data[:len(data)-sha256.Size]
+	if sum := sha256.Sum256(body); !bytes.Equal(sum[:], data[len(body):]) {
+		return false, errors.New("fork choice state file checksum mismatch")
+	}
+
+	r := bytes.NewReader(body[len(persistMagic):])
+	var storeTime uint64
+	if err := binary.Read(r, binary.LittleEndian, &storeTime); err != nil {
+		return false, fmt.Errorf("read store time: %w", err)
+	}
+	justified, finalized := new(types.Checkpoint), new(types.Checkpoint)
+	for _, cp := range []*types.Checkpoint{justified, finalized} {
+		if err := readRecord(r, cp); err != nil {
+			return false, fmt.Errorf("read checkpoint: %w", err)
+		}
+	}
+	var head, safeTarget [32]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return false, fmt.Errorf("read head: %w", err)
+	}
+	if _, err := io.ReadFull(r, safeTarget[:]); err != nil {
+		return false, fmt.Errorf("read safe target: %w", err)
+	}
+	var votes [2][]*types.SignedAttestation
+	for i := range votes {
+		var n uint32
+		if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+			return false, fmt.Errorf("read vote count: %w", err)
+		}
+		for range n {
+			sa := new(types.SignedAttestation)
+			if err := readRecord(r, sa); err != nil {
+				return false, fmt.Errorf("read vote: %w", err)
+			}
+			votes[i] = append(votes[i], sa)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.time = max(c.time, storeTime)
+	if _, ok := c.storage.GetBlock(justified.Root); ok && justified.Slot > c.latestJustified.Slot {
+		c.latestJustified = justified
+	}
+	if _, ok := c.storage.GetBlock(finalized.Root); ok && finalized.Slot > c.latestFinalized.Slot {
+		c.latestFinalized = finalized
+	}
+	dropped := 0
+	for i, set := range votes {
+		for _, sa := range set {
+			if _, missing := c.missingAttestationBlockLocked(sa.Message); missing {
+				dropped++
+				continue
+			}
+			if i == knownVotes {
+				c.setKnownLocked(sa)
+			} else {
+				c.setNewLocked(sa)
+			}
+		}
+	}
+	c.updateHeadLocked()
+	if _, ok := c.storage.GetBlock(safeTarget); ok {
+		c.safeTarget = safeTarget
+	}
+	if c.head != head {
+		log.Warn("restored fork choice picked a different head",
+			"saved", logging.ShortHash(head),
+			"restored", logging.ShortHash(c.head),
+		)
+	}
+	log.Info("restored fork choice state",
+		"known_votes", len(votes[knownVotes]),
+		"new_votes", len(votes[newVotes]),
+		"dropped", dropped,
+	)
+	return true, nil
+}
+
+type sszRecord interface {
+	MarshalSSZ() ([]byte, error)
+	UnmarshalSSZ([]byte) error
+}
+
+func writeRecord(w io.Writer, v sszRecord) error {
+	data, err := v.MarshalSSZ()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(data)))); err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func readRecord(r io.Reader, v sszRecord) error {
+	var size uint32
+	if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+		return err
+	}
+	if size > maxPersistedRecord {
+		return fmt.Errorf("record of %d bytes exceeds limit", size)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return err
+	}
+	return v.UnmarshalSSZ(data)
+}
@@ -12,11 +12,20 @@ type Signer interface {
 	Sign(signingSlot uint32, message [32]byte) ([]byte, error)
 }
 
+// ProposalRecord durably records produced blocks by slot and proposer.
+// Record must not return until the envelope would survive a crash. Prune
+// drops the records of slots below slot once they are finalized.
+type ProposalRecord interface {
+	Record(envelope *types.SignedBlockWithAttestation) error
+	Lookup(slot, proposer uint64) (*types.SignedBlockWithAttestation, bool)
+	Prune(slot uint64) error
+}
+
 // GetProposalHead returns the head for block proposal at the given slot.
 func (c *Store) GetProposalHead(slot uint64) [32]byte {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	slotTime := c.genesisTime + slot*types.SecondsPerSlot
+	slotTime := c.genesisTime + slot*c.preset.SecondsPerSlot
 	c.advanceTimeLocked(slotTime, true)
 	c.acceptNewAttestationsLocked()
 	return c.head
@@ -29,12 +38,36 @@ func (c *Store) GetVoteTarget() (*types.Checkpoint, error) {
 	return c.getVoteTargetLocked()
 }
 
+// voteTargetKey is what the vote target is computed from.
+type voteTargetKey struct {
+	head, safeTarget [32]byte
+	finalizedSlot    uint64
+}
+
+// getVoteTargetLocked returns the vote target, computed again only when the
+// head, safe target or finalized slot changed since the last call, as every
+// local validator asks for it each slot.
 func (c *Store) getVoteTargetLocked() (*types.Checkpoint, error) {
+	key := voteTargetKey{head: c.head, safeTarget: c.safeTarget, finalizedSlot: c.latestFinalized.Slot}
+	if c.voteTarget != nil && c.voteTargetKey == key {
+		cp := *c.voteTarget
+		return &cp, nil
+	}
+	target, err := c.computeVoteTargetLocked()
+	if err != nil {
+		return nil, err
+	}
+	c.voteTarget, c.voteTargetKey = target, key
+	cp := *target
+	return &cp, nil
+}
+
+func (c *Store) computeVoteTargetLocked() (*types.Checkpoint, error) {
 	targetRoot := c.head
 
 	// Walk back up to JustificationLookback steps if safe target is newer.
 	safeBlock, safeOK := c.storage.GetBlock(c.safeTarget)
-	for i := 0; i < types.JustificationLookback; i++ {
+	for range c.preset.JustificationLookback {
 		tBlock, ok := c.storage.GetBlock(targetRoot)
 		if ok && safeOK && tBlock.Slot > safeBlock.Slot {
 			targetRoot = tBlock.ParentRoot
@@ -42,12 +75,13 @@ func (c *Store) getVoteTargetLocked() (*types.Checkpoint, error) {
 	}
 
 	// Ensure target is in justifiable slot range.
+	justifiable := types.JustifiableAfter(c.latestFinalized.Slot)
 	for {
 		tBlock, ok := c.storage.GetBlock(targetRoot)
 		if !ok {
 			break
 		}
-		if types.IsJustifiableAfter(tBlock.Slot, c.latestFinalized.Slot) {
+		if justifiable.Contains(tBlock.Slot) {
 			break
 		}
 		targetRoot = tBlock.ParentRoot
@@ -63,7 +97,8 @@ func (c *Store) getVoteTargetLocked() (*types.Checkpoint, error) {
 
 // ProduceBlock creates a new signed block envelope for the given slot and validator.
 // The returned envelope includes:
-//   - the block with body attestations
+//   - the block with body attestations and slashings for the equivocations
+//     seen so far
 //   - the proposer's own attestation (head = produced block)
 //   - the signature list (body attestation sigs + proposer sig last)
 //
@@ -73,18 +108,28 @@ func (c *Store) ProduceBlock(slot, validatorIndex uint64, signer Signer) (*types
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	if !statetransition.IsProposer(validatorIndex, slot, c.numValidators) {
+	if proposer, ok := c.proposerIndexLocked(slot); !ok || proposer != validatorIndex {
 		return nil, fmt.Errorf("validator %d is not proposer for slot %d", validatorIndex, slot)
 	}
 
+	// Never sign a second block for the same (proposer, slot): return the
+	// envelope produced earlier instead of equivocating.
+	if prev, ok := c.producedEnvelopeLocked(slot, validatorIndex); ok {
+		log.Warn("block already produced for slot, reusing envelope",
+			"slot", slot,
+			"proposer", validatorIndex,
+		)
+		return prev, nil
+	}
+
 	headRoot := c.head
 	// Advance and accept before proposing.
-	slotTime := c.genesisTime + slot*types.SecondsPerSlot
+	slotTime := c.genesisTime + slot*c.preset.SecondsPerSlot
 	c.advanceTimeLocked(slotTime, true)
 	c.acceptNewAttestationsLocked()
 	headRoot = c.head
 
-	headState, ok := c.storage.GetState(headRoot)
+	headState, ok := c.getState(headRoot)
 	if !ok {
 		return nil, fmt.Errorf("head state not found")
 	}
@@ -94,6 +139,7 @@ func (c *Store) ProduceBlock(slot, validatorIndex uint64, signer Signer) (*types
 		return nil, err
 	}
 
+	proposerSlashings, attesterSlashings := c.slashingsLocked(advancedState)
 	var attestations []*types.Attestation
 	var collectedSigned []*types.SignedAttestation
 
@@ -104,7 +150,11 @@ func (c *Store) ProduceBlock(slot, validatorIndex uint64, signer Signer) (*types
 			ProposerIndex: validatorIndex,
 			ParentRoot:    headRoot,
 			StateRoot:     types.ZeroHash,
-			Body:          &types.BlockBody{Attestations: attestations},
+			Body: &types.BlockBody{
+				Attestations:      attestations,
+				ProposerSlashings: proposerSlashings,
+				AttesterSlashings: attesterSlashings,
+			},
 		}
 
 		postState, err := statetransition.ProcessBlock(advancedState, candidateBlock)
@@ -114,7 +164,7 @@ func (c *Store) ProduceBlock(slot, validatorIndex uint64, signer Signer) (*types
 
 		var newAttestations []*types.Attestation
 		var newSigned []*types.SignedAttestation
-		for _, sa := range c.latestKnownAttestations {
+		for _, sa := range c.packingCandidatesLocked(slot, postState.LatestJustified) {
 			data := sa.Message
 			if _, ok := c.storage.GetBlock(data.Head.Root); !ok {
 				continue
@@ -147,7 +197,11 @@ func (c *Store) ProduceBlock(slot, validatorIndex uint64, signer Signer) (*types
 		ProposerIndex: validatorIndex,
 		ParentRoot:    headRoot,
 		StateRoot:     types.ZeroHash,
-		Body:          &types.BlockBody{Attestations: attestations},
+		Body: &types.BlockBody{
+			Attestations:      attestations,
+			ProposerSlashings: proposerSlashings,
+			AttesterSlashings: attesterSlashings,
+		},
 	}
 	finalState, err := statetransition.ProcessBlock(advancedState, finalBlock)
 	if err != nil {
@@ -174,7 +228,7 @@ func (c *Store) ProduceBlock(slot, validatorIndex uint64, signer Signer) (*types
 	proposerAtt.Data.Target = voteTarget
 
 	// Build signature list: body attestation sigs in order, proposer sig last.
-	sigs := make([][3112]byte, len(collectedSigned)+1)
+	sigs := make([][types.XMSSSignatureSize]byte, len(collectedSigned)+1)
 	for i, sa := range collectedSigned {
 		sigs[i] = sa.Signature
 	}
@@ -199,21 +253,56 @@ func (c *Store) ProduceBlock(slot, validatorIndex uint64, signer Signer) (*types
 	}
 	copy(envelope.Signature[len(collectedSigned)][:], sig)
 
-	c.storage.PutBlock(blockHash, finalBlock)
-	c.storage.PutSignedBlock(blockHash, envelope)
-	c.storage.PutState(blockHash, finalState)
+	// The record comes first: once signed, this is the only block the duty
+	// may ever publish, even if the commit below fails.
+	if c.Proposals != nil {
+		if err := c.Proposals.Record(envelope); err != nil {
+			return nil, fmt.Errorf("record produced block: %w", err)
+		}
+	}
+	if err := c.commitBlockLocked(blockHash, finalBlock, envelope, finalState); err != nil {
+		return nil, err
+	}
+	c.packing = nil
+	c.producedBlocks[productionKey{slot: slot, proposer: validatorIndex}] = blockHash
 
 	return envelope, nil
 }
 
-// ProduceAttestation produces a signed attestation for the given slot and validator.
-// The signer produces the XMSS signature over HashTreeRoot(Attestation).
-func (c *Store) ProduceAttestation(slot, validatorIndex uint64, signer Signer) (*types.SignedAttestation, error) {
+// productionKey identifies a block proposal duty.
+type productionKey struct {
+	slot     uint64
+	proposer uint64
+}
+
+// producedEnvelopeLocked returns a previously produced envelope for the given
+// slot and proposer. It consults the in-memory production record first, then
+// the durable one, which holds blocks produced before a restart.
+func (c *Store) producedEnvelopeLocked(slot, proposer uint64) (*types.SignedBlockWithAttestation, bool) {
+	key := productionKey{slot: slot, proposer: proposer}
+	if root, ok := c.producedBlocks[key]; ok {
+		if sb, ok := c.storage.GetSignedBlock(root); ok {
+			return sb, true
+		}
+	}
+	if c.Proposals != nil {
+		return c.Proposals.Lookup(slot, proposer)
+	}
+	return nil, false
+}
+
+// ProduceAttestationData returns the attestation data local validators vote
+// for at slot. Signing is left to the caller, so it can run outside the store
+// lock.
+func (c *Store) ProduceAttestationData(slot uint64) (*types.AttestationData, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	return c.produceAttestationDataLocked(slot)
+}
 
+func (c *Store) produceAttestationDataLocked(slot uint64) (*types.AttestationData, error) {
 	// Advance and accept before voting (matches leanSpec produce_attestation_vote).
-	slotTime := c.genesisTime + slot*types.SecondsPerSlot
+	slotTime := c.genesisTime + slot*c.preset.SecondsPerSlot
 	c.advanceTimeLocked(slotTime, true)
 	c.acceptNewAttestationsLocked()
 	headRoot := c.head
@@ -229,11 +318,23 @@ func (c *Store) ProduceAttestation(slot, validatorIndex uint64, signer Signer) (
 		return nil, fmt.Errorf("vote target: %w", err)
 	}
 
-	data := &types.AttestationData{
+	return &types.AttestationData{
 		Slot:   slot,
 		Head:   headCheckpoint,
 		Target: targetCheckpoint,
 		Source: c.latestJustified,
+	}, nil
+}
+
+// ProduceAttestation produces a signed attestation for the given slot and validator.
+// The signer produces the XMSS signature over HashTreeRoot(Attestation).
+func (c *Store) ProduceAttestation(slot, validatorIndex uint64, signer Signer) (*types.SignedAttestation, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, err := c.produceAttestationDataLocked(slot)
+	if err != nil {
+		return nil, err
 	}
 
 	att := &types.Attestation{
@@ -252,7 +353,7 @@ func (c *Store) ProduceAttestation(slot, validatorIndex uint64, signer Signer) (
 		return nil, fmt.Errorf("sign attestation: %w", err)
 	}
 
-	var sigBytes [3112]byte
+	var sigBytes [types.XMSSSignatureSize]byte
 	copy(sigBytes[:], sig)
 
 	return &types.SignedAttestation{
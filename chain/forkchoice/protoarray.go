@@ -0,0 +1,359 @@
+package forkchoice
+
+import (
+	"github.com/geanlabs/gean/observability/logging"
+	"github.com/geanlabs/gean/observability/metrics"
+	"github.com/geanlabs/gean/types"
+)
+
+// Vote sets tracked by the proto-array: the latest known attestations drive
+// the head, the latest new ones the safe target.
+const (
+	knownVotes = iota
+	newVotes
+	numVoteSets
+)
+
+// protoNode is a block in the proto-array. weight holds, per vote set, the
+// weight of the votes for the block and its valid descendants.
+type protoNode struct {
+	root     [32]byte
+	parent   int // -1 for the first node
+	slot     uint64
+	children []int
+	weight   [numVoteSets]int
+	invalid  bool
+
+	// justified is the latest justified checkpoint of the block's
+	// post-state, read lazily since only leaves need it.
+	justified    types.Checkpoint
+	hasJustified bool
+}
+
+// protoArray is an incremental LMD GHOST tree. Nodes are kept in insertion
+// order, so every parent comes before its children, and subtree weights are
+// adjusted along the ancestor path as votes move. Finding the head then only
+// walks down the tree, instead of recounting every vote over every block.
+type protoArray struct {
+	nodes   []protoNode
+	indices map[[32]byte]int
+
+	// votes is the head root each validator currently votes for and the
+	// weight of that vote, and direct the weight of the votes per root,
+	// including roots not in the array yet.
+	votes  [numVoteSets]map[uint64]protoVote
+	direct [numVoteSets]map[[32]byte]int
+
+	// viable caches, for viableJustified, which nodes lie on a viable
+	// branch; it is nil when the tree changed since it was computed.
+	viable          []bool
+	viableJustified types.Checkpoint
+}
+
+// protoVote is a validator's vote as applied to the proto-array.
+type protoVote struct {
+	root   [32]byte
+	weight int
+}
+
+func newProtoArray() *protoArray {
+	p := &protoArray{indices: make(map[[32]byte]int)}
+	for set := range p.votes {
+		p.votes[set] = make(map[uint64]protoVote)
+		p.direct[set] = make(map[[32]byte]int)
+	}
+	return p
+}
+
+// insert adds a block whose parent is already in the array, or which starts
+// the array. Votes already cast for it are applied. A nil state leaves the
+// justified checkpoint to be read on demand.
+func (p *protoArray) insert(root [32]byte, block *types.Block, state *types.State) {
+	if _, ok := p.indices[root]; ok {
+		return
+	}
+	parent, ok := p.indices[block.ParentRoot]
+	if !ok {
+		if len(p.nodes) > 0 {
+			return
+		}
+		parent = -1
+	}
+	n := protoNode{root: root, parent: parent, slot: block.Slot}
+	if state != nil {
+		n.justified, n.hasJustified = *state.LatestJustified, true
+	}
+	i := len(p.nodes)
+	if parent >= 0 {
+		n.invalid = p.nodes[parent].invalid
+		p.nodes[parent].children = append(p.nodes[parent].children, i)
+	}
+	p.nodes = append(p.nodes, n)
+	p.indices[root] = i
+	if !n.invalid {
+		for set := range p.direct {
+			p.addWeight(i, set, p.direct[set][root])
+		}
+	}
+	p.viable = nil
+}
+
+// addWeight adds delta to node i and its ancestors.
+func (p *protoArray) addWeight(i, set, delta int) {
+	if delta == 0 {
+		return
+	}
+	for ; i >= 0; i = p.nodes[i].parent {
+		p.nodes[i].weight[set] += delta
+	}
+}
+
+// vote moves a validator's vote in set to root, with the given weight.
+func (p *protoArray) vote(set int, validatorID uint64, root [32]byte, weight int) {
+	if old, ok := p.votes[set][validatorID]; ok {
+		if old.root == root && old.weight == weight {
+			return
+		}
+		p.unvote(set, validatorID)
+	}
+	p.votes[set][validatorID] = protoVote{root: root, weight: weight}
+	p.direct[set][root] += weight
+	if i, ok := p.indices[root]; ok && !p.nodes[i].invalid {
+		p.addWeight(i, set, weight)
+	}
+}
+
+// unvote withdraws a validator's vote in set.
+func (p *protoArray) unvote(set int, validatorID uint64) {
+	v, ok := p.votes[set][validatorID]
+	if !ok {
+		return
+	}
+	delete(p.votes[set], validatorID)
+	if p.direct[set][v.root] -= v.weight; p.direct[set][v.root] == 0 {
+		delete(p.direct[set], v.root)
+	}
+	if i, ok := p.indices[v.root]; ok && !p.nodes[i].invalid {
+		p.addWeight(i, set, -v.weight)
+	}
+}
+
+// clearVotes withdraws every vote in set.
+func (p *protoArray) clearVotes(set int) {
+	p.votes[set] = make(map[uint64]protoVote)
+	p.direct[set] = make(map[[32]byte]int)
+	for i := range p.nodes {
+		p.nodes[i].weight[set] = 0
+	}
+}
+
+// invalidate hides root and its descendants from the head walk and takes
+// their votes off the remaining ancestors.
+func (p *protoArray) invalidate(root [32]byte) {
+	i, ok := p.indices[root]
+	if !ok || p.nodes[i].invalid {
+		return
+	}
+	for set := range p.direct {
+		p.addWeight(p.nodes[i].parent, set, -p.nodes[i].weight[set])
+	}
+	queue := []int{i}
+	for len(queue) > 0 {
+		j := queue[0]
+		queue = queue[1:]
+		p.nodes[j].invalid = true
+		queue = append(queue, p.nodes[j].children...)
+	}
+	p.viable = nil
+}
+
+// prune drops every node that does not descend from root, which becomes the
+// first node.
+func (p *protoArray) prune(root [32]byte) {
+	start, ok := p.indices[root]
+	if !ok || start == 0 {
+		return
+	}
+	keep := make([]bool, len(p.nodes))
+	keep[start] = true
+	for i := start + 1; i < len(p.nodes); i++ {
+		if parent := p.nodes[i].parent; parent >= 0 && keep[parent] {
+			keep[i] = true
+		}
+	}
+	remap := make([]int, len(p.nodes))
+	nodes := make([]protoNode, 0, len(p.nodes)-start)
+	indices := make(map[[32]byte]int, len(p.nodes)-start)
+	for i, n := range p.nodes {
+		if !keep[i] {
+			remap[i] = -1
+			continue
+		}
+		remap[i] = len(nodes)
+		indices[n.root] = len(nodes)
+		nodes = append(nodes, n)
+	}
+	for i := range nodes {
+		n := &nodes[i]
+		if n.parent >= 0 {
+			n.parent = remap[n.parent]
+		}
+		kids := n.children[:0:0]
+		for _, c := range n.children {
+			kids = append(kids, remap[c])
+		}
+		n.children = kids
+	}
+	p.nodes, p.indices = nodes, indices
+	p.viable = nil
+}
+
+// viableFor returns which nodes lie on a viable branch (see viableBlocks)
+// for justified. Children come after their parents, so one backward pass
+// settles every node. stateOf reads the post-state of leaves whose justified
+// checkpoint is not known yet; if one is missing the result is not cached.
+func (p *protoArray) viableFor(justified types.Checkpoint, stateOf func([32]byte) (*types.State, bool)) []bool {
+	if p.viable != nil && p.viableJustified == justified {
+		return p.viable
+	}
+	viable := make([]bool, len(p.nodes))
+	complete := true
+	for i := len(p.nodes) - 1; i >= 0; i-- {
+		n := &p.nodes[i]
+		if n.invalid {
+			continue
+		}
+		leaf := true
+		for _, c := range n.children {
+			if p.nodes[c].invalid {
+				continue
+			}
+			leaf = false
+			if viable[c] {
+				viable[i] = true
+			}
+		}
+		if !leaf {
+			continue
+		}
+		if !n.hasJustified {
+			st, ok := stateOf(n.root)
+			if !ok {
+				complete = false
+				continue
+			}
+			n.justified, n.hasJustified = *st.LatestJustified, true
+		}
+		viable[i] = n.justified == justified
+	}
+	if complete {
+		p.viable, p.viableJustified = viable, justified
+	}
+	return viable
+}
+
+// findHead walks down from root along the heaviest child in set that is
+// viable and has at least minScore weight. Ties go to the higher slot, then
+// the larger root. ok is false if root is not in the array.
+func (p *protoArray) findHead(root [32]byte, set, minScore int, viable []bool) (head [32]byte, ok bool) {
+	i, ok := p.indices[root]
+	if !ok || p.nodes[i].invalid {
+		return root, false
+	}
+	for {
+		best := -1
+		for _, c := range p.nodes[i].children {
+			n := &p.nodes[c]
+			if n.invalid || !viable[c] || n.weight[set] < minScore {
+				continue
+			}
+			if best < 0 || p.better(c, best, set) {
+				best = c
+			}
+		}
+		if best < 0 {
+			return p.nodes[i].root, true
+		}
+		i = best
+	}
+}
+
+// noViableBranch reports whether root has valid children but none of them
+// is viable, so a head walk from root stops at root.
+func (p *protoArray) noViableBranch(root [32]byte, viable []bool) bool {
+	i, ok := p.indices[root]
+	if !ok {
+		return false
+	}
+	valid := false
+	for _, c := range p.nodes[i].children {
+		if p.nodes[c].invalid {
+			continue
+		}
+		if viable[c] {
+			return false
+		}
+		valid = true
+	}
+	return valid
+}
+
+// better reports whether node a beats node b under the bestChild ordering.
+func (p *protoArray) better(a, b, set int) bool {
+	na, nb := &p.nodes[a], &p.nodes[b]
+	if na.weight[set] != nb.weight[set] {
+		return na.weight[set] > nb.weight[set]
+	}
+	if na.slot != nb.slot {
+		return na.slot > nb.slot
+	}
+	return hashGreater(na.root, nb.root)
+}
+
+// loadProtoArrayLocked starts the proto-array at the anchor and adds the
+// blocks already stored under it, as after a restart with a persistent
+// backend. Their justified slots are read when first needed.
+func (c *Store) loadProtoArrayLocked(anchorRoot [32]byte, anchorBlock *types.Block, state *types.State) {
+	c.proto.insert(anchorRoot, anchorBlock, state)
+	queue := [][32]byte{anchorRoot}
+	for len(queue) > 0 {
+		h := queue[0]
+		queue = queue[1:]
+		for _, k := range c.storage.GetChildren(h) {
+			if b, ok := c.storage.GetBlock(k); ok {
+				c.proto.insert(k, b, nil)
+				queue = append(queue, k)
+			}
+		}
+	}
+}
+
+// forkChoiceHeadLocked runs LMD GHOST from the justified checkpoint over the
+// votes in set. If the justified root is not in the proto-array, as when it
+// conflicts with finality, it falls back to weighing the stored blocks.
+//
+// When no branch above the justified block is viable the head stays at the
+// justified block; that and the fallback are counted and logged, since
+// either means fork choice is not following the blocks it has.
+func (c *Store) forkChoiceHeadLocked(set, minScore int) [32]byte {
+	viable := c.proto.viableFor(*c.latestJustified, c.getState)
+	if head, ok := c.proto.findHead(c.latestJustified.Root, set, minScore, viable); ok {
+		if set == knownVotes && c.proto.noViableBranch(head, viable) {
+			metrics.ForkChoiceHeadFallbacks.WithLabelValues("no_viable_branch").Inc()
+			log.Debug("no viable branch above justified checkpoint",
+				"justified_slot", c.latestJustified.Slot,
+				"justified_root", logging.ShortHash(c.latestJustified.Root))
+		}
+		return head
+	}
+	metrics.ForkChoiceHeadFallbacks.WithLabelValues("justified_unknown").Inc()
+	log.Debug("justified checkpoint not in fork choice tree, weighing stored blocks",
+		"justified_slot", c.latestJustified.Slot,
+		"justified_root", logging.ShortHash(c.latestJustified.Root))
+	attestations := c.latestKnownAttestations
+	if set == newVotes {
+		attestations = c.latestNewAttestations
+	}
+	t := newGhostTree(c.headViewLocked(), c.latestJustified.Root, c.latestJustified, attestations, c.voteWeightLocked, minScore, false)
+	return t.head(c.latestJustified.Root)
+}
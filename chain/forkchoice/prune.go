@@ -0,0 +1,131 @@
+package forkchoice
+
+import (
+	"github.com/geanlabs/gean/observability/logging"
+	"github.com/geanlabs/gean/observability/metrics"
+	"github.com/geanlabs/gean/storage"
+	"github.com/geanlabs/gean/types"
+)
+
+// pruneLocked drops what finalization has made unreachable: blocks that do
+// not descend from the finalized checkpoint and are not among its ancestors,
+// and the states of the finalized block's ancestors. The canonical blocks
+// themselves are kept so they can still be served to syncing peers, moving
+// to the archive if the storage has one.
+func (c *Store) pruneLocked() {
+	fin := c.latestFinalized
+	finBlock, ok := c.storage.GetBlock(fin.Root)
+	if !ok {
+		return
+	}
+	// Below the previous prune point only canonical blocks remain, so only
+	// blocks from there up need to be scanned.
+	var r storage.SlotRange
+	if b, ok := c.storage.GetBlock(c.prunedRoot); ok {
+		r.From = b.Slot
+	}
+	blocks := make(map[[32]byte]*types.Block)
+	c.storage.ForEachBlock(r, func(h [32]byte, b *types.Block) bool {
+		blocks[h] = b
+		return true
+	})
+
+	// Keep the finalized block, its descendants and its ancestors.
+	keep := make(map[[32]byte]bool, len(blocks))
+	// States below the previous prune point are already gone, so the walk
+	// stops there.
+	var ancestors [][32]byte
+	for h := finBlock.ParentRoot; ; {
+		b, ok := c.storage.GetBlock(h)
+		if !ok {
+			break
+		}
+		keep[h] = true
+		ancestors = append(ancestors, h)
+		if h == c.prunedRoot {
+			break
+		}
+		h = b.ParentRoot
+	}
+	children := make(map[[32]byte][][32]byte)
+	for h, b := range blocks {
+		children[b.ParentRoot] = append(children[b.ParentRoot], h)
+	}
+	queue := [][32]byte{fin.Root}
+	for len(queue) > 0 {
+		h := queue[0]
+		queue = queue[1:]
+		keep[h] = true
+		queue = append(queue, children[h]...)
+	}
+
+	if !keep[c.latestJustified.Root] {
+		// Justification is on a branch conflicting with finality; leave
+		// storage alone rather than delete the root of the head walk.
+		log.Warn("justified checkpoint does not descend from finalized, not pruning",
+			"justified_root", logging.ShortHash(c.latestJustified.Root),
+			"finalized_root", logging.ShortHash(fin.Root),
+		)
+		return
+	}
+
+	var orphans [][32]byte
+	for h := range blocks {
+		if !keep[h] {
+			orphans = append(orphans, h)
+			delete(c.invalid, h)
+		}
+	}
+	for k := range c.producedBlocks {
+		if k.slot < fin.Slot {
+			delete(c.producedBlocks, k)
+		}
+	}
+	if c.Proposals != nil {
+		if err := c.Proposals.Prune(fin.Slot); err != nil {
+			log.Warn("failed to prune produced block record", "err", err)
+		}
+	}
+	c.pruneEquivocationsLocked(fin.Slot)
+
+	if b, ok := blocks[c.safeTarget]; ok && !keep[c.safeTarget] {
+		// The safe target conflicts with finality and is about to be
+		// deleted; the finalized block is safe until the next update.
+		c.safeTarget = fin.Root
+		metrics.SafeTargetSlot.Set(float64(fin.Slot))
+		log.Debug("safe target conflicts with finalized checkpoint", "slot", b.Slot)
+	}
+	// A failed delete leaves the entries on disk, unreachable from the
+	// finalized block; the next prune retries them.
+	if len(orphans) > 0 {
+		c.states.Remove(orphans...)
+		if err := c.storage.DeleteBlocks(orphans); err != nil {
+			log.Error("failed to prune blocks", "count", len(orphans), "err", err)
+		} else {
+			metrics.ForkChoicePruned.WithLabelValues("block").Add(float64(len(orphans)))
+		}
+	}
+	if len(ancestors) > 0 {
+		c.states.Remove(ancestors...)
+		if err := c.storage.DeleteStates(ancestors); err != nil {
+			log.Error("failed to prune states", "count", len(ancestors), "err", err)
+		} else {
+			metrics.ForkChoicePruned.WithLabelValues("state").Add(float64(len(ancestors)))
+		}
+		// With an archive tier the finalized ancestors themselves move out
+		// of the hot store.
+		if a, ok := c.storage.(interface{ ArchiveBlocks([][32]byte) int }); ok {
+			if n := a.ArchiveBlocks(ancestors); n > 0 {
+				metrics.ForkChoicePruned.WithLabelValues("archived").Add(float64(n))
+			}
+		}
+	}
+	c.proto.prune(fin.Root)
+	c.prunedRoot = fin.Root
+	log.Debug("pruned below finalized checkpoint",
+		"finalized_slot", fin.Slot,
+		"finalized_root", logging.ShortHash(fin.Root),
+		"blocks", len(orphans),
+		"states", len(ancestors),
+	)
+}
@@ -0,0 +1,64 @@
+package forkchoice
+
+import (
+	"github.com/geanlabs/gean/observability/logging"
+	"github.com/geanlabs/gean/observability/metrics"
+)
+
+// reorg describes a head change: how the chain ending at the old head and
+// the one ending at the new head relate.
+type reorg struct {
+	// depth is the number of blocks of the old chain not on the new one;
+	// it is 0 when the new head descends from the old head.
+	depth uint64
+	// distance is the number of blocks of the new chain not on the old one.
+	distance uint64
+	// ancestor is the newest block on both chains. It is zero if a chain
+	// left storage before they met, in which case depth and distance only
+	// count the blocks walked.
+	ancestor [32]byte
+}
+
+// reorgLocked walks back from both heads to their common ancestor.
+func (c *Store) reorgLocked(oldHead, newHead [32]byte) reorg {
+	var r reorg
+	oldBlock, ok1 := c.storage.GetBlock(oldHead)
+	newBlock, ok2 := c.storage.GetBlock(newHead)
+	if !ok1 || !ok2 {
+		return r
+	}
+	for oldHead != newHead {
+		if oldBlock.Slot >= newBlock.Slot {
+			r.depth++
+			oldHead = oldBlock.ParentRoot
+			if oldBlock, ok1 = c.storage.GetBlock(oldHead); !ok1 {
+				return r
+			}
+		} else {
+			r.distance++
+			newHead = newBlock.ParentRoot
+			if newBlock, ok2 = c.storage.GetBlock(newHead); !ok2 {
+				return r
+			}
+		}
+	}
+	r.ancestor = oldHead
+	return r
+}
+
+// reportReorgLocked records a head change that drops blocks of the old
+// head's chain.
+func (c *Store) reportReorgLocked(oldHead [32]byte, r reorg) {
+	if r.depth == 0 {
+		return
+	}
+	metrics.Reorgs.Inc()
+	metrics.ReorgDepth.Observe(float64(r.depth))
+	log.Info("chain reorg",
+		"old_head", logging.ShortHash(oldHead),
+		"new_head", logging.ShortHash(c.head),
+		"ancestor", logging.ShortHash(r.ancestor),
+		"depth", r.depth,
+		"distance", r.distance,
+	)
+}
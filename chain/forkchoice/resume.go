@@ -0,0 +1,27 @@
+package forkchoice
+
+// Resume picks up a chain already held in storage, as after a restart with a
+// persistent backend: it recomputes the head over the stored blocks and
+// adopts the justified and finalized checkpoints of the head state when they
+// are ahead of the store's own, then prunes below the finalized checkpoint.
+// It returns the new head.
+func (c *Store) Resume() [32]byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.updateHeadLocked()
+	state, ok := c.getState(c.head)
+	if !ok {
+		return c.head
+	}
+	if state.LatestJustified.Slot > c.latestJustified.Slot {
+		c.latestJustified = state.LatestJustified
+	}
+	if state.LatestFinalized.Slot > c.latestFinalized.Slot {
+		c.latestFinalized = state.LatestFinalized
+	}
+	c.updateHeadLocked()
+	c.updateSafeTargetLocked()
+	c.pruneLocked()
+	return c.head
+}
@@ -3,3 +3,6 @@
 package forkchoice
 
 func (c *Store) shouldVerifySignatures() bool { return false }
+
+// VerifiesSignatures reports whether this build verifies XMSS signatures.
+func (c *Store) VerifiesSignatures() bool { return c.shouldVerifySignatures() }
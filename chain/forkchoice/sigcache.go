@@ -0,0 +1,128 @@
+package forkchoice
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+
+	"github.com/geanlabs/gean/internal/lru"
+	"github.com/geanlabs/gean/observability/metrics"
+	"github.com/geanlabs/gean/xmss/leansig"
+)
+
+// defaultSigCacheSize is how many verified signatures are remembered. The
+// same attestation typically arrives by gossip, in an aggregate and in a
+// block body within a few slots.
+const defaultSigCacheSize = 8192
+
+// sigCache is an LRU of signatures that passed XMSS verification, so an
+// attestation seen again is not re-verified. Entries are keyed by a digest
+// of the public key, signing slot, message root and signature: a different
+// signature over the same message is verified afresh.
+type sigCache struct {
+	verified *lru.Cache[[32]byte, struct{}]
+}
+
+func newSigCache(capacity int) *sigCache {
+	return &sigCache{verified: lru.New[[32]byte, struct{}](capacity)}
+}
+
+func sigCacheKey(pubkey []byte, slot uint32, message [32]byte, sig []byte) [32]byte {
+	h := sha256.New()
+	h.Write(pubkey)
+	h.Write(binary.LittleEndian.AppendUint32(nil, slot))
+	h.Write(message[:])
+	h.Write(sig)
+	var key [32]byte
+	h.Sum(key[:0])
+	return key
+}
+
+// verify checks sig with leansig unless the same signature already verified.
+func (c *sigCache) verify(pubkey []byte, slot uint32, message [32]byte, sig []byte) error {
+	key := sigCacheKey(pubkey, slot, message, sig)
+	if _, ok := c.verified.Get(key); ok {
+		metrics.SignatureCache.WithLabelValues("hit").Inc()
+		return nil
+	}
+	metrics.SignatureCache.WithLabelValues("miss").Inc()
+
+	if err := leansig.ValidateSignatureEncoding(sig); err != nil {
+		return err
+	}
+	if err := leansig.Verify(pubkey, slot, message, sig); err != nil {
+		return err
+	}
+	c.verified.Add(key, struct{}{})
+	return nil
+}
+
+// sigCheck is one signature of a batch.
+type sigCheck struct {
+	pubkey  []byte
+	slot    uint32
+	message [32]byte
+	sig     []byte
+}
+
+// verifyBatch checks the signatures not already verified with one
+// leansig.VerifyBatch call and returns an error for each invalid one.
+func (c *sigCache) verifyBatch(checks []sigCheck) []error {
+	errs := make([]error, len(checks))
+	keys := make([][32]byte, len(checks))
+	var pending []int
+	for i, ch := range checks {
+		keys[i] = sigCacheKey(ch.pubkey, ch.slot, ch.message, ch.sig)
+		if _, ok := c.verified.Get(keys[i]); !ok {
+			pending = append(pending, i)
+		}
+	}
+	metrics.SignatureCache.WithLabelValues("hit").Add(float64(len(checks) - len(pending)))
+	metrics.SignatureCache.WithLabelValues("miss").Add(float64(len(pending)))
+
+	batch := make([]sigCheck, len(pending))
+	for j, i := range pending {
+		batch[j] = checks[i]
+	}
+	batchErrs := verifyChecks(batch)
+	for j, i := range pending {
+		if errs[i] = batchErrs[j]; errs[i] == nil {
+			c.verified.Add(keys[i], struct{}{})
+		}
+	}
+	return errs
+}
+
+// verifyChecks checks signatures with one leansig.VerifyBatch call and
+// returns an error for each invalid one. Malformed signatures are rejected
+// without a place in the batch.
+func verifyChecks(checks []sigCheck) []error {
+	errs := make([]error, len(checks))
+	var pending []int
+	for i, ch := range checks {
+		if errs[i] = leansig.ValidateSignatureEncoding(ch.sig); errs[i] == nil {
+			pending = append(pending, i)
+		}
+	}
+	if len(pending) == 0 {
+		return errs
+	}
+
+	pubkeys := make([][]byte, len(pending))
+	slots := make([]uint32, len(pending))
+	messages := make([][32]byte, len(pending))
+	sigs := make([][]byte, len(pending))
+	for j, i := range pending {
+		pubkeys[j], slots[j], messages[j], sigs[j] = checks[i].pubkey, checks[i].slot, checks[i].message, checks[i].sig
+	}
+	valid, err := leansig.VerifyBatch(pubkeys, slots, messages, sigs)
+	for j, i := range pending {
+		switch {
+		case err != nil:
+			errs[i] = err
+		case !valid[j]:
+			errs[i] = errors.New("signature verification failed")
+		}
+	}
+	return errs
+}
@@ -0,0 +1,95 @@
+package forkchoice_test
+
+import (
+	"testing"
+
+	"github.com/geanlabs/gean/chain/forkchoice"
+	"github.com/geanlabs/gean/internal/testutil"
+	"github.com/geanlabs/gean/types"
+)
+
+func TestProducedBlocksCarrySlashings(t *testing.T) {
+	fc, _, genesisRoot := testutil.AnchoredStore(t, testutil.DynamicGenesis(testutil.Validators(3)))
+	fc.AdvanceTime(1000+types.SecondsPerSlot, false)
+
+	// Proposer 1 signs two blocks at slot 1, and validator 0 votes for both.
+	first := testutil.ImportProposal(t, fc, 1, genesisRoot, nil)
+	second := testutil.ImportProposal(t, fc, 1, genesisRoot, []*types.SignedDeposit{testutil.Deposit(9, 1)})
+	for _, envelope := range []*types.SignedBlockWithAttestation{first, second} {
+		data := *envelope.Message.ProposerAttestation.Data
+		if r := fc.ProcessAttestation(&types.SignedAttestation{ValidatorID: 0, Message: &data}); r != forkchoice.AttestationAccepted {
+			t.Fatalf("attestation rejected: %v", r)
+		}
+	}
+	if n := len(fc.Equivocations()); n < 2 {
+		t.Fatalf("got %d equivocations, want the proposer's and validator 0's", n)
+	}
+
+	fc.AdvanceTime(1000+2*types.SecondsPerSlot, true)
+	produced, err := fc.ProduceBlock(2, 2, &testutil.Signer{})
+	if err != nil {
+		t.Fatalf("produce block: %v", err)
+	}
+	body := produced.Message.Block.Body
+	if len(body.ProposerSlashings) != 1 || body.ProposerSlashings[0].Header1.ProposerIndex != 1 {
+		t.Fatalf("proposer slashings = %+v, want one for validator 1", body.ProposerSlashings)
+	}
+	// Validator 1's conflicting proposer attestations are covered by its
+	// proposer slashing.
+	if len(body.AttesterSlashings) != 1 || body.AttesterSlashings[0].Attestation1.ValidatorID != 0 {
+		t.Fatalf("attester slashings = %+v, want one for validator 0", body.AttesterSlashings)
+	}
+	root, _ := produced.Message.Block.HashTreeRoot()
+	post, _ := fc.GetState(root)
+	for v, want := range []bool{true, true, false} {
+		if post.Validators[v].Slashed != want {
+			t.Fatalf("validator %d slashed = %v, want %v", v, post.Validators[v].Slashed, want)
+		}
+	}
+
+	// Another node verifies the slashing signatures and imports the block.
+	other, _, _ := testutil.AnchoredStore(t, testutil.DynamicGenesis(testutil.Validators(3)))
+	other.AdvanceTime(1000+2*types.SecondsPerSlot, false)
+	for _, envelope := range []*types.SignedBlockWithAttestation{first, second, produced} {
+		if err := other.ProcessBlock(envelope); err != nil {
+			t.Fatalf("import on another node: %v", err)
+		}
+	}
+
+	// Slashed validators no longer propose, and evidence against them is
+	// not packed again.
+	fc.AdvanceTime(1000+4*types.SecondsPerSlot, true)
+	if fc.IsProposer(1, 4) {
+		t.Fatal("slashed validator 1 proposes")
+	}
+	next, err := fc.ProduceBlock(4, 2, &testutil.Signer{})
+	if err != nil {
+		t.Fatalf("produce block: %v", err)
+	}
+	if b := next.Message.Block.Body; len(b.ProposerSlashings) != 0 || len(b.AttesterSlashings) != 0 {
+		t.Fatalf("slashings packed twice: %+v", b)
+	}
+}
+
+func TestSpecChainBlocksCarryNoSlashings(t *testing.T) {
+	fc, _, genesisRoot := testutil.AnchoredStore(t, testutil.Genesis(testutil.Validators(3)))
+	fc.AdvanceTime(1000+types.SecondsPerSlot, false)
+	first := testutil.ImportProposal(t, fc, 1, genesisRoot, nil)
+	data := *first.Message.ProposerAttestation.Data
+	fc.ProcessAttestation(&types.SignedAttestation{ValidatorID: 0, Message: &data})
+	conflicting := data
+	conflicting.Head = &types.Checkpoint{Root: genesisRoot}
+	fc.ProcessAttestation(&types.SignedAttestation{ValidatorID: 0, Message: &conflicting})
+	if len(fc.Equivocations()) == 0 {
+		t.Fatal("equivocation not detected")
+	}
+
+	fc.AdvanceTime(1000+2*types.SecondsPerSlot, true)
+	produced, err := fc.ProduceBlock(2, 2, &testutil.Signer{})
+	if err != nil {
+		t.Fatalf("produce block: %v", err)
+	}
+	if b := produced.Message.Block.Body; b.HasOperations() {
+		t.Fatalf("spec chain block carries operations: %+v", b)
+	}
+}
@@ -0,0 +1,67 @@
+package forkchoice
+
+import (
+	"github.com/geanlabs/gean/observability/metrics"
+	"github.com/geanlabs/gean/storage"
+	"github.com/geanlabs/gean/types"
+)
+
+// defaultStateCacheSize is the number of post-states fork choice keeps in
+// memory by default.
+const defaultStateCacheSize = 32
+
+// GetState retrieves the post-state of the block with the given root.
+func (c *Store) GetState(root [32]byte) (*types.State, bool) {
+	return c.getState(root)
+}
+
+// getState reads a post-state through the state cache, loading it from
+// storage on a miss. It does not need the store lock.
+func (c *Store) getState(root [32]byte) (*types.State, bool) {
+	if st, ok := c.states.Get(root); ok {
+		metrics.ForkChoiceStateCache.WithLabelValues("hit").Inc()
+		return st, true
+	}
+	metrics.ForkChoiceStateCache.WithLabelValues("miss").Inc()
+	st, ok := c.storage.GetState(root)
+	if ok {
+		c.states.Add(root, st)
+	}
+	return st, ok
+}
+
+// SetStateCacheSize changes how many post-states fork choice keeps in memory.
+// The head and checkpoint states are kept regardless.
+func (c *Store) SetStateCacheSize(n int) {
+	c.states.Resize(n)
+}
+
+// ShedStateCache empties the fork choice state cache, except for the head
+// and checkpoint states, and returns how many states it dropped.
+func (c *Store) ShedStateCache() int {
+	return c.states.Clear()
+}
+
+// pinStatesLocked keeps the states of the head and the checkpoints in the
+// cache, since every attestation and proposal reads them.
+func (c *Store) pinStatesLocked() {
+	c.states.Pin(c.head, c.latestJustified.Root, c.latestFinalized.Root)
+}
+
+// cachedStateView routes GetState through the fork choice state cache, so
+// the leaf states read by head selection stay in memory.
+type cachedStateView struct {
+	storage.Store
+	c *Store
+}
+
+func (v *cachedStateView) GetState(root [32]byte) (*types.State, bool) {
+	if st, ok := v.c.states.Get(root); ok {
+		return st, true
+	}
+	st, ok := v.Store.GetState(root)
+	if ok {
+		v.c.states.Add(root, st)
+	}
+	return st, ok
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"sync"
 
+	"github.com/geanlabs/gean/chain/statetransition"
+	"github.com/geanlabs/gean/internal/lru"
 	"github.com/geanlabs/gean/observability/logging"
 	"github.com/geanlabs/gean/storage"
 	"github.com/geanlabs/gean/types"
@@ -15,20 +17,119 @@ var log = logging.NewComponentLogger(logging.CompForkChoice)
 type Store struct {
 	mu sync.Mutex
 
-	time          uint64
-	genesisTime   uint64
-	numValidators uint64
-	head          [32]byte
-	safeTarget    [32]byte
+	time        uint64
+	genesisTime uint64
+	config      types.Config // of the anchor state, so of the chain
+	preset      types.Preset // config.Preset()
+	head        [32]byte
+	safeTarget  [32]byte
 
 	latestJustified *types.Checkpoint
 	latestFinalized *types.Checkpoint
 	storage         storage.Store
 
+	// anchor is the block the store was initialized from. No other block can
+	// be imported at or before its slot.
+	anchor types.Checkpoint
+
 	latestKnownAttestations map[uint64]*types.SignedAttestation
 	latestNewAttestations   map[uint64]*types.SignedAttestation
+	knownBySlot             slotIndex
+	newBySlot               slotIndex
+
+	// voteTarget caches the vote target computed for voteTargetKey.
+	voteTarget    *types.Checkpoint
+	voteTargetKey voteTargetKey
+
+	// weights is the fork choice weight of each validator's vote, by index,
+	// and totalWeight their sum. They follow the head state unless
+	// fixedWeights is set by SetValidatorWeights.
+	weights      []int
+	totalWeight  int
+	fixedWeights bool
+
+	// proto holds the block tree under the anchor with vote weights kept up
+	// to date as attestations change, for head and safe target selection.
+	proto *protoArray
+
+	// producedBlocks records the block root produced for each proposal duty
+	// so a repeated duty never signs a conflicting block. It does not outlive
+	// the process; Proposals does.
+	producedBlocks map[productionKey][32]byte
+
+	// knownVersion changes whenever latestKnownAttestations does; packing is
+	// the optional pre-warmed proposal input built from it.
+	knownVersion uint64
+	packing      *packingCache
+
+	// pendingByRoot buffers attestations waiting for a referenced block.
+	pendingByRoot map[[32]byte][]pendingAttestation
+	numPending    int
+	maxPending    int
+
+	// future holds gossip attestations for the next slot until it starts.
+	future futureAttestations
+
+	// equivocations tracks messages per validator and slot to find
+	// conflicting ones.
+	equivocations *equivocations
+
+	// headSubs receive head events; eventJustified and eventFinalized are
+	// the checkpoints as of the last event.
+	headSubs       []chan HeadEvent
+	eventJustified types.Checkpoint
+	eventFinalized types.Checkpoint
+
+	// invalid holds blocks removed from fork choice by InvalidateBlock.
+	invalid map[[32]byte]bool
+
+	// indexedHead and indexedSlot are the head the storage canonical index
+	// was last updated for, and its slot.
+	indexedHead [32]byte
+	indexedSlot uint64
+
+	// prunedRoot is the finalized root at the last prune; states of its
+	// ancestors have already been deleted.
+	prunedRoot [32]byte
+
+	// sigs remembers signatures that already passed verification.
+	sigs *sigCache
+
+	// states caches post-states read through fork choice in front of
+	// storage. It has its own lock, so GetState does not take mu.
+	states *lru.Cache[[32]byte, *types.State]
 
 	NowFn func() uint64
+
+	// OnMissingBlock, if set, is called with the root of a block referenced by
+	// a deferred attestation. It runs with the store lock held and must not
+	// block or call back into the store.
+	OnMissingBlock func(root [32]byte)
+
+	// CheckInvariants enables debug assertions on the storage commit path.
+	CheckInvariants bool
+
+	// SignatureWorkers selects how body attestation signatures of a block
+	// are verified: 1 checks them one by one, anything else verifies them
+	// in a single parallel batch; 0 uses GOMAXPROCS.
+	SignatureWorkers int
+
+	// CrossValidate re-runs every imported block through the reference state
+	// transition and reports a divergence to OnDivergence, or panics if it
+	// is nil. The block is imported either way.
+	CrossValidate bool
+	OnDivergence  func(error)
+
+	// Proposals, if set, durably records every produced block before
+	// ProduceBlock returns it, and is consulted before producing one, so a
+	// restart never signs a second block for a duty even when the block
+	// itself was only held in memory storage.
+	Proposals ProposalRecord
+
+	// StrictCheckpoints panics when the justified or finalized checkpoint is
+	// found missing or off the head's chain after a block import, instead
+	// of logging it.
+	StrictCheckpoints bool
 }
 
 // ChainStatus is a snapshot of the fork choice head and checkpoint state.
@@ -59,9 +160,68 @@ func (c *Store) GetStatus() ChainStatus {
 	}
 }
 
-// NumValidators returns the number of validators in the store.
+// Time returns the store time, in intervals since genesis.
+func (c *Store) Time() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.time
+}
+
+// Anchor returns the checkpoint of the block the store was initialized from.
+func (c *Store) Anchor() types.Checkpoint {
+	return c.anchor
+}
+
+// NumValidators returns the number of validators in the registry of the
+// head state.
 func (c *Store) NumValidators() uint64 {
-	return c.numValidators
+	st, ok := c.headState()
+	if !ok {
+		return 0
+	}
+	return uint64(len(st.Validators))
+}
+
+// NumActiveValidators returns the number of validators of the head state
+// active at slot.
+func (c *Store) NumActiveValidators(slot uint64) uint64 {
+	st, ok := c.headState()
+	if !ok {
+		return 0
+	}
+	return uint64(len(st.ActiveValidators(slot)))
+}
+
+// Config returns the chain config.
+func (c *Store) Config() types.Config {
+	return c.config
+}
+
+// Preset returns the preset of the chain.
+func (c *Store) Preset() types.Preset {
+	return c.preset
+}
+
+// ProposerIndex returns the proposer of slot among the validators of the
+// head state active at slot. It reports false if none is.
+func (c *Store) ProposerIndex(slot uint64) (uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.proposerIndexLocked(slot)
+}
+
+func (c *Store) proposerIndexLocked(slot uint64) (uint64, bool) {
+	st, ok := c.getState(c.head)
+	if !ok {
+		return 0, false
+	}
+	return statetransition.StateProposerIndex(st, slot)
+}
+
+// IsProposer reports whether validatorIndex is the proposer of slot.
+func (c *Store) IsProposer(validatorIndex, slot uint64) bool {
+	proposer, ok := c.ProposerIndex(slot)
+	return ok && proposer == validatorIndex
 }
 
 // GetBlock retrieves a block by its root hash.
@@ -99,22 +259,49 @@ func NewStore(state *types.State, anchorBlock *types.Block, store storage.Store)
 
 	anchorRoot, _ := anchorBlock.HashTreeRoot()
 
-	store.PutBlock(anchorRoot, anchorBlock)
-	store.PutSignedBlock(anchorRoot, &types.SignedBlockWithAttestation{
+	batch := store.Batch()
+	batch.PutBlock(anchorRoot, anchorBlock)
+	batch.PutSignedBlock(anchorRoot, &types.SignedBlockWithAttestation{
 		Message: &types.BlockWithAttestation{Block: anchorBlock},
 	})
-	store.PutState(anchorRoot, state)
+	batch.PutState(anchorRoot, state)
+	if err := batch.Commit(); err != nil {
+		panic(fmt.Sprintf("store anchor block %x: %v", anchorRoot, err))
+	}
 
-	return &Store{
-		time:                    anchorBlock.Slot * types.SecondsPerSlot,
+	c := &Store{
+		time:                    anchorBlock.Slot * types.IntervalsPerSlot,
 		genesisTime:             state.Config.GenesisTime,
-		numValidators:           uint64(len(state.Validators)),
+		config:                  *state.Config,
+		preset:                  state.Config.Preset(),
 		head:                    anchorRoot,
 		safeTarget:              anchorRoot,
 		latestJustified:         &types.Checkpoint{Root: anchorRoot, Slot: anchorBlock.Slot},
 		latestFinalized:         &types.Checkpoint{Root: anchorRoot, Slot: anchorBlock.Slot},
 		storage:                 store,
+		anchor:                  types.Checkpoint{Root: anchorRoot, Slot: anchorBlock.Slot},
 		latestKnownAttestations: make(map[uint64]*types.SignedAttestation),
 		latestNewAttestations:   make(map[uint64]*types.SignedAttestation),
+		knownBySlot:             make(slotIndex),
+		newBySlot:               make(slotIndex),
+		producedBlocks:          make(map[productionKey][32]byte),
+		pendingByRoot:           make(map[[32]byte][]pendingAttestation),
+		maxPending:              maxPendingAttestations,
+		invalid:                 make(map[[32]byte]bool),
+		states:                  lru.New[[32]byte, *types.State](defaultStateCacheSize),
+		sigs:                    newSigCache(defaultSigCacheSize),
+		proto:                   newProtoArray(),
+		equivocations:           newEquivocations(),
+		eventJustified:          types.Checkpoint{Root: anchorRoot, Slot: anchorBlock.Slot},
+		eventFinalized:          types.Checkpoint{Root: anchorRoot, Slot: anchorBlock.Slot},
+	}
+	c.weights = validatorWeights(state, anchorBlock.Slot)
+	for _, w := range c.weights {
+		c.totalWeight += w
 	}
+	c.states.Add(anchorRoot, state)
+	c.loadProtoArrayLocked(anchorRoot, anchorBlock, state)
+	c.pinStatesLocked()
+	c.updateCanonicalLocked()
+	return c
 }
@@ -16,7 +16,7 @@ func (c *Store) advanceTimeLocked(time uint64, hasProposal bool) {
 	if time <= c.genesisTime {
 		return
 	}
-	tickInterval := (time - c.genesisTime) / types.SecondsPerInterval
+	tickInterval := (time - c.genesisTime) / c.preset.SecondsPerInterval()
 	for c.time < tickInterval {
 		shouldSignal := hasProposal && (c.time+1) == tickInterval
 		c.tickIntervalLocked(shouldSignal)
@@ -36,6 +36,7 @@ func (c *Store) tickIntervalLocked(hasProposal bool) {
 
 	switch currentInterval {
 	case 0:
+		c.releaseFutureAttestationsLocked()
 		if hasProposal {
 			c.acceptNewAttestationsLocked()
 		}
@@ -56,15 +57,31 @@ func (c *Store) AcceptNewAttestations() {
 }
 
 func (c *Store) acceptNewAttestationsLocked() {
-	for id, sa := range c.latestNewAttestations {
-		c.latestKnownAttestations[id] = sa
+	for _, sa := range c.latestNewAttestations {
+		c.setKnownLocked(sa)
 	}
 	c.latestNewAttestations = make(map[uint64]*types.SignedAttestation)
+	c.newBySlot = make(slotIndex)
+	c.proto.clearVotes(newVotes)
 	c.updateHeadLocked()
 }
 
 func (c *Store) updateHeadLocked() {
-	c.head = GetForkChoiceHead(c.storage, c.latestJustified.Root, c.latestKnownAttestations, 0)
+	oldHead := c.head
+	c.head = c.forkChoiceHeadLocked(knownVotes, 0)
+	if c.refreshWeightsLocked() {
+		// The head brought another validator set; choose again with its
+		// weights.
+		c.head = c.forkChoiceHeadLocked(knownVotes, 0)
+	}
+	c.pinStatesLocked()
+	c.updateCanonicalLocked()
+	var r reorg
+	if oldHead != c.head {
+		r = c.reorgLocked(oldHead, c.head)
+		c.reportReorgLocked(oldHead, r)
+	}
+	c.publishHeadLocked(oldHead, r)
 }
 
 // UpdateSafeTarget finds the head with sufficient (2/3+) vote support.
@@ -74,9 +91,27 @@ func (c *Store) UpdateSafeTarget() {
 	c.updateSafeTargetLocked()
 }
 
+// GetSafeTarget returns the safe target: the deepest block from the justified
+// checkpoint whose subtree holds the latest new votes of at least two thirds
+// of the validators. It is recomputed at the third interval of each slot,
+// before new votes are accepted, and validators never vote for a target
+// more than JustificationLookback blocks ahead of it.
+func (c *Store) GetSafeTarget() types.Checkpoint {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cp := types.Checkpoint{Root: c.safeTarget}
+	if block, ok := c.storage.GetBlock(c.safeTarget); ok {
+		cp.Slot = block.Slot
+	}
+	return cp
+}
+
+// updateSafeTargetLocked applies the leanSpec confirmation rule: LMD GHOST
+// from the justified root over the new votes only, descending into a child
+// only while it carries ceil(2/3) of the total validator weight.
 func (c *Store) updateSafeTargetLocked() {
-	minScore := int(ceilDiv(c.numValidators*2, 3))
-	c.safeTarget = GetForkChoiceHead(c.storage, c.latestJustified.Root, c.latestNewAttestations, minScore)
+	minScore := int(ceilDiv(uint64(c.totalWeight)*2, 3))
+	c.safeTarget = c.forkChoiceHeadLocked(newVotes, minScore)
 	if block, ok := c.storage.GetBlock(c.safeTarget); ok {
 		metrics.SafeTargetSlot.Set(float64(block.Slot))
 	}
@@ -0,0 +1,137 @@
+package forkchoice
+
+import (
+	"github.com/geanlabs/gean/observability/metrics"
+	"github.com/geanlabs/gean/types"
+)
+
+// ValidationResult is how a validated message is treated, as in gossipsub:
+// an ignored message may be honest but is of no use now, a rejected one is
+// invalid whenever it is seen and its sender is at fault.
+type ValidationResult string
+
+const (
+	ValidationAccept ValidationResult = "accept"
+	ValidationIgnore ValidationResult = "ignore"
+	ValidationReject ValidationResult = "reject"
+)
+
+// AttestationReason says why an attestation was not applied to fork choice;
+// AttestationAccepted means it was.
+type AttestationReason string
+
+const (
+	AttestationAccepted AttestationReason = ""
+
+	// Ignored.
+	AttestationUnknownSource   AttestationReason = "unknown_source"
+	AttestationUnknownTarget   AttestationReason = "unknown_target"
+	AttestationUnknownHead     AttestationReason = "unknown_head"
+	AttestationPending         AttestationReason = "pending"
+	AttestationFutureSlot      AttestationReason = "future_slot"
+	AttestationFutureQueueFull AttestationReason = "future_queue_full"
+	AttestationBeforeFinalized AttestationReason = "before_finalized"
+	AttestationNoHeadState     AttestationReason = "no_head_state"
+
+	// Rejected.
+	AttestationSourceAfterTarget AttestationReason = "source_after_target"
+	AttestationSourceMismatch    AttestationReason = "source_slot_mismatch"
+	AttestationTargetMismatch    AttestationReason = "target_slot_mismatch"
+	AttestationBlockFutureSlot   AttestationReason = "block_future_slot"
+	AttestationBadSignature      AttestationReason = "bad_signature"
+)
+
+// Result classifies the reason. AttestationPending is ignored for now: the
+// attestation is held until its block arrives or its slot starts, and is
+// validated again then.
+func (r AttestationReason) Result() ValidationResult {
+	switch r {
+	case AttestationAccepted:
+		return ValidationAccept
+	case AttestationSourceAfterTarget, AttestationSourceMismatch, AttestationTargetMismatch,
+		AttestationBlockFutureSlot, AttestationBadSignature:
+		return ValidationReject
+	default:
+		return ValidationIgnore
+	}
+}
+
+// recordAttestation counts an attestation by the outcome of its validation
+// and returns the reason.
+func recordAttestation(reason AttestationReason) AttestationReason {
+	if reason == AttestationAccepted {
+		metrics.AttestationsValid.Inc()
+		return reason
+	}
+	metrics.AttestationsInvalid.Inc()
+	metrics.AttestationsDropped.WithLabelValues(string(reason.Result()), string(reason)).Inc()
+	return reason
+}
+
+// validateGossipAttestationLocked validates an attestation received on its
+// own, from gossip or a local validator, per the spec's gossip checks on top
+// of the shared ones. Attestations for a slot more than one ahead of the
+// store's clock may be early rather than wrong, and are ignored.
+func (c *Store) validateGossipAttestationLocked(data *types.AttestationData) AttestationReason {
+	if reason := c.validateAttestationDataLocked(data); reason != AttestationAccepted {
+		return reason
+	}
+	if data.Slot > c.time/types.IntervalsPerSlot+1 {
+		return AttestationFutureSlot
+	}
+	return AttestationAccepted
+}
+
+// validateBlockAttestationLocked validates an attestation carried in a
+// block body. The block's own slot was checked on import, so an attestation
+// in it for a slot the store cannot have reached is invalid, not early.
+// Attestations that are old but not before the finalized slot are fine:
+// blocks include them for justification.
+func (c *Store) validateBlockAttestationLocked(data *types.AttestationData) AttestationReason {
+	if reason := c.validateAttestationDataLocked(data); reason != AttestationAccepted {
+		return reason
+	}
+	if data.Slot > c.time/types.IntervalsPerSlot+1 {
+		return AttestationBlockFutureSlot
+	}
+	return AttestationAccepted
+}
+
+// validateAttestationDataLocked runs the checks both pipelines share: the
+// attestation is not before the finalized slot, the blocks it names are
+// known, and its checkpoints are ordered and match the slots of their
+// blocks. Votes before the finalized slot can no longer add weight, and the
+// latest votes they would be compared with are dropped once finality passes
+// them, so they are ignored rather than counted again.
+func (c *Store) validateAttestationDataLocked(data *types.AttestationData) AttestationReason {
+	if data.Slot < c.latestFinalized.Slot {
+		return AttestationBeforeFinalized
+	}
+
+	// Availability check: source, target, and head blocks must exist.
+	sourceBlock, ok := c.storage.GetBlock(data.Source.Root)
+	if !ok {
+		return AttestationUnknownSource
+	}
+	targetBlock, ok := c.storage.GetBlock(data.Target.Root)
+	if !ok {
+		return AttestationUnknownTarget
+	}
+	if _, ok := c.storage.GetBlock(data.Head.Root); !ok {
+		return AttestationUnknownHead
+	}
+
+	// Topology check.
+	if sourceBlock.Slot > targetBlock.Slot || data.Source.Slot > data.Target.Slot {
+		return AttestationSourceAfterTarget
+	}
+
+	// Consistency check.
+	if sourceBlock.Slot != data.Source.Slot {
+		return AttestationSourceMismatch
+	}
+	if targetBlock.Slot != data.Target.Slot {
+		return AttestationTargetMismatch
+	}
+	return AttestationAccepted
+}
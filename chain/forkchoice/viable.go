@@ -0,0 +1,47 @@
+package forkchoice
+
+import (
+	"github.com/geanlabs/gean/storage"
+	"github.com/geanlabs/gean/types"
+)
+
+// viableBlocks returns the blocks under root that lie on a viable branch: a
+// branch ending in a leaf whose post-state has the same latest justified
+// checkpoint, root and slot, as the store. A leaf that has not caught up
+// with the store's justification cannot lead to a head consistent with it,
+// so its branch is excluded from the head walk. Votes for such blocks still
+// count toward shared ancestors.
+//
+// children maps each block in the subtree under root to its children.
+func viableBlocks(
+	store storage.Store,
+	children map[[32]byte][][32]byte,
+	root [32]byte,
+	justified *types.Checkpoint,
+) map[[32]byte]bool {
+	viable := make(map[[32]byte]bool)
+	var visit func(h [32]byte) bool
+	visit = func(h [32]byte) bool {
+		kids := children[h]
+		if len(kids) == 0 {
+			state, ok := store.GetState(h)
+			if !ok || *state.LatestJustified != *justified {
+				return false
+			}
+			viable[h] = true
+			return true
+		}
+		viableChild := false
+		for _, k := range kids {
+			if visit(k) {
+				viableChild = true
+			}
+		}
+		if viableChild {
+			viable[h] = true
+		}
+		return viableChild
+	}
+	visit(root)
+	return viable
+}
@@ -0,0 +1,56 @@
+package forkchoice_test
+
+import (
+	"testing"
+
+	"github.com/geanlabs/gean/internal/testutil"
+	"github.com/geanlabs/gean/types"
+)
+
+func TestVoteTargetFollowsHeadAndSafeTarget(t *testing.T) {
+	fc, _, genesisRoot := testutil.AnchoredStore(t, testutil.Genesis(testutil.Validators(3)))
+	fc.AdvanceTime(1000+types.SecondsPerSlot, true)
+	envelope, err := fc.ProduceBlock(1, 1, &testutil.Signer{})
+	if err != nil {
+		t.Fatalf("produce block: %v", err)
+	}
+	fc.RecomputeHead()
+	root, _ := envelope.Message.Block.HashTreeRoot()
+
+	check := func(want [32]byte, wantSlot uint64) {
+		t.Helper()
+		target, err := fc.GetVoteTarget()
+		if err != nil {
+			t.Fatalf("vote target: %v", err)
+		}
+		if target.Root != want || target.Slot != wantSlot {
+			t.Fatalf("vote target = slot %d %x, want slot %d %x", target.Slot, target.Root, wantSlot, want)
+		}
+		// Callers own the returned checkpoint.
+		target.Slot = 99
+	}
+
+	// The safe target is still genesis, so the target walks back to it.
+	check(genesisRoot, 0)
+	check(genesisRoot, 0)
+
+	for _, v := range []uint64{0, 2} {
+		fc.ProcessAttestation(&types.SignedAttestation{
+			ValidatorID: v,
+			Message: &types.AttestationData{
+				Slot:   1,
+				Head:   &types.Checkpoint{Root: root, Slot: 1},
+				Target: &types.Checkpoint{Root: root, Slot: 1},
+				Source: &types.Checkpoint{Root: genesisRoot, Slot: 0},
+			},
+		})
+	}
+	fc.UpdateSafeTarget()
+	check(root, 1)
+
+	// Moving the head off the block moves the target with it.
+	if _, err := fc.InvalidateBlock(root); err != nil {
+		t.Fatalf("invalidate: %v", err)
+	}
+	check(genesisRoot, 0)
+}
@@ -0,0 +1,82 @@
+package forkchoice
+
+import (
+	"slices"
+
+	"github.com/geanlabs/gean/types"
+)
+
+// validatorWeights returns the fork choice weight of each validator of
+// state, by index. Validator records carry no balance yet, so every
+// validator active at slot weighs one and the others nothing; once they do,
+// this is where it is read.
+func validatorWeights(state *types.State, slot uint64) []int {
+	weights := make([]int, len(state.Validators))
+	for _, i := range state.ActiveValidators(slot) {
+		weights[i] = 1
+	}
+	return weights
+}
+
+// SetValidatorWeights replaces the fork choice weight of each validator,
+// by index, for devnets whose stake is configured outside the state.
+// Validators beyond the slice weigh nothing, and the weights no longer
+// follow the head state. Current votes are reweighed and the head and safe
+// target recomputed.
+func (c *Store) SetValidatorWeights(weights []int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fixedWeights = true
+	c.setWeightsLocked(append([]int(nil), weights...))
+	c.updateHeadLocked()
+	c.updateSafeTargetLocked()
+}
+
+// refreshWeightsLocked recomputes the weights from the validators of the
+// head state active at the current slot, unless they are fixed, and reports
+// whether they changed.
+func (c *Store) refreshWeightsLocked() bool {
+	if c.fixedWeights {
+		return false
+	}
+	st, ok := c.getState(c.head)
+	if !ok {
+		return false
+	}
+	weights := validatorWeights(st, c.time/types.IntervalsPerSlot)
+	if slices.Equal(weights, c.weights) {
+		return false
+	}
+	c.setWeightsLocked(weights)
+	return true
+}
+
+// setWeightsLocked replaces the weights and reweighs the current votes.
+func (c *Store) setWeightsLocked(weights []int) {
+	c.weights = weights
+	c.totalWeight = 0
+	for _, w := range c.weights {
+		c.totalWeight += w
+	}
+	for id, sa := range c.latestKnownAttestations {
+		c.proto.vote(knownVotes, id, sa.Message.Head.Root, c.voteWeightLocked(id))
+	}
+	for id, sa := range c.latestNewAttestations {
+		c.proto.vote(newVotes, id, sa.Message.Head.Root, c.voteWeightLocked(id))
+	}
+}
+
+// TotalWeight returns the summed fork choice weight of all validators.
+func (c *Store) TotalWeight() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.totalWeight
+}
+
+// voteWeightLocked returns the weight a vote of validator id carries.
+func (c *Store) voteWeightLocked(id uint64) int {
+	if id >= uint64(len(c.weights)) {
+		return 0
+	}
+	return c.weights[id]
+}
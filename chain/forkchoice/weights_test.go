@@ -0,0 +1,81 @@
+package forkchoice_test
+
+import (
+	"testing"
+
+	"github.com/geanlabs/gean/internal/testutil"
+	"github.com/geanlabs/gean/types"
+)
+
+func TestValidatorWeightsDecideHeadAndSafeTarget(t *testing.T) {
+	fc, _, genesisRoot := testutil.AnchoredStore(t, testutil.Genesis(testutil.Validators(3)))
+	if w := fc.TotalWeight(); w != 3 {
+		t.Fatalf("total weight = %d, want one per validator", w)
+	}
+	fc.AdvanceTime(1000+2*types.SecondsPerSlot, false)
+	a := testutil.ImportEmptyBlock(t, fc, 1, genesisRoot)
+	b := testutil.ImportEmptyBlock(t, fc, 2, genesisRoot)
+
+	genesis := &types.Checkpoint{Root: genesisRoot, Slot: 0}
+	vote := func(validator uint64, root [32]byte, slot uint64) {
+		cp := &types.Checkpoint{Root: root, Slot: slot}
+		fc.ProcessAttestation(&types.SignedAttestation{
+			ValidatorID: validator,
+			Message:     &types.AttestationData{Slot: 2, Head: cp, Target: cp, Source: genesis},
+		})
+	}
+	vote(0, a, 1)
+	vote(1, b, 2)
+	vote(2, b, 2)
+
+	check := func(wantSafe [32]byte) {
+		t.Helper()
+		fc.UpdateSafeTarget()
+		if got := fc.GetSafeTarget().Root; got != wantSafe {
+			t.Fatalf("safe target = %x, want %x", got, wantSafe)
+		}
+	}
+	check(b)
+	// Validator 0 alone now outweighs the two others, and holds five of
+	// seven, the two thirds the safe target needs.
+	fc.SetValidatorWeights([]int{5, 1, 1})
+	if w := fc.TotalWeight(); w != 7 {
+		t.Fatalf("total weight = %d, want 7", w)
+	}
+	check(a)
+
+	fc.AcceptNewAttestations()
+	if head := fc.RecomputeHead(); head != a {
+		t.Fatalf("head = %x, want the heavier branch %x", head, a)
+	}
+	fc.SetValidatorWeights([]int{1, 1, 1})
+	if head := fc.GetStatus().Head; head != b {
+		t.Fatalf("head = %x, want %x with equal weights", head, b)
+	}
+}
+
+func TestValidatorWeightsFollowHeadState(t *testing.T) {
+	fc, _, genesisRoot := testutil.AnchoredStore(t, testutil.DynamicGenesis(testutil.Validators(3)))
+	fc.AdvanceTime(1000+2*types.SecondsPerSlot, false)
+	envelope := testutil.ImportProposal(t, fc, 1, genesisRoot, []*types.SignedDeposit{testutil.Deposit(9, 1)})
+	root, _ := envelope.Message.Block.HashTreeRoot()
+	if head := fc.GetStatus().Head; head != root {
+		t.Fatalf("head = %x, want the deposit block", head)
+	}
+	if n := fc.NumValidators(); n != 4 {
+		t.Fatalf("%d validators, want the head registry of 4", n)
+	}
+	if w := fc.TotalWeight(); w != 3 {
+		t.Fatalf("total weight = %d before the deposit activates, want 3", w)
+	}
+
+	slot := uint64(types.SlotsPerEpoch)
+	fc.AdvanceTime(1000+(slot+1)*types.SecondsPerSlot, false)
+	testutil.ImportEmptyBlock(t, fc, slot, root)
+	if w := fc.TotalWeight(); w != 4 {
+		t.Fatalf("total weight = %d once the deposit is active, want 4", w)
+	}
+	if n := fc.NumActiveValidators(slot); n != 4 {
+		t.Fatalf("%d active validators, want 4", n)
+	}
+}
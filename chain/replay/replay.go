@@ -0,0 +1,113 @@
+// Package replay re-executes chain segments from storage through the state
+// transition. It audits stored blocks and states, and rebuilds the states of
+// blocks fetched during sync.
+package replay
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/geanlabs/gean/chain/statetransition"
+	"github.com/geanlabs/gean/storage"
+	"github.com/geanlabs/gean/types"
+)
+
+// Errors returned for segments that cannot be replayed or do not check out.
+// State transition failures are returned wrapped, for statetransition.Code.
+var (
+	ErrUnknownStart        = errors.New("start state not found")
+	ErrUnknownBlock        = errors.New("block not found")
+	ErrNotLinked           = errors.New("block does not extend the previous block")
+	ErrStoredStateMismatch = errors.New("stored state differs from replayed state")
+)
+
+// Result is the outcome of a replay.
+type Result struct {
+	// Root and State are the last block replayed and its post-state, or the
+	// start block and its state if there were no blocks.
+	Root  [32]byte
+	State *types.State
+	// Blocks is the number of blocks replayed, and Checked the number of
+	// them whose stored state was compared with the replayed one.
+	Blocks  int
+	Checked int
+}
+
+// Segment replays blocks, given by root in chain order, on the stored state
+// of start. Each block must be a child of the one before it; the state
+// transition checks its state root, and where store holds the block's state
+// it must equal the replayed state. On error, the result covers the blocks
+// replayed before the failing one.
+func Segment(store storage.Store, start [32]byte, blocks [][32]byte) (*Result, error) {
+	state, ok := store.GetState(start)
+	if !ok {
+		return nil, fmt.Errorf("%w: %x", ErrUnknownStart, start)
+	}
+	res := &Result{Root: start, State: state}
+	for _, root := range blocks {
+		block, ok := store.GetBlock(root)
+		if !ok {
+			return res, fmt.Errorf("%w: %x", ErrUnknownBlock, root)
+		}
+		if block.ParentRoot != res.Root {
+			return res, fmt.Errorf("%w: block %x at slot %d has parent %x, not %x",
+				ErrNotLinked, root, block.Slot, block.ParentRoot, res.Root)
+		}
+		next, err := statetransition.StateTransition(res.State, block)
+		if err != nil {
+			return res, fmt.Errorf("block %x at slot %d: %w", root, block.Slot, err)
+		}
+		if stored, ok := store.GetState(root); ok {
+			if err := compareStored(root, block.Slot, stored, next); err != nil {
+				return res, err
+			}
+			res.Checked++
+		}
+		res.Root, res.State = root, next
+		res.Blocks++
+	}
+	return res, nil
+}
+
+// Ancestry returns the roots of the blocks after from up to and including
+// to, oldest first, following parent links in store.
+func Ancestry(store storage.Store, from, to [32]byte) ([][32]byte, error) {
+	fromSlot := uint64(0)
+	if b, ok := store.GetBlock(from); ok {
+		fromSlot = b.Slot
+	}
+	var roots [][32]byte
+	for root := to; root != from; {
+		block, ok := store.GetBlock(root)
+		if !ok {
+			return nil, fmt.Errorf("%w: %x", ErrUnknownBlock, root)
+		}
+		if block.Slot <= fromSlot {
+			return nil, fmt.Errorf("%w: %x does not descend from %x", ErrNotLinked, to, from)
+		}
+		roots = append(roots, root)
+		root = block.ParentRoot
+	}
+	for i, j := 0, len(roots)-1; i < j; i, j = i+1, j-1 {
+		roots[i], roots[j] = roots[j], roots[i]
+	}
+	return roots, nil
+}
+
+// compareStored checks a stored state against the replayed one, naming the
+// first differing field if they differ.
+func compareStored(root [32]byte, slot uint64, stored, replayed *types.State) error {
+	storedRoot, err := stored.HashTreeRoot()
+	if err != nil {
+		return fmt.Errorf("hash stored state of %x: %w", root, err)
+	}
+	replayedRoot, _ := replayed.HashTreeRoot()
+	if storedRoot == replayedRoot {
+		return nil
+	}
+	detail := "roots differ"
+	if diffs := statetransition.Diff(stored, replayed); len(diffs) > 0 {
+		detail = fmt.Sprintf("%s (%d fields differ)", diffs[0], len(diffs))
+	}
+	return fmt.Errorf("%w: block %x at slot %d: %s", ErrStoredStateMismatch, root, slot, detail)
+}
@@ -0,0 +1,70 @@
+package replay_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/geanlabs/gean/chain/forkchoice"
+	"github.com/geanlabs/gean/chain/replay"
+	"github.com/geanlabs/gean/chain/statetransition"
+	"github.com/geanlabs/gean/internal/testutil"
+	"github.com/geanlabs/gean/storage/memory"
+	"github.com/geanlabs/gean/types"
+)
+
+func TestReplaySegmentAuditsStoredChain(t *testing.T) {
+	state := statetransition.GenerateGenesis(1000, testutil.Validators(3))
+	genesis := &types.Block{Body: &types.BlockBody{Attestations: []*types.Attestation{}}}
+	genesis.StateRoot, _ = state.HashTreeRoot()
+	genesisRoot, _ := genesis.HashTreeRoot()
+	db := memory.New()
+	fc := forkchoice.NewStore(state, genesis, db)
+	fc.AdvanceTime(1000+5*types.SecondsPerSlot, false)
+
+	roots := [][32]byte{}
+	parent := genesisRoot
+	for _, slot := range []uint64{1, 2, 4} {
+		parent = testutil.ImportEmptyBlock(t, fc, slot, parent)
+		roots = append(roots, parent)
+	}
+	fork := testutil.ImportEmptyBlock(t, fc, 3, roots[1])
+
+	chain, err := replay.Ancestry(db, genesisRoot, roots[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chain) != 3 || chain[0] != roots[0] || chain[2] != roots[2] {
+		t.Fatalf("ancestry = %x, want %x", chain, roots)
+	}
+	res, err := replay.Segment(db, genesisRoot, chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := fc.GetState(roots[2])
+	wantRoot, _ := want.HashTreeRoot()
+	if got, _ := res.State.HashTreeRoot(); got != wantRoot || res.Root != roots[2] || res.Blocks != 3 || res.Checked != 3 {
+		t.Fatalf("result = %+v", res)
+	}
+
+	if _, err := replay.Ancestry(db, fork, roots[2]); !errors.Is(err, replay.ErrNotLinked) {
+		t.Fatalf("ancestry across forks: err = %v", err)
+	}
+	if _, err := replay.Segment(db, genesisRoot, [][32]byte{roots[0], fork}); !errors.Is(err, replay.ErrNotLinked) {
+		t.Fatalf("unlinked segment: err = %v", err)
+	}
+	if _, err := replay.Segment(db, genesisRoot, [][32]byte{{9}}); !errors.Is(err, replay.ErrUnknownBlock) {
+		t.Fatalf("unknown block: err = %v", err)
+	}
+
+	// A corrupted stored state is caught, and the replay stops before it.
+	bad := want.Copy()
+	bad.LatestFinalized = &types.Checkpoint{Root: [32]byte{7}, Slot: 1}
+	db.PutState(roots[2], bad)
+	res, err = replay.Segment(db, genesisRoot, chain)
+	if !errors.Is(err, replay.ErrStoredStateMismatch) {
+		t.Fatalf("corrupt state: err = %v", err)
+	}
+	if res.Root != roots[1] || res.Blocks != 2 {
+		t.Fatalf("partial result = %+v, want two blocks", res)
+	}
+}
@@ -0,0 +1,66 @@
+package statetransition
+
+import "errors"
+
+// ErrorCode classifies why the state transition rejected a block, for
+// callers that report or count failures: gossip validation, the API and the
+// spec tests. Codes are stable strings; the error messages are not.
+type ErrorCode string
+
+const (
+	CodeSlotNotAfterState       ErrorCode = "slot_not_after_state"
+	CodeBlockSlotMismatch       ErrorCode = "block_slot_mismatch"
+	CodeBlockNotNewer           ErrorCode = "block_not_newer"
+	CodeWrongProposer           ErrorCode = "wrong_proposer"
+	CodeParentMismatch          ErrorCode = "parent_mismatch"
+	CodeStateRootMismatch       ErrorCode = "state_root_mismatch"
+	CodeOperationsDisabled      ErrorCode = "operations_disabled"
+	CodeDuplicateDeposit        ErrorCode = "duplicate_deposit"
+	CodeRegistryFull            ErrorCode = "registry_full"
+	CodeUnknownValidator        ErrorCode = "unknown_validator"
+	CodeValidatorNotActive      ErrorCode = "validator_not_active"
+	CodeInvalidDeposit          ErrorCode = "invalid_deposit"
+	CodeInvalidExit             ErrorCode = "invalid_exit"
+	CodeInvalidProposerSlashing ErrorCode = "invalid_proposer_slashing"
+	CodeInvalidAttesterSlashing ErrorCode = "invalid_attester_slashing"
+	CodeNotSlashable            ErrorCode = "not_slashable"
+	// CodeOther is any error that is not a state transition rejection,
+	// such as one returned by an epoch hook.
+	CodeOther ErrorCode = "other"
+)
+
+var errorCodes = []struct {
+	err  error
+	code ErrorCode
+}{
+	{ErrSlotNotAfterState, CodeSlotNotAfterState},
+	{ErrBlockSlotMismatch, CodeBlockSlotMismatch},
+	{ErrBlockNotNewer, CodeBlockNotNewer},
+	{ErrWrongProposer, CodeWrongProposer},
+	{ErrParentMismatch, CodeParentMismatch},
+	{ErrStateRootMismatch, CodeStateRootMismatch},
+	{ErrOperationsDisabled, CodeOperationsDisabled},
+	{ErrDuplicateDeposit, CodeDuplicateDeposit},
+	{ErrRegistryFull, CodeRegistryFull},
+	{ErrUnknownValidator, CodeUnknownValidator},
+	{ErrValidatorNotActive, CodeValidatorNotActive},
+	{ErrInvalidDeposit, CodeInvalidDeposit},
+	{ErrInvalidExit, CodeInvalidExit},
+	{ErrInvalidProposerSlashing, CodeInvalidProposerSlashing},
+	{ErrInvalidAttesterSlashing, CodeInvalidAttesterSlashing},
+	{ErrNotSlashable, CodeNotSlashable},
+}
+
+// Code returns the code of the state transition error wrapped in err, or
+// CodeOther if it wraps none. A nil err has the empty code.
+func Code(err error) ErrorCode {
+	if err == nil {
+		return ""
+	}
+	for _, c := range errorCodes {
+		if errors.Is(err, c.err) {
+			return c.code
+		}
+	}
+	return CodeOther
+}
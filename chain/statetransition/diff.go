@@ -0,0 +1,142 @@
+package statetransition
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+
+	"github.com/geanlabs/gean/types"
+)
+
+// FieldDiff is one difference between two states. Path names the field as
+// the spec does, with the index of a list element or bitlist bit, e.g.
+// "historical_block_hashes[12]", "justified_slots[5]" or
+// "validators[3].exit_epoch". Pre and Post render the value in each state;
+// an element missing from the shorter list renders as "".
+type FieldDiff struct {
+	Path string
+	Pre  string
+	Post string
+}
+
+func (d FieldDiff) String() string {
+	return fmt.Sprintf("%s: %s -> %s", d.Path, orAbsent(d.Pre), orAbsent(d.Post))
+}
+
+func orAbsent(v string) string {
+	if v == "" {
+		return "<absent>"
+	}
+	return v
+}
+
+// Diff lists every difference between pre and post, field by field in SSZ
+// container order and by ascending index within lists, so two runs over the
+// same states print the same diff. It returns nil if the states are equal.
+func Diff(pre, post *types.State) []FieldDiff {
+	d := &differ{}
+	d.value("config.genesis_time", pre.Config.GenesisTime, post.Config.GenesisTime)
+	d.value("slot", pre.Slot, post.Slot)
+	d.header("latest_block_header", pre.LatestBlockHeader, post.LatestBlockHeader)
+	d.checkpoint("latest_justified", pre.LatestJustified, post.LatestJustified)
+	d.checkpoint("latest_finalized", pre.LatestFinalized, post.LatestFinalized)
+	d.roots("historical_block_hashes", pre.HistoricalBlockHashes, post.HistoricalBlockHashes)
+	d.bits("justified_slots", pre.JustifiedSlots, post.JustifiedSlots)
+	d.validators(pre.Validators, post.Validators)
+	d.roots("justifications_roots", pre.JustificationsRoots, post.JustificationsRoots)
+	d.bits("justifications_validators", pre.JustificationsValidators, post.JustificationsValidators)
+	return d.diffs
+}
+
+type differ struct {
+	diffs []FieldDiff
+}
+
+func (d *differ) add(path, pre, post string) {
+	if pre != post {
+		d.diffs = append(d.diffs, FieldDiff{Path: path, Pre: pre, Post: post})
+	}
+}
+
+func (d *differ) value(path string, pre, post uint64) {
+	d.add(path, strconv.FormatUint(pre, 10), strconv.FormatUint(post, 10))
+}
+
+func (d *differ) root(path string, pre, post [32]byte) {
+	d.add(path, rootHex(pre), rootHex(post))
+}
+
+func (d *differ) header(path string, pre, post *types.BlockHeader) {
+	d.value(path+".slot", pre.Slot, post.Slot)
+	d.value(path+".proposer_index", pre.ProposerIndex, post.ProposerIndex)
+	d.root(path+".parent_root", pre.ParentRoot, post.ParentRoot)
+	d.root(path+".state_root", pre.StateRoot, post.StateRoot)
+	d.root(path+".body_root", pre.BodyRoot, post.BodyRoot)
+}
+
+func (d *differ) checkpoint(path string, pre, post *types.Checkpoint) {
+	d.root(path+".root", pre.Root, post.Root)
+	d.value(path+".slot", pre.Slot, post.Slot)
+}
+
+func (d *differ) roots(path string, pre, post [][32]byte) {
+	for i := range max(len(pre), len(post)) {
+		var a, b string
+		if i < len(pre) {
+			a = rootHex(pre[i])
+		}
+		if i < len(post) {
+			b = rootHex(post[i])
+		}
+		d.add(fmt.Sprintf("%s[%d]", path, i), a, b)
+	}
+}
+
+func (d *differ) bits(path string, pre, post []byte) {
+	preLen, postLen := BitlistLen(pre), BitlistLen(post)
+	for i := range max(preLen, postLen) {
+		var a, b string
+		if i < preLen {
+			a = bitString(GetBit(pre, uint64(i)))
+		}
+		if i < postLen {
+			b = bitString(GetBit(post, uint64(i)))
+		}
+		d.add(fmt.Sprintf("%s[%d]", path, i), a, b)
+	}
+}
+
+func (d *differ) validators(pre, post []*types.Validator) {
+	for i := range max(len(pre), len(post)) {
+		path := fmt.Sprintf("validators[%d]", i)
+		switch {
+		case i >= len(pre):
+			d.add(path, "", validatorString(post[i]))
+		case i >= len(post):
+			d.add(path, validatorString(pre[i]), "")
+		default:
+			a, b := pre[i], post[i]
+			d.add(path+".pubkey", "0x"+hex.EncodeToString(a.Pubkey[:]), "0x"+hex.EncodeToString(b.Pubkey[:]))
+			d.value(path+".index", a.Index, b.Index)
+			d.value(path+".activation_epoch", a.ActivationEpoch, b.ActivationEpoch)
+			d.value(path+".exit_epoch", a.ExitEpoch, b.ExitEpoch)
+			d.add(path+".slashed", strconv.FormatBool(a.Slashed), strconv.FormatBool(b.Slashed))
+		}
+	}
+}
+
+func rootHex(r [32]byte) string {
+	return "0x" + hex.EncodeToString(r[:])
+}
+
+func bitString(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+func validatorString(v *types.Validator) string {
+	return fmt.Sprintf("{pubkey=0x%x index=%d activation_epoch=%d exit_epoch=%d slashed=%t}",
+		v.Pubkey, v.Index, v.ActivationEpoch, v.ExitEpoch, v.Slashed)
+}
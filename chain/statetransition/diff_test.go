@@ -0,0 +1,46 @@
+package statetransition_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/geanlabs/gean/chain/statetransition"
+	"github.com/geanlabs/gean/internal/testutil"
+	"github.com/geanlabs/gean/types"
+)
+
+func TestStateDiffListsEveryDifference(t *testing.T) {
+	genesis := statetransition.GenerateGenesis(1000, testutil.Validators(3))
+	if diffs := statetransition.Diff(genesis, genesis.Copy()); diffs != nil {
+		t.Fatalf("equal states differ: %v", diffs)
+	}
+
+	other := genesis.Copy()
+	other.Slot = 4
+	other.LatestJustified.Root = [32]byte{1}
+	other.HistoricalBlockHashes = append(other.HistoricalBlockHashes, [32]byte{2})
+	other.JustifiedSlots = statetransition.AppendBit(statetransition.AppendBit(statetransition.CloneBitlist(other.JustifiedSlots), false), true)
+	other.Validators[2] = &types.Validator{Index: 2, ExitEpoch: 7}
+
+	root := func(r [32]byte) string { return "0x" + hex.EncodeToString(r[:]) }
+	want := []statetransition.FieldDiff{
+		{Path: "slot", Pre: "0", Post: "4"},
+		{Path: "latest_justified.root", Pre: root(genesis.LatestJustified.Root), Post: root([32]byte{1})},
+		{Path: "historical_block_hashes[0]", Pre: "", Post: root([32]byte{2})},
+		{Path: "justified_slots[0]", Pre: "", Post: "0"},
+		{Path: "justified_slots[1]", Pre: "", Post: "1"},
+		{Path: "validators[2].exit_epoch", Pre: "0", Post: "7"},
+	}
+	got := statetransition.Diff(genesis, other)
+	if len(got) != len(want) {
+		t.Fatalf("got %d diffs, want %d: %v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("diff %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+	if s := got[2].String(); s != "historical_block_hashes[0]: <absent> -> "+root([32]byte{2}) {
+		t.Errorf("String() = %q", s)
+	}
+}
@@ -0,0 +1,70 @@
+package statetransition
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/geanlabs/gean/types"
+)
+
+// EpochHook is one step of epoch processing, such as validator activations
+// or rewards. It gets the state at the last slot of an epoch and returns the
+// state to continue with. Like the rest of the state transition it must not
+// write the input state's lists in place (see types.State.ShallowCopy), and
+// it must be deterministic, since every node has to reach the same state.
+type EpochHook func(state *types.State) (*types.State, error)
+
+type epochHook struct {
+	name string
+	hook EpochHook
+}
+
+var (
+	epochHooksMu sync.RWMutex
+	epochHooks   []*epochHook
+)
+
+// RegisterEpochHook adds a step to epoch processing, run after the steps
+// registered before it. It returns a function that removes the step again.
+// Steps change consensus, so they are registered at startup, before any
+// state is processed.
+func RegisterEpochHook(name string, hook EpochHook) (unregister func()) {
+	h := &epochHook{name: name, hook: hook}
+	epochHooksMu.Lock()
+	epochHooks = append(epochHooks, h)
+	epochHooksMu.Unlock()
+
+	return func() {
+		epochHooksMu.Lock()
+		defer epochHooksMu.Unlock()
+		for i, other := range epochHooks {
+			if other == h {
+				epochHooks = append(epochHooks[:i:i], epochHooks[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// IsEpochEnd reports whether slot is the last slot of its epoch.
+func IsEpochEnd(slot uint64) bool {
+	return (slot+1)%types.SlotsPerEpoch == 0
+}
+
+// ProcessEpoch runs the registered epoch hooks in order on the state at the
+// last slot of an epoch. ProcessSlots calls it before leaving that slot.
+func ProcessEpoch(state *types.State) (*types.State, error) {
+	epochHooksMu.RLock()
+	hooks := epochHooks
+	epochHooksMu.RUnlock()
+
+	s := state
+	for _, h := range hooks {
+		next, err := h.hook(s)
+		if err != nil {
+			return nil, fmt.Errorf("epoch hook %s at slot %d: %w", h.name, s.Slot, err)
+		}
+		s = next
+	}
+	return s, nil
+}
@@ -0,0 +1,44 @@
+package statetransition_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/geanlabs/gean/chain/statetransition"
+	"github.com/geanlabs/gean/internal/testutil"
+	"github.com/geanlabs/gean/types"
+)
+
+func TestEpochHooksRunAtEpochEnds(t *testing.T) {
+	genesis := statetransition.GenerateGenesis(1000, testutil.Validators(3))
+
+	var slots []uint64
+	unregister := statetransition.RegisterEpochHook("record", func(s *types.State) (*types.State, error) {
+		slots = append(slots, s.Slot)
+		return s, nil
+	})
+	if _, err := statetransition.ProcessSlots(genesis, 2*types.SlotsPerEpoch+5); err != nil {
+		t.Fatal(err)
+	}
+	if want := []uint64{types.SlotsPerEpoch - 1, 2*types.SlotsPerEpoch - 1}; len(slots) != 2 || slots[0] != want[0] || slots[1] != want[1] {
+		t.Fatalf("hook ran at slots %v, want %v", slots, want)
+	}
+
+	failing := errors.New("boom")
+	unregisterFailing := statetransition.RegisterEpochHook("fail", func(*types.State) (*types.State, error) {
+		return nil, failing
+	})
+	if _, err := statetransition.ProcessSlots(genesis, types.SlotsPerEpoch-1); err != nil {
+		t.Fatalf("hooks ran before the epoch ended: %v", err)
+	}
+	if _, err := statetransition.ProcessSlots(genesis, types.SlotsPerEpoch); !errors.Is(err, failing) {
+		t.Fatalf("err = %v, want the hook's error", err)
+	}
+
+	unregisterFailing()
+	unregister()
+	slots = nil
+	if _, err := statetransition.ProcessSlots(genesis, types.SlotsPerEpoch); err != nil || len(slots) != 0 {
+		t.Fatalf("unregistered hooks still ran: err %v, slots %v", err, slots)
+	}
+}
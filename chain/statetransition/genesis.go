@@ -1,9 +1,62 @@
 package statetransition
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/geanlabs/gean/types"
 )
 
+// ErrInvalidGenesisConfig is returned for a genesis config that cannot
+// produce a usable chain.
+var ErrInvalidGenesisConfig = errors.New("invalid genesis config")
+
+// GenesisConfig holds the per-devnet parameters of a genesis state.
+type GenesisConfig struct {
+	GenesisTime uint64
+	// Preset overrides the reference spec parameters for the devnet; the
+	// zero Preset is types.DefaultPreset.
+	Preset types.Preset
+	// Validators are read for their pubkeys and activation epochs only. A
+	// validator with a later activation epoch is in the registry but cannot
+	// exit or be slashed before it; only chains with dynamic validators may
+	// have one.
+	Validators []*types.Validator
+}
+
+// GenerateGenesisFromConfig validates cfg and returns the genesis state of
+// the devnet, whose chain config commits to the preset. Validators are
+// indexed in the order given.
+func GenerateGenesisFromConfig(cfg *GenesisConfig) (*types.State, error) {
+	preset := cfg.Preset
+	if preset == (types.Preset{}) {
+		preset = types.DefaultPreset
+	}
+	if err := preset.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidGenesisConfig, err)
+	}
+	if len(cfg.Validators) == 0 {
+		return nil, fmt.Errorf("%w: no validators", ErrInvalidGenesisConfig)
+	}
+	validators := make([]*types.Validator, len(cfg.Validators))
+	for i, v := range cfg.Validators {
+		if v.ActivationEpoch >= types.FarFutureEpoch {
+			return nil, fmt.Errorf("%w: validator %d never activates", ErrInvalidGenesisConfig, i)
+		}
+		if v.ActivationEpoch != 0 && !preset.DynamicValidators {
+			return nil, fmt.Errorf("%w: validator %d activates late without dynamic validators", ErrInvalidGenesisConfig, i)
+		}
+		validators[i] = &types.Validator{
+			Pubkey:          v.Pubkey,
+			Index:           uint64(i),
+			ActivationEpoch: v.ActivationEpoch,
+		}
+	}
+	state := GenerateGenesis(cfg.GenesisTime, validators)
+	state.Config = types.NewConfig(cfg.GenesisTime, preset)
+	return state, nil
+}
+
 // GenerateGenesis creates a genesis state with the given parameters.
 func GenerateGenesis(genesisTime uint64, validators []*types.Validator) *types.State {
 	config := &types.Config{
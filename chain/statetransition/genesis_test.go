@@ -0,0 +1,90 @@
+package statetransition_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/geanlabs/gean/chain/forkchoice"
+	"github.com/geanlabs/gean/chain/statetransition"
+	"github.com/geanlabs/gean/internal/testutil"
+	"github.com/geanlabs/gean/storage/memory"
+	"github.com/geanlabs/gean/types"
+)
+
+func TestGenerateGenesisFromConfig(t *testing.T) {
+	validators := testutil.Validators(3)
+	st, err := statetransition.GenerateGenesisFromConfig(&statetransition.GenesisConfig{
+		GenesisTime: 1000,
+		Preset:      types.Preset{SecondsPerSlot: 12, JustificationLookback: 5},
+		Validators:  validators,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The chain config commits to the preset.
+	if p := st.Config.Preset(); p.SecondsPerSlot != 12 || p.SecondsPerInterval() != 3 || p.JustificationLookback != 5 {
+		t.Fatalf("committed preset %+v", p)
+	}
+	// Fork choice times the chain by the committed preset.
+	stateRoot, _ := st.HashTreeRoot()
+	fc := forkchoice.NewStore(st, &types.Block{StateRoot: stateRoot, Body: &types.BlockBody{Attestations: []*types.Attestation{}}}, memory.New())
+	if fc.Preset() != st.Config.Preset() {
+		t.Fatalf("fork choice preset %+v, want %+v", fc.Preset(), st.Config.Preset())
+	}
+	fc.AdvanceTime(1000+12, false)
+	if fc.Time() != types.IntervalsPerSlot {
+		t.Fatalf("store time %d after one 12s slot, want %d intervals", fc.Time(), types.IntervalsPerSlot)
+	}
+	spec, _ := statetransition.GenerateGenesis(1000, st.Validators).HashTreeRoot()
+	if got, _ := st.HashTreeRoot(); got == spec {
+		t.Fatal("preset overrides do not change the genesis state root")
+	}
+
+	// The zero preset is the reference one, and its chain the spec chain.
+	st, err = statetransition.GenerateGenesisFromConfig(&statetransition.GenesisConfig{GenesisTime: 1000, Validators: validators})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := st.HashTreeRoot(); got != spec {
+		t.Fatalf("state root %x, want the spec root %x", got, spec)
+	}
+	if st.Config.Preset() != types.DefaultPreset {
+		t.Fatalf("committed preset %+v, want default", st.Config.Preset())
+	}
+}
+
+func TestGenerateGenesisWithLateActivation(t *testing.T) {
+	validators := testutil.Validators(3)
+	validators[2] = &types.Validator{Pubkey: [52]byte{7}, ActivationEpoch: 4}
+	preset := types.DefaultPreset
+	preset.DynamicValidators = true
+	st, err := statetransition.GenerateGenesisFromConfig(&statetransition.GenesisConfig{GenesisTime: 1000, Preset: preset, Validators: validators})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := st.Validators[2]
+	if v.Pubkey != [52]byte{7} || v.Index != 2 || v.ActivationEpoch != 4 || v.HasExited() {
+		t.Fatalf("validator 2 = %+v", v)
+	}
+	if !st.Config.Preset().DynamicValidators {
+		t.Fatal("dynamic validators not committed to the chain config")
+	}
+
+	// A spec chain has a fixed registry, active from genesis.
+	if _, err := statetransition.GenerateGenesisFromConfig(&statetransition.GenesisConfig{GenesisTime: 1000, Validators: validators}); !errors.Is(err, statetransition.ErrInvalidGenesisConfig) {
+		t.Fatalf("late activation without dynamic validators: err = %v", err)
+	}
+}
+
+func TestGenerateGenesisFromConfigRejects(t *testing.T) {
+	for name, cfg := range map[string]*statetransition.GenesisConfig{
+		"no validators":   {Preset: types.DefaultPreset},
+		"uneven slots":    {Preset: types.Preset{SecondsPerSlot: 5}, Validators: testutil.Validators(1)},
+		"never activates": {Validators: []*types.Validator{{ActivationEpoch: types.FarFutureEpoch}}},
+	} {
+		if _, err := statetransition.GenerateGenesisFromConfig(cfg); !errors.Is(err, statetransition.ErrInvalidGenesisConfig) {
+			t.Errorf("%s: err = %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package statetransition_test
+
+import (
+	"testing"
+
+	"github.com/geanlabs/gean/chain/statetransition"
+	"github.com/geanlabs/gean/internal/testutil"
+	"github.com/geanlabs/gean/types"
+)
+
+// votesFor returns attestations from validators [from, to) with target slot
+// on the chain of st, whose latest header is the block at slot+1's parent.
+func votesFor(st *types.State, from, to, slot uint64, target [32]byte) []*types.Attestation {
+	source := &types.Checkpoint{Root: st.HistoricalBlockHashes[0], Slot: 0}
+	atts := make([]*types.Attestation, 0, to-from)
+	for v := from; v < to; v++ {
+		cp := &types.Checkpoint{Root: target, Slot: slot}
+		atts = append(atts, &types.Attestation{
+			ValidatorID: v,
+			Data:        &types.AttestationData{Slot: slot + 1, Head: cp, Target: cp, Source: source},
+		})
+	}
+	return atts
+}
+
+func TestJustificationVotesWithLargeRegistry(t *testing.T) {
+	const n = 1000
+	genesis := statetransition.GenerateGenesis(1000, testutil.Validators(n))
+	s1, err := testutil.ApplyBody(t, genesis, 1, &types.BlockBody{Attestations: []*types.Attestation{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 600 of 1000 votes leave slot 1 pending.
+	root1 := testutil.HeaderRootAt(t, s1, 2)
+	s2, err := testutil.ApplyBody(t, s1, 2, &types.BlockBody{Attestations: votesFor(s1, 0, 600, 1, root1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s2.JustificationsRoots) != 1 || s2.JustificationsRoots[0] != root1 || s2.LatestJustified.Slot != 0 {
+		t.Fatalf("pending roots %x, justified slot %d", s2.JustificationsRoots, s2.LatestJustified.Slot)
+	}
+
+	// Repeated and new votes justify slot 1; votes for slot 2 stay pending.
+	root2 := testutil.HeaderRootAt(t, s2, 3)
+	atts := append(votesFor(s2, 550, 700, 1, root1), votesFor(s2, 0, 10, 2, root2)...)
+	s3, err := testutil.ApplyBody(t, s2, 3, &types.BlockBody{Attestations: atts})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s3.LatestJustified.Root != root1 || s3.LatestJustified.Slot != 1 {
+		t.Fatalf("justified %+v, want slot 1", s3.LatestJustified)
+	}
+	if len(s3.JustificationsRoots) != 1 || s3.JustificationsRoots[0] != root2 {
+		t.Fatalf("pending roots %x, want only slot 2", s3.JustificationsRoots)
+	}
+	if bits := statetransition.BitlistLen(s3.JustificationsValidators); bits != n {
+		t.Fatalf("pending votes span %d bits, want %d", bits, n)
+	}
+	for v := uint64(0); v < 20; v++ {
+		if got := statetransition.GetBit(s3.JustificationsValidators, v); got != (v < 10) {
+			t.Fatalf("vote of validator %d = %v", v, got)
+		}
+	}
+	if s2.JustificationsRoots[0] != root1 || statetransition.GetBit(s2.JustificationsValidators, 650) {
+		t.Fatal("processing votes changed the parent state")
+	}
+}
+
+func TestJustificationCountsActiveValidators(t *testing.T) {
+	validators := testutil.Validators(6)
+	for _, v := range validators[3:] {
+		v.ActivationEpoch = 100
+	}
+	genesis := testutil.DynamicGenesis(validators)
+	s1, err := testutil.ApplyBody(t, genesis, 1, &types.BlockBody{Attestations: []*types.Attestation{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Votes of validators not yet active are not recorded.
+	root1 := testutil.HeaderRootAt(t, s1, 2)
+	s2, err := testutil.ApplyBody(t, s1, 2, &types.BlockBody{Attestations: votesFor(s1, 2, 6, 1, root1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for v := uint64(3); v < 6; v++ {
+		if statetransition.GetBit(s2.JustificationsValidators, v) {
+			t.Fatalf("vote of inactive validator %d recorded", v)
+		}
+	}
+	if s2.LatestJustified.Slot != 0 {
+		t.Fatalf("justified slot %d with one active vote", s2.LatestJustified.Slot)
+	}
+
+	// Two of the three active validators are a supermajority.
+	s3, err := testutil.ApplyBody(t, s2, 3, &types.BlockBody{Attestations: votesFor(s2, 1, 2, 1, root1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s3.LatestJustified.Root != root1 || s3.LatestJustified.Slot != 1 {
+		t.Fatalf("justified %+v, want slot 1", s3.LatestJustified)
+	}
+}
+
+func BenchmarkProcessAttestations(b *testing.B) {
+	const n = 4096
+	genesis := statetransition.GenerateGenesis(1000, testutil.Validators(n))
+	pre, err := statetransition.ProcessSlots(genesis, 1)
+	if err != nil {
+		b.Fatal(err)
+	}
+	block := &types.Block{Slot: 1, ProposerIndex: 1, ParentRoot: testutil.HeaderRootAt(b, genesis, 1), Body: &types.BlockBody{}}
+	if pre, err = statetransition.ProcessBlock(pre, block); err != nil {
+		b.Fatal(err)
+	}
+	st, err := statetransition.ProcessSlots(pre, 2)
+	if err != nil {
+		b.Fatal(err)
+	}
+	block = &types.Block{Slot: 2, ProposerIndex: 2, ParentRoot: testutil.HeaderRootAt(b, pre, 2), Body: &types.BlockBody{}}
+	if st, err = statetransition.ProcessBlockHeader(st, block); err != nil {
+		b.Fatal(err)
+	}
+	atts := votesFor(st, 0, n/2, 1, st.HistoricalBlockHashes[1])
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		statetransition.ProcessAttestations(st, atts)
+	}
+}
@@ -0,0 +1,201 @@
+package statetransition
+
+import (
+	"bytes"
+	"math/bits"
+	"sort"
+
+	"github.com/geanlabs/gean/types"
+)
+
+// justificationTally reads and updates the pending justification votes of a
+// state in their packed form: the votes for roots[i] are the numValidators
+// bits of votes starting at i*numValidators. Nothing is unpacked; the votes
+// are copied on the first write, and re-packed only when roots are added or
+// removed. Votes of validators inactive at the state slot are kept but not
+// counted.
+type justificationTally struct {
+	numValidators uint64
+	inactive      []uint64 // validators whose votes do not count
+	roots         [][32]byte
+	votes         []byte
+	owned         bool // votes is a private copy
+	changed       bool // roots must be re-packed in sorted order
+
+	index  map[[32]byte]uint64 // live root -> position in roots
+	counts map[[32]byte]uint64 // votes per root, for the roots counted so far
+}
+
+func newJustificationTally(state *types.State) *justificationTally {
+	n := uint64(len(state.Validators))
+	t := &justificationTally{
+		numValidators: n,
+		roots:         state.JustificationsRoots,
+		votes:         state.JustificationsValidators,
+		index:         make(map[[32]byte]uint64, len(state.JustificationsRoots)),
+		counts:        make(map[[32]byte]uint64),
+	}
+	epoch := types.EpochAt(state.Slot)
+	for i, v := range state.Validators {
+		if !v.IsActive(epoch) {
+			t.inactive = append(t.inactive, uint64(i))
+		}
+	}
+	for i, root := range t.roots {
+		t.index[root] = uint64(i) // a repeated root keeps its last votes
+	}
+	if len(t.index) != len(t.roots) || !sort.SliceIsSorted(t.roots, func(i, j int) bool {
+		return bytes.Compare(t.roots[i][:], t.roots[j][:]) < 0
+	}) {
+		t.changed = true
+	}
+	if want := uint64(len(t.roots)) * n; len(t.votes) == 0 || uint64(BitlistLen(t.votes)) != want {
+		// Pad or cut a malformed bitlist to one stride per root.
+		votes := MakeBitlist(want)
+		copyBits(votes, 0, t.votes, 0, min(want, uint64(BitlistLen(t.votes))))
+		t.votes, t.owned = votes, true
+	}
+	return t
+}
+
+// vote records validator's vote for root and reports whether it is new. The
+// validator must be active.
+func (t *justificationTally) vote(root [32]byte, validator uint64) bool {
+	pos, ok := t.index[root]
+	if !ok {
+		pos = t.addRoot(root)
+	}
+	bit := pos*t.numValidators + validator
+	if GetBit(t.votes, bit) {
+		return false
+	}
+	if !t.owned {
+		t.votes, t.owned = CloneBitlist(t.votes), true
+	}
+	SetBit(t.votes, bit, true)
+	if c, ok := t.counts[root]; ok {
+		t.counts[root] = c + 1
+	}
+	return true
+}
+
+// numActive returns the number of validators whose votes count.
+func (t *justificationTally) numActive() uint64 {
+	return t.numValidators - uint64(len(t.inactive))
+}
+
+// count returns the number of active validators that voted for root.
+func (t *justificationTally) count(root [32]byte) uint64 {
+	if c, ok := t.counts[root]; ok {
+		return c
+	}
+	pos, ok := t.index[root]
+	if !ok {
+		return 0
+	}
+	c := countBits(t.votes, pos*t.numValidators, t.numValidators)
+	for _, i := range t.inactive {
+		if GetBit(t.votes, pos*t.numValidators+i) {
+			c--
+		}
+	}
+	t.counts[root] = c
+	return c
+}
+
+// remove drops root and its votes.
+func (t *justificationTally) remove(root [32]byte) {
+	if _, ok := t.index[root]; !ok {
+		return
+	}
+	delete(t.index, root)
+	delete(t.counts, root)
+	t.changed = true
+}
+
+// addRoot appends root with no votes and returns its position.
+func (t *justificationTally) addRoot(root [32]byte) uint64 {
+	pos := uint64(len(t.roots))
+	t.roots = append(t.roots[:pos:pos], root)
+	t.votes, t.owned = growBitlist(t.votes, (pos+1)*t.numValidators), true
+	t.index[root] = pos
+	t.counts[root] = 0
+	t.changed = true
+	return pos
+}
+
+// pack returns the roots, sorted, and their packed votes.
+func (t *justificationTally) pack() ([][32]byte, []byte) {
+	if !t.changed {
+		return t.roots, t.votes
+	}
+	roots := make([][32]byte, 0, len(t.index))
+	for root := range t.index {
+		roots = append(roots, root)
+	}
+	sort.Slice(roots, func(i, j int) bool {
+		return bytes.Compare(roots[i][:], roots[j][:]) < 0
+	})
+	n := t.numValidators
+	votes := MakeBitlist(uint64(len(roots)) * n)
+	for i, root := range roots {
+		copyBits(votes, uint64(i)*n, t.votes, t.index[root]*n, n)
+	}
+	return roots, votes
+}
+
+// growBitlist returns a copy of bl extended with zero bits to numBits.
+func growBitlist(bl []byte, numBits uint64) []byte {
+	oldBits := uint64(BitlistLen(bl))
+	out := MakeBitlist(numBits)
+	copy(out, bl[:oldBits/8+1])
+	out[oldBits/8] &^= 1 << (oldBits % 8)
+	out[numBits/8] |= 1 << (numBits % 8)
+	return out
+}
+
+// restrideVotes re-packs the votes of numRoots roots from from bits per root
+// to to bits per root, for a registry that grew from from to to validators.
+func restrideVotes(votes []byte, numRoots, from, to uint64) []byte {
+	out := MakeBitlist(numRoots * to)
+	for i := uint64(0); i < numRoots; i++ {
+		copyBits(out, i*to, votes, i*from, from)
+	}
+	return out
+}
+
+// copyBits sets the n bits of dst starting at dstOff that are set in src
+// starting at srcOff. Bytes of src without a vote are skipped whole.
+func copyBits(dst []byte, dstOff uint64, src []byte, srcOff, n uint64) {
+	for i := uint64(0); i < n; {
+		at := srcOff + i
+		if at%8 == 0 && n-i >= 8 && (at/8 >= uint64(len(src)) || src[at/8] == 0) {
+			i += 8
+			continue
+		}
+		if GetBit(src, at) {
+			SetBit(dst, dstOff+i, true)
+		}
+		i++
+	}
+}
+
+// countBits returns how many of the n bits of bl starting at off are set.
+func countBits(bl []byte, off, n uint64) uint64 {
+	var c uint64
+	for i := uint64(0); i < n; {
+		at := off + i
+		if at%8 == 0 && n-i >= 8 {
+			if at/8 < uint64(len(bl)) {
+				c += uint64(bits.OnesCount8(bl[at/8]))
+			}
+			i += 8
+			continue
+		}
+		if GetBit(bl, at) {
+			c++
+		}
+		i++
+	}
+	return c
+}
@@ -0,0 +1,128 @@
+package statetransition
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/geanlabs/gean/types"
+	"github.com/geanlabs/gean/xmss/leansig"
+)
+
+// Errors returned for blocks whose validator set changes are invalid.
+var (
+	ErrDuplicateDeposit   = errors.New("deposit for a registered key")
+	ErrRegistryFull       = errors.New("validator registry is full")
+	ErrUnknownValidator   = errors.New("exit for an unknown validator")
+	ErrValidatorNotActive = errors.New("exit for an inactive validator")
+	ErrInvalidDeposit     = errors.New("invalid deposit")
+	ErrInvalidExit        = errors.New("invalid exit")
+)
+
+// verifyOperation checks that sig signs root with pubkey at slot, which may
+// not be after the state's. Unlike attestations, deposits and exits are
+// checked by the state transition itself, since they change the registry
+// whose keys every other signature is checked against.
+func verifyOperation(state *types.State, pubkey [types.XMSSPubkeySize]byte, slot uint64, root [32]byte, sig [types.XMSSSignatureSize]byte) error {
+	if slot > state.Slot {
+		return fmt.Errorf("signed at slot %d, after block slot %d", slot, state.Slot)
+	}
+	if slot > math.MaxUint32 {
+		return fmt.Errorf("slot %d out of signature range", slot)
+	}
+	if !verifySignatures {
+		return nil
+	}
+	if err := leansig.ValidateSignatureEncoding(sig[:]); err != nil {
+		return err
+	}
+	return leansig.Verify(pubkey[:], uint32(slot), root, sig[:])
+}
+
+// ProcessDeposits adds a validator to the registry for each deposit, active
+// from the next epoch. Each deposit must be signed with its key. The
+// justification votes are re-packed for the larger registry; the new
+// validators have not voted.
+func ProcessDeposits(state *types.State, deposits []*types.SignedDeposit) (*types.State, error) {
+	if len(deposits) == 0 {
+		return state, nil
+	}
+	if n := len(state.Validators) + len(deposits); n > types.ValidatorRegistryLimit {
+		return nil, fmt.Errorf("%w: %d validators", ErrRegistryFull, n)
+	}
+
+	registered := make(map[[types.XMSSPubkeySize]byte]bool, len(state.Validators)+len(deposits))
+	for _, v := range state.Validators {
+		registered[v.Pubkey] = true
+	}
+	activation := types.EpochAt(state.Slot) + 1
+	validators := make([]*types.Validator, len(state.Validators), len(state.Validators)+len(deposits))
+	copy(validators, state.Validators)
+	for _, sd := range deposits {
+		d := sd.Message
+		if d == nil {
+			return nil, fmt.Errorf("%w: missing message", ErrInvalidDeposit)
+		}
+		if registered[d.Pubkey] {
+			return nil, fmt.Errorf("%w: %x", ErrDuplicateDeposit, d.Pubkey[:8])
+		}
+		root, err := d.HashTreeRoot()
+		if err != nil {
+			return nil, err
+		}
+		if err := verifyOperation(state, d.Pubkey, d.Slot, root, sd.Signature); err != nil {
+			return nil, fmt.Errorf("%w: %x: %v", ErrInvalidDeposit, d.Pubkey[:8], err)
+		}
+		registered[d.Pubkey] = true
+		validators = append(validators, &types.Validator{
+			Pubkey:          d.Pubkey,
+			Index:           uint64(len(validators)),
+			ActivationEpoch: activation,
+		})
+	}
+
+	out := state.ShallowCopy()
+	out.Validators = validators
+	out.JustificationsValidators = restrideVotes(state.JustificationsValidators,
+		uint64(len(state.JustificationsRoots)), uint64(len(state.Validators)), uint64(len(validators)))
+	return out, nil
+}
+
+// ProcessExits schedules each exiting validator to leave the active set at
+// the next epoch. Each exit must be signed by the validator's key.
+// Validators that are not active, including those already exiting, cannot
+// exit.
+func ProcessExits(state *types.State, exits []*types.SignedVoluntaryExit) (*types.State, error) {
+	if len(exits) == 0 {
+		return state, nil
+	}
+	epoch := types.EpochAt(state.Slot)
+	validators := make([]*types.Validator, len(state.Validators))
+	copy(validators, state.Validators)
+	for _, se := range exits {
+		e := se.Message
+		if e == nil {
+			return nil, fmt.Errorf("%w: missing message", ErrInvalidExit)
+		}
+		if e.ValidatorIndex >= uint64(len(validators)) {
+			return nil, fmt.Errorf("%w: %d", ErrUnknownValidator, e.ValidatorIndex)
+		}
+		v := *validators[e.ValidatorIndex]
+		if !v.IsActive(epoch) || v.HasExited() {
+			return nil, fmt.Errorf("%w: %d", ErrValidatorNotActive, e.ValidatorIndex)
+		}
+		root, err := e.HashTreeRoot()
+		if err != nil {
+			return nil, err
+		}
+		if err := verifyOperation(state, v.Pubkey, e.Slot, root, se.Signature); err != nil {
+			return nil, fmt.Errorf("%w: validator %d: %v", ErrInvalidExit, e.ValidatorIndex, err)
+		}
+		v.ExitEpoch = epoch + 1
+		validators[e.ValidatorIndex] = &v
+	}
+
+	out := state.ShallowCopy()
+	out.Validators = validators
+	return out, nil
+}
@@ -0,0 +1,99 @@
+package statetransition_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/geanlabs/gean/chain/statetransition"
+	"github.com/geanlabs/gean/internal/testutil"
+	"github.com/geanlabs/gean/types"
+)
+
+func TestDepositsAndExitsChangeTheValidatorSet(t *testing.T) {
+	validators := testutil.Validators(3)
+	for i, v := range validators {
+		v.Pubkey[0] = byte(i + 1)
+	}
+	genesis := testutil.DynamicGenesis(validators)
+	pre, err := testutil.ApplyBody(t, genesis, 1, &types.BlockBody{Attestations: []*types.Attestation{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	genesisRoot := pre.LatestJustified.Root
+	pendingRoot := pre.HistoricalBlockHashes[0]
+
+	// A pending vote for the genesis block keeps its place once the registry
+	// grows.
+	pre.JustificationsRoots = [][32]byte{pendingRoot}
+	pre.JustificationsValidators = []byte{0b1010}
+
+	body := &types.BlockBody{
+		Attestations: []*types.Attestation{},
+		Deposits:     []*types.SignedDeposit{testutil.Deposit(9, 1)},
+		Exits:        []*types.SignedVoluntaryExit{testutil.Exit(2, 1)},
+	}
+	slot := uint64(2 * types.SlotsPerEpoch)
+	post, err := testutil.ApplyBody(t, pre, slot, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(post.Validators) != 4 {
+		t.Fatalf("registry has %d validators, want 4", len(post.Validators))
+	}
+	added := post.Validators[3]
+	if added.Pubkey != [52]byte{9} || added.Index != 3 || added.ActivationEpoch != 3 || added.HasExited() {
+		t.Fatalf("deposited validator = %+v", added)
+	}
+	if added.IsActive(2) || !added.IsActive(3) {
+		t.Fatal("deposited validator must activate at the next epoch")
+	}
+	if exited := post.Validators[2]; exited.ExitEpoch != 3 || pre.Validators[2].HasExited() {
+		t.Fatalf("exit epoch = %d, parent state exit epoch = %d", exited.ExitEpoch, pre.Validators[2].ExitEpoch)
+	}
+	for v, want := range []bool{false, true, false, false} {
+		if got := statetransition.GetBit(post.JustificationsValidators, uint64(v)); got != want {
+			t.Fatalf("vote of validator %d = %v, want %v", v, got, want)
+		}
+	}
+	if n := statetransition.BitlistLen(post.JustificationsValidators); n != 4 {
+		t.Fatalf("justification votes span %d bits, want 4", n)
+	}
+	if post.LatestJustified.Root != genesisRoot {
+		t.Fatal("validator set changes moved the justified checkpoint")
+	}
+
+	for _, tc := range []struct {
+		name string
+		body *types.BlockBody
+		want error
+	}{
+		{"duplicate key", &types.BlockBody{Deposits: []*types.SignedDeposit{testutil.Deposit(1, slot)}}, statetransition.ErrDuplicateDeposit},
+		{"deposit signed ahead", &types.BlockBody{Deposits: []*types.SignedDeposit{testutil.Deposit(10, slot+2)}}, statetransition.ErrInvalidDeposit},
+		{"unknown validator", &types.BlockBody{Exits: []*types.SignedVoluntaryExit{testutil.Exit(7, slot)}}, statetransition.ErrUnknownValidator},
+		{"exit signed ahead", &types.BlockBody{Exits: []*types.SignedVoluntaryExit{testutil.Exit(1, slot+2)}}, statetransition.ErrInvalidExit},
+		{"exiting twice", &types.BlockBody{Exits: []*types.SignedVoluntaryExit{testutil.Exit(2, slot)}}, statetransition.ErrValidatorNotActive},
+		{"not yet active", &types.BlockBody{Exits: []*types.SignedVoluntaryExit{testutil.Exit(3, slot)}}, statetransition.ErrValidatorNotActive},
+	} {
+		tc.body.Attestations = []*types.Attestation{}
+		if _, err := testutil.ApplyBody(t, post, slot+1, tc.body); !errors.Is(err, tc.want) {
+			t.Errorf("%s: err = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestOperationsNeedDynamicValidators(t *testing.T) {
+	genesis := statetransition.GenerateGenesis(1000, testutil.Validators(3))
+	body := &types.BlockBody{Attestations: []*types.Attestation{}, Deposits: []*types.SignedDeposit{testutil.Deposit(9, 1)}}
+	st, err := statetransition.ProcessSlots(genesis, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parent, _ := st.LatestBlockHeader.HashTreeRoot()
+	block := &types.Block{Slot: 1, ProposerIndex: 1, ParentRoot: parent, Body: body}
+	if _, err := statetransition.ProcessBlock(st, block); !errors.Is(err, statetransition.ErrOperationsDisabled) {
+		t.Fatalf("err = %v, want %v", err, statetransition.ErrOperationsDisabled)
+	}
+	if _, err := statetransition.ReferenceStateTransition(genesis, block); err == nil {
+		t.Fatal("reference transition accepted operations on a spec chain")
+	}
+}
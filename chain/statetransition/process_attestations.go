@@ -1,9 +1,6 @@
 package statetransition
 
 import (
-	"bytes"
-	"sort"
-
 	"github.com/geanlabs/gean/types"
 )
 
@@ -12,25 +9,20 @@ import (
 //
 // Per-validator votes are tracked via justifications_roots (sorted list of
 // block roots being voted on) and justifications_validators (flat bitlist
-// where each root's validator votes are packed consecutively).
+// where each root's validator votes are packed consecutively). Votes are
+// read and recorded in the packed bitlist without unpacking it. Only
+// validators active at the state slot vote, and the supermajority is of them.
 func ProcessAttestations(state *types.State, attestations []*types.Attestation) *types.State {
 	numValidators := uint64(len(state.Validators))
-
-	// Deserialize justifications from SSZ form into working map.
-	justifications := make(map[[32]byte][]bool)
-	for i, root := range state.JustificationsRoots {
-		votes := make([]bool, numValidators)
-		for v := uint64(0); v < numValidators; v++ {
-			bitIdx := uint64(i)*numValidators + v
-			votes[v] = GetBit(state.JustificationsValidators, bitIdx)
-		}
-		justifications[root] = votes
-	}
+	tally := newJustificationTally(state)
+	numActive := tally.numActive()
+	epoch := types.EpochAt(state.Slot)
 
 	justifiedSlots := CloneBitlist(state.JustifiedSlots)
 	latestJustified := &types.Checkpoint{Root: state.LatestJustified.Root, Slot: state.LatestJustified.Slot}
 	latestFinalized := &types.Checkpoint{Root: state.LatestFinalized.Root, Slot: state.LatestFinalized.Slot}
-	originalFinalizedSlot := state.LatestFinalized.Slot
+	// Justifiability is judged against the finalized slot before this block.
+	justifiable := types.JustifiableAfter(state.LatestFinalized.Slot)
 
 	for _, att := range attestations {
 		source := att.Data.Source
@@ -64,35 +56,24 @@ func ProcessAttestations(state *types.State, attestations []*types.Attestation)
 		}
 
 		// Target must be justifiable after the original finalized slot.
-		if !types.IsJustifiableAfter(tgtSlot, originalFinalizedSlot) {
+		if !justifiable.Contains(tgtSlot) {
 			continue
 		}
 
 		// Validate validator ID.
 		validatorID := att.ValidatorID
-		if validatorID >= numValidators {
+		if validatorID >= numValidators || !state.Validators[validatorID].IsActive(epoch) {
 			continue
 		}
 
 		// Record vote (idempotent — skip if already voted).
-		if _, ok := justifications[target.Root]; !ok {
-			justifications[target.Root] = make([]bool, numValidators)
-		}
-		if justifications[target.Root][validatorID] {
+		if !tally.vote(target.Root, validatorID) {
 			continue
 		}
-		justifications[target.Root][validatorID] = true
-
-		// Count votes for this target.
-		count := uint64(0)
-		for _, voted := range justifications[target.Root] {
-			if voted {
-				count++
-			}
-		}
+		count := tally.count(target.Root)
 
-		// Supermajority: 3 * count >= 2 * numValidators.
-		if 3*count < 2*numValidators {
+		// Supermajority: 3 * count >= 2 * numActive.
+		if 3*count < 2*numActive {
 			continue
 		}
 
@@ -102,71 +83,19 @@ func ProcessAttestations(state *types.State, attestations []*types.Attestation)
 			justifiedSlots = AppendBit(justifiedSlots, false)
 		}
 		justifiedSlots = SetBit(justifiedSlots, tgtSlot, true)
-		delete(justifications, target.Root)
+		tally.remove(target.Root)
 
 		// Finalization: if no justifiable slot exists between source and target,
 		// then source becomes finalized.
-		hasJustifiableGap := false
-		for s := srcSlot + 1; s < tgtSlot; s++ {
-			if types.IsJustifiableAfter(s, originalFinalizedSlot) {
-				hasJustifiableGap = true
-				break
-			}
-		}
-		if !hasJustifiableGap {
+		if !justifiable.AnyBetween(srcSlot+1, tgtSlot) {
 			latestFinalized = &types.Checkpoint{Root: source.Root, Slot: srcSlot}
 		}
 	}
 
-	// Serialize justifications back to SSZ form.
-	sortedRoots := sortedJustificationRoots(justifications)
-	flatVotes := flattenVotes(sortedRoots, justifications, numValidators)
-
-	out := state.Copy()
+	out := state.ShallowCopy()
 	out.JustifiedSlots = justifiedSlots
 	out.LatestJustified = latestJustified
 	out.LatestFinalized = latestFinalized
-	out.JustificationsRoots = sortedRoots
-	out.JustificationsValidators = flatVotes
+	out.JustificationsRoots, out.JustificationsValidators = tally.pack()
 	return out
 }
-
-// sortedJustificationRoots returns the roots in deterministic (lexicographic) order.
-func sortedJustificationRoots(justifications map[[32]byte][]bool) [][32]byte {
-	roots := make([][32]byte, 0, len(justifications))
-	for root := range justifications {
-		roots = append(roots, root)
-	}
-	sort.Slice(roots, func(i, j int) bool {
-		return bytes.Compare(roots[i][:], roots[j][:]) < 0
-	})
-	return roots
-}
-
-// flattenVotes serializes per-root validator votes into a single SSZ bitlist.
-// For each root (in sortedRoots order), numValidators bits are appended.
-func flattenVotes(sortedRoots [][32]byte, justifications map[[32]byte][]bool, numValidators uint64) []byte {
-	totalBits := uint64(len(sortedRoots)) * numValidators
-	if totalBits == 0 {
-		return []byte{0x01} // empty bitlist with sentinel
-	}
-
-	numBytes := (totalBits + 1 + 7) / 8 // +1 for sentinel
-	bl := make([]byte, numBytes)
-
-	bitPos := uint64(0)
-	for _, root := range sortedRoots {
-		votes := justifications[root]
-		for _, voted := range votes {
-			if voted {
-				bl[bitPos/8] |= 1 << (bitPos % 8)
-			}
-			bitPos++
-		}
-	}
-
-	// Set sentinel bit at position totalBits.
-	bl[totalBits/8] |= 1 << (totalBits % 8)
-
-	return bl
-}
@@ -1,10 +1,96 @@
 package statetransition
 
-// IsProposer checks if a validator is the proposer for a given slot using
-// round-robin selection: slot % numValidators == validatorIndex.
-func IsProposer(validatorIndex, slot, numValidators uint64) bool {
+import (
+	"crypto/sha256"
+	"encoding/binary"
+
+	"github.com/geanlabs/gean/types"
+)
+
+// ProposerSelector chooses the proposer of each slot from a registry of
+// numValidators validators.
+type ProposerSelector interface {
+	Proposer(slot, numValidators uint64) uint64
+}
+
+// RoundRobin gives slot s to validator s mod n.
+type RoundRobin struct{}
+
+func (RoundRobin) Proposer(slot, numValidators uint64) uint64 {
+	return slot % numValidators
+}
+
+// Shuffled gives each round of n consecutive slots to a permutation of the n
+// validators, drawn from Seed and the round number with the swap-or-not
+// shuffle. Every validator still proposes once per round, but the order is
+// not known without the seed.
+type Shuffled struct {
+	Seed [32]byte
+}
+
+// shuffleRounds is the number of swap-or-not rounds, as in the beacon chain.
+const shuffleRounds = 90
+
+func (s Shuffled) Proposer(slot, numValidators uint64) uint64 {
+	var buf [40]byte
+	copy(buf[:32], s.Seed[:])
+	binary.LittleEndian.PutUint64(buf[32:], slot/numValidators)
+	return shuffledIndex(slot%numValidators, numValidators, sha256.Sum256(buf[:]))
+}
+
+// shuffledIndex returns the position of index after the swap-or-not shuffle
+// of n elements under seed.
+func shuffledIndex(index, n uint64, seed [32]byte) uint64 {
+	var pivotBuf [33]byte
+	var sourceBuf [37]byte
+	copy(pivotBuf[:], seed[:])
+	copy(sourceBuf[:], seed[:])
+	for round := range shuffleRounds {
+		pivotBuf[32] = byte(round)
+		h := sha256.Sum256(pivotBuf[:])
+		pivot := binary.LittleEndian.Uint64(h[:8]) % n
+		flip := (pivot + n - index) % n
+		pos := max(index, flip)
+		sourceBuf[32] = byte(round)
+		binary.LittleEndian.PutUint32(sourceBuf[33:], uint32(pos/256))
+		source := sha256.Sum256(sourceBuf[:])
+		if source[(pos%256)/8]>>(pos%8)&1 == 1 {
+			index = flip
+		}
+	}
+	return index
+}
+
+// Selector returns the proposer selector of a chain with config cfg.
+func Selector(cfg *types.Config) ProposerSelector {
+	p := cfg.Preset()
+	if p.ProposerSelection == types.ProposerShuffled {
+		return Shuffled{Seed: p.ProposerSeed}
+	}
+	return RoundRobin{}
+}
+
+// ProposerIndex returns the proposer of slot on a chain with config cfg.
+func ProposerIndex(cfg *types.Config, slot, numValidators uint64) uint64 {
 	if numValidators == 0 {
 		panic("numValidators must be > 0")
 	}
-	return slot%numValidators == validatorIndex
+	return Selector(cfg).Proposer(slot, numValidators)
+}
+
+// IsProposer checks if a validator is the proposer for a given slot on a
+// chain with config cfg.
+func IsProposer(cfg *types.Config, validatorIndex, slot, numValidators uint64) bool {
+	return ProposerIndex(cfg, slot, numValidators) == validatorIndex
+}
+
+// StateProposerIndex returns the proposer of slot on the chain of state:
+// the selector runs over the validators active at slot, in registry order.
+// It reports false if no validator is active then.
+func StateProposerIndex(state *types.State, slot uint64) (uint64, bool) {
+	active := state.ActiveValidators(slot)
+	if len(active) == 0 {
+		return 0, false
+	}
+	return active[ProposerIndex(state.Config, slot, uint64(len(active)))], true
 }
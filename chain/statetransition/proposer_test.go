@@ -0,0 +1,118 @@
+package statetransition_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/geanlabs/gean/chain/forkchoice"
+	"github.com/geanlabs/gean/chain/statetransition"
+	"github.com/geanlabs/gean/internal/testutil"
+	"github.com/geanlabs/gean/storage/memory"
+	"github.com/geanlabs/gean/types"
+)
+
+func TestShuffledProposersCoverEachRound(t *testing.T) {
+	const n = 10
+	sel := statetransition.Shuffled{Seed: [32]byte{1}}
+	orders := map[[n]uint64]bool{}
+	for round := uint64(0); round < 4; round++ {
+		var order [n]uint64
+		seen := map[uint64]bool{}
+		for i := range uint64(n) {
+			p := sel.Proposer(round*n+i, n)
+			if p >= n || seen[p] {
+				t.Fatalf("round %d: proposer %d repeated or out of range", round, p)
+			}
+			seen[p], order[i] = true, p
+		}
+		orders[order] = true
+	}
+	if len(orders) < 2 {
+		t.Fatal("every round has the same proposer order")
+	}
+	other := statetransition.Shuffled{Seed: [32]byte{2}}
+	same := true
+	for slot := range uint64(n) {
+		if sel.Proposer(slot, n) != sel.Proposer(slot, n) {
+			t.Fatal("selection is not deterministic")
+		}
+		same = same && sel.Proposer(slot, n) == other.Proposer(slot, n)
+	}
+	if same {
+		t.Fatal("seeds 1 and 2 give the same order")
+	}
+}
+
+func TestShuffledPresetSelectsProposers(t *testing.T) {
+	preset := types.DefaultPreset
+	preset.ProposerSelection = types.ProposerShuffled
+	preset.ProposerSeed = [32]byte{3}
+	genesis, err := statetransition.GenerateGenesisFromConfig(&statetransition.GenesisConfig{
+		GenesisTime: 1000,
+		Preset:      preset,
+		Validators:  testutil.Validators(3),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stateRoot, _ := genesis.HashTreeRoot()
+	genesisBlock := &types.Block{StateRoot: stateRoot, Body: &types.BlockBody{Attestations: []*types.Attestation{}}}
+	fc := forkchoice.NewStore(genesis, genesisBlock, memory.New())
+
+	// Find a slot whose shuffled proposer is not the round-robin one.
+	proposerOf := func(slot uint64) uint64 {
+		proposer, ok := fc.ProposerIndex(slot)
+		if !ok {
+			t.Fatalf("no proposer for slot %d", slot)
+		}
+		return proposer
+	}
+	slot := uint64(1)
+	for ; proposerOf(slot) == slot%3; slot++ {
+	}
+	proposer := proposerOf(slot)
+	if proposer != statetransition.ProposerIndex(genesis.Config, slot, 3) {
+		t.Fatal("fork choice and the state transition disagree on the proposer")
+	}
+	if _, err := fc.ProduceBlock(slot, slot%3, &testutil.Signer{}); err == nil {
+		t.Fatal("round-robin proposer produced a block")
+	}
+	envelope, err := fc.ProduceBlock(slot, proposer, &testutil.Signer{})
+	if err != nil {
+		t.Fatalf("shuffled proposer: %v", err)
+	}
+
+	wrong := *envelope.Message.Block
+	wrong.ProposerIndex = slot % 3
+	if _, err := statetransition.StateTransition(genesis, &wrong); !errors.Is(err, statetransition.ErrWrongProposer) {
+		t.Fatalf("round-robin proposer: err = %v, want ErrWrongProposer", err)
+	}
+
+	// A spec chain with the same validators is another chain.
+	spec := statetransition.GenerateGenesis(1000, genesis.Validators)
+	if specRoot, _ := spec.HashTreeRoot(); specRoot == stateRoot {
+		t.Fatal("proposer selection is not committed to in the genesis state")
+	}
+}
+
+func TestProposerIsDrawnFromActiveValidators(t *testing.T) {
+	validators := testutil.Validators(4)
+	validators[1].ActivationEpoch = 2
+	genesis := testutil.DynamicGenesis(validators)
+
+	for slot := uint64(0); slot < 3*types.SlotsPerEpoch; slot++ {
+		active := []uint64{0, 2, 3}
+		if types.EpochAt(slot) >= 2 {
+			active = []uint64{0, 1, 2, 3}
+		}
+		got, ok := statetransition.StateProposerIndex(genesis, slot)
+		if want := active[slot%uint64(len(active))]; !ok || got != want {
+			t.Fatalf("slot %d: proposer %d, want %d", slot, got, want)
+		}
+	}
+
+	none := testutil.DynamicGenesis([]*types.Validator{{ActivationEpoch: 1}})
+	if _, ok := statetransition.StateProposerIndex(none, 0); ok {
+		t.Fatal("proposer chosen with no active validator")
+	}
+}
@@ -0,0 +1,437 @@
+package statetransition
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/geanlabs/gean/types"
+)
+
+// ReferenceStateTransition is a deliberately simple second implementation of
+// StateTransition used to cross-check it. It works on a copy of state that
+// shares no memory with the input (decoded from its SSZ encoding), mutates
+// that copy in place instead of copying per step, and handles bitlists as
+// []bool rather than packed bytes. It does not check the block's state root;
+// callers compare the result against the optimized path instead.
+func ReferenceStateTransition(state *types.State, block *types.Block) (*types.State, error) {
+	enc, err := state.MarshalSSZ()
+	if err != nil {
+		return nil, fmt.Errorf("encode state: %w", err)
+	}
+	s := new(types.State)
+	if err := s.UnmarshalSSZ(enc); err != nil {
+		return nil, fmt.Errorf("decode state: %w", err)
+	}
+
+	if s.Slot >= block.Slot {
+		return nil, fmt.Errorf("target slot %d must be after current slot %d", block.Slot, s.Slot)
+	}
+	for s.Slot < block.Slot {
+		if s.LatestBlockHeader.StateRoot == types.ZeroHash {
+			root, err := s.HashTreeRoot()
+			if err != nil {
+				return nil, fmt.Errorf("hash state: %w", err)
+			}
+			s.LatestBlockHeader.StateRoot = root
+		}
+		// Epoch hooks are shared with the optimized path; they are
+		// extensions, not something to cross-check.
+		if IsEpochEnd(s.Slot) {
+			if s, err = ProcessEpoch(s); err != nil {
+				return nil, err
+			}
+		}
+		s.Slot++
+	}
+
+	if block.Body.HasOperations() && !s.Config.Preset().DynamicValidators {
+		return nil, fmt.Errorf("block operations without dynamic validators")
+	}
+	if err := referenceBlockHeader(s, block); err != nil {
+		return nil, err
+	}
+	referenceAttestations(s, block.Body.Attestations)
+	if err := referenceSlashings(s, block.Body); err != nil {
+		return nil, err
+	}
+	if err := referenceDeposits(s, block.Body.Deposits); err != nil {
+		return nil, err
+	}
+	if err := referenceExits(s, block.Body.Exits); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func referenceBlockHeader(s *types.State, block *types.Block) error {
+	parent := s.LatestBlockHeader
+	if block.Slot <= parent.Slot {
+		return fmt.Errorf("block slot %d <= latest header slot %d", block.Slot, parent.Slot)
+	}
+	var active []uint64
+	epoch := types.EpochAt(block.Slot)
+	for i, v := range s.Validators {
+		if v.IsActive(epoch) {
+			active = append(active, uint64(i))
+		}
+	}
+	if len(active) == 0 || block.ProposerIndex != active[ProposerIndex(s.Config, block.Slot, uint64(len(active)))] {
+		return fmt.Errorf("validator %d is not proposer for slot %d", block.ProposerIndex, block.Slot)
+	}
+	parentRoot, err := parent.HashTreeRoot()
+	if err != nil {
+		return fmt.Errorf("hash parent header: %w", err)
+	}
+	if block.ParentRoot != parentRoot {
+		return fmt.Errorf("parent root mismatch")
+	}
+
+	if parent.Slot == 0 {
+		s.LatestJustified.Root = parentRoot
+		s.LatestFinalized.Root = parentRoot
+	}
+
+	justified := decodeBits(s.JustifiedSlots)
+	s.HistoricalBlockHashes = append(s.HistoricalBlockHashes, parentRoot)
+	justified = append(justified, parent.Slot == 0)
+	for slot := parent.Slot + 1; slot < block.Slot; slot++ {
+		s.HistoricalBlockHashes = append(s.HistoricalBlockHashes, types.ZeroHash)
+		justified = append(justified, false)
+	}
+	s.JustifiedSlots = encodeBits(justified)
+
+	bodyRoot, err := block.Body.HashTreeRoot()
+	if err != nil {
+		return fmt.Errorf("hash body: %w", err)
+	}
+	s.LatestBlockHeader = &types.BlockHeader{
+		Slot:          block.Slot,
+		ProposerIndex: block.ProposerIndex,
+		ParentRoot:    block.ParentRoot,
+		BodyRoot:      bodyRoot,
+	}
+	return nil
+}
+
+func referenceAttestations(s *types.State, attestations []*types.Attestation) {
+	n := len(s.Validators)
+	flat := decodeBits(s.JustificationsValidators)
+	votes := make(map[[32]byte][]bool, len(s.JustificationsRoots))
+	for i, root := range s.JustificationsRoots {
+		v := make([]bool, n)
+		copy(v, flat[i*n:(i+1)*n])
+		votes[root] = v
+	}
+	justified := decodeBits(s.JustifiedSlots)
+	isJustified := func(slot uint64) bool {
+		return slot < uint64(len(justified)) && justified[slot]
+	}
+	matchesHistory := func(cp *types.Checkpoint) bool {
+		return cp.Slot < uint64(len(s.HistoricalBlockHashes)) && s.HistoricalBlockHashes[cp.Slot] == cp.Root
+	}
+	finalizedSlot := s.LatestFinalized.Slot
+	active := make([]bool, n)
+	numActive := 0
+	for i, v := range s.Validators {
+		if v.IsActive(types.EpochAt(s.Slot)) {
+			active[i] = true
+			numActive++
+		}
+	}
+
+	for _, att := range attestations {
+		source, target := att.Data.Source, att.Data.Target
+		switch {
+		case target.Slot <= source.Slot,
+			!isJustified(source.Slot),
+			isJustified(target.Slot),
+			!matchesHistory(source),
+			!matchesHistory(target),
+			!types.IsJustifiableAfter(target.Slot, finalizedSlot),
+			att.ValidatorID >= uint64(n),
+			!active[att.ValidatorID]:
+			continue
+		}
+
+		v, ok := votes[target.Root]
+		if !ok {
+			v = make([]bool, n)
+			votes[target.Root] = v
+		}
+		if v[att.ValidatorID] {
+			continue
+		}
+		v[att.ValidatorID] = true
+
+		count := 0
+		for i, voted := range v {
+			if voted && active[i] {
+				count++
+			}
+		}
+		if 3*count < 2*numActive {
+			continue
+		}
+
+		s.LatestJustified = &types.Checkpoint{Root: target.Root, Slot: target.Slot}
+		for uint64(len(justified)) <= target.Slot {
+			justified = append(justified, false)
+		}
+		justified[target.Slot] = true
+		delete(votes, target.Root)
+
+		gap := false
+		for slot := source.Slot + 1; slot < target.Slot; slot++ {
+			if types.IsJustifiableAfter(slot, finalizedSlot) {
+				gap = true
+				break
+			}
+		}
+		if !gap {
+			s.LatestFinalized = &types.Checkpoint{Root: source.Root, Slot: source.Slot}
+		}
+	}
+
+	roots := make([][32]byte, 0, len(votes))
+	for root := range votes {
+		roots = append(roots, root)
+	}
+	sort.Slice(roots, func(i, j int) bool { return bytes.Compare(roots[i][:], roots[j][:]) < 0 })
+	flat = flat[:0]
+	for _, root := range roots {
+		flat = append(flat, votes[root]...)
+	}
+	s.JustifiedSlots = encodeBits(justified)
+	s.JustificationsRoots = roots
+	s.JustificationsValidators = encodeBits(flat)
+}
+
+func referenceSlashings(s *types.State, body *types.BlockBody) error {
+	epoch := s.Slot / types.SlotsPerEpoch
+	slash := func(index uint64) error {
+		if index >= uint64(len(s.Validators)) {
+			return fmt.Errorf("slashing for an unknown validator")
+		}
+		v := s.Validators[index]
+		if v.Slashed || epoch < v.ActivationEpoch || (v.ExitEpoch != 0 && epoch >= v.ExitEpoch) {
+			return fmt.Errorf("validator %d is not slashable", index)
+		}
+		v.Slashed = true
+		if v.ExitEpoch == 0 {
+			v.ExitEpoch = epoch + 1
+		}
+		return nil
+	}
+
+	for _, ps := range body.ProposerSlashings {
+		h1, h2 := ps.Header1, ps.Header2
+		r1, err := h1.HashTreeRoot()
+		if err != nil {
+			return err
+		}
+		r2, err := h2.HashTreeRoot()
+		if err != nil {
+			return err
+		}
+		a1, a2 := ps.Attestation1, ps.Attestation2
+		if h1.Slot != h2.Slot || h1.ProposerIndex != h2.ProposerIndex || r1 == r2 ||
+			a1.ValidatorID != h1.ProposerIndex || a1.Message.Slot != h1.Slot || a1.Message.Head.Root != r1 ||
+			a2.ValidatorID != h1.ProposerIndex || a2.Message.Slot != h1.Slot || a2.Message.Head.Root != r2 {
+			return fmt.Errorf("invalid proposer slashing")
+		}
+		if err := slash(h1.ProposerIndex); err != nil {
+			return err
+		}
+	}
+	for _, as := range body.AttesterSlashings {
+		a1, a2 := as.Attestation1, as.Attestation2
+		r1, err := a1.Message.HashTreeRoot()
+		if err != nil {
+			return err
+		}
+		r2, err := a2.Message.HashTreeRoot()
+		if err != nil {
+			return err
+		}
+		if a1.ValidatorID != a2.ValidatorID || a1.Message.Slot != a2.Message.Slot || r1 == r2 {
+			return fmt.Errorf("invalid attester slashing")
+		}
+		if err := slash(a1.ValidatorID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func referenceDeposits(s *types.State, deposits []*types.SignedDeposit) error {
+	if len(deposits) == 0 {
+		return nil
+	}
+	n := len(s.Validators)
+	if n+len(deposits) > types.ValidatorRegistryLimit {
+		return fmt.Errorf("validator registry is full")
+	}
+	for _, sd := range deposits {
+		d := sd.Message
+		if d == nil {
+			return fmt.Errorf("deposit without a message")
+		}
+		root, err := d.HashTreeRoot()
+		if err != nil {
+			return err
+		}
+		if err := verifyOperation(s, d.Pubkey, d.Slot, root, sd.Signature); err != nil {
+			return fmt.Errorf("invalid deposit: %v", err)
+		}
+		for _, v := range s.Validators {
+			if v.Pubkey == d.Pubkey {
+				return fmt.Errorf("deposit for a registered key")
+			}
+		}
+		s.Validators = append(s.Validators, &types.Validator{
+			Pubkey:          d.Pubkey,
+			Index:           uint64(len(s.Validators)),
+			ActivationEpoch: s.Slot/types.SlotsPerEpoch + 1,
+		})
+	}
+
+	// Widen each root's votes to the new registry size.
+	m := len(s.Validators)
+	flat := decodeBits(s.JustificationsValidators)
+	wide := make([]bool, 0, len(s.JustificationsRoots)*m)
+	for i := range s.JustificationsRoots {
+		wide = append(wide, flat[i*n:(i+1)*n]...)
+		wide = append(wide, make([]bool, m-n)...)
+	}
+	s.JustificationsValidators = encodeBits(wide)
+	return nil
+}
+
+func referenceExits(s *types.State, exits []*types.SignedVoluntaryExit) error {
+	epoch := s.Slot / types.SlotsPerEpoch
+	for _, se := range exits {
+		e := se.Message
+		if e == nil {
+			return fmt.Errorf("exit without a message")
+		}
+		if e.ValidatorIndex >= uint64(len(s.Validators)) {
+			return fmt.Errorf("exit for an unknown validator")
+		}
+		v := s.Validators[e.ValidatorIndex]
+		root, err := e.HashTreeRoot()
+		if err != nil {
+			return err
+		}
+		if err := verifyOperation(s, v.Pubkey, e.Slot, root, se.Signature); err != nil {
+			return fmt.Errorf("invalid exit: %v", err)
+		}
+		if epoch < v.ActivationEpoch || v.ExitEpoch != 0 {
+			return fmt.Errorf("exit for an inactive validator")
+		}
+		v.ExitEpoch = epoch + 1
+	}
+	return nil
+}
+
+// decodeBits unpacks an SSZ bitlist.
+func decodeBits(bl []byte) []bool {
+	bits := make([]bool, BitlistLen(bl))
+	for i := range bits {
+		bits[i] = bl[i/8]&(1<<(i%8)) != 0
+	}
+	return bits
+}
+
+// encodeBits packs bits into an SSZ bitlist with its sentinel bit.
+func encodeBits(bits []bool) []byte {
+	bl := make([]byte, len(bits)/8+1)
+	for i, b := range bits {
+		if b {
+			bl[i/8] |= 1 << (i % 8)
+		}
+	}
+	bl[len(bits)/8] |= 1 << (len(bits) % 8)
+	return bl
+}
+
+// DiffStates describes every field that differs between a and b, one entry
+// per field. It returns nil if the states are equal.
+func DiffStates(a, b *types.State) []string {
+	var diffs []string
+	add := func(field string, x, y any) {
+		diffs = append(diffs, fmt.Sprintf("%s: %v != %v", field, x, y))
+	}
+
+	if a.Config.GenesisTime != b.Config.GenesisTime {
+		add("config.genesis_time", a.Config.GenesisTime, b.Config.GenesisTime)
+	}
+	if a.Slot != b.Slot {
+		add("slot", a.Slot, b.Slot)
+	}
+	if *a.LatestBlockHeader != *b.LatestBlockHeader {
+		add("latest_block_header", fmt.Sprintf("%+v", *a.LatestBlockHeader), fmt.Sprintf("%+v", *b.LatestBlockHeader))
+	}
+	if *a.LatestJustified != *b.LatestJustified {
+		add("latest_justified", checkpointString(a.LatestJustified), checkpointString(b.LatestJustified))
+	}
+	if *a.LatestFinalized != *b.LatestFinalized {
+		add("latest_finalized", checkpointString(a.LatestFinalized), checkpointString(b.LatestFinalized))
+	}
+	if i, ok := firstRootDiff(a.HistoricalBlockHashes, b.HistoricalBlockHashes); ok {
+		diffs = append(diffs, fmt.Sprintf("historical_block_hashes: first difference at index %d (len %d vs %d)",
+			i, len(a.HistoricalBlockHashes), len(b.HistoricalBlockHashes)))
+	}
+	if i, ok := firstBitDiff(a.JustifiedSlots, b.JustifiedSlots); ok {
+		diffs = append(diffs, fmt.Sprintf("justified_slots: first difference at bit %d (len %d vs %d)",
+			i, BitlistLen(a.JustifiedSlots), BitlistLen(b.JustifiedSlots)))
+	}
+	if len(a.Validators) != len(b.Validators) {
+		add("validators.len", len(a.Validators), len(b.Validators))
+	} else {
+		for i := range a.Validators {
+			if *a.Validators[i] != *b.Validators[i] {
+				diffs = append(diffs, fmt.Sprintf("validators[%d] differs", i))
+				break
+			}
+		}
+	}
+	if i, ok := firstRootDiff(a.JustificationsRoots, b.JustificationsRoots); ok {
+		diffs = append(diffs, fmt.Sprintf("justifications_roots: first difference at index %d (len %d vs %d)",
+			i, len(a.JustificationsRoots), len(b.JustificationsRoots)))
+	}
+	if i, ok := firstBitDiff(a.JustificationsValidators, b.JustificationsValidators); ok {
+		diffs = append(diffs, fmt.Sprintf("justifications_validators: first difference at bit %d (len %d vs %d)",
+			i, BitlistLen(a.JustificationsValidators), BitlistLen(b.JustificationsValidators)))
+	}
+	return diffs
+}
+
+func checkpointString(cp *types.Checkpoint) string {
+	return fmt.Sprintf("{slot=%d root=%x}", cp.Slot, cp.Root)
+}
+
+func firstRootDiff(a, b [][32]byte) (int, bool) {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return i, true
+		}
+	}
+	if len(a) != len(b) {
+		return min(len(a), len(b)), true
+	}
+	return 0, false
+}
+
+func firstBitDiff(a, b []byte) (int, bool) {
+	x, y := decodeBits(a), decodeBits(b)
+	for i := 0; i < len(x) && i < len(y); i++ {
+		if x[i] != y[i] {
+			return i, true
+		}
+	}
+	if len(x) != len(y) {
+		return min(len(x), len(y)), true
+	}
+	return 0, false
+}
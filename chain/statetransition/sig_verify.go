@@ -0,0 +1,7 @@
+//go:build !skip_sig_verify
+
+package statetransition
+
+// verifySignatures reports whether this build verifies the XMSS signatures
+// of deposits and exits.
+const verifySignatures = true
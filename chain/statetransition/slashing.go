@@ -0,0 +1,144 @@
+package statetransition
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/geanlabs/gean/types"
+)
+
+// Errors returned for blocks carrying invalid slashings.
+var (
+	ErrInvalidProposerSlashing = errors.New("invalid proposer slashing")
+	ErrInvalidAttesterSlashing = errors.New("invalid attester slashing")
+	ErrNotSlashable            = errors.New("validator is not slashable")
+)
+
+// ProcessProposerSlashings slashes the proposer of each pair of conflicting
+// blocks. Signature verification must happen externally.
+func ProcessProposerSlashings(state *types.State, slashings []*types.ProposerSlashing) (*types.State, error) {
+	if len(slashings) == 0 {
+		return state, nil
+	}
+	s := newSlasher(state)
+	for _, ps := range slashings {
+		if err := checkProposerSlashing(ps); err != nil {
+			return nil, err
+		}
+		if err := s.slash(ps.Header1.ProposerIndex); err != nil {
+			return nil, err
+		}
+	}
+	return s.state(), nil
+}
+
+// ProcessAttesterSlashings slashes the validator behind each pair of
+// conflicting attestations. Signature verification must happen externally.
+func ProcessAttesterSlashings(state *types.State, slashings []*types.AttesterSlashing) (*types.State, error) {
+	if len(slashings) == 0 {
+		return state, nil
+	}
+	s := newSlasher(state)
+	for _, as := range slashings {
+		if err := checkAttesterSlashing(as); err != nil {
+			return nil, err
+		}
+		if err := s.slash(as.Attestation1.ValidatorID); err != nil {
+			return nil, err
+		}
+	}
+	return s.state(), nil
+}
+
+// checkProposerSlashing checks the headers are two different blocks from one
+// proposer at one slot, each named as head by a proposer attestation of
+// that proposer at that slot.
+func checkProposerSlashing(ps *types.ProposerSlashing) error {
+	h1, h2 := ps.Header1, ps.Header2
+	if h1 == nil || h2 == nil {
+		return fmt.Errorf("%w: missing header", ErrInvalidProposerSlashing)
+	}
+	if h1.Slot != h2.Slot || h1.ProposerIndex != h2.ProposerIndex {
+		return fmt.Errorf("%w: headers from different slots or proposers", ErrInvalidProposerSlashing)
+	}
+	r1, _ := h1.HashTreeRoot()
+	r2, _ := h2.HashTreeRoot()
+	if r1 == r2 {
+		return fmt.Errorf("%w: headers are the same block", ErrInvalidProposerSlashing)
+	}
+	for i, pair := range []struct {
+		root [32]byte
+		att  *types.SignedAttestation
+	}{{r1, ps.Attestation1}, {r2, ps.Attestation2}} {
+		att := pair.att
+		if att == nil || att.Message == nil || att.Message.Head == nil ||
+			att.ValidatorID != h1.ProposerIndex || att.Message.Slot != h1.Slot || att.Message.Head.Root != pair.root {
+			return fmt.Errorf("%w: attestation %d does not vouch for its header", ErrInvalidProposerSlashing, i+1)
+		}
+	}
+	return nil
+}
+
+// checkAttesterSlashing checks the attestations are two different votes
+// from one validator at one slot.
+func checkAttesterSlashing(as *types.AttesterSlashing) error {
+	a1, a2 := as.Attestation1, as.Attestation2
+	if a1 == nil || a2 == nil || a1.Message == nil || a2.Message == nil {
+		return fmt.Errorf("%w: missing attestation", ErrInvalidAttesterSlashing)
+	}
+	if a1.ValidatorID != a2.ValidatorID || a1.Message.Slot != a2.Message.Slot {
+		return fmt.Errorf("%w: attestations from different validators or slots", ErrInvalidAttesterSlashing)
+	}
+	r1, err := a1.Message.HashTreeRoot()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidAttesterSlashing, err)
+	}
+	r2, err := a2.Message.HashTreeRoot()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidAttesterSlashing, err)
+	}
+	if r1 == r2 {
+		return fmt.Errorf("%w: attestations are the same vote", ErrInvalidAttesterSlashing)
+	}
+	return nil
+}
+
+// slasher marks validators slashed on a copy of the registry, copied on the
+// first slashing.
+type slasher struct {
+	pre        *types.State
+	epoch      uint64
+	validators []*types.Validator
+}
+
+func newSlasher(state *types.State) *slasher {
+	return &slasher{pre: state, epoch: types.EpochAt(state.Slot)}
+}
+
+// slash marks an active, unslashed validator slashed and makes it exit at
+// the next epoch unless it is already exiting.
+func (s *slasher) slash(index uint64) error {
+	if s.validators == nil {
+		s.validators = make([]*types.Validator, len(s.pre.Validators))
+		copy(s.validators, s.pre.Validators)
+	}
+	if index >= uint64(len(s.validators)) {
+		return fmt.Errorf("%w: unknown validator %d", ErrNotSlashable, index)
+	}
+	v := *s.validators[index]
+	if v.Slashed || !v.IsActive(s.epoch) {
+		return fmt.Errorf("%w: %d", ErrNotSlashable, index)
+	}
+	v.Slashed = true
+	if !v.HasExited() {
+		v.ExitEpoch = s.epoch + 1
+	}
+	s.validators[index] = &v
+	return nil
+}
+
+func (s *slasher) state() *types.State {
+	out := s.pre.ShallowCopy()
+	out.Validators = s.validators
+	return out
+}
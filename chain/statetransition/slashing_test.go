@@ -0,0 +1,113 @@
+package statetransition_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/geanlabs/gean/chain/statetransition"
+	"github.com/geanlabs/gean/internal/testutil"
+	"github.com/geanlabs/gean/types"
+)
+
+// vouchedHeader returns the proposer attestation naming header as head.
+func vouchedHeader(header *types.BlockHeader) *types.SignedAttestation {
+	root, _ := header.HashTreeRoot()
+	cp := &types.Checkpoint{}
+	return &types.SignedAttestation{
+		ValidatorID: header.ProposerIndex,
+		Message:     &types.AttestationData{Slot: header.Slot, Head: &types.Checkpoint{Root: root, Slot: header.Slot}, Target: cp, Source: cp},
+	}
+}
+
+func TestSlashingsMarkValidatorsSlashed(t *testing.T) {
+	genesis := testutil.DynamicGenesis(testutil.Validators(3))
+	pre, err := testutil.ApplyBody(t, genesis, 1, &types.BlockBody{Attestations: []*types.Attestation{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h1 := &types.BlockHeader{Slot: 1, ProposerIndex: 1, StateRoot: [32]byte{1}}
+	h2 := &types.BlockHeader{Slot: 1, ProposerIndex: 1, StateRoot: [32]byte{2}}
+	proposer := &types.ProposerSlashing{Header1: h1, Header2: h2, Attestation1: vouchedHeader(h1), Attestation2: vouchedHeader(h2)}
+	vote := func(validator uint64, head byte) *types.SignedAttestation {
+		cp := &types.Checkpoint{}
+		return &types.SignedAttestation{
+			ValidatorID: validator,
+			Message:     &types.AttestationData{Slot: 1, Head: &types.Checkpoint{Root: [32]byte{head}, Slot: 1}, Target: cp, Source: cp},
+		}
+	}
+	attester := &types.AttesterSlashing{Attestation1: vote(0, 1), Attestation2: vote(0, 2)}
+
+	post, err := testutil.ApplyBody(t, pre, 2, &types.BlockBody{
+		Attestations:      []*types.Attestation{},
+		ProposerSlashings: []*types.ProposerSlashing{proposer},
+		AttesterSlashings: []*types.AttesterSlashing{attester},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []uint64{0, 1} {
+		if s := post.Validators[v]; !s.Slashed || s.ExitEpoch != 1 {
+			t.Fatalf("validator %d = %+v, want slashed and exiting at epoch 1", v, s)
+		}
+		if pre.Validators[v].Slashed {
+			t.Fatalf("slashing validator %d changed the parent state", v)
+		}
+	}
+	if post.Validators[2].Slashed {
+		t.Fatal("validator 2 was slashed")
+	}
+
+	// Slashed validators neither propose nor vote, though they only exit
+	// at the next epoch.
+	for slot := uint64(3); slot < types.SlotsPerEpoch; slot++ {
+		if p, ok := statetransition.StateProposerIndex(post, slot); !ok || p != 2 {
+			t.Fatalf("slot %d: proposer %d, want the unslashed validator 2", slot, p)
+		}
+	}
+	target := &types.Checkpoint{Root: testutil.HeaderRootAt(t, post, 3), Slot: 2}
+	source := &types.Checkpoint{Root: post.LatestJustified.Root, Slot: 0}
+	var votes []*types.Attestation
+	for v := uint64(0); v < 2; v++ {
+		votes = append(votes, &types.Attestation{
+			ValidatorID: v,
+			Data:        &types.AttestationData{Slot: 3, Head: target, Target: target, Source: source},
+		})
+	}
+	voted, err := testutil.ApplyBody(t, post, 3, &types.BlockBody{Attestations: votes})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if voted.LatestJustified.Slot != 0 || len(voted.JustificationsRoots) != 0 {
+		t.Fatalf("votes of slashed validators counted: justified %d, pending %x", voted.LatestJustified.Slot, voted.JustificationsRoots)
+	}
+
+	unvouched := vouchedHeader(h1)
+	for _, tc := range []struct {
+		name string
+		body *types.BlockBody
+		want error
+	}{
+		{"same block twice", &types.BlockBody{ProposerSlashings: []*types.ProposerSlashing{{
+			Header1: h1, Header2: h1, Attestation1: vouchedHeader(h1), Attestation2: vouchedHeader(h1),
+		}}}, statetransition.ErrInvalidProposerSlashing},
+		{"attestation for another block", &types.BlockBody{ProposerSlashings: []*types.ProposerSlashing{{
+			Header1: h1, Header2: h2, Attestation1: vouchedHeader(h1), Attestation2: unvouched,
+		}}}, statetransition.ErrInvalidProposerSlashing},
+		{"same vote twice", &types.BlockBody{AttesterSlashings: []*types.AttesterSlashing{{
+			Attestation1: vote(2, 1), Attestation2: vote(2, 1),
+		}}}, statetransition.ErrInvalidAttesterSlashing},
+		{"different validators", &types.BlockBody{AttesterSlashings: []*types.AttesterSlashing{{
+			Attestation1: vote(2, 1), Attestation2: vote(1, 2),
+		}}}, statetransition.ErrInvalidAttesterSlashing},
+		{"already slashed", &types.BlockBody{AttesterSlashings: []*types.AttesterSlashing{attester}}, statetransition.ErrNotSlashable},
+		{"unknown validator", &types.BlockBody{AttesterSlashings: []*types.AttesterSlashing{{
+			Attestation1: vote(7, 1), Attestation2: vote(7, 2),
+		}}}, statetransition.ErrNotSlashable},
+	} {
+		tc.body.Attestations = []*types.Attestation{}
+		if _, err := testutil.ApplyBody(t, post, 3, tc.body); !errors.Is(err, tc.want) {
+			t.Errorf("%s: err = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package statetransition
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
@@ -8,27 +9,46 @@ import (
 	"github.com/geanlabs/gean/types"
 )
 
+// Errors returned by the state transition for blocks it rejects. They are
+// wrapped with the offending values, so match them with errors.Is.
+var (
+	ErrSlotNotAfterState  = errors.New("target slot not after state slot")
+	ErrBlockSlotMismatch  = errors.New("block slot does not match state slot")
+	ErrBlockNotNewer      = errors.New("block slot not after latest header slot")
+	ErrWrongProposer      = errors.New("wrong proposer")
+	ErrParentMismatch     = errors.New("parent root mismatch")
+	ErrStateRootMismatch  = errors.New("invalid state root")
+	ErrOperationsDisabled = errors.New("block operations without dynamic validators")
+)
+
 // ProcessSlot performs per-slot maintenance. If the latest block header has
 // a zero state_root, it caches the current state root into that header.
 func ProcessSlot(state *types.State) *types.State {
 	if state.LatestBlockHeader.StateRoot == types.ZeroHash {
 		stateRoot, _ := state.HashTreeRoot()
-		out := state.Copy()
+		out := state.ShallowCopy()
 		out.LatestBlockHeader.StateRoot = stateRoot
 		return out
 	}
 	return state
 }
 
-// ProcessSlots advances the state through empty slots up to targetSlot.
+// ProcessSlots advances the state through empty slots up to targetSlot,
+// running epoch processing at the end of the last slot of each epoch.
 func ProcessSlots(state *types.State, targetSlot uint64) (*types.State, error) {
 	if state.Slot >= targetSlot {
-		return nil, fmt.Errorf("target slot %d must be after current slot %d", targetSlot, state.Slot)
+		return nil, fmt.Errorf("%w: target slot %d must be after current slot %d", ErrSlotNotAfterState, targetSlot, state.Slot)
 	}
 	s := state
 	for s.Slot < targetSlot {
 		s = ProcessSlot(s)
-		out := s.Copy()
+		if IsEpochEnd(s.Slot) {
+			var err error
+			if s, err = ProcessEpoch(s); err != nil {
+				return nil, err
+			}
+		}
+		out := s.ShallowCopy()
 		out.Slot = s.Slot + 1
 		s = out
 	}
@@ -38,21 +58,21 @@ func ProcessSlots(state *types.State, targetSlot uint64) (*types.State, error) {
 // ProcessBlockHeader validates the block header and updates header-linked state.
 func ProcessBlockHeader(state *types.State, block *types.Block) (*types.State, error) {
 	if block.Slot != state.Slot {
-		return nil, fmt.Errorf("block slot %d != state slot %d", block.Slot, state.Slot)
+		return nil, fmt.Errorf("%w: block slot %d != state slot %d", ErrBlockSlotMismatch, block.Slot, state.Slot)
 	}
 	if block.Slot <= state.LatestBlockHeader.Slot {
-		return nil, fmt.Errorf("block slot %d <= latest header slot %d", block.Slot, state.LatestBlockHeader.Slot)
+		return nil, fmt.Errorf("%w: block slot %d <= latest header slot %d", ErrBlockNotNewer, block.Slot, state.LatestBlockHeader.Slot)
 	}
-	if !IsProposer(block.ProposerIndex, state.Slot, uint64(len(state.Validators))) {
-		return nil, fmt.Errorf("validator %d is not proposer for slot %d", block.ProposerIndex, state.Slot)
+	if proposer, ok := StateProposerIndex(state, state.Slot); !ok || proposer != block.ProposerIndex {
+		return nil, fmt.Errorf("%w: validator %d is not proposer for slot %d", ErrWrongProposer, block.ProposerIndex, state.Slot)
 	}
 
 	expectedParent, _ := state.LatestBlockHeader.HashTreeRoot()
 	if block.ParentRoot != expectedParent {
-		return nil, fmt.Errorf("parent root mismatch")
+		return nil, ErrParentMismatch
 	}
 
-	out := state.Copy()
+	out := state.ShallowCopy()
 	parentRoot := block.ParentRoot
 
 	// First block after genesis: mark genesis as justified and finalized.
@@ -61,14 +81,21 @@ func ProcessBlockHeader(state *types.State, block *types.Block) (*types.State, e
 		out.LatestFinalized = &types.Checkpoint{Root: parentRoot, Slot: state.LatestFinalized.Slot}
 	}
 
-	// Append parent root to historical hashes (already cloned by Copy).
-	out.HistoricalBlockHashes = append(out.HistoricalBlockHashes, parentRoot)
+	// The lists are shared with the parent state, so grow fresh ones: the
+	// hashes in a single allocation, the bitlist as a clone since AppendBit
+	// rewrites the old sentinel in place.
+	numEmpty := block.Slot - state.LatestBlockHeader.Slot - 1
+	hashes := make([][32]byte, len(state.HistoricalBlockHashes), len(state.HistoricalBlockHashes)+int(numEmpty)+1)
+	copy(hashes, state.HistoricalBlockHashes)
+	out.JustifiedSlots = CloneBitlist(state.JustifiedSlots)
+
+	// Append parent root to historical hashes.
+	out.HistoricalBlockHashes = append(hashes, parentRoot)
 
-	// Append justified bit for parent: true only for genesis slot (already cloned by Copy).
+	// Append justified bit for parent: true only for genesis slot.
 	out.JustifiedSlots = AppendBit(out.JustifiedSlots, state.LatestBlockHeader.Slot == 0)
 
 	// Fill empty slots between parent and this block.
-	numEmpty := block.Slot - state.LatestBlockHeader.Slot - 1
 	for i := uint64(0); i < numEmpty; i++ {
 		out.HistoricalBlockHashes = append(out.HistoricalBlockHashes, types.ZeroHash)
 		out.JustifiedSlots = AppendBit(out.JustifiedSlots, false)
@@ -87,10 +114,15 @@ func ProcessBlockHeader(state *types.State, block *types.Block) (*types.State, e
 	return out, nil
 }
 
-// ProcessBlock applies full block processing: header + attestations.
+// ProcessBlock applies full block processing: header, attestations,
+// slashings, then the validator set changes. Slashings and validator set
+// changes are only accepted on chains with dynamic validators.
 func ProcessBlock(state *types.State, block *types.Block) (*types.State, error) {
 	blockStart := time.Now()
 
+	if block.Body.HasOperations() && !state.Config.Preset().DynamicValidators {
+		return nil, ErrOperationsDisabled
+	}
 	s, err := ProcessBlockHeader(state, block)
 	if err != nil {
 		return nil, err
@@ -100,6 +132,19 @@ func ProcessBlock(state *types.State, block *types.Block) (*types.State, error)
 
 	metrics.STFAttestationsProcessed.Add(float64(len(block.Body.Attestations)))
 	metrics.STFAttestationsProcessingTime.Observe(time.Since(attStart).Seconds())
+
+	if s, err = ProcessProposerSlashings(s, block.Body.ProposerSlashings); err != nil {
+		return nil, err
+	}
+	if s, err = ProcessAttesterSlashings(s, block.Body.AttesterSlashings); err != nil {
+		return nil, err
+	}
+	if s, err = ProcessDeposits(s, block.Body.Deposits); err != nil {
+		return nil, err
+	}
+	if s, err = ProcessExits(s, block.Body.Exits); err != nil {
+		return nil, err
+	}
 	metrics.STFBlockProcessingTime.Observe(time.Since(blockStart).Seconds())
 	return s, nil
 }
@@ -126,7 +171,7 @@ func StateTransition(state *types.State, block *types.Block) (*types.State, erro
 	// Validate state root.
 	computedRoot, _ := s.HashTreeRoot()
 	if block.StateRoot != computedRoot {
-		return nil, fmt.Errorf("invalid state root: expected %x, got %x", computedRoot, block.StateRoot)
+		return nil, fmt.Errorf("%w: expected %x, got %x", ErrStateRootMismatch, computedRoot, block.StateRoot)
 	}
 
 	return s, nil
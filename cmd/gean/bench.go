@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/geanlabs/gean/node"
+	"github.com/geanlabs/gean/xmss/leansig/bench"
+)
+
+// runBench implements `gean bench`. Its only target is `sig`, which measures
+// XMSS key generation, signing and verification latency.
+func runBench(args []string) int {
+	if len(args) == 0 || args[0] != "sig" {
+		fmt.Fprintln(os.Stderr, "Usage: gean bench sig [flags]")
+		return 2
+	}
+
+	fs := flag.NewFlagSet("bench sig", flag.ExitOnError)
+	epochs := fs.String("active-epochs", "262144", "Comma-separated key lifetimes in epochs, one key each")
+	iterations := fs.Int("iterations", 32, "Signatures and verifications per key")
+	keygenIterations := fs.Int("keygen-iterations", 1, "Keys generated per lifetime to time key generation")
+	batchSize := fs.Int("batch", 64, "Signatures per batch verification (0 skips it)")
+	seed := fs.Uint64("seed", 1, "Key generation seed")
+	out := fs.String("out", "", "Write the JSON report to this file instead of stdout")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gean bench sig [--active-epochs 262144,...] [--iterations N] [--batch N] [--out report.json]")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args[1:])
+
+	cfg := bench.Config{
+		Iterations:       *iterations,
+		KeygenIterations: *keygenIterations,
+		BatchSize:        *batchSize,
+		Seed:             *seed,
+	}
+	for _, s := range strings.Split(*epochs, ",") {
+		n, err := strconv.ParseUint(strings.TrimSpace(s), 10, 64)
+		if err != nil || n == 0 {
+			fmt.Fprintf(os.Stderr, "invalid --active-epochs entry %q\n", s)
+			return 2
+		}
+		cfg.ActiveEpochs = append(cfg.ActiveEpochs, n)
+	}
+
+	report, err := bench.Run(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "bench: %v\n", err)
+		return 1
+	}
+	report.Version = node.Version
+
+	for _, r := range report.Results {
+		label := r.Operation
+		if r.BatchSize > 0 {
+			label = fmt.Sprintf("%s(%d)", r.Operation, r.BatchSize)
+		}
+		fmt.Fprintf(os.Stderr, "%-18s epochs=%-10d n=%-5d mean=%-12v p50=%-12v p99=%v\n",
+			label, r.ActiveEpochs, r.Samples,
+			time.Duration(r.MeanNs), time.Duration(r.P50Ns), time.Duration(r.P99Ns))
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "encode report: %v\n", err)
+		return 1
+	}
+	data = append(data, '\n')
+	if *out == "" {
+		os.Stdout.Write(data)
+		return 0
+	}
+	if err := os.WriteFile(*out, data, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "write report: %v\n", err)
+		return 1
+	}
+	return 0
+}
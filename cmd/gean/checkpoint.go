@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/geanlabs/gean/storage/checkpoint"
+)
+
+// runCheckpoint implements `gean checkpoint`: it creates signing keys and
+// signs and verifies checkpoint bundles exported by the admin API.
+func runCheckpoint(args []string) int {
+	usage := func() {
+		fmt.Fprintln(os.Stderr, "Usage: gean checkpoint keygen --key <file>")
+		fmt.Fprintln(os.Stderr, "       gean checkpoint sign --key <file> --in <bundle> --out <bundle>")
+		fmt.Fprintln(os.Stderr, "       gean checkpoint verify --in <bundle> [--pubkey <hex>]")
+	}
+	if len(args) == 0 {
+		usage()
+		return 2
+	}
+	switch args[0] {
+	case "keygen":
+		return runCheckpointKeygen(args[1:])
+	case "sign":
+		return runCheckpointSign(args[1:])
+	case "verify":
+		return runCheckpointVerify(args[1:])
+	default:
+		usage()
+		return 2
+	}
+}
+
+func runCheckpointKeygen(args []string) int {
+	fs := flag.NewFlagSet("checkpoint keygen", flag.ExitOnError)
+	keyPath := fs.String("key", "", "Path to write the hex-encoded ed25519 seed to")
+	_ = fs.Parse(args)
+	if *keyPath == "" {
+		fs.Usage()
+		return 2
+	}
+	pub, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "generate key: %v\n", err)
+		return 1
+	}
+	f, err := os.OpenFile(*keyPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "create key file: %v\n", err)
+		return 1
+	}
+	_, err = fmt.Fprintln(f, hex.EncodeToString(key.Seed()))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "write key file: %v\n", err)
+		return 1
+	}
+	fmt.Printf("pubkey %s\n", hex.EncodeToString(pub))
+	return 0
+}
+
+func runCheckpointSign(args []string) int {
+	fs := flag.NewFlagSet("checkpoint sign", flag.ExitOnError)
+	keyPath := fs.String("key", "", "Path to the hex-encoded ed25519 seed")
+	in := fs.String("in", "", "Unsigned or signed bundle to sign")
+	out := fs.String("out", "", "Path to write the signed bundle to")
+	_ = fs.Parse(args)
+	if *keyPath == "" || *in == "" || *out == "" {
+		fs.Usage()
+		return 2
+	}
+	key, err := loadCheckpointKey(*keyPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "load key: %v\n", err)
+		return 1
+	}
+	bundle, err := readBundle(*in)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+	var buf bytes.Buffer
+	if err := bundle.Write(&buf, key); err != nil {
+		fmt.Fprintf(os.Stderr, "sign bundle: %v\n", err)
+		return 1
+	}
+	if err := os.WriteFile(*out, buf.Bytes(), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "write bundle: %v\n", err)
+		return 1
+	}
+	printBundle(bundle)
+	fmt.Printf("signer  %s\n", hex.EncodeToString(key.Public().(ed25519.PublicKey)))
+	return 0
+}
+
+func runCheckpointVerify(args []string) int {
+	fs := flag.NewFlagSet("checkpoint verify", flag.ExitOnError)
+	in := fs.String("in", "", "Bundle to verify")
+	pubkeys := fs.String("pubkey", "", "Comma-separated hex ed25519 public keys, one of which must have signed the bundle")
+	_ = fs.Parse(args)
+	if *in == "" {
+		fs.Usage()
+		return 2
+	}
+	trusted, err := parsePubkeys(*pubkeys)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 2
+	}
+	bundle, err := readBundle(*in, trusted...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+	printBundle(bundle)
+	if bundle.Signer != nil {
+		fmt.Printf("signer  %s\n", hex.EncodeToString(bundle.Signer))
+	} else {
+		fmt.Println("signer  none")
+	}
+	return 0
+}
+
+// parsePubkeys parses a comma-separated list of hex ed25519 public keys.
+func parsePubkeys(list string) ([]ed25519.PublicKey, error) {
+	var keys []ed25519.PublicKey
+	for _, s := range strings.Split(list, ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		b, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+		if err != nil || len(b) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid pubkey %q", s)
+		}
+		keys = append(keys, ed25519.PublicKey(b))
+	}
+	return keys, nil
+}
+
+func readBundle(path string, trusted ...ed25519.PublicKey) (*checkpoint.Bundle, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open bundle: %w", err)
+	}
+	defer f.Close()
+	bundle, err := checkpoint.Read(f, trusted...)
+	if err != nil {
+		return nil, fmt.Errorf("read bundle: %w", err)
+	}
+	return bundle, nil
+}
+
+func printBundle(b *checkpoint.Bundle) {
+	root, _ := b.Root()
+	digest, _ := b.Digest()
+	fmt.Printf("slot    %d\n", b.Block.Slot)
+	fmt.Printf("root    0x%s\n", hex.EncodeToString(root[:]))
+	fmt.Printf("digest  %s\n", hex.EncodeToString(digest[:]))
+}
+
+func loadCheckpointKey(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	seed, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("%s: want a %d-byte hex-encoded ed25519 seed", path, ed25519.SeedSize)
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/geanlabs/gean/chain/statetransition"
+	"github.com/geanlabs/gean/config"
+	"github.com/geanlabs/gean/network"
+	"github.com/geanlabs/gean/network/reqresp"
+	"github.com/geanlabs/gean/types"
+)
+
+// exportTimeout bounds the whole export, including fetching the chain.
+const exportTimeout = 10 * time.Minute
+
+// exportBlock is a canonical block record in the JSONL export.
+type exportBlock struct {
+	Type                string               `json:"type"`
+	Slot                uint64               `json:"slot"`
+	Root                string               `json:"root"`
+	ParentRoot          string               `json:"parent_root"`
+	StateRoot           string               `json:"state_root"`
+	Proposer            uint64               `json:"proposer"`
+	Attestations        []exportAttestation  `json:"attestations"`
+	ProposerAttestation *exportAttestation   `json:"proposer_attestation,omitempty"`
+	Justified           exportCheckpointData `json:"justified"`
+	Finalized           exportCheckpointData `json:"finalized"`
+}
+
+// exportAttestation summarizes one attestation included in a block.
+type exportAttestation struct {
+	Validator  uint64 `json:"validator"`
+	Slot       uint64 `json:"slot"`
+	HeadRoot   string `json:"head_root"`
+	HeadSlot   uint64 `json:"head_slot"`
+	TargetRoot string `json:"target_root"`
+	TargetSlot uint64 `json:"target_slot"`
+	SourceRoot string `json:"source_root"`
+	SourceSlot uint64 `json:"source_slot"`
+}
+
+type exportCheckpointData struct {
+	Root string `json:"root"`
+	Slot uint64 `json:"slot"`
+}
+
+// exportCheckpoint records a change of the justified or finalized checkpoint
+// caused by the block at Slot.
+type exportCheckpoint struct {
+	Type      string               `json:"type"`
+	Kind      string               `json:"kind"` // "justified" or "finalized"
+	Slot      uint64               `json:"slot"`
+	BlockRoot string               `json:"block_root"`
+	Previous  exportCheckpointData `json:"previous"`
+	Current   exportCheckpointData `json:"current"`
+}
+
+// runExport implements `gean export`: it fetches the canonical chain from a
+// peer, replays it from genesis and writes blocks and checkpoint transitions
+// in a slot range as JSON lines.
+func runExport(args []string) int {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	genesisPath := fs.String("genesis", "", "Path or HTTP(S) URL of config.yaml")
+	peerAddr := fs.String("peer", "", "Multiaddr or ENR of the node to export from")
+	fromSlot := fs.Uint64("from-slot", 0, "First slot to export")
+	toSlot := fs.Uint64("to-slot", 0, "Last slot to export (0 = peer head)")
+	format := fs.String("format", "jsonl", "Output format (jsonl)")
+	outPath := fs.String("out", "", "Output file (default stdout)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gean export --genesis <config.yaml> --peer <multiaddr|enr> [--from-slot A] [--to-slot B] [--format jsonl] [--out file]")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	if *genesisPath == "" || *peerAddr == "" {
+		fs.Usage()
+		return 2
+	}
+	if *format != "jsonl" {
+		fmt.Fprintf(os.Stderr, "unsupported format %q\n", *format)
+		return 2
+	}
+	if *toSlot != 0 && *toSlot < *fromSlot {
+		fmt.Fprintf(os.Stderr, "--to-slot %d is before --from-slot %d\n", *toSlot, *fromSlot)
+		return 2
+	}
+
+	genCfg, err := config.LoadGenesisConfig(*genesisPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "load genesis: %v\n", err)
+		return 1
+	}
+	genesisState, genesisRoot, err := exportGenesis(genCfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "build genesis: %v\n", err)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	defer cancel()
+
+	chain, err := fetchCanonicalChain(ctx, *peerAddr, genesisRoot, genesisState.Config.Preset().SlotDuration())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "fetch chain: %v\n", err)
+		return 1
+	}
+
+	var out io.Writer = os.Stdout
+	if *outPath != "" {
+		f, err := os.Create(*outPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "create output: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		out = f
+	}
+	w := bufio.NewWriter(out)
+	n, err := writeExport(w, genesisState, chain, *fromSlot, *toSlot)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "export: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "exported %d records\n", n)
+	return 0
+}
+
+// exportGenesis builds the genesis state and anchor block root the same way
+// the node does.
+func exportGenesis(cfg *config.GenesisConfig) (*types.State, [32]byte, error) {
+	state, err := statetransition.GenerateGenesisFromConfig(&statetransition.GenesisConfig{
+		GenesisTime: cfg.GenesisTime,
+		Preset:      cfg.Preset,
+		Validators:  cfg.Validators,
+	})
+	if err != nil {
+		return nil, [32]byte{}, err
+	}
+	stateRoot, err := state.HashTreeRoot()
+	if err != nil {
+		return nil, [32]byte{}, err
+	}
+	block := &types.Block{
+		ParentRoot: types.ZeroHash,
+		StateRoot:  stateRoot,
+		Body:       &types.BlockBody{Attestations: []*types.Attestation{}},
+	}
+	root, err := block.HashTreeRoot()
+	if err != nil {
+		return nil, [32]byte{}, err
+	}
+	return state, root, nil
+}
+
+// fetchCanonicalChain walks back from the peer's head to genesis and returns
+// the blocks in ascending slot order, excluding the genesis block.
+func fetchCanonicalChain(ctx context.Context, peerAddr string, genesisRoot [32]byte, slotDuration time.Duration) ([]*types.SignedBlockWithAttestation, error) {
+	pi, err := network.ParseBootnode(peerAddr)
+	if err != nil {
+		return nil, fmt.Errorf("parse peer: %w", err)
+	}
+	h, err := network.NewHost("/ip4/0.0.0.0/udp/0/quic-v1", "", nil, slotDuration)
+	if err != nil {
+		return nil, fmt.Errorf("create host: %w", err)
+	}
+	defer h.Close()
+
+	if err := h.P2P.Connect(ctx, *pi); err != nil {
+		return nil, fmt.Errorf("connect: %w", err)
+	}
+	genesis := &types.Checkpoint{Root: genesisRoot}
+	status, err := reqresp.RequestStatus(ctx, h.P2P, pi.ID, reqresp.Status{Finalized: genesis, Head: genesis})
+	if err != nil {
+		return nil, fmt.Errorf("status: %w", err)
+	}
+
+	var chain []*types.SignedBlockWithAttestation
+	root := status.Head.Root
+	for root != genesisRoot {
+		blocks, err := reqresp.RequestBlocksByRoot(ctx, h.P2P, pi.ID, [][32]byte{root})
+		if err != nil {
+			return nil, fmt.Errorf("block %x: %w", root, err)
+		}
+		if len(blocks) == 0 {
+			return nil, fmt.Errorf("peer does not have block %x", root)
+		}
+		block := blocks[0].Message.Block
+		if got, _ := block.HashTreeRoot(); got != root {
+			return nil, fmt.Errorf("peer returned block %x for root %x", got, root)
+		}
+		if block.Slot == 0 {
+			return nil, fmt.Errorf("chain does not lead to the expected genesis %x", genesisRoot)
+		}
+		chain = append(chain, blocks[0])
+		root = block.ParentRoot
+	}
+
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain, nil
+}
+
+// writeExport replays chain from genesis and writes a block record for every
+// block in [from, to], plus a checkpoint record whenever such a block moves
+// the justified or finalized checkpoint. to == 0 means no upper bound. It
+// returns the number of records written.
+func writeExport(w io.Writer, genesis *types.State, chain []*types.SignedBlockWithAttestation, from, to uint64) (int, error) {
+	enc := json.NewEncoder(w)
+	state := genesis
+	n := 0
+	for _, envelope := range chain {
+		block := envelope.Message.Block
+		if to != 0 && block.Slot > to {
+			break
+		}
+		next, err := statetransition.StateTransition(state, block)
+		if err != nil {
+			return n, fmt.Errorf("slot %d: %w", block.Slot, err)
+		}
+		prev := state
+		state = next
+		if block.Slot < from {
+			continue
+		}
+
+		root, _ := block.HashTreeRoot()
+		rec := exportBlock{
+			Type:         "block",
+			Slot:         block.Slot,
+			Root:         hexRoot(root),
+			ParentRoot:   hexRoot(block.ParentRoot),
+			StateRoot:    hexRoot(block.StateRoot),
+			Proposer:     block.ProposerIndex,
+			Attestations: make([]exportAttestation, 0, len(block.Body.Attestations)),
+			Justified:    checkpointData(state.LatestJustified),
+			Finalized:    checkpointData(state.LatestFinalized),
+		}
+		for _, att := range block.Body.Attestations {
+			rec.Attestations = append(rec.Attestations, attestationSummary(att))
+		}
+		if pa := envelope.Message.ProposerAttestation; pa != nil {
+			summary := attestationSummary(pa)
+			rec.ProposerAttestation = &summary
+		}
+		if err := enc.Encode(rec); err != nil {
+			return n, err
+		}
+		n++
+
+		for _, c := range []struct {
+			kind      string
+			old, head *types.Checkpoint
+		}{
+			{"justified", prev.LatestJustified, state.LatestJustified},
+			{"finalized", prev.LatestFinalized, state.LatestFinalized},
+		} {
+			if *c.old == *c.head {
+				continue
+			}
+			if err := enc.Encode(exportCheckpoint{
+				Type:      "checkpoint",
+				Kind:      c.kind,
+				Slot:      block.Slot,
+				BlockRoot: rec.Root,
+				Previous:  checkpointData(c.old),
+				Current:   checkpointData(c.head),
+			}); err != nil {
+				return n, err
+			}
+			n++
+		}
+	}
+	return n, nil
+}
+
+func attestationSummary(att *types.Attestation) exportAttestation {
+	return exportAttestation{
+		Validator:  att.ValidatorID,
+		Slot:       att.Data.Slot,
+		HeadRoot:   hexRoot(att.Data.Head.Root),
+		HeadSlot:   att.Data.Head.Slot,
+		TargetRoot: hexRoot(att.Data.Target.Root),
+		TargetSlot: att.Data.Target.Slot,
+		SourceRoot: hexRoot(att.Data.Source.Root),
+		SourceSlot: att.Data.Source.Slot,
+	}
+}
+
+func checkpointData(cp *types.Checkpoint) exportCheckpointData {
+	return exportCheckpointData{Root: hexRoot(cp.Root), Slot: cp.Slot}
+}
+
+func hexRoot(root [32]byte) string {
+	return "0x" + hex.EncodeToString(root[:])
+}
@@ -3,12 +3,15 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"io"
 	"log"
 	"log/slog"
+	"math"
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -18,18 +21,54 @@ import (
 )
 
 func main() {
-	genesisPath := flag.String("genesis", "", "Path to config.yaml")
-	bootnodesPath := flag.String("bootnodes", "", "Path to nodes.yaml")
-	validatorsPath := flag.String("validator-registry-path", "", "Path to validators.yaml")
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "gossip-id":
+			os.Exit(runGossipID(os.Args[2:]))
+		case "export":
+			os.Exit(runExport(os.Args[2:]))
+		case "slots":
+			os.Exit(runSlots(os.Args[2:]))
+		case "checkpoint":
+			os.Exit(runCheckpoint(os.Args[2:]))
+		case "state-diff":
+			os.Exit(runStateDiff(os.Args[2:]))
+		case "bench":
+			os.Exit(runBench(os.Args[2:]))
+		}
+	}
+
+	genesisPath := flag.String("genesis", "", "Path or HTTP(S) URL of config.yaml")
+	genesisSHA256 := flag.String("genesis-sha256", "", "Expected SHA-256 (hex) of the genesis config")
+	bootnodesPath := flag.String("bootnodes", "", "Path or HTTP(S) URL of nodes.yaml")
+	validatorsPath := flag.String("validator-registry-path", "", "Path or HTTP(S) URL of validators.yaml")
 	nodeID := flag.String("node-id", "", "Node name (index into validators.yaml)")
 	nodeKey := flag.String("node-key", "", "Path to secp256k1 private key file")
 	validatorKeys := flag.String("validator-keys", "", "Path to directory containing validator keys")
+	remoteSigner := flag.String("remote-signer", "", "HTTP(S) URL of a remote signer to sign with instead of --validator-keys")
+	remoteSignerTimeout := flag.Duration("remote-signer-timeout", 0, "Timeout of each remote signing attempt (0 = 2s)")
 	listenAddr := flag.String("listen-addr", "/ip4/0.0.0.0/udp/9000/quic-v1", "QUIC listen address")
 	metricsPort := flag.Int("metrics-port", 8080, "Prometheus metrics port (0 = disabled)")
+	apiAddr := flag.String("api-addr", "", "Loopback host:port for the admin API (empty = disabled)")
+	apiTokenFile := flag.String("api-token-file", "", "Admin API bearer token file (default <data-dir>/api_token, generated if missing)")
+	maxPeers := flag.Int("max-peers", 0, "Maximum connected peers; the worst-scoring are pruned above 90% of it (0 = 50)")
 	discoveryPort := flag.Int("discovery-port", 9000, "Discovery v5 UDP port")
 	dataDir := flag.String("data-dir", ".", "Data directory for node database and keys")
+	dbBackend := flag.String("db", "memory", "Chain storage backend: memory, or leveldb to keep the chain in <data-dir>/chain across restarts")
+	stateSnapshotInterval := flag.Uint64("state-snapshot-interval", 0, "Store the state of every Nth slot and rebuild the rest by replaying blocks (0 = store every state)")
+	archiveFinalized := flag.Bool("archive-finalized", false, "Move finalized blocks out of the chain store into flat files in <data-dir>/archive")
+	checkpointSync := flag.String("checkpoint-sync", "", "Path or HTTP(S) URL of a checkpoint bundle to start an empty database from instead of genesis")
+	checkpointSyncPubkey := flag.String("checkpoint-sync-pubkey", "", "Comma-separated hex ed25519 public keys, one of which must have signed the --checkpoint-sync bundle")
 	devnetID := flag.String("devnet-id", "devnet0", "Devnet identifier for gossip topics")
 	logLevel := flag.String("log-level", "info", "Log level (debug, info, warn, error)")
+	debugInvariants := flag.Bool("debug-invariants", false, "Assert stored state roots match their blocks (slow, for debugging)")
+	debugLeansigAllocs := flag.Bool("debug-leansig-allocs", false, "Record where each leansig keypair is allocated and report keys never freed (for debugging)")
+	maxMemory := flag.String("max-memory", "0", "Memory budget for caches, e.g. 1GiB or 512MiB (0 = unlimited)")
+	publishJitter := flag.Duration("publish-jitter", 0, "Spread attestation and aggregate publishing over this window after the interval start, offset by validator index (must be under one interval)")
+	sigWorkers := flag.Int("sig-verify-workers", 0, "Block attestation signature verification parallelism (1 = sequential, 0 = one per CPU)")
+	signingWorkers := flag.Int("signing-workers", 0, "Goroutines signing attestations and blocks for local validators (0 = one per CPU)")
+	strictCheckpoints := flag.Bool("strict-checkpoints", false, "Halt if the justified or finalized checkpoint is missing or off the head's chain after a block import, instead of logging it")
+	crossValidate := flag.Bool("cross-validate", false, "Re-run each imported block through the reference state transition and halt on divergence (slow, for debugging)")
 	flag.Parse()
 
 	// Initialize structured logger and suppress noisy stdlib log output (quic-go, etc.).
@@ -42,12 +81,26 @@ func main() {
 		logger.Error("--genesis flag is required")
 		os.Exit(1)
 	}
+	maxMemoryBytes, err := parseByteSize(*maxMemory)
+	if err != nil {
+		logger.Error("invalid --max-memory", "err", err)
+		os.Exit(1)
+	}
+
+	checkpointSyncKeys, err := parsePubkeys(*checkpointSyncPubkey)
+	if err != nil {
+		logger.Error("invalid --checkpoint-sync-pubkey", "err", err)
+		os.Exit(1)
+	}
 
 	// Print banner first.
 	logging.Banner(node.Version)
 
 	// Load genesis config.
-	genCfg, err := config.LoadGenesisConfig(*genesisPath)
+	if config.IsURL(*genesisPath) && *genesisSHA256 == "" {
+		logger.Warn("genesis config fetched over HTTP without --genesis-sha256 pinning", "url", *genesisPath)
+	}
+	genCfg, err := config.LoadGenesisConfigPinned(*genesisPath, *genesisSHA256)
 	if err != nil {
 		logger.Error("failed to load genesis config", "err", err)
 		os.Exit(1)
@@ -55,6 +108,9 @@ func main() {
 	logger.Info("genesis config loaded",
 		"genesis_time", genCfg.GenesisTime,
 		"validators", len(genCfg.Validators),
+		"seconds_per_slot", genCfg.Preset.SecondsPerSlot,
+		"justification_lookback", genCfg.Preset.JustificationLookback,
+		"proposer_selection", genCfg.Preset.ProposerSelection,
 	)
 
 	if genCfg.GenesisTime < uint64(time.Now().Unix()) {
@@ -98,17 +154,36 @@ func main() {
 	}
 
 	nodeCfg := node.Config{
-		GenesisTime:      genCfg.GenesisTime,
-		Validators:       genCfg.Validators,
-		ListenAddr:       *listenAddr,
-		NodeKeyPath:      *nodeKey,
-		Bootnodes:        bootnodes,
-		ValidatorIDs:     validatorIDs,
-		ValidatorKeysDir: *validatorKeys,
-		MetricsPort:      *metricsPort,
-		DiscoveryPort:    *discoveryPort,
-		DataDir:          *dataDir,
-		DevnetID:         *devnetID,
+		GenesisTime:           genCfg.GenesisTime,
+		Validators:            genCfg.Validators,
+		Preset:                genCfg.Preset,
+		ListenAddr:            *listenAddr,
+		NodeKeyPath:           *nodeKey,
+		Bootnodes:             bootnodes,
+		ValidatorIDs:          validatorIDs,
+		ValidatorKeysDir:      *validatorKeys,
+		RemoteSignerURL:       *remoteSigner,
+		RemoteSignerTimeout:   *remoteSignerTimeout,
+		MetricsPort:           *metricsPort,
+		APIAddr:               *apiAddr,
+		APITokenPath:          *apiTokenFile,
+		DiscoveryPort:         *discoveryPort,
+		MaxPeers:              *maxPeers,
+		DataDir:               *dataDir,
+		DevnetID:              *devnetID,
+		DebugInvariants:       *debugInvariants,
+		DebugLeansigAllocs:    *debugLeansigAllocs,
+		CrossValidate:         *crossValidate,
+		StrictCheckpoints:     *strictCheckpoints,
+		SignatureWorkers:      *sigWorkers,
+		SigningWorkers:        *signingWorkers,
+		MaxMemory:             maxMemoryBytes,
+		DBBackend:             *dbBackend,
+		PublishJitter:         *publishJitter,
+		StateSnapshotInterval: *stateSnapshotInterval,
+		ArchiveFinalized:      *archiveFinalized,
+		CheckpointSync:        *checkpointSync,
+		CheckpointSyncKeys:    checkpointSyncKeys,
 	}
 
 	n, err := node.New(nodeCfg)
@@ -146,3 +221,30 @@ func parseLevel(s string) slog.Level {
 		return slog.LevelInfo
 	}
 }
+
+// parseByteSize parses a size such as "512MiB", "2GB" or "1073741824".
+// Binary (KiB, MiB, GiB) and decimal (KB, MB, GB) suffixes are accepted.
+func parseByteSize(s string) (uint64, error) {
+	units := []struct {
+		suffix string
+		mult   uint64
+	}{
+		{"KiB", 1 << 10}, {"MiB", 1 << 20}, {"GiB", 1 << 30},
+		{"KB", 1e3}, {"MB", 1e6}, {"GB", 1e9}, {"B", 1},
+	}
+	num, mult := strings.TrimSpace(s), uint64(1)
+	for _, u := range units {
+		if n, ok := strings.CutSuffix(num, u.suffix); ok {
+			num, mult = strings.TrimSpace(n), u.mult
+			break
+		}
+	}
+	v, err := strconv.ParseUint(num, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse size %q: %w", s, err)
+	}
+	if v > math.MaxUint64/mult {
+		return 0, fmt.Errorf("size %q overflows", s)
+	}
+	return v * mult, nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/geanlabs/gean/observability/slothistory"
+)
+
+// runSlots implements `gean slots`: it prints the most recent per-slot
+// records from a node's slot history.
+func runSlots(args []string) int {
+	fs := flag.NewFlagSet("slots", flag.ExitOnError)
+	dataDir := fs.String("data-dir", ".", "Data directory of the node")
+	last := fs.Int("last", 100, "Number of most recent slots to show")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gean slots [--data-dir dir] [--last N]")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	if *last <= 0 {
+		fmt.Fprintf(os.Stderr, "--last must be positive\n")
+		return 2
+	}
+	path := filepath.Join(*dataDir, slothistory.FileName)
+	if _, err := os.Stat(path); err != nil {
+		fmt.Fprintf(os.Stderr, "no slot history: %v\n", err)
+		return 1
+	}
+	table, err := slothistory.Open(path, slothistory.DefaultCapacity)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "open slot history: %v\n", err)
+		return 1
+	}
+	defer table.Close()
+
+	records, err := table.Last(*last)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "read slot history: %v\n", err)
+		return 1
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SLOT\tHEAD\tHEAD SLOT\tBLOCK\tPROPOSER\tPROPOSED\tATTESTED\tJUSTIFIED\tFINALIZED")
+	for _, r := range records {
+		fmt.Fprintf(w, "%d\t%s\t%d\t%s\t%s\t%s\t%d\t%s\t%s\n",
+			r.Slot,
+			hex.EncodeToString(r.HeadRoot[:4]),
+			r.HeadSlot,
+			yesNo(r.Has(slothistory.FlagBlock)),
+			proposerColumn(r),
+			proposedColumn(r),
+			r.LocalAttested,
+			checkpointColumn(r.JustifiedSlot, r.Has(slothistory.FlagJustifiedChanged)),
+			checkpointColumn(r.FinalizedSlot, r.Has(slothistory.FlagFinalizedChanged)),
+		)
+	}
+	if err := w.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, "write: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func proposerColumn(r slothistory.Record) string {
+	if r.Has(slothistory.FlagLocalProposer) {
+		return fmt.Sprintf("%d (local)", r.Proposer)
+	}
+	return fmt.Sprintf("%d", r.Proposer)
+}
+
+func proposedColumn(r slothistory.Record) string {
+	if !r.Has(slothistory.FlagLocalProposer) {
+		return "-"
+	}
+	return yesNo(r.Has(slothistory.FlagLocalProposed))
+}
+
+// checkpointColumn marks a checkpoint slot that changed during the slot.
+func checkpointColumn(slot uint64, changed bool) string {
+	if changed {
+		return fmt.Sprintf("%d *", slot)
+	}
+	return fmt.Sprintf("%d", slot)
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
@@ -14,8 +14,23 @@ func main() {
 	count := flag.Int("validators", 5, "Number of keys to generate")
 	outDir := flag.String("keys-dir", "keys", "Output directory for keys")
 	printYAML := flag.Bool("print-yaml", false, "Print GENESIS_VALIDATORS yaml to stdout")
+	mnemonicFile := flag.String("mnemonic-file", "", "File holding a seed phrase to derive all keys from (default: seed keys with their index)")
+	passphrase := flag.String("passphrase", "", "Optional passphrase combined with --mnemonic-file")
 	flag.Parse()
 
+	var master []byte
+	if *mnemonicFile != "" {
+		mnemonic, err := os.ReadFile(*mnemonicFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to read mnemonic: %v\n", err)
+			os.Exit(1)
+		}
+		if master, err = leansig.MnemonicSeed(string(mnemonic), *passphrase); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid mnemonic: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
 	if err := os.MkdirAll(*outDir, 0755); err != nil {
 		fmt.Fprintf(os.Stderr, "failed to create output directory: %v\n", err)
 		os.Exit(1)
@@ -25,8 +40,16 @@ func main() {
 
 	fmt.Printf("Generating %d keys in %s...\n", *count, *outDir)
 	for i := 0; i < *count; i++ {
-		// Deterministic seed based on index
+		// Deterministic seed based on index, or derived from the mnemonic
+		// at the validator's path.
 		seed := uint64(i)
+		if master != nil {
+			var err error
+			if seed, err = leansig.DeriveKeySeed(master, leansig.ValidatorKeyPath(uint64(i))); err != nil {
+				fmt.Fprintf(os.Stderr, "failed to derive key %d: %v\n", i, err)
+				os.Exit(1)
+			}
+		}
 		// Activation epoch 0, active for 256 epochs
 		kp, err := leansig.GenerateKeypair(seed, 0, 256)
 		if err != nil {
@@ -50,7 +73,11 @@ func main() {
 		}
 		pubkeys = append(pubkeys, hex.EncodeToString(pkBytes))
 
-		fmt.Printf("Generated keypair %d\n", i)
+		if master != nil {
+			fmt.Printf("Generated keypair %d at %s\n", i, leansig.ValidatorKeyPath(uint64(i)))
+		} else {
+			fmt.Printf("Generated keypair %d\n", i)
+		}
 	}
 
 	if *printYAML {
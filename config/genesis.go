@@ -3,7 +3,6 @@ package config
 import (
 	"encoding/hex"
 	"fmt"
-	"os"
 	"strings"
 
 	"github.com/geanlabs/gean/types"
@@ -14,21 +13,57 @@ import (
 type GenesisConfig struct {
 	GenesisTime uint64             `yaml:"GENESIS_TIME"`
 	Validators  []*types.Validator // populated from GENESIS_VALIDATORS
+	// Preset is types.DefaultPreset with the SECONDS_PER_SLOT,
+	// JUSTIFICATION_LOOKBACK, PROPOSER_SELECTION, PROPOSER_SEED and
+	// DYNAMIC_VALIDATORS overrides of the devnet applied.
+	Preset types.Preset
 }
 
 // rawGenesisConfig is the on-disk YAML shape.
 type rawGenesisConfig struct {
-	GenesisTime       uint64   `yaml:"GENESIS_TIME"`
-	GenesisValidators []string `yaml:"GENESIS_VALIDATORS"`
+	GenesisTime           uint64                `yaml:"GENESIS_TIME"`
+	GenesisValidators     []rawGenesisValidator `yaml:"GENESIS_VALIDATORS"`
+	SecondsPerSlot        *uint64               `yaml:"SECONDS_PER_SLOT"`
+	JustificationLookback *uint64               `yaml:"JUSTIFICATION_LOOKBACK"`
+	ProposerSelection     string                `yaml:"PROPOSER_SELECTION"`
+	ProposerSeed          string                `yaml:"PROPOSER_SEED"`
+	DynamicValidators     bool                  `yaml:"DYNAMIC_VALIDATORS"`
 }
 
-// LoadGenesisConfig loads and parses a genesis config YAML file.
+// rawGenesisValidator is a GENESIS_VALIDATORS entry: either a hex pubkey, for
+// a validator active from genesis, or a mapping with the pubkey and an
+// activation epoch.
+type rawGenesisValidator struct {
+	Pubkey          string `yaml:"pubkey"`
+	ActivationEpoch uint64 `yaml:"activation_epoch"`
+}
+
+func (v *rawGenesisValidator) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&v.Pubkey)
+	}
+	type plain rawGenesisValidator
+	return node.Decode((*plain)(v))
+}
+
+// LoadGenesisConfig loads and parses a genesis config YAML file from a path
+// or HTTP(S) URL.
 func LoadGenesisConfig(path string) (*GenesisConfig, error) {
-	data, err := os.ReadFile(path)
+	return LoadGenesisConfigPinned(path, "")
+}
+
+// LoadGenesisConfigPinned is LoadGenesisConfig with an optional expected
+// SHA-256 of the file contents.
+func LoadGenesisConfigPinned(path, wantSHA256 string) (*GenesisConfig, error) {
+	data, err := ReadSource(path, wantSHA256)
 	if err != nil {
 		return nil, fmt.Errorf("read config: %w", err)
 	}
+	return ParseGenesisConfig(data)
+}
 
+// ParseGenesisConfig parses genesis config YAML.
+func ParseGenesisConfig(data []byte) (*GenesisConfig, error) {
 	var raw rawGenesisConfig
 	if err := yaml.Unmarshal(data, &raw); err != nil {
 		return nil, fmt.Errorf("parse config: %w", err)
@@ -38,23 +73,60 @@ func LoadGenesisConfig(path string) (*GenesisConfig, error) {
 		return nil, fmt.Errorf("GENESIS_VALIDATORS must not be empty")
 	}
 
+	preset := types.DefaultPreset
+	if raw.SecondsPerSlot != nil {
+		preset.SecondsPerSlot = *raw.SecondsPerSlot
+	}
+	if raw.JustificationLookback != nil {
+		preset.JustificationLookback = *raw.JustificationLookback
+	}
+	if raw.ProposerSelection != "" {
+		sel, err := types.ParseProposerSelection(raw.ProposerSelection)
+		if err != nil {
+			return nil, fmt.Errorf("invalid preset: %w", err)
+		}
+		preset.ProposerSelection = sel
+	}
+	if raw.ProposerSeed != "" {
+		seed, err := hex.DecodeString(strings.TrimPrefix(raw.ProposerSeed, "0x"))
+		if err != nil || len(seed) != 32 {
+			return nil, fmt.Errorf("PROPOSER_SEED must be 32 bytes of hex")
+		}
+		copy(preset.ProposerSeed[:], seed)
+	}
+	preset.DynamicValidators = raw.DynamicValidators
+	if err := preset.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid preset: %w", err)
+	}
+
 	validators := make([]*types.Validator, len(raw.GenesisValidators))
-	for i, hexStr := range raw.GenesisValidators {
-		hexStr = strings.TrimPrefix(hexStr, "0x")
+	for i, entry := range raw.GenesisValidators {
+		hexStr := strings.TrimPrefix(entry.Pubkey, "0x")
 		pubkeyBytes, err := hex.DecodeString(hexStr)
 		if err != nil {
 			return nil, fmt.Errorf("invalid pubkey hex at index %d: %w", i, err)
 		}
-		if len(pubkeyBytes) != 52 {
-			return nil, fmt.Errorf("pubkey at index %d is %d bytes, want 52", i, len(pubkeyBytes))
+		if len(pubkeyBytes) != types.XMSSPubkeySize {
+			return nil, fmt.Errorf("pubkey at index %d is %d bytes, want %d", i, len(pubkeyBytes), types.XMSSPubkeySize)
 		}
-		var pubkey [52]byte
+		var pubkey [types.XMSSPubkeySize]byte
 		copy(pubkey[:], pubkeyBytes)
-		validators[i] = &types.Validator{Pubkey: pubkey, Index: uint64(i)}
+		if entry.ActivationEpoch >= types.FarFutureEpoch {
+			return nil, fmt.Errorf("validator %d activation epoch %d is out of range", i, entry.ActivationEpoch)
+		}
+		if entry.ActivationEpoch != 0 && !preset.DynamicValidators {
+			return nil, fmt.Errorf("validator %d activation epoch %d needs DYNAMIC_VALIDATORS", i, entry.ActivationEpoch)
+		}
+		validators[i] = &types.Validator{
+			Pubkey:          pubkey,
+			Index:           uint64(i),
+			ActivationEpoch: entry.ActivationEpoch,
+		}
 	}
 
 	return &GenesisConfig{
 		GenesisTime: raw.GenesisTime,
 		Validators:  validators,
+		Preset:      preset,
 	}, nil
 }
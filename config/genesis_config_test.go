@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/geanlabs/gean/config"
+	"github.com/geanlabs/gean/types"
 )
 
 func TestLoadGenesisConfigParsesValidators(t *testing.T) {
@@ -100,6 +101,74 @@ GENESIS_VALIDATORS:
 	}
 }
 
+func TestLoadGenesisConfigPresetAndActivationEpochs(t *testing.T) {
+	yaml := `
+GENESIS_TIME: 1000
+SECONDS_PER_SLOT: 8
+PROPOSER_SELECTION: shuffled
+PROPOSER_SEED: "0x0100000000000000000000000000000000000000000000000000000000000002"
+DYNAMIC_VALIDATORS: true
+GENESIS_VALIDATORS:
+  - "e2a03c16122c7e0f940e2301aa460c54a2e1e8343968bb2782f26636f051e65ec589c858b9c7980b276ebe550056b23f0bdc3b5a"
+  - pubkey: "0x0767e65924063f79ae92ee1953685f06718b1756cc665a299bd61b4b82055e377237595d9a27887421b5233d09a50832db2f303d"
+    activation_epoch: 2
+`
+	cfg, err := config.LoadGenesisConfig(writeTempYAML(t, yaml))
+	if err != nil {
+		t.Fatalf("LoadGenesisConfig: %v", err)
+	}
+	want := types.DefaultPreset
+	want.SecondsPerSlot = 8
+	want.ProposerSelection = types.ProposerShuffled
+	want.ProposerSeed[0], want.ProposerSeed[31] = 1, 2
+	want.DynamicValidators = true
+	if cfg.Preset != want {
+		t.Fatalf("Preset = %+v, want %+v", cfg.Preset, want)
+	}
+	if cfg.Validators[0].ActivationEpoch != 0 || cfg.Validators[1].ActivationEpoch != 2 {
+		t.Fatalf("activation epochs %d, %d, want 0, 2", cfg.Validators[0].ActivationEpoch, cfg.Validators[1].ActivationEpoch)
+	}
+	if cfg.Validators[1].Pubkey[0] != 0x07 || cfg.Validators[1].Index != 1 {
+		t.Fatalf("Validators[1] = %+v", cfg.Validators[1])
+	}
+}
+
+func TestLoadGenesisConfigRejectsLateActivationWithoutDynamicValidators(t *testing.T) {
+	yaml := `
+GENESIS_TIME: 1000
+GENESIS_VALIDATORS:
+  - pubkey: "0x0767e65924063f79ae92ee1953685f06718b1756cc665a299bd61b4b82055e377237595d9a27887421b5233d09a50832db2f303d"
+    activation_epoch: 2
+`
+	if _, err := config.LoadGenesisConfig(writeTempYAML(t, yaml)); err == nil {
+		t.Fatal("expected error for a late activation on a spec chain")
+	}
+}
+
+func TestLoadGenesisConfigRejectsUnevenSlots(t *testing.T) {
+	yaml := `
+GENESIS_TIME: 1000
+SECONDS_PER_SLOT: 6
+GENESIS_VALIDATORS:
+  - "e2a03c16122c7e0f940e2301aa460c54a2e1e8343968bb2782f26636f051e65ec589c858b9c7980b276ebe550056b23f0bdc3b5a"
+`
+	if _, err := config.LoadGenesisConfig(writeTempYAML(t, yaml)); err == nil {
+		t.Fatal("expected error for slots that do not split into whole intervals")
+	}
+}
+
+func TestLoadGenesisConfigRejectsUnknownProposerSelection(t *testing.T) {
+	yaml := `
+GENESIS_TIME: 1000
+PROPOSER_SELECTION: lottery
+GENESIS_VALIDATORS:
+  - "e2a03c16122c7e0f940e2301aa460c54a2e1e8343968bb2782f26636f051e65ec589c858b9c7980b276ebe550056b23f0bdc3b5a"
+`
+	if _, err := config.LoadGenesisConfig(writeTempYAML(t, yaml)); err == nil {
+		t.Fatal("expected error for unknown proposer selection")
+	}
+}
+
 func writeTempYAML(t *testing.T, content string) string {
 	t.Helper()
 	dir := t.TempDir()
@@ -2,7 +2,6 @@ package config
 
 import (
 	"fmt"
-	"os"
 
 	"gopkg.in/yaml.v3"
 )
@@ -12,12 +11,13 @@ type bootnodeEntry struct {
 	Multiaddr string `yaml:"multiaddr"`
 }
 
-// LoadBootnodes loads a nodes.yaml file and returns raw bootnode strings.
+// LoadBootnodes loads a nodes.yaml file from a path or HTTP(S) URL and
+// returns raw bootnode strings.
 // Supports both formats:
 //   - Legacy:  [{multiaddr: "/ip4/..."}]
 //   - ENR:     ["enr:-IW4Q..."]
 func LoadBootnodes(path string) ([]string, error) {
-	data, err := os.ReadFile(path)
+	data, err := ReadSource(path, "")
 	if err != nil {
 		return nil, fmt.Errorf("read nodes: %w", err)
 	}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// fetchTimeout bounds a remote config download.
+	fetchTimeout = 30 * time.Second
+
+	// maxRemoteConfigSize caps the size of a remote config file.
+	maxRemoteConfigSize = 16 << 20
+)
+
+// IsURL reports whether location is an HTTP(S) URL rather than a file path.
+func IsURL(location string) bool {
+	return strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://")
+}
+
+// ReadSource returns the contents of a config file given as a local path or
+// an HTTP(S) URL. If wantSHA256 is non-empty the contents must hash to it
+// (hex, optionally 0x-prefixed).
+func ReadSource(location, wantSHA256 string) ([]byte, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if IsURL(location) {
+		data, err = fetch(location)
+	} else {
+		data, err = os.ReadFile(location)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if wantSHA256 != "" {
+		sum := sha256.Sum256(data)
+		got := hex.EncodeToString(sum[:])
+		want := strings.ToLower(strings.TrimPrefix(wantSHA256, "0x"))
+		if got != want {
+			return nil, fmt.Errorf("checksum mismatch for %s: got sha256 %s, want %s", location, got, want)
+		}
+	}
+	return data, nil
+}
+
+func fetch(url string) ([]byte, error) {
+	client := &http.Client{Timeout: fetchTimeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch %s: unexpected status %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteConfigSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("fetch %s: %w", url, err)
+	}
+	if len(data) > maxRemoteConfigSize {
+		return nil, fmt.Errorf("fetch %s: response exceeds %d bytes", url, maxRemoteConfigSize)
+	}
+	return data, nil
+}
@@ -0,0 +1,69 @@
+package config_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/geanlabs/gean/config"
+)
+
+const remoteGenesisYAML = `
+GENESIS_TIME: 1704085200
+GENESIS_VALIDATORS:
+  - "e2a03c16122c7e0f940e2301aa460c54a2e1e8343968bb2782f26636f051e65ec589c858b9c7980b276ebe550056b23f0bdc3b5a"
+`
+
+func serveConfig(t *testing.T, body string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/config.yaml" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestLoadGenesisConfigFromURLWithChecksum(t *testing.T) {
+	srv := serveConfig(t, remoteGenesisYAML)
+	sum := sha256.Sum256([]byte(remoteGenesisYAML))
+
+	cfg, err := config.LoadGenesisConfigPinned(srv.URL+"/config.yaml", "0x"+hex.EncodeToString(sum[:]))
+	if err != nil {
+		t.Fatalf("LoadGenesisConfigPinned: %v", err)
+	}
+	if cfg.GenesisTime != 1704085200 || len(cfg.Validators) != 1 {
+		t.Fatalf("unexpected config: time=%d validators=%d", cfg.GenesisTime, len(cfg.Validators))
+	}
+}
+
+func TestLoadGenesisConfigRejectsChecksumMismatch(t *testing.T) {
+	srv := serveConfig(t, remoteGenesisYAML)
+	wrong := hex.EncodeToString(make([]byte, 32))
+	if _, err := config.LoadGenesisConfigPinned(srv.URL+"/config.yaml", wrong); err == nil {
+		t.Fatal("expected checksum mismatch error")
+	}
+}
+
+func TestReadSourceRejectsHTTPErrors(t *testing.T) {
+	srv := serveConfig(t, remoteGenesisYAML)
+	if _, err := config.ReadSource(srv.URL+"/missing.yaml", ""); err == nil {
+		t.Fatal("expected error for 404 response")
+	}
+}
+
+func TestReadSourceChecksumAppliesToFiles(t *testing.T) {
+	path := writeTempYAML(t, remoteGenesisYAML)
+	sum := sha256.Sum256([]byte(remoteGenesisYAML))
+	if _, err := config.ReadSource(path, hex.EncodeToString(sum[:])); err != nil {
+		t.Fatalf("ReadSource: %v", err)
+	}
+	if _, err := config.ReadSource(path, hex.EncodeToString(make([]byte, 32))); err == nil {
+		t.Fatal("expected checksum mismatch error")
+	}
+}
@@ -2,7 +2,6 @@ package config
 
 import (
 	"fmt"
-	"os"
 
 	"gopkg.in/yaml.v3"
 )
@@ -18,9 +17,10 @@ type ValidatorRegistry struct {
 	Assignments []ValidatorAssignment `yaml:"assignments"`
 }
 
-// LoadValidators loads and parses a validators.yaml file.
+// LoadValidators loads and parses a validators.yaml file from a path or
+// HTTP(S) URL.
 func LoadValidators(path string) (*ValidatorRegistry, error) {
-	data, err := os.ReadFile(path)
+	data, err := ReadSource(path, "")
 	if err != nil {
 		return nil, fmt.Errorf("read validators: %w", err)
 	}
@@ -0,0 +1,133 @@
+// Package lru is the least-recently-used cache behind the state and signature
+// caches of fork choice and the state caches of the storage backends.
+package lru
+
+import (
+	"container/list"
+	"sync"
+)
+
+// Cache is an LRU of at most its capacity entries, pinned ones included,
+// unless more keys are pinned than fit. Pinned keys are never evicted or
+// cleared, only removed. A Cache is safe for concurrent use.
+type Cache[K comparable, V any] struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // front is most recently used
+	entries  map[K]*list.Element
+	pinned   map[K]struct{}
+}
+
+type entry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// New returns a cache holding up to capacity entries, at least one.
+func New[K comparable, V any](capacity int) *Cache[K, V] {
+	return &Cache[K, V]{
+		capacity: max(capacity, 1),
+		order:    list.New(),
+		entries:  make(map[K]*list.Element),
+	}
+}
+
+// Get returns the value for key and marks it most recently used.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*entry[K, V]).value, true
+}
+
+// Add sets the value for key, marks it most recently used and evicts down
+// to capacity.
+func (c *Cache[K, V]) Add(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok {
+		e.Value.(*entry[K, V]).value = value
+		c.order.MoveToFront(e)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&entry[K, V]{key: key, value: value})
+	c.evictLocked()
+}
+
+// Remove drops keys, pinned or not.
+func (c *Cache[K, V]) Remove(keys ...K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		if e, ok := c.entries[key]; ok {
+			c.order.Remove(e)
+			delete(c.entries, key)
+		}
+	}
+}
+
+// Pin replaces the pinned keys and evicts down to capacity.
+func (c *Cache[K, V]) Pin(keys ...K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pinned = make(map[K]struct{}, len(keys))
+	for _, key := range keys {
+		c.pinned[key] = struct{}{}
+	}
+	c.evictLocked()
+}
+
+// Resize sets the capacity, at least one, and evicts down to it.
+func (c *Cache[K, V]) Resize(capacity int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.capacity = max(capacity, 1)
+	c.evictLocked()
+}
+
+// Clear drops every unpinned entry and returns how many there were.
+func (c *Cache[K, V]) Clear() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for e := c.order.Front(); e != nil; {
+		next := e.Next()
+		if key := e.Value.(*entry[K, V]).key; !c.isPinned(key) {
+			c.order.Remove(e)
+			delete(c.entries, key)
+			n++
+		}
+		e = next
+	}
+	return n
+}
+
+// Len returns the number of entries, pinned ones included.
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *Cache[K, V]) isPinned(key K) bool {
+	_, ok := c.pinned[key]
+	return ok
+}
+
+// evictLocked drops least recently used unpinned entries until the cache is
+// within capacity or holds only pinned entries.
+func (c *Cache[K, V]) evictLocked() {
+	for e := c.order.Back(); e != nil && c.order.Len() > c.capacity; {
+		prev := e.Prev()
+		if key := e.Value.(*entry[K, V]).key; !c.isPinned(key) {
+			c.order.Remove(e)
+			delete(c.entries, key)
+		}
+		e = prev
+	}
+}
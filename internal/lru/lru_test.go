@@ -0,0 +1,70 @@
+package lru_test
+
+import (
+	"testing"
+
+	"github.com/geanlabs/gean/internal/lru"
+)
+
+func TestEvictsLeastRecentlyUsed(t *testing.T) {
+	c := lru.New[int, string](2)
+	c.Add(1, "a")
+	c.Add(2, "b")
+	c.Get(1)
+	c.Add(3, "c")
+
+	if _, ok := c.Get(2); ok {
+		t.Fatal("least recently used entry kept")
+	}
+	for _, k := range []int{1, 3} {
+		if _, ok := c.Get(k); !ok {
+			t.Fatalf("entry %d evicted", k)
+		}
+	}
+}
+
+func TestPinnedEntriesSurviveEvictionAndClear(t *testing.T) {
+	c := lru.New[int, string](2)
+	c.Add(1, "a")
+	c.Pin(1)
+	c.Add(2, "b")
+	c.Add(3, "c")
+
+	if _, ok := c.Get(1); !ok {
+		t.Fatal("pinned entry evicted")
+	}
+	if _, ok := c.Get(2); ok {
+		t.Fatal("least recently used unpinned entry kept")
+	}
+	if c.Len() != 2 {
+		t.Fatalf("len = %d, want 2", c.Len())
+	}
+	if n := c.Clear(); n != 1 {
+		t.Fatalf("cleared %d entries, want 1", n)
+	}
+	if _, ok := c.Get(1); !ok {
+		t.Fatal("pinned entry cleared")
+	}
+	c.Remove(1)
+	if _, ok := c.Get(1); ok {
+		t.Fatal("pinned entry not removed")
+	}
+}
+
+func TestResizeEvicts(t *testing.T) {
+	c := lru.New[int, int](4)
+	for i := 0; i < 4; i++ {
+		c.Add(i, i)
+	}
+	c.Resize(2)
+	if c.Len() != 2 {
+		t.Fatalf("len = %d after resize, want 2", c.Len())
+	}
+	if _, ok := c.Get(3); !ok {
+		t.Fatal("most recent entry evicted")
+	}
+	c.Resize(0)
+	if c.Len() != 1 {
+		t.Fatalf("len = %d after resize to zero, want 1", c.Len())
+	}
+}
@@ -0,0 +1,197 @@
+// Package testutil builds the validators, states, blocks and fork choice
+// stores that the tests of the chain packages and the node share.
+package testutil
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/geanlabs/gean/chain/forkchoice"
+	"github.com/geanlabs/gean/chain/statetransition"
+	"github.com/geanlabs/gean/storage/memory"
+	"github.com/geanlabs/gean/types"
+)
+
+// GenesisTime is the genesis time of the chains built here.
+const GenesisTime = 1000
+
+// Validators returns n validators with zero keys, active from genesis.
+func Validators(n uint64) []*types.Validator {
+	vals := make([]*types.Validator, n)
+	for i := uint64(0); i < n; i++ {
+		vals[i] = &types.Validator{
+			Pubkey: [52]byte{},
+			Index:  i,
+		}
+	}
+	return vals
+}
+
+// Genesis returns the genesis state of the spec chain of validators.
+func Genesis(validators []*types.Validator) *types.State {
+	return statetransition.GenerateGenesis(GenesisTime, validators)
+}
+
+// DynamicGenesis returns the genesis state of a chain of validators with
+// dynamic validators enabled.
+func DynamicGenesis(validators []*types.Validator) *types.State {
+	st := statetransition.GenerateGenesis(GenesisTime, validators)
+	preset := types.DefaultPreset
+	preset.DynamicValidators = true
+	st.Config = types.NewConfig(GenesisTime, preset)
+	return st
+}
+
+// Deposit and Exit return unsigned operations signed at slot; the
+// signatures are only checked in builds that verify them.
+func Deposit(pubkey byte, slot uint64) *types.SignedDeposit {
+	return &types.SignedDeposit{Message: &types.Deposit{Pubkey: [52]byte{pubkey}, Slot: slot}}
+}
+
+func Exit(index, slot uint64) *types.SignedVoluntaryExit {
+	return &types.SignedVoluntaryExit{Message: &types.VoluntaryExit{ValidatorIndex: index, Slot: slot}}
+}
+
+// ApplyBody runs a block with body at slot on pre through both state
+// transitions and checks they agree.
+func ApplyBody(t testing.TB, pre *types.State, slot uint64, body *types.BlockBody) (*types.State, error) {
+	t.Helper()
+	st, err := statetransition.ProcessSlots(pre, slot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parent, _ := st.LatestBlockHeader.HashTreeRoot()
+	proposer, _ := statetransition.StateProposerIndex(st, slot)
+	block := &types.Block{Slot: slot, ProposerIndex: proposer, ParentRoot: parent, Body: body}
+	post, err := statetransition.ProcessBlock(st, block)
+	if err != nil {
+		return nil, err
+	}
+	block.StateRoot, _ = post.HashTreeRoot()
+	ref, err := statetransition.ReferenceStateTransition(pre, block)
+	if err != nil {
+		t.Fatalf("reference transition: %v", err)
+	}
+	if diffs := statetransition.DiffStates(post, ref); len(diffs) > 0 {
+		t.Fatalf("reference transition diverged: %v", diffs)
+	}
+	return post, nil
+}
+
+// HeaderRootAt returns the root of the latest block of pre once advanced to
+// slot.
+func HeaderRootAt(t testing.TB, pre *types.State, slot uint64) [32]byte {
+	t.Helper()
+	st, err := statetransition.ProcessSlots(pre, slot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, _ := st.LatestBlockHeader.HashTreeRoot()
+	return root
+}
+
+// AnchoredStore returns a fork choice store anchored at the genesis block of
+// state, and that block and its root.
+func AnchoredStore(t testing.TB, state *types.State) (*forkchoice.Store, *types.Block, [32]byte) {
+	t.Helper()
+	stateRoot, _ := state.HashTreeRoot()
+	genesisBlock := &types.Block{
+		Slot:       0,
+		ParentRoot: types.ZeroHash,
+		StateRoot:  stateRoot,
+		Body:       &types.BlockBody{Attestations: []*types.Attestation{}},
+	}
+	fc := forkchoice.NewStore(state, genesisBlock, memory.New())
+	root, _ := genesisBlock.HashTreeRoot()
+	return fc, genesisBlock, root
+}
+
+// BuildBlock returns the block at slot on parent with body, proposed by the
+// proposer of that slot and committing to its post-state.
+func BuildBlock(t testing.TB, fc *forkchoice.Store, slot uint64, parent [32]byte, body *types.BlockBody) *types.Block {
+	t.Helper()
+	pre, ok := fc.GetState(parent)
+	if !ok {
+		t.Fatalf("no state for parent %x", parent)
+	}
+	st, err := statetransition.ProcessSlots(pre, slot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proposer, _ := statetransition.StateProposerIndex(st, slot)
+	block := &types.Block{Slot: slot, ProposerIndex: proposer, ParentRoot: parent, Body: body}
+	if st, err = statetransition.ProcessBlock(st, block); err != nil {
+		t.Fatal(err)
+	}
+	block.StateRoot, _ = st.HashTreeRoot()
+	return block
+}
+
+// ImportEmptyBlock imports a block without attestations at slot on parent
+// and returns its root.
+func ImportEmptyBlock(t testing.TB, fc *forkchoice.Store, slot uint64, parent [32]byte) [32]byte {
+	t.Helper()
+	block := BuildBlock(t, fc, slot, parent, &types.BlockBody{Attestations: []*types.Attestation{}})
+	if err := fc.ProcessBlock(&types.SignedBlockWithAttestation{Message: &types.BlockWithAttestation{Block: block}}); err != nil {
+		t.Fatalf("import block: %v", err)
+	}
+	root, _ := block.HashTreeRoot()
+	return root
+}
+
+// ImportProposal imports a block at slot on parent with the given deposits,
+// carrying a proposer attestation that votes from the anchor, and returns
+// the envelope.
+func ImportProposal(t testing.TB, fc *forkchoice.Store, slot uint64, parent [32]byte, deposits []*types.SignedDeposit) *types.SignedBlockWithAttestation {
+	t.Helper()
+	block := BuildBlock(t, fc, slot, parent, &types.BlockBody{Attestations: []*types.Attestation{}, Deposits: deposits})
+	root, _ := block.HashTreeRoot()
+	anchor := fc.Anchor()
+	cp := &types.Checkpoint{Root: anchor.Root, Slot: anchor.Slot}
+	envelope := &types.SignedBlockWithAttestation{
+		Message: &types.BlockWithAttestation{
+			Block: block,
+			ProposerAttestation: &types.Attestation{
+				ValidatorID: block.ProposerIndex,
+				Data: &types.AttestationData{
+					Slot:   slot,
+					Head:   &types.Checkpoint{Root: root, Slot: slot},
+					Target: cp,
+					Source: cp,
+				},
+			},
+		},
+		Signature: [][3112]byte{{0xAA}},
+	}
+	if err := fc.ProcessBlock(envelope); err != nil {
+		t.Fatalf("import block: %v", err)
+	}
+	return envelope
+}
+
+// Signer returns Sig, or a fixed placeholder if Sig is nil, as the signature
+// of every message.
+type Signer struct {
+	Sig []byte
+}
+
+func (s *Signer) Sign(epoch uint32, message [32]byte) ([]byte, error) {
+	if s.Sig != nil {
+		return s.Sig, nil
+	}
+	out := make([]byte, 3112)
+	out[0] = 0xAA
+	return out, nil
+}
+
+// CounterValue returns the current value of c.
+func CounterValue(t testing.TB, c prometheus.Counter) float64 {
+	t.Helper()
+	var m dto.Metric
+	if err := c.Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	return m.GetCounter().GetValue()
+}
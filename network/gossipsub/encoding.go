@@ -3,6 +3,7 @@ package gossipsub
 import (
 	"context"
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/binary"
 	"fmt"
 
@@ -38,12 +39,22 @@ func PublishAttestation(ctx context.Context, topic *pubsub.Topic, sa *types.Sign
 }
 
 // PublishAggregatedAttestation publishes an aggregated attestation to gossip.
-// Wire format: data_ssz_len(4) + data_ssz + bits_len(4) + bits + agg_sig.
 func PublishAggregatedAttestation(ctx context.Context, topic *pubsub.Topic, agg *types.AggregatedAttestation) error {
-	dataSSZ, err := agg.Data.MarshalSSZ()
+	buf, err := EncodeAggregatedAttestation(agg)
 	if err != nil {
 		return err
 	}
+	return topic.Publish(ctx, snappy.Encode(nil, buf))
+}
+
+// EncodeAggregatedAttestation encodes an aggregated attestation in its
+// uncompressed wire format, the inverse of DecodeAggregatedAttestation.
+// Wire format: data_ssz_len(4) + data_ssz + bits_len(4) + bits + agg_sig.
+func EncodeAggregatedAttestation(agg *types.AggregatedAttestation) ([]byte, error) {
+	dataSSZ, err := agg.Data.MarshalSSZ()
+	if err != nil {
+		return nil, err
+	}
 
 	var buf []byte
 	dataLen := make([]byte, 4)
@@ -57,8 +68,7 @@ func PublishAggregatedAttestation(ctx context.Context, topic *pubsub.Topic, agg
 	buf = append(buf, agg.AggregationBits...)
 
 	buf = append(buf, agg.AggregatedSignature...)
-
-	return topic.Publish(ctx, snappy.Encode(nil, buf))
+	return buf, nil
 }
 
 // DecodeAggregatedAttestation decodes a raw aggregated attestation message.
@@ -66,10 +76,16 @@ func DecodeAggregatedAttestation(data []byte) (*types.AggregatedAttestation, err
 	if len(data) < 8 {
 		return nil, fmt.Errorf("message too short: %d", len(data))
 	}
+	if len(data) > maxAggregatedAttestationSize {
+		return nil, ErrMessageTooLarge
+	}
 
 	offset := 0
 	dataLen := int(binary.LittleEndian.Uint32(data[offset : offset+4]))
 	offset += 4
+	if dataLen != new(types.AttestationData).SizeSSZ() {
+		return nil, fmt.Errorf("unexpected attestation data length %d", dataLen)
+	}
 	if offset+dataLen > len(data) {
 		return nil, fmt.Errorf("data length exceeds message")
 	}
@@ -84,6 +100,9 @@ func DecodeAggregatedAttestation(data []byte) (*types.AggregatedAttestation, err
 	}
 	bitsLen := int(binary.LittleEndian.Uint32(data[offset : offset+4]))
 	offset += 4
+	if bitsLen > maxAggregationBitsSize {
+		return nil, fmt.Errorf("aggregation bits length %d exceeds limit", bitsLen)
+	}
 	if offset+bitsLen > len(data) {
 		return nil, fmt.Errorf("bits length exceeds message")
 	}
@@ -91,6 +110,9 @@ func DecodeAggregatedAttestation(data []byte) (*types.AggregatedAttestation, err
 	copy(bits, data[offset:offset+bitsLen])
 	offset += bitsLen
 
+	if (len(data)-offset)%types.XMSSSignatureSize != 0 {
+		return nil, fmt.Errorf("aggregated signature length %d not a multiple of %d", len(data)-offset, types.XMSSSignatureSize)
+	}
 	aggSig := make([]byte, len(data)-offset)
 	copy(aggSig, data[offset:])
 
@@ -101,7 +123,17 @@ func DecodeAggregatedAttestation(data []byte) (*types.AggregatedAttestation, err
 	}, nil
 }
 
+// snappyMaxExpansion bounds how much larger than its input a valid snappy
+// block decompresses to: the densest element, a 3-byte copy, yields 64 bytes.
+const snappyMaxExpansion = 22
+
 // ComputeMessageID computes SHA256(domain + uint64_le(topic_len) + topic + data)[:20].
+//
+// The domain depends only on whether data is valid snappy, as the spec
+// requires: topic size limits are enforced by the topic validators, not
+// here, so an oversized message keeps its valid-snappy ID. A length header
+// claiming more than snappyMaxExpansion times the input cannot belong to a
+// valid block and is not allocated.
 func ComputeMessageID(pmsg *pb.Message) string {
 	topic := pmsg.GetTopic()
 	data := pmsg.GetData()
@@ -109,11 +141,18 @@ func ComputeMessageID(pmsg *pb.Message) string {
 	// Try snappy decompress to determine domain.
 	domain := DomainInvalidSnappy
 	msgData := data
-	if decoded, err := snappy.Decode(nil, data); err == nil {
+	if decoded, bp, err := decodeSnappy(data, len(data)*snappyMaxExpansion); err == nil {
+		defer releaseBuffer(bp)
 		domain = DomainValidSnappy
 		msgData = decoded
 	}
 
+	return ComputeMessageIDWithDomain(domain, topic, msgData)
+}
+
+// ComputeMessageIDWithDomain computes the message ID for data under an
+// explicit domain. For DomainValidSnappy, data is the decompressed payload.
+func ComputeMessageIDWithDomain(domain []byte, topic string, data []byte) string {
 	topicBytes := []byte(topic)
 	var topicLen [8]byte
 	binary.LittleEndian.PutUint64(topicLen[:], uint64(len(topicBytes)))
@@ -122,8 +161,14 @@ func ComputeMessageID(pmsg *pb.Message) string {
 	h.Write(domain)
 	h.Write(topicLen[:])
 	h.Write(topicBytes)
-	h.Write(msgData)
+	h.Write(data)
 	digest := h.Sum(nil)
 
 	return string(digest[:20])
 }
+
+// TopicHash returns the libp2p hashed form of a topic: base64(sha256(topic)).
+func TopicHash(topic string) string {
+	sum := sha256.Sum256([]byte(topic))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
@@ -21,12 +21,17 @@ type Topics struct {
 	Block                *pubsub.Topic
 	Attestation          *pubsub.Topic
 	AggregateAttestation *pubsub.Topic
+
+	ps *pubsub.PubSub // registers the topic validators on subscribe
 }
 
-// NewGossipSub creates a configured gossipsub instance.
-func NewGossipSub(ctx context.Context, h host.Host) (*pubsub.PubSub, error) {
-	return pubsub.NewGossipSub(ctx, h,
+// NewGossipSub creates a configured gossipsub instance with peer scoring,
+// whose counters decay once per slot of slotDuration. Extra options are
+// applied after the defaults.
+func NewGossipSub(ctx context.Context, h host.Host, slotDuration time.Duration, opts ...pubsub.Option) (*pubsub.PubSub, error) {
+	return pubsub.NewGossipSub(ctx, h, append([]pubsub.Option{
 		pubsub.WithMessageSignaturePolicy(pubsub.StrictNoSign),
+		pubsub.WithPeerScore(peerScoreParams(slotDuration), peerScoreThresholds()),
 		pubsub.WithGossipSubParams(pubsub.GossipSubParams{
 			D:                         8,
 			Dlo:                       6,
@@ -51,13 +56,15 @@ func NewGossipSub(ctx context.Context, h host.Host) (*pubsub.PubSub, error) {
 			MaxIHaveMessages:          10,
 			IWantFollowupTime:         3 * time.Second,
 		}),
-		pubsub.WithSeenMessagesTTL(24*time.Second),
+		pubsub.WithSeenMessagesTTL(24 * time.Second),
 		pubsub.WithMessageIdFn(ComputeMessageID),
-	)
+	}, opts...)...)
 }
 
-// JoinTopics joins the block and attestation gossip topics.
-func JoinTopics(ps *pubsub.PubSub, devnetID string) (*Topics, error) {
+// JoinTopics joins the block and attestation gossip topics and sets their
+// peer score parameters, sized for numValidators attesters and slots of
+// slotDuration.
+func JoinTopics(ps *pubsub.PubSub, devnetID string, numValidators int, slotDuration time.Duration) (*Topics, error) {
 	blockTopic, err := ps.Join(fmt.Sprintf(BlockTopicFmt, devnetID))
 	if err != nil {
 		return nil, fmt.Errorf("join block topic: %w", err)
@@ -67,5 +74,9 @@ func JoinTopics(ps *pubsub.PubSub, devnetID string) (*Topics, error) {
 		return nil, fmt.Errorf("join attestation topic: %w", err)
 	}
 	// aggregate_attestation is not part of current devnet-1 interop topics.
-	return &Topics{Block: blockTopic, Attestation: attTopic}, nil
+	topics := &Topics{Block: blockTopic, Attestation: attTopic, ps: ps}
+	if err := setTopicScores(topics, numValidators, slotDuration); err != nil {
+		return nil, err
+	}
+	return topics, nil
 }
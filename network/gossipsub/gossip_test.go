@@ -4,6 +4,7 @@ import (
 	"crypto/sha256"
 	"encoding/binary"
 	"encoding/hex"
+	"errors"
 	"testing"
 
 	"github.com/golang/snappy"
@@ -105,3 +106,34 @@ func TestComputeMessageIDInvalidSnappyVectors(t *testing.T) {
 		t.Errorf("invalid snappy message ID mismatch:\n  got:  %s\n  want: %s", got, expected)
 	}
 }
+
+func TestMessageIDVectorsFile(t *testing.T) {
+	vectors, err := gossipsub.LoadMessageIDVectors("testdata/message_id_vectors.json")
+	if err != nil {
+		t.Fatalf("load vectors: %v", err)
+	}
+	for _, v := range vectors {
+		got, ok, err := v.Check()
+		if err != nil {
+			t.Fatalf("%s vector: %v", v.Client, err)
+		}
+		if !ok {
+			t.Errorf("%s vector mismatch for topic %q:\n  got:  %s\n  want: %s", v.Client, v.Topic, got, v.MessageID)
+		}
+	}
+}
+
+func TestComputeMessageIDOversizedValidSnappy(t *testing.T) {
+	topic := "/leanconsensus/devnet0/attestation/ssz_snappy"
+	payload := make([]byte, gossipsub.MaxGossipSize+1)
+	compressed := snappy.Encode(nil, payload)
+
+	want := gossipsub.ComputeMessageIDWithDomain(gossipsub.DomainValidSnappy, topic, payload)
+	if got := gossipsub.ComputeMessageID(&pb.Message{Topic: &topic, Data: compressed}); got != want {
+		t.Fatalf("oversized message ID = %x, want the valid-snappy ID %x", got, want)
+	}
+	// The size limit is enforced when the message is decoded for validation.
+	if _, err := gossipsub.DecodeAttestation(compressed); !errors.Is(err, gossipsub.ErrMessageTooLarge) {
+		t.Fatalf("decode err = %v, want ErrMessageTooLarge", err)
+	}
+}
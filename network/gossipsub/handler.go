@@ -2,22 +2,43 @@ package gossipsub
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
-	"github.com/golang/snappy"
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
 
 	"github.com/geanlabs/gean/types"
 )
 
 // GossipHandler processes decoded gossip messages.
 type GossipHandler struct {
-	OnBlock                 func(*types.SignedBlockWithAttestation)
+	// FilterBlock, if set, is consulted with the header fields of a block
+	// message before it is fully decoded; returning false drops the message.
+	FilterBlock func(types.BlockHeaderFields) bool
+
+	// Seen, if set, drops messages whose ID it already holds and records the
+	// IDs of messages that decode successfully.
+	Seen *SeenCache
+
+	// OnBlock receives each decoded block with the time spent decompressing
+	// and decoding it.
+	OnBlock                 func(*types.SignedBlockWithAttestation, time.Duration)
 	OnAttestation           func(*types.SignedAttestation)
 	OnAggregatedAttestation func(*types.AggregatedAttestation)
 }
 
-// SubscribeTopics subscribes to topics and dispatches messages to handler.
+// SubscribeTopics registers the topic validators, subscribes to topics and
+// dispatches messages to handler.
+//
+// Messages are decoded by the validators: one that cannot be decoded is
+// rejected, which counts against the sending peer's gossipsub score, while
+// duplicates and blocks dropped by FilterBlock are only ignored.
 func SubscribeTopics(ctx context.Context, topics *Topics, handler *GossipHandler) error {
+	if err := registerValidators(topics, handler); err != nil {
+		return err
+	}
 	blockSub, err := topics.Block.Subscribe()
 	if err != nil {
 		return err
@@ -27,74 +48,115 @@ func SubscribeTopics(ctx context.Context, topics *Topics, handler *GossipHandler
 		return err
 	}
 
-	go readBlockMessages(ctx, blockSub, handler)
-	go readAttestationMessages(ctx, attSub, handler)
+	go readMessages(ctx, blockSub, handler, func(data any) {
+		if handler.OnBlock != nil {
+			d := data.(decodedBlock)
+			handler.OnBlock(d.block, d.decode)
+		}
+	})
+	go readMessages(ctx, attSub, handler, func(data any) {
+		if handler.OnAttestation != nil {
+			handler.OnAttestation(data.(*types.SignedAttestation))
+		}
+	})
 	if topics.AggregateAttestation != nil && handler.OnAggregatedAttestation != nil {
 		aggSub, err := topics.AggregateAttestation.Subscribe()
 		if err != nil {
 			return err
 		}
-		go readAggregatedAttestationMessages(ctx, aggSub, handler)
+		go readMessages(ctx, aggSub, handler, func(data any) {
+			handler.OnAggregatedAttestation(data.(*types.AggregatedAttestation))
+		})
 	}
 	return nil
 }
 
-func readBlockMessages(ctx context.Context, sub *pubsub.Subscription, handler *GossipHandler) {
-	for {
-		msg, err := sub.Next(ctx)
-		if err != nil {
-			return
-		}
-		decoded, err := snappy.Decode(nil, msg.Data)
-		if err != nil {
-			continue
-		}
-		block := new(types.SignedBlockWithAttestation)
-		if err := block.UnmarshalSSZ(decoded); err != nil {
+// decodedBlock is a block message as decoded by its topic validator.
+type decodedBlock struct {
+	block  *types.SignedBlockWithAttestation
+	decode time.Duration
+}
+
+// registerValidators registers a decoding validator on each joined topic.
+func registerValidators(topics *Topics, handler *GossipHandler) error {
+	if topics.ps == nil {
+		return errors.New("topics were not joined with JoinTopics")
+	}
+	validators := []struct {
+		topic  *pubsub.Topic
+		decode func([]byte) (any, error)
+	}{
+		{topics.Block, func(data []byte) (any, error) {
+			start := time.Now()
+			block, err := decodeBlockFiltered(data, handler.FilterBlock)
+			if err != nil || block == nil {
+				return nil, err
+			}
+			return decodedBlock{block, time.Since(start)}, nil
+		}},
+		{topics.Attestation, func(data []byte) (any, error) {
+			att, err := DecodeAttestation(data)
+			if err != nil {
+				return nil, err
+			}
+			return att, nil
+		}},
+		{topics.AggregateAttestation, func(data []byte) (any, error) {
+			decoded, bp, err := decodeSnappy(data, maxAggregatedAttestationSize)
+			if err != nil {
+				return nil, err
+			}
+			defer releaseBuffer(bp)
+			agg, err := DecodeAggregatedAttestation(decoded)
+			if err != nil {
+				return nil, err
+			}
+			return agg, nil
+		}},
+	}
+	for _, v := range validators {
+		if v.topic == nil {
 			continue
 		}
-		if handler.OnBlock != nil {
-			handler.OnBlock(block)
+		if err := topics.ps.RegisterTopicValidator(v.topic.String(), decodingValidator(handler.Seen, v.decode)); err != nil {
+			return fmt.Errorf("register validator for %s: %w", v.topic, err)
 		}
 	}
+	return nil
 }
 
-func readAttestationMessages(ctx context.Context, sub *pubsub.Subscription, handler *GossipHandler) {
-	for {
-		msg, err := sub.Next(ctx)
-		if err != nil {
-			return
+// decodingValidator ignores messages already seen, rejects those decode
+// fails on and passes the decoded value on as the message's ValidatorData.
+// A nil value without an error, a filtered message, is ignored.
+func decodingValidator(seen *SeenCache, decode func([]byte) (any, error)) pubsub.ValidatorEx {
+	return func(_ context.Context, _ peer.ID, msg *pubsub.Message) pubsub.ValidationResult {
+		if seen.Contains(msg.ID) {
+			return pubsub.ValidationIgnore
 		}
-		decoded, err := snappy.Decode(nil, msg.Data)
+		data, err := decode(msg.Data)
 		if err != nil {
-			continue
+			return pubsub.ValidationReject
 		}
-		att := new(types.SignedAttestation)
-		if err := att.UnmarshalSSZ(decoded); err != nil {
-			continue
-		}
-		if handler.OnAttestation != nil {
-			handler.OnAttestation(att)
+		if data == nil {
+			return pubsub.ValidationIgnore
 		}
+		msg.ValidatorData = data
+		return pubsub.ValidationAccept
 	}
 }
 
-func readAggregatedAttestationMessages(ctx context.Context, sub *pubsub.Subscription, handler *GossipHandler) {
+// readMessages records each validated message of sub as seen and hands its
+// decoded value to dispatch.
+func readMessages(ctx context.Context, sub *pubsub.Subscription, handler *GossipHandler, dispatch func(any)) {
 	for {
 		msg, err := sub.Next(ctx)
 		if err != nil {
 			return
 		}
-		decoded, err := snappy.Decode(nil, msg.Data)
-		if err != nil {
-			continue
-		}
-		agg, err := DecodeAggregatedAttestation(decoded)
-		if err != nil {
+		if msg.ValidatorData == nil {
 			continue
 		}
-		if handler.OnAggregatedAttestation != nil {
-			handler.OnAggregatedAttestation(agg)
-		}
+		handler.Seen.Add(msg.ID)
+		dispatch(msg.ValidatorData)
 	}
 }
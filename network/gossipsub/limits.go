@@ -0,0 +1,31 @@
+package gossipsub
+
+import (
+	"errors"
+
+	"github.com/geanlabs/gean/types"
+)
+
+// MaxGossipSize caps the decompressed size of any gossip message, matching
+// the 10 MiB req/resp payload limit.
+const MaxGossipSize = 10 << 20
+
+// Per-topic decompressed size limits, checked against the snappy length
+// header before any buffer is allocated.
+var (
+	// signedAttestationSize is the exact encoded size of a SignedAttestation.
+	signedAttestationSize = new(types.SignedAttestation).SizeSSZ()
+
+	// maxAggregationBitsSize is the largest bitlist for ValidatorRegistryLimit
+	// validators, including the sentinel bit.
+	maxAggregationBitsSize = types.ValidatorRegistryLimit/8 + 1
+
+	maxBlockSize                 = MaxGossipSize
+	maxAggregatedAttestationSize = min(MaxGossipSize,
+		4+new(types.AttestationData).SizeSSZ()+4+maxAggregationBitsSize+
+			types.ValidatorRegistryLimit*types.XMSSSignatureSize)
+)
+
+// ErrMessageTooLarge is returned for messages whose decompressed size exceeds
+// the limit for their topic.
+var ErrMessageTooLarge = errors.New("gossip message exceeds size limit")
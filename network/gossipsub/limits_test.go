@@ -0,0 +1,92 @@
+package gossipsub_test
+
+import (
+	"encoding/binary"
+	"errors"
+	"runtime"
+	"testing"
+
+	"github.com/golang/snappy"
+	pb "github.com/libp2p/go-libp2p-pubsub/pb"
+
+	"github.com/geanlabs/gean/network/gossipsub"
+)
+
+// snappyBomb is a tiny message whose snappy header claims size bytes of
+// decompressed output.
+func snappyBomb(size uint64) []byte {
+	buf := binary.AppendUvarint(nil, size)
+	// A literal tag for a single byte, so the stream starts out valid.
+	return append(buf, 0x00, 0xAB)
+}
+
+func TestDecodeRejectsSnappyBombWithoutAllocating(t *testing.T) {
+	bomb := snappyBomb(1 << 31)
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	if _, err := gossipsub.DecodeAttestation(bomb); !errors.Is(err, gossipsub.ErrMessageTooLarge) {
+		t.Fatalf("attestation: err = %v, want ErrMessageTooLarge", err)
+	}
+	if _, err := gossipsub.DecodeBlock(bomb); !errors.Is(err, gossipsub.ErrMessageTooLarge) {
+		t.Fatalf("block: err = %v, want ErrMessageTooLarge", err)
+	}
+	runtime.ReadMemStats(&after)
+	if grew := after.TotalAlloc - before.TotalAlloc; grew > 1<<20 {
+		t.Fatalf("decoding a snappy bomb allocated %d bytes", grew)
+	}
+}
+
+func TestDecodeBlockRejectsOversizedPayload(t *testing.T) {
+	// Zeros compress extremely well: a small message that inflates past the cap.
+	payload := snappy.Encode(nil, make([]byte, gossipsub.MaxGossipSize+1))
+	if _, err := gossipsub.DecodeBlock(payload); !errors.Is(err, gossipsub.ErrMessageTooLarge) {
+		t.Fatalf("err = %v, want ErrMessageTooLarge", err)
+	}
+}
+
+func TestDecodeAttestationRejectsWrongSize(t *testing.T) {
+	valid, err := snappy.Decode(nil, testAttestationPayload(t, 1, 2))
+	if err != nil {
+		t.Fatalf("decode fixture: %v", err)
+	}
+	for _, size := range []int{len(valid) - 1, len(valid) + 1} {
+		data := make([]byte, size)
+		copy(data, valid)
+		if _, err := gossipsub.DecodeAttestation(snappy.Encode(nil, data)); err == nil {
+			t.Fatalf("expected error for %d-byte attestation", size)
+		}
+	}
+}
+
+func TestDecodeAggregatedAttestationRejectsBadLengths(t *testing.T) {
+	valid := make([]byte, 0, 4+128+4+1)
+	valid = binary.LittleEndian.AppendUint32(valid, 128)
+	valid = append(valid, make([]byte, 128)...)
+	valid = binary.LittleEndian.AppendUint32(valid, 1)
+	valid = append(valid, 0x01)
+	if _, err := gossipsub.DecodeAggregatedAttestation(valid); err != nil {
+		t.Fatalf("valid aggregate rejected: %v", err)
+	}
+
+	badData := append([]byte(nil), valid...)
+	binary.LittleEndian.PutUint32(badData[0:4], 127)
+	if _, err := gossipsub.DecodeAggregatedAttestation(badData); err == nil {
+		t.Fatal("expected error for wrong attestation data length")
+	}
+
+	badSig := append(append([]byte(nil), valid...), 0xFF)
+	if _, err := gossipsub.DecodeAggregatedAttestation(badSig); err == nil {
+		t.Fatal("expected error for partial signature")
+	}
+}
+
+func TestComputeMessageIDTreatsSnappyBombAsInvalid(t *testing.T) {
+	topic := "/leanconsensus/devnet0/block/ssz_snappy"
+	bomb := snappyBomb(1 << 31)
+	msg := &pb.Message{Data: bomb, Topic: &topic}
+	want := gossipsub.ComputeMessageIDWithDomain(gossipsub.DomainInvalidSnappy, topic, bomb)
+	if got := gossipsub.ComputeMessageID(msg); got != want {
+		t.Fatal("snappy bomb should use the invalid-snappy message ID domain")
+	}
+}
@@ -0,0 +1,108 @@
+package gossipsub
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/golang/snappy"
+
+	"github.com/geanlabs/gean/types"
+)
+
+// maxPooledBufferSize bounds the buffers kept in decodeBufPool so a single
+// large message does not pin memory indefinitely.
+const maxPooledBufferSize = 1 << 20
+
+// decodeBufPool holds scratch buffers for snappy decompression.
+//
+// Ownership: a pooled buffer is only lent out for the duration of a decode.
+// SSZ unmarshalling copies every field out of the input, so the buffer can be
+// returned as soon as UnmarshalSSZ (or hashing, for message IDs) is done.
+// Decoded structs are never pooled: fork choice retains attestations and
+// blocks after the handler returns.
+var decodeBufPool = sync.Pool{
+	New: func() any {
+		b := make([]byte, 0, 4096)
+		return &b
+	},
+}
+
+// decodeSnappy decompresses src into a pooled buffer. The decompressed length
+// declared in the snappy header is checked against limit before anything is
+// allocated. On success the caller must pass the returned handle to
+// releaseBuffer once it no longer references the decoded bytes.
+func decodeSnappy(src []byte, limit int) ([]byte, *[]byte, error) {
+	n, err := snappy.DecodedLen(src)
+	if err != nil {
+		return nil, nil, err
+	}
+	if n > limit {
+		return nil, nil, ErrMessageTooLarge
+	}
+	bp := decodeBufPool.Get().(*[]byte)
+	if cap(*bp) < n {
+		*bp = make([]byte, n)
+	}
+	decoded, err := snappy.Decode((*bp)[:cap(*bp)], src)
+	if err != nil {
+		releaseBuffer(bp)
+		return nil, nil, err
+	}
+	return decoded, bp, nil
+}
+
+// releaseBuffer returns a buffer obtained from decodeSnappy to the pool.
+func releaseBuffer(bp *[]byte) {
+	if cap(*bp) > maxPooledBufferSize {
+		return
+	}
+	*bp = (*bp)[:0]
+	decodeBufPool.Put(bp)
+}
+
+// DecodeBlock snappy-decompresses and SSZ-decodes a gossip block message.
+func DecodeBlock(data []byte) (*types.SignedBlockWithAttestation, error) {
+	return decodeBlockFiltered(data, nil)
+}
+
+// decodeBlockFiltered is DecodeBlock with an optional header pre-check. If
+// keep rejects the peeked header fields it returns a nil block and no error.
+func decodeBlockFiltered(data []byte, keep func(types.BlockHeaderFields) bool) (*types.SignedBlockWithAttestation, error) {
+	decoded, bp, err := decodeSnappy(data, maxBlockSize)
+	if err != nil {
+		return nil, err
+	}
+	defer releaseBuffer(bp)
+	if keep != nil {
+		h, err := types.PeekSignedBlockHeader(decoded)
+		if err != nil {
+			return nil, err
+		}
+		if !keep(h) {
+			return nil, nil
+		}
+	}
+	block := new(types.SignedBlockWithAttestation)
+	if err := block.UnmarshalSSZ(decoded); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+// DecodeAttestation snappy-decompresses and SSZ-decodes a gossip attestation
+// message.
+func DecodeAttestation(data []byte) (*types.SignedAttestation, error) {
+	decoded, bp, err := decodeSnappy(data, signedAttestationSize)
+	if err != nil {
+		return nil, err
+	}
+	defer releaseBuffer(bp)
+	if len(decoded) != signedAttestationSize {
+		return nil, fmt.Errorf("attestation size %d, want %d", len(decoded), signedAttestationSize)
+	}
+	att := new(types.SignedAttestation)
+	if err := att.UnmarshalSSZ(decoded); err != nil {
+		return nil, err
+	}
+	return att, nil
+}
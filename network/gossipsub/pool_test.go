@@ -0,0 +1,144 @@
+package gossipsub_test
+
+import (
+	"testing"
+
+	"github.com/golang/snappy"
+	pb "github.com/libp2p/go-libp2p-pubsub/pb"
+
+	"github.com/geanlabs/gean/network/gossipsub"
+	"github.com/geanlabs/gean/types"
+)
+
+func testAttestationPayload(t testing.TB, validatorID, slot uint64) []byte {
+	t.Helper()
+	sa := &types.SignedAttestation{
+		ValidatorID: validatorID,
+		Message: &types.AttestationData{
+			Slot:   slot,
+			Head:   &types.Checkpoint{Root: [32]byte{0x01}, Slot: 9},
+			Target: &types.Checkpoint{Root: [32]byte{0x02}, Slot: 8},
+			Source: &types.Checkpoint{Root: [32]byte{0x03}, Slot: 4},
+		},
+	}
+	sa.Signature[0] = 0xAB
+	sa.Signature[len(sa.Signature)-1] = 0xCD
+	data, err := sa.MarshalSSZ()
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return snappy.Encode(nil, data)
+}
+
+func TestDecodeAttestationDoesNotAliasPooledBuffer(t *testing.T) {
+	first, err := gossipsub.DecodeAttestation(testAttestationPayload(t, 3, 9))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	// Decoding again reuses the pooled buffer; the first result must be intact.
+	if _, err := gossipsub.DecodeAttestation(testAttestationPayload(t, 5, 11)); err != nil {
+		t.Fatalf("second decode: %v", err)
+	}
+	if first.ValidatorID != 3 || first.Message.Slot != 9 {
+		t.Fatalf("decoded attestation corrupted: %+v", first.Message)
+	}
+	if first.Signature[0] != 0xAB || first.Signature[len(first.Signature)-1] != 0xCD {
+		t.Fatal("signature corrupted after buffer reuse")
+	}
+}
+
+func TestDecodeAttestationRejectsInvalidSnappy(t *testing.T) {
+	if _, err := gossipsub.DecodeAttestation([]byte{0xff, 0xff, 0xff}); err == nil {
+		t.Fatal("expected error for invalid snappy payload")
+	}
+}
+
+func testBlockSSZ(t testing.TB) (*types.SignedBlockWithAttestation, []byte) {
+	t.Helper()
+	att := &types.Attestation{
+		ValidatorID: 2,
+		Data: &types.AttestationData{
+			Slot:   6,
+			Head:   &types.Checkpoint{Root: [32]byte{0x01}, Slot: 6},
+			Target: &types.Checkpoint{Root: [32]byte{0x02}, Slot: 5},
+			Source: &types.Checkpoint{Root: [32]byte{0x03}, Slot: 4},
+		},
+	}
+	sb := &types.SignedBlockWithAttestation{
+		Message: &types.BlockWithAttestation{
+			Block: &types.Block{
+				Slot:          7,
+				ProposerIndex: 3,
+				ParentRoot:    [32]byte{0xAA},
+				StateRoot:     [32]byte{0xBB},
+				Body:          &types.BlockBody{Attestations: []*types.Attestation{att}},
+			},
+			ProposerAttestation: att,
+		},
+		Signature: make([][3112]byte, 2),
+	}
+	data, err := sb.MarshalSSZ()
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return sb, data
+}
+
+func TestPeekSignedBlockHeaderMatchesFullDecode(t *testing.T) {
+	sb, data := testBlockSSZ(t)
+	h, err := types.PeekSignedBlockHeader(data)
+	if err != nil {
+		t.Fatalf("peek: %v", err)
+	}
+	block := sb.Message.Block
+	if h.Slot != block.Slot || h.ProposerIndex != block.ProposerIndex ||
+		h.ParentRoot != block.ParentRoot || h.StateRoot != block.StateRoot {
+		t.Fatalf("peeked %+v, want block %+v", h, block)
+	}
+
+	if _, err := types.PeekSignedBlockHeader(data[:100]); err == nil {
+		t.Fatal("expected error for truncated block")
+	}
+	corrupt := append([]byte(nil), data...)
+	corrupt[0] = 9
+	if _, err := types.PeekSignedBlockHeader(corrupt); err == nil {
+		t.Fatal("expected error for bad message offset")
+	}
+}
+
+// BenchmarkDecodeAttestationUnpooled is the previous decode path, kept as a
+// baseline for BenchmarkDecodeAttestation.
+func BenchmarkDecodeAttestationUnpooled(b *testing.B) {
+	payload := testAttestationPayload(b, 3, 9)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		decoded, err := snappy.Decode(nil, payload)
+		if err != nil {
+			b.Fatal(err)
+		}
+		sa := new(types.SignedAttestation)
+		if err := sa.UnmarshalSSZ(decoded); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeAttestation(b *testing.B) {
+	payload := testAttestationPayload(b, 3, 9)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := gossipsub.DecodeAttestation(payload); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkComputeMessageID(b *testing.B) {
+	msg := &pb.Message{Data: testAttestationPayload(b, 3, 9)}
+	topic := "/leanconsensus/devnet0/attestation/ssz_snappy"
+	msg.Topic = &topic
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		gossipsub.ComputeMessageID(msg)
+	}
+}
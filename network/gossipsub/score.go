@@ -0,0 +1,141 @@
+package gossipsub
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// Peer score thresholds. A peer below gossipThreshold gets no gossip from
+// us, below publishThreshold none of our own messages, and below
+// graylistThreshold all its RPCs are ignored.
+const (
+	gossipThreshold             = -4000
+	publishThreshold            = -8000
+	graylistThreshold           = -16000
+	acceptPXThreshold           = 100
+	opportunisticGraftThreshold = 5
+)
+
+// Score parameter tuning, in slots where it is a duration.
+const (
+	// topicScoreCap bounds the positive score a peer earns across topics,
+	// so good behaviour cannot bank credit against later invalid messages.
+	topicScoreCap = 50
+
+	// invalidToGraylist is the number of invalid messages on one topic that
+	// takes a peer with no positive score to the graylist threshold.
+	invalidToGraylist = 5
+
+	// ipColocationThreshold is the number of peers allowed on one IP before
+	// each further peer costs all of them score.
+	ipColocationThreshold = 10
+
+	decayToZero         = 0.01
+	retainScoreSlots    = 100
+	behaviourDecaySlots = 32
+	invalidDecaySlots   = 100
+	deliveryDecaySlots  = 20
+	timeInMeshCapSlots  = 300
+)
+
+// Topic weights: a block is worth more than a single vote, and each topic
+// contributes at most its weight times its per-topic cap.
+const (
+	blockTopicWeight       = 0.5
+	attestationTopicWeight = 0.25
+	aggregateTopicWeight   = 0.25
+)
+
+// decayOver returns the decay factor that takes a counter to zero over the
+// given number of slots. The slot is the decay interval of all counters.
+func decayOver(slots int, slotDuration time.Duration) float64 {
+	return pubsub.ScoreParameterDecayWithBase(time.Duration(slots)*slotDuration, slotDuration, decayToZero)
+}
+
+// peerScoreThresholds returns the score thresholds for the router.
+func peerScoreThresholds() *pubsub.PeerScoreThresholds {
+	return &pubsub.PeerScoreThresholds{
+		GossipThreshold:             gossipThreshold,
+		PublishThreshold:            publishThreshold,
+		GraylistThreshold:           graylistThreshold,
+		AcceptPXThreshold:           acceptPXThreshold,
+		OpportunisticGraftThreshold: opportunisticGraftThreshold,
+	}
+}
+
+// peerScoreParams returns the topic-independent score parameters. Topic
+// parameters are set as each topic is joined. Loopback addresses are exempt
+// from the IP colocation penalty, since local devnets run every client on
+// one host.
+func peerScoreParams(slotDuration time.Duration) *pubsub.PeerScoreParams {
+	_, loopback4, _ := net.ParseCIDR("127.0.0.0/8")
+	_, loopback6, _ := net.ParseCIDR("::1/128")
+	return &pubsub.PeerScoreParams{
+		Topics:                      make(map[string]*pubsub.TopicScoreParams),
+		TopicScoreCap:               topicScoreCap,
+		AppSpecificScore:            func(peer.ID) float64 { return 0 },
+		AppSpecificWeight:           1,
+		IPColocationFactorWeight:    -topicScoreCap,
+		IPColocationFactorThreshold: ipColocationThreshold,
+		IPColocationFactorWhitelist: []*net.IPNet{loopback4, loopback6},
+		BehaviourPenaltyWeight:      -15.9,
+		BehaviourPenaltyThreshold:   6,
+		BehaviourPenaltyDecay:       decayOver(behaviourDecaySlots, slotDuration),
+		DecayInterval:               slotDuration,
+		DecayToZero:                 decayToZero,
+		RetainScore:                 time.Duration(retainScoreSlots) * slotDuration,
+	}
+}
+
+// topicScoreParams returns the score parameters of a topic with the given
+// weight that carries about perSlot messages a slot.
+//
+// Mesh delivery penalties (P3, P3b) stay off: on small devnets a topic can
+// go quiet for slots at a time, which would penalize honest mesh peers.
+// Peers earn score for time in the mesh and for first deliveries, and lose
+// it, quadratically, for messages that fail validation.
+func topicScoreParams(weight, perSlot float64, slotDuration time.Duration) *pubsub.TopicScoreParams {
+	// First deliveries are worth at most topicScoreCap before the topic
+	// weight is applied.
+	firstDeliveriesCap := perSlot * deliveryDecaySlots
+	return &pubsub.TopicScoreParams{
+		TopicWeight: weight,
+
+		TimeInMeshWeight:  1.0 / timeInMeshCapSlots,
+		TimeInMeshQuantum: slotDuration,
+		TimeInMeshCap:     timeInMeshCapSlots,
+
+		FirstMessageDeliveriesWeight: topicScoreCap / firstDeliveriesCap,
+		FirstMessageDeliveriesDecay:  decayOver(deliveryDecaySlots, slotDuration),
+		FirstMessageDeliveriesCap:    firstDeliveriesCap,
+
+		InvalidMessageDeliveriesWeight: graylistThreshold / (invalidToGraylist * invalidToGraylist * weight),
+		InvalidMessageDeliveriesDecay:  decayOver(invalidDecaySlots, slotDuration),
+	}
+}
+
+// setTopicScores applies the score parameters of each joined topic.
+// numValidators sizes the expected attestation traffic.
+func setTopicScores(topics *Topics, numValidators int, slotDuration time.Duration) error {
+	perSlot := float64(max(numValidators, 1))
+	for _, t := range []struct {
+		topic  *pubsub.Topic
+		params *pubsub.TopicScoreParams
+	}{
+		{topics.Block, topicScoreParams(blockTopicWeight, 1, slotDuration)},
+		{topics.Attestation, topicScoreParams(attestationTopicWeight, perSlot, slotDuration)},
+		{topics.AggregateAttestation, topicScoreParams(aggregateTopicWeight, 1, slotDuration)},
+	} {
+		if t.topic == nil {
+			continue
+		}
+		if err := t.topic.SetScoreParams(t.params); err != nil {
+			return fmt.Errorf("set score params for %s: %w", t.topic, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package gossipsub_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+
+	"github.com/geanlabs/gean/network/gossipsub"
+	"github.com/geanlabs/gean/types"
+)
+
+func TestInvalidGossipLowersPeerScore(t *testing.T) {
+	mn, err := mocknet.FullMeshConnected(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mn.Close()
+	hosts := mn.Hosts()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	scores := map[peer.ID]float64{}
+	inspect := func(s map[peer.ID]float64) {
+		mu.Lock()
+		defer mu.Unlock()
+		for pid, score := range s {
+			scores[pid] = score
+		}
+	}
+
+	sender, err := gossipsub.NewGossipSub(ctx, hosts[0], types.DefaultPreset.SlotDuration())
+	if err != nil {
+		t.Fatal(err)
+	}
+	receiver, err := gossipsub.NewGossipSub(ctx, hosts[1], types.DefaultPreset.SlotDuration(), pubsub.WithPeerScoreInspect(inspect, 50*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	senderTopics, err := gossipsub.JoinTopics(sender, "devnet0", 4, types.DefaultPreset.SlotDuration())
+	if err != nil {
+		t.Fatalf("join topics: %v", err)
+	}
+	receiverTopics, err := gossipsub.JoinTopics(receiver, "devnet0", 4, types.DefaultPreset.SlotDuration())
+	if err != nil {
+		t.Fatalf("join topics: %v", err)
+	}
+
+	var delivered sync.WaitGroup
+	delivered.Add(1)
+	if err := gossipsub.SubscribeTopics(ctx, receiverTopics, &gossipsub.GossipHandler{
+		OnAttestation: func(*types.SignedAttestation) { delivered.Done() },
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	waitFor(t, func() bool { return len(senderTopics.Attestation.ListPeers()) > 0 })
+
+	// The sender registered no validators, so the garbage leaves it and
+	// the receiver's validator rejects it.
+	if err := senderTopics.Attestation.Publish(ctx, []byte("not an attestation")); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	waitFor(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return scores[hosts[0].ID()] < 0
+	})
+
+	// A well-formed attestation still goes through.
+	if err := gossipsub.PublishAttestation(ctx, senderTopics.Attestation, &types.SignedAttestation{
+		Message: &types.AttestationData{Head: &types.Checkpoint{}, Target: &types.Checkpoint{}, Source: &types.Checkpoint{}},
+	}); err != nil {
+		t.Fatalf("publish attestation: %v", err)
+	}
+	done := make(chan struct{})
+	go func() { delivered.Wait(); close(done) }()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("valid attestation not delivered")
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,179 @@
+package gossipsub
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// messageIDSize is the length of a gossip message ID (see ComputeMessageID).
+const messageIDSize = 20
+
+// SeenCache is a set of recently processed gossip message IDs with a TTL.
+// Unlike the gossipsub seen cache it can be saved to disk, so duplicates
+// still circulating in the mesh are not re-verified after a quick restart.
+type SeenCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	max     int // 0 means unbounded
+	expires map[string]time.Time
+}
+
+// NewSeenCache returns an empty cache whose entries expire after ttl.
+func NewSeenCache(ttl time.Duration) *SeenCache {
+	return &SeenCache{ttl: ttl, expires: make(map[string]time.Time)}
+}
+
+// Add records id as seen and reports whether it was not already present. A
+// nil cache records nothing.
+func (c *SeenCache) Add(id string) bool {
+	if c == nil {
+		return true
+	}
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if exp, ok := c.expires[id]; ok && now.Before(exp) {
+		return false
+	}
+	c.pruneLocked(now)
+	if c.max > 0 {
+		c.evictToLocked(c.max - 1) // make room without evicting id itself
+	}
+	c.expires[id] = now.Add(c.ttl)
+	return true
+}
+
+// SetMaxEntries bounds the cache to n entries; 0 removes the bound. When full,
+// arbitrary entries are evicted, which at worst lets a duplicate be verified
+// again.
+func (c *SeenCache) SetMaxEntries(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.max = n
+	if n > 0 {
+		c.evictToLocked(n)
+	}
+}
+
+// Clear drops every entry and returns how many there were.
+func (c *SeenCache) Clear() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := len(c.expires)
+	clear(c.expires)
+	return n
+}
+
+func (c *SeenCache) evictToLocked(n int) {
+	for id := range c.expires {
+		if len(c.expires) <= n {
+			return
+		}
+		delete(c.expires, id)
+	}
+}
+
+// Contains reports whether id was seen and has not expired.
+func (c *SeenCache) Contains(id string) bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	exp, ok := c.expires[id]
+	return ok && time.Now().Before(exp)
+}
+
+// Len returns the number of unexpired entries.
+func (c *SeenCache) Len() int {
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pruneLocked(now)
+	return len(c.expires)
+}
+
+// pruneLocked drops expired entries once the map has grown, amortizing the
+// sweep across inserts.
+func (c *SeenCache) pruneLocked(now time.Time) {
+	if len(c.expires) < 1024 && len(c.expires)%64 != 0 {
+		return
+	}
+	for id, exp := range c.expires {
+		if !now.Before(exp) {
+			delete(c.expires, id)
+		}
+	}
+}
+
+// Save writes the unexpired entries to path as a sequence of 20-byte message
+// IDs, each followed by its expiry in Unix nanoseconds (little-endian).
+func (c *SeenCache) Save(path string) error {
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	var exp [8]byte
+	for id, t := range c.expires {
+		if len(id) != messageIDSize || !now.Before(t) {
+			continue
+		}
+		binary.LittleEndian.PutUint64(exp[:], uint64(t.UnixNano()))
+		w.WriteString(id)
+		w.Write(exp[:])
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// LoadSeenCache reads a cache written by Save, dropping expired entries. A
+// missing file yields an empty cache.
+func LoadSeenCache(path string, ttl time.Duration) (*SeenCache, error) {
+	c := NewSeenCache(ttl)
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	now := time.Now()
+	r := bufio.NewReader(f)
+	var rec [messageIDSize + 8]byte
+	for {
+		if _, err := io.ReadFull(r, rec[:]); err != nil {
+			if err == io.EOF {
+				return c, nil
+			}
+			return nil, fmt.Errorf("read seen cache: %w", err)
+		}
+		exp := time.Unix(0, int64(binary.LittleEndian.Uint64(rec[messageIDSize:])))
+		// Never trust an expiry further out than a fresh entry would get.
+		if limit := now.Add(ttl); exp.After(limit) {
+			exp = limit
+		}
+		if now.Before(exp) {
+			c.expires[string(rec[:messageIDSize])] = exp
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package gossipsub_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/geanlabs/gean/network/gossipsub"
+)
+
+func seenID(b byte) string {
+	return strings.Repeat(string([]byte{b}), 20)
+}
+
+func TestSeenCacheAddAndExpire(t *testing.T) {
+	c := gossipsub.NewSeenCache(50 * time.Millisecond)
+	if !c.Add(seenID(1)) {
+		t.Fatal("first Add reported duplicate")
+	}
+	if c.Add(seenID(1)) {
+		t.Fatal("second Add did not report duplicate")
+	}
+	if !c.Contains(seenID(1)) || c.Contains(seenID(2)) {
+		t.Fatal("Contains mismatch")
+	}
+	time.Sleep(60 * time.Millisecond)
+	if c.Contains(seenID(1)) {
+		t.Fatal("entry did not expire")
+	}
+}
+
+func TestSeenCacheSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "seen")
+	c := gossipsub.NewSeenCache(time.Minute)
+	c.Add(seenID(1))
+	c.Add(seenID(2))
+	if err := c.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	loaded, err := gossipsub.LoadSeenCache(path, time.Minute)
+	if err != nil {
+		t.Fatalf("LoadSeenCache: %v", err)
+	}
+	if loaded.Len() != 2 || !loaded.Contains(seenID(1)) || !loaded.Contains(seenID(2)) {
+		t.Fatalf("loaded %d entries, want both saved IDs", loaded.Len())
+	}
+
+	// Entries that expired while the node was down are dropped.
+	stale := gossipsub.NewSeenCache(5 * time.Millisecond)
+	stale.Add(seenID(3))
+	if err := stale.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	loaded, err = gossipsub.LoadSeenCache(path, time.Minute)
+	if err != nil {
+		t.Fatalf("LoadSeenCache: %v", err)
+	}
+	if loaded.Len() != 0 {
+		t.Fatalf("loaded %d expired entries, want 0", loaded.Len())
+	}
+}
+
+func TestLoadSeenCacheMissingFile(t *testing.T) {
+	c, err := gossipsub.LoadSeenCache(filepath.Join(t.TempDir(), "absent"), time.Minute)
+	if err != nil {
+		t.Fatalf("LoadSeenCache: %v", err)
+	}
+	if c.Len() != 0 {
+		t.Fatalf("Len = %d, want 0", c.Len())
+	}
+}
+
+func TestSeenCacheMaxEntries(t *testing.T) {
+	c := gossipsub.NewSeenCache(time.Minute)
+	for i := byte(0); i < 10; i++ {
+		c.Add(seenID(i))
+	}
+	c.SetMaxEntries(4)
+	if got := c.Len(); got != 4 {
+		t.Fatalf("Len after SetMaxEntries = %d, want 4", got)
+	}
+	c.Add(seenID(100))
+	if got := c.Len(); got != 4 {
+		t.Fatalf("Len after Add = %d, want 4", got)
+	}
+	if !c.Contains(seenID(100)) {
+		t.Fatal("newest entry was evicted")
+	}
+	if n := c.Clear(); n != 4 || c.Len() != 0 {
+		t.Fatalf("Clear dropped %d, Len %d", n, c.Len())
+	}
+}
@@ -0,0 +1,44 @@
+package gossipsub
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	pb "github.com/libp2p/go-libp2p-pubsub/pb"
+)
+
+// MessageIDVector is a message ID another client computed for a topic and
+// raw (as-published) payload.
+type MessageIDVector struct {
+	Client     string `json:"client"`
+	Topic      string `json:"topic"`
+	PayloadHex string `json:"payload_hex"`
+	MessageID  string `json:"message_id"`
+}
+
+// LoadMessageIDVectors reads a JSON array of MessageIDVector from path.
+func LoadMessageIDVectors(path string) ([]MessageIDVector, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var vectors []MessageIDVector
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return vectors, nil
+}
+
+// Check computes the message ID for the vector and returns it hex-encoded,
+// along with whether it matches the expected value.
+func (v MessageIDVector) Check() (string, bool, error) {
+	payload, err := hex.DecodeString(v.PayloadHex)
+	if err != nil {
+		return "", false, fmt.Errorf("decode payload_hex: %w", err)
+	}
+	topic := v.Topic
+	id := hex.EncodeToString([]byte(ComputeMessageID(&pb.Message{Topic: &topic, Data: payload})))
+	return id, id == v.MessageID, nil
+}
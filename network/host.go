@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/libp2p/go-libp2p"
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
@@ -30,7 +31,8 @@ type Host struct {
 }
 
 // NewHost creates a libp2p host with QUIC transport and secp256k1 identity.
-func NewHost(listenAddr string, nodeKeyPath string, bootnodes []string) (*Host, error) {
+// slotDuration is the slot length of the chain, which paces peer scoring.
+func NewHost(listenAddr string, nodeKeyPath string, bootnodes []string, slotDuration time.Duration) (*Host, error) {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	privKey, err := loadOrGenerateKey(nodeKeyPath)
@@ -54,7 +56,7 @@ func NewHost(listenAddr string, nodeKeyPath string, bootnodes []string) (*Host,
 		return nil, fmt.Errorf("new host: %w", err)
 	}
 
-	gs, err := gossipsub.NewGossipSub(ctx, h)
+	gs, err := gossipsub.NewGossipSub(ctx, h, slotDuration)
 	if err != nil {
 		h.Close()
 		cancel()
@@ -73,7 +75,7 @@ func (h *Host) Close() error {
 // ConnectBootnodes dials the given addresses (multiaddr or ENR) and connects to them.
 func ConnectBootnodes(ctx context.Context, h host.Host, addrs []string) {
 	for _, addr := range addrs {
-		pi, err := parseBootnode(addr)
+		pi, err := ParseBootnode(addr)
 		if err != nil {
 			netLog.Warn("invalid bootnode", "addr", addr, "err", err)
 			continue
@@ -94,7 +96,8 @@ func ConnectBootnodes(ctx context.Context, h host.Host, addrs []string) {
 	}
 }
 
-func parseBootnode(addr string) (*peer.AddrInfo, error) {
+// ParseBootnode parses a bootnode address given as a multiaddr or an ENR.
+func ParseBootnode(addr string) (*peer.AddrInfo, error) {
 	if strings.HasPrefix(addr, "enr:") {
 		return p2p.ENRToAddrInfo(addr)
 	}
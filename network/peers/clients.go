@@ -0,0 +1,69 @@
+package peers
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// ClientCount is the number of connected peers running one client version.
+type ClientCount struct {
+	Client  string `json:"client"`
+	Version string `json:"version"`
+	Peers   int    `json:"peers"`
+}
+
+// ParseAgent splits a libp2p identify agent version such as "zeam/v0.3.0" or
+// "lighthouse/v5.1.0-abc/x86_64-linux" into a lower-case client name and a
+// version. Missing parts are reported as "unknown". An agent that is a Go
+// module path, the go-libp2p default, is named by its last element.
+func ParseAgent(agent string) (client, version string) {
+	client, version = "unknown", "unknown"
+	parts := strings.Split(strings.TrimSpace(agent), "/")
+	if strings.Contains(parts[0], ".") {
+		return strings.ToLower(parts[len(parts)-1]), version
+	}
+	if name := strings.ToLower(strings.TrimSpace(parts[0])); name != "" {
+		client = name
+	}
+	if len(parts) > 1 && strings.TrimSpace(parts[1]) != "" {
+		version = strings.TrimSpace(parts[1])
+	}
+	return client, version
+}
+
+// SetAgent records the identify agent version of a peer.
+func (m *Manager) SetAgent(pid peer.ID, agent string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.agents[pid] = agent
+}
+
+// ClientCounts returns how many identified peers run each client version,
+// ordered by descending peer count, then client and version.
+func (m *Manager) ClientCounts() []ClientCount {
+	m.mu.Lock()
+	counts := make(map[ClientCount]int)
+	for _, agent := range m.agents {
+		client, version := ParseAgent(agent)
+		counts[ClientCount{Client: client, Version: version}]++
+	}
+	m.mu.Unlock()
+
+	out := make([]ClientCount, 0, len(counts))
+	for c, n := range counts {
+		c.Peers = n
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Peers != out[j].Peers {
+			return out[i].Peers > out[j].Peers
+		}
+		if out[i].Client != out[j].Client {
+			return out[i].Client < out[j].Client
+		}
+		return out[i].Version < out[j].Version
+	})
+	return out
+}
@@ -0,0 +1,243 @@
+package peers
+
+import (
+	"sort"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/geanlabs/gean/types"
+)
+
+// DefaultMaxPeers is the peer cap used when none is configured.
+const DefaultMaxPeers = 50
+
+// Limits bounds the number of connected peers. Connections beyond Max are
+// pruned as soon as they open; above Target, the worst peers are pruned at
+// the next prune round.
+type Limits struct {
+	Target int
+	Max    int
+}
+
+// LimitsFor returns the limits for a peer cap of max, keeping a tenth of it
+// as headroom above the target so new peers can still connect and compete
+// with the ones already held. A max of zero or less uses DefaultMaxPeers.
+func LimitsFor(max int) Limits {
+	if max <= 0 {
+		max = DefaultMaxPeers
+	}
+	return Limits{Target: max - max/10, Max: max}
+}
+
+// Direction is which side opened the connection to a peer.
+type Direction int
+
+const (
+	DirUnknown Direction = iota
+	Inbound
+	Outbound
+)
+
+func (d Direction) String() string {
+	switch d {
+	case Inbound:
+		return "inbound"
+	case Outbound:
+		return "outbound"
+	}
+	return "unknown"
+}
+
+// ChainStatus is the chain a peer reported in its last status exchange.
+type ChainStatus struct {
+	Finalized types.Checkpoint
+	Head      types.Checkpoint
+	Updated   time.Time
+}
+
+// PeerInfo is a snapshot of what the manager knows about a connected peer.
+type PeerInfo struct {
+	ID          peer.ID
+	Direction   Direction
+	ConnectedAt time.Time
+	Score       int
+	Status      *ChainStatus // nil until a status exchange succeeds
+}
+
+type connection struct {
+	direction   Direction
+	connectedAt time.Time
+	status      *ChainStatus
+}
+
+// SetLimits replaces the peer limits.
+func (m *Manager) SetLimits(l Limits) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.limits = l
+}
+
+// Limits returns the peer limits.
+func (m *Manager) Limits() Limits {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.limits
+}
+
+// Connected records a new connection to pid. A second connection to a peer
+// that is already connected keeps the first one's direction. Connected
+// reports whether the peer count is now above the limit.
+func (m *Manager) Connected(pid peer.ID, dir Direction) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.conns[pid]; !ok {
+		m.conns[pid] = &connection{direction: dir, connectedAt: time.Now()}
+	}
+	return m.limits.Max > 0 && len(m.conns) > m.limits.Max
+}
+
+// Disconnected records that the last connection to pid closed. The score is
+// kept so a peer cannot reset it by reconnecting.
+func (m *Manager) Disconnected(pid peer.ID) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.conns, pid)
+}
+
+// SetChainStatus records the chain pid reported in a status exchange. It is
+// ignored for peers that are not connected.
+func (m *Manager) SetChainStatus(pid peer.ID, finalized, head types.Checkpoint) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if c, ok := m.conns[pid]; ok {
+		c.status = &ChainStatus{Finalized: finalized, Head: head, Updated: time.Now()}
+	}
+}
+
+// Peer returns what is known about a connected peer.
+func (m *Manager) Peer(pid peer.ID) (PeerInfo, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c, ok := m.conns[pid]
+	if !ok {
+		return PeerInfo{}, false
+	}
+	return m.infoLocked(pid, c), true
+}
+
+// ConnectedPeers returns every connected peer.
+func (m *Manager) ConnectedPeers() []PeerInfo {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]PeerInfo, 0, len(m.conns))
+	for pid, c := range m.conns {
+		out = append(out, m.infoLocked(pid, c))
+	}
+	return out
+}
+
+// DirectionCounts returns the number of connected peers per direction.
+func (m *Manager) DirectionCounts() map[Direction]int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	counts := make(map[Direction]int, 2)
+	for _, c := range m.conns {
+		counts[c.direction]++
+	}
+	return counts
+}
+
+func (m *Manager) infoLocked(pid peer.ID, c *connection) PeerInfo {
+	info := PeerInfo{
+		ID:          pid,
+		Direction:   c.direction,
+		ConnectedAt: c.connectedAt,
+		Score:       m.scores[pid],
+	}
+	if c.status != nil {
+		s := *c.status
+		info.Status = &s
+	}
+	return info
+}
+
+// PruneCandidates returns the peers to disconnect to bring the peer count
+// back to the target, worst first: the lowest score, then inbound before
+// outbound, since outbound peers were chosen by us, then the most recently
+// connected. Peers at or below DisconnectThreshold are always included.
+func (m *Manager) PruneCandidates() []peer.ID {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	infos := make([]PeerInfo, 0, len(m.conns))
+	for pid, c := range m.conns {
+		infos = append(infos, m.infoLocked(pid, c))
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		a, b := infos[i], infos[j]
+		if a.Score != b.Score {
+			return a.Score < b.Score
+		}
+		if a.Direction != b.Direction {
+			return a.Direction == Inbound
+		}
+		if !a.ConnectedAt.Equal(b.ConnectedAt) {
+			return a.ConnectedAt.After(b.ConnectedAt)
+		}
+		return a.ID < b.ID
+	})
+
+	excess := 0
+	if m.limits.Target > 0 && len(infos) > m.limits.Target {
+		excess = len(infos) - m.limits.Target
+	}
+	var out []peer.ID
+	for i, info := range infos {
+		if i >= excess && info.Score > DisconnectThreshold {
+			break
+		}
+		out = append(out, info.ID)
+	}
+	return out
+}
+
+// watchConnections keeps connection records in step with the host's
+// network and signals pruneCh when a new connection goes over the limit.
+func (m *Manager) watchConnections(net network.Network) {
+	net.Notify(&network.NotifyBundle{
+		ConnectedF: func(_ network.Network, c network.Conn) {
+			if m.Connected(c.RemotePeer(), connDirection(c)) {
+				select {
+				case m.pruneCh <- struct{}{}:
+				default:
+				}
+			}
+		},
+		DisconnectedF: func(net network.Network, c network.Conn) {
+			if net.Connectedness(c.RemotePeer()) != network.Connected {
+				m.Disconnected(c.RemotePeer())
+			}
+		},
+	})
+	for _, c := range net.Conns() {
+		m.Connected(c.RemotePeer(), connDirection(c))
+	}
+}
+
+func connDirection(c network.Conn) Direction {
+	switch c.Stat().Direction {
+	case network.DirInbound:
+		return Inbound
+	case network.DirOutbound:
+		return Outbound
+	}
+	return DirUnknown
+}
+
+// OverLimit is signalled when a new connection takes the peer count above
+// the limit, so the caller can prune without waiting for its next round.
+func (m *Manager) OverLimit() <-chan struct{} {
+	return m.pruneCh
+}
@@ -0,0 +1,97 @@
+package peers
+
+import (
+	"sort"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// latencyAlpha is the EWMA weight given to each new latency sample.
+const latencyAlpha = 0.3
+
+// failureCost is the time a failed request is assumed to waste, roughly the
+// req/resp timeout.
+const failureCost = 10 * time.Second
+
+// requestStats tracks req/resp performance for a peer.
+type requestStats struct {
+	latency   time.Duration // EWMA of successful request latency
+	successes int
+	failures  int
+}
+
+// cost estimates the expected time a request to the peer takes, weighting its
+// average latency and failureCost by its (smoothed) success rate.
+func (s *requestStats) cost() float64 {
+	successRate := float64(s.successes+1) / float64(s.successes+s.failures+2)
+	return successRate*float64(s.latency) + (1-successRate)*float64(failureCost)
+}
+
+// RecordRequest records the outcome and latency of a req/resp request.
+func (m *Manager) RecordRequest(pid peer.ID, latency time.Duration, ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, exists := m.stats[pid]
+	if !exists {
+		s = &requestStats{}
+		m.stats[pid] = s
+	}
+	if !ok {
+		s.failures++
+		return
+	}
+	if s.successes == 0 {
+		s.latency = latency
+	} else {
+		s.latency = time.Duration(latencyAlpha*float64(latency) + (1-latencyAlpha)*float64(s.latency))
+	}
+	s.successes++
+}
+
+// Latency returns the smoothed request latency of a peer, if measured.
+func (m *Manager) Latency(pid peer.ID) (time.Duration, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.stats[pid]
+	if !ok || s.successes == 0 {
+		return 0, false
+	}
+	return s.latency, true
+}
+
+// RankPeers orders candidates for a request. Penalized peers go last, lowest
+// score last, so a peer that failed a request is retried only after the
+// others. Among peers with equal scores, those without measurements come
+// first so they get sampled, in round-robin order across calls; measured
+// peers follow, cheapest expected cost first.
+func (m *Manager) RankPeers(candidates []peer.ID) []peer.ID {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := len(candidates)
+	if n == 0 {
+		return nil
+	}
+	start := m.rrOffset % n
+	m.rrOffset++
+
+	ranked := make([]peer.ID, 0, n)
+	ranked = append(ranked, candidates[start:]...)
+	ranked = append(ranked, candidates[:start]...)
+
+	cost := func(pid peer.ID) float64 {
+		s, ok := m.stats[pid]
+		if !ok {
+			return 0
+		}
+		return s.cost()
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if si, sj := m.scores[ranked[i]], m.scores[ranked[j]]; si != sj {
+			return si > sj
+		}
+		return cost(ranked[i]) < cost(ranked[j])
+	})
+	return ranked
+}
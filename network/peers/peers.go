@@ -0,0 +1,75 @@
+package peers
+
+import (
+	"sync"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
+
+// Score penalties applied for misbehaviour observed in the sync layer.
+const (
+	PenaltyUnrequestedBlock = 20
+	PenaltyBrokenChain      = 20
+	PenaltyRequestFailure   = 5
+	PenaltyUnconnectedChain = 2
+)
+
+// DisconnectThreshold is the score at or below which a peer should be dropped.
+const DisconnectThreshold = -50
+
+// Manager tracks a reputation score, the supported req/resp protocols, the
+// client agent and request performance for each peer, and the connection
+// direction and chain status of connected peers. Scores start at zero and
+// only decrease; a peer that crosses DisconnectThreshold should be dropped.
+type Manager struct {
+	mu        sync.Mutex
+	scores    map[peer.ID]int
+	protocols map[peer.ID]map[protocol.ID]struct{}
+	stats     map[peer.ID]*requestStats
+	agents    map[peer.ID]string
+	conns     map[peer.ID]*connection
+	limits    Limits
+	pruneCh   chan struct{}
+	rrOffset  int
+}
+
+// NewManager creates an empty peer manager.
+func NewManager() *Manager {
+	return &Manager{
+		scores:    make(map[peer.ID]int),
+		protocols: make(map[peer.ID]map[protocol.ID]struct{}),
+		stats:     make(map[peer.ID]*requestStats),
+		agents:    make(map[peer.ID]string),
+		conns:     make(map[peer.ID]*connection),
+		limits:    LimitsFor(0),
+		pruneCh:   make(chan struct{}, 1),
+	}
+}
+
+// Penalize lowers a peer's score and reports whether the peer has crossed
+// DisconnectThreshold.
+func (m *Manager) Penalize(pid peer.ID, amount int) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.scores[pid] -= amount
+	return m.scores[pid] <= DisconnectThreshold
+}
+
+// Score returns the current score of a peer.
+func (m *Manager) Score(pid peer.ID) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.scores[pid]
+}
+
+// Remove forgets everything known about a peer, including its score.
+func (m *Manager) Remove(pid peer.ID) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.scores, pid)
+	delete(m.protocols, pid)
+	delete(m.stats, pid)
+	delete(m.agents, pid)
+	delete(m.conns, pid)
+}
@@ -0,0 +1,279 @@
+package peers_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+
+	"github.com/geanlabs/gean/network/peers"
+	"github.com/geanlabs/gean/types"
+)
+
+func TestPenalizeCrossesThreshold(t *testing.T) {
+	m := peers.NewManager()
+	pid := peer.ID("peer-a")
+
+	if m.Penalize(pid, peers.PenaltyUnrequestedBlock) {
+		t.Fatal("single penalty should not cross threshold")
+	}
+	if got := m.Score(pid); got != -peers.PenaltyUnrequestedBlock {
+		t.Fatalf("score = %d, want %d", got, -peers.PenaltyUnrequestedBlock)
+	}
+
+	crossed := false
+	for i := 0; i < 10 && !crossed; i++ {
+		crossed = m.Penalize(pid, peers.PenaltyBrokenChain)
+	}
+	if !crossed {
+		t.Fatal("repeated penalties should cross threshold")
+	}
+}
+
+func TestRemoveResetsScore(t *testing.T) {
+	m := peers.NewManager()
+	pid := peer.ID("peer-b")
+
+	m.Penalize(pid, peers.PenaltyRequestFailure)
+	m.Remove(pid)
+	if got := m.Score(pid); got != 0 {
+		t.Fatalf("score after remove = %d, want 0", got)
+	}
+}
+
+func TestSelectProtocols(t *testing.T) {
+	m := peers.NewManager()
+	pid := peer.ID("peer-c")
+	preferred := []protocol.ID{"/req/blocks/2", "/req/blocks/1"}
+
+	// Unidentified peers keep the full candidate list for negotiation.
+	if got := m.SelectProtocols(pid, preferred); len(got) != 2 {
+		t.Fatalf("unidentified peer: got %v, want all candidates", got)
+	}
+
+	m.SetProtocols(pid, []protocol.ID{"/req/blocks/1", "/other/1"})
+	got := m.SelectProtocols(pid, preferred)
+	if len(got) != 1 || got[0] != "/req/blocks/1" {
+		t.Fatalf("got %v, want [/req/blocks/1]", got)
+	}
+
+	m.SetProtocols(peer.ID("peer-d"), []protocol.ID{"/req/blocks/2", "/req/blocks/1"})
+	counts := m.ProtocolCounts(preferred)
+	if counts["/req/blocks/1"] != 2 || counts["/req/blocks/2"] != 1 {
+		t.Fatalf("unexpected protocol counts: %v", counts)
+	}
+}
+
+func TestRankPeersPrefersFastReliablePeers(t *testing.T) {
+	m := peers.NewManager()
+	slow, fast, flaky := peer.ID("slow"), peer.ID("fast"), peer.ID("flaky")
+
+	m.RecordRequest(slow, 800*time.Millisecond, true)
+	m.RecordRequest(fast, 50*time.Millisecond, true)
+	m.RecordRequest(flaky, 40*time.Millisecond, true)
+	for i := 0; i < 20; i++ {
+		m.RecordRequest(flaky, 0, false)
+	}
+
+	ranked := m.RankPeers([]peer.ID{slow, flaky, fast})
+	if ranked[0] != fast {
+		t.Fatalf("ranked[0] = %s, want fast", ranked[0])
+	}
+	if ranked[2] != flaky {
+		t.Fatalf("ranked[2] = %s, want flaky", ranked[2])
+	}
+}
+
+func TestRankPeersPutsPenalizedPeersLast(t *testing.T) {
+	m := peers.NewManager()
+	stalled, other := peer.ID("stalled"), peer.ID("other")
+	m.RecordRequest(stalled, 10*time.Millisecond, true)
+	m.RecordRequest(other, 500*time.Millisecond, true)
+
+	if ranked := m.RankPeers([]peer.ID{stalled, other}); ranked[0] != stalled {
+		t.Fatalf("ranked[0] = %s, want the faster stalled", ranked[0])
+	}
+	m.Penalize(stalled, peers.PenaltyUnconnectedChain)
+	if ranked := m.RankPeers([]peer.ID{stalled, other}); ranked[0] != other {
+		t.Fatalf("ranked[0] = %s, want other after stalled was penalized", ranked[0])
+	}
+}
+
+func TestRankPeersRoundRobinWithoutMeasurements(t *testing.T) {
+	m := peers.NewManager()
+	candidates := []peer.ID{"a", "b", "c"}
+
+	first := m.RankPeers(candidates)[0]
+	second := m.RankPeers(candidates)[0]
+	if first == second {
+		t.Fatalf("expected rotation across calls, got %s twice", first)
+	}
+}
+
+func TestParseAgent(t *testing.T) {
+	for agent, want := range map[string][2]string{
+		"gean/v0.1.0":                    {"gean", "v0.1.0"},
+		"Lighthouse/v5.1.0/x86_64-linux": {"lighthouse", "v5.1.0"},
+		"zeam":                           {"zeam", "unknown"},
+		"":                               {"unknown", "unknown"},
+		"github.com/libp2p/go-libp2p":    {"go-libp2p", "unknown"},
+	} {
+		client, version := peers.ParseAgent(agent)
+		if client != want[0] || version != want[1] {
+			t.Errorf("ParseAgent(%q) = %q, %q; want %q, %q", agent, client, version, want[0], want[1])
+		}
+	}
+}
+
+func TestClientCounts(t *testing.T) {
+	m := peers.NewManager()
+	m.SetAgent(peer.ID("a"), "zeam/v0.3.0")
+	m.SetAgent(peer.ID("b"), "zeam/v0.3.0")
+	m.SetAgent(peer.ID("c"), "ream/v0.1.0")
+	m.SetAgent(peer.ID("d"), "gean/v0.1.0")
+	m.Remove(peer.ID("d"))
+
+	got := m.ClientCounts()
+	want := []peers.ClientCount{
+		{Client: "zeam", Version: "v0.3.0", Peers: 2},
+		{Client: "ream", Version: "v0.1.0", Peers: 1},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestLimitsFor(t *testing.T) {
+	if got := peers.LimitsFor(0); got.Max != peers.DefaultMaxPeers {
+		t.Fatalf("default max = %d, want %d", got.Max, peers.DefaultMaxPeers)
+	}
+	if got := peers.LimitsFor(20); got != (peers.Limits{Target: 18, Max: 20}) {
+		t.Fatalf("LimitsFor(20) = %+v", got)
+	}
+}
+
+func TestConnectedReportsOverLimit(t *testing.T) {
+	m := peers.NewManager()
+	m.SetLimits(peers.Limits{Target: 1, Max: 2})
+
+	if m.Connected("a", peers.Outbound) || m.Connected("b", peers.Inbound) {
+		t.Fatal("connections within the maximum reported over limit")
+	}
+	if m.Connected("b", peers.Outbound) {
+		t.Fatal("second connection to a known peer reported over limit")
+	}
+	if info, _ := m.Peer("b"); info.Direction != peers.Inbound {
+		t.Fatalf("direction = %s, want the first connection's inbound", info.Direction)
+	}
+	if !m.Connected("c", peers.Inbound) {
+		t.Fatal("connection beyond the maximum not reported")
+	}
+	m.Disconnected("c")
+	if _, ok := m.Peer("c"); ok {
+		t.Fatal("disconnected peer still tracked")
+	}
+}
+
+func TestPruneCandidatesWorstFirst(t *testing.T) {
+	m := peers.NewManager()
+	m.SetLimits(peers.Limits{Target: 2, Max: 4})
+
+	m.Connected("good-out", peers.Outbound)
+	m.Connected("good-in", peers.Inbound)
+	m.Connected("penalized-out", peers.Outbound)
+	m.Connected("fresh-in", peers.Inbound)
+	m.Penalize("penalized-out", peers.PenaltyRequestFailure)
+
+	if got := m.PruneCandidates(); len(got) != 2 || got[0] != "penalized-out" || got[1] != "fresh-in" {
+		t.Fatalf("candidates = %v, want [penalized-out fresh-in]", got)
+	}
+
+	m.Disconnected("penalized-out")
+	m.Disconnected("fresh-in")
+	if got := m.PruneCandidates(); len(got) != 0 {
+		t.Fatalf("candidates at target = %v, want none", got)
+	}
+
+	// A peer past the disconnect threshold goes even at the target.
+	for !m.Penalize("good-out", peers.PenaltyBrokenChain) {
+	}
+	if got := m.PruneCandidates(); len(got) != 1 || got[0] != "good-out" {
+		t.Fatalf("candidates = %v, want [good-out]", got)
+	}
+}
+
+func TestChainStatusOnlyForConnectedPeers(t *testing.T) {
+	m := peers.NewManager()
+	finalized := types.Checkpoint{Root: [32]byte{1}, Slot: 8}
+	head := types.Checkpoint{Root: [32]byte{2}, Slot: 12}
+
+	m.SetChainStatus("a", finalized, head)
+	m.Connected("a", peers.Outbound)
+	if info, _ := m.Peer("a"); info.Status != nil {
+		t.Fatal("status recorded before the peer connected")
+	}
+	m.SetChainStatus("a", finalized, head)
+	info, _ := m.Peer("a")
+	if info.Status == nil || info.Status.Finalized != finalized || info.Status.Head != head {
+		t.Fatalf("status = %+v, want finalized %v head %v", info.Status, finalized, head)
+	}
+}
+
+func TestWatchTracksConnectionDirection(t *testing.T) {
+	mn := mocknet.New()
+	defer mn.Close()
+	a, err := mn.GenPeer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := mn.GenPeer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mn.LinkAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	m := peers.NewManager()
+	m.SetLimits(peers.Limits{Target: 1, Max: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := m.Watch(ctx, a); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := mn.ConnectPeers(b.ID(), a.ID()); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool {
+		info, ok := m.Peer(b.ID())
+		return ok && info.Direction == peers.Inbound
+	})
+
+	if err := a.Network().ClosePeer(b.ID()); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool {
+		_, ok := m.Peer(b.ID())
+		return !ok
+	})
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,124 @@
+package peers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/libp2p/go-libp2p/core/event"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
+
+// SetProtocols replaces the set of protocols a peer is known to support.
+func (m *Manager) SetProtocols(pid peer.ID, protos []protocol.ID) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	set := make(map[protocol.ID]struct{}, len(protos))
+	for _, p := range protos {
+		set[p] = struct{}{}
+	}
+	m.protocols[pid] = set
+}
+
+// updateProtocols applies an incremental protocol change for a peer.
+func (m *Manager) updateProtocols(pid peer.ID, added, removed []protocol.ID) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	set, ok := m.protocols[pid]
+	if !ok {
+		set = make(map[protocol.ID]struct{})
+		m.protocols[pid] = set
+	}
+	for _, p := range added {
+		set[p] = struct{}{}
+	}
+	for _, p := range removed {
+		delete(set, p)
+	}
+}
+
+// forgetIdentity drops the protocol set and agent of a disconnected peer.
+func (m *Manager) forgetIdentity(pid peer.ID) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.protocols, pid)
+	delete(m.agents, pid)
+}
+
+// SelectProtocols filters candidates, given in order of preference, down to
+// the ones the peer supports. If the peer has not been identified yet the
+// candidates are returned unchanged so stream negotiation can decide.
+func (m *Manager) SelectProtocols(pid peer.ID, candidates []protocol.ID) []protocol.ID {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	set, ok := m.protocols[pid]
+	if !ok {
+		return candidates
+	}
+	var out []protocol.ID
+	for _, p := range candidates {
+		if _, ok := set[p]; ok {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// ProtocolCounts returns how many identified peers support each of protos.
+func (m *Manager) ProtocolCounts(protos []protocol.ID) map[protocol.ID]int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	counts := make(map[protocol.ID]int, len(protos))
+	for _, p := range protos {
+		counts[p] = 0
+		for _, set := range m.protocols {
+			if _, ok := set[p]; ok {
+				counts[p]++
+			}
+		}
+	}
+	return counts
+}
+
+// Watch keeps protocol sets, agents and connections in sync with libp2p
+// identify results and connection changes until ctx is cancelled.
+func (m *Manager) Watch(ctx context.Context, h host.Host) error {
+	m.watchConnections(h.Network())
+
+	sub, err := h.EventBus().Subscribe([]interface{}{
+		new(event.EvtPeerIdentificationCompleted),
+		new(event.EvtPeerProtocolsUpdated),
+		new(event.EvtPeerConnectednessChanged),
+	})
+	if err != nil {
+		return fmt.Errorf("subscribe peer events: %w", err)
+	}
+
+	go func() {
+		defer sub.Close()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case e, ok := <-sub.Out():
+				if !ok {
+					return
+				}
+				switch evt := e.(type) {
+				case event.EvtPeerIdentificationCompleted:
+					m.SetProtocols(evt.Peer, evt.Protocols)
+					m.SetAgent(evt.Peer, evt.AgentVersion)
+				case event.EvtPeerProtocolsUpdated:
+					m.updateProtocols(evt.Peer, evt.Added, evt.Removed)
+				case event.EvtPeerConnectednessChanged:
+					if evt.Connectedness == network.NotConnected {
+						m.forgetIdentity(evt.Peer)
+					}
+				}
+			}
+		}
+	}()
+	return nil
+}
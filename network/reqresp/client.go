@@ -13,11 +13,16 @@ import (
 )
 
 // RequestStatus sends a status request to a peer and returns their response.
-func RequestStatus(ctx context.Context, h host.Host, pid peer.ID, status Status) (*Status, error) {
+// protos optionally restricts and orders the protocol versions to negotiate;
+// it defaults to StatusProtocols.
+func RequestStatus(ctx context.Context, h host.Host, pid peer.ID, status Status, protos ...protocol.ID) (*Status, error) {
 	ctx, cancel := context.WithTimeout(ctx, reqRespTimeout)
 	defer cancel()
 
-	s, err := h.NewStream(ctx, pid, protocol.ID(StatusProtocol))
+	if len(protos) == 0 {
+		protos = StatusProtocols
+	}
+	s, err := h.NewStream(ctx, pid, protos...)
 	if err != nil {
 		return nil, fmt.Errorf("open stream: %w", err)
 	}
@@ -45,12 +50,24 @@ func RequestStatus(ctx context.Context, h host.Host, pid peer.ID, status Status)
 	return &resp, nil
 }
 
-// RequestBlocksByRoot requests blocks by their roots from a peer.
-func RequestBlocksByRoot(ctx context.Context, h host.Host, pid peer.ID, roots [][32]byte) ([]*types.SignedBlockWithAttestation, error) {
+// RequestBlocksByRoot requests blocks by their roots from a peer. protos
+// optionally restricts and orders the protocol versions to negotiate; it
+// defaults to BlocksByRootProtocols.
+func RequestBlocksByRoot(ctx context.Context, h host.Host, pid peer.ID, roots [][32]byte, protos ...protocol.ID) ([]*types.SignedBlockWithAttestation, error) {
+	return RequestBlocksByRootFiltered(ctx, h, pid, roots, nil, protos...)
+}
+
+// RequestBlocksByRootFiltered is RequestBlocksByRoot with an optional header
+// pre-check: response chunks whose header fields keep rejects are skipped
+// without a full decode.
+func RequestBlocksByRootFiltered(ctx context.Context, h host.Host, pid peer.ID, roots [][32]byte, keep func(types.BlockHeaderFields) bool, protos ...protocol.ID) ([]*types.SignedBlockWithAttestation, error) {
 	ctx, cancel := context.WithTimeout(ctx, reqRespTimeout)
 	defer cancel()
 
-	s, err := h.NewStream(ctx, pid, protocol.ID(BlocksByRootProtocol), protocol.ID(BlocksByRootProtocolLegacy))
+	if len(protos) == 0 {
+		protos = BlocksByRootProtocols
+	}
+	s, err := h.NewStream(ctx, pid, protos...)
 	if err != nil {
 		return nil, fmt.Errorf("open stream: %w", err)
 	}
@@ -85,6 +102,12 @@ func RequestBlocksByRoot(ctx context.Context, h host.Host, pid peer.ID, roots []
 		if err != nil {
 			return blocks, fmt.Errorf("read block: %w", err)
 		}
+		if keep != nil {
+			hdr, err := types.PeekSignedBlockHeader(data)
+			if err != nil || !keep(hdr) {
+				continue
+			}
+		}
 		block := new(types.SignedBlockWithAttestation)
 		if err := block.UnmarshalSSZ(data); err != nil {
 			continue
@@ -0,0 +1,206 @@
+package reqresp
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/geanlabs/gean/chain/statetransition"
+	"github.com/geanlabs/gean/types"
+)
+
+// MaxFinalityProofHeaders bounds the header chain in a finality proof. A
+// trusted checkpoint further behind the server's head than this must be
+// advanced in steps.
+const MaxFinalityProofHeaders = 1024
+
+// ErrNoFinalityProof is returned by the server handler when it cannot link
+// the trusted checkpoint to its head.
+var ErrNoFinalityProof = errors.New("trusted checkpoint is not an ancestor of head")
+
+// FinalityProof lets a client that trusts one checkpoint verify a later
+// finalized checkpoint without running fork choice. Headers are the block
+// headers from the child of the trusted block up to the proving block, in
+// ascending order, and State is the post-state of the proving block; its
+// LatestFinalized is the proven checkpoint.
+type FinalityProof struct {
+	Headers []*types.BlockHeader
+	State   *types.State
+}
+
+// WriteFinalityProof writes proof as a sequence of success-prefixed chunks:
+// the state, then each header.
+func WriteFinalityProof(w io.Writer, proof *FinalityProof) error {
+	state, err := proof.State.MarshalSSZ()
+	if err != nil {
+		return fmt.Errorf("encode state: %w", err)
+	}
+	if err := writeChunk(w, state); err != nil {
+		return err
+	}
+	for _, h := range proof.Headers {
+		data, err := h.MarshalSSZ()
+		if err != nil {
+			return fmt.Errorf("encode header: %w", err)
+		}
+		if err := writeChunk(w, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadFinalityProof reads a proof written by WriteFinalityProof until EOF.
+func ReadFinalityProof(r io.Reader) (*FinalityProof, error) {
+	data, err := readChunk(r)
+	if err != nil {
+		return nil, fmt.Errorf("read state: %w", err)
+	}
+	proof := &FinalityProof{State: new(types.State)}
+	if err := proof.State.UnmarshalSSZ(data); err != nil {
+		return nil, fmt.Errorf("decode state: %w", err)
+	}
+	for {
+		data, err := readChunk(r)
+		if err == io.EOF {
+			return proof, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read header: %w", err)
+		}
+		if len(proof.Headers) == MaxFinalityProofHeaders {
+			return nil, fmt.Errorf("more than %d headers", MaxFinalityProofHeaders)
+		}
+		h := new(types.BlockHeader)
+		if err := h.UnmarshalSSZ(data); err != nil {
+			return nil, fmt.Errorf("decode header: %w", err)
+		}
+		proof.Headers = append(proof.Headers, h)
+	}
+}
+
+func writeChunk(w io.Writer, data []byte) error {
+	if _, err := w.Write([]byte{ResponseSuccess}); err != nil {
+		return err
+	}
+	return WriteSnappyFrame(w, data)
+}
+
+// readChunk reads one success-prefixed chunk. It returns io.EOF at a clean
+// end of stream.
+func readChunk(r io.Reader) ([]byte, error) {
+	code, err := ReadResponseCode(r)
+	if err != nil {
+		return nil, err
+	}
+	if code != ResponseSuccess {
+		return nil, fmt.Errorf("peer returned error code %d", code)
+	}
+	return ReadSnappyFrame(r)
+}
+
+// VerifyFinalityProof checks proof against a trusted checkpoint and returns
+// the finalized checkpoint it proves. It verifies that the headers form a
+// chain from the trusted block, that the last header commits to the proof
+// state, and that the state's finalized checkpoint lies on that chain and is
+// marked justified.
+func VerifyFinalityProof(trusted types.Checkpoint, proof *FinalityProof) (*types.Checkpoint, error) {
+	if len(proof.Headers) == 0 {
+		return nil, errors.New("proof has no headers")
+	}
+	if proof.State == nil || proof.State.LatestFinalized == nil {
+		return nil, errors.New("proof has no state")
+	}
+
+	chain := map[[32]byte]uint64{trusted.Root: trusted.Slot}
+	parent, slot := trusted.Root, trusted.Slot
+	for i, h := range proof.Headers {
+		if h.ParentRoot != parent {
+			return nil, fmt.Errorf("header %d does not extend the previous header", i)
+		}
+		if h.Slot <= slot {
+			return nil, fmt.Errorf("header %d slot %d is not after %d", i, h.Slot, slot)
+		}
+		root, err := h.HashTreeRoot()
+		if err != nil {
+			return nil, fmt.Errorf("hash header %d: %w", i, err)
+		}
+		chain[root] = h.Slot
+		parent, slot = root, h.Slot
+	}
+
+	stateRoot, err := proof.State.HashTreeRoot()
+	if err != nil {
+		return nil, fmt.Errorf("hash state: %w", err)
+	}
+	last := proof.Headers[len(proof.Headers)-1]
+	if stateRoot != last.StateRoot {
+		return nil, fmt.Errorf("state root %x does not match last header %x", stateRoot, last.StateRoot)
+	}
+
+	finalized := proof.State.LatestFinalized
+	if s, ok := chain[finalized.Root]; !ok || s != finalized.Slot {
+		return nil, fmt.Errorf("finalized checkpoint %x at slot %d is not on the proven chain", finalized.Root, finalized.Slot)
+	}
+	if !statetransition.GetBit(proof.State.JustifiedSlots, finalized.Slot) {
+		return nil, fmt.Errorf("finalized slot %d is not marked justified", finalized.Slot)
+	}
+	return &types.Checkpoint{Root: finalized.Root, Slot: finalized.Slot}, nil
+}
+
+// RequestFinalityProof asks a peer for a proof of its latest finalized
+// checkpoint relative to trusted. The proof is returned unverified.
+func RequestFinalityProof(ctx context.Context, h host.Host, pid peer.ID, trusted types.Checkpoint) (*FinalityProof, error) {
+	ctx, cancel := context.WithTimeout(ctx, reqRespTimeout)
+	defer cancel()
+
+	s, err := h.NewStream(ctx, pid, FinalityProofProtocol)
+	if err != nil {
+		return nil, fmt.Errorf("open stream: %w", err)
+	}
+	defer s.Close()
+
+	if err := WriteSnappyFrame(s, encodeCheckpoint(trusted)); err != nil {
+		return nil, fmt.Errorf("write request: %w", err)
+	}
+	if err := s.CloseWrite(); err != nil {
+		return nil, fmt.Errorf("close write: %w", err)
+	}
+	return ReadFinalityProof(s)
+}
+
+func handleFinalityProof(s network.Stream, handler *ReqRespHandler) {
+	if handler.OnFinalityProof == nil {
+		return
+	}
+	data, err := ReadSnappyFrame(s)
+	if err != nil || len(data) != 40 {
+		s.Write([]byte{ResponseInvalidRequest})
+		return
+	}
+	trusted := types.Checkpoint{Slot: binary.LittleEndian.Uint64(data[32:40])}
+	copy(trusted.Root[:], data[:32])
+	if !allowRequest(s, handler, 1) {
+		return
+	}
+
+	proof, err := handler.OnFinalityProof(trusted)
+	if err != nil {
+		s.Write([]byte{ResponseResourceUnavailable})
+		return
+	}
+	WriteFinalityProof(s, proof)
+}
+
+func encodeCheckpoint(cp types.Checkpoint) []byte {
+	buf := make([]byte, 40)
+	copy(buf[:32], cp.Root[:])
+	binary.LittleEndian.PutUint64(buf[32:], cp.Slot)
+	return buf
+}
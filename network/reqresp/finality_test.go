@@ -0,0 +1,100 @@
+package reqresp_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/geanlabs/gean/chain/forkchoice"
+	"github.com/geanlabs/gean/chain/statetransition"
+	"github.com/geanlabs/gean/network/reqresp"
+	"github.com/geanlabs/gean/storage/memory"
+	"github.com/geanlabs/gean/types"
+)
+
+type zeroSigner struct{}
+
+func (zeroSigner) Sign(epoch uint32, message [32]byte) ([]byte, error) {
+	return make([]byte, types.XMSSSignatureSize), nil
+}
+
+// testFinalityProof builds a proof that the first block after genesis
+// finalizes genesis, and returns it with the genesis checkpoint.
+func testFinalityProof(t *testing.T) (types.Checkpoint, *reqresp.FinalityProof) {
+	t.Helper()
+	validators := make([]*types.Validator, 3)
+	for i := range validators {
+		validators[i] = &types.Validator{Index: uint64(i)}
+	}
+	state := statetransition.GenerateGenesis(1000, validators)
+	genesis := &types.Block{Body: &types.BlockBody{Attestations: []*types.Attestation{}}}
+	genesis.StateRoot, _ = state.HashTreeRoot()
+	genesisRoot, _ := genesis.HashTreeRoot()
+
+	fc := forkchoice.NewStore(state, genesis, memory.New())
+	envelope, err := fc.ProduceBlock(1, 1, zeroSigner{})
+	if err != nil {
+		t.Fatalf("ProduceBlock: %v", err)
+	}
+	block := envelope.Message.Block
+	root, _ := block.HashTreeRoot()
+	post, ok := fc.GetState(root)
+	if !ok {
+		t.Fatal("post-state not stored")
+	}
+	bodyRoot, _ := block.Body.HashTreeRoot()
+	header := &types.BlockHeader{
+		Slot:          block.Slot,
+		ProposerIndex: block.ProposerIndex,
+		ParentRoot:    block.ParentRoot,
+		StateRoot:     block.StateRoot,
+		BodyRoot:      bodyRoot,
+	}
+	return types.Checkpoint{Root: genesisRoot}, &reqresp.FinalityProof{Headers: []*types.BlockHeader{header}, State: post}
+}
+
+func TestFinalityProofRoundTripAndVerify(t *testing.T) {
+	trusted, proof := testFinalityProof(t)
+
+	var buf bytes.Buffer
+	if err := reqresp.WriteFinalityProof(&buf, proof); err != nil {
+		t.Fatalf("WriteFinalityProof: %v", err)
+	}
+	decoded, err := reqresp.ReadFinalityProof(&buf)
+	if err != nil {
+		t.Fatalf("ReadFinalityProof: %v", err)
+	}
+
+	finalized, err := reqresp.VerifyFinalityProof(trusted, decoded)
+	if err != nil {
+		t.Fatalf("VerifyFinalityProof: %v", err)
+	}
+	if *finalized != trusted {
+		t.Fatalf("finalized = %+v, want genesis %+v", *finalized, trusted)
+	}
+}
+
+func TestVerifyFinalityProofRejectsTampering(t *testing.T) {
+	trusted, proof := testFinalityProof(t)
+
+	unlinked := *proof.Headers[0]
+	unlinked.ParentRoot = [32]byte{0x01}
+	if _, err := reqresp.VerifyFinalityProof(trusted, &reqresp.FinalityProof{
+		Headers: []*types.BlockHeader{&unlinked},
+		State:   proof.State,
+	}); err == nil {
+		t.Error("accepted headers that do not extend the trusted checkpoint")
+	}
+
+	forged := proof.State.Copy()
+	forged.LatestFinalized = &types.Checkpoint{Root: [32]byte{0x02}, Slot: 0}
+	if _, err := reqresp.VerifyFinalityProof(trusted, &reqresp.FinalityProof{
+		Headers: proof.Headers,
+		State:   forged,
+	}); err == nil {
+		t.Error("accepted a state that does not match the last header")
+	}
+
+	if _, err := reqresp.VerifyFinalityProof(types.Checkpoint{Root: [32]byte{0x03}}, proof); err == nil {
+		t.Error("accepted a proof for a different trusted checkpoint")
+	}
+}
@@ -0,0 +1,103 @@
+package reqresp
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// GoodbyeProtocol tells a peer why it is about to be disconnected.
+const GoodbyeProtocol = "/leanconsensus/req/goodbye/1/ssz_snappy"
+
+// goodbyeTimeout bounds sending a goodbye, which must not hold up the
+// disconnect it announces.
+const goodbyeTimeout = time.Second
+
+// GoodbyeReason is the SSZ uint64 carried by a goodbye message. The codes
+// follow the Ethereum consensus p2p specification.
+type GoodbyeReason uint64
+
+const (
+	GoodbyeClientShutdown    GoodbyeReason = 1
+	GoodbyeIrrelevantNetwork GoodbyeReason = 2
+	GoodbyeFault             GoodbyeReason = 3
+	GoodbyeUnableToVerify    GoodbyeReason = 128
+	GoodbyeTooManyPeers      GoodbyeReason = 129
+	GoodbyeBadScore          GoodbyeReason = 250
+	GoodbyeBanned            GoodbyeReason = 251
+)
+
+// String returns a metric-friendly name for the reason.
+func (r GoodbyeReason) String() string {
+	switch r {
+	case GoodbyeClientShutdown:
+		return "client_shutdown"
+	case GoodbyeIrrelevantNetwork:
+		return "irrelevant_network"
+	case GoodbyeFault:
+		return "fault"
+	case GoodbyeUnableToVerify:
+		return "unable_to_verify_network"
+	case GoodbyeTooManyPeers:
+		return "too_many_peers"
+	case GoodbyeBadScore:
+		return "bad_score"
+	case GoodbyeBanned:
+		return "banned"
+	}
+	return "code_" + strconv.FormatUint(uint64(r), 10)
+}
+
+// ReadGoodbye reads and decodes a snappy-framed goodbye reason.
+func ReadGoodbye(r io.Reader) (GoodbyeReason, error) {
+	data, err := ReadSnappyFrame(r)
+	if err != nil {
+		return 0, err
+	}
+	if len(data) != 8 {
+		return 0, fmt.Errorf("invalid goodbye length: %d", len(data))
+	}
+	return GoodbyeReason(binary.LittleEndian.Uint64(data)), nil
+}
+
+// WriteGoodbye encodes and writes a snappy-framed goodbye reason.
+func WriteGoodbye(w io.Writer, reason GoodbyeReason) error {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(reason))
+	return WriteSnappyFrame(w, buf[:])
+}
+
+// SendGoodbye tells pid why it is being disconnected. No response is
+// expected; the caller closes the connection afterwards.
+func SendGoodbye(ctx context.Context, h host.Host, pid peer.ID, reason GoodbyeReason) error {
+	ctx, cancel := context.WithTimeout(ctx, goodbyeTimeout)
+	defer cancel()
+
+	s, err := h.NewStream(ctx, pid, GoodbyeProtocol)
+	if err != nil {
+		return fmt.Errorf("open stream: %w", err)
+	}
+	defer s.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = s.SetWriteDeadline(deadline)
+	}
+	if err := WriteGoodbye(s, reason); err != nil {
+		return fmt.Errorf("write goodbye: %w", err)
+	}
+	return s.CloseWrite()
+}
+
+func handleGoodbye(s network.Stream, handler *ReqRespHandler) {
+	reason, err := ReadGoodbye(s)
+	if err != nil || handler.OnGoodbye == nil {
+		return
+	}
+	handler.OnGoodbye(s.Conn().RemotePeer(), reason)
+}
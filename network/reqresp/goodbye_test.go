@@ -0,0 +1,62 @@
+package reqresp_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+
+	"github.com/geanlabs/gean/network/reqresp"
+)
+
+func TestGoodbyeRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := reqresp.WriteGoodbye(&buf, reqresp.GoodbyeTooManyPeers); err != nil {
+		t.Fatal(err)
+	}
+	reason, err := reqresp.ReadGoodbye(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reason != reqresp.GoodbyeTooManyPeers || reason.String() != "too_many_peers" {
+		t.Fatalf("reason = %d (%s), want too_many_peers", reason, reason)
+	}
+	if got := reqresp.GoodbyeReason(7).String(); got != "code_7" {
+		t.Fatalf("unknown reason name = %q", got)
+	}
+}
+
+func TestSendGoodbye(t *testing.T) {
+	mn, err := mocknet.FullMeshConnected(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mn.Close()
+	hosts := mn.Hosts()
+
+	type goodbye struct {
+		pid    peer.ID
+		reason reqresp.GoodbyeReason
+	}
+	got := make(chan goodbye, 1)
+	reqresp.RegisterReqResp(hosts[1], &reqresp.ReqRespHandler{
+		OnGoodbye: func(pid peer.ID, reason reqresp.GoodbyeReason) {
+			got <- goodbye{pid, reason}
+		},
+	})
+
+	if err := reqresp.SendGoodbye(context.Background(), hosts[0], hosts[1].ID(), reqresp.GoodbyeClientShutdown); err != nil {
+		t.Fatalf("SendGoodbye: %v", err)
+	}
+	select {
+	case g := <-got:
+		if g.pid != hosts[0].ID() || g.reason != reqresp.GoodbyeClientShutdown {
+			t.Fatalf("goodbye = %+v, want client shutdown from %s", g, hosts[0].ID())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("goodbye not received")
+	}
+}
@@ -3,6 +3,9 @@ package reqresp
 import (
 	"time"
 
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+
 	"github.com/geanlabs/gean/types"
 )
 
@@ -11,6 +14,14 @@ const (
 	StatusProtocol             = "/leanconsensus/req/status/1/ssz_snappy"
 	BlocksByRootProtocol       = "/leanconsensus/req/lean_blocks_by_root/1/ssz_snappy"
 	BlocksByRootProtocolLegacy = "/leanconsensus/req/blocks_by_root/1/ssz_snappy"
+	FinalityProofProtocol      = "/leanconsensus/req/finality_proof/1/ssz_snappy"
+)
+
+// Supported versions of each protocol, newest first. Requests negotiate the
+// first entry the remote peer also supports.
+var (
+	StatusProtocols       = []protocol.ID{StatusProtocol}
+	BlocksByRootProtocols = []protocol.ID{BlocksByRootProtocol, BlocksByRootProtocolLegacy}
 )
 
 // Response status codes.
@@ -33,4 +44,15 @@ type Status struct {
 type ReqRespHandler struct {
 	OnStatus       func(Status) Status
 	OnBlocksByRoot func([][32]byte) []*types.SignedBlockWithAttestation
+	// OnFinalityProof builds a proof of the latest finalized checkpoint
+	// relative to a checkpoint the requester trusts.
+	OnFinalityProof func(trusted types.Checkpoint) (*FinalityProof, error)
+	// OnGoodbye is told that a peer is about to disconnect and why.
+	OnGoodbye func(pid peer.ID, reason GoodbyeReason)
+
+	// Limiter, if set, bounds the requests served per peer and in total.
+	// Requests over quota get ResponseResourceUnavailable and are reported
+	// to OnRateLimited.
+	Limiter       *RateLimiter
+	OnRateLimited func(pid peer.ID, proto protocol.ID)
 }
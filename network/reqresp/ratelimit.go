@@ -0,0 +1,172 @@
+package reqresp
+
+import (
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+
+	"github.com/geanlabs/gean/types"
+)
+
+// Quota allows Tokens requests, or blocks for blocks_by_root, per Period,
+// all of which may be spent at once.
+type Quota struct {
+	Tokens float64
+	Period time.Duration
+}
+
+// RateLimit is the per-peer and the global quota of one protocol. A zero
+// quota does not limit.
+type RateLimit struct {
+	PerPeer Quota
+	Global  Quota
+}
+
+// DefaultRateLimits bounds the requests served for each protocol. A peer
+// may fetch MaxRequestBlocks blocks a minute, enough for one full request;
+// finality proofs carry a whole state, so they are the scarcest.
+var DefaultRateLimits = map[protocol.ID]RateLimit{
+	StatusProtocol: {
+		PerPeer: Quota{Tokens: 5, Period: 15 * time.Second},
+		Global:  Quota{Tokens: 100, Period: 15 * time.Second},
+	},
+	BlocksByRootProtocol: {
+		PerPeer: Quota{Tokens: types.MaxRequestBlocks, Period: time.Minute},
+		Global:  Quota{Tokens: 8 * types.MaxRequestBlocks, Period: time.Minute},
+	},
+	FinalityProofProtocol: {
+		PerPeer: Quota{Tokens: 2, Period: time.Minute},
+		Global:  Quota{Tokens: 10, Period: time.Minute},
+	},
+}
+
+// limiterPruneInterval is how often buckets of peers that have not used
+// their quota for a full period are dropped.
+const limiterPruneInterval = time.Minute
+
+// RateLimiter enforces per-peer and global token-bucket quotas on incoming
+// requests, keyed by protocol. Versions of a protocol share one quota, under
+// the ID of the newest.
+type RateLimiter struct {
+	mu        sync.Mutex
+	limits    map[protocol.ID]RateLimit
+	global    map[protocol.ID]*bucket
+	peers     map[protocol.ID]map[peer.ID]*bucket
+	lastPrune time.Time
+
+	// NowFn returns the current time; tests replace it.
+	NowFn func() time.Time
+}
+
+// NewRateLimiter creates a limiter enforcing limits. Protocols without an
+// entry are not limited.
+func NewRateLimiter(limits map[protocol.ID]RateLimit) *RateLimiter {
+	return &RateLimiter{
+		limits: limits,
+		global: make(map[protocol.ID]*bucket),
+		peers:  make(map[protocol.ID]map[peer.ID]*bucket),
+		NowFn:  time.Now,
+	}
+}
+
+// Allow spends cost tokens of pid's and the global quota of proto, and
+// reports whether both had them. Nothing is spent if either falls short.
+// A nil limiter allows everything.
+func (l *RateLimiter) Allow(pid peer.ID, proto protocol.ID, cost float64) bool {
+	if l == nil {
+		return true
+	}
+	proto = quotaProtocol(proto)
+	limit, ok := l.limits[proto]
+	if !ok {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.NowFn()
+	if now.Sub(l.lastPrune) >= limiterPruneInterval {
+		l.pruneLocked(now)
+	}
+
+	var peerBucket, globalBucket *bucket
+	if limit.PerPeer.Tokens > 0 {
+		byPeer := l.peers[proto]
+		if byPeer == nil {
+			byPeer = make(map[peer.ID]*bucket)
+			l.peers[proto] = byPeer
+		}
+		if peerBucket = byPeer[pid]; peerBucket == nil {
+			peerBucket = newBucket(limit.PerPeer, now)
+			byPeer[pid] = peerBucket
+		}
+		if !peerBucket.has(cost, now) {
+			return false
+		}
+	}
+	if limit.Global.Tokens > 0 {
+		if globalBucket = l.global[proto]; globalBucket == nil {
+			globalBucket = newBucket(limit.Global, now)
+			l.global[proto] = globalBucket
+		}
+		if !globalBucket.has(cost, now) {
+			return false
+		}
+	}
+	if peerBucket != nil {
+		peerBucket.tokens -= cost
+	}
+	if globalBucket != nil {
+		globalBucket.tokens -= cost
+	}
+	return true
+}
+
+// pruneLocked drops peer buckets that have refilled completely; a new bucket
+// would start in the same state.
+func (l *RateLimiter) pruneLocked(now time.Time) {
+	l.lastPrune = now
+	for _, byPeer := range l.peers {
+		for pid, b := range byPeer {
+			if b.refill(now); b.tokens >= b.capacity {
+				delete(byPeer, pid)
+			}
+		}
+	}
+}
+
+// quotaProtocol maps older protocol versions to the ID their quota is kept
+// under.
+func quotaProtocol(proto protocol.ID) protocol.ID {
+	if proto == BlocksByRootProtocolLegacy {
+		return BlocksByRootProtocol
+	}
+	return proto
+}
+
+// bucket is a token bucket refilled continuously at capacity per period.
+type bucket struct {
+	tokens   float64
+	capacity float64
+	rate     float64 // tokens per second
+	last     time.Time
+}
+
+func newBucket(q Quota, now time.Time) *bucket {
+	return &bucket{tokens: q.Tokens, capacity: q.Tokens, rate: q.Tokens / q.Period.Seconds(), last: now}
+}
+
+func (b *bucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = min(b.capacity, b.tokens+elapsed*b.rate)
+		b.last = now
+	}
+}
+
+// has refills the bucket and reports whether it holds cost tokens.
+func (b *bucket) has(cost float64, now time.Time) bool {
+	b.refill(now)
+	return b.tokens >= cost
+}
@@ -0,0 +1,110 @@
+package reqresp_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+
+	"github.com/geanlabs/gean/network/reqresp"
+	"github.com/geanlabs/gean/types"
+)
+
+func newTestLimiter(limits map[protocol.ID]reqresp.RateLimit) (*reqresp.RateLimiter, *time.Time) {
+	now := time.Unix(1_700_000_000, 0)
+	l := reqresp.NewRateLimiter(limits)
+	l.NowFn = func() time.Time { return now }
+	return l, &now
+}
+
+func TestRateLimiterPerPeerQuota(t *testing.T) {
+	l, now := newTestLimiter(map[protocol.ID]reqresp.RateLimit{
+		reqresp.StatusProtocol: {PerPeer: reqresp.Quota{Tokens: 3, Period: 3 * time.Second}},
+	})
+
+	for i := 0; i < 3; i++ {
+		if !l.Allow("a", reqresp.StatusProtocol, 1) {
+			t.Fatalf("request %d within quota refused", i)
+		}
+	}
+	if l.Allow("a", reqresp.StatusProtocol, 1) {
+		t.Fatal("request over quota allowed")
+	}
+	if !l.Allow("b", reqresp.StatusProtocol, 1) {
+		t.Fatal("another peer's quota was spent")
+	}
+
+	*now = now.Add(time.Second)
+	if !l.Allow("a", reqresp.StatusProtocol, 1) {
+		t.Fatal("quota did not refill")
+	}
+	if l.Allow("a", reqresp.StatusProtocol, 1) {
+		t.Fatal("refilled more than one token in one second")
+	}
+}
+
+func TestRateLimiterGlobalQuota(t *testing.T) {
+	l, _ := newTestLimiter(map[protocol.ID]reqresp.RateLimit{
+		reqresp.BlocksByRootProtocol: {
+			PerPeer: reqresp.Quota{Tokens: 10, Period: time.Minute},
+			Global:  reqresp.Quota{Tokens: 12, Period: time.Minute},
+		},
+	})
+
+	if !l.Allow("a", reqresp.BlocksByRootProtocol, 8) {
+		t.Fatal("request within quota refused")
+	}
+	if l.Allow("b", reqresp.BlocksByRootProtocol, 8) {
+		t.Fatal("request over the global quota allowed")
+	}
+	// The refused request spent nothing from b's own quota.
+	if !l.Allow("b", reqresp.BlocksByRootProtocolLegacy, 4) {
+		t.Fatal("legacy request within the remaining global quota refused")
+	}
+	if l.Allow("c", reqresp.BlocksByRootProtocol, 1) {
+		t.Fatal("legacy request did not share the global quota")
+	}
+	if !l.Allow("c", reqresp.FinalityProofProtocol, 100) {
+		t.Fatal("protocol without a limit refused")
+	}
+}
+
+func TestStatusOverQuotaGetsResourceUnavailable(t *testing.T) {
+	mn, err := mocknet.FullMeshConnected(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mn.Close()
+	hosts := mn.Hosts()
+
+	limited := make(chan peer.ID, 1)
+	status := reqresp.Status{Finalized: &types.Checkpoint{}, Head: &types.Checkpoint{Slot: 3}}
+	reqresp.RegisterReqResp(hosts[1], &reqresp.ReqRespHandler{
+		OnStatus: func(reqresp.Status) reqresp.Status { return status },
+		Limiter: reqresp.NewRateLimiter(map[protocol.ID]reqresp.RateLimit{
+			reqresp.StatusProtocol: {PerPeer: reqresp.Quota{Tokens: 1, Period: time.Hour}},
+		}),
+		OnRateLimited: func(pid peer.ID, _ protocol.ID) { limited <- pid },
+	})
+
+	ctx := context.Background()
+	if _, err := reqresp.RequestStatus(ctx, hosts[0], hosts[1].ID(), status); err != nil {
+		t.Fatalf("first status: %v", err)
+	}
+	_, err = reqresp.RequestStatus(ctx, hosts[0], hosts[1].ID(), status)
+	if err == nil || !strings.Contains(err.Error(), "error code 3") {
+		t.Fatalf("second status err = %v, want resource unavailable", err)
+	}
+	select {
+	case pid := <-limited:
+		if pid != hosts[0].ID() {
+			t.Fatalf("rate limited %s, want %s", pid, hosts[0].ID())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnRateLimited not called")
+	}
+}
@@ -18,6 +18,16 @@ func RegisterReqResp(h host.Host, handler *ReqRespHandler) {
 	}
 	h.SetStreamHandler(BlocksByRootProtocol, bbr)
 	h.SetStreamHandler(BlocksByRootProtocolLegacy, bbr)
+
+	h.SetStreamHandler(FinalityProofProtocol, func(s network.Stream) {
+		defer s.Close()
+		handleFinalityProof(s, handler)
+	})
+
+	h.SetStreamHandler(GoodbyeProtocol, func(s network.Stream) {
+		defer s.Close()
+		handleGoodbye(s, handler)
+	})
 }
 
 func handleStatus(s network.Stream, handler *ReqRespHandler) {
@@ -28,6 +38,9 @@ func handleStatus(s network.Stream, handler *ReqRespHandler) {
 	if err != nil {
 		return
 	}
+	if !allowRequest(s, handler, 1) {
+		return
+	}
 	resp := handler.OnStatus(req)
 	if _, err := s.Write([]byte{ResponseSuccess}); err != nil {
 		return
@@ -45,6 +58,9 @@ func handleBlocksByRoot(s network.Stream, handler *ReqRespHandler) {
 	if err != nil {
 		return
 	}
+	if !allowRequest(s, handler, float64(len(roots))) {
+		return
+	}
 	blocks := handler.OnBlocksByRoot(roots)
 	for _, block := range blocks {
 		if _, err := s.Write([]byte{ResponseSuccess}); err != nil {
@@ -55,3 +71,18 @@ func handleBlocksByRoot(s network.Stream, handler *ReqRespHandler) {
 		}
 	}
 }
+
+// allowRequest charges cost to the requesting peer's quota for the stream's
+// protocol. Over quota, it answers ResponseResourceUnavailable and reports
+// false.
+func allowRequest(s network.Stream, handler *ReqRespHandler, cost float64) bool {
+	pid, proto := s.Conn().RemotePeer(), s.Protocol()
+	if handler.Limiter.Allow(pid, proto, cost) {
+		return true
+	}
+	s.Write([]byte{ResponseResourceUnavailable})
+	if handler.OnRateLimited != nil {
+		handler.OnRateLimited(pid, proto)
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/geanlabs/gean/chain/forkchoice"
+	"github.com/geanlabs/gean/chain/statetransition"
+	"github.com/geanlabs/gean/observability/logging"
+	"github.com/geanlabs/gean/observability/metrics"
+	"github.com/geanlabs/gean/types"
+)
+
+// blockBacklogSize bounds the background queue of gossip blocks for past
+// slots. Blocks dropped when it is full are recovered by sync.
+const blockBacklogSize = 256
+
+// gossipSeenFile, under the data directory, persists recently processed
+// gossip message IDs across restarts. Entries older than gossipSeenTTL have
+// left the mesh and are dropped.
+const (
+	gossipSeenFile = "gossip_seen"
+	gossipSeenTTL  = 2 * time.Minute
+)
+
+// admitBlock routes a gossip block by slot: blocks for the current and next
+// slot are imported immediately, older ones go to the background queue so a
+// burst of stale branches cannot delay the head.
+func (n *Node) admitBlock(sb *types.SignedBlockWithAttestation, decode time.Duration) {
+	slot := sb.Message.Block.Slot
+	if slot >= n.Clock.CurrentSlot() {
+		n.importGossipBlock(gossipBlock{sb, decode})
+		return
+	}
+	select {
+	case n.blockBacklog <- gossipBlock{sb, decode}:
+		metrics.GossipBlocksDeferred.Inc()
+	default:
+		metrics.GossipBlocksDropped.Inc()
+		n.log.Debug("block backlog full, dropping gossip block", "slot", slot)
+	}
+}
+
+// runBlockBacklog imports deferred gossip blocks until ctx is cancelled.
+func (n *Node) runBlockBacklog(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case gb := <-n.blockBacklog:
+			n.importGossipBlock(gb)
+		}
+	}
+}
+
+// gossipBlock is a decoded gossip block and the time its decode took.
+type gossipBlock struct {
+	sb     *types.SignedBlockWithAttestation
+	decode time.Duration
+}
+
+func (n *Node) importGossipBlock(gb gossipBlock) {
+	block := gb.sb.Message.Block
+	blockRoot, _ := block.HashTreeRoot()
+	n.gossipLog.Debug("received block via gossip",
+		"slot", block.Slot,
+		"proposer", block.ProposerIndex,
+		"block_root", logging.ShortHash(blockRoot),
+	)
+	timings, err := n.FC.ProcessBlockTimed(gb.sb)
+	if err != nil {
+		code := rejectCode(err)
+		metrics.GossipBlocksRejected.WithLabelValues(code).Inc()
+		n.gossipLog.Warn("rejected gossip block",
+			"slot", block.Slot,
+			"code", code,
+			"err", err,
+		)
+		return
+	}
+	n.gossipLog.Info("imported gossip block",
+		append([]any{
+			"slot", block.Slot,
+			"proposer", block.ProposerIndex,
+			"block_root", logging.ShortHash(blockRoot),
+			"decode", gb.decode,
+		}, importTimingAttrs(gb.sb, timings)...)...,
+	)
+}
+
+// rejectCode classifies a block import failure for metrics and logs.
+func rejectCode(err error) string {
+	if errors.Is(err, forkchoice.ErrUnknownParent) {
+		return "unknown_parent"
+	}
+	return string(statetransition.Code(err))
+}
+
+// importTimingAttrs returns log attributes with the attestation count and the
+// per-phase import timings of a block.
+func importTimingAttrs(sb *types.SignedBlockWithAttestation, t forkchoice.ImportTimings) []any {
+	return []any{
+		"attestations", len(sb.Message.Block.Body.Attestations),
+		"state_transition", t.StateTransition,
+		"sig_verify", t.SignatureVerify,
+		"votes", t.VoteProcessing,
+		"head_update", t.HeadUpdate,
+		"total", t.Total,
+	}
+}
+
+// admitAttestation reports whether a gossip attestation is worth verifying.
+// Votes older than one already held for the same validator are shed: fork
+// choice would discard them after paying for signature verification.
+func (n *Node) admitAttestation(sa *types.SignedAttestation) bool {
+	slot := sa.Message.Slot
+	if prev, ok := n.FC.GetNewAttestation(sa.ValidatorID); ok && prev.Message.Slot >= slot {
+		metrics.GossipAttestationsShed.Inc()
+		return false
+	}
+	if prev, ok := n.FC.GetKnownAttestation(sa.ValidatorID); ok && prev.Message.Slot >= slot {
+		metrics.GossipAttestationsShed.Inc()
+		return false
+	}
+	return true
+}
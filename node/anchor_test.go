@@ -0,0 +1,108 @@
+package node_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/geanlabs/gean/chain/forkchoice"
+	"github.com/geanlabs/gean/internal/testutil"
+	"github.com/geanlabs/gean/types"
+)
+
+func newAnchoredStore(t *testing.T) (*forkchoice.Store, *types.Block, [32]byte) {
+	t.Helper()
+	return testutil.AnchoredStore(t, testutil.Genesis(testutil.Validators(3)))
+}
+
+func TestProcessBlock_DuplicateAnchorIsIgnored(t *testing.T) {
+	fc, genesisBlock, anchorRoot := newAnchoredStore(t)
+
+	// A peer re-gossips the genesis block wrapped in its own envelope.
+	dup := *genesisBlock
+	envelope := &types.SignedBlockWithAttestation{
+		Message:   &types.BlockWithAttestation{Block: &dup},
+		Signature: [][3112]byte{{0xBB}},
+	}
+	if err := fc.ProcessBlock(envelope); err != nil {
+		t.Fatalf("duplicate anchor rejected: %v", err)
+	}
+	if head := fc.GetStatus().Head; head != anchorRoot {
+		t.Fatalf("head = %x, want anchor %x", head, anchorRoot)
+	}
+	stored, ok := fc.GetSignedBlock(anchorRoot)
+	if !ok || len(stored.Signature) != 0 {
+		t.Fatalf("stored anchor envelope was replaced: %+v", stored)
+	}
+}
+
+func TestProcessBlock_RejectsCompetingAnchorSlotBlocks(t *testing.T) {
+	fc, genesisBlock, anchorRoot := newAnchoredStore(t)
+
+	competing := *genesisBlock
+	competing.ProposerIndex = 2
+	competing.StateRoot = [32]byte{0x01}
+
+	childAtZero := *genesisBlock
+	childAtZero.ParentRoot = anchorRoot
+
+	for name, block := range map[string]*types.Block{
+		"competing genesis": &competing,
+		"slot 0 on anchor":  &childAtZero,
+	} {
+		t.Run(name, func(t *testing.T) {
+			envelope := &types.SignedBlockWithAttestation{
+				Message: &types.BlockWithAttestation{Block: block},
+			}
+			err := fc.ProcessBlock(envelope)
+			if !errors.Is(err, forkchoice.ErrConflictsWithAnchor) {
+				t.Fatalf("err = %v, want ErrConflictsWithAnchor", err)
+			}
+			root, _ := block.HashTreeRoot()
+			if _, ok := fc.GetBlock(root); ok {
+				t.Fatal("conflicting block was stored")
+			}
+			if head := fc.GetStatus().Head; head != anchorRoot {
+				t.Fatalf("head = %x, want anchor %x", head, anchorRoot)
+			}
+		})
+	}
+	if got := fc.Anchor(); got.Root != anchorRoot || got.Slot != 0 {
+		t.Fatalf("anchor = %+v", got)
+	}
+}
+
+func TestProcessBlock_ConcurrentImportsOfOneBlock(t *testing.T) {
+	producer, _, _ := newAnchoredStore(t)
+	producer.AdvanceTime(1000+types.SecondsPerSlot, true)
+	envelope, err := producer.ProduceBlock(1, 1, &testutil.Signer{})
+	if err != nil {
+		t.Fatalf("produce block: %v", err)
+	}
+	root, _ := envelope.Message.Block.HashTreeRoot()
+
+	fc, _, _ := newAnchoredStore(t)
+	fc.AdvanceTime(1000+types.SecondsPerSlot, false)
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- fc.ProcessBlock(envelope)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("concurrent import: %v", err)
+		}
+	}
+	if head := fc.RecomputeHead(); head != root {
+		t.Fatalf("head = %x, want imported block %x", head, root)
+	}
+	if n := len(fc.Equivocations()); n != 0 {
+		t.Fatalf("one block imported concurrently reported %d equivocations", n)
+	}
+}
@@ -0,0 +1,51 @@
+package node_test
+
+import (
+	"testing"
+
+	"github.com/geanlabs/gean/internal/testutil"
+	"github.com/geanlabs/gean/types"
+)
+
+func TestProcessAttestationsAppliesBatchInOrder(t *testing.T) {
+	fc, _, genesisRoot := newAnchoredStore(t)
+	fc.AdvanceTime(1000+2*types.SecondsPerSlot, true)
+	envelope, err := fc.ProduceBlock(1, 1, &testutil.Signer{})
+	if err != nil {
+		t.Fatalf("produce block: %v", err)
+	}
+	root, _ := envelope.Message.Block.HashTreeRoot()
+
+	vote := func(validator, slot uint64, head [32]byte, headSlot uint64) *types.SignedAttestation {
+		return &types.SignedAttestation{
+			ValidatorID: validator,
+			Message: &types.AttestationData{
+				Slot:   slot,
+				Head:   &types.Checkpoint{Root: head, Slot: headSlot},
+				Target: &types.Checkpoint{Root: genesisRoot, Slot: 0},
+				Source: &types.Checkpoint{Root: genesisRoot, Slot: 0},
+			},
+		}
+	}
+	fc.ProcessAttestations([]*types.SignedAttestation{
+		vote(0, 1, genesisRoot, 0),
+		vote(0, 2, root, 1), // newer vote from the same validator wins
+		vote(2, 2, root, 1),
+		vote(2, 1, genesisRoot, 0), // older vote is ignored
+		vote(3, 2, root, 1),        // unknown validator
+	})
+
+	for _, v := range []uint64{0, 2} {
+		sa, ok := fc.GetNewAttestation(v)
+		if !ok || sa.Message.Slot != 2 || sa.Message.Head.Root != root {
+			t.Fatalf("validator %d new attestation = %+v, %v; want the slot 2 vote", v, sa, ok)
+		}
+	}
+	if _, ok := fc.GetNewAttestation(1); ok {
+		t.Fatal("validator 1 has a new attestation")
+	}
+	fc.UpdateSafeTarget()
+	if got := fc.GetSafeTarget(); got.Root != root {
+		t.Fatalf("safe target = slot %d, want the block both batch votes support", got.Slot)
+	}
+}
@@ -0,0 +1,127 @@
+package node_test
+
+import (
+	"testing"
+
+	"github.com/geanlabs/gean/chain/forkchoice"
+	"github.com/geanlabs/gean/internal/testutil"
+	"github.com/geanlabs/gean/observability/metrics"
+	"github.com/geanlabs/gean/types"
+)
+
+func TestGossipAttestationReasons(t *testing.T) {
+	fc, _, genesisRoot := newAnchoredStore(t)
+	fc.AdvanceTime(1000+types.SecondsPerSlot, false)
+	genesis := &types.Checkpoint{Root: genesisRoot, Slot: 0}
+
+	tests := []struct {
+		name      string
+		validator uint64
+		data      *types.AttestationData
+		reason    forkchoice.AttestationReason
+		result    forkchoice.ValidationResult
+	}{
+		{"valid", 0, &types.AttestationData{Slot: 1, Head: genesis, Target: genesis, Source: genesis},
+			forkchoice.AttestationAccepted, forkchoice.ValidationAccept},
+		{"next slot", 1, &types.AttestationData{Slot: 2, Head: genesis, Target: genesis, Source: genesis},
+			forkchoice.AttestationPending, forkchoice.ValidationIgnore},
+		{"far future", 1, &types.AttestationData{Slot: 3, Head: genesis, Target: genesis, Source: genesis},
+			forkchoice.AttestationFutureSlot, forkchoice.ValidationIgnore},
+		{"unknown head", 1, &types.AttestationData{Slot: 1, Head: &types.Checkpoint{Root: [32]byte{1}, Slot: 1}, Target: genesis, Source: genesis},
+			forkchoice.AttestationPending, forkchoice.ValidationIgnore},
+		{"target slot mismatch", 2, &types.AttestationData{Slot: 1, Head: genesis, Target: &types.Checkpoint{Root: genesisRoot, Slot: 1}, Source: genesis},
+			forkchoice.AttestationTargetMismatch, forkchoice.ValidationReject},
+		{"source after target", 2, &types.AttestationData{Slot: 1, Head: genesis, Target: genesis, Source: &types.Checkpoint{Root: genesisRoot, Slot: 1}},
+			forkchoice.AttestationSourceAfterTarget, forkchoice.ValidationReject},
+		{"unknown validator", 99, &types.AttestationData{Slot: 1, Head: genesis, Target: genesis, Source: genesis},
+			forkchoice.AttestationBadSignature, forkchoice.ValidationReject},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason := fc.ProcessAttestation(&types.SignedAttestation{ValidatorID: tt.validator, Message: tt.data})
+			if reason != tt.reason || reason.Result() != tt.result {
+				t.Fatalf("reason = %q (%s), want %q (%s)", reason, reason.Result(), tt.reason, tt.result)
+			}
+		})
+	}
+}
+
+func TestRejectedAttestationsAreCountedByReason(t *testing.T) {
+	fc, _, genesisRoot := newAnchoredStore(t)
+	fc.AdvanceTime(1000+types.SecondsPerSlot, false)
+	genesis := &types.Checkpoint{Root: genesisRoot, Slot: 0}
+	counter := metrics.AttestationsDropped.WithLabelValues("reject", string(forkchoice.AttestationTargetMismatch))
+	before := testutil.CounterValue(t, counter)
+
+	reasons := fc.ProcessAttestations([]*types.SignedAttestation{
+		{ValidatorID: 0, Message: &types.AttestationData{Slot: 1, Head: genesis, Target: genesis, Source: genesis}},
+		{ValidatorID: 1, Message: &types.AttestationData{Slot: 1, Head: genesis, Target: &types.Checkpoint{Root: genesisRoot, Slot: 1}, Source: genesis}},
+	})
+	if len(reasons) != 2 || reasons[0] != forkchoice.AttestationAccepted || reasons[1] != forkchoice.AttestationTargetMismatch {
+		t.Fatalf("reasons = %q", reasons)
+	}
+	if got := testutil.CounterValue(t, counter) - before; got != 1 {
+		t.Fatalf("rejected counter rose by %v, want 1", got)
+	}
+}
+
+func TestAttestationBeforeFinalizedIsNotReplayed(t *testing.T) {
+	fc, _, genesisRoot := newAnchoredStore(t)
+	fc.AdvanceTime(1000+types.SecondsPerSlot, false)
+	root1 := testutil.ImportEmptyBlock(t, fc, 1, genesisRoot)
+	genesis := &types.Checkpoint{Root: genesisRoot, Slot: 0}
+	cp1 := &types.Checkpoint{Root: root1, Slot: 1}
+	old := &types.Attestation{
+		ValidatorID: 2,
+		Data:        &types.AttestationData{Slot: 1, Head: cp1, Target: cp1, Source: genesis},
+	}
+	importBlock := func(slot uint64, parent [32]byte, atts []*types.Attestation) [32]byte {
+		t.Helper()
+		fc.AdvanceTime(1000+slot*types.SecondsPerSlot, false)
+		block := testutil.BuildBlock(t, fc, slot, parent, &types.BlockBody{Attestations: atts})
+		envelope := &types.SignedBlockWithAttestation{
+			Message:   &types.BlockWithAttestation{Block: block},
+			Signature: make([][3112]byte, len(atts)),
+		}
+		if err := fc.ProcessBlock(envelope); err != nil {
+			t.Fatalf("import block %d: %v", slot, err)
+		}
+		root, _ := block.HashTreeRoot()
+		return root
+	}
+
+	// Validators 0 and 1 justify slots 1, 2 and 3 in turn, finalizing slot
+	// 2; validator 2's vote for slot 1 rides along in the first block.
+	parent, source, target := root1, genesis, cp1
+	for slot := uint64(2); slot <= 4; slot++ {
+		var atts []*types.Attestation
+		for v := uint64(0); v < 2; v++ {
+			atts = append(atts, &types.Attestation{
+				ValidatorID: v,
+				Data:        &types.AttestationData{Slot: target.Slot, Head: target, Target: target, Source: source},
+			})
+		}
+		if slot == 2 {
+			atts = append(atts, old)
+		}
+		parent = importBlock(slot, parent, atts)
+		source, target = target, &types.Checkpoint{Root: parent, Slot: slot}
+	}
+	if status := fc.GetStatus(); status.FinalizedSlot != 2 {
+		t.Fatalf("finalized slot %d, want 2", status.FinalizedSlot)
+	}
+	if _, ok := fc.GetKnownAttestation(2); ok {
+		t.Fatal("vote before the finalized slot was kept")
+	}
+
+	// Replayed in a later block or on gossip once the vote it replaced is
+	// gone, the old vote is still ignored.
+	importBlock(5, parent, []*types.Attestation{old})
+	if _, ok := fc.GetKnownAttestation(2); ok {
+		t.Fatal("vote before the finalized slot replayed in a block was recorded")
+	}
+	reason := fc.ProcessAttestation(&types.SignedAttestation{ValidatorID: old.ValidatorID, Message: old.Data})
+	if reason != forkchoice.AttestationBeforeFinalized {
+		t.Fatalf("replayed reason = %q, want %q", reason, forkchoice.AttestationBeforeFinalized)
+	}
+}
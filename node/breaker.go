@@ -0,0 +1,105 @@
+package node
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/geanlabs/gean/chain/forkchoice"
+	"github.com/geanlabs/gean/observability/metrics"
+)
+
+// signingFailureLimit is the number of consecutive signing failures after
+// which a validator's duties are disabled until re-enabled by an operator.
+const signingFailureLimit = 5
+
+// breakerSigner reports the outcome of every signature to the duties'
+// circuit breaker, so only signing errors (not e.g. a missing head) count.
+type breakerSigner struct {
+	forkchoice.Signer
+	v   *ValidatorDuties
+	idx uint64
+}
+
+func (s breakerSigner) Sign(signingSlot uint32, message [32]byte) ([]byte, error) {
+	sig, err := s.Signer.Sign(signingSlot, message)
+	s.v.recordSigning(s.idx, err)
+	return sig, err
+}
+
+// signerFor returns the key of validator idx wrapped for failure tracking,
+// or false if the validator has no key or its duties are disabled.
+func (v *ValidatorDuties) signerFor(idx uint64) (forkchoice.Signer, bool) {
+	if v.DutiesDisabled(idx) {
+		return nil, false
+	}
+	key, ok := v.key(idx)
+	if !ok {
+		v.Log.Error("validator key not found", "validator", idx)
+		return nil, false
+	}
+	return breakerSigner{Signer: key, v: v, idx: idx}, true
+}
+
+func (v *ValidatorDuties) recordSigning(idx uint64, err error) {
+	v.breakerMu.Lock()
+	defer v.breakerMu.Unlock()
+	if err == nil {
+		delete(v.signFailures, idx)
+		return
+	}
+
+	label := strconv.FormatUint(idx, 10)
+	metrics.ValidatorSigningFailures.WithLabelValues(label).Inc()
+	if v.signFailures == nil {
+		v.signFailures = make(map[uint64]int)
+	}
+	v.signFailures[idx]++
+	if v.signFailures[idx] < signingFailureLimit || v.disabled[idx] {
+		return
+	}
+	if v.disabled == nil {
+		v.disabled = make(map[uint64]bool)
+	}
+	v.disabled[idx] = true
+	metrics.ValidatorDutiesDisabled.WithLabelValues(label).Set(1)
+	v.Log.Error("validator duties disabled after repeated signing failures",
+		"validator", idx,
+		"consecutive_failures", v.signFailures[idx],
+		"err", err,
+	)
+}
+
+// DutiesDisabled reports whether the circuit breaker disabled idx's duties.
+func (v *ValidatorDuties) DutiesDisabled(idx uint64) bool {
+	v.breakerMu.Lock()
+	defer v.breakerMu.Unlock()
+	return v.disabled[idx]
+}
+
+// DisabledValidators returns the validators whose duties are disabled, in
+// ascending order.
+func (v *ValidatorDuties) DisabledValidators() []uint64 {
+	v.breakerMu.Lock()
+	defer v.breakerMu.Unlock()
+	out := make([]uint64, 0, len(v.disabled))
+	for idx := range v.disabled {
+		out = append(out, idx)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+// EnableDuties re-enables a validator disabled by the circuit breaker and
+// resets its failure count. It reports whether the validator was disabled.
+func (v *ValidatorDuties) EnableDuties(idx uint64) bool {
+	v.breakerMu.Lock()
+	defer v.breakerMu.Unlock()
+	if !v.disabled[idx] {
+		return false
+	}
+	delete(v.disabled, idx)
+	delete(v.signFailures, idx)
+	metrics.ValidatorDutiesDisabled.WithLabelValues(strconv.FormatUint(idx, 10)).Set(0)
+	v.Log.Info("validator duties re-enabled", "validator", idx)
+	return true
+}
@@ -0,0 +1,52 @@
+package node_test
+
+import (
+	"testing"
+
+	"github.com/geanlabs/gean/internal/testutil"
+	"github.com/geanlabs/gean/types"
+)
+
+func TestCanonicalRootFollowsHead(t *testing.T) {
+	fc, _, genesisRoot := newAnchoredStore(t)
+	signer := &testutil.Signer{}
+
+	if root, ok := fc.CanonicalRoot(0); !ok || root != genesisRoot {
+		t.Fatalf("slot 0 canonical root = %x, %v; want genesis", root, ok)
+	}
+
+	roots := map[uint64][32]byte{}
+	for _, slot := range []uint64{1, 2, 4} {
+		fc.AdvanceTime(1000+slot*types.SecondsPerSlot, true)
+		envelope, err := fc.ProduceBlock(slot, slot%3, signer)
+		if err != nil {
+			t.Fatalf("produce block at slot %d: %v", slot, err)
+		}
+		roots[slot], _ = envelope.Message.Block.HashTreeRoot()
+	}
+	if head := fc.RecomputeHead(); head != roots[4] {
+		t.Fatalf("head = %x, want block at slot 4", head)
+	}
+
+	for _, slot := range []uint64{1, 2, 4} {
+		if root, ok := fc.CanonicalRoot(slot); !ok || root != roots[slot] {
+			t.Fatalf("slot %d canonical root = %x, %v; want %x", slot, root, ok, roots[slot])
+		}
+	}
+	for _, slot := range []uint64{3, 5} {
+		if root, ok := fc.CanonicalRoot(slot); ok {
+			t.Fatalf("slot %d has canonical root %x, want none", slot, root)
+		}
+	}
+
+	// Dropping the head reorgs back to slot 2 and clears slot 4.
+	if _, err := fc.InvalidateBlock(roots[4]); err != nil {
+		t.Fatalf("invalidate: %v", err)
+	}
+	if _, ok := fc.CanonicalRoot(4); ok {
+		t.Fatal("slot 4 still canonical after reorg")
+	}
+	if root, ok := fc.CanonicalRoot(2); !ok || root != roots[2] {
+		t.Fatalf("slot 2 canonical root = %x, %v after reorg", root, ok)
+	}
+}
@@ -0,0 +1,157 @@
+package node
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/geanlabs/gean/config"
+	"github.com/geanlabs/gean/observability/logging"
+	"github.com/geanlabs/gean/storage"
+	"github.com/geanlabs/gean/storage/checkpoint"
+	"github.com/geanlabs/gean/types"
+)
+
+// anchorFile keeps the checkpoint bundle a persistent database was started
+// from, so the node can resume it after a restart.
+const anchorFile = "anchor.ckp"
+
+// checkpointFetchTimeout bounds downloading a checkpoint bundle.
+const checkpointFetchTimeout = 2 * time.Minute
+
+// readCheckpointBundle reads and verifies a checkpoint bundle from a local
+// path or an HTTP(S) URL. If trusted is non-empty the bundle must be signed
+// by one of its keys.
+func readCheckpointBundle(location string, trusted []ed25519.PublicKey) (*checkpoint.Bundle, error) {
+	var r io.Reader
+	if config.IsURL(location) {
+		client := &http.Client{Timeout: checkpointFetchTimeout}
+		resp, err := client.Get(location)
+		if err != nil {
+			return nil, fmt.Errorf("fetch checkpoint %s: %w", location, err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("fetch checkpoint %s: unexpected status %s", location, resp.Status)
+		}
+		r = resp.Body
+	} else {
+		f, err := os.Open(location)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	bundle, err := checkpoint.Read(r, trusted...)
+	if err != nil {
+		return nil, fmt.Errorf("read checkpoint %s: %w", location, err)
+	}
+	return bundle, nil
+}
+
+// checkBundleGenesis checks that a bundle's state belongs to the chain of the
+// genesis state: same chain config, including the preset overrides, and
+// validator set.
+func checkBundleGenesis(genesis *types.State, b *checkpoint.Bundle) error {
+	st := b.State
+	if st.Config == nil || st.Config.GenesisTime != genesis.Config.GenesisTime {
+		return fmt.Errorf("checkpoint is for another chain: genesis time differs from %d", genesis.Config.GenesisTime)
+	}
+	if st.Config.Overrides != genesis.Config.Overrides {
+		return fmt.Errorf("checkpoint is for another chain: preset %+v differs from %+v", st.Config.Preset(), genesis.Config.Preset())
+	}
+	if len(st.Validators) != len(genesis.Validators) {
+		return fmt.Errorf("checkpoint is for another chain: %d validators, want %d", len(st.Validators), len(genesis.Validators))
+	}
+	for i, v := range st.Validators {
+		if *v != *genesis.Validators[i] {
+			return fmt.Errorf("checkpoint is for another chain: validator %d differs", i)
+		}
+	}
+	return nil
+}
+
+// saveAnchor writes the bundle a database is started from into the data
+// directory. It has already been checked, so it is stored unsigned.
+func saveAnchor(dataDir string, b *checkpoint.Bundle) error {
+	path := filepath.Join(dataDir, anchorFile)
+	tmp, err := os.CreateTemp(dataDir, anchorFile+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := b.Write(tmp, nil); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// checkpointAnchor returns the block and state to start fork choice from
+// instead of genesis, or nils to start from genesis. A database holding a
+// chain resumes from the anchor it was started from; an empty one starts from
+// cfg.CheckpointSync if set.
+func checkpointAnchor(log *slog.Logger, cfg Config, db storage.Store, stored int, genesis *types.State, genesisRoot [32]byte) (*types.State, *types.Block, error) {
+	if stored > 0 {
+		if cfg.CheckpointSync != "" {
+			log.Info("database already holds a chain, ignoring checkpoint sync", "blocks", stored)
+		}
+		if _, ok := db.GetBlock(genesisRoot); ok {
+			return nil, nil, nil
+		}
+		bundle, err := readCheckpointBundle(filepath.Join(cfg.DataDir, anchorFile), nil)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil, nil, fmt.Errorf("database in %s holds a chain with a different genesis", cfg.DataDir)
+			}
+			return nil, nil, err
+		}
+		if err := checkBundleGenesis(genesis, bundle); err != nil {
+			return nil, nil, fmt.Errorf("database in %s holds a chain with a different genesis: %w", cfg.DataDir, err)
+		}
+		root, _ := bundle.Root()
+		if _, ok := db.GetBlock(root); !ok {
+			return nil, nil, fmt.Errorf("database in %s does not hold its checkpoint anchor %x", cfg.DataDir, root)
+		}
+		log.Info("resuming from checkpoint anchor", "slot", bundle.Block.Slot, "root", logging.ShortHash(root))
+		return bundle.State, bundle.Block, nil
+	}
+	if cfg.CheckpointSync == "" {
+		return nil, nil, nil
+	}
+
+	if len(cfg.CheckpointSyncKeys) == 0 {
+		log.Warn("checkpoint sync without a trusted signer key", "source", cfg.CheckpointSync)
+	}
+	bundle, err := readCheckpointBundle(cfg.CheckpointSync, cfg.CheckpointSyncKeys)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkBundleGenesis(genesis, bundle); err != nil {
+		return nil, nil, err
+	}
+	if storageBackend(cfg) != "memory" {
+		if err := saveAnchor(cfg.DataDir, bundle); err != nil {
+			return nil, nil, fmt.Errorf("save checkpoint anchor: %w", err)
+		}
+	}
+	root, _ := bundle.Root()
+	log.Info("starting from checkpoint",
+		"slot", bundle.Block.Slot,
+		"root", logging.ShortHash(root),
+		"justified", bundle.State.LatestJustified.Slot,
+		"finalized", bundle.State.LatestFinalized.Slot,
+	)
+	return bundle.State, bundle.Block, nil
+}
@@ -0,0 +1,125 @@
+package node
+
+import (
+	"crypto/ed25519"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/geanlabs/gean/chain/statetransition"
+	"github.com/geanlabs/gean/storage/checkpoint"
+	"github.com/geanlabs/gean/types"
+)
+
+// writeTestBundle writes a bundle for an empty block at slot 2 on top of the
+// genesis of cfg, signed with key, and returns its path and root.
+func writeTestBundle(t *testing.T, cfg Config, key ed25519.PrivateKey) (string, [32]byte) {
+	t.Helper()
+	genesis, err := statetransition.GenerateGenesisFromConfig(&statetransition.GenesisConfig{
+		GenesisTime: cfg.GenesisTime,
+		Preset:      cfg.Preset,
+		Validators:  cfg.Validators,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	genesisStateRoot, _ := genesis.HashTreeRoot()
+	parent := &types.Block{StateRoot: genesisStateRoot, Body: &types.BlockBody{Attestations: []*types.Attestation{}}}
+	parentRoot, _ := parent.HashTreeRoot()
+
+	block := &types.Block{
+		Slot:          2,
+		ProposerIndex: 2,
+		ParentRoot:    parentRoot,
+		Body:          &types.BlockBody{Attestations: []*types.Attestation{}},
+	}
+	state, err := statetransition.ProcessSlots(genesis, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state, err = statetransition.ProcessBlock(state, block); err != nil {
+		t.Fatal(err)
+	}
+	block.StateRoot, _ = state.HashTreeRoot()
+	bundle, err := checkpoint.New(block, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "checkpoint.bin")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := bundle.Write(f, key); err != nil {
+		t.Fatal(err)
+	}
+	root, _ := bundle.Root()
+	return path, root
+}
+
+func TestCheckpointSyncStartsAndResumesFromAnchor(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	validators := []*types.Validator{{Index: 0}, {Index: 1}, {Index: 2}}
+	pub, key, _ := ed25519.GenerateKey(nil)
+	cfg := Config{GenesisTime: 1000, Validators: validators, DataDir: t.TempDir(), DBBackend: "leveldb"}
+	path, root := writeTestBundle(t, cfg, key)
+
+	start := func(cfg Config) error {
+		t.Helper()
+		db, err := openStorage(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer closeStorage(db)
+		fc, err := initGenesis(log, cfg, db)
+		if err != nil {
+			return err
+		}
+		if anchor := fc.Anchor(); anchor.Root != root || anchor.Slot != 2 {
+			t.Fatalf("anchor = slot %d %x, want slot 2 %x", anchor.Slot, anchor.Root, root)
+		}
+		if head := fc.GetStatus().Head; head != root {
+			t.Fatalf("head = %x, want checkpoint %x", head, root)
+		}
+		return nil
+	}
+
+	other, _, _ := ed25519.GenerateKey(nil)
+	untrusted := cfg
+	untrusted.CheckpointSync, untrusted.CheckpointSyncKeys = path, []ed25519.PublicKey{other}
+	if err := start(untrusted); err == nil || !strings.Contains(err.Error(), "trusted") {
+		t.Fatalf("untrusted signer: err = %v", err)
+	}
+	wrongGenesis := cfg
+	wrongGenesis.GenesisTime++
+	wrongGenesis.CheckpointSync = path
+	if err := start(wrongGenesis); err == nil || !strings.Contains(err.Error(), "another chain") {
+		t.Fatalf("other chain: err = %v", err)
+	}
+	wrongPreset := cfg
+	wrongPreset.Preset = types.DefaultPreset
+	wrongPreset.Preset.ProposerSelection = types.ProposerShuffled
+	wrongPreset.CheckpointSync = path
+	if err := start(wrongPreset); err == nil || !strings.Contains(err.Error(), "preset") {
+		t.Fatalf("other preset: err = %v", err)
+	}
+
+	synced := cfg
+	synced.CheckpointSync, synced.CheckpointSyncKeys = path, []ed25519.PublicKey{pub}
+	if err := start(synced); err != nil {
+		t.Fatalf("checkpoint sync: %v", err)
+	}
+	// The restarted node resumes from the saved anchor without the flag.
+	if err := start(cfg); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	wrongGenesis.CheckpointSync = ""
+	if err := start(wrongGenesis); err == nil || !strings.Contains(err.Error(), "different genesis") {
+		t.Fatalf("resume with other genesis: err = %v", err)
+	}
+}
@@ -9,11 +9,13 @@ import (
 // Clock tracks slot and interval timing relative to genesis.
 type Clock struct {
 	GenesisTime uint64
+	Preset      types.Preset
 }
 
-// NewClock creates a clock from genesis time (unix seconds).
-func NewClock(genesisTime uint64) *Clock {
-	return &Clock{GenesisTime: genesisTime}
+// NewClock creates a clock from genesis time (unix seconds) and the preset
+// of the chain.
+func NewClock(genesisTime uint64, preset types.Preset) *Clock {
+	return &Clock{GenesisTime: genesisTime, Preset: preset}
 }
 
 // IsBeforeGenesis returns true if the current time is before genesis.
@@ -28,7 +30,7 @@ func (c *Clock) CurrentSlot() uint64 {
 		return 0
 	}
 	elapsed := now - c.GenesisTime
-	return elapsed / types.SecondsPerSlot
+	return elapsed / c.Preset.SecondsPerSlot
 }
 
 // CurrentInterval returns the current interval within the slot (0-3), or 0 if before genesis.
@@ -38,7 +40,7 @@ func (c *Clock) CurrentInterval() uint64 {
 		return 0
 	}
 	elapsed := now - c.GenesisTime
-	return (elapsed % types.SecondsPerSlot) / types.SecondsPerInterval
+	return (elapsed % c.Preset.SecondsPerSlot) / c.Preset.SecondsPerInterval()
 }
 
 // CurrentTime returns the current unix time in seconds.
@@ -48,5 +50,5 @@ func (c *Clock) CurrentTime() uint64 {
 
 // SlotTicker returns a channel that fires at the start of each interval.
 func (c *Clock) SlotTicker() *time.Ticker {
-	return time.NewTicker(types.SecondsPerInterval * time.Second)
+	return time.NewTicker(time.Duration(c.Preset.SecondsPerInterval()) * time.Second)
 }
@@ -0,0 +1,47 @@
+package node_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/geanlabs/gean/chain/forkchoice"
+	"github.com/geanlabs/gean/chain/statetransition"
+	"github.com/geanlabs/gean/internal/testutil"
+	"github.com/geanlabs/gean/observability/metrics"
+	"github.com/geanlabs/gean/storage/memory"
+	"github.com/geanlabs/gean/types"
+)
+
+func TestCheckpointMissingFromStorageIsReported(t *testing.T) {
+	state := statetransition.GenerateGenesis(1000, testutil.Validators(3))
+	genesis := &types.Block{Body: &types.BlockBody{Attestations: []*types.Attestation{}}}
+	genesis.StateRoot, _ = state.HashTreeRoot()
+	genesisRoot, _ := genesis.HashTreeRoot()
+	db := memory.New()
+	fc := forkchoice.NewStore(state, genesis, db)
+	fc.AdvanceTime(1000+3*types.SecondsPerSlot, false)
+	justified := metrics.CheckpointViolations.WithLabelValues("justified")
+	before := testutil.CounterValue(t, justified)
+
+	root := testutil.ImportEmptyBlock(t, fc, 1, genesisRoot)
+	if got := testutil.CounterValue(t, justified); got != before {
+		t.Fatalf("consistent chain counted %v violations", got-before)
+	}
+
+	// Losing the justified block from storage is caught on the next import.
+	db.DeleteBlocks([][32]byte{genesisRoot})
+	root = testutil.ImportEmptyBlock(t, fc, 2, root)
+	if got := testutil.CounterValue(t, justified); got != before+1 {
+		t.Fatalf("violations = %v, want %v", got, before+1)
+	}
+
+	fc.StrictCheckpoints = true
+	defer func() {
+		r := recover()
+		if msg, _ := r.(string); !strings.Contains(msg, "justified checkpoint") {
+			t.Fatalf("strict mode recovered %v, want a justified checkpoint panic", r)
+		}
+	}()
+	testutil.ImportEmptyBlock(t, fc, 3, root)
+	t.Fatal("strict mode did not halt")
+}
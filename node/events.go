@@ -0,0 +1,33 @@
+package node
+
+import (
+	"context"
+
+	"github.com/geanlabs/gean/observability/logging"
+	"github.com/geanlabs/gean/observability/metrics"
+)
+
+// runHeadEvents keeps the head and checkpoint gauges current between slot
+// ticks and logs each change.
+func (n *Node) runHeadEvents(ctx context.Context) {
+	events := n.FC.SubscribeHead()
+	defer n.FC.UnsubscribeHead(events)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev := <-events:
+			metrics.HeadSlot.Set(float64(ev.HeadSlot))
+			metrics.LatestJustifiedSlot.Set(float64(ev.Justified.Slot))
+			metrics.LatestFinalizedSlot.Set(float64(ev.Finalized.Slot))
+			n.log.Debug("head updated",
+				"head", logging.ShortHash(ev.NewHead),
+				"head_slot", ev.HeadSlot,
+				"reorg_depth", ev.ReorgDepth,
+				"reorg_distance", ev.ReorgDistance,
+				"justified", ev.Justified.Slot,
+				"finalized", ev.Finalized.Slot,
+			)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package node
+
+import (
+	"context"
+
+	"github.com/geanlabs/gean/network/peers"
+	"github.com/geanlabs/gean/observability/logging"
+)
+
+// maxFetchDepth bounds how many unknown ancestors are fetched when a missing
+// block's parent is also unknown.
+const maxFetchDepth = 8
+
+// requestMissingBlock asynchronously fetches a block referenced by a deferred
+// attestation. Concurrent requests for the same root are coalesced. It is
+// installed as forkchoice.Store.OnMissingBlock and must not block.
+func (n *Node) requestMissingBlock(root [32]byte) {
+	n.fetchMu.Lock()
+	if n.fetching[root] {
+		n.fetchMu.Unlock()
+		return
+	}
+	n.fetching[root] = true
+	n.fetchMu.Unlock()
+
+	go func() {
+		defer func() {
+			n.fetchMu.Lock()
+			delete(n.fetching, root)
+			n.fetchMu.Unlock()
+		}()
+		n.fetchBlock(n.Host.Ctx, root, maxFetchDepth)
+	}()
+}
+
+// fetchBlock requests root from connected peers, fetching unknown ancestors
+// first, and imports it into fork choice. Importing replays any attestations
+// that were waiting for the block.
+func (n *Node) fetchBlock(ctx context.Context, root [32]byte, depth int) bool {
+	if _, ok := n.FC.GetBlock(root); ok {
+		return true
+	}
+	for _, pid := range n.Peers.RankPeers(n.Host.P2P.Network().Peers()) {
+		blocks, err := n.requestBlocksByRoot(ctx, pid, [][32]byte{root})
+		if err != nil || len(blocks) == 0 {
+			continue
+		}
+		if err := validateBlocksByRootResponse([][32]byte{root}, blocks); err != nil {
+			n.penalizePeer(pid, peers.PenaltyUnrequestedBlock, err.Error())
+			continue
+		}
+
+		sb := blocks[0]
+		if sb.Message.Block.Slot <= n.FC.Anchor().Slot {
+			// A block competing with the anchor has no ancestry worth fetching.
+			n.log.Debug("fetched block conflicts with anchor",
+				"block_root", logging.ShortHash(root),
+				"slot", sb.Message.Block.Slot,
+			)
+			return false
+		}
+		parent := sb.Message.Block.ParentRoot
+		if _, ok := n.FC.GetBlock(parent); !ok {
+			if depth == 0 || !n.fetchBlock(ctx, parent, depth-1) {
+				return false
+			}
+		}
+		timings, err := n.FC.ProcessBlockTimed(sb)
+		if err != nil {
+			n.log.Debug("fetched block rejected", "block_root", logging.ShortHash(root), "err", err)
+			return false
+		}
+		n.log.Info("imported missing block",
+			append([]any{"slot", sb.Message.Block.Slot, "block_root", logging.ShortHash(root)},
+				importTimingAttrs(sb, timings)...)...,
+		)
+		return true
+	}
+	return false
+}
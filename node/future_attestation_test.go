@@ -0,0 +1,27 @@
+package node_test
+
+import (
+	"testing"
+
+	"github.com/geanlabs/gean/types"
+)
+
+func TestNextSlotAttestationIsAppliedWhenSlotStarts(t *testing.T) {
+	fc, _, genesisRoot := newAnchoredStore(t)
+	fc.AdvanceTime(1000+types.SecondsPerSlot, false)
+
+	genesis := &types.Checkpoint{Root: genesisRoot, Slot: 0}
+	fc.ProcessAttestation(&types.SignedAttestation{
+		ValidatorID: 1,
+		Message:     &types.AttestationData{Slot: 2, Head: genesis, Target: genesis, Source: genesis},
+	})
+	if _, ok := fc.GetNewAttestation(1); ok {
+		t.Fatal("attestation for slot 2 applied during slot 1")
+	}
+
+	fc.AdvanceTime(1000+2*types.SecondsPerSlot, false)
+	sa, ok := fc.GetNewAttestation(1)
+	if !ok || sa.Message.Slot != 2 {
+		t.Fatalf("attestation not applied at slot 2: %+v, %v", sa, ok)
+	}
+}
@@ -2,11 +2,13 @@ package node
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/geanlabs/gean/chain/forkchoice"
 	"github.com/geanlabs/gean/network/gossipsub"
 	"github.com/geanlabs/gean/network/reqresp"
 	"github.com/geanlabs/gean/observability/logging"
+	"github.com/geanlabs/gean/storage/wal"
 	"github.com/geanlabs/gean/types"
 )
 
@@ -32,33 +34,32 @@ func registerHandlers(n *Node, fc *forkchoice.Store) error {
 			}
 			return blocks
 		},
+		OnFinalityProof: n.finalityProof,
+		OnGoodbye:       n.onGoodbye,
+		Limiter:         reqresp.NewRateLimiter(reqresp.DefaultRateLimits),
+		OnRateLimited:   n.onRateLimited,
 	})
 
 	// Subscribe to gossip.
 	if err := gossipsub.SubscribeTopics(n.Host.Ctx, n.Topics, &gossipsub.GossipHandler{
-		OnBlock: func(sb *types.SignedBlockWithAttestation) {
-			block := sb.Message.Block
-			blockRoot, _ := block.HashTreeRoot()
-			gossipLog.Info("received block via gossip",
-				"slot", block.Slot,
-				"proposer", block.ProposerIndex,
-				"block_root", logging.ShortHash(blockRoot),
-			)
-			if err := fc.ProcessBlock(sb); err != nil {
-				gossipLog.Warn("rejected gossip block",
-					"slot", block.Slot,
-					"err", err,
-				)
-			}
+		FilterBlock: n.filterGossipBlock,
+		Seen:        n.seen,
+		OnBlock: func(sb *types.SignedBlockWithAttestation, decode time.Duration) {
+			n.logGossip(wal.KindBlock, sb)
+			n.admitBlock(sb, decode)
 		},
 		OnAttestation: func(sa *types.SignedAttestation) {
-			fc.ProcessAttestation(sa)
+			n.logGossip(wal.KindAttestation, sa)
+			if n.admitAttestation(sa) {
+				fc.ProcessAttestation(sa)
+			}
 		},
 		OnAggregatedAttestation: func(agg *types.AggregatedAttestation) {
 			gossipLog.Debug("received aggregated attestation via gossip",
 				"slot", agg.Data.Slot,
 				"num_sigs", len(agg.AggregatedSignature)/types.XMSSSignatureSize,
 			)
+			n.logGossip(wal.KindAggregate, agg)
 			fc.ProcessAggregatedAttestation(agg)
 		},
 	}); err != nil {
@@ -67,3 +68,64 @@ func registerHandlers(n *Node, fc *forkchoice.Store) error {
 
 	return nil
 }
+
+// filterGossipBlock pre-validates a gossip block from its header fields so
+// blocks that can never be imported are dropped before a full decode.
+func (n *Node) filterGossipBlock(h types.BlockHeaderFields) bool {
+	status := n.FC.GetStatus()
+	reason := ""
+	switch {
+	case h.Slot <= status.FinalizedSlot:
+		reason = "at or before finalized slot"
+	case h.Slot > n.Clock.CurrentSlot()+1:
+		reason = "from a future slot"
+	case !n.FC.IsProposer(h.ProposerIndex, h.Slot):
+		reason = "unexpected proposer"
+	}
+	if reason != "" {
+		n.log.Debug("dropping gossip block before decode",
+			"slot", h.Slot,
+			"proposer", h.ProposerIndex,
+			"reason", reason,
+		)
+		return false
+	}
+	return true
+}
+
+// finalityProof links trusted to the current head through block headers and
+// attaches the head state, whose finalized checkpoint the proof establishes.
+func (n *Node) finalityProof(trusted types.Checkpoint) (*reqresp.FinalityProof, error) {
+	head := n.FC.GetStatus().Head
+	state, ok := n.FC.GetState(head)
+	if !ok {
+		return nil, fmt.Errorf("head state %x not found", head)
+	}
+
+	var headers []*types.BlockHeader
+	for root := head; root != trusted.Root; {
+		block, ok := n.FC.GetBlock(root)
+		if !ok || block.Slot <= trusted.Slot || len(headers) == reqresp.MaxFinalityProofHeaders {
+			return nil, reqresp.ErrNoFinalityProof
+		}
+		bodyRoot, err := block.Body.HashTreeRoot()
+		if err != nil {
+			return nil, err
+		}
+		headers = append(headers, &types.BlockHeader{
+			Slot:          block.Slot,
+			ProposerIndex: block.ProposerIndex,
+			ParentRoot:    block.ParentRoot,
+			StateRoot:     block.StateRoot,
+			BodyRoot:      bodyRoot,
+		})
+		root = block.ParentRoot
+	}
+	if len(headers) == 0 {
+		return nil, reqresp.ErrNoFinalityProof // trusted is the head
+	}
+	for i, j := 0, len(headers)-1; i < j; i, j = i+1, j-1 {
+		headers[i], headers[j] = headers[j], headers[i]
+	}
+	return &reqresp.FinalityProof{Headers: headers, State: state}, nil
+}
@@ -0,0 +1,114 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/geanlabs/gean/network/peers"
+	"github.com/geanlabs/gean/network/reqresp"
+	"github.com/geanlabs/gean/observability/metrics"
+	"github.com/geanlabs/gean/types"
+)
+
+var (
+	// errNoStatusProtocol is returned for an identified peer that speaks no
+	// status protocol version we do, so it is not a lean consensus peer.
+	errNoStatusProtocol = errors.New("peer lacks a supported status protocol")
+	// errIrreconcilable is returned for a peer whose finalized checkpoint
+	// conflicts with our chain: another fork or another devnet.
+	errIrreconcilable = errors.New("peer chain irreconcilable with ours")
+)
+
+// watchHandshakes exchanges status with every newly connected peer.
+func (n *Node) watchHandshakes() {
+	n.Host.P2P.Network().Notify(&network.NotifyBundle{
+		ConnectedF: func(net network.Network, c network.Conn) {
+			// Only the first connection to a peer starts a handshake.
+			if len(net.ConnsToPeer(c.RemotePeer())) > 1 {
+				return
+			}
+			go n.handshake(n.Host.Ctx, c.RemotePeer())
+		},
+	})
+}
+
+// handshake exchanges status with a newly connected peer. A peer on another
+// chain or network is disconnected; one that fails to answer is penalized.
+func (n *Node) handshake(ctx context.Context, pid peer.ID) {
+	_, err := n.exchangeStatus(ctx, pid)
+	switch {
+	case err == nil:
+		metrics.PeerHandshakes.WithLabelValues("ok").Inc()
+	case errors.Is(err, errNoStatusProtocol), errors.Is(err, errIrreconcilable):
+		metrics.PeerHandshakes.WithLabelValues("irrelevant").Inc()
+		n.log.Info("disconnecting peer on another chain", "peer", pid.String()[:16], "err", err)
+		n.disconnectPeer(pid, reqresp.GoodbyeIrrelevantNetwork)
+	case ctx.Err() != nil:
+	default:
+		metrics.PeerHandshakes.WithLabelValues("failed").Inc()
+		n.penalizePeer(pid, peers.PenaltyRequestFailure, "status handshake failed")
+	}
+}
+
+// exchangeStatus sends our status to pid, records its reply with the peer
+// manager and checks that its finalized checkpoint can be on our chain.
+func (n *Node) exchangeStatus(ctx context.Context, pid peer.ID) (*reqresp.Status, error) {
+	status := n.FC.GetStatus()
+	ourStatus := reqresp.Status{
+		Finalized: &types.Checkpoint{Root: status.FinalizedRoot, Slot: status.FinalizedSlot},
+		Head:      &types.Checkpoint{Root: status.Head, Slot: status.HeadSlot},
+	}
+
+	statusProtos := n.Peers.SelectProtocols(pid, reqresp.StatusProtocols)
+	if len(statusProtos) == 0 {
+		return nil, errNoStatusProtocol
+	}
+
+	reqStart := time.Now()
+	peerStatus, err := reqresp.RequestStatus(ctx, n.Host.P2P, pid, ourStatus, statusProtos...)
+	n.Peers.RecordRequest(pid, time.Since(reqStart), err == nil)
+	if err != nil {
+		return nil, err
+	}
+	n.Peers.SetChainStatus(pid, *peerStatus.Finalized, *peerStatus.Head)
+	if err := n.checkFinalized(*peerStatus.Finalized); err != nil {
+		return nil, err
+	}
+	return peerStatus, nil
+}
+
+// checkFinalized reports errIrreconcilable if a peer's finalized checkpoint
+// cannot be on our chain: it differs from our block at a slot we have
+// finalized, or it is a block we know that does not descend from our
+// finalized checkpoint. Checkpoints we cannot place, ahead of us or before
+// our anchor, pass.
+func (n *Node) checkFinalized(cp types.Checkpoint) error {
+	status := n.FC.GetStatus()
+	if cp.Slot <= status.FinalizedSlot {
+		if root, ok := n.FC.CanonicalRoot(cp.Slot); ok && root != cp.Root {
+			return fmt.Errorf("%w: finalized %x at slot %d, ours is %x", errIrreconcilable, cp.Root[:4], cp.Slot, root[:4])
+		}
+		return nil
+	}
+
+	root := cp.Root
+	for {
+		block, ok := n.FC.GetBlock(root)
+		if !ok {
+			return nil
+		}
+		if block.Slot <= status.FinalizedSlot {
+			if root != status.FinalizedRoot {
+				return fmt.Errorf("%w: finalized %x at slot %d does not descend from ours at slot %d",
+					errIrreconcilable, cp.Root[:4], cp.Slot, status.FinalizedSlot)
+			}
+			return nil
+		}
+		root = block.ParentRoot
+	}
+}
@@ -0,0 +1,33 @@
+package node
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/geanlabs/gean/chain/forkchoice"
+	"github.com/geanlabs/gean/chain/statetransition"
+	"github.com/geanlabs/gean/storage/memory"
+	"github.com/geanlabs/gean/types"
+)
+
+func TestCheckFinalized(t *testing.T) {
+	state := statetransition.GenerateGenesis(1000, []*types.Validator{{Index: 0}, {Index: 1}})
+	genesis := &types.Block{Body: &types.BlockBody{Attestations: []*types.Attestation{}}}
+	genesis.StateRoot, _ = state.HashTreeRoot()
+	genesisRoot, _ := genesis.HashTreeRoot()
+	n := &Node{FC: forkchoice.NewStore(state, genesis, memory.New())}
+
+	for _, tc := range []struct {
+		name string
+		cp   types.Checkpoint
+		want error
+	}{
+		{"same genesis", types.Checkpoint{Root: genesisRoot}, nil},
+		{"other genesis", types.Checkpoint{Root: [32]byte{1}}, errIrreconcilable},
+		{"ahead of us", types.Checkpoint{Root: [32]byte{2}, Slot: 8}, nil},
+	} {
+		if err := n.checkFinalized(tc.cp); !errors.Is(err, tc.want) {
+			t.Errorf("%s: err = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+//go:build integration
+
+package node_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/geanlabs/gean/chain/forkchoice"
+	"github.com/geanlabs/gean/chain/statetransition"
+	"github.com/geanlabs/gean/network"
+	"github.com/geanlabs/gean/network/gossipsub"
+	"github.com/geanlabs/gean/node"
+	"github.com/geanlabs/gean/observability/logging"
+	"github.com/geanlabs/gean/storage/memory"
+	"github.com/geanlabs/gean/types"
+	"github.com/geanlabs/gean/xmss/leansig"
+)
+
+// testNode is a minimal in-process node: a real libp2p host with gossipsub,
+// a fork choice store and validator duties for a single validator.
+type testNode struct {
+	host   *network.Host
+	fc     *forkchoice.Store
+	duties *node.ValidatorDuties
+}
+
+// TestTwoNodesExchangeBlockAndAttestation runs the real gossipsub + SSZ +
+// snappy path: node A proposes, node B imports the block and attests back.
+// Run with: make integration-test
+func TestTwoNodesExchangeBlockAndAttestation(t *testing.T) {
+	const numValidators = 2
+
+	keys := make([]*leansig.Keypair, numValidators)
+	validators := make([]*types.Validator, numValidators)
+	for i := range keys {
+		kp, err := leansig.GenerateKeypair(uint64(i), 0, 256)
+		if err != nil {
+			t.Fatalf("generate keypair %d: %v", i, err)
+		}
+		defer kp.Free()
+		pk, err := kp.PublicKeyBytes()
+		if err != nil {
+			t.Fatalf("public key %d: %v", i, err)
+		}
+		keys[i] = kp
+		validators[i] = &types.Validator{Index: uint64(i)}
+		copy(validators[i].Pubkey[:], pk)
+	}
+
+	// Genesis one slot in the past so slot 1 is the current slot.
+	genesisTime := uint64(time.Now().Unix()) - types.SecondsPerSlot - 1
+
+	// Slot 1 proposer is validator 1 (round-robin); node B attests as validator 0.
+	a := newTestNode(t, genesisTime, validators, 1, keys[1])
+	b := newTestNode(t, genesisTime, validators, 0, keys[0])
+
+	if err := a.host.P2P.Connect(context.Background(), peer.AddrInfo{
+		ID:    b.host.P2P.ID(),
+		Addrs: b.host.P2P.Addrs(),
+	}); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	waitFor(t, "gossip mesh", func() bool {
+		return len(a.duties.Topics.Block.ListPeers()) > 0 &&
+			len(a.duties.Topics.Attestation.ListPeers()) > 0 &&
+			len(b.duties.Topics.Block.ListPeers()) > 0 &&
+			len(b.duties.Topics.Attestation.ListPeers()) > 0
+	})
+	// Meshes are grafted on the gossipsub heartbeat; give it a couple of rounds.
+	time.Sleep(2 * time.Second)
+
+	var proposed [32]byte
+	publish := a.duties.PublishBlock
+	a.duties.PublishBlock = func(ctx context.Context, topic *pubsub.Topic, sb *types.SignedBlockWithAttestation) error {
+		proposed, _ = sb.Message.Block.HashTreeRoot()
+		return publish(ctx, topic, sb)
+	}
+
+	ctx := context.Background()
+	a.duties.TryPropose(ctx, 1)
+	if proposed == types.ZeroHash {
+		t.Fatal("node A did not publish a block")
+	}
+
+	waitFor(t, "block import on node B", func() bool {
+		return b.fc.GetStatus().Head == proposed
+	})
+
+	b.duties.TryAttest(ctx, 1)
+	waitFor(t, "attestation import on node A", func() bool {
+		sa, ok := a.fc.GetNewAttestation(0)
+		return ok && sa.Message.Head.Root == proposed
+	})
+}
+
+func newTestNode(t *testing.T, genesisTime uint64, validators []*types.Validator, index uint64, kp *leansig.Keypair) *testNode {
+	t.Helper()
+
+	state := statetransition.GenerateGenesis(genesisTime, validators)
+	genesisBlock := &types.Block{
+		Body: &types.BlockBody{Attestations: []*types.Attestation{}},
+	}
+	genesisBlock.StateRoot, _ = state.HashTreeRoot()
+	fc := forkchoice.NewStore(state, genesisBlock, memory.New())
+	fc.NowFn = func() uint64 { return uint64(time.Now().Unix()) }
+
+	host, err := network.NewHost("/ip4/127.0.0.1/udp/0/quic-v1", "", nil, types.DefaultPreset.SlotDuration())
+	if err != nil {
+		t.Fatalf("new host: %v", err)
+	}
+	t.Cleanup(func() { host.Close() })
+
+	topics, err := gossipsub.JoinTopics(host.PubSub, "devnet0", len(state.Validators), types.DefaultPreset.SlotDuration())
+	if err != nil {
+		t.Fatalf("join topics: %v", err)
+	}
+	if err := gossipsub.SubscribeTopics(host.Ctx, topics, &gossipsub.GossipHandler{
+		OnBlock: func(sb *types.SignedBlockWithAttestation, _ time.Duration) {
+			if err := fc.ProcessBlock(sb); err != nil {
+				t.Logf("block rejected: %v", err)
+			}
+		},
+		OnAttestation: func(sa *types.SignedAttestation) {
+			fc.ProcessAttestation(sa)
+		},
+		OnAggregatedAttestation: func(agg *types.AggregatedAttestation) {
+			fc.ProcessAggregatedAttestation(agg)
+		},
+	}); err != nil {
+		t.Fatalf("subscribe topics: %v", err)
+	}
+
+	return &testNode{
+		host: host,
+		fc:   fc,
+		duties: &node.ValidatorDuties{
+			Indices:                      []uint64{index},
+			Keys:                         map[uint64]forkchoice.Signer{index: kp},
+			FC:                           fc,
+			Topics:                       topics,
+			PublishBlock:                 gossipsub.PublishBlock,
+			PublishAttestation:           gossipsub.PublishAttestation,
+			PublishAggregatedAttestation: gossipsub.PublishAggregatedAttestation,
+			Log:                          logging.NewComponentLogger(logging.CompValidator),
+		},
+	}
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %s", what)
+}
@@ -0,0 +1,150 @@
+package node
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/geanlabs/gean/chain/forkchoice"
+	"github.com/geanlabs/gean/observability/metrics"
+	"github.com/geanlabs/gean/xmss/leansig"
+)
+
+// validators returns a copy of the local validator indices.
+func (v *ValidatorDuties) validators() []uint64 {
+	v.keysMu.RLock()
+	defer v.keysMu.RUnlock()
+	return append([]uint64(nil), v.Indices...)
+}
+
+// key returns the key of local validator idx.
+func (v *ValidatorDuties) key(idx uint64) (forkchoice.Signer, bool) {
+	v.keysMu.RLock()
+	defer v.keysMu.RUnlock()
+	key, ok := v.Keys[idx]
+	return key, ok
+}
+
+// AddKey makes idx a local validator signing with key, starting with the
+// next duty. It reports false if idx already has a key.
+func (v *ValidatorDuties) AddKey(idx uint64, key forkchoice.Signer) bool {
+	v.keysMu.Lock()
+	defer v.keysMu.Unlock()
+	if _, ok := v.Keys[idx]; ok {
+		return false
+	}
+	if v.Keys == nil {
+		v.Keys = make(map[uint64]forkchoice.Signer)
+	}
+	v.Keys[idx] = key
+	v.Indices = append(v.Indices, idx)
+	sort.Slice(v.Indices, func(i, j int) bool { return v.Indices[i] < v.Indices[j] })
+	metrics.ValidatorsCount.Set(float64(len(v.Indices)))
+	v.Log.Info("validator key added", "validator", idx)
+	return true
+}
+
+// RemoveKey stops idx's duties and drops its key, after the signatures
+// already queued for it. It reports false if idx has no key.
+func (v *ValidatorDuties) RemoveKey(idx uint64) bool {
+	v.keysMu.Lock()
+	key, ok := v.Keys[idx]
+	if ok {
+		delete(v.Keys, idx)
+		for i, local := range v.Indices {
+			if local == idx {
+				v.Indices = append(v.Indices[:i:i], v.Indices[i+1:]...)
+				break
+			}
+		}
+		metrics.ValidatorsCount.Set(float64(len(v.Indices)))
+	}
+	v.keysMu.Unlock()
+	if !ok {
+		return false
+	}
+
+	// Release the key once its queued signatures are done; a closed signing
+	// service leaves it to the finalizer.
+	if kp, ok := key.(interface{ Free() }); ok {
+		_ = v.exclusive(idx, func() error {
+			kp.Free()
+			return nil
+		})
+	}
+	v.Log.Info("validator key removed", "validator", idx)
+	return true
+}
+
+// ValidatorKeys returns the public key of each local validator: the key's
+// own public key where it exposes one, otherwise the registered one.
+func (v *ValidatorDuties) ValidatorKeys() map[uint64][]byte {
+	v.keysMu.RLock()
+	keys := make(map[uint64]forkchoice.Signer, len(v.Keys))
+	for idx, key := range v.Keys {
+		keys[idx] = key
+	}
+	v.keysMu.RUnlock()
+
+	out := make(map[uint64][]byte, len(keys))
+	for idx, key := range keys {
+		if kp, ok := key.(interface{ PublicKeyBytes() ([]byte, error) }); ok {
+			if pub, err := kp.PublicKeyBytes(); err == nil {
+				out[idx] = pub
+				continue
+			}
+		}
+		if pub, ok := v.registeredPubkey(idx); ok {
+			out[idx] = pub
+		}
+	}
+	return out
+}
+
+// ImportKeystore restores the serialized keypair of validator idx and adds
+// it with AddKey. The public key must be the one registered for idx. It
+// reports false if idx already has a key.
+func (v *ValidatorDuties) ImportKeystore(idx uint64, pubkey, seckey []byte) (bool, error) {
+	registered, ok := v.registeredPubkey(idx)
+	if !ok {
+		return false, fmt.Errorf("validator %d not in registry", idx)
+	}
+	if !bytes.Equal(pubkey, registered) {
+		return false, fmt.Errorf("public key does not match validator %d", idx)
+	}
+	if _, ok := v.key(idx); ok {
+		return false, nil
+	}
+	kp, err := leansig.RestoreKeypair(pubkey, seckey)
+	if err != nil {
+		return false, fmt.Errorf("restore keypair: %w", err)
+	}
+	if !v.AddKey(idx, kp) {
+		kp.Free()
+		return false, nil
+	}
+	return true, nil
+}
+
+// registeredPubkey returns idx's public key in the head state.
+func (v *ValidatorDuties) registeredPubkey(idx uint64) ([]byte, bool) {
+	state, ok := v.FC.GetState(v.FC.GetStatus().Head)
+	if !ok || idx >= uint64(len(state.Validators)) {
+		return nil, false
+	}
+	return append([]byte(nil), state.Validators[idx].Pubkey[:]...), true
+}
+
+// freeKeys releases every local validator key. It runs at shutdown, once
+// signing has stopped.
+func (v *ValidatorDuties) freeKeys() {
+	v.keysMu.Lock()
+	defer v.keysMu.Unlock()
+	for idx, key := range v.Keys {
+		if kp, ok := key.(interface{ Free() }); ok {
+			kp.Free()
+		}
+		delete(v.Keys, idx)
+	}
+	v.Indices = nil
+}
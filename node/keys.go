@@ -0,0 +1,355 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/geanlabs/gean/chain/forkchoice"
+	"github.com/geanlabs/gean/observability/metrics"
+	"github.com/geanlabs/gean/xmss/leansig"
+)
+
+// keyHeadroomWarnEpochs is the prepared-window headroom below which a
+// validator key triggers a warning.
+const keyHeadroomWarnEpochs = 128
+
+// preparedWindow is implemented by signers that expose their XMSS
+// preparation window, such as *leansig.Keypair.
+type preparedWindow interface {
+	PreparedEnd() uint64
+}
+
+// UpdateKeyHeadroom publishes the prepared-window headroom of each loaded
+// key at the given slot and warns once when a key falls below
+// keyHeadroomWarnEpochs. Signing epochs equal slots.
+func (v *ValidatorDuties) UpdateKeyHeadroom(slot uint64) {
+	for _, idx := range v.validators() {
+		key, _ := v.key(idx)
+		kp, ok := key.(preparedWindow)
+		if !ok {
+			continue
+		}
+		end := kp.PreparedEnd()
+		var remaining uint64
+		if end > slot {
+			remaining = end - slot
+		}
+
+		label := strconv.FormatUint(idx, 10)
+		metrics.ValidatorKeyPreparedEndEpoch.WithLabelValues(label).Set(float64(end))
+		metrics.ValidatorKeyEpochsRemaining.WithLabelValues(label).Set(float64(remaining))
+
+		if remaining >= keyHeadroomWarnEpochs {
+			delete(v.headroomWarned, idx)
+			continue
+		}
+		if v.headroomWarned[idx] {
+			continue
+		}
+		if v.headroomWarned == nil {
+			v.headroomWarned = make(map[uint64]bool)
+		}
+		v.headroomWarned[idx] = true
+		v.Log.Warn("validator key prepared window running low",
+			"validator", idx,
+			"prepared_end", end,
+			"epochs_remaining", remaining,
+		)
+	}
+}
+
+// preparableKey is implemented by signers whose XMSS preparation window can
+// be moved forward, such as *leansig.Keypair.
+type preparableKey interface {
+	preparedWindow
+	PreparedStart() uint64
+	AdvancePreparation() error
+}
+
+// WarmupKeys readies each loaded key for signing at epoch so the first real
+// proposal or attestation does not pay the preparation cost. The window is
+// advanced until it covers epoch, then a throwaway signature is produced.
+// XMSS keys are one-time per epoch, so the throwaway signature uses the last
+// epoch before epoch and is skipped when that falls outside the window.
+func (v *ValidatorDuties) WarmupKeys(epoch uint64) {
+	for _, idx := range v.validators() {
+		key, ok := v.key(idx)
+		if !ok {
+			continue
+		}
+		start := time.Now()
+
+		var advanced int
+		windowStart := uint64(0)
+		if kp, ok := key.(preparableKey); ok {
+			for kp.PreparedEnd() <= epoch {
+				end := kp.PreparedEnd()
+				if err := kp.AdvancePreparation(); err != nil || kp.PreparedEnd() == end {
+					v.Log.Warn("validator key cannot be prepared for current epoch",
+						"validator", idx,
+						"epoch", epoch,
+						"prepared_end", end,
+						"err", err,
+					)
+					break
+				}
+				advanced++
+			}
+			windowStart = kp.PreparedStart()
+		}
+
+		signed := epoch > windowStart
+		if signed {
+			if _, err := key.Sign(uint32(epoch-1), [32]byte{}); err != nil {
+				v.Log.Warn("validator key warmup signature failed", "validator", idx, "err", err)
+				continue
+			}
+		}
+		v.Log.Info("validator key warmed up",
+			"validator", idx,
+			"epoch", epoch,
+			"windows_advanced", advanced,
+			"warmup_signature", signed,
+			"duration", time.Since(start),
+		)
+	}
+}
+
+// keyPrepareMargin is how many slots past the middle of its prepared window
+// a key is advanced. Advancing moves the window start to its middle, so the
+// margin keeps the previous slot signable for duties still queued for it.
+const keyPrepareMargin = 1
+
+// errKeyExhausted reports a key whose prepared window no longer advances
+// because it reached the end of the key's activation.
+var errKeyExhausted = errors.New("prepared window reached the end of key activation")
+
+// PrepareKeys advances the prepared window of each loaded key that is
+// past the middle of its window at slot, so the window moves ahead of the
+// chain instead of running out. Advancing runs behind the validator's
+// queued signatures, as it changes the key. It reports how many keys were
+// advanced.
+func (v *ValidatorDuties) PrepareKeys(slot uint64) int {
+	var advanced int
+	for _, idx := range v.validators() {
+		key, _ := v.key(idx)
+		kp, ok := key.(preparableKey)
+		if !ok {
+			continue
+		}
+
+		var from, to uint64
+		err := v.exclusive(idx, func() error {
+			start, end := kp.PreparedStart(), kp.PreparedEnd()
+			if slot < start+(end-start)/2+keyPrepareMargin {
+				return nil
+			}
+			from = end
+			if err := kp.AdvancePreparation(); err != nil {
+				return err
+			}
+			if to = kp.PreparedEnd(); to == end {
+				return errKeyExhausted
+			}
+			return nil
+		})
+
+		label := strconv.FormatUint(idx, 10)
+		switch {
+		case errors.Is(err, ErrSigningClosed):
+			return advanced
+		case errors.Is(err, errKeyExhausted):
+			if v.keysExhausted[idx] {
+				continue
+			}
+			if v.keysExhausted == nil {
+				v.keysExhausted = make(map[uint64]bool)
+			}
+			v.keysExhausted[idx] = true
+			metrics.ValidatorKeyPreparations.WithLabelValues(label, "exhausted").Inc()
+			v.Log.Warn("validator key prepared window cannot advance further",
+				"validator", idx,
+				"slot", slot,
+				"prepared_end", from,
+			)
+		case err != nil:
+			metrics.ValidatorKeyPreparations.WithLabelValues(label, "failed").Inc()
+			v.Log.Warn("validator key preparation failed",
+				"validator", idx,
+				"slot", slot,
+				"err", err,
+			)
+		case to != 0:
+			advanced++
+			metrics.ValidatorKeyPreparations.WithLabelValues(label, "advanced").Inc()
+			v.Log.Info("validator key prepared window advanced",
+				"validator", idx,
+				"slot", slot,
+				"prepared_end", to,
+			)
+		}
+	}
+	return advanced
+}
+
+// runKeyPreparation calls PrepareKeys once per slot until ctx is cancelled.
+func (n *Node) runKeyPreparation(ctx context.Context) {
+	ticker := time.NewTicker(n.Clock.Preset.SlotDuration())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !n.Clock.IsBeforeGenesis() {
+				n.Validator.PrepareKeys(n.Clock.CurrentSlot())
+			}
+		}
+	}
+}
+
+// ActivationKey is a validator key with a bounded activation and a
+// preparation window, such as *leansig.Keypair.
+type ActivationKey interface {
+	forkchoice.Signer
+	preparableKey
+	ActivationStart() uint64
+	ActivationEnd() uint64
+}
+
+// Errors of validator key sets.
+var (
+	// ErrNoKeyForSlot is returned when none of a validator's keys is active
+	// at the signing slot.
+	ErrNoKeyForSlot = errors.New("no validator key active at slot")
+	// ErrOverlappingKeys is returned for a key set in which two keys are
+	// active at the same slot.
+	ErrOverlappingKeys = errors.New("validator key activations overlap")
+)
+
+// KeySet is a validator's current key and its pending replacements. It signs
+// with the key whose activation covers the signing slot, so the validator
+// moves to a replacement when the current key's active epochs run out. The
+// keys' activations do not overlap. The preparation window it reports and
+// advances is that of the active key, which only moves forward: to a key
+// once it signed, or to the next key once the active key's window cannot
+// advance further.
+//
+// The chain verifies a validator's signatures with its registered public
+// key and has no operation to change it, so every key of a set must have
+// that public key; loadValidatorKeys checks it.
+type KeySet struct {
+	keys []ActivationKey // by activation start
+
+	mu     sync.Mutex
+	active int // key whose preparation window is reported
+}
+
+// NewKeySet returns a KeySet of one or more keys, in any order. It fails
+// with ErrOverlappingKeys if two keys are active at the same slot.
+func NewKeySet(keys ...ActivationKey) (*KeySet, error) {
+	sorted := append([]ActivationKey(nil), keys...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].ActivationStart() < sorted[j].ActivationStart()
+	})
+	for i := 1; i < len(sorted); i++ {
+		prev, key := sorted[i-1], sorted[i]
+		if key.ActivationStart() < prev.ActivationEnd() {
+			return nil, fmt.Errorf("%w: [%d, %d) and [%d, %d)", ErrOverlappingKeys,
+				prev.ActivationStart(), prev.ActivationEnd(), key.ActivationStart(), key.ActivationEnd())
+		}
+	}
+	return &KeySet{keys: sorted}, nil
+}
+
+// KeyFor returns the key that signs at slot.
+func (k *KeySet) KeyFor(slot uint64) (ActivationKey, bool) {
+	if i := k.indexFor(slot); i >= 0 {
+		return k.keys[i], true
+	}
+	return nil, false
+}
+
+func (k *KeySet) indexFor(slot uint64) int {
+	for i, key := range k.keys {
+		if key.ActivationStart() <= slot && slot < key.ActivationEnd() {
+			return i
+		}
+	}
+	return -1
+}
+
+// Sign signs with the key for signingSlot.
+func (k *KeySet) Sign(signingSlot uint32, message [32]byte) ([]byte, error) {
+	i := k.indexFor(uint64(signingSlot))
+	if i < 0 {
+		return nil, fmt.Errorf("%w %d", ErrNoKeyForSlot, signingSlot)
+	}
+	k.mu.Lock()
+	k.active = max(k.active, i)
+	k.mu.Unlock()
+	return k.keys[i].Sign(signingSlot, message)
+}
+
+// PreparedStart returns the prepared window start of the active key.
+func (k *KeySet) PreparedStart() uint64 { return k.activeKey().PreparedStart() }
+
+// PreparedEnd returns the prepared window end of the active key.
+func (k *KeySet) PreparedEnd() uint64 { return k.activeKey().PreparedEnd() }
+
+func (k *KeySet) activeKey() ActivationKey {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.keys[k.active]
+}
+
+// AdvancePreparation advances the active key's window. Once that window
+// cannot advance further, the next key becomes active instead and its
+// window is the one reported from then on; the previous key still signs the
+// slots its window covers.
+func (k *KeySet) AdvancePreparation() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	key := k.keys[k.active]
+	end := key.PreparedEnd()
+	if err := key.AdvancePreparation(); err != nil {
+		return err
+	}
+	if key.PreparedEnd() == end && k.active+1 < len(k.keys) {
+		k.active++
+	}
+	return nil
+}
+
+// Free releases each of the set's keys that holds a handle.
+func (k *KeySet) Free() {
+	for _, key := range k.keys {
+		if kp, ok := key.(interface{ Free() }); ok {
+			kp.Free()
+		}
+	}
+}
+
+// reportLeansigAllocations logs the leansig allocation counts at shutdown
+// and, with allocation tracking on, where each keypair still alive was
+// allocated.
+func reportLeansigAllocations(log *slog.Logger) {
+	stats := leansig.Allocations()
+	if stats.Keypairs == 0 && stats.Buffers == 0 && stats.LeakedKeypairs == 0 {
+		return
+	}
+	log.Warn("leansig allocations outstanding at shutdown",
+		"keypairs", stats.Keypairs,
+		"buffers", stats.Buffers,
+		"leaked_keypairs", stats.LeakedKeypairs,
+	)
+	for _, stack := range leansig.OutstandingKeypairs() {
+		log.Warn("leansig keypair not freed", "allocated_at", stack)
+	}
+}
@@ -1,42 +1,79 @@
 package node
 
 import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/geanlabs/gean/api"
 	"github.com/geanlabs/gean/chain/forkchoice"
 	"github.com/geanlabs/gean/chain/statetransition"
 	"github.com/geanlabs/gean/network"
 	"github.com/geanlabs/gean/network/gossipsub"
 	"github.com/geanlabs/gean/network/p2p"
+	"github.com/geanlabs/gean/network/peers"
 	"github.com/geanlabs/gean/observability/logging"
 	"github.com/geanlabs/gean/observability/metrics"
-	"github.com/geanlabs/gean/storage/memory"
+	"github.com/geanlabs/gean/observability/slothistory"
+	"github.com/geanlabs/gean/storage"
+	"github.com/geanlabs/gean/storage/proposals"
+	"github.com/geanlabs/gean/storage/wal"
 	"github.com/geanlabs/gean/types"
 	"github.com/geanlabs/gean/xmss/leansig"
+	"github.com/geanlabs/gean/xmss/leansig/remote"
 )
 
 // New creates and wires up a new Node.
 func New(cfg Config) (*Node, error) {
 	log := logging.NewComponentLogger(logging.CompNode)
 
-	fc := initGenesis(log, cfg)
+	// The wire types fix the XMSS sizes; a leansig built for another
+	// instantiation could not sign or verify them.
+	if leansig.SignatureSize() != types.XMSSSignatureSize || leansig.PublicKeySize() != types.XMSSPubkeySize {
+		return nil, fmt.Errorf("leansig encodes %d-byte signatures and %d-byte public keys, want %d and %d",
+			leansig.SignatureSize(), leansig.PublicKeySize(), types.XMSSSignatureSize, types.XMSSPubkeySize)
+	}
+
+	db, err := openStorage(cfg)
+	if err != nil {
+		return nil, err
+	}
+	fc, err := initGenesis(log, cfg, db)
+	if err != nil {
+		closeStorage(db)
+		return nil, err
+	}
+	preset := fc.Preset()
+	if interval := time.Duration(preset.SecondsPerInterval()) * time.Second; cfg.PublishJitter < 0 || cfg.PublishJitter >= interval {
+		closeStorage(db)
+		return nil, fmt.Errorf("publish jitter %s must be shorter than an interval (%s)", cfg.PublishJitter, interval)
+	}
 
-	host, topics, err := initP2P(cfg)
+	host, topics, err := initP2P(cfg, preset.SlotDuration())
 	if err != nil {
+		closeStorage(db)
 		return nil, err
 	}
 
 	p2pManager, p2pDiscovery, err2 := initDiscovery(log, cfg)
 	if err2 != nil {
 		host.Close()
+		closeStorage(db)
 		return nil, err2
 	}
 
+	if cfg.DebugLeansigAllocs {
+		leansig.TrackAllocations(true)
+		log.Warn("recording leansig keypair allocation stacks")
+	}
 	validatorKeys, err := loadValidatorKeys(log, cfg)
 	if err != nil {
 		if p2pDiscovery != nil {
@@ -46,6 +83,7 @@ func New(cfg Config) (*Node, error) {
 			p2pManager.Close()
 		}
 		host.Close()
+		closeStorage(db)
 		return nil, err
 	}
 
@@ -58,20 +96,92 @@ func New(cfg Config) (*Node, error) {
 		PublishAttestation:           gossipsub.PublishAttestation,
 		PublishAggregatedAttestation: gossipsub.PublishAggregatedAttestation,
 		Log:                          logging.NewComponentLogger(logging.CompValidator),
+		PublishJitter:                cfg.PublishJitter,
+		Signing:                      NewSigningService(cfg.SigningWorkers),
+	}
+
+	seenPath := filepath.Join(cfg.DataDir, gossipSeenFile)
+	seen, err := gossipsub.LoadSeenCache(seenPath, gossipSeenTTL)
+	if err != nil {
+		log.Warn("ignoring unreadable seen gossip messages", "path", seenPath, "err", err)
+		seen = gossipsub.NewSeenCache(gossipSeenTTL)
+	} else if seen.Len() > 0 {
+		log.Info("restored seen gossip messages", "count", seen.Len())
+	}
+
+	historyPath := filepath.Join(cfg.DataDir, slothistory.FileName)
+	history, err := slothistory.Open(historyPath, slothistory.DefaultCapacity)
+	if err != nil {
+		log.Warn("slot history disabled", "path", historyPath, "err", err)
+		history = nil
+	}
+
+	walPath := filepath.Join(cfg.DataDir, gossipWALFile)
+	gossipWAL, err := wal.Open(walPath)
+	if err != nil {
+		log.Warn("gossip log disabled", "path", walPath, "err", err)
+		gossipWAL = nil
+	}
+
+	// Without the record a restart could sign a second block for a duty
+	// whose block was only held in memory storage, so it is not optional.
+	proposalsPath := filepath.Join(cfg.DataDir, proposals.FileName)
+	proposalLog, err := proposals.Open(proposalsPath)
+	if err != nil {
+		validator.Signing.Close()
+		if p2pDiscovery != nil {
+			p2pDiscovery.Close()
+		}
+		if p2pManager != nil {
+			p2pManager.Close()
+		}
+		host.Close()
+		closeStorage(db)
+		return nil, fmt.Errorf("open produced block record %s: %w", proposalsPath, err)
 	}
+	fc.Proposals = proposalLog
 
 	n := &Node{
 		FC:           fc,
 		Host:         host,
 		Topics:       topics,
-		Clock:        NewClock(cfg.GenesisTime),
+		Clock:        NewClock(cfg.GenesisTime, preset),
 		Validator:    validator,
 		P2PManager:   p2pManager,
 		P2PDiscovery: p2pDiscovery,
+		Peers:        peers.NewManager(),
 		log:          log,
+		gossipLog:    logging.NewComponentLogger(logging.CompGossip),
+		fetching:     make(map[[32]byte]bool),
+		blockBacklog: make(chan gossipBlock, cacheSizesFor(cfg.MaxMemory).BlockBacklog),
+		seen:         seen,
+		seenPath:     seenPath,
+		slotHistory:  history,
+		wal:          gossipWAL,
+		proposalLog:  proposalLog,
+		db:           db,
+		fcPath:       forkChoicePath(cfg),
+		maxMemory:    cfg.MaxMemory,
+	}
+	n.applyMemoryLimit(cfg.MaxMemory)
+	n.Peers.SetLimits(peers.LimitsFor(cfg.MaxPeers))
+	fc.OnMissingBlock = n.requestMissingBlock
+
+	if err := n.Peers.Watch(host.Ctx, host.P2P); err != nil {
+		validator.Signing.Close()
+		if p2pDiscovery != nil {
+			p2pDiscovery.Close()
+		}
+		if p2pManager != nil {
+			p2pManager.Close()
+		}
+		host.Close()
+		closeStorage(db)
+		return nil, err
 	}
 
 	if err := registerHandlers(n, fc); err != nil {
+		validator.Signing.Close()
 		if p2pDiscovery != nil {
 			p2pDiscovery.Close()
 		}
@@ -79,20 +189,91 @@ func New(cfg Config) (*Node, error) {
 			p2pManager.Close()
 		}
 		host.Close()
+		closeStorage(db)
 		return nil, err
 	}
 
+	n.watchHandshakes()
+
 	if len(cfg.Bootnodes) > 0 {
 		network.ConnectBootnodes(host.Ctx, host.P2P, cfg.Bootnodes)
 	}
 
 	startMetrics(log, cfg)
+	if n.API, err = startAPI(fc, validator, n.Peers, cfg); err != nil {
+		n.Close()
+		return nil, err
+	}
+	logEffectiveConfig(n, cfg)
 
 	return n, nil
 }
 
-func initGenesis(log *slog.Logger, cfg Config) *forkchoice.Store {
-	genesisState := statetransition.GenerateGenesis(cfg.GenesisTime, cfg.Validators)
+// logEffectiveConfig logs one structured record with the configuration the
+// node is actually running with, for inclusion in bug reports.
+func logEffectiveConfig(n *Node, cfg Config) {
+	devnetID := cfg.DevnetID
+	if devnetID == "" {
+		devnetID = "devnet0"
+	}
+	preset := n.FC.Preset()
+	sigMode := "enabled"
+	if !n.FC.VerifiesSignatures() {
+		sigMode = "skipped"
+	}
+
+	var listenAddrs []string
+	for _, a := range n.Host.P2P.Addrs() {
+		listenAddrs = append(listenAddrs, a.String())
+	}
+	topics := []string{n.Topics.Block.String(), n.Topics.Attestation.String()}
+	if n.Topics.AggregateAttestation != nil {
+		topics = append(topics, n.Topics.AggregateAttestation.String())
+	}
+
+	n.log.Info("effective config",
+		"version", Version,
+		"devnet_id", devnetID,
+		"genesis_time", cfg.GenesisTime,
+		"genesis_root", fmt.Sprintf("%x", n.FC.Anchor().Root),
+		"num_validators", len(cfg.Validators),
+		"seconds_per_slot", preset.SecondsPerSlot,
+		"justification_lookback", preset.JustificationLookback,
+		"proposer_selection", preset.ProposerSelection,
+		"validator_indices", fmt.Sprintf("%v", cfg.ValidatorIDs),
+		"signature_verification", sigMode,
+		"signature_workers", n.FC.SignatureWorkers,
+		"signing_workers", cfg.SigningWorkers,
+		"remote_signer", cfg.RemoteSignerURL,
+		"debug_invariants", cfg.DebugInvariants,
+		"debug_leansig_allocs", cfg.DebugLeansigAllocs,
+		"strict_checkpoints", cfg.StrictCheckpoints,
+		"storage_backend", storageBackend(cfg),
+		"state_snapshot_interval", cfg.StateSnapshotInterval,
+		"archive_finalized", cfg.ArchiveFinalized,
+		"anchor_slot", n.FC.Anchor().Slot,
+		"publish_jitter", cfg.PublishJitter,
+		"data_dir", cfg.DataDir,
+		"peer_id", n.Host.P2P.ID().String(),
+		"listen_addrs", strings.Join(listenAddrs, ","),
+		"discovery_port", cfg.DiscoveryPort,
+		"bootnodes", len(cfg.Bootnodes),
+		"max_peers", n.Peers.Limits().Max,
+		"target_peers", n.Peers.Limits().Target,
+		"topics", strings.Join(topics, ","),
+		"metrics_port", cfg.MetricsPort,
+	)
+}
+
+func initGenesis(log *slog.Logger, cfg Config, db storage.Store) (*forkchoice.Store, error) {
+	genesisState, err := statetransition.GenerateGenesisFromConfig(&statetransition.GenesisConfig{
+		GenesisTime: cfg.GenesisTime,
+		Preset:      cfg.Preset,
+		Validators:  cfg.Validators,
+	})
+	if err != nil {
+		return nil, err
+	}
 
 	genesisBlock := &types.Block{
 		Slot:          0,
@@ -111,13 +292,51 @@ func initGenesis(log *slog.Logger, cfg Config) *forkchoice.Store {
 		"block_root", logging.ShortHash(genesisRoot),
 	)
 
-	fc := forkchoice.NewStore(genesisState, genesisBlock, memory.New())
+	stored := 0
+	db.ForEachBlock(storage.SlotRange{}, func([32]byte, *types.Block) bool {
+		stored++
+		return true
+	})
+	anchorState, anchorBlock, err := checkpointAnchor(log, cfg, db, stored, genesisState, genesisRoot)
+	if err != nil {
+		return nil, err
+	}
+	if anchorState == nil {
+		anchorState, anchorBlock = genesisState, genesisBlock
+	}
+
+	fc := forkchoice.NewStore(anchorState, anchorBlock, db)
 	fc.NowFn = func() uint64 { return uint64(time.Now().Unix()) }
-	return fc
+	fc.CheckInvariants = cfg.DebugInvariants
+	if cfg.DebugInvariants {
+		log.Warn("debug invariant checks enabled")
+	}
+	fc.SignatureWorkers = cfg.SignatureWorkers
+	fc.StrictCheckpoints = cfg.StrictCheckpoints
+	fc.CrossValidate = cfg.CrossValidate
+	if cfg.CrossValidate {
+		log.Warn("cross-validating state transitions against the reference implementation")
+	}
+	if stored > 0 {
+		fc.Resume()
+		if path := forkChoicePath(cfg); path != "" {
+			if _, err := fc.Load(path); err != nil {
+				log.Warn("ignoring unreadable fork choice state", "path", path, "err", err)
+			}
+		}
+		status := fc.GetStatus()
+		log.Info("resumed chain from database",
+			"blocks", stored,
+			"head_slot", status.HeadSlot,
+			"justified", status.JustifiedSlot,
+			"finalized", status.FinalizedSlot,
+		)
+	}
+	return fc, nil
 }
 
-func initP2P(cfg Config) (*network.Host, *gossipsub.Topics, error) {
-	host, err := network.NewHost(cfg.ListenAddr, cfg.NodeKeyPath, cfg.Bootnodes)
+func initP2P(cfg Config, slotDuration time.Duration) (*network.Host, *gossipsub.Topics, error) {
+	host, err := network.NewHost(cfg.ListenAddr, cfg.NodeKeyPath, cfg.Bootnodes, slotDuration)
 	if err != nil {
 		return nil, nil, fmt.Errorf("create host: %w", err)
 	}
@@ -132,7 +351,7 @@ func initP2P(cfg Config) (*network.Host, *gossipsub.Topics, error) {
 	if devnetID == "" {
 		devnetID = "devnet0"
 	}
-	topics, err := gossipsub.JoinTopics(host.PubSub, devnetID)
+	topics, err := gossipsub.JoinTopics(host.PubSub, devnetID, len(cfg.Validators), slotDuration)
 	if err != nil {
 		host.Close()
 		return nil, nil, fmt.Errorf("join topics: %w", err)
@@ -170,6 +389,24 @@ func initDiscovery(log *slog.Logger, cfg Config) (*p2p.LocalNodeManager, *p2p.Di
 
 func loadValidatorKeys(log *slog.Logger, cfg Config) (map[uint64]forkchoice.Signer, error) {
 	keys := make(map[uint64]forkchoice.Signer)
+	if cfg.RemoteSignerURL != "" {
+		for _, idx := range cfg.ValidatorIDs {
+			if idx >= uint64(len(cfg.Validators)) {
+				return nil, fmt.Errorf("validator %d not in genesis validators", idx)
+			}
+			signer, err := remote.New(remote.Config{
+				URL:     cfg.RemoteSignerURL,
+				Pubkey:  cfg.Validators[idx].Pubkey[:],
+				Timeout: cfg.RemoteSignerTimeout,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("remote signer for validator %d: %w", idx, err)
+			}
+			keys[idx] = signer
+		}
+		log.Info("validators sign through remote signer", "url", cfg.RemoteSignerURL, "validators", len(keys))
+		return keys, nil
+	}
 	if cfg.ValidatorKeysDir == "" {
 		if len(cfg.ValidatorIDs) > 0 {
 			log.Warn("no validator keys directory specified; validator duties will fail signing")
@@ -185,8 +422,50 @@ func loadValidatorKeys(log *slog.Logger, cfg Config) (map[uint64]forkchoice.Sign
 		if err != nil {
 			return nil, fmt.Errorf("failed to load keypair for validator %d: %w", idx, err)
 		}
-		keys[idx] = kp
-		log.Info("loaded validator keypair", "validator_index", idx)
+
+		// Replacement keys, taking over when the current key's active
+		// epochs run out, are validator_<idx>.<n>.pk/.sk for n = 1, 2, ...
+		// They must have the current key's public key: the chain cannot
+		// change a validator's key, so another key's signatures would not
+		// verify.
+		pk, err := kp.PublicKeyBytes()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read public key of validator %d: %w", idx, err)
+		}
+		set := []ActivationKey{kp}
+		for n := 1; ; n++ {
+			pkPath := filepath.Join(cfg.ValidatorKeysDir, fmt.Sprintf("validator_%d.%d.pk", idx, n))
+			skPath := filepath.Join(cfg.ValidatorKeysDir, fmt.Sprintf("validator_%d.%d.sk", idx, n))
+			if _, err := os.Stat(pkPath); errors.Is(err, os.ErrNotExist) {
+				break
+			}
+			next, err := leansig.LoadKeypair(pkPath, skPath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load replacement keypair %d for validator %d: %w", n, idx, err)
+			}
+			nextPK, err := next.PublicKeyBytes()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read public key of replacement keypair %d for validator %d: %w", n, idx, err)
+			}
+			if !bytes.Equal(nextPK, pk) {
+				return nil, fmt.Errorf("replacement keypair %d for validator %d has another public key, and the chain cannot change a validator's key", n, idx)
+			}
+			set = append(set, next)
+		}
+		if len(set) == 1 {
+			keys[idx] = kp
+		} else {
+			ks, err := NewKeySet(set...)
+			if err != nil {
+				return nil, fmt.Errorf("validator %d: %w", idx, err)
+			}
+			keys[idx] = ks
+		}
+		log.Info("loaded validator keypair",
+			"validator_index", idx,
+			"activation_end", kp.ActivationEnd(),
+			"replacement_keys", len(set)-1,
+		)
 	}
 	return keys, nil
 }
@@ -201,3 +480,50 @@ func startMetrics(log *slog.Logger, cfg Config) {
 	metrics.Serve(cfg.MetricsPort)
 	log.Info("metrics server started", "port", cfg.MetricsPort)
 }
+
+// startAPI starts the node API if cfg.APIAddr is set. The bearer token is
+// read from cfg.APITokenPath, or generated and written there on first start.
+func startAPI(fc *forkchoice.Store, duties *ValidatorDuties, pm *peers.Manager, cfg Config) (*api.Service, error) {
+	if cfg.APIAddr == "" {
+		return nil, nil
+	}
+	tokenPath := cfg.APITokenPath
+	if tokenPath == "" {
+		tokenPath = filepath.Join(cfg.DataDir, "api_token")
+	}
+	token, err := loadOrGenerateToken(tokenPath)
+	if err != nil {
+		return nil, fmt.Errorf("api token: %w", err)
+	}
+	svc, err := api.New(fc, duties, pm, token)
+	if err != nil {
+		return nil, err
+	}
+	if err := svc.Start(cfg.APIAddr); err != nil {
+		return nil, fmt.Errorf("start api: %w", err)
+	}
+	return svc, nil
+}
+
+func loadOrGenerateToken(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		token := strings.TrimSpace(string(data))
+		if token == "" {
+			return "", fmt.Errorf("%s is empty", path)
+		}
+		return token, nil
+	}
+	if !os.IsNotExist(err) {
+		return "", err
+	}
+	var raw [32]byte
+	if _, err := rand.Read(raw[:]); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(raw[:])
+	if err := os.WriteFile(path, []byte(token+"\n"), 0600); err != nil {
+		return "", err
+	}
+	return token, nil
+}
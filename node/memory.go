@@ -0,0 +1,136 @@
+package node
+
+import (
+	"context"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"github.com/geanlabs/gean/observability/metrics"
+)
+
+const (
+	// referenceMemory is the budget the default cache sizes are tuned for.
+	referenceMemory = 2 << 30
+
+	// memoryCheckInterval is how often the watchdog samples the heap.
+	memoryCheckInterval = 5 * time.Second
+
+	// memoryShedFraction of the budget in use makes the watchdog shed caches.
+	memoryShedFraction = 0.9
+
+	// memoryShedCooldown spaces out sheds under sustained pressure.
+	memoryShedCooldown = time.Minute
+)
+
+// stateCacheControl is implemented by storage backends with a state cache.
+type stateCacheControl interface {
+	SetStateCacheSize(n int)
+	ShedStateCache() int
+}
+
+// cacheSizes are the capacities of the node's bounded caches.
+type cacheSizes struct {
+	States              int // materialized states in the storage LRU
+	PendingAttestations int // attestations waiting for missing blocks
+	BlockBacklog        int // gossip blocks queued for background import
+	SeenMessages        int // gossip message IDs; 0 is unbounded
+}
+
+var defaultCacheSizes = cacheSizes{
+	States:              64,
+	PendingAttestations: 1024,
+	BlockBacklog:        blockBacklogSize,
+	SeenMessages:        0,
+}
+
+// cacheSizesFor scales the default cache sizes to a memory budget in bytes,
+// relative to referenceMemory. Each cache keeps a floor so a small budget
+// degrades performance rather than correctness, and is capped at four times
+// its default. A zero budget returns the defaults.
+func cacheSizesFor(maxMemory uint64) cacheSizes {
+	if maxMemory == 0 {
+		return defaultCacheSizes
+	}
+	scale := float64(maxMemory) / referenceMemory
+	size := func(def, floor int) int {
+		return min(max(int(float64(def)*scale), floor), 4*def)
+	}
+	return cacheSizes{
+		States:              size(defaultCacheSizes.States, 4),
+		PendingAttestations: size(defaultCacheSizes.PendingAttestations, 64),
+		BlockBacklog:        size(defaultCacheSizes.BlockBacklog, 16),
+		SeenMessages:        size(1<<16, 4096),
+	}
+}
+
+// applyMemoryLimit sizes the node's caches for cfg.MaxMemory and sets it as
+// the Go runtime's soft memory limit.
+func (n *Node) applyMemoryLimit(maxMemory uint64) {
+	if maxMemory == 0 {
+		return
+	}
+	sizes := cacheSizesFor(maxMemory)
+	if db, ok := n.db.(stateCacheControl); ok {
+		db.SetStateCacheSize(sizes.States)
+	}
+	// Fork choice caches the hottest states in front of storage.
+	n.FC.SetStateCacheSize(sizes.States / 2)
+	n.FC.SetPendingLimit(sizes.PendingAttestations)
+	n.seen.SetMaxEntries(sizes.SeenMessages)
+	debug.SetMemoryLimit(int64(maxMemory))
+	n.log.Info("memory budget applied",
+		"max_memory_mib", maxMemory>>20,
+		"state_cache", sizes.States,
+		"pending_attestations", sizes.PendingAttestations,
+		"block_backlog", sizes.BlockBacklog,
+		"seen_messages", sizes.SeenMessages,
+	)
+}
+
+// runMemoryWatchdog sheds caches whenever the heap nears maxMemory, until ctx
+// is cancelled.
+func (n *Node) runMemoryWatchdog(ctx context.Context, maxMemory uint64) {
+	ticker := time.NewTicker(memoryCheckInterval)
+	defer ticker.Stop()
+	threshold := uint64(float64(maxMemory) * memoryShedFraction)
+	var lastShed time.Time
+	var ms runtime.MemStats
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			runtime.ReadMemStats(&ms)
+			if ms.HeapAlloc < threshold || time.Since(lastShed) < memoryShedCooldown {
+				continue
+			}
+			lastShed = time.Now()
+			n.shedCaches(ms.HeapAlloc, maxMemory)
+		}
+	}
+}
+
+// shedCaches empties the caches that can be rebuilt or refilled from the
+// network, then returns the freed memory to the OS.
+func (n *Node) shedCaches(heap, maxMemory uint64) {
+	states := n.FC.ShedStateCache()
+	if db, ok := n.db.(stateCacheControl); ok {
+		states += db.ShedStateCache()
+	}
+	pending := n.FC.ShedPendingAttestations()
+	seen := n.seen.Clear()
+	debug.FreeOSMemory()
+	metrics.MemorySheds.Inc()
+
+	var after runtime.MemStats
+	runtime.ReadMemStats(&after)
+	n.log.Warn("memory pressure, shed caches",
+		"heap_mib", heap>>20,
+		"heap_after_mib", after.HeapAlloc>>20,
+		"max_memory_mib", maxMemory>>20,
+		"states", states,
+		"pending_attestations", pending,
+		"seen_messages", seen,
+	)
+}
@@ -0,0 +1,21 @@
+package node
+
+import "testing"
+
+func TestCacheSizesFor(t *testing.T) {
+	if got := cacheSizesFor(0); got != defaultCacheSizes {
+		t.Fatalf("zero budget = %+v, want defaults", got)
+	}
+	ref := cacheSizesFor(referenceMemory)
+	if ref.States != defaultCacheSizes.States || ref.PendingAttestations != defaultCacheSizes.PendingAttestations {
+		t.Fatalf("reference budget = %+v, want default sizes", ref)
+	}
+	small := cacheSizesFor(64 << 20)
+	if small.States != 4 || small.BlockBacklog != 16 || small.SeenMessages != 4096 {
+		t.Fatalf("small budget = %+v, want floors", small)
+	}
+	large := cacheSizesFor(1 << 40)
+	if large.States != 4*defaultCacheSizes.States {
+		t.Fatalf("large budget states = %d, want cap %d", large.States, 4*defaultCacheSizes.States)
+	}
+}
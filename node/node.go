@@ -2,12 +2,21 @@ package node
 
 import (
 	"context"
+	"crypto/ed25519"
 	"log/slog"
+	"sync"
+	"time"
 
+	"github.com/geanlabs/gean/api"
 	"github.com/geanlabs/gean/chain/forkchoice"
 	"github.com/geanlabs/gean/network"
 	"github.com/geanlabs/gean/network/gossipsub"
 	"github.com/geanlabs/gean/network/p2p"
+	"github.com/geanlabs/gean/network/peers"
+	"github.com/geanlabs/gean/observability/slothistory"
+	"github.com/geanlabs/gean/storage"
+	"github.com/geanlabs/gean/storage/proposals"
+	"github.com/geanlabs/gean/storage/wal"
 	"github.com/geanlabs/gean/types"
 )
 
@@ -15,25 +24,92 @@ var Version = "v0.1.0"
 
 // Node is the main gean node orchestrator.
 type Node struct {
-	FC     *forkchoice.Store
-	Host   *network.Host
-	Topics *gossipsub.Topics
-	// API       *api.Service // Temporary disable until found
+	FC        *forkchoice.Store
+	Host      *network.Host
+	Topics    *gossipsub.Topics
+	API       *api.Service // nil unless Config.APIAddr is set
 	Validator *ValidatorDuties
 
 	// P2P Services
 	P2PManager   *p2p.LocalNodeManager
 	P2PDiscovery *p2p.DiscoveryService
+	Peers        *peers.Manager
 
-	Clock *Clock
-	log   *slog.Logger
+	Clock     *Clock
+	log       *slog.Logger
+	gossipLog *slog.Logger
+
+	// fetching holds roots with an in-flight missing-block request.
+	fetchMu  sync.Mutex
+	fetching map[[32]byte]bool
+
+	// blockBacklog queues gossip blocks for past slots so they are imported
+	// without delaying blocks for the current slot.
+	blockBacklog chan gossipBlock
+
+	// seen holds recently processed gossip message IDs; it is saved to
+	// seenPath on Close and reloaded on startup.
+	seen     *gossipsub.SeenCache
+	seenPath string
+
+	// slotHistory keeps a per-slot summary in the data directory; nil if it
+	// could not be opened.
+	slotHistory *slothistory.Table
+
+	// wal logs gossip messages before they are processed, for replay after
+	// a crash; nil if it could not be opened.
+	wal *wal.Log
+
+	// proposalLog durably records the blocks local validators produced.
+	proposalLog *proposals.Log
+
+	// db is the fork choice storage, kept to size and shed its state cache
+	// and to close on shutdown.
+	db storage.Store
+	// fcPath is where fork choice state is saved; "" for the memory backend.
+	fcPath string
+	// maxMemory is the memory budget in bytes; 0 disables the watchdog.
+	maxMemory uint64
 
 	ctx    context.Context
 	cancel context.CancelFunc
 }
 
+// Close stops the node's services and saves state kept across restarts.
 func (n *Node) Close() {
-	n.cancel()
+	if n.cancel != nil {
+		n.cancel()
+	}
+	if n.API != nil {
+		n.API.Close()
+	}
+	if n.Validator != nil && n.Validator.Signing != nil {
+		n.Validator.Signing.Close()
+	}
+	if n.Validator != nil {
+		n.Validator.freeKeys()
+		reportLeansigAllocations(n.log)
+	}
+	if n.seen != nil && n.seenPath != "" {
+		if err := n.seen.Save(n.seenPath); err != nil {
+			n.log.Warn("failed to save seen gossip messages", "path", n.seenPath, "err", err)
+		}
+	}
+	if n.slotHistory != nil {
+		n.slotHistory.Close()
+	}
+	if n.wal != nil {
+		n.wal.Close()
+	}
+	if n.proposalLog != nil {
+		n.proposalLog.Close()
+	}
+	if n.FC != nil {
+		n.saveForkChoice()
+	}
+	if n.db != nil {
+		closeStorage(n.db)
+	}
 	if n.P2PDiscovery != nil {
 		n.P2PDiscovery.Close()
 	}
@@ -41,21 +117,41 @@ func (n *Node) Close() {
 		n.P2PManager.Close()
 	}
 	if n.Host != nil {
+		n.sayGoodbyeAll()
 		n.Host.Close()
 	}
 }
 
 // Config holds node configuration.
 type Config struct {
-	GenesisTime      uint64
-	Validators       []*types.Validator
-	ListenAddr       string
-	NodeKeyPath      string
-	Bootnodes        []string
-	DiscoveryPort    int
-	DataDir          string
-	ValidatorIDs     []uint64
-	ValidatorKeysDir string
-	MetricsPort      int
-	DevnetID         string
+	GenesisTime           uint64
+	Validators            []*types.Validator
+	Preset                types.Preset // zero means types.DefaultPreset
+	ListenAddr            string
+	NodeKeyPath           string
+	Bootnodes             []string
+	DiscoveryPort         int
+	DataDir               string
+	ValidatorIDs          []uint64
+	ValidatorKeysDir      string
+	RemoteSignerURL       string        // sign through this remote signer instead of keys from ValidatorKeysDir
+	RemoteSignerTimeout   time.Duration // per remote signing attempt; 0 uses remote.DefaultTimeout
+	MetricsPort           int
+	MaxPeers              int    // connected peer cap; 0 uses peers.DefaultMaxPeers
+	APIAddr               string // loopback host:port for the admin API; empty disables it
+	APITokenPath          string // file holding the admin API bearer token
+	DevnetID              string
+	DebugInvariants       bool
+	DebugLeansigAllocs    bool // record leansig keypair allocation stacks and report unfreed keys at shutdown
+	CrossValidate         bool
+	StrictCheckpoints     bool                // halt when a checkpoint is found off the head's chain after a block import
+	MaxMemory             uint64              // bytes; sizes caches and enables the memory watchdog
+	DBBackend             string              // "memory" (default) or "leveldb" in <DataDir>/chain
+	PublishJitter         time.Duration       // window for spreading attestation and aggregate publishing; 0 disables
+	StateSnapshotInterval uint64              // store every Nth state and replay the rest; 0 or 1 stores all
+	ArchiveFinalized      bool                // move finalized blocks to flat files in <DataDir>/archive
+	SigningWorkers        int                 // goroutines signing for local validators; 0 uses GOMAXPROCS
+	SignatureWorkers      int                 // 1 verifies body attestation signatures one by one, more in one batch; 0 uses GOMAXPROCS
+	CheckpointSync        string              // path or HTTP(S) URL of a checkpoint bundle to start an empty database from
+	CheckpointSyncKeys    []ed25519.PublicKey // signers one of which must have signed the checkpoint bundle; empty accepts any
 }
@@ -0,0 +1,95 @@
+package node
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+
+	"github.com/geanlabs/gean/network/peers"
+	"github.com/geanlabs/gean/network/reqresp"
+	"github.com/geanlabs/gean/observability/metrics"
+)
+
+// disconnectPeer says goodbye to a peer, closes its connections and forgets
+// it.
+func (n *Node) disconnectPeer(pid peer.ID, reason reqresp.GoodbyeReason) {
+	n.sendGoodbye(pid, reason)
+	_ = n.Host.P2P.Network().ClosePeer(pid)
+	n.Peers.Remove(pid)
+}
+
+func (n *Node) sendGoodbye(pid peer.ID, reason reqresp.GoodbyeReason) {
+	if err := reqresp.SendGoodbye(n.Host.Ctx, n.Host.P2P, pid, reason); err != nil {
+		n.log.Debug("goodbye not delivered", "peer", pid.String()[:16], "reason", reason, "err", err)
+		return
+	}
+	metrics.PeerGoodbyes.WithLabelValues("sent", reason.String()).Inc()
+}
+
+// onGoodbye drops a peer that announced it is disconnecting.
+func (n *Node) onGoodbye(pid peer.ID, reason reqresp.GoodbyeReason) {
+	metrics.PeerGoodbyes.WithLabelValues("received", reason.String()).Inc()
+	n.log.Info("peer said goodbye", "peer", pid.String()[:16], "reason", reason)
+	_ = n.Host.P2P.Network().ClosePeer(pid)
+	n.Peers.Remove(pid)
+}
+
+// onRateLimited records a request refused for exceeding its quota.
+func (n *Node) onRateLimited(pid peer.ID, proto protocol.ID) {
+	metrics.ReqRespRateLimited.WithLabelValues(string(proto)).Inc()
+	n.log.Debug("request over quota", "peer", pid.String()[:16], "protocol", proto)
+}
+
+// sayGoodbyeAll tells every connected peer that the node is shutting down.
+func (n *Node) sayGoodbyeAll() {
+	var wg sync.WaitGroup
+	for _, pid := range n.Host.P2P.Network().Peers() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n.sendGoodbye(pid, reqresp.GoodbyeClientShutdown)
+		}()
+	}
+	wg.Wait()
+}
+
+// peerPruneInterval is how often the peer count is brought back to the
+// target. Connections beyond the maximum are pruned as soon as they open.
+const peerPruneInterval = 30 * time.Second
+
+// runPeerPruning disconnects the worst peers whenever the node holds more
+// than the peer manager's limits allow.
+func (n *Node) runPeerPruning(ctx context.Context) {
+	ticker := time.NewTicker(peerPruneInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-n.Peers.OverLimit():
+		}
+		n.prunePeers()
+	}
+}
+
+// prunePeers disconnects the peers the peer manager picks for pruning.
+func (n *Node) prunePeers() {
+	candidates := n.Peers.PruneCandidates()
+	if len(candidates) == 0 {
+		return
+	}
+	limits := n.Peers.Limits()
+	n.log.Info("pruning peers", "count", len(candidates), "target", limits.Target, "max", limits.Max)
+	for _, pid := range candidates {
+		reason := reqresp.GoodbyeTooManyPeers
+		if n.Peers.Score(pid) <= peers.DisconnectThreshold {
+			reason = reqresp.GoodbyeBadScore
+		}
+		n.disconnectPeer(pid, reason)
+		metrics.PeersPruned.Inc()
+	}
+}
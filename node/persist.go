@@ -0,0 +1,27 @@
+package node
+
+import "path/filepath"
+
+// forkChoiceFile, under the data directory, holds the fork choice votes and
+// checkpoints of a persistent database, saved every slot and on Close so a
+// restart resumes fork choice where it stopped.
+const forkChoiceFile = "forkchoice.dat"
+
+// forkChoicePath returns where fork choice state is saved, or "" when the
+// database does not outlive the process.
+func forkChoicePath(cfg Config) string {
+	if storageBackend(cfg) == "memory" {
+		return ""
+	}
+	return filepath.Join(cfg.DataDir, forkChoiceFile)
+}
+
+// saveForkChoice writes the fork choice state file, if the node keeps one.
+func (n *Node) saveForkChoice() {
+	if n.fcPath == "" {
+		return
+	}
+	if err := n.FC.Save(n.fcPath); err != nil {
+		n.log.Warn("failed to save fork choice state", "path", n.fcPath, "err", err)
+	}
+}
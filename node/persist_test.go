@@ -0,0 +1,112 @@
+package node_test
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/geanlabs/gean/chain/forkchoice"
+	"github.com/geanlabs/gean/chain/statetransition"
+	"github.com/geanlabs/gean/internal/testutil"
+	"github.com/geanlabs/gean/storage/leveldb"
+	"github.com/geanlabs/gean/storage/memory"
+	"github.com/geanlabs/gean/storage/proposals"
+	"github.com/geanlabs/gean/types"
+)
+
+func TestForkChoiceStateSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	dbPath, statePath := filepath.Join(dir, "chain"), filepath.Join(dir, "forkchoice.dat")
+	genesisState := statetransition.GenerateGenesis(1000, testutil.Validators(3))
+	stateRoot, _ := genesisState.HashTreeRoot()
+	genesisBlock := &types.Block{StateRoot: stateRoot, Body: &types.BlockBody{Attestations: []*types.Attestation{}}}
+	genesisRoot, _ := genesisBlock.HashTreeRoot()
+
+	open := func() (*forkchoice.Store, *leveldb.Store) {
+		t.Helper()
+		db, err := leveldb.Open(dbPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return forkchoice.NewStore(genesisState, genesisBlock, db), db
+	}
+
+	fc, db := open()
+	if ok, err := fc.Load(statePath); ok || err != nil {
+		t.Fatalf("load without a state file = %v, %v", ok, err)
+	}
+	fc.AdvanceTime(1000+types.SecondsPerSlot, true)
+	envelope, err := fc.ProduceBlock(1, 1, &testutil.Signer{})
+	if err != nil {
+		t.Fatalf("produce block: %v", err)
+	}
+	fc.RecomputeHead()
+	root, _ := envelope.Message.Block.HashTreeRoot()
+	vote := func(validator uint64) {
+		fc.ProcessAttestation(&types.SignedAttestation{
+			ValidatorID: validator,
+			Message: &types.AttestationData{
+				Slot:   1,
+				Head:   &types.Checkpoint{Root: root, Slot: 1},
+				Target: &types.Checkpoint{Root: root, Slot: 1},
+				Source: &types.Checkpoint{Root: genesisRoot, Slot: 0},
+			},
+		})
+	}
+	vote(0)
+	fc.AcceptNewAttestations()
+	vote(2)
+	if err := fc.Save(statePath); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	db.Close()
+
+	fc, db = open()
+	defer db.Close()
+	fc.Resume()
+	if ok, err := fc.Load(statePath); !ok || err != nil {
+		t.Fatalf("load = %v, %v", ok, err)
+	}
+	if _, ok := fc.GetKnownAttestation(0); !ok {
+		t.Fatal("known vote of validator 0 not restored")
+	}
+	if _, ok := fc.GetNewAttestation(2); !ok {
+		t.Fatal("new vote of validator 2 not restored")
+	}
+	if _, ok := fc.GetKnownAttestation(1); ok {
+		t.Fatal("validator 1 never voted")
+	}
+	if head := fc.GetStatus().Head; head != root {
+		t.Fatalf("head = %x, want %x", head, root)
+	}
+}
+
+func TestProducedBlockRecordSurvivesMemoryRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), proposals.FileName)
+	genesisState := statetransition.GenerateGenesis(1000, testutil.Validators(3))
+	stateRoot, _ := genesisState.HashTreeRoot()
+	genesisBlock := &types.Block{StateRoot: stateRoot, Body: &types.BlockBody{Attestations: []*types.Attestation{}}}
+
+	produce := func(signer *testutil.Signer) *types.SignedBlockWithAttestation {
+		t.Helper()
+		log, err := proposals.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer log.Close()
+		fc := forkchoice.NewStore(genesisState, genesisBlock, memory.New())
+		fc.Proposals = log
+		fc.AdvanceTime(1000+types.SecondsPerSlot, true)
+		envelope, err := fc.ProduceBlock(1, 1, signer)
+		if err != nil {
+			t.Fatalf("produce block: %v", err)
+		}
+		return envelope
+	}
+
+	first := produce(&testutil.Signer{})
+	second := produce(&testutil.Signer{Sig: make([]byte, 3112)})
+	if !reflect.DeepEqual(first.Signature, second.Signature) {
+		t.Fatal("restarted node signed a second block for the same duty")
+	}
+}
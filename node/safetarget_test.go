@@ -0,0 +1,49 @@
+package node_test
+
+import (
+	"testing"
+
+	"github.com/geanlabs/gean/internal/testutil"
+	"github.com/geanlabs/gean/types"
+)
+
+func TestSafeTargetNeedsTwoThirdsOfNewVotes(t *testing.T) {
+	fc, _, genesisRoot := newAnchoredStore(t)
+	fc.AdvanceTime(1000+types.SecondsPerSlot, true)
+	envelope, err := fc.ProduceBlock(1, 1, &testutil.Signer{})
+	if err != nil {
+		t.Fatalf("produce block: %v", err)
+	}
+	block := envelope.Message.Block
+	root, _ := block.HashTreeRoot()
+
+	vote := func(validator uint64) {
+		fc.ProcessAttestation(&types.SignedAttestation{
+			ValidatorID: validator,
+			Message: &types.AttestationData{
+				Slot:   1,
+				Head:   &types.Checkpoint{Root: root, Slot: 1},
+				Target: &types.Checkpoint{Root: root, Slot: 1},
+				Source: &types.Checkpoint{Root: genesisRoot, Slot: 0},
+			},
+		})
+	}
+
+	check := func(want [32]byte, wantSlot uint64) {
+		t.Helper()
+		fc.UpdateSafeTarget()
+		if got := fc.GetSafeTarget(); got.Root != want || got.Slot != wantSlot {
+			t.Fatalf("safe target = slot %d %x, want slot %d %x", got.Slot, got.Root, wantSlot, want)
+		}
+	}
+
+	check(genesisRoot, 0)
+	// One of three validators is short of two thirds.
+	vote(0)
+	check(genesisRoot, 0)
+	vote(2)
+	check(root, 1)
+	// Only new votes count: once accepted they no longer confirm it.
+	fc.AcceptNewAttestations()
+	check(genesisRoot, 0)
+}
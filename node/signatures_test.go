@@ -0,0 +1,76 @@
+package node_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/geanlabs/gean/internal/testutil"
+	"github.com/geanlabs/gean/observability/metrics"
+	"github.com/geanlabs/gean/types"
+)
+
+func TestProcessBlock_ReportsFirstBadBodySignature(t *testing.T) {
+	for _, workers := range []int{1, 4} {
+		fc, _, genesisRoot := newAnchoredStore(t)
+		if !fc.VerifiesSignatures() {
+			t.Skip("signature verification disabled in this build")
+		}
+		fc.SignatureWorkers = workers
+		fc.AdvanceTime(1000+types.SecondsPerSlot, false)
+
+		genesis := &types.Checkpoint{Root: genesisRoot, Slot: 0}
+		atts := make([]*types.Attestation, 12)
+		for i := range atts {
+			atts[i] = &types.Attestation{
+				ValidatorID: uint64(i % 3),
+				Data:        &types.AttestationData{Slot: 0, Head: genesis, Target: genesis, Source: genesis},
+			}
+		}
+		// Unknown validators fail verification.
+		atts[5].ValidatorID = 99
+		atts[9].ValidatorID = 99
+		envelope := &types.SignedBlockWithAttestation{
+			Message: &types.BlockWithAttestation{Block: &types.Block{
+				Slot:          1,
+				ProposerIndex: 1,
+				ParentRoot:    genesisRoot,
+				Body:          &types.BlockBody{Attestations: atts},
+			}},
+			Signature: make([][3112]byte, len(atts)),
+		}
+		err := fc.ProcessBlock(envelope)
+		if err == nil || !strings.Contains(err.Error(), "at index 5") {
+			t.Fatalf("workers=%d: err = %v, want failure at index 5", workers, err)
+		}
+	}
+}
+
+func TestRepeatedAttestationSignatureIsVerifiedOnce(t *testing.T) {
+	fc, _, genesisRoot := newAnchoredStore(t)
+	if !fc.VerifiesSignatures() {
+		t.Skip("signature verification disabled in this build")
+	}
+	fc.AdvanceTime(1000+types.SecondsPerSlot, false)
+	genesis := &types.Checkpoint{Root: genesisRoot, Slot: 0}
+	sa := &types.SignedAttestation{
+		ValidatorID: 1,
+		Message:     &types.AttestationData{Slot: 1, Head: genesis, Target: genesis, Source: genesis},
+	}
+	sa.Signature[0] = 0xAA
+	hits, misses := metrics.SignatureCache.WithLabelValues("hit"), metrics.SignatureCache.WithLabelValues("miss")
+	hit0, miss0 := testutil.CounterValue(t, hits), testutil.CounterValue(t, misses)
+
+	fc.ProcessAttestation(sa)
+	fc.ProcessAttestation(sa)
+	if hit, miss := testutil.CounterValue(t, hits)-hit0, testutil.CounterValue(t, misses)-miss0; hit != 1 || miss != 1 {
+		t.Fatalf("hits, misses = %v, %v; want 1, 1", hit, miss)
+	}
+
+	// Another signature over the same message is verified again.
+	other := *sa
+	other.Signature[0] = 0xBB
+	fc.ProcessAttestation(&other)
+	if miss := testutil.CounterValue(t, misses) - miss0; miss != 2 {
+		t.Fatalf("misses = %v, want 2", miss)
+	}
+}
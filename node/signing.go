@@ -0,0 +1,175 @@
+package node
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/geanlabs/gean/chain/forkchoice"
+	"github.com/geanlabs/gean/observability/metrics"
+)
+
+// ErrSigningClosed is returned for signing requests submitted after the
+// signing service was closed.
+var ErrSigningClosed = errors.New("signing service closed")
+
+// SignResult is the outcome of a signing request.
+type SignResult struct {
+	Signature []byte
+	Err       error
+	Duration  time.Duration // time spent in the signer, excluding queueing
+}
+
+// SigningService signs on worker goroutines so XMSS signing, which takes
+// tens of milliseconds, does not run inline in the duty loop. Requests are
+// queued per validator: those of one validator run one at a time in
+// submission order, as an XMSS key must not sign concurrently, while
+// different validators sign in parallel.
+type SigningService struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	queues map[uint64][]func()
+	ready  []uint64 // validators with queued requests and no worker on them
+	queued int
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// NewSigningService starts a signing service with the given number of
+// workers; 0 or less uses GOMAXPROCS.
+func NewSigningService(workers int) *SigningService {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	s := &SigningService{queues: make(map[uint64][]func())}
+	s.cond = sync.NewCond(&s.mu)
+	s.wg.Add(workers)
+	for range workers {
+		go s.work()
+	}
+	return s
+}
+
+// Sign queues a request for validator idx to sign message at slot with key.
+// The returned channel receives exactly one result.
+func (s *SigningService) Sign(idx uint64, key forkchoice.Signer, slot uint32, message [32]byte) <-chan SignResult {
+	result := make(chan SignResult, 1)
+	queued := s.submit(idx, func() {
+		start := time.Now()
+		sig, err := key.Sign(slot, message)
+		result <- SignResult{Signature: sig, Err: err, Duration: time.Since(start)}
+	})
+	if !queued {
+		result <- SignResult{Err: ErrSigningClosed}
+	}
+	return result
+}
+
+// Exclusive queues fn behind validator idx's signing requests, so it may use
+// the validator's key without racing a signature. The returned channel
+// receives fn's error, or ErrSigningClosed if the service is closed.
+func (s *SigningService) Exclusive(idx uint64, fn func() error) <-chan error {
+	result := make(chan error, 1)
+	if !s.submit(idx, func() { result <- fn() }) {
+		result <- ErrSigningClosed
+	}
+	return result
+}
+
+// submit queues job for validator idx and reports false if the service is
+// closed.
+func (s *SigningService) submit(idx uint64, job func()) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	q, active := s.queues[idx]
+	s.queues[idx] = append(q, job)
+	if !active {
+		s.ready = append(s.ready, idx)
+		s.cond.Signal()
+	}
+	s.queued++
+	metrics.SigningQueueDepth.Set(float64(s.queued))
+	return true
+}
+
+// Close rejects new requests and returns once the queued ones are signed.
+func (s *SigningService) Close() {
+	s.mu.Lock()
+	s.closed = true
+	s.cond.Broadcast()
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+func (s *SigningService) work() {
+	defer s.wg.Done()
+	for {
+		s.mu.Lock()
+		for len(s.ready) == 0 && !s.closed {
+			s.cond.Wait()
+		}
+		if len(s.ready) == 0 {
+			s.mu.Unlock()
+			return
+		}
+		idx := s.ready[0]
+		s.ready = s.ready[1:]
+		job := s.queues[idx][0]
+		s.queues[idx] = s.queues[idx][1:]
+		s.queued--
+		metrics.SigningQueueDepth.Set(float64(s.queued))
+		s.mu.Unlock()
+
+		job()
+
+		// The validator stays off the ready list while its request runs,
+		// so its next request cannot start before this one ends.
+		s.mu.Lock()
+		if len(s.queues[idx]) > 0 {
+			s.ready = append(s.ready, idx)
+			s.cond.Signal()
+		} else {
+			delete(s.queues, idx)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// sign signs through the duties' signing service, or inline when there is
+// none.
+func (v *ValidatorDuties) sign(idx uint64, key forkchoice.Signer, slot uint32, message [32]byte) <-chan SignResult {
+	if v.Signing != nil {
+		return v.Signing.Sign(idx, key, slot, message)
+	}
+	out := make(chan SignResult, 1)
+	start := time.Now()
+	sig, err := key.Sign(slot, message)
+	out <- SignResult{Signature: sig, Err: err, Duration: time.Since(start)}
+	return out
+}
+
+// exclusive runs fn through the duties' signing service, or inline when there
+// is none.
+func (v *ValidatorDuties) exclusive(idx uint64, fn func() error) error {
+	if v.Signing != nil {
+		return <-v.Signing.Exclusive(idx, fn)
+	}
+	return fn()
+}
+
+// queuedSigner is a forkchoice.Signer that signs through the duties' signing
+// service, for callers that need the signature before they can continue.
+type queuedSigner struct {
+	v   *ValidatorDuties
+	idx uint64
+	key forkchoice.Signer
+}
+
+func (s queuedSigner) Sign(signingSlot uint32, message [32]byte) ([]byte, error) {
+	res := <-s.v.sign(s.idx, s.key, signingSlot, message)
+	return res.Signature, res.Err
+}
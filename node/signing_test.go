@@ -0,0 +1,181 @@
+package node_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+
+	"github.com/geanlabs/gean/chain/forkchoice"
+	"github.com/geanlabs/gean/network/gossipsub"
+	"github.com/geanlabs/gean/node"
+	"github.com/geanlabs/gean/observability/logging"
+	"github.com/geanlabs/gean/types"
+)
+
+// rendezvousSigner blocks each Sign until want signers are signing at once,
+// failing after a timeout, so passing proves the signatures ran in parallel.
+type rendezvousSigner struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	signing int
+	want    int
+}
+
+func newRendezvousSigner(want int) *rendezvousSigner {
+	s := &rendezvousSigner{want: want}
+	s.cond = sync.NewCond(&s.mu)
+	return s
+}
+
+func (s *rendezvousSigner) Sign(epoch uint32, message [32]byte) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.signing++
+	s.cond.Broadcast()
+	deadline := time.Now().Add(2 * time.Second)
+	for s.signing < s.want {
+		if time.Now().After(deadline) {
+			return nil, errors.New("signers did not run in parallel")
+		}
+		s.mu.Unlock()
+		time.Sleep(time.Millisecond)
+		s.mu.Lock()
+	}
+	out := make([]byte, 3112)
+	out[0] = byte(epoch)
+	return out, nil
+}
+
+// exclusiveSigner fails if it is used by two goroutines at once.
+type exclusiveSigner struct {
+	mu    sync.Mutex
+	busy  bool
+	order []uint32
+}
+
+func (s *exclusiveSigner) Sign(epoch uint32, message [32]byte) ([]byte, error) {
+	s.mu.Lock()
+	if s.busy {
+		s.mu.Unlock()
+		return nil, errors.New("concurrent use of one key")
+	}
+	s.busy = true
+	s.order = append(s.order, epoch)
+	s.mu.Unlock()
+
+	time.Sleep(time.Millisecond)
+
+	s.mu.Lock()
+	s.busy = false
+	s.mu.Unlock()
+	return []byte{byte(epoch)}, nil
+}
+
+func TestSigningService_SerializesPerValidator(t *testing.T) {
+	svc := node.NewSigningService(4)
+	defer svc.Close()
+
+	keys := []*exclusiveSigner{{}, {}}
+	var results [2][]<-chan node.SignResult
+	for slot := uint32(1); slot <= 5; slot++ {
+		for idx, key := range keys {
+			results[idx] = append(results[idx], svc.Sign(uint64(idx), key, slot, [32]byte{}))
+		}
+	}
+
+	for idx := range keys {
+		for i, ch := range results[idx] {
+			res := <-ch
+			if res.Err != nil {
+				t.Fatalf("validator %d request %d: %v", idx, i, res.Err)
+			}
+			if res.Signature[0] != byte(i+1) {
+				t.Fatalf("validator %d request %d signed slot %d", idx, i, res.Signature[0])
+			}
+		}
+		for i, slot := range keys[idx].order {
+			if slot != uint32(i+1) {
+				t.Fatalf("validator %d signed slots %v, want submission order", idx, keys[idx].order)
+			}
+		}
+	}
+}
+
+func TestSigningService_ValidatorsSignInParallel(t *testing.T) {
+	svc := node.NewSigningService(2)
+	defer svc.Close()
+
+	key := newRendezvousSigner(2)
+	a := svc.Sign(0, key, 1, [32]byte{})
+	b := svc.Sign(1, key, 1, [32]byte{})
+	for _, ch := range []<-chan node.SignResult{a, b} {
+		if res := <-ch; res.Err != nil {
+			t.Fatal(res.Err)
+		}
+	}
+}
+
+func TestSigningService_Close(t *testing.T) {
+	svc := node.NewSigningService(1)
+	key := &exclusiveSigner{}
+	queued := []<-chan node.SignResult{
+		svc.Sign(0, key, 1, [32]byte{}),
+		svc.Sign(0, key, 2, [32]byte{}),
+	}
+	svc.Close()
+
+	for i, ch := range queued {
+		select {
+		case res := <-ch:
+			if res.Err != nil {
+				t.Fatalf("queued request %d: %v", i, res.Err)
+			}
+		default:
+			t.Fatalf("queued request %d not signed before Close returned", i)
+		}
+	}
+	if res := <-svc.Sign(0, key, 3, [32]byte{}); !errors.Is(res.Err, node.ErrSigningClosed) {
+		t.Fatalf("err = %v, want ErrSigningClosed", res.Err)
+	}
+}
+
+func TestValidatorDuties_TryAttest_SigningService(t *testing.T) {
+	fc, _, _ := newAnchoredStore(t)
+	svc := node.NewSigningService(2)
+	defer svc.Close()
+
+	// Validator 0 proposes slot 0; 1 and 2 attest and must sign at once.
+	key := newRendezvousSigner(2)
+	var mu sync.Mutex
+	published := make(map[uint64]*types.SignedAttestation)
+	duties := &node.ValidatorDuties{
+		Indices: []uint64{1, 2},
+		Keys:    map[uint64]forkchoice.Signer{1: key, 2: key},
+		FC:      fc,
+		Topics:  &gossipsub.Topics{Attestation: &pubsub.Topic{}},
+		PublishAttestation: func(ctx context.Context, topic *pubsub.Topic, sa *types.SignedAttestation) error {
+			mu.Lock()
+			defer mu.Unlock()
+			published[sa.ValidatorID] = sa
+			return nil
+		},
+		Log:     logging.NewComponentLogger(logging.CompValidator),
+		Signing: svc,
+	}
+
+	duties.TryAttest(context.Background(), 0)
+
+	if len(published) != 2 || published[1] == nil || published[2] == nil {
+		t.Fatalf("published attestations of %v, want validators 1 and 2", published)
+	}
+	if *published[1].Message != *published[2].Message {
+		t.Fatal("local validators attested to different data")
+	}
+	if got := duties.Outcome(0).Attested; got != 2 {
+		t.Fatalf("attested = %d, want 2", got)
+	}
+}
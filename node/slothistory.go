@@ -0,0 +1,40 @@
+package node
+
+import (
+	"github.com/geanlabs/gean/chain/forkchoice"
+	"github.com/geanlabs/gean/observability/slothistory"
+)
+
+// recordSlot writes the summary of a finished slot to the slot history,
+// using the chain status observed at the start of the next slot.
+func (n *Node) recordSlot(slot uint64, status forkchoice.ChainStatus) {
+	if n.slotHistory == nil {
+		return
+	}
+	r := slothistory.Record{
+		Slot:          slot,
+		HeadRoot:      status.Head,
+		HeadSlot:      status.HeadSlot,
+		JustifiedSlot: status.JustifiedSlot,
+		FinalizedSlot: status.FinalizedSlot,
+	}
+	if proposer, ok := n.FC.ProposerIndex(slot); ok {
+		r.Proposer = proposer
+		for _, idx := range n.Validator.validators() {
+			if idx == r.Proposer {
+				r.Flags |= slothistory.FlagLocalProposer
+			}
+		}
+	}
+	outcome := n.Validator.Outcome(slot)
+	if outcome.Proposed {
+		r.Flags |= slothistory.FlagLocalProposed
+	}
+	r.LocalAttested = uint16(outcome.Attested)
+	if _, ok := n.FC.CanonicalRoot(slot); ok {
+		r.Flags |= slothistory.FlagBlock
+	}
+	if err := n.slotHistory.Put(r); err != nil {
+		n.log.Warn("failed to record slot history", "slot", slot, "err", err)
+	}
+}
@@ -0,0 +1,91 @@
+package node
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/geanlabs/gean/observability/metrics"
+	"github.com/geanlabs/gean/storage"
+	"github.com/geanlabs/gean/storage/archive"
+	"github.com/geanlabs/gean/storage/leveldb"
+	"github.com/geanlabs/gean/storage/memory"
+	"github.com/geanlabs/gean/storage/metered"
+	"github.com/geanlabs/gean/storage/snapshot"
+)
+
+// chainDBDir is the LevelDB directory within the data directory.
+const chainDBDir = "chain"
+
+// archiveDir holds finalized blocks moved out of the chain store.
+const archiveDir = "archive"
+
+func storageBackend(cfg Config) string {
+	if cfg.DBBackend == "" {
+		return "memory"
+	}
+	return cfg.DBBackend
+}
+
+// openStorage opens the block and state store selected by cfg.DBBackend,
+// timed for metrics and wrapped to keep only periodic state snapshots if
+// cfg.StateSnapshotInterval is set and to archive finalized blocks if
+// cfg.ArchiveFinalized is set.
+func openStorage(cfg Config) (storage.Store, error) {
+	var db storage.Store
+	switch backend := storageBackend(cfg); backend {
+	case "memory":
+		db = memory.New()
+	case "leveldb":
+		ldb, err := leveldb.Open(filepath.Join(cfg.DataDir, chainDBDir))
+		if err != nil {
+			return nil, err
+		}
+		db = ldb
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", backend)
+	}
+	db = metered.New(db)
+	if cfg.StateSnapshotInterval > 1 {
+		db = snapshot.New(db, cfg.StateSnapshotInterval)
+	}
+	if cfg.ArchiveFinalized {
+		dir := filepath.Join(cfg.DataDir, archiveDir)
+		if storageBackend(cfg) == "memory" {
+			// The in-memory chain restarts from genesis, and the archive
+			// must not hold blocks the hot store has never seen.
+			if err := os.RemoveAll(dir); err != nil {
+				closeStorage(db)
+				return nil, err
+			}
+		}
+		adb, err := archive.Open(dir, db)
+		if err != nil {
+			closeStorage(db)
+			return nil, err
+		}
+		db = adb
+	}
+	return db, nil
+}
+
+// updateStorageMetrics publishes the block and state counts and on-disk size
+// of db, if it reports them.
+func updateStorageMetrics(db storage.Store) {
+	s, ok := db.(interface{ Stats() storage.Stats })
+	if !ok {
+		return
+	}
+	st := s.Stats()
+	metrics.StorageBlocks.Set(float64(st.Blocks))
+	metrics.StorageStates.Set(float64(st.States))
+	metrics.StorageDiskBytes.Set(float64(st.DiskBytes))
+}
+
+// closeStorage closes db if the backend holds resources.
+func closeStorage(db storage.Store) {
+	if c, ok := db.(io.Closer); ok {
+		c.Close()
+	}
+}
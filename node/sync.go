@@ -2,9 +2,13 @@ package node
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/libp2p/go-libp2p/core/peer"
 
+	"github.com/geanlabs/gean/network/peers"
 	"github.com/geanlabs/gean/network/reqresp"
 	"github.com/geanlabs/gean/types"
 )
@@ -14,12 +18,12 @@ import (
 // processes them in forward order.
 func (n *Node) syncWithPeer(ctx context.Context, pid peer.ID) bool {
 	status := n.FC.GetStatus()
-	ourStatus := reqresp.Status{
-		Finalized: &types.Checkpoint{Root: status.FinalizedRoot, Slot: status.FinalizedSlot},
-		Head:      &types.Checkpoint{Root: status.Head, Slot: status.HeadSlot},
+	peerStatus, err := n.exchangeStatus(ctx, pid)
+	if errors.Is(err, errIrreconcilable) {
+		n.log.Info("disconnecting peer on another chain", "peer", pid.String()[:16], "err", err)
+		n.disconnectPeer(pid, reqresp.GoodbyeIrrelevantNetwork)
+		return false
 	}
-
-	peerStatus, err := reqresp.RequestStatus(ctx, n.Host.P2P, pid, ourStatus)
 	if err != nil {
 		n.log.Debug("status exchange failed", "peer", pid.String()[:16], "err", err)
 		return false
@@ -38,23 +42,50 @@ func (n *Node) syncWithPeer(ctx context.Context, pid peer.ID) bool {
 	var pending []*types.SignedBlockWithAttestation
 	nextRoot := peerStatus.Head.Root
 	const maxSyncDepth = 64
+	connected := false
 
 	for i := 0; i < maxSyncDepth; i++ {
 		if _, ok := n.FC.GetBlock(nextRoot); ok {
+			connected = true
 			break // We have this block, chain is connected.
 		}
 
-		blocks, err := reqresp.RequestBlocksByRoot(ctx, n.Host.P2P, pid, [][32]byte{nextRoot})
+		blocks, err := n.requestBlocksByRoot(ctx, pid, [][32]byte{nextRoot})
 		if err != nil || len(blocks) == 0 {
 			n.log.Debug("blocks_by_root failed during sync walk", "peer", pid.String()[:16], "err", err)
+			n.penalizePeer(pid, peers.PenaltyRequestFailure, "blocks_by_root failed")
 			break
 		}
 
+		if err := validateBlocksByRootResponse([][32]byte{nextRoot}, blocks); err != nil {
+			n.penalizePeer(pid, peers.PenaltyUnrequestedBlock, err.Error())
+			return false
+		}
+
 		sb := blocks[0]
+		if len(pending) > 0 {
+			child := pending[len(pending)-1].Message.Block
+			if sb.Message.Block.Slot >= child.Slot {
+				n.penalizePeer(pid, peers.PenaltyBrokenChain, fmt.Sprintf(
+					"parent slot %d not below child slot %d", sb.Message.Block.Slot, child.Slot))
+				return false
+			}
+		}
 		pending = append(pending, sb)
 		nextRoot = sb.Message.Block.ParentRoot
 	}
 
+	// Only import once the oldest fetched block links to a block we know;
+	// otherwise every block would be rejected for a missing parent state.
+	// The peer is penalized so the next attempt ranks other peers first.
+	if !connected {
+		if len(pending) > 0 {
+			n.penalizePeer(pid, peers.PenaltyUnconnectedChain, fmt.Sprintf(
+				"sync walk of %d blocks did not reach a known ancestor", len(pending)))
+		}
+		return false
+	}
+
 	// Process in forward order (oldest first).
 	synced := 0
 	for i := len(pending) - 1; i >= 0; i-- {
@@ -69,11 +100,72 @@ func (n *Node) syncWithPeer(ctx context.Context, pid peer.ID) bool {
 	return synced > 0
 }
 
+// requestBlocksByRoot requests blocks from a peer using the best mutually
+// supported protocol version and records the request outcome for peer ranking.
+func (n *Node) requestBlocksByRoot(ctx context.Context, pid peer.ID, roots [][32]byte) ([]*types.SignedBlockWithAttestation, error) {
+	protos := n.Peers.SelectProtocols(pid, reqresp.BlocksByRootProtocols)
+	if len(protos) == 0 {
+		return nil, fmt.Errorf("peer does not support blocks_by_root")
+	}
+	start := time.Now()
+	blocks, err := reqresp.RequestBlocksByRootFiltered(ctx, n.Host.P2P, pid, roots, n.syncBlockFilter(), protos...)
+	n.Peers.RecordRequest(pid, time.Since(start), err == nil && len(blocks) > 0)
+	return blocks, err
+}
+
+// syncBlockFilter returns a header pre-check for one blocks_by_root response.
+// It skips blocks at or before the finalized slot, which cannot be imported,
+// and repeated headers within the response.
+func (n *Node) syncBlockFilter() func(types.BlockHeaderFields) bool {
+	finalizedSlot := n.FC.GetStatus().FinalizedSlot
+	seen := make(map[types.BlockHeaderFields]bool)
+	return func(h types.BlockHeaderFields) bool {
+		if h.Slot <= finalizedSlot || seen[h] {
+			return false
+		}
+		seen[h] = true
+		return true
+	}
+}
+
+// validateBlocksByRootResponse checks that every returned block hashes to one
+// of the requested roots and that no root is answered twice.
+func validateBlocksByRootResponse(requested [][32]byte, blocks []*types.SignedBlockWithAttestation) error {
+	want := make(map[[32]byte]bool, len(requested))
+	for _, r := range requested {
+		want[r] = true
+	}
+	for _, sb := range blocks {
+		if sb == nil || sb.Message == nil || sb.Message.Block == nil {
+			return fmt.Errorf("malformed block in response")
+		}
+		root, err := sb.Message.Block.HashTreeRoot()
+		if err != nil {
+			return fmt.Errorf("hash returned block: %w", err)
+		}
+		if !want[root] {
+			return fmt.Errorf("unrequested block root %x", root[:8])
+		}
+		delete(want, root)
+	}
+	return nil
+}
+
+// penalizePeer lowers a peer's score and disconnects it once the score
+// crosses the disconnect threshold.
+func (n *Node) penalizePeer(pid peer.ID, amount int, reason string) {
+	n.log.Debug("penalizing peer", "peer", pid.String()[:16], "reason", reason)
+	if !n.Peers.Penalize(pid, amount) {
+		return
+	}
+	n.log.Warn("disconnecting misbehaving peer", "peer", pid.String()[:16], "score", n.Peers.Score(pid))
+	n.disconnectPeer(pid, reqresp.GoodbyeBadScore)
+}
+
 // initialSync exchanges status with connected peers and requests any blocks
 // we're missing. This allows a node that restarts mid-devnet to catch up.
 func (n *Node) initialSync(ctx context.Context) {
-	peers := n.Host.P2P.Network().Peers()
-	for _, pid := range peers {
+	for _, pid := range n.Peers.RankPeers(n.Host.P2P.Network().Peers()) {
 		n.syncWithPeer(ctx, pid)
 	}
 }
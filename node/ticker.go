@@ -5,6 +5,10 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/libp2p/go-libp2p/core/protocol"
+
+	"github.com/geanlabs/gean/network/peers"
+	"github.com/geanlabs/gean/network/reqresp"
 	"github.com/geanlabs/gean/observability/logging"
 	"github.com/geanlabs/gean/observability/metrics"
 )
@@ -12,10 +16,25 @@ import (
 // Run starts the main event loop.
 func (n *Node) Run(ctx context.Context) error {
 	n.log.Info("node started",
-		"validators", fmt.Sprintf("%v", n.Validator.Indices),
+		"validators", fmt.Sprintf("%v", n.Validator.validators()),
 		"peers", len(n.Host.P2P.Network().Peers()),
 	)
 
+	go n.runBlockBacklog(ctx)
+	go n.runHeadEvents(ctx)
+	if n.maxMemory > 0 {
+		go n.runMemoryWatchdog(ctx, n.maxMemory)
+	}
+
+	// Ready validator keys before the first duty.
+	n.Validator.WarmupKeys(n.Clock.CurrentSlot())
+	// Keys may be imported at runtime, so preparation runs even without any.
+	go n.runKeyPreparation(ctx)
+	go n.runPeerPruning(ctx)
+
+	// Re-process gossip received before the last shutdown.
+	n.replayGossipWAL()
+
 	// Attempt initial sync with connected peers.
 	n.initialSync(ctx)
 
@@ -27,9 +46,7 @@ func (n *Node) Run(ctx context.Context) error {
 		select {
 		case <-ctx.Done():
 			n.log.Info("node shutting down")
-			if err := n.Host.Close(); err != nil {
-				n.log.Warn("host close error", "err", err)
-			}
+			n.Close()
 			return nil
 		case <-ticker.C:
 			if n.Clock.IsBeforeGenesis() {
@@ -44,9 +61,11 @@ func (n *Node) Run(ctx context.Context) error {
 
 			status := n.FC.GetStatus()
 
-			// Sync before duties: if head is behind, try catching up.
+			// Sync before duties: if head is behind, try catching up. A peer
+			// that cannot serve a connecting chain is penalized and the next
+			// one is tried.
 			if slot > status.HeadSlot+2 {
-				for _, pid := range n.Host.P2P.Network().Peers() {
+				for _, pid := range n.Peers.RankPeers(n.Host.P2P.Network().Peers()) {
 					if n.syncWithPeer(ctx, pid) {
 						status = n.FC.GetStatus() // refresh after sync
 						break
@@ -71,17 +90,44 @@ func (n *Node) Run(ctx context.Context) error {
 				metrics.LatestJustifiedSlot.Set(float64(status.JustifiedSlot))
 				peerCount := len(n.Host.P2P.Network().Peers())
 				metrics.ConnectedPeers.Set(float64(peerCount))
+				dirCounts := n.Peers.DirectionCounts()
+				for _, dir := range []peers.Direction{peers.Inbound, peers.Outbound} {
+					metrics.PeersByDirection.WithLabelValues(dir.String()).Set(float64(dirCounts[dir]))
+				}
+				n.updateProtocolMetrics()
+				updateStorageMetrics(n.db)
+				n.Validator.UpdateKeyHeadroom(slot)
 
 				n.log.Info("slot",
 					"slot", slot,
 					"head", status.HeadSlot,
 					"finalized", status.FinalizedSlot,
 					"justified", status.JustifiedSlot,
+					"safe_target", n.FC.GetSafeTarget().Slot,
 					"peers", peerCount,
 					"elapsed", logging.TimeSince(start),
 				)
+				if slot > 0 {
+					n.recordSlot(slot-1, status)
+				}
+				n.rotateGossipWAL(slot)
+				n.saveForkChoice()
 				lastSlot = slot
 			}
 		}
 	}
 }
+
+// updateProtocolMetrics publishes per-protocol peer counts for every req/resp
+// protocol version this node speaks, and peer counts per client.
+func (n *Node) updateProtocolMetrics() {
+	protos := append(append([]protocol.ID{}, reqresp.StatusProtocols...), reqresp.BlocksByRootProtocols...)
+	for p, count := range n.Peers.ProtocolCounts(protos) {
+		metrics.PeersByProtocol.WithLabelValues(string(p)).Set(float64(count))
+	}
+	// Reset so client versions that left the mesh stop being reported.
+	metrics.PeersByClient.Reset()
+	for _, c := range n.Peers.ClientCounts() {
+		metrics.PeersByClient.WithLabelValues(c.Client, c.Version).Set(float64(c.Peers))
+	}
+}
@@ -5,12 +5,13 @@ import (
 	"encoding/hex"
 	"fmt"
 	"log/slog"
+	"sort"
+	"sync"
 	"time"
 
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
 
 	"github.com/geanlabs/gean/chain/forkchoice"
-	"github.com/geanlabs/gean/chain/statetransition"
 	"github.com/geanlabs/gean/network/gossipsub"
 	"github.com/geanlabs/gean/observability/logging"
 	"github.com/geanlabs/gean/observability/metrics"
@@ -19,6 +20,8 @@ import (
 
 // ValidatorDuties handles proposer and attester duties.
 type ValidatorDuties struct {
+	// Indices and Keys are the local validators and their keys. Once the
+	// node runs they change only through AddKey and RemoveKey.
 	Indices                      []uint64
 	Keys                         map[uint64]forkchoice.Signer
 	FC                           *forkchoice.Store
@@ -28,15 +31,79 @@ type ValidatorDuties struct {
 	PublishAggregatedAttestation func(context.Context, *pubsub.Topic, *types.AggregatedAttestation) error
 	Log                          *slog.Logger
 
+	// PublishJitter is the window after the interval start over which
+	// attestations and aggregates are published. Each validator publishes at
+	// a fixed offset derived from its index, so nodes do not all gossip at
+	// the interval boundary. Zero publishes immediately.
+	PublishJitter time.Duration
+
+	// Signing signs on worker goroutines; nil signs inline.
+	Signing *SigningService
+
+	// keysMu guards Indices and Keys against the keymanager API.
+	keysMu sync.RWMutex
+
 	// pendingAttestations collects signed attestations produced during interval 1
 	// for aggregation during interval 2.
 	pendingAttestations []*types.SignedAttestation
+
+	// headroomWarned tracks keys already warned about low prepared-window
+	// headroom so the warning is not repeated every slot.
+	headroomWarned map[uint64]bool
+
+	// keysExhausted tracks keys already reported as unable to advance
+	// their prepared window. It is only touched by PrepareKeys.
+	keysExhausted map[uint64]bool
+
+	// Circuit breaker: consecutive signing failures per validator, and the
+	// validators whose duties were disabled because of them.
+	breakerMu    sync.Mutex
+	signFailures map[uint64]int
+	disabled     map[uint64]bool
+
+	// outcomes records what the local validators published in recent slots.
+	// It is only touched from the duty loop.
+	outcomes map[uint64]*SlotOutcome
+}
+
+// SlotOutcome is what the local validators published in one slot.
+type SlotOutcome struct {
+	Proposed bool // a local proposer published its block
+	Attested int  // local validators whose attestation was published
+}
+
+// slotOutcomeRetention is how many slots of outcomes are kept.
+const slotOutcomeRetention = 8
+
+// Outcome returns what the local validators published at slot.
+func (v *ValidatorDuties) Outcome(slot uint64) SlotOutcome {
+	if o, ok := v.outcomes[slot]; ok {
+		return *o
+	}
+	return SlotOutcome{}
+}
+
+func (v *ValidatorDuties) outcome(slot uint64) *SlotOutcome {
+	if v.outcomes == nil {
+		v.outcomes = make(map[uint64]*SlotOutcome)
+	}
+	o, ok := v.outcomes[slot]
+	if !ok {
+		o = &SlotOutcome{}
+		v.outcomes[slot] = o
+		for s := range v.outcomes {
+			if s+slotOutcomeRetention < slot {
+				delete(v.outcomes, s)
+			}
+		}
+	}
+	return o
 }
 
 // HasProposal reports whether this node has a proposer for the slot.
 func (v *ValidatorDuties) HasProposal(slot uint64) bool {
-	for _, idx := range v.Indices {
-		if statetransition.IsProposer(idx, slot, v.FC.NumValidators()) {
+	for _, idx := range v.validators() {
+		if v.FC.IsProposer(idx, slot) {
 			return true
 		}
 	}
@@ -47,11 +114,20 @@ func (v *ValidatorDuties) HasProposal(slot uint64) bool {
 func (v *ValidatorDuties) OnInterval(ctx context.Context, slot, interval uint64) {
 	switch interval {
 	case 0:
+		if slot > 0 {
+			v.reportParticipation(slot - 1)
+		}
 		v.TryPropose(ctx, slot)
 	case 1:
 		v.TryAttest(ctx, slot)
 	case 2:
 		v.TryAggregate(ctx, slot)
+	case 3:
+		// The head settles after interval 3 accepts new votes; warm the
+		// packing inputs if we propose next slot.
+		if v.HasProposal(slot + 1) {
+			go v.FC.PreparePacking(slot + 1)
+		}
 	}
 }
 
@@ -61,18 +137,17 @@ func (v *ValidatorDuties) TryPropose(ctx context.Context, slot uint64) {
 		return
 	}
 
-	for _, idx := range v.Indices {
-		if !statetransition.IsProposer(idx, slot, v.FC.NumValidators()) {
+	for _, idx := range v.validators() {
+		if !v.FC.IsProposer(idx, slot) {
 			continue
 		}
 
-		kp, ok := v.Keys[idx]
+		kp, ok := v.signerFor(idx)
 		if !ok {
-			v.Log.Error("proposer key not found", "validator", idx)
 			continue
 		}
 
-		envelope, err := v.FC.ProduceBlock(slot, idx, kp)
+		envelope, err := v.FC.ProduceBlock(slot, idx, queuedSigner{v: v, idx: idx, key: kp})
 		if err != nil {
 			v.Log.Error("block proposal failed",
 				"slot", slot,
@@ -100,7 +175,11 @@ func (v *ValidatorDuties) TryPropose(ctx context.Context, slot uint64) {
 				"proposer", idx,
 				"err", err,
 			)
+			v.publishProposerAttestation(ctx, envelope)
 		} else {
+			o := v.outcome(slot)
+			o.Proposed = true
+			o.Attested++ // the proposer attests through its block
 			v.Log.Info("proposed block",
 				"slot", slot,
 				"proposer", idx,
@@ -110,60 +189,142 @@ func (v *ValidatorDuties) TryPropose(ctx context.Context, slot uint64) {
 	}
 }
 
+// publishProposerAttestation publishes the proposer attestation embedded in an
+// envelope whose block failed to publish, so the proposer's vote still reaches
+// the network. The signature from the envelope is reused: signing a second
+// message for the same slot would reuse a one-time XMSS key.
+func (v *ValidatorDuties) publishProposerAttestation(ctx context.Context, envelope *types.SignedBlockWithAttestation) {
+	pa := envelope.Message.ProposerAttestation
+	if pa == nil || len(envelope.Signature) == 0 || v.PublishAttestation == nil {
+		return
+	}
+	sa := &types.SignedAttestation{
+		ValidatorID: pa.ValidatorID,
+		Message:     pa.Data,
+		Signature:   envelope.Signature[len(envelope.Signature)-1],
+	}
+	if err := v.PublishAttestation(ctx, v.Topics.Attestation, sa); err != nil {
+		v.Log.Error("failed to publish fallback proposer attestation",
+			"slot", pa.Data.Slot,
+			"validator", pa.ValidatorID,
+			"err", err,
+		)
+		return
+	}
+	v.outcome(pa.Data.Slot).Attested++
+	v.Log.Info("published proposer attestation after block publish failure",
+		"slot", pa.Data.Slot,
+		"validator", pa.ValidatorID,
+		"head_slot", pa.Data.Head.Slot,
+	)
+}
+
 func (v *ValidatorDuties) TryAttest(ctx context.Context, slot uint64) {
+	start := time.Now()
 	v.pendingAttestations = nil // reset for this slot
 
-	for _, idx := range v.Indices {
+	var data *types.AttestationData
+
+	// Queue every local validator's signature before waiting on any, so the
+	// signing service works on them in parallel.
+	type request struct {
+		idx    uint64
+		result <-chan SignResult
+	}
+	var requests []request
+	for _, idx := range v.validators() {
 		// Skip if this validator is the proposer for this slot.
 		// The proposer already attests via ProposerAttestation in its block.
-		if statetransition.IsProposer(idx, slot, v.FC.NumValidators()) {
+		if v.FC.IsProposer(idx, slot) {
 			continue
 		}
 
-		kp, ok := v.Keys[idx]
+		kp, ok := v.signerFor(idx)
 		if !ok {
-			v.Log.Error("validator key not found", "validator", idx)
 			continue
 		}
 
-		signStart := time.Now()
-		sa, err := v.FC.ProduceAttestation(slot, idx, kp)
-		signDuration := time.Since(signStart)
-		metrics.SigningTime.Observe(signDuration.Seconds())
+		if data == nil {
+			var err error
+			if data, err = v.FC.ProduceAttestationData(slot); err != nil {
+				v.Log.Error("attestation failed",
+					"slot", slot,
+					"validator", idx,
+					"err", err,
+				)
+				return
+			}
+		}
 
+		// Sign the attestation message root (validator_id + data).
+		messageRoot, err := (&types.Attestation{ValidatorID: idx, Data: data}).HashTreeRoot()
 		if err != nil {
 			v.Log.Error("attestation failed",
 				"slot", slot,
 				"validator", idx,
-				"err", err,
+				"err", fmt.Errorf("hash attestation: %w", err),
 			)
 			continue
 		}
+		requests = append(requests, request{idx: idx, result: v.sign(idx, kp, uint32(slot), messageRoot)})
+	}
+
+	for _, req := range requests {
+		var res SignResult
+		select {
+		case <-ctx.Done():
+			return
+		case res = <-req.result:
+		}
+		metrics.SigningTime.Observe(res.Duration.Seconds())
+
+		if res.Err != nil {
+			v.Log.Error("attestation failed",
+				"slot", slot,
+				"validator", req.idx,
+				"err", fmt.Errorf("sign attestation: %w", res.Err),
+			)
+			continue
+		}
+
+		sa := &types.SignedAttestation{ValidatorID: req.idx, Message: data}
+		copy(sa.Signature[:], res.Signature)
 
 		// Log signing confirmation.
 		v.Log.Info("attestation signed (XMSS)",
 			"slot", slot,
-			"validator", idx,
+			"validator", req.idx,
 			"sig_size", fmt.Sprintf("%d bytes", len(sa.Signature)),
 			"sig_prefix", hex.EncodeToString(sa.Signature[:8]),
-			"signing_time", signDuration,
+			"signing_time", res.Duration,
 		)
 
 		v.pendingAttestations = append(v.pendingAttestations, sa)
 
 		// Process locally so the vote counts even without gossip self-delivery.
 		v.FC.ProcessAttestation(sa)
+	}
 
+	// Publish in order of each validator's offset into the interval.
+	signed := append([]*types.SignedAttestation(nil), v.pendingAttestations...)
+	sort.SliceStable(signed, func(i, j int) bool {
+		return v.publishOffset(signed[i].ValidatorID) < v.publishOffset(signed[j].ValidatorID)
+	})
+	for _, sa := range signed {
+		if !sleepUntil(ctx, start.Add(v.publishOffset(sa.ValidatorID))) {
+			return
+		}
 		if err := v.PublishAttestation(ctx, v.Topics.Attestation, sa); err != nil {
 			v.Log.Error("failed to publish attestation",
 				"slot", slot,
-				"validator", idx,
+				"validator", sa.ValidatorID,
 				"err", err,
 			)
 		} else {
+			v.outcome(slot).Attested++
 			v.Log.Debug("published attestation",
 				"slot", slot,
-				"validator", idx,
+				"validator", sa.ValidatorID,
 				"target_slot", sa.Message.Target.Slot,
 			)
 		}
@@ -173,6 +334,7 @@ func (v *ValidatorDuties) TryAttest(ctx context.Context, slot uint64) {
 // TryAggregate aggregates pending attestations from interval 1 and publishes
 // the aggregate to the aggregate_attestation gossip topic.
 func (v *ValidatorDuties) TryAggregate(ctx context.Context, slot uint64) {
+	start := time.Now()
 	if len(v.pendingAttestations) == 0 {
 		return
 	}
@@ -197,6 +359,14 @@ func (v *ValidatorDuties) TryAggregate(ctx context.Context, slot uint64) {
 	)
 
 	if v.PublishAggregatedAttestation != nil && v.Topics.AggregateAttestation != nil {
+		// The aggregate is published at the offset of the lowest local index.
+		first := v.pendingAttestations[0].ValidatorID
+		for _, sa := range v.pendingAttestations[1:] {
+			first = min(first, sa.ValidatorID)
+		}
+		if !sleepUntil(ctx, start.Add(v.publishOffset(first))) {
+			return
+		}
 		if err := v.PublishAggregatedAttestation(ctx, v.Topics.AggregateAttestation, agg); err != nil {
 			v.Log.Error("failed to publish aggregated attestation",
 				"slot", slot,
@@ -212,3 +382,42 @@ func (v *ValidatorDuties) TryAggregate(ctx context.Context, slot uint64) {
 
 	v.pendingAttestations = nil
 }
+
+// publishOffset returns validator idx's fixed delay into an interval before
+// publishing, spread over [0, PublishJitter) by a multiplicative hash of the
+// index so consecutive indices land far apart.
+func (v *ValidatorDuties) publishOffset(idx uint64) time.Duration {
+	if v.PublishJitter <= 0 {
+		return 0
+	}
+	h := (idx + 1) * 0x9e3779b97f4a7c15
+	return time.Duration((h >> 32) * uint64(v.PublishJitter) >> 32)
+}
+
+// sleepUntil waits until t, returning false if ctx is cancelled first.
+func sleepUntil(ctx context.Context, t time.Time) bool {
+	d := time.Until(t)
+	if d <= 0 {
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// reportParticipation logs how many validators attested at slot compared to
+// the validators active then.
+func (v *ValidatorDuties) reportParticipation(slot uint64) {
+	expected := v.FC.NumActiveValidators(slot)
+	received := len(v.FC.AttestationsAtSlot(slot))
+	v.Log.Debug("slot participation",
+		"slot", slot,
+		"received", received,
+		"expected", expected,
+	)
+}
@@ -2,35 +2,28 @@ package node_test
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/geanlabs/gean/chain/forkchoice"
 	"github.com/geanlabs/gean/chain/statetransition"
+	"github.com/geanlabs/gean/internal/testutil"
 	"github.com/geanlabs/gean/network/gossipsub"
 	"github.com/geanlabs/gean/node"
 	"github.com/geanlabs/gean/observability/logging"
+	"github.com/geanlabs/gean/observability/metrics"
 	"github.com/geanlabs/gean/storage/memory"
 	"github.com/geanlabs/gean/types"
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 )
 
-type testSigner struct {
-	sig []byte
-}
-
-func (s *testSigner) Sign(epoch uint32, message [32]byte) ([]byte, error) {
-	if s.sig != nil {
-		return s.sig, nil
-	}
-	out := make([]byte, 3112)
-	out[0] = 0xAA
-	return out, nil
-}
-
 func TestValidatorDuties_TryAttest_SignsAndPublishes(t *testing.T) {
 	// Setup
 	numValidators := uint64(3)
-	state := statetransition.GenerateGenesis(1000, makeTestValidators(numValidators))
+	state := statetransition.GenerateGenesis(1000, testutil.Validators(numValidators))
 	emptyBody := &types.BlockBody{Attestations: []*types.Attestation{}}
 	genesisBlock := &types.Block{
 		Slot:          0,
@@ -49,7 +42,7 @@ func TestValidatorDuties_TryAttest_SignsAndPublishes(t *testing.T) {
 	keys := make(map[uint64]forkchoice.Signer)
 	expectedSig := make([]byte, 3112)
 	expectedSig[0] = 0xAA // Marker
-	keys[1] = &testSigner{sig: expectedSig}
+	keys[1] = &testutil.Signer{Sig: expectedSig}
 
 	// Capture published attestation
 	var publishedAtt *types.SignedAttestation
@@ -83,10 +76,69 @@ func TestValidatorDuties_TryAttest_SignsAndPublishes(t *testing.T) {
 	}
 }
 
+func TestValidatorDuties_TryAttest_PublishJitter(t *testing.T) {
+	state := statetransition.GenerateGenesis(1000, testutil.Validators(8))
+	genesisBlock := &types.Block{
+		Slot:          0,
+		ProposerIndex: 0,
+		ParentRoot:    types.ZeroHash,
+		StateRoot:     types.ZeroHash,
+		Body:          &types.BlockBody{Attestations: []*types.Attestation{}},
+	}
+	stateRoot, _ := state.HashTreeRoot()
+	genesisBlock.StateRoot = stateRoot
+
+	fc := forkchoice.NewStore(state, genesisBlock, memory.New())
+	indices := []uint64{2, 3, 4, 5, 6}
+	keys := make(map[uint64]forkchoice.Signer)
+	for _, idx := range indices {
+		keys[idx] = &testutil.Signer{}
+	}
+	var order []uint64
+	var times []time.Time
+	const jitter = 200 * time.Millisecond
+	duties := &node.ValidatorDuties{
+		Indices: indices,
+		Keys:    keys,
+		FC:      fc,
+		Topics:  &gossipsub.Topics{Attestation: &pubsub.Topic{}},
+		PublishAttestation: func(ctx context.Context, topic *pubsub.Topic, sa *types.SignedAttestation) error {
+			order = append(order, sa.ValidatorID)
+			times = append(times, time.Now())
+			return nil
+		},
+		PublishJitter: jitter,
+		Log:           logging.NewComponentLogger(logging.CompValidator),
+	}
+
+	// Slots 0 and 1 are proposed by validators 0 and 1, so all five attest.
+	start := time.Now()
+	duties.TryAttest(context.Background(), 0)
+	if len(order) != len(indices) {
+		t.Fatalf("published %d attestations, want %d", len(order), len(indices))
+	}
+	if elapsed := times[len(times)-1].Sub(start); elapsed >= jitter+100*time.Millisecond {
+		t.Fatalf("last attestation published after %s, want within the %s window", elapsed, jitter)
+	}
+	if times[len(times)-1].Sub(times[0]) < 10*time.Millisecond {
+		t.Fatal("attestations were not spread over the jitter window")
+	}
+
+	// The offsets depend only on the validator index.
+	first := order
+	order, times = nil, nil
+	duties.TryAttest(context.Background(), 1)
+	for i := range first {
+		if order[i] != first[i] {
+			t.Fatalf("publish order changed between slots: %v then %v", first, order)
+		}
+	}
+}
+
 func TestValidatorDuties_TryPropose_SignsAndPublishes(t *testing.T) {
 	// Setup
 	numValidators := uint64(3)
-	state := statetransition.GenerateGenesis(1000, makeTestValidators(numValidators))
+	state := statetransition.GenerateGenesis(1000, testutil.Validators(numValidators))
 	emptyBody := &types.BlockBody{Attestations: []*types.Attestation{}}
 	genesisBlock := &types.Block{
 		Slot:          0,
@@ -105,7 +157,7 @@ func TestValidatorDuties_TryPropose_SignsAndPublishes(t *testing.T) {
 	keys := make(map[uint64]forkchoice.Signer)
 	expectedSig := make([]byte, 3112)
 	expectedSig[0] = 0xBB // Marker
-	keys[1] = &testSigner{sig: expectedSig}
+	keys[1] = &testutil.Signer{Sig: expectedSig}
 
 	// Capture published block
 	var publishedBlock *types.SignedBlockWithAttestation
@@ -142,14 +194,440 @@ func TestValidatorDuties_TryPropose_SignsAndPublishes(t *testing.T) {
 	}
 }
 
+func TestValidatorDuties_TryPropose_NoDoubleProposal(t *testing.T) {
+	numValidators := uint64(3)
+	state := statetransition.GenerateGenesis(1000, testutil.Validators(numValidators))
+	genesisBlock := &types.Block{
+		Slot:          0,
+		ProposerIndex: 0,
+		ParentRoot:    types.ZeroHash,
+		StateRoot:     types.ZeroHash,
+		Body:          &types.BlockBody{Attestations: []*types.Attestation{}},
+	}
+	stateRoot, _ := state.HashTreeRoot()
+	genesisBlock.StateRoot = stateRoot
+
+	fc := forkchoice.NewStore(state, genesisBlock, memory.New())
+	signer := &countingSigner{}
+
+	var published []*types.SignedBlockWithAttestation
+	duties := &node.ValidatorDuties{
+		Indices: []uint64{1},
+		Keys:    map[uint64]forkchoice.Signer{1: signer},
+		FC:      fc,
+		Topics:  &gossipsub.Topics{Block: &pubsub.Topic{}},
+		PublishBlock: func(ctx context.Context, topic *pubsub.Topic, sb *types.SignedBlockWithAttestation) error {
+			published = append(published, sb)
+			return nil
+		},
+		Log: logging.NewComponentLogger(logging.CompValidator),
+	}
+
+	// The duty fires twice for slot 1 (e.g. clock hiccup).
+	duties.TryPropose(context.Background(), 1)
+	duties.TryPropose(context.Background(), 1)
+
+	if len(published) != 2 {
+		t.Fatalf("published %d envelopes, want 2", len(published))
+	}
+	first, _ := published[0].Message.Block.HashTreeRoot()
+	second, _ := published[1].Message.Block.HashTreeRoot()
+	if first != second {
+		t.Fatal("second proposal produced a different block")
+	}
+	if signer.calls != 1 {
+		t.Fatalf("signer called %d times, want 1", signer.calls)
+	}
+}
+
+func TestValidatorDuties_TryPropose_PublishesAttestationOnBlockFailure(t *testing.T) {
+	numValidators := uint64(3)
+	state := statetransition.GenerateGenesis(1000, testutil.Validators(numValidators))
+	genesisBlock := &types.Block{
+		Slot:          0,
+		ProposerIndex: 0,
+		ParentRoot:    types.ZeroHash,
+		StateRoot:     types.ZeroHash,
+		Body:          &types.BlockBody{Attestations: []*types.Attestation{}},
+	}
+	stateRoot, _ := state.HashTreeRoot()
+	genesisBlock.StateRoot = stateRoot
+
+	fc := forkchoice.NewStore(state, genesisBlock, memory.New())
+	signer := &countingSigner{}
+
+	var published []*types.SignedAttestation
+	duties := &node.ValidatorDuties{
+		Indices: []uint64{1},
+		Keys:    map[uint64]forkchoice.Signer{1: signer},
+		FC:      fc,
+		Topics:  &gossipsub.Topics{Block: &pubsub.Topic{}, Attestation: &pubsub.Topic{}},
+		PublishBlock: func(ctx context.Context, topic *pubsub.Topic, sb *types.SignedBlockWithAttestation) error {
+			return errors.New("no peers in mesh")
+		},
+		PublishAttestation: func(ctx context.Context, topic *pubsub.Topic, sa *types.SignedAttestation) error {
+			published = append(published, sa)
+			return nil
+		},
+		Log: logging.NewComponentLogger(logging.CompValidator),
+	}
+
+	duties.TryPropose(context.Background(), 1)
+
+	if len(published) != 1 {
+		t.Fatalf("published %d attestations, want 1", len(published))
+	}
+	sa := published[0]
+	if sa.ValidatorID != 1 || sa.Message.Slot != 1 {
+		t.Fatalf("attestation validator=%d slot=%d, want validator=1 slot=1", sa.ValidatorID, sa.Message.Slot)
+	}
+	// The proposer attestation signature is reused rather than signing again.
+	if signer.calls != 1 {
+		t.Fatalf("signer called %d times, want 1", signer.calls)
+	}
+	if got := duties.Outcome(1); got.Proposed || got.Attested != 1 {
+		t.Fatalf("outcome = %+v, want attested without a proposal", got)
+	}
+}
+
+func TestValidatorDuties_CircuitBreaker(t *testing.T) {
+	state := statetransition.GenerateGenesis(1000, testutil.Validators(3))
+	genesisBlock := &types.Block{
+		Slot:          0,
+		ProposerIndex: 0,
+		ParentRoot:    types.ZeroHash,
+		StateRoot:     types.ZeroHash,
+		Body:          &types.BlockBody{Attestations: []*types.Attestation{}},
+	}
+	stateRoot, _ := state.HashTreeRoot()
+	genesisBlock.StateRoot = stateRoot
+
+	fc := forkchoice.NewStore(state, genesisBlock, memory.New())
+	signer := &failingSigner{fail: true}
+	published := 0
+	duties := &node.ValidatorDuties{
+		Indices: []uint64{1},
+		Keys:    map[uint64]forkchoice.Signer{1: signer},
+		FC:      fc,
+		Topics:  &gossipsub.Topics{Attestation: &pubsub.Topic{}},
+		PublishAttestation: func(ctx context.Context, topic *pubsub.Topic, sa *types.SignedAttestation) error {
+			published++
+			return nil
+		},
+		Log: logging.NewComponentLogger(logging.CompValidator),
+	}
+
+	// Validator 1 proposes at slots 1, 4, 7, ...; attest at the others.
+	attestSlots := []uint64{0, 2, 3, 5, 6, 8, 9}
+	for _, slot := range attestSlots[:5] {
+		duties.TryAttest(context.Background(), slot)
+	}
+	if !duties.DutiesDisabled(1) {
+		t.Fatalf("duties not disabled after %d signing failures", signer.calls)
+	}
+	if got := duties.DisabledValidators(); len(got) != 1 || got[0] != 1 {
+		t.Fatalf("DisabledValidators = %v, want [1]", got)
+	}
+
+	// Disabled duties do not touch the key.
+	calls := signer.calls
+	duties.TryAttest(context.Background(), attestSlots[5])
+	if signer.calls != calls {
+		t.Fatal("disabled validator still signed")
+	}
+
+	// Re-enabled duties sign again and a success resets the failure count.
+	signer.fail = false
+	if !duties.EnableDuties(1) {
+		t.Fatal("EnableDuties reported validator was not disabled")
+	}
+	duties.TryAttest(context.Background(), attestSlots[6])
+	if published != 1 || duties.DutiesDisabled(1) {
+		t.Fatalf("published=%d disabled=%v after re-enable, want 1 and false", published, duties.DutiesDisabled(1))
+	}
+}
+
 // Helpers
-func makeTestValidators(n uint64) []*types.Validator {
-	vals := make([]*types.Validator, n)
-	for i := uint64(0); i < n; i++ {
-		vals[i] = &types.Validator{
-			Pubkey: [52]byte{},
-			Index:  i,
-		}
+type countingSigner struct {
+	calls int
+}
+
+func (s *countingSigner) Sign(epoch uint32, message [32]byte) ([]byte, error) {
+	s.calls++
+	return make([]byte, 3112), nil
+}
+
+type failingSigner struct {
+	calls int
+	fail  bool
+}
+
+func (s *failingSigner) Sign(epoch uint32, message [32]byte) ([]byte, error) {
+	s.calls++
+	if s.fail {
+		return nil, errors.New("epoch not prepared")
+	}
+	return make([]byte, 3112), nil
+}
+
+type windowSigner struct {
+	testutil.Signer
+	end uint64
+}
+
+func (s *windowSigner) PreparedEnd() uint64 { return s.end }
+
+func TestValidatorDuties_UpdateKeyHeadroom(t *testing.T) {
+	duties := &node.ValidatorDuties{
+		Indices: []uint64{7},
+		Keys:    map[uint64]forkchoice.Signer{7: &windowSigner{end: 100}},
+		Log:     logging.NewComponentLogger(logging.CompValidator),
+	}
+
+	duties.UpdateKeyHeadroom(40)
+	if got := gaugeValue(metrics.ValidatorKeyEpochsRemaining.WithLabelValues("7")); got != 60 {
+		t.Fatalf("epochs remaining = %v, want 60", got)
+	}
+	if got := gaugeValue(metrics.ValidatorKeyPreparedEndEpoch.WithLabelValues("7")); got != 100 {
+		t.Fatalf("prepared end = %v, want 100", got)
+	}
+
+	duties.UpdateKeyHeadroom(150)
+	if got := gaugeValue(metrics.ValidatorKeyEpochsRemaining.WithLabelValues("7")); got != 0 {
+		t.Fatalf("epochs remaining past window = %v, want 0", got)
+	}
+}
+
+// preparableSigner advances its window by 8 epochs per call and records
+// the epochs it signs at.
+type preparableSigner struct {
+	testutil.Signer
+	start, end uint64
+	signed     []uint32
+}
+
+func (s *preparableSigner) PreparedStart() uint64 { return s.start }
+func (s *preparableSigner) PreparedEnd() uint64   { return s.end }
+
+func (s *preparableSigner) AdvancePreparation() error {
+	s.start += 8
+	s.end += 8
+	return nil
+}
+
+func (s *preparableSigner) Sign(epoch uint32, message [32]byte) ([]byte, error) {
+	s.signed = append(s.signed, epoch)
+	return s.Signer.Sign(epoch, message)
+}
+
+func TestValidatorDuties_WarmupKeys(t *testing.T) {
+	key := &preparableSigner{start: 0, end: 16}
+	duties := &node.ValidatorDuties{
+		Indices: []uint64{0},
+		Keys:    map[uint64]forkchoice.Signer{0: key},
+		Log:     logging.NewComponentLogger(logging.CompValidator),
+	}
+
+	duties.WarmupKeys(30)
+	if key.start > 30 || key.end <= 30 {
+		t.Fatalf("prepared window [%d, %d) does not cover epoch 30", key.start, key.end)
+	}
+	if len(key.signed) != 1 || key.signed[0] != 29 {
+		t.Fatalf("warmup signed at %v, want [29]", key.signed)
+	}
+
+	// Pre-genesis: no past epoch to sign at, so no signature is produced.
+	fresh := &preparableSigner{start: 0, end: 16}
+	duties.Keys[0] = fresh
+	duties.WarmupKeys(0)
+	if len(fresh.signed) != 0 {
+		t.Fatalf("warmup signed at %v before genesis, want none", fresh.signed)
+	}
+}
+
+// stuckSigner is a preparable key at the end of its activation.
+type stuckSigner struct {
+	preparableSigner
+}
+
+func (s *stuckSigner) AdvancePreparation() error { return nil }
+
+func TestValidatorDuties_PrepareKeys(t *testing.T) {
+	key := &preparableSigner{start: 0, end: 16}
+	stuck := &stuckSigner{preparableSigner{start: 0, end: 16}}
+	svc := node.NewSigningService(2)
+	defer svc.Close()
+	duties := &node.ValidatorDuties{
+		Indices: []uint64{0, 1},
+		Keys:    map[uint64]forkchoice.Signer{0: key, 1: stuck},
+		Log:     logging.NewComponentLogger(logging.CompValidator),
+		Signing: svc,
+	}
+	exhausted := metrics.ValidatorKeyPreparations.WithLabelValues("1", "exhausted")
+	before := testutil.CounterValue(t, exhausted)
+
+	// Up to one slot past the middle of the window nothing moves.
+	if n := duties.PrepareKeys(8); n != 0 || key.start != 0 {
+		t.Fatalf("advanced %d keys at slot 8, window [%d, %d)", n, key.start, key.end)
+	}
+	if n := duties.PrepareKeys(9); n != 1 || key.start != 8 || key.end != 24 {
+		t.Fatalf("advanced %d keys at slot 9, window [%d, %d), want 1 and [8, 24)", n, key.start, key.end)
+	}
+	if n := duties.PrepareKeys(9); n != 0 || key.start != 8 {
+		t.Fatalf("advanced %d keys again at slot 9, window [%d, %d)", n, key.start, key.end)
+	}
+
+	// An exhausted key is reported once.
+	duties.PrepareKeys(20)
+	if got := testutil.CounterValue(t, exhausted) - before; got != 1 {
+		t.Fatalf("exhausted reported %v times, want 1", got)
+	}
+}
+
+// activationSigner is a preparableSigner with an activation interval; its
+// window does not advance past the end of the activation.
+type activationSigner struct {
+	preparableSigner
+	activationStart, activationEnd uint64
+}
+
+func (s *activationSigner) ActivationStart() uint64 { return s.activationStart }
+func (s *activationSigner) ActivationEnd() uint64   { return s.activationEnd }
+
+func (s *activationSigner) AdvancePreparation() error {
+	if s.end+8 <= s.activationEnd {
+		return s.preparableSigner.AdvancePreparation()
+	}
+	return nil
+}
+
+func TestKeySet_SignsWithKeyActiveAtSlot(t *testing.T) {
+	current := &activationSigner{preparableSigner{start: 0, end: 16}, 0, 16}
+	next := &activationSigner{preparableSigner{start: 16, end: 32}, 16, 48}
+	ks, err := node.NewKeySet(next, current)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ks.Sign(5, [32]byte{}); err != nil {
+		t.Fatal(err)
+	}
+	if ks.PreparedEnd() != 16 {
+		t.Fatalf("prepared end = %d before rotation, want the current key's 16", ks.PreparedEnd())
+	}
+	if _, err := ks.Sign(20, [32]byte{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(current.signed) != 1 || current.signed[0] != 5 || len(next.signed) != 1 || next.signed[0] != 20 {
+		t.Fatalf("current signed %v, next signed %v, want [5] and [20]", current.signed, next.signed)
+	}
+	if ks.PreparedEnd() != 32 {
+		t.Fatalf("prepared end = %d after rotation, want the replacement's 32", ks.PreparedEnd())
+	}
+	if key, ok := ks.KeyFor(10); !ok || key != current {
+		t.Fatal("slot 10 not mapped to the current key")
+	}
+
+	if _, err := ks.Sign(48, [32]byte{}); !errors.Is(err, node.ErrNoKeyForSlot) {
+		t.Fatalf("err = %v, want ErrNoKeyForSlot", err)
+	}
+}
+
+func TestKeySet_RejectsOverlappingActivations(t *testing.T) {
+	current := &activationSigner{preparableSigner{start: 0, end: 16}, 0, 32}
+	next := &activationSigner{preparableSigner{start: 16, end: 32}, 16, 48}
+	if _, err := node.NewKeySet(next, current); !errors.Is(err, node.ErrOverlappingKeys) {
+		t.Fatalf("err = %v, want ErrOverlappingKeys", err)
+	}
+}
+
+func TestKeySet_PreparesReplacementOnceCurrentIsExhausted(t *testing.T) {
+	current := &activationSigner{preparableSigner{start: 0, end: 16}, 0, 16}
+	next := &activationSigner{preparableSigner{start: 16, end: 32}, 16, 48}
+	ks, err := node.NewKeySet(current, next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	duties := &node.ValidatorDuties{
+		Indices: []uint64{0},
+		Keys:    map[uint64]forkchoice.Signer{0: ks},
+		Log:     logging.NewComponentLogger(logging.CompValidator),
+	}
+
+	// The current key cannot advance, so the replacement takes over
+	// preparation while the current key still signs slot 12.
+	if n := duties.PrepareKeys(12); n != 1 {
+		t.Fatalf("advanced %d keys, want 1", n)
+	}
+	if _, err := duties.Keys[0].Sign(12, [32]byte{}); err != nil {
+		t.Fatalf("current key no longer signs its last slots: %v", err)
+	}
+	if n := duties.PrepareKeys(25); n != 1 || next.end != 40 {
+		t.Fatalf("advanced %d keys, replacement window ends at %d, want 1 and 40", n, next.end)
+	}
+}
+
+func gaugeValue(g prometheus.Gauge) float64 {
+	var m dto.Metric
+	if err := g.Write(&m); err != nil {
+		return -1
+	}
+	return m.GetGauge().GetValue()
+}
+
+// freeingSigner records when its handle is released.
+type freeingSigner struct {
+	testutil.Signer
+	freed bool
+}
+
+func (s *freeingSigner) Free() { s.freed = true }
+
+func TestValidatorDuties_AddRemoveKey(t *testing.T) {
+	state := statetransition.GenerateGenesis(1000, testutil.Validators(3))
+	genesisBlock := &types.Block{Body: &types.BlockBody{Attestations: []*types.Attestation{}}}
+	genesisBlock.StateRoot, _ = state.HashTreeRoot()
+	duties := &node.ValidatorDuties{
+		Indices: []uint64{2},
+		Keys:    map[uint64]forkchoice.Signer{2: &testutil.Signer{}},
+		FC:      forkchoice.NewStore(state, genesisBlock, memory.New()),
+		Log:     logging.NewComponentLogger(logging.CompValidator),
+		Signing: node.NewSigningService(1),
+	}
+	defer duties.Signing.Close()
+
+	key := &freeingSigner{}
+	if !duties.AddKey(0, key) {
+		t.Fatal("AddKey rejected a new validator")
+	}
+	if duties.AddKey(0, &testutil.Signer{}) {
+		t.Fatal("AddKey replaced a loaded key")
+	}
+	if len(duties.Indices) != 2 || duties.Indices[0] != 0 || duties.Indices[1] != 2 {
+		t.Fatalf("Indices = %v, want [0 2]", duties.Indices)
+	}
+	if keys := duties.ValidatorKeys(); len(keys) != 2 || len(keys[0]) != types.XMSSPubkeySize {
+		t.Fatalf("ValidatorKeys = %v, want registered keys of 0 and 2", keys)
+	}
+
+	// The new validator proposes slot 3, which is validator 0's slot.
+	if !duties.HasProposal(3) {
+		t.Fatal("added validator has no proposal duty")
+	}
+
+	if !duties.RemoveKey(0) {
+		t.Fatal("RemoveKey did not find the added key")
+	}
+	if duties.RemoveKey(0) {
+		t.Fatal("RemoveKey removed a key twice")
+	}
+	if !key.freed {
+		t.Fatal("removed key was not freed")
+	}
+	if duties.HasProposal(3) {
+		t.Fatal("removed validator still has a proposal duty")
+	}
+	if len(duties.Indices) != 1 || duties.Indices[0] != 2 {
+		t.Fatalf("Indices = %v, want [2]", duties.Indices)
 	}
-	return vals
 }
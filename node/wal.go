@@ -0,0 +1,119 @@
+package node
+
+import (
+	"github.com/geanlabs/gean/network/gossipsub"
+	"github.com/geanlabs/gean/storage/wal"
+	"github.com/geanlabs/gean/types"
+)
+
+// gossipWALFile, under the data directory, logs gossip messages before they
+// are processed. The log is rotated every gossipWALRotateSlots slots and
+// keeps the previous generation, so it holds the messages of the last
+// gossipWALRotateSlots to 2*gossipWALRotateSlots slots.
+const (
+	gossipWALFile        = "gossip.wal"
+	gossipWALRotateSlots = 4
+)
+
+// logGossip appends a received gossip message to the write-ahead log.
+func (n *Node) logGossip(kind wal.Kind, msg any) {
+	if n.wal == nil {
+		return
+	}
+	var payload []byte
+	var err error
+	switch m := msg.(type) {
+	case *types.SignedBlockWithAttestation:
+		payload, err = m.MarshalSSZ()
+	case *types.SignedAttestation:
+		payload, err = m.MarshalSSZ()
+	case *types.AggregatedAttestation:
+		payload, err = gossipsub.EncodeAggregatedAttestation(m)
+	}
+	if err == nil {
+		err = n.wal.Append(kind, payload)
+	}
+	if err != nil {
+		n.log.Warn("failed to log gossip message", "kind", kind, "err", err)
+	}
+}
+
+// replayGossipWAL feeds the messages logged before the last shutdown or
+// crash back into fork choice. Messages that were already processed are
+// known blocks or superseded votes and are ignored.
+func (n *Node) replayGossipWAL() {
+	if n.wal == nil {
+		return
+	}
+	var blocks, votes, skipped int
+	// Runs of attestations are applied as one batch, flushed before the next
+	// block or aggregate so the log order is kept.
+	var batch []*types.SignedAttestation
+	flush := func() {
+		n.FC.ProcessAttestations(batch)
+		batch = batch[:0]
+	}
+	err := n.wal.Replay(func(kind wal.Kind, payload []byte) error {
+		if kind != wal.KindAttestation {
+			flush()
+		}
+		switch kind {
+		case wal.KindBlock:
+			sb := new(types.SignedBlockWithAttestation)
+			if err := sb.UnmarshalSSZ(payload); err != nil {
+				skipped++
+				return nil
+			}
+			if err := n.FC.ProcessBlock(sb); err != nil {
+				n.log.Debug("skipping logged block", "slot", sb.Message.Block.Slot, "err", err)
+				skipped++
+				return nil
+			}
+			blocks++
+		case wal.KindAttestation:
+			sa := new(types.SignedAttestation)
+			if err := sa.UnmarshalSSZ(payload); err != nil {
+				skipped++
+				return nil
+			}
+			if n.admitAttestation(sa) {
+				batch = append(batch, sa)
+			}
+			votes++
+		case wal.KindAggregate:
+			agg, err := gossipsub.DecodeAggregatedAttestation(payload)
+			if err != nil {
+				skipped++
+				return nil
+			}
+			n.FC.ProcessAggregatedAttestation(agg)
+			votes++
+		default:
+			skipped++
+		}
+		return nil
+	})
+	flush()
+	if err != nil {
+		n.log.Warn("failed to replay gossip log", "err", err)
+		return
+	}
+	if blocks+votes+skipped > 0 {
+		n.log.Info("replayed gossip log",
+			"blocks", blocks,
+			"attestations", votes,
+			"skipped", skipped,
+		)
+	}
+}
+
+// rotateGossipWAL starts a new log generation every gossipWALRotateSlots
+// slots.
+func (n *Node) rotateGossipWAL(slot uint64) {
+	if n.wal == nil || slot%gossipWALRotateSlots != 0 {
+		return
+	}
+	if err := n.wal.Rotate(); err != nil {
+		n.log.Warn("failed to rotate gossip log", "err", err)
+	}
+}
@@ -20,6 +20,9 @@ const (
 	CompGossip     = "gossip"
 	CompReqResp    = "reqresp"
 	CompMetrics    = "metrics"
+	CompAPI        = "api"
+	CompStorage    = "storage"
+	CompCrypto     = "crypto"
 )
 
 // ANSI color codes.
@@ -13,6 +13,10 @@ import (
 var (
 	fastBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 1}
 	stfBuckets  = []float64{0.25, 0.5, 0.75, 1, 1.25, 1.5, 2, 2.5, 3, 4}
+	// storageBuckets spans in-memory hits through slow disk reads.
+	storageBuckets = []float64{0.00001, 0.0001, 0.001, 0.005, 0.01, 0.05, 0.25, 1}
+	// reorgBuckets counts blocks.
+	reorgBuckets = []float64{1, 2, 3, 4, 8, 16, 32, 64}
 )
 
 // --- Node Info ---
@@ -27,6 +31,11 @@ var NodeStartTime = prometheus.NewGauge(prometheus.GaugeOpts{
 	Help: "Start timestamp",
 })
 
+var MemorySheds = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "lean_memory_sheds_total",
+	Help: "Times caches were emptied because the heap neared --max-memory",
+})
+
 // --- Fork-Choice ---
 
 var HeadSlot = prometheus.NewGauge(prometheus.GaugeOpts{
@@ -50,6 +59,73 @@ var ForkChoiceBlockProcessingTime = prometheus.NewHistogram(prometheus.Histogram
 	Buckets: fastBuckets,
 })
 
+var StorageCorruptEntries = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "lean_storage_corrupt_entries_total",
+	Help: "Database entries that failed to decode or verify and were quarantined, by key prefix (b block, e signed block, s state)",
+}, []string{"kind"})
+
+var StorageOperationTime = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "lean_storage_operation_seconds",
+	Help:    "Latency of chain storage operations, by op (get, put, commit) and kind (block, signed_block, state, batch)",
+	Buckets: storageBuckets,
+}, []string{"op", "kind"})
+
+var StorageBlocks = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "lean_storage_blocks",
+	Help: "Blocks held in chain storage, including archived ones",
+})
+
+var StorageStates = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "lean_storage_states",
+	Help: "States held in chain storage",
+})
+
+var StorageDiskBytes = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "lean_storage_disk_bytes",
+	Help: "On-disk size of chain storage (0 for the memory backend)",
+})
+
+var ForkChoiceStateCache = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "lean_fork_choice_state_cache_total",
+	Help: "Fork choice state cache lookups, by result (hit, miss)",
+}, []string{"result"})
+
+var SignatureCache = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "lean_signature_cache_total",
+	Help: "Verified-signature cache lookups before XMSS verification, by result (hit, miss)",
+}, []string{"result"})
+
+var CheckpointViolations = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "lean_fork_choice_checkpoint_violations_total",
+	Help: "Block imports after which a checkpoint was missing from storage or not on the head's chain, by checkpoint (justified, finalized)",
+}, []string{"checkpoint"})
+
+var ForkChoicePruned = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "lean_fork_choice_pruned_total",
+	Help: "Entries removed from fork choice storage below the finalized checkpoint, by kind (block, state, archived)",
+}, []string{"kind"})
+
+var ForkChoiceHeadFallbacks = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "lean_fork_choice_head_fallbacks_total",
+	Help: "Head updates that did not walk a viable branch from the justified checkpoint, by reason (no_viable_branch, justified_unknown)",
+}, []string{"reason"})
+
+var Reorgs = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "lean_fork_choice_reorgs_total",
+	Help: "Head changes to a block that does not descend from the previous head",
+})
+
+var ReorgDepth = prometheus.NewHistogram(prometheus.HistogramOpts{
+	Name:    "lean_fork_choice_reorg_depth",
+	Help:    "Blocks of the previous head's chain dropped by a reorg",
+	Buckets: reorgBuckets,
+})
+
+var Equivocations = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "lean_equivocations_total",
+	Help: "Equivocations detected, by kind (proposer, attestation)",
+}, []string{"kind"})
+
 var AttestationsValid = prometheus.NewCounter(prometheus.CounterOpts{
 	Name: "lean_attestations_valid_total",
 	Help: "Total number of valid attestations",
@@ -60,6 +136,11 @@ var AttestationsInvalid = prometheus.NewCounter(prometheus.CounterOpts{
 	Help: "Total number of invalid attestations",
 })
 
+var AttestationsDropped = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "lean_attestations_dropped_total",
+	Help: "Attestations not applied to fork choice, by validation result (ignore, reject) and reason",
+}, []string{"result", "reason"})
+
 var AttestationValidationTime = prometheus.NewHistogram(prometheus.HistogramOpts{
 	Name:    "lean_attestation_validation_time_seconds",
 	Help:    "Time taken to validate attestation",
@@ -119,6 +200,36 @@ var ValidatorsCount = prometheus.NewGauge(prometheus.GaugeOpts{
 	Help: "Number of validators managed by a node",
 })
 
+var ValidatorKeyPreparedEndEpoch = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "lean_validator_key_prepared_end_epoch",
+	Help: "End (exclusive) of the prepared signing window of a validator key",
+}, []string{"validator"})
+
+var ValidatorKeyEpochsRemaining = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "lean_validator_key_epochs_remaining",
+	Help: "Epochs left in the prepared signing window of a validator key",
+}, []string{"validator"})
+
+var ValidatorDutiesDisabled = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "lean_validator_duties_disabled",
+	Help: "1 if a validator's duties are disabled after repeated signing failures",
+}, []string{"validator"})
+
+var ValidatorSigningFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "lean_validator_signing_failures_total",
+	Help: "Total signing failures per validator",
+}, []string{"validator"})
+
+var ValidatorKeyPreparations = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "lean_validator_key_preparations_total",
+	Help: "Automatic advances of a validator key's prepared signing window, by result",
+}, []string{"validator", "result"})
+
+var SigningQueueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "lean_validator_signing_queue_depth",
+	Help: "Signing requests waiting for a signing worker",
+})
+
 // --- Network ---
 
 var ConnectedPeers = prometheus.NewGauge(prometheus.GaugeOpts{
@@ -126,6 +237,76 @@ var ConnectedPeers = prometheus.NewGauge(prometheus.GaugeOpts{
 	Help: "Number of connected peers",
 })
 
+var PeersByProtocol = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "lean_peers_by_protocol",
+	Help: "Number of identified peers supporting each req/resp protocol",
+}, []string{"protocol"})
+
+var PeersByClient = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "lean_peers_by_client",
+	Help: "Connected peers by client implementation and version, from libp2p identify",
+}, []string{"client", "version"})
+
+var PeersByDirection = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "lean_peers_by_direction",
+	Help: "Connected peers by connection direction (inbound, outbound)",
+}, []string{"direction"})
+
+var PeersPruned = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "lean_peers_pruned_total",
+	Help: "Peers disconnected to bring the peer count back under the target",
+})
+
+var PeerGoodbyes = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "lean_peer_goodbyes_total",
+	Help: "Goodbye messages by direction (sent, received) and reason",
+}, []string{"direction", "reason"})
+
+var PeerHandshakes = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "lean_peer_handshakes_total",
+	Help: "Status handshakes with newly connected peers by result (ok, irrelevant, failed)",
+}, []string{"result"})
+
+var ReqRespRateLimited = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "lean_reqresp_rate_limited_total",
+	Help: "Incoming req/resp requests refused for exceeding a per-peer or global quota, by protocol",
+}, []string{"protocol"})
+
+var GossipBlocksDeferred = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "lean_gossip_blocks_deferred_total",
+	Help: "Gossip blocks for past slots moved to the background import queue",
+})
+
+var GossipBlocksDropped = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "lean_gossip_blocks_dropped_total",
+	Help: "Gossip blocks dropped because the background import queue was full",
+})
+
+var GossipBlocksRejected = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "lean_gossip_blocks_rejected_total",
+	Help: "Gossip blocks that failed import, by state transition error code, unknown_parent or other",
+}, []string{"code"})
+
+var GossipAttestationsShed = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "lean_gossip_attestations_shed_total",
+	Help: "Gossip attestations dropped before verification because a newer vote from the validator is known",
+})
+
+var LeansigKeypairs = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "lean_leansig_keypairs",
+	Help: "leansig keypair handles allocated and not yet freed",
+})
+
+var LeansigBuffers = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "lean_leansig_buffers",
+	Help: "Byte buffers returned by leansig and not yet freed",
+})
+
+var LeansigKeypairsLeaked = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "lean_leansig_keypairs_leaked_total",
+	Help: "leansig keypair handles released by the garbage collector because Free was never called",
+})
+
 // --- Devnet-1 Baseline Metrics ---
 
 var SignatureVerificationTime = prometheus.NewHistogram(prometheus.HistogramOpts{
@@ -150,13 +331,28 @@ func init() {
 		// Node info
 		NodeInfo,
 		NodeStartTime,
+		MemorySheds,
 		// Fork choice
 		HeadSlot,
 		CurrentSlot,
 		SafeTargetSlot,
 		ForkChoiceBlockProcessingTime,
+		ForkChoicePruned,
+		ForkChoiceHeadFallbacks,
+		CheckpointViolations,
+		ForkChoiceStateCache,
+		SignatureCache,
+		Reorgs,
+		ReorgDepth,
+		StorageCorruptEntries,
+		StorageOperationTime,
+		StorageBlocks,
+		StorageStates,
+		StorageDiskBytes,
+		Equivocations,
 		AttestationsValid,
 		AttestationsInvalid,
+		AttestationsDropped,
 		AttestationValidationTime,
 		// State transition
 		LatestJustifiedSlot,
@@ -169,8 +365,29 @@ func init() {
 		STFAttestationsProcessingTime,
 		// Validator
 		ValidatorsCount,
+		ValidatorKeyPreparedEndEpoch,
+		ValidatorKeyEpochsRemaining,
+		ValidatorDutiesDisabled,
+		ValidatorSigningFailures,
+		ValidatorKeyPreparations,
+		SigningQueueDepth,
 		// Network
 		ConnectedPeers,
+		PeersByProtocol,
+		PeersByClient,
+		PeersByDirection,
+		PeersPruned,
+		PeerGoodbyes,
+		ReqRespRateLimited,
+		PeerHandshakes,
+		GossipBlocksDeferred,
+		GossipBlocksDropped,
+		GossipBlocksRejected,
+		GossipAttestationsShed,
+		// leansig
+		LeansigKeypairs,
+		LeansigBuffers,
+		LeansigKeypairsLeaked,
 		// Devnet-1 baselines
 		SignatureVerificationTime,
 		SigningTime,
@@ -0,0 +1,205 @@
+// Package slothistory keeps a fixed-size on-disk ring buffer of per-slot
+// summaries, giving operators local history beyond metrics retention.
+package slothistory
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+)
+
+// DefaultCapacity is the number of slots a new table keeps.
+const DefaultCapacity = 8192
+
+// FileName is the table's file name within the data directory.
+const FileName = "slot_history"
+
+const (
+	recordSize = 80
+	headerSize = 16
+)
+
+var magic = [8]byte{'g', 'e', 'a', 'n', 's', 'l', 'o', 't'}
+
+// Record flags.
+const (
+	FlagLocalProposer    = 1 << iota // a local validator was the proposer
+	FlagLocalProposed                // the local proposer published a block
+	FlagBlock                        // the canonical chain has a block at the slot
+	FlagJustifiedChanged             // the justified checkpoint moved since the previous record
+	FlagFinalizedChanged             // the finalized checkpoint moved since the previous record
+)
+
+// Record summarizes one slot as seen by the node at its end.
+type Record struct {
+	Slot          uint64
+	HeadRoot      [32]byte
+	HeadSlot      uint64
+	Proposer      uint64
+	Flags         uint8
+	LocalAttested uint16 // local validators that published an attestation
+	JustifiedSlot uint64
+	FinalizedSlot uint64
+}
+
+// Has reports whether all bits of flag are set.
+func (r Record) Has(flag uint8) bool {
+	return r.Flags&flag == flag
+}
+
+func (r Record) encode(buf []byte) {
+	binary.LittleEndian.PutUint64(buf[0:8], r.Slot+1) // zero marks an empty entry
+	copy(buf[8:40], r.HeadRoot[:])
+	binary.LittleEndian.PutUint64(buf[40:48], r.HeadSlot)
+	binary.LittleEndian.PutUint64(buf[48:56], r.Proposer)
+	buf[56] = r.Flags
+	binary.LittleEndian.PutUint16(buf[57:59], r.LocalAttested)
+	binary.LittleEndian.PutUint64(buf[59:67], r.JustifiedSlot)
+	binary.LittleEndian.PutUint64(buf[67:75], r.FinalizedSlot)
+}
+
+func decodeRecord(buf []byte) (Record, bool) {
+	marker := binary.LittleEndian.Uint64(buf[0:8])
+	if marker == 0 {
+		return Record{}, false
+	}
+	r := Record{
+		Slot:          marker - 1,
+		HeadSlot:      binary.LittleEndian.Uint64(buf[40:48]),
+		Proposer:      binary.LittleEndian.Uint64(buf[48:56]),
+		Flags:         buf[56],
+		LocalAttested: binary.LittleEndian.Uint16(buf[57:59]),
+		JustifiedSlot: binary.LittleEndian.Uint64(buf[59:67]),
+		FinalizedSlot: binary.LittleEndian.Uint64(buf[67:75]),
+	}
+	copy(r.HeadRoot[:], buf[8:40])
+	return r, true
+}
+
+// Table is a ring buffer of Records in a file, indexed by slot modulo its
+// capacity.
+type Table struct {
+	mu       sync.Mutex
+	f        *os.File
+	capacity uint64
+	last     Record
+	hasLast  bool
+}
+
+// Open opens the table at path, creating it with the given capacity if it
+// does not exist. An existing table keeps the capacity it was created with.
+func Open(path string, capacity int) (*Table, error) {
+	if capacity <= 0 {
+		return nil, fmt.Errorf("invalid capacity %d", capacity)
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	t := &Table{f: f}
+
+	var hdr [headerSize]byte
+	_, err = io.ReadFull(f, hdr[:])
+	switch {
+	case errors.Is(err, io.EOF):
+		copy(hdr[:8], magic[:])
+		binary.LittleEndian.PutUint64(hdr[8:], uint64(capacity))
+		if _, err := f.WriteAt(hdr[:], 0); err != nil {
+			f.Close()
+			return nil, err
+		}
+		if err := f.Truncate(headerSize + int64(capacity)*recordSize); err != nil {
+			f.Close()
+			return nil, err
+		}
+		t.capacity = uint64(capacity)
+	case err != nil:
+		f.Close()
+		return nil, fmt.Errorf("read header: %w", err)
+	case !bytes.Equal(hdr[:8], magic[:]):
+		f.Close()
+		return nil, fmt.Errorf("%s is not a slot history table", path)
+	default:
+		t.capacity = binary.LittleEndian.Uint64(hdr[8:])
+	}
+
+	records, err := t.readAll()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if n := len(records); n > 0 {
+		t.last, t.hasLast = records[n-1], true
+	}
+	return t, nil
+}
+
+// Put stores r, replacing whatever occupied its entry, and sets the
+// checkpoint change flags by comparing with the previous record.
+func (t *Table) Put(r Record) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	r.Flags &^= FlagJustifiedChanged | FlagFinalizedChanged
+	if t.hasLast && t.last.Slot < r.Slot {
+		if r.JustifiedSlot != t.last.JustifiedSlot {
+			r.Flags |= FlagJustifiedChanged
+		}
+		if r.FinalizedSlot != t.last.FinalizedSlot {
+			r.Flags |= FlagFinalizedChanged
+		}
+	}
+
+	var buf [recordSize]byte
+	r.encode(buf[:])
+	if _, err := t.f.WriteAt(buf[:], t.offset(r.Slot)); err != nil {
+		return err
+	}
+	if !t.hasLast || r.Slot >= t.last.Slot {
+		t.last, t.hasLast = r, true
+	}
+	return nil
+}
+
+// Last returns up to n of the most recent records in ascending slot order.
+func (t *Table) Last(n int) ([]Record, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	records, err := t.readAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) > n {
+		records = records[len(records)-n:]
+	}
+	return records, nil
+}
+
+// Close closes the underlying file.
+func (t *Table) Close() error {
+	return t.f.Close()
+}
+
+func (t *Table) offset(slot uint64) int64 {
+	return headerSize + int64(slot%t.capacity)*recordSize
+}
+
+func (t *Table) readAll() ([]Record, error) {
+	data := make([]byte, t.capacity*recordSize)
+	if _, err := t.f.ReadAt(data, headerSize); err != nil {
+		return nil, fmt.Errorf("read records: %w", err)
+	}
+	var records []Record
+	for i := uint64(0); i < t.capacity; i++ {
+		if r, ok := decodeRecord(data[i*recordSize : (i+1)*recordSize]); ok {
+			records = append(records, r)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Slot < records[j].Slot })
+	return records, nil
+}
@@ -0,0 +1,67 @@
+package slothistory_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/geanlabs/gean/observability/slothistory"
+)
+
+func TestTableWrapsAndPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), slothistory.FileName)
+	table, err := slothistory.Open(path, 4)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	for slot := uint64(0); slot < 6; slot++ {
+		r := slothistory.Record{Slot: slot, HeadSlot: slot, JustifiedSlot: slot / 3}
+		if err := table.Put(r); err != nil {
+			t.Fatalf("Put(%d): %v", slot, err)
+		}
+	}
+	table.Close()
+
+	// Reopening with a different capacity keeps the original one.
+	table, err = slothistory.Open(path, 100)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer table.Close()
+
+	records, err := table.Last(10)
+	if err != nil {
+		t.Fatalf("Last: %v", err)
+	}
+	if len(records) != 4 || records[0].Slot != 2 || records[3].Slot != 5 {
+		t.Fatalf("records = %+v, want slots 2..5", records)
+	}
+	for _, r := range records {
+		want := r.Slot == 3
+		if got := r.Has(slothistory.FlagJustifiedChanged); got != want {
+			t.Errorf("slot %d justified changed = %v, want %v", r.Slot, got, want)
+		}
+	}
+
+	// Change flags continue from the last record on disk.
+	if err := table.Put(slothistory.Record{Slot: 6, JustifiedSlot: 2}); err != nil {
+		t.Fatal(err)
+	}
+	last, err := table.Last(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(last) != 1 || last[0].Slot != 6 || !last[0].Has(slothistory.FlagJustifiedChanged) {
+		t.Fatalf("last = %+v, want slot 6 with justified change", last)
+	}
+}
+
+func TestOpenRejectsForeignFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "other")
+	if err := os.WriteFile(path, []byte("not a slot history table"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := slothistory.Open(path, 4); err == nil {
+		t.Fatal("opened a file without the table header")
+	}
+}
@@ -0,0 +1,411 @@
+// Package archive wraps a storage.Store with a cold tier for finalized
+// blocks. Archived blocks and their signed envelopes are appended to flat
+// files and removed from the wrapped store, which then only holds the
+// unfinalized part of the chain.
+package archive
+
+import (
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/geanlabs/gean/observability/logging"
+	"github.com/geanlabs/gean/storage"
+	"github.com/geanlabs/gean/types"
+)
+
+const (
+	dataFile  = "blocks.dat"
+	indexFile = "blocks.idx"
+)
+
+// An index record is the slot, root, data offset and data length of an
+// archived block. A data record is the length of the SSZ block followed by
+// the block and, if the block had one, its SSZ signed envelope.
+const indexRecordSize = 8 + 32 + 8 + 4
+
+type entry struct {
+	slot   uint64
+	root   [32]byte
+	offset int64
+	length uint32
+}
+
+// Store serves blocks from the wrapped store and, once archived, from the
+// archive files. States, the canonical index and writes go to the wrapped
+// store only.
+type Store struct {
+	storage.Store
+	log *slog.Logger
+
+	mu       sync.Mutex
+	data     *os.File
+	index    *os.File
+	dataSize int64
+	entries  []entry // ascending slot
+	byRoot   map[[32]byte]int
+}
+
+// Open opens or creates the archive in dir and wraps inner with it. Records
+// left half-written by a crash are truncated.
+func Open(dir string, inner storage.Store) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	data, err := os.OpenFile(filepath.Join(dir, dataFile), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	index, err := os.OpenFile(filepath.Join(dir, indexFile), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		data.Close()
+		return nil, err
+	}
+	s := &Store{
+		Store:  inner,
+		log:    logging.NewComponentLogger(logging.CompStorage),
+		data:   data,
+		index:  index,
+		byRoot: make(map[[32]byte]int),
+	}
+	if err := s.load(); err != nil {
+		data.Close()
+		index.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// load reads the index, dropping trailing records whose data is incomplete,
+// and truncates both files to the last complete record.
+func (s *Store) load() error {
+	raw, err := io.ReadAll(s.index)
+	if err != nil {
+		return fmt.Errorf("read archive index: %w", err)
+	}
+	info, err := s.data.Stat()
+	if err != nil {
+		return err
+	}
+	for i := 0; i+indexRecordSize <= len(raw); i += indexRecordSize {
+		rec := raw[i : i+indexRecordSize]
+		e := entry{
+			slot:   binary.LittleEndian.Uint64(rec[0:8]),
+			offset: int64(binary.LittleEndian.Uint64(rec[40:48])),
+			length: binary.LittleEndian.Uint32(rec[48:52]),
+		}
+		copy(e.root[:], rec[8:40])
+		if e.offset != s.dataSize || e.offset+int64(e.length) > info.Size() {
+			break
+		}
+		s.byRoot[e.root] = len(s.entries)
+		s.entries = append(s.entries, e)
+		s.dataSize += int64(e.length)
+	}
+	if err := s.index.Truncate(int64(len(s.entries)) * indexRecordSize); err != nil {
+		return err
+	}
+	if _, err := s.index.Seek(0, io.SeekEnd); err != nil {
+		return err
+	}
+	return s.data.Truncate(s.dataSize)
+}
+
+// NumArchived returns the number of archived blocks.
+func (s *Store) NumArchived() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+// ArchiveBlocks moves the given finalized blocks from the wrapped store to
+// the archive and returns how many were newly archived. Blocks must be
+// archived in ascending slot order across calls; roots are sorted here, and
+// fork choice only archives below the finalized checkpoint, which only
+// advances. Roots already archived are only removed from the wrapped store.
+func (s *Store) ArchiveBlocks(roots [][32]byte) int {
+	type pending struct {
+		root   [32]byte
+		block  *types.Block
+		signed *types.SignedBlockWithAttestation
+	}
+	var todo []pending
+	var drop [][32]byte
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, root := range roots {
+		block, ok := s.Store.GetBlock(root)
+		if !ok {
+			continue
+		}
+		drop = append(drop, root)
+		if _, ok := s.byRoot[root]; ok {
+			continue
+		}
+		sb, _ := s.Store.GetSignedBlock(root)
+		todo = append(todo, pending{root, block, sb})
+	}
+	sort.Slice(todo, func(i, j int) bool { return todo[i].block.Slot < todo[j].block.Slot })
+
+	var data, index []byte
+	var added []entry
+	offset := s.dataSize
+	for _, p := range todo {
+		if n := len(s.entries); n > 0 && p.block.Slot <= s.entries[n-1].slot {
+			s.log.Error("refusing to archive block out of slot order",
+				"slot", p.block.Slot,
+				"root", logging.ShortHash(p.root),
+			)
+			return 0
+		}
+		rec, err := encodeRecord(p.block, p.signed)
+		if err != nil {
+			s.log.Error("failed to encode block for archive",
+				"slot", p.block.Slot,
+				"root", logging.ShortHash(p.root),
+				"err", err,
+			)
+			return 0
+		}
+		e := entry{slot: p.block.Slot, root: p.root, offset: offset, length: uint32(len(rec))}
+		data = append(data, rec...)
+		index = binary.LittleEndian.AppendUint64(index, e.slot)
+		index = append(index, e.root[:]...)
+		index = binary.LittleEndian.AppendUint64(index, uint64(e.offset))
+		index = binary.LittleEndian.AppendUint32(index, e.length)
+		added = append(added, e)
+		offset += int64(len(rec))
+	}
+
+	if len(added) > 0 {
+		// The index is written after the data it points to is durable, so a
+		// crash leaves at most unindexed data, which load truncates.
+		if err := s.write(data, index); err != nil {
+			s.log.Error("failed to write archive", "err", err)
+			s.index.Truncate(int64(len(s.entries)) * indexRecordSize)
+			s.index.Seek(0, io.SeekEnd)
+			s.data.Truncate(s.dataSize)
+			return 0
+		}
+		for _, e := range added {
+			s.byRoot[e.root] = len(s.entries)
+			s.entries = append(s.entries, e)
+		}
+		s.dataSize = offset
+	}
+	if len(drop) > 0 {
+		if err := s.Store.DeleteBlocks(drop); err != nil {
+			// The archived copies are served first, so the hot copies left
+			// behind are only wasted space until the next prune.
+			s.log.Error("failed to drop archived blocks from the hot store", "count", len(drop), "err", err)
+		}
+	}
+	return len(added)
+}
+
+func (s *Store) write(data, index []byte) error {
+	if _, err := s.data.WriteAt(data, s.dataSize); err != nil {
+		return err
+	}
+	if err := s.data.Sync(); err != nil {
+		return err
+	}
+	if _, err := s.index.Write(index); err != nil {
+		return err
+	}
+	return s.index.Sync()
+}
+
+func encodeRecord(block *types.Block, sb *types.SignedBlockWithAttestation) ([]byte, error) {
+	b, err := block.MarshalSSZ()
+	if err != nil {
+		return nil, err
+	}
+	rec := binary.LittleEndian.AppendUint32(nil, uint32(len(b)))
+	rec = append(rec, b...)
+	if sb != nil {
+		enc, err := sb.MarshalSSZ()
+		if err != nil {
+			return nil, err
+		}
+		rec = append(rec, enc...)
+	}
+	return rec, nil
+}
+
+// readLocked returns the data record of an archived block, split into its
+// block and signed envelope encodings.
+func (s *Store) readLocked(e entry) (block, signed []byte, err error) {
+	rec := make([]byte, e.length)
+	if _, err := s.data.ReadAt(rec, e.offset); err != nil {
+		return nil, nil, err
+	}
+	if len(rec) < 4 {
+		return nil, nil, errors.New("short archive record")
+	}
+	n := binary.LittleEndian.Uint32(rec[:4])
+	if uint64(n) > uint64(len(rec)-4) {
+		return nil, nil, errors.New("corrupt archive record")
+	}
+	return rec[4 : 4+n], rec[4+n:], nil
+}
+
+func (s *Store) archivedBlock(root [32]byte) (*types.Block, bool) {
+	s.mu.Lock()
+	i, ok := s.byRoot[root]
+	if !ok {
+		s.mu.Unlock()
+		return nil, false
+	}
+	enc, _, err := s.readLocked(s.entries[i])
+	s.mu.Unlock()
+	if err != nil {
+		s.log.Error("failed to read archived block", "root", logging.ShortHash(root), "err", err)
+		return nil, false
+	}
+	block := new(types.Block)
+	if err := block.UnmarshalSSZ(enc); err != nil {
+		s.log.Error("failed to decode archived block", "root", logging.ShortHash(root), "err", err)
+		return nil, false
+	}
+	return block, true
+}
+
+func (s *Store) GetBlock(root [32]byte) (*types.Block, bool) {
+	if b, ok := s.Store.GetBlock(root); ok {
+		return b, true
+	}
+	return s.archivedBlock(root)
+}
+
+func (s *Store) GetSignedBlock(root [32]byte) (*types.SignedBlockWithAttestation, bool) {
+	if sb, ok := s.Store.GetSignedBlock(root); ok {
+		return sb, true
+	}
+	s.mu.Lock()
+	i, ok := s.byRoot[root]
+	if !ok {
+		s.mu.Unlock()
+		return nil, false
+	}
+	_, enc, err := s.readLocked(s.entries[i])
+	s.mu.Unlock()
+	if err != nil || len(enc) == 0 {
+		return nil, false
+	}
+	sb := new(types.SignedBlockWithAttestation)
+	if err := sb.UnmarshalSSZ(enc); err != nil {
+		s.log.Error("failed to decode archived block", "root", logging.ShortHash(root), "err", err)
+		return nil, false
+	}
+	return sb, true
+}
+
+// GetChildren returns the children held by the wrapped store and, for an
+// archived block, the next archived block if it is a child.
+func (s *Store) GetChildren(root [32]byte) [][32]byte {
+	kids := s.Store.GetChildren(root)
+	s.mu.Lock()
+	i, ok := s.byRoot[root]
+	ok = ok && i+1 < len(s.entries)
+	var next [32]byte
+	if ok {
+		next = s.entries[i+1].root
+	}
+	s.mu.Unlock()
+	if !ok || slices.Contains(kids, next) {
+		return kids
+	}
+	if b, found := s.archivedBlock(next); found && b.ParentRoot == root {
+		kids = append(kids, next)
+	}
+	return kids
+}
+
+// ForEachBlock visits the blocks in r held by the wrapped store, then the
+// archived ones.
+func (s *Store) ForEachBlock(r storage.SlotRange, fn func(root [32]byte, block *types.Block) bool) {
+	stopped := false
+	s.Store.ForEachBlock(r, func(root [32]byte, b *types.Block) bool {
+		stopped = !fn(root, b)
+		return !stopped
+	})
+	if stopped {
+		return
+	}
+
+	s.mu.Lock()
+	lo, _ := slices.BinarySearchFunc(s.entries, r.From, func(e entry, slot uint64) int {
+		return cmp.Compare(e.slot, slot)
+	})
+	var roots [][32]byte
+	for _, e := range s.entries[lo:] {
+		if !r.Contains(e.slot) {
+			break
+		}
+		roots = append(roots, e.root)
+	}
+	s.mu.Unlock()
+
+	for _, root := range roots {
+		if _, ok := s.Store.GetBlock(root); ok {
+			continue // visited above; not yet dropped after a crash
+		}
+		b, ok := s.archivedBlock(root)
+		if !ok {
+			continue
+		}
+		if !fn(root, b) {
+			return
+		}
+	}
+}
+
+// SetStateCacheSize forwards to the wrapped store if it has a state cache.
+func (s *Store) SetStateCacheSize(n int) {
+	if c, ok := s.Store.(interface{ SetStateCacheSize(int) }); ok {
+		c.SetStateCacheSize(n)
+	}
+}
+
+// ShedStateCache forwards to the wrapped store if it has a state cache.
+func (s *Store) ShedStateCache() int {
+	if c, ok := s.Store.(interface{ ShedStateCache() int }); ok {
+		return c.ShedStateCache()
+	}
+	return 0
+}
+
+// Stats adds the archived blocks and the size of the archive files to the
+// stats of the wrapped store.
+func (s *Store) Stats() storage.Stats {
+	var st storage.Stats
+	if inner, ok := s.Store.(interface{ Stats() storage.Stats }); ok {
+		st = inner.Stats()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st.Blocks += len(s.entries)
+	st.DiskBytes += s.dataSize + int64(len(s.entries))*indexRecordSize
+	return st
+}
+
+// Close closes the archive files and the wrapped store.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := errors.Join(s.data.Close(), s.index.Close())
+	if c, ok := s.Store.(io.Closer); ok {
+		err = errors.Join(err, c.Close())
+	}
+	return err
+}
@@ -0,0 +1,168 @@
+package archive_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/geanlabs/gean/storage"
+	"github.com/geanlabs/gean/storage/archive"
+	"github.com/geanlabs/gean/storage/memory"
+	"github.com/geanlabs/gean/types"
+)
+
+func openArchive(t *testing.T, dir string, inner storage.Store) *archive.Store {
+	t.Helper()
+	s, err := archive.Open(dir, inner)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	return s
+}
+
+// putChain stores blocks at slots 1..n, each with a signed envelope, and
+// returns their roots.
+func putChain(s storage.Store, n int) [][32]byte {
+	var roots [][32]byte
+	parent := [32]byte{}
+	for slot := uint64(1); slot <= uint64(n); slot++ {
+		block := &types.Block{Slot: slot, ProposerIndex: slot, ParentRoot: parent, Body: &types.BlockBody{}}
+		root, _ := block.HashTreeRoot()
+		s.PutBlock(root, block)
+		s.PutSignedBlock(root, &types.SignedBlockWithAttestation{
+			Message: &types.BlockWithAttestation{
+				Block:               block,
+				ProposerAttestation: &types.Attestation{ValidatorID: slot, Data: &types.AttestationData{}},
+			},
+		})
+		roots = append(roots, root)
+		parent = root
+	}
+	return roots
+}
+
+func TestArchivedBlocksLeaveHotStore(t *testing.T) {
+	inner := memory.New()
+	s := openArchive(t, t.TempDir(), inner)
+	defer s.Close()
+	roots := putChain(s, 4)
+
+	if n := s.ArchiveBlocks(roots[:3]); n != 3 {
+		t.Fatalf("ArchiveBlocks = %d, want 3", n)
+	}
+	for i, root := range roots[:3] {
+		if _, ok := inner.GetBlock(root); ok {
+			t.Fatalf("slot %d: archived block still in hot store", i+1)
+		}
+		b, ok := s.GetBlock(root)
+		if !ok || b.Slot != uint64(i+1) {
+			t.Fatalf("slot %d: GetBlock = %+v, %v", i+1, b, ok)
+		}
+		sb, ok := s.GetSignedBlock(root)
+		if !ok || sb.Message.ProposerAttestation.ValidatorID != uint64(i+1) {
+			t.Fatalf("slot %d: GetSignedBlock = %+v, %v", i+1, sb, ok)
+		}
+	}
+	if _, ok := inner.GetBlock(roots[3]); !ok {
+		t.Fatal("unarchived block left the hot store")
+	}
+
+	// Archiving again only drops what is still in the hot store.
+	if n := s.ArchiveBlocks(roots[:3]); n != 0 {
+		t.Fatalf("re-archiving = %d, want 0", n)
+	}
+}
+
+func TestArchiveSurvivesReopen(t *testing.T) {
+	dir := t.TempDir()
+	s := openArchive(t, dir, memory.New())
+	roots := putChain(s, 3)
+	s.ArchiveBlocks(roots)
+	s.Close()
+
+	s = openArchive(t, dir, memory.New())
+	defer s.Close()
+	if s.NumArchived() != 3 {
+		t.Fatalf("NumArchived = %d, want 3", s.NumArchived())
+	}
+	if b, ok := s.GetBlock(roots[1]); !ok || b.Slot != 2 {
+		t.Fatalf("GetBlock after reopen = %+v, %v", b, ok)
+	}
+}
+
+func TestTornIndexIsTruncated(t *testing.T) {
+	dir := t.TempDir()
+	s := openArchive(t, dir, memory.New())
+	roots := putChain(s, 2)
+	s.ArchiveBlocks(roots)
+	s.Close()
+
+	// Drop the tail of the data file, as if the crash hit before it synced.
+	data := filepath.Join(dir, "blocks.dat")
+	info, _ := os.Stat(data)
+	if err := os.Truncate(data, info.Size()-1); err != nil {
+		t.Fatal(err)
+	}
+
+	inner := memory.New()
+	s = openArchive(t, dir, inner)
+	defer s.Close()
+	if s.NumArchived() != 1 {
+		t.Fatalf("NumArchived = %d, want 1", s.NumArchived())
+	}
+	if _, ok := s.GetBlock(roots[1]); ok {
+		t.Fatal("block with incomplete data still served")
+	}
+
+	// The lost block can be archived again.
+	more := putChain(inner, 2)
+	if n := s.ArchiveBlocks(more[1:]); n != 1 {
+		t.Fatalf("ArchiveBlocks after truncation = %d, want 1", n)
+	}
+}
+
+func TestForEachBlockIncludesArchive(t *testing.T) {
+	s := openArchive(t, t.TempDir(), memory.New())
+	defer s.Close()
+	roots := putChain(s, 5)
+	s.ArchiveBlocks(roots[:3])
+
+	var slots []uint64
+	s.ForEachBlock(storage.SlotRange{From: 2, To: 5}, func(_ [32]byte, b *types.Block) bool {
+		slots = append(slots, b.Slot)
+		return true
+	})
+	slices.Sort(slots)
+	if !slices.Equal(slots, []uint64{2, 3, 4}) {
+		t.Fatalf("visited slots %v, want [2 3 4]", slots)
+	}
+}
+
+func TestChildrenSpanArchive(t *testing.T) {
+	s := openArchive(t, t.TempDir(), memory.New())
+	defer s.Close()
+	roots := putChain(s, 4)
+	s.ArchiveBlocks(roots[:2])
+
+	for i := 0; i < 3; i++ {
+		if kids := s.GetChildren(roots[i]); !slices.Equal(kids, [][32]byte{roots[i+1]}) {
+			t.Fatalf("slot %d: children = %x, want %x", i+1, kids, roots[i+1])
+		}
+	}
+}
+
+func TestStatsIncludeArchive(t *testing.T) {
+	s := openArchive(t, t.TempDir(), memory.New())
+	defer s.Close()
+	roots := putChain(s, 4)
+	s.ArchiveBlocks(roots[:3])
+
+	st := s.Stats()
+	if st.Blocks != 4 {
+		t.Fatalf("Blocks = %d, want 4", st.Blocks)
+	}
+	if st.DiskBytes == 0 {
+		t.Fatal("archive files not counted in DiskBytes")
+	}
+}
@@ -0,0 +1,217 @@
+// Package checkpoint reads and writes checkpoint bundles: a finalized block
+// and its post-state in one self-contained file, for handing a trusted
+// starting point to new devnet participants. A bundle may carry an ed25519
+// signature from whoever vouches for it.
+package checkpoint
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/geanlabs/gean/types"
+)
+
+// magic starts every bundle.
+var magic = []byte("geanckp1")
+
+// maxSection bounds the length of the block or state read from a bundle.
+const maxSection = 1 << 28
+
+// A bundle is the magic, the SSZ block and the SSZ state, each prefixed by
+// its little-endian uint32 length, then a trailer: a zero byte if unsigned,
+// or 1 followed by the 32-byte public key and the 64-byte signature over
+// the bundle digest.
+const (
+	trailerUnsigned = 0
+	trailerEd25519  = 1
+)
+
+// ErrUntrusted is returned by Read when a bundle is not signed by any of the
+// trusted keys.
+var ErrUntrusted = errors.New("checkpoint bundle is not signed by a trusted key")
+
+// Bundle is a block and its post-state.
+type Bundle struct {
+	Block *types.Block
+	State *types.State
+
+	// Signer is the public key that signed the bundle, or nil if it is
+	// unsigned. Only set by Read.
+	Signer ed25519.PublicKey
+}
+
+// New returns a bundle for block and state after checking that they match.
+func New(block *types.Block, state *types.State) (*Bundle, error) {
+	b := &Bundle{Block: block, State: state}
+	if err := b.Verify(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Root returns the block root, which is the checkpoint the bundle anchors.
+func (b *Bundle) Root() ([32]byte, error) {
+	return b.Block.HashTreeRoot()
+}
+
+// Verify checks that the state is the post-state of the block.
+func (b *Bundle) Verify() error {
+	if b.Block == nil || b.State == nil {
+		return errors.New("bundle needs a block and a state")
+	}
+	if b.Block.Slot != b.State.Slot {
+		return fmt.Errorf("block slot %d does not match state slot %d", b.Block.Slot, b.State.Slot)
+	}
+	stateRoot, err := b.State.HashTreeRoot()
+	if err != nil {
+		return fmt.Errorf("hash state: %w", err)
+	}
+	if stateRoot != b.Block.StateRoot {
+		return fmt.Errorf("state root %x does not match block state root %x", stateRoot, b.Block.StateRoot)
+	}
+	return nil
+}
+
+// body returns the bundle up to its trailer.
+func (b *Bundle) body() ([]byte, error) {
+	block, err := b.Block.MarshalSSZ()
+	if err != nil {
+		return nil, fmt.Errorf("encode block: %w", err)
+	}
+	state, err := b.State.MarshalSSZ()
+	if err != nil {
+		return nil, fmt.Errorf("encode state: %w", err)
+	}
+	out := make([]byte, 0, len(magic)+8+len(block)+len(state))
+	out = append(out, magic...)
+	out = binary.LittleEndian.AppendUint32(out, uint32(len(block)))
+	out = append(out, block...)
+	out = binary.LittleEndian.AppendUint32(out, uint32(len(state)))
+	return append(out, state...), nil
+}
+
+// Digest returns the SHA-256 of the bundle without its trailer. It is what
+// Write signs, and can be pinned out of band instead of a signature.
+func (b *Bundle) Digest() ([32]byte, error) {
+	body, err := b.body()
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return sha256.Sum256(body), nil
+}
+
+// Write encodes b to w, signed with key unless key is nil.
+func (b *Bundle) Write(w io.Writer, key ed25519.PrivateKey) error {
+	if err := b.Verify(); err != nil {
+		return err
+	}
+	body, err := b.body()
+	if err != nil {
+		return err
+	}
+	if key == nil {
+		body = append(body, trailerUnsigned)
+	} else {
+		digest := sha256.Sum256(body)
+		sig := ed25519.Sign(key, digest[:])
+		body = append(body, trailerEd25519)
+		body = append(body, key.Public().(ed25519.PublicKey)...)
+		body = append(body, sig...)
+	}
+	_, err = w.Write(body)
+	return err
+}
+
+// Read decodes and verifies a bundle from r. If trusted is non-empty, the
+// bundle must be signed by one of the keys in it; otherwise any valid
+// signature is accepted and recorded in Signer.
+func Read(r io.Reader, trusted ...ed25519.PublicKey) (*Bundle, error) {
+	br := bufio.NewReader(r)
+	hash := sha256.New()
+	tr := io.TeeReader(br, hash)
+
+	head := make([]byte, len(magic))
+	if _, err := io.ReadFull(tr, head); err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	if !bytes.Equal(head, magic) {
+		return nil, errors.New("not a gean checkpoint bundle")
+	}
+	b := &Bundle{Block: new(types.Block), State: new(types.State)}
+	if err := readSection(tr, "block", b.Block); err != nil {
+		return nil, err
+	}
+	if err := readSection(tr, "state", b.State); err != nil {
+		return nil, err
+	}
+	var digest [32]byte
+	hash.Sum(digest[:0])
+
+	kind, err := br.ReadByte()
+	if err != nil {
+		return nil, fmt.Errorf("read trailer: %w", err)
+	}
+	switch kind {
+	case trailerUnsigned:
+	case trailerEd25519:
+		var trailer [ed25519.PublicKeySize + ed25519.SignatureSize]byte
+		if _, err := io.ReadFull(br, trailer[:]); err != nil {
+			return nil, fmt.Errorf("read signature: %w", err)
+		}
+		pub := ed25519.PublicKey(trailer[:ed25519.PublicKeySize])
+		if !ed25519.Verify(pub, digest[:], trailer[ed25519.PublicKeySize:]) {
+			return nil, errors.New("invalid checkpoint bundle signature")
+		}
+		b.Signer = pub
+	default:
+		return nil, fmt.Errorf("unknown trailer kind %d", kind)
+	}
+	if _, err := br.ReadByte(); err != io.EOF {
+		return nil, errors.New("trailing data after checkpoint bundle")
+	}
+
+	if len(trusted) > 0 && !isTrusted(b.Signer, trusted) {
+		return nil, ErrUntrusted
+	}
+	if err := b.Verify(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func readSection(r io.Reader, name string, v interface{ UnmarshalSSZ([]byte) error }) error {
+	var lenBuf [4]byte
+	if _, err := io.ReadFull(r, lenBuf[:]); err != nil {
+		return fmt.Errorf("read %s: %w", name, err)
+	}
+	size := binary.LittleEndian.Uint32(lenBuf[:])
+	if size > maxSection {
+		return fmt.Errorf("%s of %d bytes exceeds limit", name, size)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return fmt.Errorf("read %s: %w", name, err)
+	}
+	if err := v.UnmarshalSSZ(data); err != nil {
+		return fmt.Errorf("decode %s: %w", name, err)
+	}
+	return nil
+}
+
+func isTrusted(signer ed25519.PublicKey, trusted []ed25519.PublicKey) bool {
+	if signer == nil {
+		return false
+	}
+	for _, k := range trusted {
+		if signer.Equal(k) {
+			return true
+		}
+	}
+	return false
+}
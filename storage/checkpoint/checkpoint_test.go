@@ -0,0 +1,109 @@
+package checkpoint_test
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"testing"
+
+	"github.com/geanlabs/gean/chain/statetransition"
+	"github.com/geanlabs/gean/storage/checkpoint"
+	"github.com/geanlabs/gean/types"
+)
+
+func newBundle(t *testing.T) *checkpoint.Bundle {
+	t.Helper()
+	state := statetransition.GenerateGenesis(1000, []*types.Validator{{Index: 0}, {Index: 1}})
+	stateRoot, err := state.HashTreeRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := checkpoint.New(&types.Block{StateRoot: stateRoot, Body: &types.BlockBody{}}, state)
+	if err != nil {
+		t.Fatalf("new bundle: %v", err)
+	}
+	return b
+}
+
+func TestUnsignedRoundTrip(t *testing.T) {
+	b := newBundle(t)
+	var buf bytes.Buffer
+	if err := b.Write(&buf, nil); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	got, err := checkpoint.Read(&buf)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if got.Signer != nil {
+		t.Fatal("unsigned bundle has a signer")
+	}
+	wantRoot, _ := b.Root()
+	gotRoot, _ := got.Root()
+	if gotRoot != wantRoot {
+		t.Fatalf("root = %x, want %x", gotRoot, wantRoot)
+	}
+}
+
+func TestSignedBundleChecksTrust(t *testing.T) {
+	b := newBundle(t)
+	pub, key, _ := ed25519.GenerateKey(nil)
+	other, _, _ := ed25519.GenerateKey(nil)
+	var buf bytes.Buffer
+	if err := b.Write(&buf, key); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	enc := buf.Bytes()
+
+	got, err := checkpoint.Read(bytes.NewReader(enc), pub)
+	if err != nil {
+		t.Fatalf("read with trusted key: %v", err)
+	}
+	if !got.Signer.Equal(pub) {
+		t.Fatal("signer not recorded")
+	}
+	if _, err := checkpoint.Read(bytes.NewReader(enc), other); !errors.Is(err, checkpoint.ErrUntrusted) {
+		t.Fatalf("read with other key: err = %v, want ErrUntrusted", err)
+	}
+
+	var unsigned bytes.Buffer
+	b.Write(&unsigned, nil)
+	if _, err := checkpoint.Read(&unsigned, pub); !errors.Is(err, checkpoint.ErrUntrusted) {
+		t.Fatalf("unsigned with trusted key: err = %v, want ErrUntrusted", err)
+	}
+}
+
+func TestTamperedBundleIsRejected(t *testing.T) {
+	b := newBundle(t)
+	_, key, _ := ed25519.GenerateKey(nil)
+	var buf bytes.Buffer
+	if err := b.Write(&buf, key); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	enc := buf.Bytes()
+	// Flip a byte of the state's genesis time, just after the block.
+	enc[8+4+len(mustSSZ(t, b.Block))+4] ^= 1
+	if _, err := checkpoint.Read(bytes.NewReader(enc)); err == nil {
+		t.Fatal("tampered bundle accepted")
+	}
+}
+
+func TestMismatchedStateIsRejected(t *testing.T) {
+	b := newBundle(t)
+	b.Block.StateRoot[0] ^= 1
+	if err := b.Verify(); err == nil {
+		t.Fatal("mismatched state root accepted")
+	}
+	if err := b.Write(new(bytes.Buffer), nil); err == nil {
+		t.Fatal("wrote bundle with mismatched state root")
+	}
+}
+
+func mustSSZ(t *testing.T, v interface{ MarshalSSZ() ([]byte, error) }) []byte {
+	t.Helper()
+	data, err := v.MarshalSSZ()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/geanlabs/gean/types"
+)
+
+// exportMagic starts every export stream.
+var exportMagic = []byte("geanexp1")
+
+// Export record kinds.
+const (
+	recordBlock       = 'b'
+	recordSignedBlock = 'e'
+	recordState       = 's'
+)
+
+// maxExportRecord bounds the length of a single record read by Import.
+const maxExportRecord = 1 << 28
+
+// importBatchSize is the number of records Import commits per batch.
+const importBatchSize = 256
+
+// Export writes every block, signed envelope and state in s to w. Each
+// record is a kind byte, the 32-byte block root, a little-endian uint32
+// length and the SSZ encoding. Blocks come before states, so Import can
+// load the stream into a store that consults blocks when storing states.
+func Export(w io.Writer, s Store) error {
+	bw := bufio.NewWriter(w)
+	if _, err := bw.Write(exportMagic); err != nil {
+		return err
+	}
+
+	var err error
+	write := func(kind byte, root [32]byte, v interface{ MarshalSSZ() ([]byte, error) }) bool {
+		var data []byte
+		if data, err = v.MarshalSSZ(); err != nil {
+			err = fmt.Errorf("encode %c %x: %w", kind, root, err)
+			return false
+		}
+		var hdr [1 + 32 + 4]byte
+		hdr[0] = kind
+		copy(hdr[1:33], root[:])
+		binary.LittleEndian.PutUint32(hdr[33:], uint32(len(data)))
+		if _, err = bw.Write(hdr[:]); err == nil {
+			_, err = bw.Write(data)
+		}
+		return err == nil
+	}
+
+	s.ForEachBlock(SlotRange{}, func(root [32]byte, b *types.Block) bool {
+		if !write(recordBlock, root, b) {
+			return false
+		}
+		if sb, ok := s.GetSignedBlock(root); ok {
+			return write(recordSignedBlock, root, sb)
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	s.ForEachState(SlotRange{}, func(root [32]byte, st *types.State) bool {
+		return write(recordState, root, st)
+	})
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// Import reads a stream written by Export into s and returns the number of
+// records loaded. Records are committed in batches, so on error the records
+// before the failing batch remain in s.
+func Import(r io.Reader, s Store) (int, error) {
+	br := bufio.NewReader(r)
+	magic := make([]byte, len(exportMagic))
+	if _, err := io.ReadFull(br, magic); err != nil {
+		return 0, fmt.Errorf("read header: %w", err)
+	}
+	if !bytes.Equal(magic, exportMagic) {
+		return 0, errors.New("not a gean export stream")
+	}
+
+	n := 0
+	batch := s.Batch()
+	pending := 0
+	states := false
+	var hdr [1 + 32 + 4]byte
+	for {
+		if _, err := io.ReadFull(br, hdr[:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return n, fmt.Errorf("read record %d: %w", n+pending, err)
+		}
+		var root [32]byte
+		copy(root[:], hdr[1:33])
+		size := binary.LittleEndian.Uint32(hdr[33:])
+		if size > maxExportRecord {
+			return n, fmt.Errorf("record %d of %d bytes exceeds limit", n+pending, size)
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(br, data); err != nil {
+			return n, fmt.Errorf("read record %d: %w", n+pending, err)
+		}
+
+		switch kind := hdr[0]; kind {
+		case recordBlock:
+			b := new(types.Block)
+			if err := b.UnmarshalSSZ(data); err != nil {
+				return n, fmt.Errorf("decode block %x: %w", root, err)
+			}
+			batch.PutBlock(root, b)
+		case recordSignedBlock:
+			sb := new(types.SignedBlockWithAttestation)
+			if err := sb.UnmarshalSSZ(data); err != nil {
+				return n, fmt.Errorf("decode signed block %x: %w", root, err)
+			}
+			batch.PutSignedBlock(root, sb)
+		case recordState:
+			if !states {
+				// Make the blocks visible before the states that refer to them.
+				if err := batch.Commit(); err != nil {
+					return n, fmt.Errorf("write records: %w", err)
+				}
+				n += pending
+				batch, pending, states = s.Batch(), 0, true
+			}
+			st := new(types.State)
+			if err := st.UnmarshalSSZ(data); err != nil {
+				return n, fmt.Errorf("decode state %x: %w", root, err)
+			}
+			batch.PutState(root, st)
+		default:
+			return n, fmt.Errorf("unknown record kind %q", kind)
+		}
+
+		if pending++; pending == importBatchSize {
+			if err := batch.Commit(); err != nil {
+				return n, fmt.Errorf("write records: %w", err)
+			}
+			n += pending
+			batch, pending = s.Batch(), 0
+		}
+	}
+	if err := batch.Commit(); err != nil {
+		return n, fmt.Errorf("write records: %w", err)
+	}
+	return n + pending, nil
+}
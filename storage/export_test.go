@@ -0,0 +1,83 @@
+package storage_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/geanlabs/gean/chain/statetransition"
+	"github.com/geanlabs/gean/storage"
+	"github.com/geanlabs/gean/storage/memory"
+	"github.com/geanlabs/gean/types"
+)
+
+func TestExportImportRoundTrip(t *testing.T) {
+	src := memory.New()
+	validators := []*types.Validator{{Index: 0}, {Index: 1}}
+	for slot := uint64(0); slot < 3; slot++ {
+		root := [32]byte{byte(slot + 1)}
+		block := &types.Block{Slot: slot, Body: &types.BlockBody{}}
+		state := statetransition.GenerateGenesis(1000, validators)
+		state.Slot = slot
+		src.PutBlock(root, block)
+		src.PutState(root, state)
+	}
+	signedRoot := [32]byte{1}
+	src.PutSignedBlock(signedRoot, &types.SignedBlockWithAttestation{
+		Message: &types.BlockWithAttestation{
+			Block:               &types.Block{Body: &types.BlockBody{}},
+			ProposerAttestation: &types.Attestation{ValidatorID: 7, Data: &types.AttestationData{}},
+		},
+	})
+
+	var buf bytes.Buffer
+	if err := storage.Export(&buf, src); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	dst := memory.New()
+	n, err := storage.Import(&buf, dst)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if n != 7 {
+		t.Fatalf("imported %d records, want 7", n)
+	}
+
+	for slot := uint64(0); slot < 3; slot++ {
+		root := [32]byte{byte(slot + 1)}
+		if b, ok := dst.GetBlock(root); !ok || b.Slot != slot {
+			t.Fatalf("slot %d: block = %+v, %v", slot, b, ok)
+		}
+		want, _ := src.GetState(root)
+		got, ok := dst.GetState(root)
+		if !ok {
+			t.Fatalf("slot %d: state missing", slot)
+		}
+		wantRoot, _ := want.HashTreeRoot()
+		gotRoot, _ := got.HashTreeRoot()
+		if gotRoot != wantRoot {
+			t.Fatalf("slot %d: state root changed", slot)
+		}
+	}
+	if sb, ok := dst.GetSignedBlock(signedRoot); !ok || sb.Message.ProposerAttestation.ValidatorID != 7 {
+		t.Fatalf("signed block = %+v, %v", sb, ok)
+	}
+}
+
+func TestImportRejectsForeignStream(t *testing.T) {
+	if _, err := storage.Import(bytes.NewReader([]byte("not an export")), memory.New()); err == nil {
+		t.Fatal("imported a stream without the export header")
+	}
+}
+
+func TestImportRejectsTruncatedStream(t *testing.T) {
+	src := memory.New()
+	src.PutBlock([32]byte{1}, &types.Block{Slot: 1, Body: &types.BlockBody{}})
+	var buf bytes.Buffer
+	if err := storage.Export(&buf, src); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	data := buf.Bytes()[:buf.Len()-1]
+	if _, err := storage.Import(bytes.NewReader(data), memory.New()); err == nil {
+		t.Fatal("imported a truncated stream")
+	}
+}
@@ -2,14 +2,109 @@ package storage
 
 import "github.com/geanlabs/gean/types"
 
-// Store is a storage interface for blocks and states.
+// Store is a storage interface for blocks and states. Writes return an
+// error if a persistent backend failed to make them durable; the write is
+// then not visible to readers either.
 type Store interface {
 	GetBlock(root [32]byte) (*types.Block, bool)
-	PutBlock(root [32]byte, block *types.Block)
+	PutBlock(root [32]byte, block *types.Block) error
 	GetSignedBlock(root [32]byte) (*types.SignedBlockWithAttestation, bool)
-	PutSignedBlock(root [32]byte, sb *types.SignedBlockWithAttestation)
+	PutSignedBlock(root [32]byte, sb *types.SignedBlockWithAttestation) error
 	GetState(root [32]byte) (*types.State, bool)
+	PutState(root [32]byte, state *types.State) error
+	// ForEachBlock calls fn for each stored block whose slot is in r, in no
+	// particular order, until fn returns false. fn may call back into the
+	// store.
+	ForEachBlock(r SlotRange, fn func(root [32]byte, block *types.Block) bool)
+	// ForEachState is ForEachBlock for states. Backends that do not hold
+	// states in memory decode or rebuild each one, so it is expensive over
+	// wide ranges.
+	ForEachState(r SlotRange, fn func(root [32]byte, state *types.State) bool)
+	// GetChildren returns the roots of the stored blocks whose parent is
+	// root, in no particular order. It is kept up to date as blocks are
+	// written and deleted, so callers need not scan every block.
+	GetChildren(root [32]byte) [][32]byte
+	// DeleteBlocks removes blocks together with their signed envelopes and
+	// states.
+	DeleteBlocks(roots [][32]byte) error
+	// DeleteStates removes the states of blocks, keeping the blocks.
+	DeleteStates(roots [][32]byte) error
+	// Batch starts a set of writes that become visible together on Commit.
+	Batch() Batch
+
+	// GetCanonicalRoot returns the root of the canonical block at slot, as
+	// last recorded by fork choice. Empty slots have no entry.
+	GetCanonicalRoot(slot uint64) ([32]byte, bool)
+	// PutCanonicalRoot records root as the canonical block at slot.
+	PutCanonicalRoot(slot uint64, root [32]byte) error
+	// DeleteCanonicalRoot clears the canonical entry at slot.
+	DeleteCanonicalRoot(slot uint64) error
+}
+
+// SlotRange selects the slots From <= slot < To. A zero To leaves the range
+// unbounded above, so the zero SlotRange selects every slot.
+type SlotRange struct {
+	From, To uint64
+}
+
+// Contains reports whether slot is in the range.
+func (r SlotRange) Contains(slot uint64) bool {
+	return slot >= r.From && (r.To == 0 || slot < r.To)
+}
+
+// Stats is a snapshot of how much a Store holds. Backends report it through
+// an optional Stats method, which wrappers forward.
+type Stats struct {
+	Blocks    int
+	States    int
+	DiskBytes int64 // zero for stores kept only in memory
+}
+
+// Batch collects writes to a Store. Nothing is visible to readers until
+// Commit, which applies all writes at once; a persistent backend writes them
+// atomically. A batch must not be used after Commit. Errors from the
+// writes, including values that fail to encode, are returned by Commit, and
+// a failed Commit applies nothing.
+type Batch interface {
+	PutBlock(root [32]byte, block *types.Block)
+	PutSignedBlock(root [32]byte, sb *types.SignedBlockWithAttestation)
 	PutState(root [32]byte, state *types.State)
-	GetAllBlocks() map[[32]byte]*types.Block
-	GetAllStates() map[[32]byte]*types.State
+	Commit() error
+}
+
+// ChildIndex maps block roots to the roots of stored blocks that have them
+// as parent. Backends keep one next to their blocks to serve GetChildren; it
+// is not safe for concurrent use.
+type ChildIndex map[[32]byte][][32]byte
+
+// Add records root as a child of parent. Adding it twice is a no-op.
+func (x ChildIndex) Add(root, parent [32]byte) {
+	for _, c := range x[parent] {
+		if c == root {
+			return
+		}
+	}
+	x[parent] = append(x[parent], root)
+}
+
+// Remove drops root from the children of parent.
+func (x ChildIndex) Remove(root, parent [32]byte) {
+	kids := x[parent]
+	for i, c := range kids {
+		if c == root {
+			kids[i] = kids[len(kids)-1]
+			kids = kids[:len(kids)-1]
+			break
+		}
+	}
+	if len(kids) == 0 {
+		delete(x, parent)
+	} else {
+		x[parent] = kids
+	}
+}
+
+// Children returns a copy of the children of root.
+func (x ChildIndex) Children(root [32]byte) [][32]byte {
+	return append([][32]byte(nil), x[root]...)
 }
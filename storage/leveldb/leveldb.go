@@ -0,0 +1,544 @@
+// Package leveldb is a persistent storage.Store backed by LevelDB, so the
+// chain survives node restarts.
+//
+// LevelDB rather than Pebble: goleveldb is pure Go and already in the module
+// graph through the libp2p datastores, while Pebble would add a large
+// dependency tree for a write load of a few blocks and states a slot, well
+// within what LevelDB handles. The backend sits behind storage.Store, so
+// another engine can be added as a sibling package without touching callers.
+package leveldb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+
+	"github.com/geanlabs/gean/internal/lru"
+	"github.com/geanlabs/gean/observability/logging"
+	"github.com/geanlabs/gean/observability/metrics"
+	"github.com/geanlabs/gean/storage"
+	"github.com/geanlabs/gean/storage/migrate"
+	"github.com/geanlabs/gean/types"
+)
+
+// Key prefixes; each is followed by a 32-byte block root, except
+// prefixCanonical, which is followed by a big-endian slot, and
+// prefixCorrupt, which is followed by the full key of a quarantined entry.
+// Keys starting with 'm' hold metadata such as migrate.VersionKey.
+const (
+	prefixBlock       = 'b'
+	prefixSignedBlock = 'e'
+	prefixState       = 's'
+	prefixCanonical   = 'c'
+	prefixCorrupt     = 'x'
+)
+
+// migrations upgrade databases written by older builds, in order. Append a
+// migration whenever the key layout or value encoding changes.
+var migrations []migrate.Migration
+
+// stateCacheSize is the number of decoded states kept in memory.
+const stateCacheSize = 64
+
+// Store is a LevelDB implementation of storage.Store. Values are SSZ
+// encoded. All blocks are also kept in memory, since fork choice walks them
+// constantly; states are decoded on read and recently used ones cached.
+//
+// Values are checked as they are read from disk: a block or signed block
+// must hash to its key and a state to its block's state root. Entries that
+// fail to decode or verify are moved to a corrupt bucket, kept for
+// inspection, and read as missing.
+//
+// Write failures are returned to the caller and leave the in-memory block
+// index and state cache as they were.
+type Store struct {
+	db   *leveldb.DB
+	path string
+	log  *slog.Logger
+
+	mu         sync.RWMutex
+	blocks     map[[32]byte]*types.Block
+	children   storage.ChildIndex
+	stateRoots map[[32]byte]struct{} // roots with a stored state
+
+	states *lru.Cache[[32]byte, *types.State]
+}
+
+// Open opens or creates the database at path, migrates it to the current
+// schema and loads its blocks. Databases from a newer schema are refused.
+func Open(path string) (*Store, error) {
+	db, err := leveldb.OpenFile(path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("open leveldb %s: %w", path, err)
+	}
+	if err := migrate.Run(db, migrations); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("open leveldb %s: %w", path, err)
+	}
+	s := &Store{
+		db:         db,
+		path:       path,
+		log:        logging.NewComponentLogger(logging.CompStorage),
+		blocks:     make(map[[32]byte]*types.Block),
+		children:   make(storage.ChildIndex),
+		stateRoots: make(map[[32]byte]struct{}),
+		states:     lru.New[[32]byte, *types.State](stateCacheSize),
+	}
+	if err := s.loadBlocks(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err := s.loadStateRoots(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// loadBlocks reads every block into memory, quarantining those that do not
+// decode or hash to their key.
+func (s *Store) loadBlocks() error {
+	type corrupt struct {
+		key, data []byte
+		reason    string
+	}
+	var bad []corrupt
+	iter := s.db.NewIterator(util.BytesPrefix([]byte{prefixBlock}), nil)
+	for iter.Next() {
+		var root [32]byte
+		copy(root[:], iter.Key()[1:])
+		b := new(types.Block)
+		reason := ""
+		if err := b.UnmarshalSSZ(iter.Value()); err != nil {
+			reason = err.Error()
+		} else if h, err := b.HashTreeRoot(); err != nil || h != root {
+			reason = "block root mismatch"
+		}
+		if reason != "" {
+			bad = append(bad, corrupt{slices.Clone(iter.Key()), slices.Clone(iter.Value()), reason})
+			continue
+		}
+		s.blocks[root] = b
+		s.children.Add(root, b.ParentRoot)
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return fmt.Errorf("load blocks: %w", err)
+	}
+	for _, c := range bad {
+		s.quarantine(c.key, c.data, c.reason)
+	}
+	return nil
+}
+
+// loadStateRoots records which roots have a stored state. Only keys are
+// kept; states are decoded on read.
+func (s *Store) loadStateRoots() error {
+	iter := s.db.NewIterator(util.BytesPrefix([]byte{prefixState}), nil)
+	defer iter.Release()
+	for iter.Next() {
+		var root [32]byte
+		copy(root[:], iter.Key()[1:])
+		s.stateRoots[root] = struct{}{}
+	}
+	if err := iter.Error(); err != nil {
+		return fmt.Errorf("load state roots: %w", err)
+	}
+	return nil
+}
+
+// quarantine moves the entry at k to the corrupt bucket so it is no longer
+// served.
+func (s *Store) quarantine(k, data []byte, reason string) {
+	var root [32]byte
+	copy(root[:], k[1:])
+	s.log.Error("quarantining corrupt database entry",
+		"kind", string(k[0]),
+		"root", logging.ShortHash(root),
+		"reason", reason,
+	)
+	metrics.StorageCorruptEntries.WithLabelValues(string(k[0])).Inc()
+	batch := new(leveldb.Batch)
+	batch.Put(append([]byte{prefixCorrupt}, k...), data)
+	batch.Delete(k)
+	if err := s.write(batch); err != nil {
+		s.log.Error("failed to quarantine database entry", "root", logging.ShortHash(root), "err", err)
+		return
+	}
+	if k[0] == prefixState {
+		s.mu.Lock()
+		delete(s.stateRoots, root)
+		s.mu.Unlock()
+	}
+}
+
+// validState reports whether state hashes to the state root of the block
+// stored under root. States of unknown blocks cannot be checked and pass.
+func (s *Store) validState(root [32]byte, state *types.State) bool {
+	b, ok := s.GetBlock(root)
+	if !ok {
+		return true
+	}
+	h, err := state.HashTreeRoot()
+	return err == nil && h == b.StateRoot
+}
+
+// SetStateCacheSize changes how many decoded states are kept in memory.
+func (s *Store) SetStateCacheSize(n int) {
+	s.states.Resize(n)
+}
+
+// ShedStateCache empties the state cache and returns how many states it
+// held.
+func (s *Store) ShedStateCache() int {
+	return s.states.Clear()
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// NumBlocks returns the number of stored blocks.
+func (s *Store) NumBlocks() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.blocks)
+}
+
+// Stats returns the number of stored blocks and states and the size of the
+// database directory, including the journal of writes not yet compacted.
+func (s *Store) Stats() storage.Stats {
+	s.mu.RLock()
+	st := storage.Stats{Blocks: len(s.blocks), States: len(s.stateRoots)}
+	s.mu.RUnlock()
+	entries, err := os.ReadDir(s.path)
+	if err != nil {
+		s.log.Warn("failed to read database size", "err", err)
+		return st
+	}
+	for _, e := range entries {
+		if info, err := e.Info(); err == nil && info.Mode().IsRegular() {
+			st.DiskBytes += info.Size()
+		}
+	}
+	return st
+}
+
+func key(prefix byte, root [32]byte) []byte {
+	return append([]byte{prefix}, root[:]...)
+}
+
+// put encodes and writes v.
+func (s *Store) put(prefix byte, root [32]byte, v interface{ MarshalSSZ() ([]byte, error) }) error {
+	data, err := v.MarshalSSZ()
+	if err != nil {
+		return fmt.Errorf("encode %c %x: %w", prefix, root, err)
+	}
+	if err := s.db.Put(key(prefix, root), data, nil); err != nil {
+		return fmt.Errorf("write %c %x: %w", prefix, root, err)
+	}
+	return nil
+}
+
+// get reads and decodes the value at prefix and root, then checks it with
+// valid. Values that fail either are quarantined.
+func (s *Store) get(prefix byte, root [32]byte, v interface{ UnmarshalSSZ([]byte) error }, valid func() bool) bool {
+	k := key(prefix, root)
+	data, err := s.db.Get(k, nil)
+	if err != nil {
+		if err != leveldb.ErrNotFound {
+			s.log.Error("failed to read from database", "kind", string(prefix), "root", logging.ShortHash(root), "err", err)
+		}
+		return false
+	}
+	if err := v.UnmarshalSSZ(data); err != nil {
+		s.quarantine(k, data, err.Error())
+		return false
+	}
+	if !valid() {
+		s.quarantine(k, data, "root mismatch")
+		return false
+	}
+	return true
+}
+
+func (s *Store) GetBlock(root [32]byte) (*types.Block, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	b, ok := s.blocks[root]
+	return b, ok
+}
+
+func (s *Store) PutBlock(root [32]byte, block *types.Block) error {
+	if err := s.put(prefixBlock, root, block); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blocks[root] = block
+	s.children.Add(root, block.ParentRoot)
+	return nil
+}
+
+func (s *Store) GetChildren(root [32]byte) [][32]byte {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.children.Children(root)
+}
+
+func (s *Store) GetSignedBlock(root [32]byte) (*types.SignedBlockWithAttestation, bool) {
+	sb := new(types.SignedBlockWithAttestation)
+	valid := func() bool {
+		if sb.Message == nil || sb.Message.Block == nil {
+			return false
+		}
+		h, err := sb.Message.Block.HashTreeRoot()
+		return err == nil && h == root
+	}
+	if !s.get(prefixSignedBlock, root, sb, valid) {
+		return nil, false
+	}
+	return sb, true
+}
+
+func (s *Store) PutSignedBlock(root [32]byte, sb *types.SignedBlockWithAttestation) error {
+	return s.put(prefixSignedBlock, root, sb)
+}
+
+// GetState serves cached states. A miss is read, decoded and verified
+// against its block's state root with no lock held, so a slow hash does not
+// hold up other reads; concurrent misses for one root may both load it.
+func (s *Store) GetState(root [32]byte) (*types.State, bool) {
+	if st, ok := s.states.Get(root); ok {
+		return st, true
+	}
+	st := new(types.State)
+	if !s.get(prefixState, root, st, func() bool { return s.validState(root, st) }) {
+		return nil, false
+	}
+	s.states.Add(root, st)
+	return st, true
+}
+
+func (s *Store) PutState(root [32]byte, state *types.State) error {
+	if err := s.put(prefixState, root, state); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.stateRoots[root] = struct{}{}
+	s.mu.Unlock()
+	s.states.Add(root, state.Copy())
+	return nil
+}
+
+// ForEachBlock calls fn on the blocks in r, which are held in memory. The
+// matching blocks are collected first, so fn may write to the store.
+func (s *Store) ForEachBlock(r storage.SlotRange, fn func(root [32]byte, block *types.Block) bool) {
+	s.mu.RLock()
+	roots := make([][32]byte, 0, len(s.blocks))
+	blocks := make([]*types.Block, 0, len(s.blocks))
+	for root, b := range s.blocks {
+		if r.Contains(b.Slot) {
+			roots = append(roots, root)
+			blocks = append(blocks, b)
+		}
+	}
+	s.mu.RUnlock()
+	for i, root := range roots {
+		if !fn(root, blocks[i]) {
+			return
+		}
+	}
+}
+
+// ForEachState streams the states in r from a database snapshot, decoding
+// and verifying one at a time. States of known blocks outside r are skipped
+// undecoded.
+func (s *Store) ForEachState(r storage.SlotRange, fn func(root [32]byte, state *types.State) bool) {
+	iter := s.db.NewIterator(util.BytesPrefix([]byte{prefixState}), nil)
+	defer iter.Release()
+	for iter.Next() {
+		var root [32]byte
+		copy(root[:], iter.Key()[1:])
+		if b, ok := s.GetBlock(root); ok && !r.Contains(b.Slot) {
+			continue
+		}
+		st := new(types.State)
+		if err := st.UnmarshalSSZ(iter.Value()); err != nil {
+			s.quarantine(slices.Clone(iter.Key()), slices.Clone(iter.Value()), err.Error())
+			continue
+		}
+		if !s.validState(root, st) {
+			s.quarantine(slices.Clone(iter.Key()), slices.Clone(iter.Value()), "root mismatch")
+			continue
+		}
+		if !r.Contains(st.Slot) {
+			continue
+		}
+		if !fn(root, st) {
+			return
+		}
+	}
+}
+
+func (s *Store) DeleteBlocks(roots [][32]byte) error {
+	batch := new(leveldb.Batch)
+	for _, root := range roots {
+		batch.Delete(key(prefixBlock, root))
+		batch.Delete(key(prefixSignedBlock, root))
+		batch.Delete(key(prefixState, root))
+	}
+	if err := s.write(batch); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	for _, root := range roots {
+		if b, ok := s.blocks[root]; ok {
+			s.children.Remove(root, b.ParentRoot)
+		}
+		delete(s.blocks, root)
+		delete(s.stateRoots, root)
+	}
+	s.mu.Unlock()
+	s.states.Remove(roots...)
+	return nil
+}
+
+func (s *Store) DeleteStates(roots [][32]byte) error {
+	batch := new(leveldb.Batch)
+	for _, root := range roots {
+		batch.Delete(key(prefixState, root))
+	}
+	if err := s.write(batch); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	for _, root := range roots {
+		delete(s.stateRoots, root)
+	}
+	s.mu.Unlock()
+	s.states.Remove(roots...)
+	return nil
+}
+
+func (s *Store) write(batch *leveldb.Batch) error {
+	if err := s.db.Write(batch, nil); err != nil {
+		return fmt.Errorf("write %d ops: %w", batch.Len(), err)
+	}
+	return nil
+}
+
+func canonicalKey(slot uint64) []byte {
+	return binary.BigEndian.AppendUint64([]byte{prefixCanonical}, slot)
+}
+
+func (s *Store) GetCanonicalRoot(slot uint64) ([32]byte, bool) {
+	var root [32]byte
+	data, err := s.db.Get(canonicalKey(slot), nil)
+	if err != nil {
+		if err != leveldb.ErrNotFound {
+			s.log.Error("failed to read from database", "kind", "c", "slot", slot, "err", err)
+		}
+		return root, false
+	}
+	if len(data) != len(root) {
+		s.log.Error("failed to decode database value", "kind", "c", "slot", slot, "len", len(data))
+		return root, false
+	}
+	copy(root[:], data)
+	return root, true
+}
+
+func (s *Store) PutCanonicalRoot(slot uint64, root [32]byte) error {
+	if err := s.db.Put(canonicalKey(slot), root[:], nil); err != nil {
+		return fmt.Errorf("write canonical root at slot %d: %w", slot, err)
+	}
+	return nil
+}
+
+func (s *Store) DeleteCanonicalRoot(slot uint64) error {
+	if err := s.db.Delete(canonicalKey(slot), nil); err != nil {
+		return fmt.Errorf("delete canonical root at slot %d: %w", slot, err)
+	}
+	return nil
+}
+
+// Batch returns a batch written to the database in one atomic write.
+func (s *Store) Batch() storage.Batch {
+	return &batch{s: s, b: new(leveldb.Batch)}
+}
+
+type batch struct {
+	s      *Store
+	b      *leveldb.Batch
+	blocks map[[32]byte]*types.Block
+	states map[[32]byte]*types.State
+	err    error // first encoding error, returned by Commit
+}
+
+func (b *batch) put(prefix byte, root [32]byte, v interface{ MarshalSSZ() ([]byte, error) }) bool {
+	if b.err != nil {
+		return false
+	}
+	data, err := v.MarshalSSZ()
+	if err != nil {
+		b.err = fmt.Errorf("encode %c %x: %w", prefix, root, err)
+		return false
+	}
+	b.b.Put(key(prefix, root), data)
+	return true
+}
+
+func (b *batch) PutBlock(root [32]byte, block *types.Block) {
+	if b.put(prefixBlock, root, block) {
+		if b.blocks == nil {
+			b.blocks = make(map[[32]byte]*types.Block)
+		}
+		b.blocks[root] = block
+	}
+}
+
+func (b *batch) PutSignedBlock(root [32]byte, sb *types.SignedBlockWithAttestation) {
+	b.put(prefixSignedBlock, root, sb)
+}
+
+func (b *batch) PutState(root [32]byte, state *types.State) {
+	if b.put(prefixState, root, state) {
+		if b.states == nil {
+			b.states = make(map[[32]byte]*types.State)
+		}
+		b.states[root] = state.Copy()
+	}
+}
+
+// Commit writes the batch. If a value failed to encode or the write fails,
+// nothing is applied.
+func (b *batch) Commit() error {
+	if b.err != nil {
+		return b.err
+	}
+	if err := b.s.write(b.b); err != nil {
+		return err
+	}
+	b.s.mu.Lock()
+	for root, block := range b.blocks {
+		b.s.blocks[root] = block
+		b.s.children.Add(root, block.ParentRoot)
+	}
+	for root := range b.states {
+		b.s.stateRoots[root] = struct{}{}
+	}
+	b.s.mu.Unlock()
+	for root, state := range b.states {
+		b.s.states.Add(root, state)
+	}
+	return nil
+}
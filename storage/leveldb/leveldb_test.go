@@ -0,0 +1,434 @@
+package leveldb_test
+
+import (
+	"encoding/binary"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	goleveldb "github.com/syndtr/goleveldb/leveldb"
+
+	"github.com/geanlabs/gean/chain/statetransition"
+	"github.com/geanlabs/gean/storage"
+	"github.com/geanlabs/gean/storage/leveldb"
+	"github.com/geanlabs/gean/storage/migrate"
+	"github.com/geanlabs/gean/types"
+)
+
+func openStore(t *testing.T, path string) *leveldb.Store {
+	t.Helper()
+	s, err := leveldb.Open(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	return s
+}
+
+func genesisState(t *testing.T) *types.State {
+	t.Helper()
+	validators := []*types.Validator{{Index: 0}, {Index: 1}}
+	return statetransition.GenerateGenesis(1000, validators)
+}
+
+// stateAt returns the genesis state moved to slot.
+func stateAt(t *testing.T, slot uint64) *types.State {
+	t.Helper()
+	st := genesisState(t)
+	st.Slot = slot
+	return st
+}
+
+// newBlock returns a block at slot and its root. A non-nil state becomes the
+// block's post-state, so both pass the store's integrity checks.
+func newBlock(slot uint64, parent [32]byte, state *types.State) ([32]byte, *types.Block) {
+	block := &types.Block{Slot: slot, ParentRoot: parent, Body: &types.BlockBody{}}
+	if state != nil {
+		block.StateRoot, _ = state.HashTreeRoot()
+	}
+	root, _ := block.HashTreeRoot()
+	return root, block
+}
+
+func TestDataSurvivesReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chain")
+	s := openStore(t, path)
+
+	state := stateAt(t, 3)
+	stateRoot, _ := state.HashTreeRoot()
+	block := &types.Block{Slot: 3, ProposerIndex: 1, StateRoot: stateRoot, Body: &types.BlockBody{}}
+	root, _ := block.HashTreeRoot()
+	s.PutBlock(root, block)
+	s.PutSignedBlock(root, &types.SignedBlockWithAttestation{
+		Message: &types.BlockWithAttestation{
+			Block:               block,
+			ProposerAttestation: &types.Attestation{Data: &types.AttestationData{}},
+		},
+	})
+	s.PutState(root, state)
+	if err := s.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	s = openStore(t, path)
+	defer s.Close()
+
+	if s.NumBlocks() != 1 {
+		t.Fatalf("NumBlocks = %d, want 1", s.NumBlocks())
+	}
+	got, ok := s.GetBlock(root)
+	if !ok || got.Slot != 3 || got.ProposerIndex != 1 {
+		t.Fatalf("GetBlock = %+v, %v", got, ok)
+	}
+	found := false
+	s.ForEachBlock(storage.SlotRange{}, func(r [32]byte, _ *types.Block) bool {
+		found = r == root
+		return !found
+	})
+	if !found {
+		t.Fatal("ForEachBlock missing block after reopen")
+	}
+	sb, ok := s.GetSignedBlock(root)
+	if !ok || sb.Message.Block.Slot != 3 {
+		t.Fatalf("GetSignedBlock = %+v, %v", sb, ok)
+	}
+	st, ok := s.GetState(root)
+	if !ok {
+		t.Fatal("expected state after reopen")
+	}
+	want, _ := state.HashTreeRoot()
+	have, _ := st.HashTreeRoot()
+	if have != want {
+		t.Fatal("state root changed across reopen")
+	}
+	states := 0
+	s.ForEachState(storage.SlotRange{}, func([32]byte, *types.State) bool {
+		states++
+		return true
+	})
+	if states != 1 {
+		t.Fatalf("ForEachState visited %d states, want 1", states)
+	}
+}
+
+func TestGetMissing(t *testing.T) {
+	s := openStore(t, t.TempDir())
+	defer s.Close()
+
+	if _, ok := s.GetBlock([32]byte{0xff}); ok {
+		t.Fatal("expected missing block")
+	}
+	if _, ok := s.GetSignedBlock([32]byte{0xff}); ok {
+		t.Fatal("expected missing signed block")
+	}
+	if _, ok := s.GetState([32]byte{0xff}); ok {
+		t.Fatal("expected missing state")
+	}
+}
+
+func TestShedStateCacheKeepsStatesReadable(t *testing.T) {
+	s := openStore(t, t.TempDir())
+	defer s.Close()
+
+	for i := range 4 {
+		st := genesisState(t)
+		st.Slot = uint64(i)
+		s.PutState([32]byte{byte(i + 1)}, st)
+	}
+	s.SetStateCacheSize(2)
+	if n := s.ShedStateCache(); n != 2 {
+		t.Fatalf("ShedStateCache = %d, want 2", n)
+	}
+	for i := range 4 {
+		st, ok := s.GetState([32]byte{byte(i + 1)})
+		if !ok || st.Slot != uint64(i) {
+			t.Fatalf("state %d: got %+v, %v", i, st, ok)
+		}
+	}
+}
+
+func TestDeleteBlocksAndStates(t *testing.T) {
+	path := t.TempDir()
+	s := openStore(t, path)
+
+	var roots [][32]byte
+	for slot := uint64(1); slot <= 3; slot++ {
+		st := stateAt(t, slot)
+		root, block := newBlock(slot, [32]byte{}, st)
+		s.PutBlock(root, block)
+		s.PutState(root, st)
+		roots = append(roots, root)
+	}
+	s.DeleteBlocks(roots[:1])
+	s.DeleteStates(roots[1:2])
+	s.Close()
+
+	s = openStore(t, path)
+	defer s.Close()
+	if _, ok := s.GetBlock(roots[0]); ok {
+		t.Fatal("deleted block loaded on reopen")
+	}
+	if _, ok := s.GetState(roots[0]); ok {
+		t.Fatal("state of deleted block still readable")
+	}
+	if _, ok := s.GetBlock(roots[1]); !ok {
+		t.Fatal("DeleteStates removed the block")
+	}
+	if _, ok := s.GetState(roots[1]); ok {
+		t.Fatal("deleted state still readable")
+	}
+	if _, ok := s.GetState(roots[2]); !ok {
+		t.Fatal("untouched state missing")
+	}
+}
+
+func TestBatchCommitsTogether(t *testing.T) {
+	path := t.TempDir()
+	s := openStore(t, path)
+	state := stateAt(t, 9)
+	root, block := newBlock(9, [32]byte{}, state)
+
+	b := s.Batch()
+	b.PutBlock(root, block)
+	b.PutState(root, state)
+	if _, ok := s.GetBlock(root); ok {
+		t.Fatal("block visible before commit")
+	}
+	if _, ok := s.GetState(root); ok {
+		t.Fatal("state visible before commit")
+	}
+	if err := b.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	if _, ok := s.GetBlock(root); !ok {
+		t.Fatal("block missing after commit")
+	}
+	s.Close()
+
+	s = openStore(t, path)
+	defer s.Close()
+	if _, ok := s.GetBlock(root); !ok {
+		t.Fatal("committed block lost on reopen")
+	}
+	if _, ok := s.GetState(root); !ok {
+		t.Fatal("committed state lost on reopen")
+	}
+}
+
+// Writes that fail are returned and leave the store as it was.
+func TestWriteErrorsAreReturned(t *testing.T) {
+	s := openStore(t, t.TempDir())
+	state := stateAt(t, 4)
+	root, block := newBlock(4, [32]byte{}, state)
+	s.Close()
+
+	if err := s.PutBlock(root, block); err == nil {
+		t.Fatal("PutBlock on a closed database succeeded")
+	}
+	if _, ok := s.GetBlock(root); ok {
+		t.Fatal("failed block write is visible")
+	}
+	if err := s.PutState(root, state); err == nil {
+		t.Fatal("PutState on a closed database succeeded")
+	}
+	if _, ok := s.GetState(root); ok {
+		t.Fatal("failed state write is cached")
+	}
+	b := s.Batch()
+	b.PutBlock(root, block)
+	b.PutState(root, state)
+	if err := b.Commit(); err == nil {
+		t.Fatal("batch commit on a closed database succeeded")
+	}
+	if _, ok := s.GetBlock(root); ok {
+		t.Fatal("failed batch is visible")
+	}
+	if err := s.PutCanonicalRoot(4, root); err == nil {
+		t.Fatal("PutCanonicalRoot on a closed database succeeded")
+	}
+	if err := s.DeleteBlocks([][32]byte{root}); err == nil {
+		t.Fatal("DeleteBlocks on a closed database succeeded")
+	}
+}
+
+func TestCanonicalIndexSurvivesReopen(t *testing.T) {
+	path := t.TempDir()
+	s := openStore(t, path)
+	s.PutCanonicalRoot(1, [32]byte{1})
+	s.PutCanonicalRoot(2, [32]byte{2})
+	s.DeleteCanonicalRoot(2)
+	s.Close()
+
+	s = openStore(t, path)
+	defer s.Close()
+	if root, ok := s.GetCanonicalRoot(1); !ok || root != ([32]byte{1}) {
+		t.Fatalf("slot 1 = %x, %v", root, ok)
+	}
+	if _, ok := s.GetCanonicalRoot(2); ok {
+		t.Fatal("deleted canonical entry still present")
+	}
+	if s.NumBlocks() != 0 {
+		t.Fatalf("canonical entries loaded as blocks: %d", s.NumBlocks())
+	}
+}
+
+func TestForEachFiltersBySlot(t *testing.T) {
+	s := openStore(t, t.TempDir())
+	defer s.Close()
+
+	for slot := uint64(1); slot <= 5; slot++ {
+		st := stateAt(t, slot)
+		root, block := newBlock(slot, [32]byte{}, st)
+		s.PutBlock(root, block)
+		s.PutState(root, st)
+	}
+
+	r := storage.SlotRange{From: 2, To: 4}
+	var blockSlots, stateSlots []uint64
+	s.ForEachBlock(r, func(_ [32]byte, b *types.Block) bool {
+		blockSlots = append(blockSlots, b.Slot)
+		return true
+	})
+	s.ForEachState(r, func(_ [32]byte, st *types.State) bool {
+		stateSlots = append(stateSlots, st.Slot)
+		return true
+	})
+	slices.Sort(blockSlots)
+	slices.Sort(stateSlots)
+	if !slices.Equal(blockSlots, []uint64{2, 3}) || !slices.Equal(stateSlots, []uint64{2, 3}) {
+		t.Fatalf("blocks at %v, states at %v; want [2 3] for both", blockSlots, stateSlots)
+	}
+
+	visited := 0
+	s.ForEachBlock(storage.SlotRange{}, func([32]byte, *types.Block) bool {
+		visited++
+		return false
+	})
+	if visited != 1 {
+		t.Fatalf("iteration continued after fn returned false: %d calls", visited)
+	}
+}
+
+func TestOpenRefusesNewerSchema(t *testing.T) {
+	path := t.TempDir()
+	s := openStore(t, path)
+	s.Close()
+
+	db, err := goleveldb.OpenFile(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Put(migrate.VersionKey, binary.BigEndian.AppendUint64(nil, 1<<32), nil)
+	db.Close()
+
+	if s, err := leveldb.Open(path); err == nil {
+		s.Close()
+		t.Fatal("opened a database from a newer schema")
+	}
+}
+
+func TestChildIndexRebuiltOnReopen(t *testing.T) {
+	path := t.TempDir()
+	s := openStore(t, path)
+	parent, block := newBlock(1, [32]byte{}, nil)
+	s.PutBlock(parent, block)
+	gone, block := newBlock(2, parent, nil)
+	s.PutBlock(gone, block)
+	kept, block := newBlock(3, parent, nil)
+	b := s.Batch()
+	b.PutBlock(kept, block)
+	b.Commit()
+	s.DeleteBlocks([][32]byte{gone})
+	s.Close()
+
+	s = openStore(t, path)
+	defer s.Close()
+	if kids := s.GetChildren(parent); !slices.Equal(kids, [][32]byte{kept}) {
+		t.Fatalf("children = %x, want %x", kids, kept)
+	}
+}
+
+func TestCorruptEntriesAreQuarantined(t *testing.T) {
+	path := t.TempDir()
+	s := openStore(t, path)
+	state := stateAt(t, 1)
+	root, block := newBlock(1, [32]byte{}, state)
+	s.PutBlock(root, block)
+	s.PutSignedBlock(root, &types.SignedBlockWithAttestation{
+		Message: &types.BlockWithAttestation{
+			Block:               block,
+			ProposerAttestation: &types.Attestation{Data: &types.AttestationData{}},
+		},
+	})
+	s.PutState(root, state)
+	s.Close()
+
+	// Store another block's encoding under a key it does not hash to, and
+	// a state that is not the block's post-state.
+	forged := [32]byte{0xee}
+	_, other := newBlock(2, [32]byte{}, nil)
+	otherSSZ, _ := other.MarshalSSZ()
+	wrongState, _ := stateAt(t, 2).MarshalSSZ()
+	db, err := goleveldb.OpenFile(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Put(append([]byte{'b'}, forged[:]...), otherSSZ, nil)
+	db.Put(append([]byte{'s'}, root[:]...), wrongState, nil)
+	db.Close()
+
+	s = openStore(t, path)
+	if _, ok := s.GetBlock(forged); ok {
+		t.Fatal("block under a forged key served")
+	}
+	if _, ok := s.GetBlock(root); !ok {
+		t.Fatal("intact block quarantined")
+	}
+	if _, ok := s.GetSignedBlock(root); !ok {
+		t.Fatal("intact signed block quarantined")
+	}
+	if _, ok := s.GetState(root); ok {
+		t.Fatal("mismatched state served")
+	}
+	s.Close()
+
+	db, err = goleveldb.OpenFile(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, k := range [][]byte{append([]byte("xb"), forged[:]...), append([]byte("xs"), root[:]...)} {
+		if ok, _ := db.Has(k, nil); !ok {
+			t.Fatalf("quarantined entry %q missing", k[:2])
+		}
+	}
+}
+
+func TestStatsCountBlocksAndStates(t *testing.T) {
+	path := t.TempDir()
+	s := openStore(t, path)
+	state := stateAt(t, 1)
+	root, block := newBlock(1, [32]byte{}, state)
+	s.PutBlock(root, block)
+	s.PutState(root, state)
+	other, block := newBlock(2, root, nil)
+	b := s.Batch()
+	b.PutBlock(other, block)
+	b.PutState(other, stateAt(t, 2))
+	b.Commit()
+
+	if st := s.Stats(); st.Blocks != 2 || st.States != 2 || st.DiskBytes == 0 {
+		t.Fatalf("stats = %+v, want 2 blocks, 2 states, nonzero size", st)
+	}
+	s.DeleteStates([][32]byte{root})
+	s.Close()
+
+	s = openStore(t, path)
+	defer s.Close()
+	if st := s.Stats(); st.Blocks != 2 || st.States != 1 {
+		t.Fatalf("stats after reopen = %+v, want 2 blocks, 1 state", st)
+	}
+	s.DeleteBlocks([][32]byte{other})
+	if st := s.Stats(); st.Blocks != 1 || st.States != 0 {
+		t.Fatalf("stats after delete = %+v, want 1 block, 0 states", st)
+	}
+}
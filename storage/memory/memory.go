@@ -3,26 +3,61 @@ package memory
 import (
 	"sync"
 
+	"github.com/geanlabs/gean/internal/lru"
+	"github.com/geanlabs/gean/storage"
 	"github.com/geanlabs/gean/types"
 )
 
 // Store is an in-memory implementation of storage.Store.
+//
+// States are kept as diffs against their parent block's state and
+// materialized on read, with recently used states held in an LRU.
 type Store struct {
 	mu           sync.RWMutex
 	blocks       map[[32]byte]*types.Block
+	children     storage.ChildIndex
 	signedBlocks map[[32]byte]*types.SignedBlockWithAttestation
-	states       map[[32]byte]*types.State
+	states       map[[32]byte]*stateDiff
+	cache        *lru.Cache[[32]byte, *types.State]
+	canonical    map[uint64][32]byte
 }
 
 // New creates a new in-memory store.
 func New() *Store {
 	return &Store{
 		blocks:       make(map[[32]byte]*types.Block),
+		children:     make(storage.ChildIndex),
 		signedBlocks: make(map[[32]byte]*types.SignedBlockWithAttestation),
-		states:       make(map[[32]byte]*types.State),
+		states:       make(map[[32]byte]*stateDiff),
+		cache:        lru.New[[32]byte, *types.State](stateCacheSize),
+		canonical:    make(map[uint64][32]byte),
 	}
 }
 
+// SetStateCacheSize changes how many materialized states are kept, evicting
+// the least recently used ones if the cache is over the new size.
+func (m *Store) SetStateCacheSize(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cache.Resize(n)
+}
+
+// ShedStateCache empties the state cache and returns how many states it
+// held. States remain readable; they are rebuilt from their diffs on the next
+// read.
+func (m *Store) ShedStateCache() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.cache.Clear()
+}
+
+// Stats returns the number of stored blocks and states.
+func (m *Store) Stats() storage.Stats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return storage.Stats{Blocks: len(m.blocks), States: len(m.states)}
+}
+
 func (m *Store) GetBlock(root [32]byte) (*types.Block, bool) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -30,10 +65,22 @@ func (m *Store) GetBlock(root [32]byte) (*types.Block, bool) {
 	return b, ok
 }
 
-func (m *Store) PutBlock(root [32]byte, block *types.Block) {
+func (m *Store) PutBlock(root [32]byte, block *types.Block) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	m.putBlockLocked(root, block)
+	return nil
+}
+
+func (m *Store) putBlockLocked(root [32]byte, block *types.Block) {
 	m.blocks[root] = block
+	m.children.Add(root, block.ParentRoot)
+}
+
+func (m *Store) GetChildren(root [32]byte) [][32]byte {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.children.Children(root)
 }
 
 func (m *Store) GetSignedBlock(root [32]byte) (*types.SignedBlockWithAttestation, bool) {
@@ -43,25 +90,82 @@ func (m *Store) GetSignedBlock(root [32]byte) (*types.SignedBlockWithAttestation
 	return sb, ok
 }
 
-func (m *Store) PutSignedBlock(root [32]byte, sb *types.SignedBlockWithAttestation) {
+func (m *Store) PutSignedBlock(root [32]byte, sb *types.SignedBlockWithAttestation) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.signedBlocks[root] = sb
+	return nil
 }
 
+// GetState only takes the read lock; the state cache locks itself to record
+// the use, so concurrent reads do not serialize.
 func (m *Store) GetState(root [32]byte) (*types.State, bool) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
-	s, ok := m.states[root]
-	return s, ok
+	return m.getStateLocked(root)
 }
 
-func (m *Store) PutState(root [32]byte, state *types.State) {
+// PutState stores state as a diff against the state of its parent block when
+// that is known, or as a full snapshot otherwise.
+func (m *Store) PutState(root [32]byte, state *types.State) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	m.states[root] = state
+	m.putStateLocked(root, state)
+	return nil
 }
 
+func (m *Store) putStateLocked(root [32]byte, state *types.State) {
+	var parentRoot [32]byte
+	if state.LatestBlockHeader != nil {
+		parentRoot = state.LatestBlockHeader.ParentRoot
+	}
+	parentDiff, ok := m.states[parentRoot]
+	if !ok || parentRoot == root || parentDiff.depth+1 >= snapshotInterval {
+		m.states[root] = snapshotState(state)
+	} else if parent, ok := m.getStateLocked(parentRoot); ok {
+		m.states[root] = diffState(parentRoot, parent, parentDiff.depth, state)
+	} else {
+		m.states[root] = snapshotState(state)
+	}
+	m.cache.Add(root, state.Copy())
+}
+
+// getStateLocked materializes the state for root, replaying diffs from the
+// nearest cached state or snapshot. m.mu must be held, for reading at least.
+func (m *Store) getStateLocked(root [32]byte) (*types.State, bool) {
+	if s, ok := m.cache.Get(root); ok {
+		return s, true
+	}
+	d, ok := m.states[root]
+	if !ok {
+		return nil, false
+	}
+
+	// Collect diffs back to a materialized ancestor.
+	chain := []*stateDiff{d}
+	var base *types.State
+	for d.hasParent {
+		if s, ok := m.cache.Get(d.parent); ok {
+			base = s
+			break
+		}
+		parent, ok := m.states[d.parent]
+		if !ok {
+			return nil, false
+		}
+		chain = append(chain, parent)
+		d = parent
+	}
+
+	state := base
+	for i := len(chain) - 1; i >= 0; i-- {
+		state = chain[i].apply(state)
+	}
+	m.cache.Add(root, state)
+	return state, true
+}
+
+// GetAllBlocks returns a copy of every stored block.
 func (m *Store) GetAllBlocks() map[[32]byte]*types.Block {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -72,12 +176,159 @@ func (m *Store) GetAllBlocks() map[[32]byte]*types.Block {
 	return cp
 }
 
+// GetAllStates materializes every stored state. It is expensive and meant
+// for debugging and tests.
 func (m *Store) GetAllStates() map[[32]byte]*types.State {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 	cp := make(map[[32]byte]*types.State, len(m.states))
-	for k, v := range m.states {
-		cp[k] = v
+	for k := range m.states {
+		if s, ok := m.getStateLocked(k); ok {
+			cp[k] = s
+		}
 	}
 	return cp
 }
+
+// ForEachBlock collects the blocks in r under the lock and calls fn on them
+// after releasing it.
+func (m *Store) ForEachBlock(r storage.SlotRange, fn func(root [32]byte, block *types.Block) bool) {
+	m.mu.RLock()
+	roots := make([][32]byte, 0, len(m.blocks))
+	blocks := make([]*types.Block, 0, len(m.blocks))
+	for root, b := range m.blocks {
+		if r.Contains(b.Slot) {
+			roots = append(roots, root)
+			blocks = append(blocks, b)
+		}
+	}
+	m.mu.RUnlock()
+	for i, root := range roots {
+		if !fn(root, blocks[i]) {
+			return
+		}
+	}
+}
+
+// ForEachState materializes each state in r as fn reaches it. States whose
+// block is stored are filtered by the block's slot without being
+// materialized.
+func (m *Store) ForEachState(r storage.SlotRange, fn func(root [32]byte, state *types.State) bool) {
+	m.mu.RLock()
+	roots := make([][32]byte, 0, len(m.states))
+	for root := range m.states {
+		if b, ok := m.blocks[root]; !ok || r.Contains(b.Slot) {
+			roots = append(roots, root)
+		}
+	}
+	m.mu.RUnlock()
+	for _, root := range roots {
+		st, ok := m.GetState(root)
+		if !ok || !r.Contains(st.Slot) {
+			continue
+		}
+		if !fn(root, st) {
+			return
+		}
+	}
+}
+
+func (m *Store) DeleteBlocks(roots [][32]byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, root := range roots {
+		if b, ok := m.blocks[root]; ok {
+			m.children.Remove(root, b.ParentRoot)
+		}
+		delete(m.blocks, root)
+		delete(m.signedBlocks, root)
+	}
+	m.deleteStatesLocked(roots)
+	return nil
+}
+
+// DeleteStates removes states. Remaining states stored as diffs against a
+// removed one are first rewritten as snapshots.
+func (m *Store) DeleteStates(roots [][32]byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deleteStatesLocked(roots)
+	return nil
+}
+
+func (m *Store) deleteStatesLocked(roots [][32]byte) {
+	gone := make(map[[32]byte]bool, len(roots))
+	for _, root := range roots {
+		if _, ok := m.states[root]; ok {
+			gone[root] = true
+		}
+	}
+	if len(gone) == 0 {
+		return
+	}
+	for root, d := range m.states {
+		if gone[root] || !d.hasParent || !gone[d.parent] {
+			continue
+		}
+		if s, ok := m.getStateLocked(root); ok {
+			m.states[root] = snapshotState(s)
+		}
+	}
+	for root := range gone {
+		delete(m.states, root)
+		m.cache.Remove(root)
+	}
+}
+
+func (m *Store) GetCanonicalRoot(slot uint64) ([32]byte, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	root, ok := m.canonical[slot]
+	return root, ok
+}
+
+func (m *Store) PutCanonicalRoot(slot uint64, root [32]byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.canonical[slot] = root
+	return nil
+}
+
+func (m *Store) DeleteCanonicalRoot(slot uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.canonical, slot)
+	return nil
+}
+
+// Batch returns a batch whose writes are applied under a single lock.
+func (m *Store) Batch() storage.Batch {
+	return &batch{m: m}
+}
+
+type batch struct {
+	m   *Store
+	ops []func()
+}
+
+func (b *batch) PutBlock(root [32]byte, block *types.Block) {
+	b.ops = append(b.ops, func() { b.m.putBlockLocked(root, block) })
+}
+
+func (b *batch) PutSignedBlock(root [32]byte, sb *types.SignedBlockWithAttestation) {
+	b.ops = append(b.ops, func() { b.m.signedBlocks[root] = sb })
+}
+
+func (b *batch) PutState(root [32]byte, state *types.State) {
+	b.ops = append(b.ops, func() { b.m.putStateLocked(root, state) })
+}
+
+func (b *batch) Commit() error {
+	b.m.mu.Lock()
+	defer b.m.mu.Unlock()
+	for _, op := range b.ops {
+		op()
+	}
+	b.ops = nil
+	return nil
+}
@@ -1,8 +1,13 @@
 package memory_test
 
 import (
+	"bytes"
+	"fmt"
+	"slices"
+	"sync"
 	"testing"
 
+	"github.com/geanlabs/gean/storage"
 	"github.com/geanlabs/gean/storage/memory"
 	"github.com/geanlabs/gean/types"
 )
@@ -85,3 +90,249 @@ func TestGetAllStatesCopiesMap(t *testing.T) {
 		t.Fatal("deleting from GetAllStates result should not affect store")
 	}
 }
+
+func TestStateDiffsReconstructLongChain(t *testing.T) {
+	s := memory.New()
+
+	// Long enough to span several snapshots and evict early states from the
+	// materialized-state cache.
+	const n = 200
+	roots := make([][32]byte, n)
+	want := make([][32]byte, n)
+	var parent *types.State
+	for i := 0; i < n; i++ {
+		roots[i] = [32]byte{byte(i), byte(i >> 8), 0xAA}
+		st := &types.State{
+			Config:                   &types.Config{GenesisTime: 1000},
+			Slot:                     uint64(i),
+			LatestBlockHeader:        &types.BlockHeader{Slot: uint64(i)},
+			LatestJustified:          &types.Checkpoint{},
+			LatestFinalized:          &types.Checkpoint{},
+			HistoricalBlockHashes:    [][32]byte{},
+			JustifiedSlots:           []byte{0x01},
+			Validators:               []*types.Validator{{Index: 0}, {Index: 1}},
+			JustificationsRoots:      [][32]byte{},
+			JustificationsValidators: []byte{0x01},
+		}
+		if parent != nil {
+			st = parent.Copy()
+			st.Slot = uint64(i)
+			st.LatestBlockHeader = &types.BlockHeader{Slot: uint64(i), ParentRoot: roots[i-1]}
+			st.HistoricalBlockHashes = append(st.HistoricalBlockHashes, roots[i-1])
+			if i%5 == 0 {
+				st.LatestJustified = &types.Checkpoint{Root: roots[i-1], Slot: uint64(i - 1)}
+				st.JustificationsRoots = append(st.JustificationsRoots, roots[i-1])
+			}
+		}
+		s.PutState(roots[i], st)
+		want[i], _ = st.HashTreeRoot()
+		parent = st
+	}
+
+	for i := 0; i < n; i++ {
+		got, ok := s.GetState(roots[i])
+		if !ok {
+			t.Fatalf("state %d not found", i)
+		}
+		root, err := got.HashTreeRoot()
+		if err != nil {
+			t.Fatalf("hash state %d: %v", i, err)
+		}
+		if root != want[i] {
+			t.Fatalf("state %d reconstructed with root %x, want %x", i, root, want[i])
+		}
+	}
+}
+
+// Reads share the store lock while the state cache tracks recency under
+// its own, so concurrent reads that miss and evict stay consistent.
+func TestConcurrentGetState(t *testing.T) {
+	s := memory.New()
+	s.SetStateCacheSize(4)
+	const n = 64
+	roots := make([][32]byte, n)
+	for i := 0; i < n; i++ {
+		roots[i] = [32]byte{byte(i), 0xBB}
+		st := &types.State{Slot: uint64(i), LatestBlockHeader: &types.BlockHeader{Slot: uint64(i)}}
+		if i > 0 {
+			st.LatestBlockHeader.ParentRoot = roots[i-1]
+		}
+		s.PutState(roots[i], st)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < n; i++ {
+				j := (i*7 + g*13) % n
+				if st, ok := s.GetState(roots[j]); !ok || st.Slot != uint64(j) {
+					errs <- fmt.Errorf("state %d = %v, %v", j, st, ok)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestShedStateCacheKeepsStates(t *testing.T) {
+	s := memory.New()
+	for i := byte(1); i <= 5; i++ {
+		s.PutState([32]byte{i}, &types.State{Slot: uint64(i)})
+	}
+	s.SetStateCacheSize(2)
+	if n := s.ShedStateCache(); n != 2 {
+		t.Fatalf("shed %d cached states, want 2", n)
+	}
+	for i := byte(1); i <= 5; i++ {
+		got, ok := s.GetState([32]byte{i})
+		if !ok || got.Slot != uint64(i) {
+			t.Fatalf("state %d not readable after shedding: %v %v", i, got, ok)
+		}
+	}
+}
+
+func TestDeleteStatesKeepsDescendantDiffsReadable(t *testing.T) {
+	s := memory.New()
+
+	const n = 10
+	roots := make([][32]byte, n)
+	want := make([][32]byte, n)
+	st := &types.State{
+		Config:                   &types.Config{GenesisTime: 1000},
+		LatestBlockHeader:        &types.BlockHeader{},
+		LatestJustified:          &types.Checkpoint{},
+		LatestFinalized:          &types.Checkpoint{},
+		HistoricalBlockHashes:    [][32]byte{},
+		JustifiedSlots:           []byte{0x01},
+		Validators:               []*types.Validator{{Index: 0}},
+		JustificationsRoots:      [][32]byte{},
+		JustificationsValidators: []byte{0x01},
+	}
+	for i := 0; i < n; i++ {
+		roots[i] = [32]byte{byte(i), 0xBB}
+		if i > 0 {
+			st = st.Copy()
+			st.Slot = uint64(i)
+			st.LatestBlockHeader = &types.BlockHeader{Slot: uint64(i), ParentRoot: roots[i-1]}
+			st.HistoricalBlockHashes = append(st.HistoricalBlockHashes, roots[i-1])
+		}
+		s.PutState(roots[i], st)
+		want[i], _ = st.HashTreeRoot()
+	}
+
+	s.PutBlock(roots[0], &types.Block{Slot: 0})
+	s.DeleteBlocks(roots[:1])
+	s.DeleteStates(roots[1:5])
+	s.ShedStateCache()
+
+	if _, ok := s.GetBlock(roots[0]); ok {
+		t.Fatal("deleted block still readable")
+	}
+	for i := 0; i < 5; i++ {
+		if _, ok := s.GetState(roots[i]); ok {
+			t.Fatalf("deleted state %d still readable", i)
+		}
+	}
+	for i := 5; i < n; i++ {
+		got, ok := s.GetState(roots[i])
+		if !ok {
+			t.Fatalf("state %d lost with its ancestors", i)
+		}
+		if root, _ := got.HashTreeRoot(); root != want[i] {
+			t.Fatalf("state %d reconstructed with root %x, want %x", i, root, want[i])
+		}
+	}
+}
+
+func TestBatchVisibleOnlyAfterCommit(t *testing.T) {
+	s := memory.New()
+	root := [32]byte{7}
+
+	b := s.Batch()
+	b.PutBlock(root, &types.Block{Slot: 7})
+	b.PutSignedBlock(root, &types.SignedBlockWithAttestation{})
+	b.PutState(root, &types.State{Slot: 7})
+	if _, ok := s.GetBlock(root); ok {
+		t.Fatal("block visible before commit")
+	}
+	if _, ok := s.GetState(root); ok {
+		t.Fatal("state visible before commit")
+	}
+
+	b.Commit()
+	if _, ok := s.GetBlock(root); !ok {
+		t.Fatal("block missing after commit")
+	}
+	if _, ok := s.GetSignedBlock(root); !ok {
+		t.Fatal("signed block missing after commit")
+	}
+	if st, ok := s.GetState(root); !ok || st.Slot != 7 {
+		t.Fatalf("state after commit = %v, %v", st, ok)
+	}
+}
+
+func TestForEachFiltersBySlot(t *testing.T) {
+	s := memory.New()
+	for slot := uint64(1); slot <= 5; slot++ {
+		root := [32]byte{byte(slot)}
+		s.PutBlock(root, &types.Block{Slot: slot})
+		s.PutState(root, &types.State{Slot: slot})
+	}
+	// A state without a block is filtered by its own slot.
+	s.PutState([32]byte{0xff}, &types.State{Slot: 3})
+
+	r := storage.SlotRange{From: 2, To: 4}
+	var blocks, states int
+	s.ForEachBlock(r, func(_ [32]byte, b *types.Block) bool {
+		if !r.Contains(b.Slot) {
+			t.Fatalf("block at slot %d outside %+v", b.Slot, r)
+		}
+		blocks++
+		return true
+	})
+	s.ForEachState(r, func(_ [32]byte, st *types.State) bool {
+		if !r.Contains(st.Slot) {
+			t.Fatalf("state at slot %d outside %+v", st.Slot, r)
+		}
+		states++
+		return true
+	})
+	if blocks != 2 || states != 3 {
+		t.Fatalf("visited %d blocks and %d states, want 2 and 3", blocks, states)
+	}
+}
+
+func TestChildIndexFollowsWritesAndDeletes(t *testing.T) {
+	s := memory.New()
+	parent := [32]byte{1}
+	s.PutBlock(parent, &types.Block{Slot: 1})
+	s.PutBlock([32]byte{2}, &types.Block{Slot: 2, ParentRoot: parent})
+	b := s.Batch()
+	b.PutBlock([32]byte{3}, &types.Block{Slot: 3, ParentRoot: parent})
+	b.Commit()
+	s.PutBlock([32]byte{2}, &types.Block{Slot: 2, ParentRoot: parent}) // rewrite
+
+	kids := s.GetChildren(parent)
+	slices.SortFunc(kids, func(a, b [32]byte) int { return bytes.Compare(a[:], b[:]) })
+	if !slices.Equal(kids, [][32]byte{{2}, {3}}) {
+		t.Fatalf("children = %x, want [2 3]", kids)
+	}
+
+	s.DeleteBlocks([][32]byte{{2}})
+	if kids := s.GetChildren(parent); !slices.Equal(kids, [][32]byte{{3}}) {
+		t.Fatalf("children after delete = %x, want [3]", kids)
+	}
+	// The index outlives the parent while children remain.
+	s.DeleteBlocks([][32]byte{parent})
+	if kids := s.GetChildren(parent); len(kids) != 1 {
+		t.Fatalf("children of deleted parent = %x, want [3]", kids)
+	}
+}
@@ -0,0 +1,196 @@
+package memory
+
+import (
+	"bytes"
+
+	"github.com/geanlabs/gean/types"
+)
+
+const (
+	// snapshotInterval bounds how many diffs GetState replays: every state
+	// this many blocks away from its last snapshot is stored in full.
+	snapshotInterval = 32
+
+	// stateCacheSize is the number of materialized states kept in the LRU.
+	stateCacheSize = 64
+)
+
+// stateDiff is a state stored relative to its parent block's state. Fields
+// that are nil are inherited from the parent unchanged. A diff without a
+// parent is a full snapshot.
+type stateDiff struct {
+	parent    [32]byte
+	hasParent bool
+	depth     int // diffs since the last snapshot
+
+	slot            uint64
+	header          types.BlockHeader
+	latestJustified types.Checkpoint
+	latestFinalized types.Checkpoint
+
+	// appendedHashes extends the parent's historical block hashes; if
+	// historicalHashes is set it replaces them instead.
+	appendedHashes   [][32]byte
+	historicalHashes [][32]byte
+
+	config                   *types.Config
+	validators               []*types.Validator
+	justifiedSlots           []byte
+	justificationsRoots      [][32]byte
+	justificationsValidators []byte
+}
+
+// snapshotState stores a full copy of state.
+func snapshotState(state *types.State) *stateDiff {
+	s := state.Copy()
+	d := &stateDiff{
+		slot:                     s.Slot,
+		historicalHashes:         s.HistoricalBlockHashes,
+		config:                   s.Config,
+		validators:               s.Validators,
+		justifiedSlots:           s.JustifiedSlots,
+		justificationsRoots:      s.JustificationsRoots,
+		justificationsValidators: s.JustificationsValidators,
+	}
+	d.setCheckpoints(s)
+	if d.historicalHashes == nil {
+		d.historicalHashes = [][32]byte{}
+	}
+	return d
+}
+
+// diffState stores state relative to parent, whose diff entry has the given
+// depth.
+func diffState(parentRoot [32]byte, parent *types.State, parentDepth int, state *types.State) *stateDiff {
+	d := &stateDiff{
+		parent:    parentRoot,
+		hasParent: true,
+		depth:     parentDepth + 1,
+		slot:      state.Slot,
+	}
+	d.setCheckpoints(state)
+
+	if n := len(parent.HistoricalBlockHashes); len(state.HistoricalBlockHashes) >= n &&
+		rootsEqual(parent.HistoricalBlockHashes, state.HistoricalBlockHashes[:n]) {
+		d.appendedHashes = append([][32]byte(nil), state.HistoricalBlockHashes[n:]...)
+	} else {
+		d.historicalHashes = append([][32]byte{}, state.HistoricalBlockHashes...)
+	}
+
+	if !configEqual(parent.Config, state.Config) {
+		d.config = &types.Config{GenesisTime: state.Config.GenesisTime}
+	}
+	if !validatorsEqual(parent.Validators, state.Validators) {
+		d.validators = copyValidators(state.Validators)
+	}
+	if !bytes.Equal(parent.JustifiedSlots, state.JustifiedSlots) {
+		d.justifiedSlots = append([]byte{}, state.JustifiedSlots...)
+	}
+	if !rootsEqual(parent.JustificationsRoots, state.JustificationsRoots) {
+		d.justificationsRoots = append([][32]byte{}, state.JustificationsRoots...)
+	}
+	if !bytes.Equal(parent.JustificationsValidators, state.JustificationsValidators) {
+		d.justificationsValidators = append([]byte{}, state.JustificationsValidators...)
+	}
+	return d
+}
+
+func (d *stateDiff) setCheckpoints(s *types.State) {
+	if s.LatestBlockHeader != nil {
+		d.header = *s.LatestBlockHeader
+	}
+	if s.LatestJustified != nil {
+		d.latestJustified = *s.LatestJustified
+	}
+	if s.LatestFinalized != nil {
+		d.latestFinalized = *s.LatestFinalized
+	}
+}
+
+// apply materializes the state described by d on top of parent, which is
+// nil for snapshots. parent is not modified.
+func (d *stateDiff) apply(parent *types.State) *types.State {
+	header := d.header
+	justified := d.latestJustified
+	finalized := d.latestFinalized
+	out := &types.State{
+		Slot:              d.slot,
+		LatestBlockHeader: &header,
+		LatestJustified:   &justified,
+		LatestFinalized:   &finalized,
+	}
+	if parent != nil {
+		out.Config = parent.Config
+		out.Validators = parent.Validators
+		out.JustifiedSlots = parent.JustifiedSlots
+		out.JustificationsRoots = parent.JustificationsRoots
+		out.JustificationsValidators = parent.JustificationsValidators
+	}
+
+	switch {
+	case d.historicalHashes != nil:
+		out.HistoricalBlockHashes = d.historicalHashes
+	case parent != nil:
+		hashes := make([][32]byte, 0, len(parent.HistoricalBlockHashes)+len(d.appendedHashes))
+		hashes = append(hashes, parent.HistoricalBlockHashes...)
+		out.HistoricalBlockHashes = append(hashes, d.appendedHashes...)
+	}
+	if d.config != nil {
+		out.Config = d.config
+	}
+	if d.validators != nil {
+		out.Validators = d.validators
+	}
+	if d.justifiedSlots != nil {
+		out.JustifiedSlots = d.justifiedSlots
+	}
+	if d.justificationsRoots != nil {
+		out.JustificationsRoots = d.justificationsRoots
+	}
+	if d.justificationsValidators != nil {
+		out.JustificationsValidators = d.justificationsValidators
+	}
+	// Materialized states never share backing arrays with diffs or with each
+	// other, so a caller mutating one cannot corrupt another.
+	return out.Copy()
+}
+
+func copyValidators(vs []*types.Validator) []*types.Validator {
+	out := make([]*types.Validator, len(vs))
+	for i, v := range vs {
+		cp := *v
+		out[i] = &cp
+	}
+	return out
+}
+
+func rootsEqual(a, b [][32]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func configEqual(a, b *types.Config) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.GenesisTime == b.GenesisTime
+}
+
+func validatorsEqual(a, b []*types.Validator) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if *a[i] != *b[i] {
+			return false
+		}
+	}
+	return true
+}
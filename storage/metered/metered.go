@@ -0,0 +1,120 @@
+// Package metered wraps a storage.Store to record the latency of its reads
+// and writes in lean_storage_operation_seconds.
+package metered
+
+import (
+	"io"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/geanlabs/gean/observability/metrics"
+	"github.com/geanlabs/gean/storage"
+	"github.com/geanlabs/gean/types"
+)
+
+// Store times the block, signed block and state reads and writes of the
+// wrapped store, and batch commits. Everything else passes through.
+type Store struct {
+	storage.Store
+
+	getBlock, putBlock   prometheus.Observer
+	getSigned, putSigned prometheus.Observer
+	getState, putState   prometheus.Observer
+	commit               prometheus.Observer
+}
+
+// New wraps inner.
+func New(inner storage.Store) *Store {
+	op := metrics.StorageOperationTime
+	return &Store{
+		Store:     inner,
+		getBlock:  op.WithLabelValues("get", "block"),
+		putBlock:  op.WithLabelValues("put", "block"),
+		getSigned: op.WithLabelValues("get", "signed_block"),
+		putSigned: op.WithLabelValues("put", "signed_block"),
+		getState:  op.WithLabelValues("get", "state"),
+		putState:  op.WithLabelValues("put", "state"),
+		commit:    op.WithLabelValues("commit", "batch"),
+	}
+}
+
+func since(o prometheus.Observer, start time.Time) {
+	o.Observe(time.Since(start).Seconds())
+}
+
+func (s *Store) GetBlock(root [32]byte) (*types.Block, bool) {
+	defer since(s.getBlock, time.Now())
+	return s.Store.GetBlock(root)
+}
+
+func (s *Store) PutBlock(root [32]byte, block *types.Block) error {
+	defer since(s.putBlock, time.Now())
+	return s.Store.PutBlock(root, block)
+}
+
+func (s *Store) GetSignedBlock(root [32]byte) (*types.SignedBlockWithAttestation, bool) {
+	defer since(s.getSigned, time.Now())
+	return s.Store.GetSignedBlock(root)
+}
+
+func (s *Store) PutSignedBlock(root [32]byte, sb *types.SignedBlockWithAttestation) error {
+	defer since(s.putSigned, time.Now())
+	return s.Store.PutSignedBlock(root, sb)
+}
+
+func (s *Store) GetState(root [32]byte) (*types.State, bool) {
+	defer since(s.getState, time.Now())
+	return s.Store.GetState(root)
+}
+
+func (s *Store) PutState(root [32]byte, state *types.State) error {
+	defer since(s.putState, time.Now())
+	return s.Store.PutState(root, state)
+}
+
+// Batch returns a batch whose Commit is timed.
+func (s *Store) Batch() storage.Batch {
+	return &batch{Batch: s.Store.Batch(), commit: s.commit}
+}
+
+type batch struct {
+	storage.Batch
+	commit prometheus.Observer
+}
+
+func (b *batch) Commit() error {
+	defer since(b.commit, time.Now())
+	return b.Batch.Commit()
+}
+
+// Stats forwards to the wrapped store if it reports stats.
+func (s *Store) Stats() storage.Stats {
+	if st, ok := s.Store.(interface{ Stats() storage.Stats }); ok {
+		return st.Stats()
+	}
+	return storage.Stats{}
+}
+
+// SetStateCacheSize forwards to the wrapped store if it has a state cache.
+func (s *Store) SetStateCacheSize(n int) {
+	if c, ok := s.Store.(interface{ SetStateCacheSize(int) }); ok {
+		c.SetStateCacheSize(n)
+	}
+}
+
+// ShedStateCache forwards to the wrapped store if it has a state cache.
+func (s *Store) ShedStateCache() int {
+	if c, ok := s.Store.(interface{ ShedStateCache() int }); ok {
+		return c.ShedStateCache()
+	}
+	return 0
+}
+
+// Close closes the wrapped store if it holds resources.
+func (s *Store) Close() error {
+	if c, ok := s.Store.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
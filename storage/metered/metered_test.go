@@ -0,0 +1,49 @@
+package metered_test
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/geanlabs/gean/observability/metrics"
+	"github.com/geanlabs/gean/storage/memory"
+	"github.com/geanlabs/gean/storage/metered"
+	"github.com/geanlabs/gean/types"
+)
+
+func sampleCount(t *testing.T, op, kind string) uint64 {
+	t.Helper()
+	var m dto.Metric
+	if err := metrics.StorageOperationTime.WithLabelValues(op, kind).(prometheus.Metric).Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestOperationsAreTimed(t *testing.T) {
+	s := metered.New(memory.New())
+	puts, gets, commits := sampleCount(t, "put", "block"), sampleCount(t, "get", "block"), sampleCount(t, "commit", "batch")
+
+	root := [32]byte{1}
+	s.PutBlock(root, &types.Block{Slot: 1, Body: &types.BlockBody{}})
+	if _, ok := s.GetBlock(root); !ok {
+		t.Fatal("block not passed through")
+	}
+	b := s.Batch()
+	b.PutBlock([32]byte{2}, &types.Block{Slot: 2, Body: &types.BlockBody{}})
+	b.Commit()
+
+	if n := sampleCount(t, "put", "block"); n != puts+1 {
+		t.Fatalf("put samples = %d, want %d", n, puts+1)
+	}
+	if n := sampleCount(t, "get", "block"); n != gets+1 {
+		t.Fatalf("get samples = %d, want %d", n, gets+1)
+	}
+	if n := sampleCount(t, "commit", "batch"); n != commits+1 {
+		t.Fatalf("commit samples = %d, want %d", n, commits+1)
+	}
+	if st := s.Stats(); st.Blocks != 2 {
+		t.Fatalf("Stats not forwarded: %+v", st)
+	}
+}
@@ -0,0 +1,117 @@
+// Package migrate versions the schema of a LevelDB chain database. The
+// version is stored under VersionKey; on open, Run applies the migrations
+// between the stored version and the latest one in order and refuses
+// databases written by a newer schema.
+package migrate
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/syndtr/goleveldb/leveldb"
+
+	"github.com/geanlabs/gean/observability/logging"
+)
+
+// VersionKey holds the schema version as a big-endian uint64. Its leading
+// 'm' keeps it clear of the data key prefixes.
+var VersionKey = []byte("mschema_version")
+
+// BaseVersion is the schema of databases created before versioning, which
+// have no version key.
+const BaseVersion = 1
+
+// Migration upgrades a database from Version-1 to Version. Apply reads from
+// db and adds its writes to batch, which is committed together with the new
+// version, so a failed or interrupted migration leaves the database at the
+// previous version.
+type Migration struct {
+	Version uint64
+	Name    string
+	Apply   func(db *leveldb.DB, batch *leveldb.Batch) error
+}
+
+// Latest returns the schema version after all of migrations.
+func Latest(migrations []Migration) uint64 {
+	return BaseVersion + uint64(len(migrations))
+}
+
+// Run brings db to the latest schema version. An empty database is stamped
+// with the latest version. migrations must be numbered consecutively from
+// BaseVersion+1.
+func Run(db *leveldb.DB, migrations []Migration) error {
+	for i, m := range migrations {
+		if m.Version != BaseVersion+uint64(i)+1 {
+			return fmt.Errorf("migration %q has version %d, want %d", m.Name, m.Version, BaseVersion+uint64(i)+1)
+		}
+	}
+	latest := Latest(migrations)
+
+	stored, err := Version(db)
+	if err != nil {
+		return err
+	}
+	version := stored
+	if stored == 0 {
+		empty, err := isEmpty(db)
+		if err != nil {
+			return err
+		}
+		if empty {
+			return setVersion(db, new(leveldb.Batch), latest)
+		}
+		version = BaseVersion
+	}
+	if version > latest {
+		return fmt.Errorf("database schema version %d is newer than this build supports (%d)", version, latest)
+	}
+
+	pending := migrations[version-BaseVersion:]
+	if stored == 0 && len(pending) == 0 {
+		// Stamp a database from before versioning with its schema.
+		return setVersion(db, new(leveldb.Batch), BaseVersion)
+	}
+	log := logging.NewComponentLogger(logging.CompStorage)
+	for _, m := range pending {
+		log.Info("migrating database", "version", m.Version, "migration", m.Name)
+		batch := new(leveldb.Batch)
+		if err := m.Apply(db, batch); err != nil {
+			return fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+		}
+		if err := setVersion(db, batch, m.Version); err != nil {
+			return fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+		}
+	}
+	return nil
+}
+
+// Version returns the schema version recorded in db, or 0 if there is none.
+func Version(db *leveldb.DB) (uint64, error) {
+	data, err := db.Get(VersionKey, nil)
+	if errors.Is(err, leveldb.ErrNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("read schema version: %w", err)
+	}
+	if len(data) != 8 {
+		return 0, fmt.Errorf("malformed schema version of %d bytes", len(data))
+	}
+	return binary.BigEndian.Uint64(data), nil
+}
+
+func setVersion(db *leveldb.DB, batch *leveldb.Batch, version uint64) error {
+	batch.Put(VersionKey, binary.BigEndian.AppendUint64(nil, version))
+	if err := db.Write(batch, nil); err != nil {
+		return fmt.Errorf("write schema version: %w", err)
+	}
+	return nil
+}
+
+func isEmpty(db *leveldb.DB) (bool, error) {
+	iter := db.NewIterator(nil, nil)
+	defer iter.Release()
+	empty := !iter.Next()
+	return empty, iter.Error()
+}
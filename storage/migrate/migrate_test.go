@@ -0,0 +1,128 @@
+package migrate_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+
+	"github.com/geanlabs/gean/storage/migrate"
+)
+
+func openDB(t *testing.T) *leveldb.DB {
+	t.Helper()
+	db, err := leveldb.Open(storage.NewMemStorage(), nil)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func version(t *testing.T, db *leveldb.DB) uint64 {
+	t.Helper()
+	v, err := migrate.Version(db)
+	if err != nil {
+		t.Fatalf("version: %v", err)
+	}
+	return v
+}
+
+// renameKey is a migration that moves the value at from to to.
+func renameKey(v uint64, from, to string) migrate.Migration {
+	return migrate.Migration{
+		Version: v,
+		Name:    "rename " + from,
+		Apply: func(db *leveldb.DB, batch *leveldb.Batch) error {
+			val, err := db.Get([]byte(from), nil)
+			if err != nil {
+				return err
+			}
+			batch.Delete([]byte(from))
+			batch.Put([]byte(to), val)
+			return nil
+		},
+	}
+}
+
+func TestNewDatabaseIsStampedLatest(t *testing.T) {
+	db := openDB(t)
+	migrations := []migrate.Migration{renameKey(2, "a", "b")}
+	if err := migrate.Run(db, migrations); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if v := version(t, db); v != 2 {
+		t.Fatalf("version = %d, want 2", v)
+	}
+}
+
+func TestUnversionedDatabaseIsMigratedFromBase(t *testing.T) {
+	db := openDB(t)
+	db.Put([]byte("a"), []byte("x"), nil)
+
+	migrations := []migrate.Migration{renameKey(2, "a", "b"), renameKey(3, "b", "c")}
+	if err := migrate.Run(db, migrations); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if v := version(t, db); v != 3 {
+		t.Fatalf("version = %d, want 3", v)
+	}
+	if val, err := db.Get([]byte("c"), nil); err != nil || string(val) != "x" {
+		t.Fatalf("migrated value = %q, %v", val, err)
+	}
+}
+
+func TestUnversionedDatabaseIsStampedBase(t *testing.T) {
+	db := openDB(t)
+	db.Put([]byte("a"), []byte("x"), nil)
+	if err := migrate.Run(db, nil); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if v := version(t, db); v != migrate.BaseVersion {
+		t.Fatalf("version = %d, want %d", v, migrate.BaseVersion)
+	}
+}
+
+func TestNewerDatabaseIsRefused(t *testing.T) {
+	db := openDB(t)
+	if err := migrate.Run(db, []migrate.Migration{renameKey(2, "a", "b")}); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if err := migrate.Run(db, nil); err == nil {
+		t.Fatal("opened a database from a newer schema")
+	}
+}
+
+func TestFailedMigrationKeepsVersion(t *testing.T) {
+	db := openDB(t)
+	db.Put([]byte("a"), []byte("x"), nil)
+	if err := migrate.Run(db, nil); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	failing := migrate.Migration{
+		Version: 3,
+		Name:    "fail",
+		Apply: func(*leveldb.DB, *leveldb.Batch) error {
+			return errors.New("boom")
+		},
+	}
+	err := migrate.Run(db, []migrate.Migration{renameKey(2, "a", "b"), failing})
+	if err == nil {
+		t.Fatal("expected migration error")
+	}
+	if v := version(t, db); v != 2 {
+		t.Fatalf("version = %d, want 2 after the failing step", v)
+	}
+	if _, err := db.Get([]byte("b"), nil); err != nil {
+		t.Fatalf("completed migration lost: %v", err)
+	}
+}
+
+func TestMisnumberedMigrationsAreRejected(t *testing.T) {
+	db := openDB(t)
+	if err := migrate.Run(db, []migrate.Migration{renameKey(3, "a", "b")}); err == nil {
+		t.Fatal("accepted a migration list with a gap")
+	}
+}
@@ -0,0 +1,225 @@
+// Package proposals records the blocks a node produced. Each envelope is
+// synced to disk before it is returned for publishing, so a restarted node
+// finds the block it already signed for a proposal duty and never signs a
+// second one, whichever chain storage backend it runs with.
+package proposals
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/geanlabs/gean/types"
+)
+
+// FileName is the name of the record under the data directory.
+const FileName = "proposals.dat"
+
+// maxRecordSize bounds the payload length read back from a record, so a
+// corrupt length cannot trigger a huge allocation.
+const maxRecordSize = 1 << 24
+
+// Each record is a little-endian payload length, a CRC-32 (Castagnoli) of
+// the payload and the SSZ SignedBlockWithAttestation.
+const recordHeaderSize = 8
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+type duty struct {
+	slot, proposer uint64
+}
+
+// Log is an append-only file of produced block envelopes, indexed in memory
+// by slot and proposer.
+type Log struct {
+	mu     sync.Mutex
+	path   string
+	f      *os.File
+	byDuty map[duty]*types.SignedBlockWithAttestation
+}
+
+// Open opens the record at path, creating it if needed, and loads it. A torn
+// record left at the end of the file by a crash is truncated; it was never
+// published, since Record returns only after the sync.
+func Open(path string) (*Log, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	l := &Log{path: path, f: f, byDuty: make(map[duty]*types.SignedBlockWithAttestation)}
+	valid, err := scan(f, l.index)
+	if err == nil {
+		err = f.Truncate(valid)
+	}
+	if err == nil {
+		_, err = f.Seek(valid, io.SeekStart)
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *Log) index(payload []byte) error {
+	sb := new(types.SignedBlockWithAttestation)
+	if err := sb.UnmarshalSSZ(payload); err != nil {
+		return fmt.Errorf("decode recorded block: %w", err)
+	}
+	if sb.Message == nil || sb.Message.Block == nil {
+		return fmt.Errorf("recorded envelope has no block")
+	}
+	b := sb.Message.Block
+	l.byDuty[duty{b.Slot, b.ProposerIndex}] = sb
+	return nil
+}
+
+// Record appends envelope and syncs it to disk.
+func (l *Log) Record(envelope *types.SignedBlockWithAttestation) error {
+	payload, err := envelope.MarshalSSZ()
+	if err != nil {
+		return fmt.Errorf("encode block: %w", err)
+	}
+	if len(payload) > maxRecordSize {
+		return fmt.Errorf("record of %d bytes exceeds limit", len(payload))
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.f.Write(frame(payload)); err != nil {
+		return err
+	}
+	if err := l.f.Sync(); err != nil {
+		return err
+	}
+	b := envelope.Message.Block
+	l.byDuty[duty{b.Slot, b.ProposerIndex}] = envelope
+	return nil
+}
+
+// Lookup returns the envelope recorded for proposer at slot.
+func (l *Log) Lookup(slot, proposer uint64) (*types.SignedBlockWithAttestation, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	sb, ok := l.byDuty[duty{slot, proposer}]
+	return sb, ok
+}
+
+// Len returns the number of recorded envelopes.
+func (l *Log) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.byDuty)
+}
+
+// Prune drops the envelopes of slots below slot, which can no longer be
+// proposed for, by rewriting the file and renaming it into place.
+func (l *Log) Prune(slot uint64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var keep []*types.SignedBlockWithAttestation
+	for d, sb := range l.byDuty {
+		if d.slot >= slot {
+			keep = append(keep, sb)
+		}
+	}
+	if len(keep) == len(l.byDuty) {
+		return nil
+	}
+
+	tmp := l.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	for _, sb := range keep {
+		var payload []byte
+		if payload, err = sb.MarshalSSZ(); err != nil {
+			break
+		}
+		if _, err = w.Write(frame(payload)); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if err == nil {
+		err = os.Rename(tmp, l.path)
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if dir, err := os.Open(filepath.Dir(l.path)); err == nil {
+		dir.Sync()
+		dir.Close()
+	}
+	if _, err := f.Seek(0, io.SeekEnd); err != nil {
+		f.Close()
+		return err
+	}
+	l.f.Close()
+	l.f = f
+	for d := range l.byDuty {
+		if d.slot < slot {
+			delete(l.byDuty, d)
+		}
+	}
+	return nil
+}
+
+// Close closes the file.
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.f.Close()
+}
+
+func frame(payload []byte) []byte {
+	out := make([]byte, recordHeaderSize, recordHeaderSize+len(payload))
+	binary.LittleEndian.PutUint32(out[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(out[4:8], crc32.Checksum(payload, crcTable))
+	return append(out, payload...)
+}
+
+// scan reads records from the start of f, calling fn for each, and returns
+// the offset just past the last intact record. A short or corrupt record
+// ends the scan without error.
+func scan(f *os.File, fn func([]byte) error) (int64, error) {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	r := bufio.NewReader(f)
+	var valid int64
+	var hdr [recordHeaderSize]byte
+	for {
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			return valid, nil
+		}
+		n := binary.LittleEndian.Uint32(hdr[0:4])
+		if n > maxRecordSize {
+			return valid, nil
+		}
+		payload := make([]byte, n)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return valid, nil
+		}
+		if crc32.Checksum(payload, crcTable) != binary.LittleEndian.Uint32(hdr[4:8]) {
+			return valid, nil
+		}
+		if err := fn(payload); err != nil {
+			return valid, err
+		}
+		valid += recordHeaderSize + int64(n)
+	}
+}
@@ -0,0 +1,117 @@
+package proposals_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/geanlabs/gean/storage/proposals"
+	"github.com/geanlabs/gean/types"
+)
+
+func openLog(t *testing.T, path string) *proposals.Log {
+	t.Helper()
+	l, err := proposals.Open(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	return l
+}
+
+func envelope(slot, proposer uint64) *types.SignedBlockWithAttestation {
+	return &types.SignedBlockWithAttestation{
+		Message: &types.BlockWithAttestation{
+			Block:               &types.Block{Slot: slot, ProposerIndex: proposer, Body: &types.BlockBody{}},
+			ProposerAttestation: &types.Attestation{Data: &types.AttestationData{}},
+		},
+	}
+}
+
+func record(t *testing.T, l *proposals.Log, slot, proposer uint64) {
+	t.Helper()
+	if err := l.Record(envelope(slot, proposer)); err != nil {
+		t.Fatalf("record slot %d: %v", slot, err)
+	}
+}
+
+func TestLookupAfterReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), proposals.FileName)
+	l := openLog(t, path)
+	record(t, l, 3, 1)
+	record(t, l, 4, 2)
+	l.Close()
+
+	l = openLog(t, path)
+	defer l.Close()
+	if l.Len() != 2 {
+		t.Fatalf("len = %d, want 2", l.Len())
+	}
+	sb, ok := l.Lookup(4, 2)
+	if !ok || sb.Message.Block.Slot != 4 || sb.Message.Block.ProposerIndex != 2 {
+		t.Fatalf("Lookup(4, 2) = %+v, %v", sb, ok)
+	}
+	if _, ok := l.Lookup(4, 1); ok {
+		t.Fatal("found a block for a duty never recorded")
+	}
+}
+
+func TestTornTailIsTruncated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), proposals.FileName)
+	l := openLog(t, path)
+	record(t, l, 3, 1)
+	l.Close()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte{0xff, 0x00, 0x00})
+	f.Close()
+
+	l = openLog(t, path)
+	if l.Len() != 1 {
+		t.Fatalf("len = %d after torn tail, want 1", l.Len())
+	}
+	record(t, l, 5, 1)
+	l.Close()
+
+	l = openLog(t, path)
+	defer l.Close()
+	if _, ok := l.Lookup(5, 1); !ok {
+		t.Fatal("record appended after a torn tail was lost")
+	}
+	if info2, _ := os.Stat(path); info2.Size() <= info.Size() {
+		t.Fatalf("file did not grow past the truncated tail")
+	}
+}
+
+func TestPruneDropsOldSlots(t *testing.T) {
+	path := filepath.Join(t.TempDir(), proposals.FileName)
+	l := openLog(t, path)
+	for slot := uint64(1); slot <= 4; slot++ {
+		record(t, l, slot, 0)
+	}
+	if err := l.Prune(3); err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+	if l.Len() != 2 {
+		t.Fatalf("len = %d after prune, want 2", l.Len())
+	}
+	record(t, l, 5, 0)
+	l.Close()
+
+	l = openLog(t, path)
+	defer l.Close()
+	if _, ok := l.Lookup(2, 0); ok {
+		t.Fatal("pruned record came back after reopen")
+	}
+	for _, slot := range []uint64{3, 4, 5} {
+		if _, ok := l.Lookup(slot, 0); !ok {
+			t.Fatalf("slot %d lost", slot)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+// Package snapshot wraps a storage.Store so that only every Nth state is
+// written to it. The states in between are rebuilt on read by replaying
+// blocks through the state transition from the nearest stored ancestor.
+package snapshot
+
+import (
+	"io"
+	"log/slog"
+	"sync"
+
+	"github.com/geanlabs/gean/chain/statetransition"
+	"github.com/geanlabs/gean/internal/lru"
+	"github.com/geanlabs/gean/observability/logging"
+	"github.com/geanlabs/gean/storage"
+	"github.com/geanlabs/gean/types"
+)
+
+// stateCacheSize is the number of materialized states kept in memory.
+const stateCacheSize = 64
+
+// Store keeps blocks in the wrapped store and the states of blocks whose
+// slot is a multiple of the snapshot interval. Other states are held in an
+// LRU while recent and replayed from their snapshot once evicted, so at most
+// interval-1 blocks are replayed per read.
+type Store struct {
+	storage.Store
+	interval uint64
+	log      *slog.Logger
+
+	mu    sync.Mutex
+	cache *lru.Cache[[32]byte, *types.State]
+}
+
+// New wraps inner, keeping a state snapshot every interval slots. An
+// interval of 0 or 1 keeps every state.
+func New(inner storage.Store, interval uint64) *Store {
+	return &Store{
+		Store:    inner,
+		interval: max(interval, 1),
+		log:      logging.NewComponentLogger(logging.CompStorage),
+		cache:    lru.New[[32]byte, *types.State](stateCacheSize),
+	}
+}
+
+// SetStateCacheSize changes how many materialized states are kept, here and
+// in the wrapped store if it has a cache.
+func (s *Store) SetStateCacheSize(n int) {
+	s.mu.Lock()
+	s.cache.Resize(n)
+	s.mu.Unlock()
+	if c, ok := s.Store.(interface{ SetStateCacheSize(int) }); ok {
+		c.SetStateCacheSize(n)
+	}
+}
+
+// ShedStateCache empties the state caches and returns how many states they
+// held. Dropped states are replayed on the next read.
+func (s *Store) ShedStateCache() int {
+	s.mu.Lock()
+	n := s.cache.Clear()
+	s.mu.Unlock()
+	if c, ok := s.Store.(interface{ ShedStateCache() int }); ok {
+		n += c.ShedStateCache()
+	}
+	return n
+}
+
+// Stats forwards to the wrapped store if it reports stats.
+func (s *Store) Stats() storage.Stats {
+	if st, ok := s.Store.(interface{ Stats() storage.Stats }); ok {
+		return st.Stats()
+	}
+	return storage.Stats{}
+}
+
+// Close closes the wrapped store if it holds resources.
+func (s *Store) Close() error {
+	if c, ok := s.Store.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// PutState writes state to the wrapped store if it is a snapshot: its slot
+// is a multiple of the interval or its parent block is unknown, as for an
+// anchor. Every state written is cached.
+func (s *Store) PutState(root [32]byte, state *types.State) error {
+	if s.isSnapshot(state) {
+		if err := s.Store.PutState(root, state); err != nil {
+			return err
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cache.Add(root, state.Copy())
+	return nil
+}
+
+func (s *Store) isSnapshot(state *types.State) bool {
+	if state.Slot%s.interval == 0 || state.LatestBlockHeader == nil {
+		return true
+	}
+	_, ok := s.Store.GetBlock(state.LatestBlockHeader.ParentRoot)
+	return !ok
+}
+
+func (s *Store) GetState(root [32]byte) (*types.State, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.getStateLocked(root)
+}
+
+// getStateLocked returns the state for root from the cache or the wrapped
+// store, or replays it from the nearest ancestor state found in either.
+func (s *Store) getStateLocked(root [32]byte) (*types.State, bool) {
+	if st, ok := s.cache.Get(root); ok {
+		return st, true
+	}
+	if st, ok := s.Store.GetState(root); ok {
+		return st, true
+	}
+
+	var blocks []*types.Block
+	var base *types.State
+	for h := root; base == nil; {
+		if st, ok := s.cache.Get(h); ok {
+			base = st
+		} else if st, ok := s.Store.GetState(h); ok {
+			base = st
+		} else {
+			b, ok := s.Store.GetBlock(h)
+			if !ok {
+				return nil, false
+			}
+			blocks = append(blocks, b)
+			h = b.ParentRoot
+		}
+	}
+
+	state := base
+	for i := len(blocks) - 1; i >= 0; i-- {
+		next, err := statetransition.StateTransition(state, blocks[i])
+		if err != nil {
+			s.log.Error("failed to replay state",
+				"root", logging.ShortHash(root),
+				"slot", blocks[i].Slot,
+				"err", err,
+			)
+			return nil, false
+		}
+		state = next
+	}
+	s.cache.Add(root, state)
+	return state, true
+}
+
+// ForEachState materializes the state of each stored block in r, replaying
+// where needed, as fn reaches it.
+func (s *Store) ForEachState(r storage.SlotRange, fn func(root [32]byte, state *types.State) bool) {
+	s.Store.ForEachBlock(r, func(root [32]byte, _ *types.Block) bool {
+		st, ok := s.GetState(root)
+		if !ok {
+			return true
+		}
+		return fn(root, st)
+	})
+}
+
+func (s *Store) DeleteBlocks(roots [][32]byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.snapshotChildrenLocked(roots); err != nil {
+		return err
+	}
+	for _, root := range roots {
+		s.cache.Remove(root)
+	}
+	return s.Store.DeleteBlocks(roots)
+}
+
+func (s *Store) DeleteStates(roots [][32]byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.snapshotChildrenLocked(roots); err != nil {
+		return err
+	}
+	for _, root := range roots {
+		s.cache.Remove(root)
+	}
+	return s.Store.DeleteStates(roots)
+}
+
+// snapshotChildrenLocked writes the state of every remaining block whose
+// parent is in roots and whose own state is not stored, so it can still be
+// replayed once roots are deleted. It stops at the first failed write, so
+// nothing is deleted that a remaining state still needs.
+func (s *Store) snapshotChildrenLocked(roots [][32]byte) error {
+	gone := make(map[[32]byte]bool, len(roots))
+	from := ^uint64(0)
+	for _, root := range roots {
+		gone[root] = true
+		if b, ok := s.Store.GetBlock(root); ok {
+			from = min(from, b.Slot)
+		}
+	}
+	if from == ^uint64(0) {
+		return nil
+	}
+	var err error
+	s.Store.ForEachBlock(storage.SlotRange{From: from}, func(root [32]byte, b *types.Block) bool {
+		if gone[root] || !gone[b.ParentRoot] {
+			return true
+		}
+		if _, ok := s.Store.GetState(root); ok {
+			return true
+		}
+		if st, ok := s.getStateLocked(root); ok {
+			err = s.Store.PutState(root, st)
+		}
+		return err == nil
+	})
+	return err
+}
+
+// Batch returns a batch of the wrapped store that drops non-snapshot states
+// and caches every state on commit.
+func (s *Store) Batch() storage.Batch {
+	return &batch{s: s, inner: s.Store.Batch()}
+}
+
+type batch struct {
+	s      *Store
+	inner  storage.Batch
+	states map[[32]byte]*types.State
+}
+
+func (b *batch) PutBlock(root [32]byte, block *types.Block) {
+	b.inner.PutBlock(root, block)
+}
+
+func (b *batch) PutSignedBlock(root [32]byte, sb *types.SignedBlockWithAttestation) {
+	b.inner.PutSignedBlock(root, sb)
+}
+
+func (b *batch) PutState(root [32]byte, state *types.State) {
+	if b.s.isSnapshot(state) {
+		b.inner.PutState(root, state)
+	}
+	if b.states == nil {
+		b.states = make(map[[32]byte]*types.State)
+	}
+	b.states[root] = state.Copy()
+}
+
+func (b *batch) Commit() error {
+	if err := b.inner.Commit(); err != nil {
+		return err
+	}
+	b.s.mu.Lock()
+	defer b.s.mu.Unlock()
+	for root, state := range b.states {
+		b.s.cache.Add(root, state)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package snapshot_test
+
+import (
+	"testing"
+
+	"github.com/geanlabs/gean/chain/statetransition"
+	"github.com/geanlabs/gean/storage/memory"
+	"github.com/geanlabs/gean/storage/snapshot"
+	"github.com/geanlabs/gean/types"
+)
+
+// buildChain stores a chain of n empty blocks on top of genesis and returns
+// their roots, genesis first, with the root of each post-state.
+func buildChain(t *testing.T, s *snapshot.Store, n int) ([][32]byte, [][32]byte) {
+	t.Helper()
+	validators := []*types.Validator{{Index: 0}, {Index: 1}, {Index: 2}}
+	state := statetransition.GenerateGenesis(1000, validators)
+	stateRoot, _ := state.HashTreeRoot()
+	block := &types.Block{StateRoot: stateRoot, Body: &types.BlockBody{}}
+	root, _ := block.HashTreeRoot()
+	s.PutBlock(root, block)
+	s.PutState(root, state)
+
+	roots := [][32]byte{root}
+	stateRoots := [][32]byte{stateRoot}
+	for slot := uint64(1); slot <= uint64(n); slot++ {
+		block = &types.Block{
+			Slot:          slot,
+			ProposerIndex: slot % uint64(len(validators)),
+			ParentRoot:    root,
+			Body:          &types.BlockBody{},
+		}
+		pre, err := statetransition.ProcessSlots(state, slot)
+		if err != nil {
+			t.Fatalf("process slots %d: %v", slot, err)
+		}
+		post, err := statetransition.ProcessBlock(pre, block)
+		if err != nil {
+			t.Fatalf("process block %d: %v", slot, err)
+		}
+		block.StateRoot, _ = post.HashTreeRoot()
+		root, _ = block.HashTreeRoot()
+		s.PutBlock(root, block)
+		s.PutState(root, post)
+		state = post
+		roots = append(roots, root)
+		stateRoots = append(stateRoots, block.StateRoot)
+	}
+	return roots, stateRoots
+}
+
+func TestOnlySnapshotsAreStored(t *testing.T) {
+	inner := memory.New()
+	s := snapshot.New(inner, 4)
+	roots, _ := buildChain(t, s, 9)
+
+	for slot, root := range roots {
+		_, stored := inner.GetState(root)
+		if want := slot%4 == 0; stored != want {
+			t.Errorf("slot %d: stored = %v, want %v", slot, stored, want)
+		}
+	}
+}
+
+func TestStatesReplayAfterEviction(t *testing.T) {
+	s := snapshot.New(memory.New(), 4)
+	roots, stateRoots := buildChain(t, s, 9)
+	s.ShedStateCache()
+
+	for slot, root := range roots {
+		st, ok := s.GetState(root)
+		if !ok {
+			t.Fatalf("slot %d: state not found", slot)
+		}
+		if got, _ := st.HashTreeRoot(); got != stateRoots[slot] {
+			t.Fatalf("slot %d: replayed state root %x, want %x", slot, got, stateRoots[slot])
+		}
+	}
+}
+
+func TestDeleteStatesKeepsDescendantsReplayable(t *testing.T) {
+	inner := memory.New()
+	s := snapshot.New(inner, 4)
+	roots, stateRoots := buildChain(t, s, 9)
+
+	// Prune the states below slot 6, whose replay base is the slot 4 snapshot.
+	s.DeleteStates(roots[:6])
+	s.ShedStateCache()
+
+	for slot := 0; slot < 6; slot++ {
+		if _, ok := s.GetState(roots[slot]); ok {
+			t.Fatalf("slot %d: deleted state still readable", slot)
+		}
+	}
+	for slot := 6; slot < len(roots); slot++ {
+		st, ok := s.GetState(roots[slot])
+		if !ok {
+			t.Fatalf("slot %d: state lost with its ancestors", slot)
+		}
+		if got, _ := st.HashTreeRoot(); got != stateRoots[slot] {
+			t.Fatalf("slot %d: replayed state root %x, want %x", slot, got, stateRoots[slot])
+		}
+	}
+}
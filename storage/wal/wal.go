@@ -0,0 +1,202 @@
+// Package wal is an append-only log of received gossip messages. Messages
+// are appended before they are processed, so after a crash the node can
+// replay what it had received instead of waiting for peers to re-gossip it.
+package wal
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"sync"
+)
+
+// Kind identifies the type of a logged message.
+type Kind uint8
+
+const (
+	KindBlock       Kind = iota + 1 // SSZ SignedBlockWithAttestation
+	KindAttestation                 // SSZ SignedAttestation
+	KindAggregate                   // gossip-encoded AggregatedAttestation
+)
+
+// maxRecordSize bounds the payload length read back from a record, so a
+// corrupt length cannot trigger a huge allocation.
+const maxRecordSize = 1 << 24
+
+// Each record is a little-endian payload length, a CRC-32 (Castagnoli) of
+// the kind and payload, the kind byte and the payload.
+const recordHeaderSize = 9
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// Log is a write-ahead log in two generations: the file at its path, which
+// is appended to, and the previous one at path.1. Rotate discards the
+// previous generation, bounding the log to what was received since the
+// rotation before last.
+//
+// Appends are not synced to disk; the log survives a process crash but not
+// necessarily a power loss.
+type Log struct {
+	mu   sync.Mutex
+	path string
+	f    *os.File
+	w    *bufio.Writer
+}
+
+// Open opens the log at path, creating it if needed. A torn record left at
+// the end of the file by a crash is truncated.
+func Open(path string) (*Log, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	valid, err := scan(f, nil)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.Truncate(valid); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if _, err := f.Seek(valid, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &Log{path: path, f: f, w: bufio.NewWriter(f)}, nil
+}
+
+// Append writes a record to the log.
+func (l *Log) Append(kind Kind, payload []byte) error {
+	if len(payload) > maxRecordSize {
+		return fmt.Errorf("record of %d bytes exceeds limit", len(payload))
+	}
+	var hdr [recordHeaderSize]byte
+	binary.LittleEndian.PutUint32(hdr[0:4], uint32(len(payload)))
+	crc := crc32.Update(0, crcTable, []byte{byte(kind)})
+	binary.LittleEndian.PutUint32(hdr[4:8], crc32.Update(crc, crcTable, payload))
+	hdr[8] = byte(kind)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.w.Write(hdr[:]); err != nil {
+		return err
+	}
+	if _, err := l.w.Write(payload); err != nil {
+		return err
+	}
+	return l.w.Flush()
+}
+
+// Replay calls fn with every record in the log, oldest first: the previous
+// generation, then the current one. The records are read before fn is
+// called, so fn may append to the log. Replay stops at the first error from
+// fn.
+func (l *Log) Replay(fn func(kind Kind, payload []byte) error) error {
+	var records []record
+	collect := func(kind Kind, payload []byte) error {
+		records = append(records, record{kind, payload})
+		return nil
+	}
+
+	l.mu.Lock()
+	for _, path := range []string{l.prevPath(), l.path} {
+		f, err := os.Open(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			l.mu.Unlock()
+			return err
+		}
+		_, err = scan(f, collect)
+		f.Close()
+		if err != nil {
+			l.mu.Unlock()
+			return err
+		}
+	}
+	l.mu.Unlock()
+
+	for _, r := range records {
+		if err := fn(r.kind, r.payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type record struct {
+	kind    Kind
+	payload []byte
+}
+
+// Rotate makes the current file the previous generation, replacing the
+// older one, and starts a new current file.
+func (l *Log) Rotate() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(l.path, l.prevPath()); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	l.f = f
+	l.w.Reset(f)
+	return nil
+}
+
+// Close closes the log file.
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.f.Close()
+}
+
+func (l *Log) prevPath() string {
+	return l.path + ".1"
+}
+
+// scan reads records from the start of f, calling fn for each if it is not
+// nil, and returns the offset just past the last intact record. A short or
+// corrupt record ends the scan without error.
+func scan(f *os.File, fn func(Kind, []byte) error) (int64, error) {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	r := bufio.NewReader(f)
+	var valid int64
+	var hdr [recordHeaderSize]byte
+	for {
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			return valid, nil
+		}
+		n := binary.LittleEndian.Uint32(hdr[0:4])
+		if n > maxRecordSize {
+			return valid, nil
+		}
+		payload := make([]byte, n)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return valid, nil
+		}
+		crc := crc32.Update(0, crcTable, hdr[8:9])
+		if crc32.Update(crc, crcTable, payload) != binary.LittleEndian.Uint32(hdr[4:8]) {
+			return valid, nil
+		}
+		if fn != nil {
+			if err := fn(Kind(hdr[8]), payload); err != nil {
+				return valid, err
+			}
+		}
+		valid += recordHeaderSize + int64(n)
+	}
+}
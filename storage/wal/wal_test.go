@@ -0,0 +1,133 @@
+package wal_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/geanlabs/gean/storage/wal"
+)
+
+type record struct {
+	kind    wal.Kind
+	payload []byte
+}
+
+func openLog(t *testing.T, path string) *wal.Log {
+	t.Helper()
+	l, err := wal.Open(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	return l
+}
+
+func replayAll(t *testing.T, l *wal.Log) []record {
+	t.Helper()
+	var got []record
+	if err := l.Replay(func(kind wal.Kind, payload []byte) error {
+		got = append(got, record{kind, payload})
+		return nil
+	}); err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	return got
+}
+
+func checkRecords(t *testing.T, got, want []record) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("replayed %d records, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].kind != want[i].kind || !bytes.Equal(got[i].payload, want[i].payload) {
+			t.Fatalf("record %d = %v %x, want %v %x", i, got[i].kind, got[i].payload, want[i].kind, want[i].payload)
+		}
+	}
+}
+
+func TestReplayAfterReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gossip.wal")
+	l := openLog(t, path)
+	want := []record{
+		{wal.KindBlock, []byte("block")},
+		{wal.KindAttestation, []byte("vote")},
+		{wal.KindAggregate, nil},
+	}
+	for _, r := range want {
+		if err := l.Append(r.kind, r.payload); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+	l.Close()
+
+	l = openLog(t, path)
+	defer l.Close()
+	checkRecords(t, replayAll(t, l), want)
+}
+
+func TestTornTailIsTruncated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gossip.wal")
+	l := openLog(t, path)
+	l.Append(wal.KindBlock, []byte("first"))
+	l.Append(wal.KindBlock, []byte("second"))
+	l.Close()
+
+	// Cut the last record short, as a crash mid-write would.
+	info, _ := os.Stat(path)
+	if err := os.Truncate(path, info.Size()-3); err != nil {
+		t.Fatal(err)
+	}
+
+	l = openLog(t, path)
+	defer l.Close()
+	if err := l.Append(wal.KindAttestation, []byte("third")); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	checkRecords(t, replayAll(t, l), []record{
+		{wal.KindBlock, []byte("first")},
+		{wal.KindAttestation, []byte("third")},
+	})
+}
+
+func TestCorruptRecordEndsReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gossip.wal")
+	l := openLog(t, path)
+	l.Append(wal.KindBlock, []byte("first"))
+	l.Append(wal.KindBlock, []byte("second"))
+	l.Close()
+
+	data, _ := os.ReadFile(path)
+	data[len(data)-1] ^= 0xff
+	os.WriteFile(path, data, 0644)
+
+	l = openLog(t, path)
+	defer l.Close()
+	checkRecords(t, replayAll(t, l), []record{{wal.KindBlock, []byte("first")}})
+}
+
+func TestRotateKeepsOneGeneration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gossip.wal")
+	l := openLog(t, path)
+	defer l.Close()
+
+	l.Append(wal.KindBlock, []byte("gen0"))
+	if err := l.Rotate(); err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	l.Append(wal.KindBlock, []byte("gen1"))
+	checkRecords(t, replayAll(t, l), []record{
+		{wal.KindBlock, []byte("gen0")},
+		{wal.KindBlock, []byte("gen1")},
+	})
+
+	if err := l.Rotate(); err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	l.Append(wal.KindBlock, []byte("gen2"))
+	checkRecords(t, replayAll(t, l), []record{
+		{wal.KindBlock, []byte("gen1")},
+		{wal.KindBlock, []byte("gen2")},
+	})
+}
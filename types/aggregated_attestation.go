@@ -1,7 +1,12 @@
 package types
 
-// XMSSSignatureSize is the fixed size of an individual XMSS signature.
-const XMSSSignatureSize = 3112
+// Sizes of the devnet-1 XMSS encodings. They must equal
+// leansig.SignatureSize() and leansig.PublicKeySize(); the SSZ struct tags
+// repeat them as literals.
+const (
+	XMSSSignatureSize = 3112 // an individual XMSS signature
+	XMSSPubkeySize    = 52   // an XMSS public key
+)
 
 // AggregatedAttestation contains an attestation aggregated from multiple
 // validators. Signatures are concatenated in validator index order.
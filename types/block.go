@@ -9,9 +9,68 @@ type BlockHeader struct {
 	BodyRoot      [32]byte `ssz-size:"32"`
 }
 
-// BlockBody contains the payload of a block.
+// Per-block limits on validator set changes and slashings.
+const (
+	MaxDepositsPerBlock          = 16
+	MaxExitsPerBlock             = 16
+	MaxProposerSlashingsPerBlock = 16
+	MaxAttesterSlashingsPerBlock = 2
+)
+
+// BlockBody contains the payload of a block. Deposits, exits and slashings
+// are only valid on chains with dynamic validators; without them the body
+// is the reference spec BlockBody (see block_body_encoding.go).
 type BlockBody struct {
-	Attestations []*Attestation `ssz-max:"4096"`
+	Attestations      []*Attestation         `ssz-max:"4096"`
+	Deposits          []*SignedDeposit       `ssz-max:"16"`
+	Exits             []*SignedVoluntaryExit `ssz-max:"16"`
+	ProposerSlashings []*ProposerSlashing    `ssz-max:"16"`
+	AttesterSlashings []*AttesterSlashing    `ssz-max:"2"`
+}
+
+// Deposit adds a validator with the given key to the registry.
+type Deposit struct {
+	Pubkey [52]byte `ssz-size:"52"`
+	Slot   uint64
+}
+
+// SignedDeposit is a deposit signed with the deposited key at its slot,
+// proving possession of the key. The slot may not be after the block's.
+type SignedDeposit struct {
+	Message   *Deposit
+	Signature [3112]byte `ssz-size:"3112"`
+}
+
+// VoluntaryExit takes a validator out of the active set.
+type VoluntaryExit struct {
+	ValidatorIndex uint64
+	Slot           uint64
+}
+
+// SignedVoluntaryExit is an exit signed by the validator's key at its slot,
+// which may not be after the block's. The signature uses the one-time key
+// of that slot, so the validator must not sign anything else at it.
+type SignedVoluntaryExit struct {
+	Message   *VoluntaryExit
+	Signature [3112]byte `ssz-size:"3112"`
+}
+
+// ProposerSlashing proves a proposer produced two different blocks at one
+// slot. Blocks are not signed as such; each header is vouched for by the
+// signed proposer attestation its block carried, which names the block as
+// its head.
+type ProposerSlashing struct {
+	Header1      *BlockHeader
+	Header2      *BlockHeader
+	Attestation1 *SignedAttestation
+	Attestation2 *SignedAttestation
+}
+
+// AttesterSlashing proves a validator signed two different attestations at
+// one slot.
+type AttesterSlashing struct {
+	Attestation1 *SignedAttestation
+	Attestation2 *SignedAttestation
 }
 
 // Block is a complete block including header fields and body.
@@ -0,0 +1,397 @@
+package types
+
+import (
+	ssz "github.com/ferranbt/fastssz"
+)
+
+// BlockBody is excluded from sszgen (see generate.go): a body without
+// deposits, exits or slashings encodes and hashes as the reference spec
+// BlockBody, Attestations only. A body with operations, which only chains
+// with dynamic validators accept, encodes all five fields. Decoders tell
+// the two apart by the first offset; a five-field body without operations
+// is rejected, so each body has one encoding. The methods below are the
+// sszgen output for the two containers.
+
+const (
+	blockBodySpecFixedSize     = 4
+	blockBodyExtendedFixedSize = 20
+
+	attestationSize      = 136
+	depositSize          = 3172
+	voluntaryExitSize    = 3128
+	proposerSlashingSize = 6720
+	attesterSlashingSize = 6496
+)
+
+// HasOperations reports whether the body carries deposits, exits or
+// slashings.
+func (b *BlockBody) HasOperations() bool {
+	return len(b.Deposits) != 0 || len(b.Exits) != 0 ||
+		len(b.ProposerSlashings) != 0 || len(b.AttesterSlashings) != 0
+}
+
+// MarshalSSZ ssz marshals the BlockBody object
+func (b *BlockBody) MarshalSSZ() ([]byte, error) {
+	return ssz.MarshalSSZ(b)
+}
+
+// MarshalSSZTo ssz marshals the BlockBody object to a target array
+func (b *BlockBody) MarshalSSZTo(buf []byte) (dst []byte, err error) {
+	dst = buf
+	extended := b.HasOperations()
+	offset := int(blockBodySpecFixedSize)
+	if extended {
+		offset = blockBodyExtendedFixedSize
+	}
+
+	// Offset (0) 'Attestations'
+	dst = ssz.WriteOffset(dst, offset)
+	offset += len(b.Attestations) * attestationSize
+
+	if extended {
+		// Offset (1) 'Deposits'
+		dst = ssz.WriteOffset(dst, offset)
+		offset += len(b.Deposits) * depositSize
+
+		// Offset (2) 'Exits'
+		dst = ssz.WriteOffset(dst, offset)
+		offset += len(b.Exits) * voluntaryExitSize
+
+		// Offset (3) 'ProposerSlashings'
+		dst = ssz.WriteOffset(dst, offset)
+		offset += len(b.ProposerSlashings) * proposerSlashingSize
+
+		// Offset (4) 'AttesterSlashings'
+		dst = ssz.WriteOffset(dst, offset)
+	}
+
+	// Field (0) 'Attestations'
+	if size := len(b.Attestations); size > 4096 {
+		err = ssz.ErrListTooBigFn("BlockBody.Attestations", size, 4096)
+		return
+	}
+	for ii := 0; ii < len(b.Attestations); ii++ {
+		if dst, err = b.Attestations[ii].MarshalSSZTo(dst); err != nil {
+			return
+		}
+	}
+	if !extended {
+		return
+	}
+
+	// Field (1) 'Deposits'
+	if size := len(b.Deposits); size > MaxDepositsPerBlock {
+		err = ssz.ErrListTooBigFn("BlockBody.Deposits", size, MaxDepositsPerBlock)
+		return
+	}
+	for ii := 0; ii < len(b.Deposits); ii++ {
+		if dst, err = b.Deposits[ii].MarshalSSZTo(dst); err != nil {
+			return
+		}
+	}
+
+	// Field (2) 'Exits'
+	if size := len(b.Exits); size > MaxExitsPerBlock {
+		err = ssz.ErrListTooBigFn("BlockBody.Exits", size, MaxExitsPerBlock)
+		return
+	}
+	for ii := 0; ii < len(b.Exits); ii++ {
+		if dst, err = b.Exits[ii].MarshalSSZTo(dst); err != nil {
+			return
+		}
+	}
+
+	// Field (3) 'ProposerSlashings'
+	if size := len(b.ProposerSlashings); size > MaxProposerSlashingsPerBlock {
+		err = ssz.ErrListTooBigFn("BlockBody.ProposerSlashings", size, MaxProposerSlashingsPerBlock)
+		return
+	}
+	for ii := 0; ii < len(b.ProposerSlashings); ii++ {
+		if dst, err = b.ProposerSlashings[ii].MarshalSSZTo(dst); err != nil {
+			return
+		}
+	}
+
+	// Field (4) 'AttesterSlashings'
+	if size := len(b.AttesterSlashings); size > MaxAttesterSlashingsPerBlock {
+		err = ssz.ErrListTooBigFn("BlockBody.AttesterSlashings", size, MaxAttesterSlashingsPerBlock)
+		return
+	}
+	for ii := 0; ii < len(b.AttesterSlashings); ii++ {
+		if dst, err = b.AttesterSlashings[ii].MarshalSSZTo(dst); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// UnmarshalSSZ ssz unmarshals the BlockBody object
+func (b *BlockBody) UnmarshalSSZ(buf []byte) error {
+	var err error
+	size := uint64(len(buf))
+	if size < blockBodySpecFixedSize {
+		return ssz.ErrSize
+	}
+
+	tail := buf
+	var o0, o1, o2, o3, o4 uint64
+
+	// Offset (0) 'Attestations'
+	if o0 = ssz.ReadOffset(buf[0:4]); o0 > size {
+		return ssz.ErrOffset
+	}
+
+	if o0 != blockBodySpecFixedSize && o0 != blockBodyExtendedFixedSize {
+		return ssz.ErrInvalidVariableOffset
+	}
+	extended := o0 == blockBodyExtendedFixedSize
+	o1, o2, o3, o4 = size, size, size, size
+
+	if extended {
+		// Offset (1) 'Deposits'
+		if o1 = ssz.ReadOffset(buf[4:8]); o1 > size || o0 > o1 {
+			return ssz.ErrOffset
+		}
+
+		// Offset (2) 'Exits'
+		if o2 = ssz.ReadOffset(buf[8:12]); o2 > size || o1 > o2 {
+			return ssz.ErrOffset
+		}
+
+		// Offset (3) 'ProposerSlashings'
+		if o3 = ssz.ReadOffset(buf[12:16]); o3 > size || o2 > o3 {
+			return ssz.ErrOffset
+		}
+
+		// Offset (4) 'AttesterSlashings'
+		if o4 = ssz.ReadOffset(buf[16:20]); o4 > size || o3 > o4 {
+			return ssz.ErrOffset
+		}
+	}
+
+	// Field (0) 'Attestations'
+	{
+		buf = tail[o0:o1]
+		num, err := ssz.DivideInt2(len(buf), attestationSize, 4096)
+		if err != nil {
+			return err
+		}
+		b.Attestations = make([]*Attestation, num)
+		for ii := 0; ii < num; ii++ {
+			if b.Attestations[ii] == nil {
+				b.Attestations[ii] = new(Attestation)
+			}
+			if err = b.Attestations[ii].UnmarshalSSZ(buf[ii*attestationSize : (ii+1)*attestationSize]); err != nil {
+				return err
+			}
+		}
+	}
+	b.Deposits, b.Exits, b.ProposerSlashings, b.AttesterSlashings = nil, nil, nil, nil
+	if !extended {
+		return err
+	}
+
+	// Field (1) 'Deposits'
+	{
+		buf = tail[o1:o2]
+		num, err := ssz.DivideInt2(len(buf), depositSize, MaxDepositsPerBlock)
+		if err != nil {
+			return err
+		}
+		b.Deposits = make([]*SignedDeposit, num)
+		for ii := 0; ii < num; ii++ {
+			if b.Deposits[ii] == nil {
+				b.Deposits[ii] = new(SignedDeposit)
+			}
+			if err = b.Deposits[ii].UnmarshalSSZ(buf[ii*depositSize : (ii+1)*depositSize]); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Field (2) 'Exits'
+	{
+		buf = tail[o2:o3]
+		num, err := ssz.DivideInt2(len(buf), voluntaryExitSize, MaxExitsPerBlock)
+		if err != nil {
+			return err
+		}
+		b.Exits = make([]*SignedVoluntaryExit, num)
+		for ii := 0; ii < num; ii++ {
+			if b.Exits[ii] == nil {
+				b.Exits[ii] = new(SignedVoluntaryExit)
+			}
+			if err = b.Exits[ii].UnmarshalSSZ(buf[ii*voluntaryExitSize : (ii+1)*voluntaryExitSize]); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Field (3) 'ProposerSlashings'
+	{
+		buf = tail[o3:o4]
+		num, err := ssz.DivideInt2(len(buf), proposerSlashingSize, MaxProposerSlashingsPerBlock)
+		if err != nil {
+			return err
+		}
+		b.ProposerSlashings = make([]*ProposerSlashing, num)
+		for ii := 0; ii < num; ii++ {
+			if b.ProposerSlashings[ii] == nil {
+				b.ProposerSlashings[ii] = new(ProposerSlashing)
+			}
+			if err = b.ProposerSlashings[ii].UnmarshalSSZ(buf[ii*proposerSlashingSize : (ii+1)*proposerSlashingSize]); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Field (4) 'AttesterSlashings'
+	{
+		buf = tail[o4:]
+		num, err := ssz.DivideInt2(len(buf), attesterSlashingSize, MaxAttesterSlashingsPerBlock)
+		if err != nil {
+			return err
+		}
+		b.AttesterSlashings = make([]*AttesterSlashing, num)
+		for ii := 0; ii < num; ii++ {
+			if b.AttesterSlashings[ii] == nil {
+				b.AttesterSlashings[ii] = new(AttesterSlashing)
+			}
+			if err = b.AttesterSlashings[ii].UnmarshalSSZ(buf[ii*attesterSlashingSize : (ii+1)*attesterSlashingSize]); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !b.HasOperations() {
+		return ssz.ErrInvalidVariableOffset
+	}
+	return err
+}
+
+// SizeSSZ returns the ssz encoded size in bytes for the BlockBody object
+func (b *BlockBody) SizeSSZ() (size int) {
+	size = blockBodySpecFixedSize
+
+	// Field (0) 'Attestations'
+	size += len(b.Attestations) * attestationSize
+	if !b.HasOperations() {
+		return
+	}
+	size += blockBodyExtendedFixedSize - blockBodySpecFixedSize
+
+	// Field (1) 'Deposits'
+	size += len(b.Deposits) * depositSize
+
+	// Field (2) 'Exits'
+	size += len(b.Exits) * voluntaryExitSize
+
+	// Field (3) 'ProposerSlashings'
+	size += len(b.ProposerSlashings) * proposerSlashingSize
+
+	// Field (4) 'AttesterSlashings'
+	size += len(b.AttesterSlashings) * attesterSlashingSize
+
+	return
+}
+
+// HashTreeRoot ssz hashes the BlockBody object
+func (b *BlockBody) HashTreeRoot() ([32]byte, error) {
+	return ssz.HashWithDefaultHasher(b)
+}
+
+// HashTreeRootWith ssz hashes the BlockBody object with a hasher
+func (b *BlockBody) HashTreeRootWith(hh ssz.HashWalker) (err error) {
+	indx := hh.Index()
+
+	// Field (0) 'Attestations'
+	{
+		subIndx := hh.Index()
+		num := uint64(len(b.Attestations))
+		if num > 4096 {
+			err = ssz.ErrIncorrectListSize
+			return
+		}
+		for _, elem := range b.Attestations {
+			if err = elem.HashTreeRootWith(hh); err != nil {
+				return
+			}
+		}
+		hh.MerkleizeWithMixin(subIndx, num, 4096)
+	}
+
+	if b.HasOperations() {
+		// Field (1) 'Deposits'
+		{
+			subIndx := hh.Index()
+			num := uint64(len(b.Deposits))
+			if num > MaxDepositsPerBlock {
+				err = ssz.ErrIncorrectListSize
+				return
+			}
+			for _, elem := range b.Deposits {
+				if err = elem.HashTreeRootWith(hh); err != nil {
+					return
+				}
+			}
+			hh.MerkleizeWithMixin(subIndx, num, MaxDepositsPerBlock)
+		}
+
+		// Field (2) 'Exits'
+		{
+			subIndx := hh.Index()
+			num := uint64(len(b.Exits))
+			if num > MaxExitsPerBlock {
+				err = ssz.ErrIncorrectListSize
+				return
+			}
+			for _, elem := range b.Exits {
+				if err = elem.HashTreeRootWith(hh); err != nil {
+					return
+				}
+			}
+			hh.MerkleizeWithMixin(subIndx, num, MaxExitsPerBlock)
+		}
+
+		// Field (3) 'ProposerSlashings'
+		{
+			subIndx := hh.Index()
+			num := uint64(len(b.ProposerSlashings))
+			if num > MaxProposerSlashingsPerBlock {
+				err = ssz.ErrIncorrectListSize
+				return
+			}
+			for _, elem := range b.ProposerSlashings {
+				if err = elem.HashTreeRootWith(hh); err != nil {
+					return
+				}
+			}
+			hh.MerkleizeWithMixin(subIndx, num, MaxProposerSlashingsPerBlock)
+		}
+
+		// Field (4) 'AttesterSlashings'
+		{
+			subIndx := hh.Index()
+			num := uint64(len(b.AttesterSlashings))
+			if num > MaxAttesterSlashingsPerBlock {
+				err = ssz.ErrIncorrectListSize
+				return
+			}
+			for _, elem := range b.AttesterSlashings {
+				if err = elem.HashTreeRootWith(hh); err != nil {
+					return
+				}
+			}
+			hh.MerkleizeWithMixin(subIndx, num, MaxAttesterSlashingsPerBlock)
+		}
+	}
+
+	hh.Merkleize(indx)
+	return
+}
+
+// GetTree ssz hashes the BlockBody object
+func (b *BlockBody) GetTree() (*ssz.Node, error) {
+	return ssz.ProofTree(b)
+}
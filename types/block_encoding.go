@@ -1,5 +1,5 @@
 // Code generated by fastssz. DO NOT EDIT.
-// Hash: 6b1f21194a6dd7d468868013c6f418717ff9b1a46497537e2f7ab040fdd6217d
+// Hash: 0971aea3f9cbb442de98ec7c82263ff23d0a7e6f66a4d36a499a21f0f528a341
 // Version: 0.1.3
 package types
 
@@ -99,115 +99,532 @@ func (b *BlockHeader) GetTree() (*ssz.Node, error) {
 	return ssz.ProofTree(b)
 }
 
-// MarshalSSZ ssz marshals the BlockBody object
-func (b *BlockBody) MarshalSSZ() ([]byte, error) {
-	return ssz.MarshalSSZ(b)
+// MarshalSSZ ssz marshals the Deposit object
+func (d *Deposit) MarshalSSZ() ([]byte, error) {
+	return ssz.MarshalSSZ(d)
 }
 
-// MarshalSSZTo ssz marshals the BlockBody object to a target array
-func (b *BlockBody) MarshalSSZTo(buf []byte) (dst []byte, err error) {
+// MarshalSSZTo ssz marshals the Deposit object to a target array
+func (d *Deposit) MarshalSSZTo(buf []byte) (dst []byte, err error) {
 	dst = buf
-	offset := int(4)
 
-	// Offset (0) 'Attestations'
-	dst = ssz.WriteOffset(dst, offset)
+	// Field (0) 'Pubkey'
+	dst = append(dst, d.Pubkey[:]...)
+
+	// Field (1) 'Slot'
+	dst = ssz.MarshalUint64(dst, d.Slot)
+
+	return
+}
+
+// UnmarshalSSZ ssz unmarshals the Deposit object
+func (d *Deposit) UnmarshalSSZ(buf []byte) error {
+	var err error
+	size := uint64(len(buf))
+	if size != 60 {
+		return ssz.ErrSize
+	}
+
+	// Field (0) 'Pubkey'
+	copy(d.Pubkey[:], buf[0:52])
+
+	// Field (1) 'Slot'
+	d.Slot = ssz.UnmarshallUint64(buf[52:60])
+
+	return err
+}
+
+// SizeSSZ returns the ssz encoded size in bytes for the Deposit object
+func (d *Deposit) SizeSSZ() (size int) {
+	size = 60
+	return
+}
 
-	// Field (0) 'Attestations'
-	if size := len(b.Attestations); size > 4096 {
-		err = ssz.ErrListTooBigFn("BlockBody.Attestations", size, 4096)
+// HashTreeRoot ssz hashes the Deposit object
+func (d *Deposit) HashTreeRoot() ([32]byte, error) {
+	return ssz.HashWithDefaultHasher(d)
+}
+
+// HashTreeRootWith ssz hashes the Deposit object with a hasher
+func (d *Deposit) HashTreeRootWith(hh ssz.HashWalker) (err error) {
+	indx := hh.Index()
+
+	// Field (0) 'Pubkey'
+	hh.PutBytes(d.Pubkey[:])
+
+	// Field (1) 'Slot'
+	hh.PutUint64(d.Slot)
+
+	hh.Merkleize(indx)
+	return
+}
+
+// GetTree ssz hashes the Deposit object
+func (d *Deposit) GetTree() (*ssz.Node, error) {
+	return ssz.ProofTree(d)
+}
+
+// MarshalSSZ ssz marshals the SignedDeposit object
+func (s *SignedDeposit) MarshalSSZ() ([]byte, error) {
+	return ssz.MarshalSSZ(s)
+}
+
+// MarshalSSZTo ssz marshals the SignedDeposit object to a target array
+func (s *SignedDeposit) MarshalSSZTo(buf []byte) (dst []byte, err error) {
+	dst = buf
+
+	// Field (0) 'Message'
+	if s.Message == nil {
+		s.Message = new(Deposit)
+	}
+	if dst, err = s.Message.MarshalSSZTo(dst); err != nil {
 		return
 	}
-	for ii := 0; ii < len(b.Attestations); ii++ {
-		if dst, err = b.Attestations[ii].MarshalSSZTo(dst); err != nil {
-			return
-		}
+
+	// Field (1) 'Signature'
+	dst = append(dst, s.Signature[:]...)
+
+	return
+}
+
+// UnmarshalSSZ ssz unmarshals the SignedDeposit object
+func (s *SignedDeposit) UnmarshalSSZ(buf []byte) error {
+	var err error
+	size := uint64(len(buf))
+	if size != 3172 {
+		return ssz.ErrSize
+	}
+
+	// Field (0) 'Message'
+	if s.Message == nil {
+		s.Message = new(Deposit)
+	}
+	if err = s.Message.UnmarshalSSZ(buf[0:60]); err != nil {
+		return err
 	}
 
+	// Field (1) 'Signature'
+	copy(s.Signature[:], buf[60:3172])
+
+	return err
+}
+
+// SizeSSZ returns the ssz encoded size in bytes for the SignedDeposit object
+func (s *SignedDeposit) SizeSSZ() (size int) {
+	size = 3172
 	return
 }
 
-// UnmarshalSSZ ssz unmarshals the BlockBody object
-func (b *BlockBody) UnmarshalSSZ(buf []byte) error {
+// HashTreeRoot ssz hashes the SignedDeposit object
+func (s *SignedDeposit) HashTreeRoot() ([32]byte, error) {
+	return ssz.HashWithDefaultHasher(s)
+}
+
+// HashTreeRootWith ssz hashes the SignedDeposit object with a hasher
+func (s *SignedDeposit) HashTreeRootWith(hh ssz.HashWalker) (err error) {
+	indx := hh.Index()
+
+	// Field (0) 'Message'
+	if s.Message == nil {
+		s.Message = new(Deposit)
+	}
+	if err = s.Message.HashTreeRootWith(hh); err != nil {
+		return
+	}
+
+	// Field (1) 'Signature'
+	hh.PutBytes(s.Signature[:])
+
+	hh.Merkleize(indx)
+	return
+}
+
+// GetTree ssz hashes the SignedDeposit object
+func (s *SignedDeposit) GetTree() (*ssz.Node, error) {
+	return ssz.ProofTree(s)
+}
+
+// MarshalSSZ ssz marshals the VoluntaryExit object
+func (v *VoluntaryExit) MarshalSSZ() ([]byte, error) {
+	return ssz.MarshalSSZ(v)
+}
+
+// MarshalSSZTo ssz marshals the VoluntaryExit object to a target array
+func (v *VoluntaryExit) MarshalSSZTo(buf []byte) (dst []byte, err error) {
+	dst = buf
+
+	// Field (0) 'ValidatorIndex'
+	dst = ssz.MarshalUint64(dst, v.ValidatorIndex)
+
+	// Field (1) 'Slot'
+	dst = ssz.MarshalUint64(dst, v.Slot)
+
+	return
+}
+
+// UnmarshalSSZ ssz unmarshals the VoluntaryExit object
+func (v *VoluntaryExit) UnmarshalSSZ(buf []byte) error {
 	var err error
 	size := uint64(len(buf))
-	if size < 4 {
+	if size != 16 {
 		return ssz.ErrSize
 	}
 
-	tail := buf
-	var o0 uint64
+	// Field (0) 'ValidatorIndex'
+	v.ValidatorIndex = ssz.UnmarshallUint64(buf[0:8])
 
-	// Offset (0) 'Attestations'
-	if o0 = ssz.ReadOffset(buf[0:4]); o0 > size {
-		return ssz.ErrOffset
+	// Field (1) 'Slot'
+	v.Slot = ssz.UnmarshallUint64(buf[8:16])
+
+	return err
+}
+
+// SizeSSZ returns the ssz encoded size in bytes for the VoluntaryExit object
+func (v *VoluntaryExit) SizeSSZ() (size int) {
+	size = 16
+	return
+}
+
+// HashTreeRoot ssz hashes the VoluntaryExit object
+func (v *VoluntaryExit) HashTreeRoot() ([32]byte, error) {
+	return ssz.HashWithDefaultHasher(v)
+}
+
+// HashTreeRootWith ssz hashes the VoluntaryExit object with a hasher
+func (v *VoluntaryExit) HashTreeRootWith(hh ssz.HashWalker) (err error) {
+	indx := hh.Index()
+
+	// Field (0) 'ValidatorIndex'
+	hh.PutUint64(v.ValidatorIndex)
+
+	// Field (1) 'Slot'
+	hh.PutUint64(v.Slot)
+
+	hh.Merkleize(indx)
+	return
+}
+
+// GetTree ssz hashes the VoluntaryExit object
+func (v *VoluntaryExit) GetTree() (*ssz.Node, error) {
+	return ssz.ProofTree(v)
+}
+
+// MarshalSSZ ssz marshals the SignedVoluntaryExit object
+func (s *SignedVoluntaryExit) MarshalSSZ() ([]byte, error) {
+	return ssz.MarshalSSZ(s)
+}
+
+// MarshalSSZTo ssz marshals the SignedVoluntaryExit object to a target array
+func (s *SignedVoluntaryExit) MarshalSSZTo(buf []byte) (dst []byte, err error) {
+	dst = buf
+
+	// Field (0) 'Message'
+	if s.Message == nil {
+		s.Message = new(VoluntaryExit)
+	}
+	if dst, err = s.Message.MarshalSSZTo(dst); err != nil {
+		return
 	}
 
-	if o0 != 4 {
-		return ssz.ErrInvalidVariableOffset
+	// Field (1) 'Signature'
+	dst = append(dst, s.Signature[:]...)
+
+	return
+}
+
+// UnmarshalSSZ ssz unmarshals the SignedVoluntaryExit object
+func (s *SignedVoluntaryExit) UnmarshalSSZ(buf []byte) error {
+	var err error
+	size := uint64(len(buf))
+	if size != 3128 {
+		return ssz.ErrSize
 	}
 
-	// Field (0) 'Attestations'
-	{
-		buf = tail[o0:]
-		num, err := ssz.DivideInt2(len(buf), 136, 4096)
-		if err != nil {
-			return err
-		}
-		b.Attestations = make([]*Attestation, num)
-		for ii := 0; ii < num; ii++ {
-			if b.Attestations[ii] == nil {
-				b.Attestations[ii] = new(Attestation)
-			}
-			if err = b.Attestations[ii].UnmarshalSSZ(buf[ii*136 : (ii+1)*136]); err != nil {
-				return err
-			}
-		}
+	// Field (0) 'Message'
+	if s.Message == nil {
+		s.Message = new(VoluntaryExit)
+	}
+	if err = s.Message.UnmarshalSSZ(buf[0:16]); err != nil {
+		return err
 	}
+
+	// Field (1) 'Signature'
+	copy(s.Signature[:], buf[16:3128])
+
 	return err
 }
 
-// SizeSSZ returns the ssz encoded size in bytes for the BlockBody object
-func (b *BlockBody) SizeSSZ() (size int) {
-	size = 4
+// SizeSSZ returns the ssz encoded size in bytes for the SignedVoluntaryExit object
+func (s *SignedVoluntaryExit) SizeSSZ() (size int) {
+	size = 3128
+	return
+}
+
+// HashTreeRoot ssz hashes the SignedVoluntaryExit object
+func (s *SignedVoluntaryExit) HashTreeRoot() ([32]byte, error) {
+	return ssz.HashWithDefaultHasher(s)
+}
 
-	// Field (0) 'Attestations'
-	size += len(b.Attestations) * 136
+// HashTreeRootWith ssz hashes the SignedVoluntaryExit object with a hasher
+func (s *SignedVoluntaryExit) HashTreeRootWith(hh ssz.HashWalker) (err error) {
+	indx := hh.Index()
 
+	// Field (0) 'Message'
+	if s.Message == nil {
+		s.Message = new(VoluntaryExit)
+	}
+	if err = s.Message.HashTreeRootWith(hh); err != nil {
+		return
+	}
+
+	// Field (1) 'Signature'
+	hh.PutBytes(s.Signature[:])
+
+	hh.Merkleize(indx)
 	return
 }
 
-// HashTreeRoot ssz hashes the BlockBody object
-func (b *BlockBody) HashTreeRoot() ([32]byte, error) {
-	return ssz.HashWithDefaultHasher(b)
+// GetTree ssz hashes the SignedVoluntaryExit object
+func (s *SignedVoluntaryExit) GetTree() (*ssz.Node, error) {
+	return ssz.ProofTree(s)
+}
+
+// MarshalSSZ ssz marshals the ProposerSlashing object
+func (p *ProposerSlashing) MarshalSSZ() ([]byte, error) {
+	return ssz.MarshalSSZ(p)
+}
+
+// MarshalSSZTo ssz marshals the ProposerSlashing object to a target array
+func (p *ProposerSlashing) MarshalSSZTo(buf []byte) (dst []byte, err error) {
+	dst = buf
+
+	// Field (0) 'Header1'
+	if p.Header1 == nil {
+		p.Header1 = new(BlockHeader)
+	}
+	if dst, err = p.Header1.MarshalSSZTo(dst); err != nil {
+		return
+	}
+
+	// Field (1) 'Header2'
+	if p.Header2 == nil {
+		p.Header2 = new(BlockHeader)
+	}
+	if dst, err = p.Header2.MarshalSSZTo(dst); err != nil {
+		return
+	}
+
+	// Field (2) 'Attestation1'
+	if p.Attestation1 == nil {
+		p.Attestation1 = new(SignedAttestation)
+	}
+	if dst, err = p.Attestation1.MarshalSSZTo(dst); err != nil {
+		return
+	}
+
+	// Field (3) 'Attestation2'
+	if p.Attestation2 == nil {
+		p.Attestation2 = new(SignedAttestation)
+	}
+	if dst, err = p.Attestation2.MarshalSSZTo(dst); err != nil {
+		return
+	}
+
+	return
+}
+
+// UnmarshalSSZ ssz unmarshals the ProposerSlashing object
+func (p *ProposerSlashing) UnmarshalSSZ(buf []byte) error {
+	var err error
+	size := uint64(len(buf))
+	if size != 6720 {
+		return ssz.ErrSize
+	}
+
+	// Field (0) 'Header1'
+	if p.Header1 == nil {
+		p.Header1 = new(BlockHeader)
+	}
+	if err = p.Header1.UnmarshalSSZ(buf[0:112]); err != nil {
+		return err
+	}
+
+	// Field (1) 'Header2'
+	if p.Header2 == nil {
+		p.Header2 = new(BlockHeader)
+	}
+	if err = p.Header2.UnmarshalSSZ(buf[112:224]); err != nil {
+		return err
+	}
+
+	// Field (2) 'Attestation1'
+	if p.Attestation1 == nil {
+		p.Attestation1 = new(SignedAttestation)
+	}
+	if err = p.Attestation1.UnmarshalSSZ(buf[224:3472]); err != nil {
+		return err
+	}
+
+	// Field (3) 'Attestation2'
+	if p.Attestation2 == nil {
+		p.Attestation2 = new(SignedAttestation)
+	}
+	if err = p.Attestation2.UnmarshalSSZ(buf[3472:6720]); err != nil {
+		return err
+	}
+
+	return err
+}
+
+// SizeSSZ returns the ssz encoded size in bytes for the ProposerSlashing object
+func (p *ProposerSlashing) SizeSSZ() (size int) {
+	size = 6720
+	return
+}
+
+// HashTreeRoot ssz hashes the ProposerSlashing object
+func (p *ProposerSlashing) HashTreeRoot() ([32]byte, error) {
+	return ssz.HashWithDefaultHasher(p)
 }
 
-// HashTreeRootWith ssz hashes the BlockBody object with a hasher
-func (b *BlockBody) HashTreeRootWith(hh ssz.HashWalker) (err error) {
+// HashTreeRootWith ssz hashes the ProposerSlashing object with a hasher
+func (p *ProposerSlashing) HashTreeRootWith(hh ssz.HashWalker) (err error) {
 	indx := hh.Index()
 
-	// Field (0) 'Attestations'
-	{
-		subIndx := hh.Index()
-		num := uint64(len(b.Attestations))
-		if num > 4096 {
-			err = ssz.ErrIncorrectListSize
-			return
-		}
-		for _, elem := range b.Attestations {
-			if err = elem.HashTreeRootWith(hh); err != nil {
-				return
-			}
-		}
-		hh.MerkleizeWithMixin(subIndx, num, 4096)
+	// Field (0) 'Header1'
+	if p.Header1 == nil {
+		p.Header1 = new(BlockHeader)
+	}
+	if err = p.Header1.HashTreeRootWith(hh); err != nil {
+		return
+	}
+
+	// Field (1) 'Header2'
+	if p.Header2 == nil {
+		p.Header2 = new(BlockHeader)
+	}
+	if err = p.Header2.HashTreeRootWith(hh); err != nil {
+		return
+	}
+
+	// Field (2) 'Attestation1'
+	if p.Attestation1 == nil {
+		p.Attestation1 = new(SignedAttestation)
+	}
+	if err = p.Attestation1.HashTreeRootWith(hh); err != nil {
+		return
+	}
+
+	// Field (3) 'Attestation2'
+	if p.Attestation2 == nil {
+		p.Attestation2 = new(SignedAttestation)
+	}
+	if err = p.Attestation2.HashTreeRootWith(hh); err != nil {
+		return
 	}
 
 	hh.Merkleize(indx)
 	return
 }
 
-// GetTree ssz hashes the BlockBody object
-func (b *BlockBody) GetTree() (*ssz.Node, error) {
-	return ssz.ProofTree(b)
+// GetTree ssz hashes the ProposerSlashing object
+func (p *ProposerSlashing) GetTree() (*ssz.Node, error) {
+	return ssz.ProofTree(p)
+}
+
+// MarshalSSZ ssz marshals the AttesterSlashing object
+func (a *AttesterSlashing) MarshalSSZ() ([]byte, error) {
+	return ssz.MarshalSSZ(a)
+}
+
+// MarshalSSZTo ssz marshals the AttesterSlashing object to a target array
+func (a *AttesterSlashing) MarshalSSZTo(buf []byte) (dst []byte, err error) {
+	dst = buf
+
+	// Field (0) 'Attestation1'
+	if a.Attestation1 == nil {
+		a.Attestation1 = new(SignedAttestation)
+	}
+	if dst, err = a.Attestation1.MarshalSSZTo(dst); err != nil {
+		return
+	}
+
+	// Field (1) 'Attestation2'
+	if a.Attestation2 == nil {
+		a.Attestation2 = new(SignedAttestation)
+	}
+	if dst, err = a.Attestation2.MarshalSSZTo(dst); err != nil {
+		return
+	}
+
+	return
+}
+
+// UnmarshalSSZ ssz unmarshals the AttesterSlashing object
+func (a *AttesterSlashing) UnmarshalSSZ(buf []byte) error {
+	var err error
+	size := uint64(len(buf))
+	if size != 6496 {
+		return ssz.ErrSize
+	}
+
+	// Field (0) 'Attestation1'
+	if a.Attestation1 == nil {
+		a.Attestation1 = new(SignedAttestation)
+	}
+	if err = a.Attestation1.UnmarshalSSZ(buf[0:3248]); err != nil {
+		return err
+	}
+
+	// Field (1) 'Attestation2'
+	if a.Attestation2 == nil {
+		a.Attestation2 = new(SignedAttestation)
+	}
+	if err = a.Attestation2.UnmarshalSSZ(buf[3248:6496]); err != nil {
+		return err
+	}
+
+	return err
+}
+
+// SizeSSZ returns the ssz encoded size in bytes for the AttesterSlashing object
+func (a *AttesterSlashing) SizeSSZ() (size int) {
+	size = 6496
+	return
+}
+
+// HashTreeRoot ssz hashes the AttesterSlashing object
+func (a *AttesterSlashing) HashTreeRoot() ([32]byte, error) {
+	return ssz.HashWithDefaultHasher(a)
+}
+
+// HashTreeRootWith ssz hashes the AttesterSlashing object with a hasher
+func (a *AttesterSlashing) HashTreeRootWith(hh ssz.HashWalker) (err error) {
+	indx := hh.Index()
+
+	// Field (0) 'Attestation1'
+	if a.Attestation1 == nil {
+		a.Attestation1 = new(SignedAttestation)
+	}
+	if err = a.Attestation1.HashTreeRootWith(hh); err != nil {
+		return
+	}
+
+	// Field (1) 'Attestation2'
+	if a.Attestation2 == nil {
+		a.Attestation2 = new(SignedAttestation)
+	}
+	if err = a.Attestation2.HashTreeRootWith(hh); err != nil {
+		return
+	}
+
+	hh.Merkleize(indx)
+	return
+}
+
+// GetTree ssz hashes the AttesterSlashing object
+func (a *AttesterSlashing) GetTree() (*ssz.Node, error) {
+	return ssz.ProofTree(a)
 }
 
 // MarshalSSZ ssz marshals the Block object
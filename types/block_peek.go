@@ -0,0 +1,60 @@
+package types
+
+import (
+	ssz "github.com/ferranbt/fastssz"
+)
+
+// Fixed-size prefix lengths of the SignedBlockWithAttestation encoding.
+const (
+	signedBlockFixedLen     = 8   // Message offset + Signature offset
+	blockWithAttFixedLen    = 140 // Block offset + ProposerAttestation
+	blockFixedLen           = 84  // Slot, ProposerIndex, ParentRoot, StateRoot, Body offset
+	signedBlockHeaderOffset = signedBlockFixedLen + blockWithAttFixedLen
+)
+
+// BlockHeaderFields are the fixed-size block fields that can be read from a
+// SignedBlockWithAttestation encoding without decoding its variable parts.
+type BlockHeaderFields struct {
+	Slot          uint64
+	ProposerIndex uint64
+	ParentRoot    [32]byte
+	StateRoot     [32]byte
+}
+
+// PeekSignedBlockHeader extracts the block header fields from an SSZ-encoded
+// SignedBlockWithAttestation. Only the offsets leading to the block are
+// checked; the attestation and signature lists are not decoded, so a
+// successful peek does not guarantee UnmarshalSSZ will succeed.
+func PeekSignedBlockHeader(buf []byte) (BlockHeaderFields, error) {
+	var h BlockHeaderFields
+	size := uint64(len(buf))
+	if size < signedBlockHeaderOffset+blockFixedLen {
+		return h, ssz.ErrSize
+	}
+
+	// SignedBlockWithAttestation: Message must start right after the offsets
+	// and end no earlier than the fixed part of the block.
+	if ssz.ReadOffset(buf[0:4]) != signedBlockFixedLen {
+		return h, ssz.ErrInvalidVariableOffset
+	}
+	if o1 := ssz.ReadOffset(buf[4:8]); o1 > size || o1 < signedBlockHeaderOffset+blockFixedLen {
+		return h, ssz.ErrOffset
+	}
+
+	// BlockWithAttestation: Block follows the proposer attestation.
+	msg := buf[signedBlockFixedLen:]
+	if ssz.ReadOffset(msg[0:4]) != blockWithAttFixedLen {
+		return h, ssz.ErrInvalidVariableOffset
+	}
+
+	// Block: fixed fields, then the Body offset.
+	block := buf[signedBlockHeaderOffset:]
+	if ssz.ReadOffset(block[80:84]) != blockFixedLen {
+		return h, ssz.ErrInvalidVariableOffset
+	}
+	h.Slot = ssz.UnmarshallUint64(block[0:8])
+	h.ProposerIndex = ssz.UnmarshallUint64(block[8:16])
+	copy(h.ParentRoot[:], block[16:48])
+	copy(h.StateRoot[:], block[48:80])
+	return h, nil
+}
@@ -0,0 +1,94 @@
+package types
+
+import (
+	"testing"
+
+	ssz "github.com/ferranbt/fastssz"
+)
+
+func TestBlockBodyOperationsRoundTrip(t *testing.T) {
+	cp := &Checkpoint{Root: [32]byte{1}, Slot: 1}
+	body := &BlockBody{
+		Attestations: []*Attestation{{ValidatorID: 2, Data: &AttestationData{Slot: 1, Head: cp, Target: cp, Source: &Checkpoint{}}}},
+		Deposits:     []*SignedDeposit{{Message: &Deposit{Pubkey: [52]byte{7}}}, {Message: &Deposit{Pubkey: [52]byte{8}, Slot: 3}, Signature: [3112]byte{1}}},
+		Exits:        []*SignedVoluntaryExit{{Message: &VoluntaryExit{ValidatorIndex: 5, Slot: 2}}},
+		AttesterSlashings: []*AttesterSlashing{{
+			Attestation1: &SignedAttestation{ValidatorID: 3, Message: &AttestationData{Slot: 4, Head: cp, Target: cp, Source: cp}},
+			Attestation2: &SignedAttestation{ValidatorID: 3, Message: &AttestationData{Slot: 4, Head: &Checkpoint{}, Target: cp, Source: cp}},
+		}},
+	}
+	enc, err := body.MarshalSSZ()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(enc) != body.SizeSSZ() {
+		t.Fatalf("encoded %d bytes, SizeSSZ says %d", len(enc), body.SizeSSZ())
+	}
+	var dec BlockBody
+	if err := dec.UnmarshalSSZ(enc); err != nil {
+		t.Fatal(err)
+	}
+	if len(dec.Attestations) != 1 || len(dec.Deposits) != 2 || *dec.Deposits[1].Message != (Deposit{Pubkey: [52]byte{8}, Slot: 3}) || dec.Deposits[1].Signature[0] != 1 ||
+		len(dec.Exits) != 1 || *dec.Exits[0].Message != (VoluntaryExit{ValidatorIndex: 5, Slot: 2}) ||
+		len(dec.AttesterSlashings) != 1 || dec.AttesterSlashings[0].Attestation2.ValidatorID != 3 {
+		t.Fatalf("decoded %+v", dec)
+	}
+	want, _ := body.HashTreeRoot()
+	if got, _ := dec.HashTreeRoot(); got != want {
+		t.Fatal("decoded body hashes differently")
+	}
+
+	// The operations are part of the body root.
+	body.Exits = nil
+	withoutExits, _ := body.HashTreeRoot()
+	if withoutExits == want {
+		t.Fatal("body root ignores exits")
+	}
+	body.AttesterSlashings = nil
+	if got, _ := body.HashTreeRoot(); got == withoutExits {
+		t.Fatal("body root ignores slashings")
+	}
+
+	body.Deposits = make([]*SignedDeposit, MaxDepositsPerBlock+1)
+	for i := range body.Deposits {
+		body.Deposits[i] = &SignedDeposit{Message: &Deposit{}}
+	}
+	if _, err := body.MarshalSSZ(); err == nil {
+		t.Fatal("encoded more deposits than a block may carry")
+	}
+}
+
+func TestBlockBodyWithoutOperationsIsSpecBody(t *testing.T) {
+	body := &BlockBody{Attestations: []*Attestation{{ValidatorID: 1, Data: &AttestationData{Head: &Checkpoint{}, Target: &Checkpoint{}, Source: &Checkpoint{}}}}}
+	enc, err := body.MarshalSSZ()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(enc) != blockBodySpecFixedSize+attestationSize || enc[0] != blockBodySpecFixedSize {
+		t.Fatalf("body without operations encoded in %d bytes with offset %d", len(enc), enc[0])
+	}
+
+	// The spec body is a container of the attestation list alone, so its
+	// root is the list root.
+	hh := ssz.NewHasher()
+	indx := hh.Index()
+	for _, a := range body.Attestations {
+		if err := a.HashTreeRootWith(hh); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hh.MerkleizeWithMixin(indx, 1, 4096)
+	want, _ := hh.HashRoot()
+	if got, _ := body.HashTreeRoot(); got != want {
+		t.Fatalf("root %x, want the attestation list root %x", got, want)
+	}
+
+	// The five-field layout is only canonical for a body with operations.
+	extended := make([]byte, blockBodyExtendedFixedSize)
+	for i := 0; i < 5; i++ {
+		extended[4*i] = blockBodyExtendedFixedSize
+	}
+	if err := new(BlockBody).UnmarshalSSZ(extended); err == nil {
+		t.Fatal("decoded an extended body without operations")
+	}
+}
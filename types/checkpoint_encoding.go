@@ -1,5 +1,5 @@
 // Code generated by fastssz. DO NOT EDIT.
-// Hash: 6b1f21194a6dd7d468868013c6f418717ff9b1a46497537e2f7ab040fdd6217d
+// Hash: 0971aea3f9cbb442de98ec7c82263ff23d0a7e6f66a4d36a499a21f0f528a341
 // Version: 0.1.3
 package types
 
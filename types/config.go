@@ -1,6 +1,47 @@
 package types
 
-// Config holds genesis configuration.
+// Config is the chain config, committed to by the state. GenesisTime is the
+// reference spec config. Overrides holds the preset parameters a devnet
+// changes, zero fields keeping the spec value; a state encodes it only when
+// it is not zero (see state_extension.go), so a chain that changes nothing
+// has the spec state root and a chain that does cannot be mistaken for it.
 type Config struct {
 	GenesisTime uint64
+	Overrides   Preset
+}
+
+// NewConfig returns the config of a chain starting at genesisTime with
+// preset p. Parameters p leaves at their spec value are not overridden.
+func NewConfig(genesisTime uint64, p Preset) *Config {
+	if p.SecondsPerSlot == DefaultPreset.SecondsPerSlot {
+		p.SecondsPerSlot = 0
+	}
+	if p.JustificationLookback == DefaultPreset.JustificationLookback {
+		p.JustificationLookback = 0
+	}
+	return &Config{GenesisTime: genesisTime, Overrides: p}
+}
+
+// Preset returns the preset of the chain: the spec preset with the
+// overrides applied.
+func (c *Config) Preset() Preset {
+	p := DefaultPreset
+	if c == nil {
+		return p
+	}
+	if c.Overrides.SecondsPerSlot != 0 {
+		p.SecondsPerSlot = c.Overrides.SecondsPerSlot
+	}
+	if c.Overrides.JustificationLookback != 0 {
+		p.JustificationLookback = c.Overrides.JustificationLookback
+	}
+	p.ProposerSelection = c.Overrides.ProposerSelection
+	p.ProposerSeed = c.Overrides.ProposerSeed
+	p.DynamicValidators = c.Overrides.DynamicValidators
+	return p
+}
+
+// hasOverrides reports whether the config changes any spec parameter.
+func (c *Config) hasOverrides() bool {
+	return c != nil && c.Overrides != (Preset{})
 }
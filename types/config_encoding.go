@@ -1,12 +1,14 @@
-// Code generated by fastssz. DO NOT EDIT.
-// Hash: 6b1f21194a6dd7d468868013c6f418717ff9b1a46497537e2f7ab040fdd6217d
-// Version: 0.1.3
 package types
 
 import (
 	ssz "github.com/ferranbt/fastssz"
 )
 
+// Config is excluded from sszgen (see generate.go): it encodes as the
+// reference spec Config, GenesisTime only. The overrides are encoded with
+// the state; see state_extension.go. The methods below are the sszgen
+// output for the spec Config.
+
 // MarshalSSZ ssz marshals the Config object
 func (c *Config) MarshalSSZ() ([]byte, error) {
 	return ssz.MarshalSSZ(c)
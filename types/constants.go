@@ -1,10 +1,12 @@
 package types
 
-// Protocol constants from the reference spec.
+// Protocol constants from the reference spec. A devnet may override the
+// slot duration and the justification lookback in its genesis config, so
+// chain code reads them from Config.Preset rather than from here.
 const (
-	SecondsPerSlot        = 4
 	IntervalsPerSlot      = 4
-	SecondsPerInterval    = SecondsPerSlot / IntervalsPerSlot // 1
+	SecondsPerSlot        = 4
+	SecondsPerInterval    = SecondsPerSlot / IntervalsPerSlot
 	JustificationLookback = 3
 	MaxRequestBlocks      = 1024
 	SlotsPerEpoch         = 32
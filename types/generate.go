@@ -1,3 +1,3 @@
 package types
 
-//go:generate sszgen --path . --objs Checkpoint,Config,Validator,AttestationData,Attestation,SignedAttestation,BlockHeader,BlockBody,Block,BlockWithAttestation,SignedBlockWithAttestation,State
+//go:generate sszgen --path . --objs Checkpoint,AttestationData,Attestation,SignedAttestation,BlockHeader,Deposit,SignedDeposit,VoluntaryExit,SignedVoluntaryExit,ProposerSlashing,AttesterSlashing,Block,BlockWithAttestation,SignedBlockWithAttestation
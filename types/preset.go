@@ -0,0 +1,86 @@
+package types
+
+import (
+	"fmt"
+	"time"
+)
+
+// ProposerSelection is a proposer selection scheme.
+type ProposerSelection uint8
+
+const (
+	// ProposerRoundRobin gives slot s to validator s mod n.
+	ProposerRoundRobin ProposerSelection = iota
+	// ProposerShuffled gives each round of n slots to a permutation of the
+	// n validators drawn from the proposer seed.
+	ProposerShuffled
+)
+
+var proposerSelectionNames = [...]string{
+	ProposerRoundRobin: "round_robin",
+	ProposerShuffled:   "shuffled",
+}
+
+func (p ProposerSelection) String() string {
+	if int(p) < len(proposerSelectionNames) {
+		return proposerSelectionNames[p]
+	}
+	return fmt.Sprintf("ProposerSelection(%d)", p)
+}
+
+// ParseProposerSelection returns the scheme named s.
+func ParseProposerSelection(s string) (ProposerSelection, error) {
+	for p, name := range proposerSelectionNames {
+		if s == name {
+			return ProposerSelection(p), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown proposer selection %q", s)
+}
+
+// Preset holds the protocol parameters a devnet may override in its genesis
+// config instead of using the reference spec values.
+type Preset struct {
+	SecondsPerSlot        uint64
+	JustificationLookback uint64
+	ProposerSelection     ProposerSelection
+	ProposerSeed          [32]byte // for ProposerShuffled
+	// DynamicValidators enables deposits, exits and slashings. The spec
+	// registry is fixed at genesis, so a chain that enables them is not
+	// interoperable with spec clients.
+	DynamicValidators bool
+}
+
+// DefaultPreset is the reference spec preset. A chain's preset is
+// committed to its genesis config; see Config.Preset.
+var DefaultPreset = Preset{
+	SecondsPerSlot:        SecondsPerSlot,
+	JustificationLookback: JustificationLookback,
+	ProposerSelection:     ProposerRoundRobin,
+}
+
+// Validate checks that p is usable: slots must split into whole-second
+// intervals, the lookback must be positive and the proposer selection must
+// be known.
+func (p Preset) Validate() error {
+	if p.SecondsPerSlot == 0 || p.SecondsPerSlot%IntervalsPerSlot != 0 {
+		return fmt.Errorf("seconds per slot %d must be a positive multiple of %d", p.SecondsPerSlot, IntervalsPerSlot)
+	}
+	if p.JustificationLookback == 0 {
+		return fmt.Errorf("justification lookback must be positive")
+	}
+	if int(p.ProposerSelection) >= len(proposerSelectionNames) {
+		return fmt.Errorf("unknown proposer selection %s", p.ProposerSelection)
+	}
+	return nil
+}
+
+// SecondsPerInterval returns the length of one interval of a slot.
+func (p Preset) SecondsPerInterval() uint64 {
+	return p.SecondsPerSlot / IntervalsPerSlot
+}
+
+// SlotDuration returns the length of a slot.
+func (p Preset) SlotDuration() time.Duration {
+	return time.Duration(p.SecondsPerSlot) * time.Second
+}
@@ -2,6 +2,11 @@ package types
 
 import "math"
 
+// EpochAt returns the epoch slot falls in.
+func EpochAt(slot uint64) uint64 {
+	return slot / SlotsPerEpoch
+}
+
 // IsJustifiableAfter checks if a slot is a valid candidate for justification
 // after a given finalized slot according to 3SF-mini rules.
 //
@@ -9,13 +14,83 @@ import "math"
 //  1. Less than or equal to 5
 //  2. A perfect square (e.g., 9, 16, 25...)
 //  3. A pronic number n*(n+1) (e.g., 6, 12, 20, 30...)
+//
+// Distances within justifiableWindow are answered from a precomputed bitmap.
 func IsJustifiableAfter(slot, finalizedSlot uint64) bool {
 	if slot < finalizedSlot {
 		return false
 	}
+	return isJustifiableDelta(slot - finalizedSlot)
+}
+
+// justifiableWindow is how many slots after the finalized slot the
+// justifiability bitmap covers. Later slots are computed.
+const justifiableWindow = 1 << 14
+
+// justifiableBits has bit d set when distance d from the finalized slot is
+// justifiable, for d below justifiableWindow.
+var justifiableBits = func() []uint64 {
+	bits := make([]uint64, justifiableWindow/64)
+	for d := uint64(0); d < justifiableWindow; d++ {
+		if computeJustifiableDelta(d) {
+			bits[d/64] |= 1 << (d % 64)
+		}
+	}
+	return bits
+}()
+
+func isJustifiableDelta(delta uint64) bool {
+	if delta < justifiableWindow {
+		return justifiableBits[delta/64]&(1<<(delta%64)) != 0
+	}
+	return computeJustifiableDelta(delta)
+}
 
-	delta := slot - finalizedSlot
+// JustifiableSlots answers justifiability for one finalized slot. It is what
+// hot loops over candidate slots use, in state transition and fork choice
+// alike.
+type JustifiableSlots struct {
+	finalized uint64
+}
+
+// JustifiableAfter returns the justifiable slots after finalizedSlot.
+func JustifiableAfter(finalizedSlot uint64) JustifiableSlots {
+	return JustifiableSlots{finalized: finalizedSlot}
+}
+
+// Contains reports whether slot is justifiable, as IsJustifiableAfter.
+func (j JustifiableSlots) Contains(slot uint64) bool {
+	return slot >= j.finalized && isJustifiableDelta(slot-j.finalized)
+}
+
+// AnyBetween reports whether a slot in [from, to) is justifiable. Within the
+// bitmap it tests 64 slots at a time.
+func (j JustifiableSlots) AnyBetween(from, to uint64) bool {
+	from = max(from, j.finalized)
+	for from < to {
+		d := from - j.finalized
+		if d >= justifiableWindow {
+			if computeJustifiableDelta(d) {
+				return true
+			}
+			from++
+			continue
+		}
+		word := justifiableBits[d/64] >> (d % 64)
+		if n := min(64-d%64, to-from); n < 64 {
+			word &= 1<<n - 1
+		}
+		if word != 0 {
+			return true
+		}
+		from += 64 - d%64
+	}
+	return false
+}
 
+// computeJustifiableDelta applies the 3SF-mini rules to a distance from the
+// finalized slot.
+func computeJustifiableDelta(delta uint64) bool {
 	// Rule 1: first 5 slots always justifiable
 	if delta <= 5 {
 		return true
@@ -0,0 +1,37 @@
+package types
+
+import "testing"
+
+func TestJustifiableBitmapMatchesRules(t *testing.T) {
+	for d := uint64(0); d < justifiableWindow+100; d++ {
+		if got, want := IsJustifiableAfter(7+d, 7), computeJustifiableDelta(d); got != want {
+			t.Fatalf("distance %d: justifiable = %v, want %v", d, got, want)
+		}
+	}
+	if IsJustifiableAfter(6, 7) || JustifiableAfter(7).Contains(6) {
+		t.Fatal("slot before the finalized slot is justifiable")
+	}
+}
+
+func TestJustifiableAnyBetween(t *testing.T) {
+	for _, finalized := range []uint64{0, 3, 64, 1000, justifiableWindow - 50} {
+		j := JustifiableAfter(finalized)
+		for _, r := range [][2]uint64{
+			{0, 10}, {finalized + 6, finalized + 9}, {finalized + 10, finalized + 12},
+			{finalized + 31, finalized + 36}, {finalized + 63, finalized + 200},
+			{finalized + 1000, finalized + 1100}, {finalized + 5, finalized + 5},
+			{finalized + justifiableWindow - 10, finalized + justifiableWindow + 300},
+		} {
+			want := false
+			for s := r[0]; s < r[1]; s++ {
+				if IsJustifiableAfter(s, finalized) {
+					want = true
+					break
+				}
+			}
+			if got := j.AnyBetween(r[0], r[1]); got != want {
+				t.Fatalf("finalized %d, [%d, %d): any = %v, want %v", finalized, r[0], r[1], got, want)
+			}
+		}
+	}
+}
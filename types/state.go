@@ -1,5 +1,7 @@
 package types
 
+import "math"
+
 // SSZ limits matching the reference spec.
 const (
 	HistoricalRootsLimit   = 1 << 18                                       // 262144
@@ -7,10 +9,44 @@ const (
 	JustificationValsLimit = HistoricalRootsLimit * ValidatorRegistryLimit // 1073741824
 )
 
-// Validator represents a validator in the registry.
+// FarFutureEpoch bounds activation epochs: a validator activating then
+// would never be active.
+const FarFutureEpoch = math.MaxUint64
+
+// Validator represents a validator in the registry. Its SSZ container is
+// the reference spec one, Pubkey and Index; the lifecycle fields are only
+// set on chains with dynamic validators and are encoded with the state
+// (see state_extension.go). The zero lifecycle is a validator active from
+// genesis that never exits.
 type Validator struct {
 	Pubkey [52]byte `ssz-size:"52"`
 	Index  uint64
+
+	// ActivationEpoch is the first epoch the validator is active in.
+	ActivationEpoch uint64
+	// ExitEpoch is the first epoch the validator is no longer active in, or
+	// zero if it has not exited. No exit takes effect at epoch zero.
+	ExitEpoch uint64
+	// Slashed is set once the validator is caught equivocating. A slashed
+	// validator is no longer active, and its exit is scheduled.
+	Slashed bool
+}
+
+// IsActive reports whether the validator is active in epoch. A slashed
+// validator is never active, even before its exit epoch.
+func (v *Validator) IsActive(epoch uint64) bool {
+	return !v.Slashed && v.ActivationEpoch <= epoch && (v.ExitEpoch == 0 || epoch < v.ExitEpoch)
+}
+
+// HasExited reports whether an exit is scheduled or past for the validator.
+func (v *Validator) HasExited() bool {
+	return v.ExitEpoch != 0
+}
+
+// hasLifecycle reports whether the validator's lifecycle differs from the
+// zero one.
+func (v *Validator) hasLifecycle() bool {
+	return v.ActivationEpoch != 0 || v.ExitEpoch != 0 || v.Slashed
 }
 
 // State is the main consensus state object.
@@ -25,16 +61,57 @@ type State struct {
 	Validators               []*Validator `json:"validators"                 ssz-max:"4096"`
 	JustificationsRoots      [][32]byte   `json:"justifications_roots"       ssz-max:"262144"`
 	JustificationsValidators []byte       `json:"justifications_validators"  ssz:"bitlist" ssz-max:"1073741824"`
+
+	hashCache *stateHashCache
+}
+
+// ShallowCopy returns a copy of the state that shares its lists with s, so it
+// costs the same whatever the state size. The small fields are copied. The
+// lists are clipped to their length, so appending to them on either state
+// reallocates instead of writing into the other's backing array; their
+// elements must never be written in place. The state transition follows
+// that rule, building new lists for whatever it changes.
+func (s *State) ShallowCopy() *State {
+	out := &State{
+		Slot:                     s.Slot,
+		HistoricalBlockHashes:    clip(s.HistoricalBlockHashes),
+		JustifiedSlots:           clip(s.JustifiedSlots),
+		Validators:               clip(s.Validators),
+		JustificationsRoots:      clip(s.JustificationsRoots),
+		JustificationsValidators: clip(s.JustificationsValidators),
+		hashCache:                s.sharedHashCache(),
+	}
+	if s.Config != nil {
+		config := *s.Config
+		out.Config = &config
+	}
+	if s.LatestBlockHeader != nil {
+		h := *s.LatestBlockHeader
+		out.LatestBlockHeader = &h
+	}
+	if s.LatestJustified != nil {
+		out.LatestJustified = &Checkpoint{Root: s.LatestJustified.Root, Slot: s.LatestJustified.Slot}
+	}
+	if s.LatestFinalized != nil {
+		out.LatestFinalized = &Checkpoint{Root: s.LatestFinalized.Root, Slot: s.LatestFinalized.Slot}
+	}
+	return out
+}
+
+func clip[T any](s []T) []T {
+	return s[:len(s):len(s)]
 }
 
 // Copy returns a deep copy of the state.
 func (s *State) Copy() *State {
 	out := &State{
-		Slot: s.Slot,
+		Slot:      s.Slot,
+		hashCache: s.sharedHashCache(),
 	}
 
 	if s.Config != nil {
-		out.Config = &Config{GenesisTime: s.Config.GenesisTime}
+		config := *s.Config
+		out.Config = &config
 	}
 	if s.LatestBlockHeader != nil {
 		h := *s.LatestBlockHeader
@@ -72,3 +149,16 @@ func (s *State) Copy() *State {
 
 	return out
 }
+
+// ActiveValidators returns the indices of the validators of s active at
+// slot, in registry order.
+func (s *State) ActiveValidators(slot uint64) []uint64 {
+	epoch := EpochAt(slot)
+	active := make([]uint64, 0, len(s.Validators))
+	for i, v := range s.Validators {
+		if v.IsActive(epoch) {
+			active = append(active, uint64(i))
+		}
+	}
+	return active
+}
@@ -0,0 +1,178 @@
+package types
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	ssz "github.com/ferranbt/fastssz"
+)
+
+// A state whose chain config has overrides, or whose validators have a
+// lifecycle other than the zero one, carries them in an eleventh field, the
+// extension, after the ten fields of the reference spec State. A state
+// without either encodes and hashes exactly as the spec State. Decoders tell
+// the two apart by the first offset, which is the size of the fixed part; an
+// extension that carries nothing is rejected, so each state has one
+// encoding.
+//
+// The extension container is
+//
+//	Overrides  Preset
+//	Lifecycles List[ValidatorLifecycle, ValidatorRegistryLimit]
+//
+// where ValidatorLifecycle is (ActivationEpoch, ExitEpoch, Slashed). The
+// list is empty when every validator has the zero lifecycle and otherwise
+// holds one entry per validator.
+
+const (
+	stateFixedSize         = 228
+	stateExtendedFixedSize = stateFixedSize + 4
+	presetSize             = 8 + 8 + 1 + 32 + 1
+	lifecycleSize          = 8 + 8 + 1
+	extensionFixedSize     = presetSize + 4
+)
+
+var (
+	errEmptyExtension = errors.New("state extension carries nothing")
+	errInvalidBool    = errors.New("state extension: invalid bool")
+)
+
+// stateExtension is the SSZ container of the extension field.
+type stateExtension struct {
+	Overrides  Preset
+	Lifecycles []validatorLifecycle
+}
+
+type validatorLifecycle struct {
+	ActivationEpoch uint64
+	ExitEpoch       uint64
+	Slashed         bool
+}
+
+// extension returns the extension of s, or nil if it encodes as the spec
+// State.
+func (s *State) extension() *stateExtension {
+	ext := &stateExtension{}
+	if s.Config != nil {
+		ext.Overrides = s.Config.Overrides
+	}
+	for _, v := range s.Validators {
+		if v.hasLifecycle() {
+			ext.Lifecycles = make([]validatorLifecycle, len(s.Validators))
+			for i, v := range s.Validators {
+				ext.Lifecycles[i] = validatorLifecycle{v.ActivationEpoch, v.ExitEpoch, v.Slashed}
+			}
+			break
+		}
+	}
+	if ext.Overrides == (Preset{}) && ext.Lifecycles == nil {
+		return nil
+	}
+	return ext
+}
+
+// apply sets the overrides and validator lifecycles of s from e.
+func (e *stateExtension) apply(s *State) error {
+	if len(e.Lifecycles) != 0 && len(e.Lifecycles) != len(s.Validators) {
+		return fmt.Errorf("state extension: %d lifecycles for %d validators", len(e.Lifecycles), len(s.Validators))
+	}
+	s.Config.Overrides = e.Overrides
+	for i, l := range e.Lifecycles {
+		v := s.Validators[i]
+		v.ActivationEpoch, v.ExitEpoch, v.Slashed = l.ActivationEpoch, l.ExitEpoch, l.Slashed
+	}
+	return nil
+}
+
+func (e *stateExtension) sizeSSZ() int {
+	return extensionFixedSize + len(e.Lifecycles)*lifecycleSize
+}
+
+func (e *stateExtension) marshalTo(dst []byte) []byte {
+	p := &e.Overrides
+	dst = ssz.MarshalUint64(dst, p.SecondsPerSlot)
+	dst = ssz.MarshalUint64(dst, p.JustificationLookback)
+	dst = append(dst, byte(p.ProposerSelection))
+	dst = append(dst, p.ProposerSeed[:]...)
+	dst = ssz.MarshalBool(dst, p.DynamicValidators)
+	dst = ssz.WriteOffset(dst, extensionFixedSize)
+	for _, l := range e.Lifecycles {
+		dst = ssz.MarshalUint64(dst, l.ActivationEpoch)
+		dst = ssz.MarshalUint64(dst, l.ExitEpoch)
+		dst = ssz.MarshalBool(dst, l.Slashed)
+	}
+	return dst
+}
+
+func (e *stateExtension) unmarshal(buf []byte) error {
+	if len(buf) < extensionFixedSize {
+		return ssz.ErrSize
+	}
+	p := &e.Overrides
+	p.SecondsPerSlot = ssz.UnmarshallUint64(buf[0:8])
+	p.JustificationLookback = ssz.UnmarshallUint64(buf[8:16])
+	p.ProposerSelection = ProposerSelection(buf[16])
+	copy(p.ProposerSeed[:], buf[17:49])
+	if buf[49] > 1 {
+		return errInvalidBool
+	}
+	p.DynamicValidators = buf[49] == 1
+	if int(p.ProposerSelection) >= len(proposerSelectionNames) {
+		return fmt.Errorf("state extension: unknown proposer selection %d", p.ProposerSelection)
+	}
+	if ssz.ReadOffset(buf[presetSize:extensionFixedSize]) != extensionFixedSize {
+		return ssz.ErrInvalidVariableOffset
+	}
+
+	tail := buf[extensionFixedSize:]
+	num, err := ssz.DivideInt2(len(tail), lifecycleSize, ValidatorRegistryLimit)
+	if err != nil {
+		return err
+	}
+	e.Lifecycles = make([]validatorLifecycle, num)
+	nonZero := false
+	for i := range e.Lifecycles {
+		b := tail[i*lifecycleSize : (i+1)*lifecycleSize]
+		if b[16] > 1 {
+			return errInvalidBool
+		}
+		l := validatorLifecycle{ssz.UnmarshallUint64(b[0:8]), ssz.UnmarshallUint64(b[8:16]), b[16] == 1}
+		nonZero = nonZero || l != (validatorLifecycle{})
+		e.Lifecycles[i] = l
+	}
+	if num != 0 && !nonZero {
+		return fmt.Errorf("state extension: every lifecycle is zero")
+	}
+	if *p == (Preset{}) && num == 0 {
+		return errEmptyExtension
+	}
+	return nil
+}
+
+func (e *stateExtension) hashTreeRoot() [32]byte {
+	p := &e.Overrides
+	var chunks [5][32]byte
+	binary.LittleEndian.PutUint64(chunks[0][:8], p.SecondsPerSlot)
+	binary.LittleEndian.PutUint64(chunks[1][:8], p.JustificationLookback)
+	chunks[2][0] = byte(p.ProposerSelection)
+	chunks[3] = p.ProposerSeed
+	if p.DynamicValidators {
+		chunks[4][0] = 1
+	}
+	presetRoot := merkleize(chunks[:], 3)
+
+	leaves := make([][32]byte, len(e.Lifecycles))
+	for i, l := range e.Lifecycles {
+		var fields [3][32]byte
+		binary.LittleEndian.PutUint64(fields[0][:8], l.ActivationEpoch)
+		binary.LittleEndian.PutUint64(fields[1][:8], l.ExitEpoch)
+		if l.Slashed {
+			fields[2][0] = 1
+		}
+		leaves[i] = merkleize(fields[:], 2)
+	}
+	lifecyclesRoot := mixInLength(merkleize(leaves, depthOf(ValidatorRegistryLimit)), uint64(len(leaves)))
+
+	return merkleize([][32]byte{presetRoot, lifecyclesRoot}, 1)
+}
@@ -0,0 +1,193 @@
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"runtime"
+	"sync"
+
+	ssz "github.com/ferranbt/fastssz"
+)
+
+// parallelHashThreshold is the number of leaves below which a list is
+// merkleized on the calling goroutine; smaller lists hash faster than the
+// goroutines cost.
+const parallelHashThreshold = 512
+
+// zeroHashes[i] is the root of a subtree of depth i whose leaves are zero.
+var zeroHashes [41][32]byte
+
+func init() {
+	for i := 1; i < len(zeroHashes); i++ {
+		zeroHashes[i] = hashPair(zeroHashes[i-1], zeroHashes[i-1])
+	}
+}
+
+// HashTreeRoot ssz hashes the State object. The list and bitlist fields are
+// hashed concurrently against the trees kept in the state's hash cache, so
+// only what changed since the last hash is rehashed; large rebuilds are
+// split across cores. The result is identical to hashing with
+// HashTreeRootWith.
+func (s *State) HashTreeRoot() ([32]byte, error) {
+	if size := len(s.HistoricalBlockHashes); size > HistoricalRootsLimit {
+		return [32]byte{}, ssz.ErrListTooBigFn("State.HistoricalBlockHashes", size, HistoricalRootsLimit)
+	}
+	if len(s.JustifiedSlots) == 0 {
+		return [32]byte{}, ssz.ErrEmptyBitlist
+	}
+	if len(s.Validators) > ValidatorRegistryLimit {
+		return [32]byte{}, ssz.ErrIncorrectListSize
+	}
+	if size := len(s.JustificationsRoots); size > HistoricalRootsLimit {
+		return [32]byte{}, ssz.ErrListTooBigFn("State.JustificationsRoots", size, HistoricalRootsLimit)
+	}
+	if len(s.JustificationsValidators) == 0 {
+		return [32]byte{}, ssz.ErrEmptyBitlist
+	}
+
+	c := s.sharedHashCache()
+
+	var fields [11][32]byte
+	var errs [11]error
+	var wg sync.WaitGroup
+	run := func(i int, f func() ([32]byte, error)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fields[i], errs[i] = f()
+		}()
+	}
+
+	run(5, func() ([32]byte, error) {
+		return c.historicalBlockHashes.use(func(t *merkleTree) ([32]byte, error) {
+			return t.listRoot(s.HistoricalBlockHashes, HistoricalRootsLimit), nil
+		})
+	})
+	run(6, func() ([32]byte, error) {
+		return c.justifiedSlots.use(func(t *merkleTree) ([32]byte, error) {
+			return t.bitlistRoot(s.JustifiedSlots, HistoricalRootsLimit)
+		})
+	})
+	run(7, func() ([32]byte, error) {
+		return c.validators.root(s.Validators)
+	})
+	run(8, func() ([32]byte, error) {
+		return c.justificationsRoots.use(func(t *merkleTree) ([32]byte, error) {
+			return t.listRoot(s.JustificationsRoots, HistoricalRootsLimit), nil
+		})
+	})
+	run(9, func() ([32]byte, error) {
+		return c.justificationsValidators.use(func(t *merkleTree) ([32]byte, error) {
+			return t.bitlistRoot(s.JustificationsValidators, JustificationValsLimit)
+		})
+	})
+
+	if s.Config == nil {
+		s.Config = new(Config)
+	}
+	if s.LatestBlockHeader == nil {
+		s.LatestBlockHeader = new(BlockHeader)
+	}
+	if s.LatestJustified == nil {
+		s.LatestJustified = new(Checkpoint)
+	}
+	if s.LatestFinalized == nil {
+		s.LatestFinalized = new(Checkpoint)
+	}
+	fields[0], errs[0] = s.Config.HashTreeRoot()
+	binary.LittleEndian.PutUint64(fields[1][:8], s.Slot)
+	fields[2], errs[2] = s.LatestBlockHeader.HashTreeRoot()
+	fields[3], errs[3] = s.LatestJustified.HashTreeRoot()
+	fields[4], errs[4] = s.LatestFinalized.HashTreeRoot()
+
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return [32]byte{}, err
+		}
+	}
+	n := 10
+	if ext := s.extension(); ext != nil {
+		fields[10] = ext.hashTreeRoot()
+		n++
+	}
+	return merkleize(fields[:n], 4), nil
+}
+
+// bitlistRoot hashes an SSZ bitlist with the given bit limit.
+func bitlistRoot(bits []byte, limit uint64) ([32]byte, error) {
+	hh := ssz.DefaultHasherPool.Get()
+	defer ssz.DefaultHasherPool.Put(hh)
+	hh.PutBitlist(bits, limit)
+	return hh.HashRoot()
+}
+
+// merkleize returns the root of a tree of the given depth whose first leaves
+// are chunks and the rest zero.
+func merkleize(chunks [][32]byte, depth uint8) [32]byte {
+	return merkleizeFrom(chunks, 0, depth)
+}
+
+// merkleizeFrom is merkleize for chunks that are roots of subtrees of depth
+// level, so missing chunks are padded with zero subtrees of that depth.
+func merkleizeFrom(chunks [][32]byte, level, depth uint8) [32]byte {
+	if len(chunks) == 0 {
+		return zeroHashes[depth]
+	}
+	layer := make([][32]byte, len(chunks))
+	copy(layer, chunks)
+	for d := level; d < depth; d++ {
+		next := layer[:(len(layer)+1)/2]
+		for i := range next {
+			right := zeroHashes[d]
+			if 2*i+1 < len(layer) {
+				right = layer[2*i+1]
+			}
+			next[i] = hashPair(layer[2*i], right)
+		}
+		layer = next
+	}
+	return layer[0]
+}
+
+func mixInLength(root [32]byte, length uint64) [32]byte {
+	var size [32]byte
+	binary.LittleEndian.PutUint64(size[:8], length)
+	return hashPair(root, size)
+}
+
+func hashPair(a, b [32]byte) [32]byte {
+	var buf [64]byte
+	copy(buf[:32], a[:])
+	copy(buf[32:], b[:])
+	return sha256.Sum256(buf[:])
+}
+
+// depthOf returns the depth of a tree with room for limit leaves.
+func depthOf(limit uint64) uint8 {
+	depth := uint8(0)
+	for uint64(1)<<depth < limit {
+		depth++
+	}
+	return depth
+}
+
+// forEachRange splits [0, n) into one contiguous range per core and calls f
+// on each concurrently. Small n runs on the calling goroutine.
+func forEachRange(n int, f func(lo, hi int)) {
+	workers := runtime.GOMAXPROCS(0)
+	if n < parallelHashThreshold || workers == 1 {
+		f(0, n)
+		return
+	}
+	per := (n + workers - 1) / workers
+	var wg sync.WaitGroup
+	for lo := 0; lo < n; lo += per {
+		wg.Add(1)
+		go func(lo, hi int) {
+			defer wg.Done()
+			f(lo, hi)
+		}(lo, min(lo+per, n))
+	}
+	wg.Wait()
+}
@@ -0,0 +1,236 @@
+package types
+
+import (
+	"math/bits"
+	"sync"
+)
+
+// stateHashCache keeps the merkle trees of a state's lists between hashes.
+// States derived from one another through Copy and ShallowCopy share one
+// cache. A hash diffs each list against the leaves the cache last saw and
+// rehashes only the paths above the leaves that changed, so hashing a state
+// a slot or a block on from the last one hashed costs little more than
+// comparing its lists. Diffing keeps the cache correct whichever state of
+// the lineage hashed last.
+//
+// Each list's tree has its own lock, and a hash that finds a tree in use
+// hashes that list from scratch rather than waiting, so states of one
+// lineage hashed concurrently, as by fork choice and the proposer, never
+// queue behind one another.
+type stateHashCache struct {
+	historicalBlockHashes    lockedTree
+	justifiedSlots           lockedTree
+	validators               validatorTree
+	justificationsRoots      lockedTree
+	justificationsValidators lockedTree
+}
+
+// hashCacheMu guards the lazy creation of State.hashCache.
+var hashCacheMu sync.Mutex
+
+// sharedHashCache returns the state's hash cache, creating it on first use.
+func (s *State) sharedHashCache() *stateHashCache {
+	hashCacheMu.Lock()
+	defer hashCacheMu.Unlock()
+	if s.hashCache == nil {
+		s.hashCache = new(stateHashCache)
+	}
+	return s.hashCache
+}
+
+// lockedTree is a cached merkle tree guarded by its own lock.
+type lockedTree struct {
+	mu   sync.Mutex
+	tree merkleTree
+}
+
+// use runs f on the cached tree, or on a scratch tree if another hash holds
+// the cached one.
+func (l *lockedTree) use(f func(t *merkleTree) ([32]byte, error)) ([32]byte, error) {
+	if !l.mu.TryLock() {
+		var scratch merkleTree
+		return f(&scratch)
+	}
+	defer l.mu.Unlock()
+	return f(&l.tree)
+}
+
+// validatorTree is the cached tree of the validator list along with the
+// validators behind its leaves.
+type validatorTree struct {
+	mu     sync.Mutex
+	tree   merkleTree
+	values []Validator
+}
+
+// root hashes the validator list, rehashing only the validators that differ
+// from the ones the cache last saw. If another hash holds the cache, the
+// list is hashed from scratch.
+func (c *validatorTree) root(vals []*Validator) ([32]byte, error) {
+	if !c.mu.TryLock() {
+		var scratch validatorTree
+		return scratch.rootLocked(vals)
+	}
+	defer c.mu.Unlock()
+	return c.rootLocked(vals)
+}
+
+func (c *validatorTree) rootLocked(vals []*Validator) ([32]byte, error) {
+	leaves := make([][32]byte, len(vals))
+	var changed []int
+	for i, v := range vals {
+		if i < len(c.values) && c.values[i] == *v {
+			leaves[i] = c.tree.layers[0][i]
+			continue
+		}
+		changed = append(changed, i)
+	}
+
+	var firstErr error
+	var errOnce sync.Once
+	forEachRange(len(changed), func(lo, hi int) {
+		for _, i := range changed[lo:hi] {
+			root, err := vals[i].HashTreeRoot()
+			if err != nil {
+				errOnce.Do(func() { firstErr = err })
+				return
+			}
+			leaves[i] = root
+		}
+	})
+	if firstErr != nil {
+		return [32]byte{}, firstErr
+	}
+
+	c.values = c.values[:0]
+	for _, v := range vals {
+		c.values = append(c.values, *v)
+	}
+	root := c.tree.root(leaves, depthOf(ValidatorRegistryLimit))
+	return mixInLength(root, uint64(len(vals))), nil
+}
+
+// listRoot returns the root of an SSZ list of 32-byte leaves with the given
+// limit.
+func (t *merkleTree) listRoot(leaves [][32]byte, limit uint64) [32]byte {
+	return mixInLength(t.root(leaves, depthOf(limit)), uint64(len(leaves)))
+}
+
+// bitlistRoot hashes an SSZ bitlist with the given bit limit. Bitlists the
+// hasher treats specially, with no sentinel or more chunks than the limit,
+// go to the uncached hasher so errors match it.
+func (t *merkleTree) bitlistRoot(bl []byte, limit uint64) ([32]byte, error) {
+	last := bl[len(bl)-1]
+	maxChunks := (limit + 255) / 256
+	if last == 0 || uint64(len(bl)+31)/32 > maxChunks {
+		t.layers = nil
+		return bitlistRoot(bl, limit)
+	}
+	msb := bits.Len8(last) - 1
+	size := 8*uint64(len(bl)-1) + uint64(msb)
+
+	chunks := make([][32]byte, (len(bl)+31)/32)
+	for i := range chunks {
+		copy(chunks[i][:], bl[i*32:])
+	}
+	chunks[(len(bl)-1)/32][(len(bl)-1)%32] &^= 1 << msb
+	return mixInLength(t.root(chunks, depthOf(maxChunks)), size), nil
+}
+
+// merkleTree is a binary merkle tree kept between hashes: layers[0] holds
+// the leaves and layers[d] the nodes d levels up, without the zero padding
+// to the right of the last leaf.
+type merkleTree struct {
+	layers [][][32]byte
+}
+
+// root returns the root of the tree of the given depth over leaves, zero
+// padded, updating the cached tree to it. Only nodes above leaves that
+// differ from the cached ones are rehashed.
+func (t *merkleTree) root(leaves [][32]byte, depth uint8) [32]byte {
+	if len(leaves) == 0 {
+		t.layers = nil
+		return zeroHashes[depth]
+	}
+	if len(t.layers) != int(depth)+1 {
+		t.layers = make([][][32]byte, depth+1)
+	}
+
+	old := t.layers[0]
+	var dirty []int
+	for i := range leaves {
+		if i >= len(old) || old[i] != leaves[i] {
+			dirty = append(dirty, i)
+		}
+	}
+	if i := len(leaves) - 1; len(leaves) < len(old) && (len(dirty) == 0 || dirty[len(dirty)-1] != i) {
+		// The last leaf lost its right sibling.
+		dirty = append(dirty, i)
+	}
+	t.layers[0] = append(old[:0], leaves...)
+
+	if len(leaves) >= parallelHashThreshold && 2*len(dirty) > len(leaves) {
+		t.rebuild(depth)
+		return t.layers[depth][0]
+	}
+
+	for d := uint8(0); d < depth; d++ {
+		layer := t.layers[d]
+		oldLen := len(t.layers[d+1])
+		next := resize(t.layers[d+1], (len(layer)+1)/2)
+		if len(dirty) == 0 && len(next) == oldLen {
+			break
+		}
+		// Parents are written at or before the dirty index being read, so
+		// dirty is rewritten in place.
+		up := dirty[:0]
+		for _, i := range dirty {
+			p := i / 2
+			if len(up) > 0 && up[len(up)-1] == p {
+				continue
+			}
+			next[p] = hashPair(layer[2*p], sibling(layer, 2*p+1, d))
+			up = append(up, p)
+		}
+		if p := len(next) - 1; len(next) < oldLen && (len(up) == 0 || up[len(up)-1] != p) {
+			// The last node lost its right sibling.
+			next[p] = hashPair(layer[2*p], sibling(layer, 2*p+1, d))
+			up = append(up, p)
+		}
+		t.layers[d+1] = next
+		dirty = up
+	}
+	return t.layers[depth][0]
+}
+
+// rebuild recomputes every layer above the leaves, splitting large layers
+// across cores.
+func (t *merkleTree) rebuild(depth uint8) {
+	for d := uint8(0); d < depth; d++ {
+		layer := t.layers[d]
+		next := resize(t.layers[d+1], (len(layer)+1)/2)
+		forEachRange(len(next), func(lo, hi int) {
+			for p := lo; p < hi; p++ {
+				next[p] = hashPair(layer[2*p], sibling(layer, 2*p+1, d))
+			}
+		})
+		t.layers[d+1] = next
+	}
+}
+
+// sibling returns layer[i], or the zero subtree of depth d past its end.
+func sibling(layer [][32]byte, i int, d uint8) [32]byte {
+	if i < len(layer) {
+		return layer[i]
+	}
+	return zeroHashes[d]
+}
+
+func resize(layer [][32]byte, n int) [][32]byte {
+	if cap(layer) >= n {
+		return layer[:n]
+	}
+	out := make([][32]byte, n, n+n/4)
+	copy(out, layer)
+	return out
+}
@@ -0,0 +1,186 @@
+package types
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	ssz "github.com/ferranbt/fastssz"
+)
+
+func testState(numValidators, history int) *State {
+	s := &State{
+		Config:                   &Config{GenesisTime: 1000},
+		Slot:                     uint64(history),
+		LatestBlockHeader:        &BlockHeader{Slot: uint64(history)},
+		LatestJustified:          &Checkpoint{Slot: 1},
+		LatestFinalized:          &Checkpoint{},
+		JustifiedSlots:           []byte{0x05},
+		JustificationsValidators: []byte{0x01},
+	}
+	for i := 0; i < numValidators; i++ {
+		v := &Validator{Index: uint64(i)}
+		v.Pubkey[0], v.Pubkey[1] = byte(i), byte(i>>8)
+		s.Validators = append(s.Validators, v)
+	}
+	for i := 0; i < history; i++ {
+		var h [32]byte
+		h[0], h[1], h[2] = byte(i), byte(i>>8), byte(i>>16)
+		s.HistoricalBlockHashes = append(s.HistoricalBlockHashes, h)
+	}
+	s.JustificationsRoots = s.HistoricalBlockHashes[:history/2]
+	return s
+}
+
+// serialRoot hashes s with the sszgen-style walker.
+func serialRoot(t testing.TB, s *State) [32]byte {
+	hh := ssz.NewHasher()
+	if err := s.HashTreeRootWith(hh); err != nil {
+		t.Fatal(err)
+	}
+	root, err := hh.HashRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func TestStateHashTreeRootMatchesSerial(t *testing.T) {
+	for _, tc := range []struct{ validators, history int }{
+		{0, 0}, {1, 1}, {5, 3}, {600, 700}, {ValidatorRegistryLimit, 5000},
+	} {
+		t.Run(fmt.Sprintf("%dv_%dh", tc.validators, tc.history), func(t *testing.T) {
+			s := testState(tc.validators, tc.history)
+			got, err := s.HashTreeRoot()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := serialRoot(t, s); got != want {
+				t.Fatalf("root %x, want %x", got, want)
+			}
+		})
+	}
+}
+
+func TestStateHashTreeRootCachedMatchesSerial(t *testing.T) {
+	check := func(s *State) {
+		t.Helper()
+		got, err := s.HashTreeRoot()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := serialRoot(t, s); got != want {
+			t.Fatalf("cached root %x, want %x", got, want)
+		}
+	}
+
+	base := testState(700, 1500)
+	check(base)
+
+	// Each step derives a new state sharing the cache, as the state
+	// transition does, and changes the lists the way blocks can.
+	grow := base.ShallowCopy()
+	grow.HistoricalBlockHashes = append(grow.HistoricalBlockHashes, [32]byte{1}, [32]byte{2})
+	grow.JustifiedSlots = append(grow.JustifiedSlots[:len(grow.JustifiedSlots):len(grow.JustifiedSlots)], 0xff, 0x03)
+	check(grow)
+
+	edit := grow.ShallowCopy()
+	edit.Validators = append([]*Validator(nil), edit.Validators...)
+	edit.Validators[3] = &Validator{Index: 3, Pubkey: [52]byte{9}}
+	edit.JustificationsRoots = append([][32]byte{{7}}, edit.JustificationsRoots[1:]...)
+	edit.JustificationsValidators = make([]byte, 100)
+	edit.JustificationsValidators[99] = 0x10
+	check(edit)
+
+	shrink := edit.ShallowCopy()
+	shrink.HistoricalBlockHashes = shrink.HistoricalBlockHashes[:513]
+	shrink.Validators = shrink.Validators[:5]
+	shrink.JustificationsRoots = nil
+	shrink.JustificationsValidators = []byte{0x01}
+	check(shrink)
+
+	// Alternating between forks of the lineage stays correct.
+	for _, s := range []*State{base, shrink, grow, edit, base} {
+		check(s)
+	}
+}
+
+// States of one lineage hashed at the same time share the cache without
+// waiting on each other; whichever loses the race for a tree hashes from
+// scratch, and every root must still be right.
+func TestStateHashTreeRootConcurrentLineage(t *testing.T) {
+	base := testState(700, 1500)
+	states := []*State{base}
+	for i := 0; i < 8; i++ {
+		next := base.ShallowCopy()
+		next.HistoricalBlockHashes = append(next.HistoricalBlockHashes, [32]byte{byte(i)})
+		next.Validators = append([]*Validator(nil), next.Validators...)
+		next.Validators[i] = &Validator{Index: uint64(i), Pubkey: [52]byte{byte(i), 1}}
+		states = append(states, next)
+	}
+	want := make([][32]byte, len(states))
+	for i, s := range states {
+		want[i] = serialRoot(t, s)
+	}
+
+	errs := make(chan error, len(states)*4)
+	var wg sync.WaitGroup
+	for round := 0; round < 4; round++ {
+		for i, s := range states {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				got, err := s.HashTreeRoot()
+				if err == nil && got != want[i] {
+					err = fmt.Errorf("state %d: root %x, want %x", i, got, want[i])
+				}
+				if err != nil {
+					errs <- err
+				}
+			}()
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestStateHashTreeRootRejectsOversizedRegistry(t *testing.T) {
+	s := testState(ValidatorRegistryLimit+1, 1)
+	if _, err := s.HashTreeRoot(); err == nil {
+		t.Fatal("hashed a registry over the limit")
+	}
+}
+
+// The validator registry is capped at ValidatorRegistryLimit (4096), so the
+// largest benchmark uses a full registry.
+func BenchmarkStateHashTreeRoot(b *testing.B) {
+	for _, n := range []int{1000, ValidatorRegistryLimit} {
+		s := testState(n, 1000)
+		b.Run(fmt.Sprintf("validators=%d/cold", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				s.hashCache = nil
+				if _, err := s.HashTreeRoot(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("validators=%d/next_block", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				next := s.ShallowCopy()
+				next.HistoricalBlockHashes = append(next.HistoricalBlockHashes, [32]byte{byte(i)})
+				next.LatestBlockHeader = &BlockHeader{Slot: uint64(i)}
+				if _, err := next.HashTreeRoot(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("validators=%d/serial", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				serialRoot(b, s)
+			}
+		})
+	}
+}
@@ -1,76 +1,13 @@
-// Code generated by fastssz. DO NOT EDIT.
-// Hash: 6b1f21194a6dd7d468868013c6f418717ff9b1a46497537e2f7ab040fdd6217d
-// Version: 0.1.3
 package types
 
 import (
 	ssz "github.com/ferranbt/fastssz"
 )
 
-// MarshalSSZ ssz marshals the Validator object
-func (v *Validator) MarshalSSZ() ([]byte, error) {
-	return ssz.MarshalSSZ(v)
-}
-
-// MarshalSSZTo ssz marshals the Validator object to a target array
-func (v *Validator) MarshalSSZTo(buf []byte) (dst []byte, err error) {
-	dst = buf
-
-	// Field (0) 'Pubkey'
-	dst = append(dst, v.Pubkey[:]...)
-
-	// Field (1) 'Index'
-	dst = ssz.MarshalUint64(dst, v.Index)
-
-	return
-}
-
-// UnmarshalSSZ ssz unmarshals the Validator object
-func (v *Validator) UnmarshalSSZ(buf []byte) error {
-	var err error
-	size := uint64(len(buf))
-	if size != 60 {
-		return ssz.ErrSize
-	}
-
-	// Field (0) 'Pubkey'
-	copy(v.Pubkey[:], buf[0:52])
-
-	// Field (1) 'Index'
-	v.Index = ssz.UnmarshallUint64(buf[52:60])
-
-	return err
-}
-
-// SizeSSZ returns the ssz encoded size in bytes for the Validator object
-func (v *Validator) SizeSSZ() (size int) {
-	size = 60
-	return
-}
-
-// HashTreeRoot ssz hashes the Validator object
-func (v *Validator) HashTreeRoot() ([32]byte, error) {
-	return ssz.HashWithDefaultHasher(v)
-}
-
-// HashTreeRootWith ssz hashes the Validator object with a hasher
-func (v *Validator) HashTreeRootWith(hh ssz.HashWalker) (err error) {
-	indx := hh.Index()
-
-	// Field (0) 'Pubkey'
-	hh.PutBytes(v.Pubkey[:])
-
-	// Field (1) 'Index'
-	hh.PutUint64(v.Index)
-
-	hh.Merkleize(indx)
-	return
-}
-
-// GetTree ssz hashes the Validator object
-func (v *Validator) GetTree() (*ssz.Node, error) {
-	return ssz.ProofTree(v)
-}
+// State is excluded from sszgen (see generate.go) so that its HashTreeRoot
+// can hash field subtrees concurrently; see state_hash.go. The methods below
+// are the sszgen output for State, plus the extension field of
+// state_extension.go, and must be kept in sync with its fields.
 
 // MarshalSSZ ssz marshals the State object
 func (s *State) MarshalSSZ() ([]byte, error) {
@@ -80,7 +17,11 @@ func (s *State) MarshalSSZ() ([]byte, error) {
 // MarshalSSZTo ssz marshals the State object to a target array
 func (s *State) MarshalSSZTo(buf []byte) (dst []byte, err error) {
 	dst = buf
-	offset := int(228)
+	offset := int(stateFixedSize)
+	ext := s.extension()
+	if ext != nil {
+		offset = stateExtendedFixedSize
+	}
 
 	// Field (0) 'Config'
 	if s.Config == nil {
@@ -127,7 +68,7 @@ func (s *State) MarshalSSZTo(buf []byte) (dst []byte, err error) {
 
 	// Offset (7) 'Validators'
 	dst = ssz.WriteOffset(dst, offset)
-	offset += len(s.Validators) * 60
+	offset += len(s.Validators) * validatorSize
 
 	// Offset (8) 'JustificationsRoots'
 	dst = ssz.WriteOffset(dst, offset)
@@ -135,6 +76,12 @@ func (s *State) MarshalSSZTo(buf []byte) (dst []byte, err error) {
 
 	// Offset (9) 'JustificationsValidators'
 	dst = ssz.WriteOffset(dst, offset)
+	offset += len(s.JustificationsValidators)
+
+	// Offset (10) 'Extension'
+	if ext != nil {
+		dst = ssz.WriteOffset(dst, offset)
+	}
 
 	// Field (5) 'HistoricalBlockHashes'
 	if size := len(s.HistoricalBlockHashes); size > 262144 {
@@ -179,6 +126,11 @@ func (s *State) MarshalSSZTo(buf []byte) (dst []byte, err error) {
 	}
 	dst = append(dst, s.JustificationsValidators...)
 
+	// Field (10) 'Extension'
+	if ext != nil {
+		dst = ext.marshalTo(dst)
+	}
+
 	return
 }
 
@@ -186,12 +138,12 @@ func (s *State) MarshalSSZTo(buf []byte) (dst []byte, err error) {
 func (s *State) UnmarshalSSZ(buf []byte) error {
 	var err error
 	size := uint64(len(buf))
-	if size < 228 {
+	if size < stateFixedSize {
 		return ssz.ErrSize
 	}
 
 	tail := buf
-	var o5, o6, o7, o8, o9 uint64
+	var o5, o6, o7, o8, o9, o10 uint64
 
 	// Field (0) 'Config'
 	if s.Config == nil {
@@ -200,6 +152,7 @@ func (s *State) UnmarshalSSZ(buf []byte) error {
 	if err = s.Config.UnmarshalSSZ(buf[0:8]); err != nil {
 		return err
 	}
+	s.Config.Overrides = Preset{}
 
 	// Field (1) 'Slot'
 	s.Slot = ssz.UnmarshallUint64(buf[8:16])
@@ -233,9 +186,10 @@ func (s *State) UnmarshalSSZ(buf []byte) error {
 		return ssz.ErrOffset
 	}
 
-	if o5 != 228 {
+	if o5 != stateFixedSize && o5 != stateExtendedFixedSize {
 		return ssz.ErrInvalidVariableOffset
 	}
+	o10 = size
 
 	// Offset (6) 'JustifiedSlots'
 	if o6 = ssz.ReadOffset(buf[212:216]); o6 > size || o5 > o6 {
@@ -257,6 +211,13 @@ func (s *State) UnmarshalSSZ(buf []byte) error {
 		return ssz.ErrOffset
 	}
 
+	// Offset (10) 'Extension'
+	if o5 == stateExtendedFixedSize {
+		if o10 = ssz.ReadOffset(buf[228:232]); o10 > size || o9 > o10 {
+			return ssz.ErrOffset
+		}
+	}
+
 	// Field (5) 'HistoricalBlockHashes'
 	{
 		buf = tail[o5:o6]
@@ -285,7 +246,7 @@ func (s *State) UnmarshalSSZ(buf []byte) error {
 	// Field (7) 'Validators'
 	{
 		buf = tail[o7:o8]
-		num, err := ssz.DivideInt2(len(buf), 60, 4096)
+		num, err := ssz.DivideInt2(len(buf), validatorSize, 4096)
 		if err != nil {
 			return err
 		}
@@ -294,7 +255,7 @@ func (s *State) UnmarshalSSZ(buf []byte) error {
 			if s.Validators[ii] == nil {
 				s.Validators[ii] = new(Validator)
 			}
-			if err = s.Validators[ii].UnmarshalSSZ(buf[ii*60 : (ii+1)*60]); err != nil {
+			if err = s.Validators[ii].UnmarshalSSZ(buf[ii*validatorSize : (ii+1)*validatorSize]); err != nil {
 				return err
 			}
 		}
@@ -315,7 +276,7 @@ func (s *State) UnmarshalSSZ(buf []byte) error {
 
 	// Field (9) 'JustificationsValidators'
 	{
-		buf = tail[o9:]
+		buf = tail[o9:o10]
 		if err = ssz.ValidateBitlist(buf, 1073741824); err != nil {
 			return err
 		}
@@ -324,12 +285,27 @@ func (s *State) UnmarshalSSZ(buf []byte) error {
 		}
 		s.JustificationsValidators = append(s.JustificationsValidators, buf...)
 	}
+
+	// Field (10) 'Extension'
+	if o5 == stateExtendedFixedSize {
+		var ext stateExtension
+		if err = ext.unmarshal(tail[o10:]); err != nil {
+			return err
+		}
+		if err = ext.apply(s); err != nil {
+			return err
+		}
+	}
 	return err
 }
 
 // SizeSSZ returns the ssz encoded size in bytes for the State object
 func (s *State) SizeSSZ() (size int) {
-	size = 228
+	size = stateFixedSize
+	ext := s.extension()
+	if ext != nil {
+		size = stateExtendedFixedSize + ext.sizeSSZ()
+	}
 
 	// Field (5) 'HistoricalBlockHashes'
 	size += len(s.HistoricalBlockHashes) * 32
@@ -338,7 +314,7 @@ func (s *State) SizeSSZ() (size int) {
 	size += len(s.JustifiedSlots)
 
 	// Field (7) 'Validators'
-	size += len(s.Validators) * 60
+	size += len(s.Validators) * validatorSize
 
 	// Field (8) 'JustificationsRoots'
 	size += len(s.JustificationsRoots) * 32
@@ -349,11 +325,6 @@ func (s *State) SizeSSZ() (size int) {
 	return
 }
 
-// HashTreeRoot ssz hashes the State object
-func (s *State) HashTreeRoot() ([32]byte, error) {
-	return ssz.HashWithDefaultHasher(s)
-}
-
 // HashTreeRootWith ssz hashes the State object with a hasher
 func (s *State) HashTreeRootWith(hh ssz.HashWalker) (err error) {
 	indx := hh.Index()
@@ -451,6 +422,12 @@ func (s *State) HashTreeRootWith(hh ssz.HashWalker) (err error) {
 	}
 	hh.PutBitlist(s.JustificationsValidators, 1073741824)
 
+	// Field (10) 'Extension'
+	if ext := s.extension(); ext != nil {
+		root := ext.hashTreeRoot()
+		hh.PutBytes(root[:])
+	}
+
 	hh.Merkleize(indx)
 	return
 }
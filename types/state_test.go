@@ -0,0 +1,136 @@
+package types
+
+import (
+	"testing"
+
+	ssz "github.com/ferranbt/fastssz"
+)
+
+func TestShallowCopyAppendsDoNotAlias(t *testing.T) {
+	hashes := make([][32]byte, 1, 8)
+	s := &State{
+		LatestBlockHeader:     &BlockHeader{Slot: 1},
+		LatestJustified:       &Checkpoint{},
+		LatestFinalized:       &Checkpoint{},
+		HistoricalBlockHashes: hashes,
+	}
+
+	// Two forks built on the same parent each append their own root.
+	a, b := s.ShallowCopy(), s.ShallowCopy()
+	a.HistoricalBlockHashes = append(a.HistoricalBlockHashes, [32]byte{0xa})
+	b.HistoricalBlockHashes = append(b.HistoricalBlockHashes, [32]byte{0xb})
+	if a.HistoricalBlockHashes[1] != [32]byte{0xa} || b.HistoricalBlockHashes[1] != [32]byte{0xb} {
+		t.Fatalf("appends on sibling copies overwrote each other")
+	}
+	if len(s.HistoricalBlockHashes) != 1 {
+		t.Fatalf("parent hashes grew to %d", len(s.HistoricalBlockHashes))
+	}
+
+	a.LatestBlockHeader.StateRoot = [32]byte{1}
+	a.LatestJustified.Slot = 5
+	if s.LatestBlockHeader.StateRoot != ZeroHash || s.LatestJustified.Slot != 0 {
+		t.Fatal("copy shares the header or checkpoints with the parent")
+	}
+}
+
+func TestStateExtensionOnlyWithOverrides(t *testing.T) {
+	spec := testState(3, 4)
+	specBytes, err := spec.MarshalSSZ()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ssz.ReadOffset(specBytes[208:212]) != stateFixedSize {
+		t.Fatal("state without overrides is not encoded as the spec state")
+	}
+
+	devnet := testState(3, 4)
+	devnet.Config = NewConfig(1000, Preset{SecondsPerSlot: 8, JustificationLookback: 3, ProposerSelection: ProposerShuffled, ProposerSeed: [32]byte{5}})
+	if devnet.Config.Overrides.JustificationLookback != 0 {
+		t.Fatal("spec lookback recorded as an override")
+	}
+	data, err := devnet.MarshalSSZ()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != devnet.SizeSSZ() {
+		t.Fatalf("encoded %d bytes, SizeSSZ %d", len(data), devnet.SizeSSZ())
+	}
+	got := new(State)
+	if err := got.UnmarshalSSZ(data); err != nil {
+		t.Fatal(err)
+	}
+	if *got.Config != *devnet.Config {
+		t.Fatalf("config %+v, want %+v", got.Config, devnet.Config)
+	}
+	if p := got.Config.Preset(); p.SecondsPerSlot != 8 || p.JustificationLookback != 3 || p.ProposerSelection != ProposerShuffled {
+		t.Fatalf("preset %+v", p)
+	}
+
+	root, err := devnet.HashTreeRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := serialRoot(t, devnet); root != want {
+		t.Fatalf("root %x, want %x", root, want)
+	}
+	if specRoot, _ := spec.HashTreeRoot(); specRoot == root {
+		t.Fatal("overrides do not change the state root")
+	}
+
+	// An extension without overrides is not a canonical encoding.
+	zeroed := append([]byte(nil), data...)
+	clear(zeroed[len(zeroed)-extensionFixedSize : len(zeroed)-4])
+	if err := new(State).UnmarshalSSZ(zeroed); err == nil {
+		t.Fatal("decoded an empty extension")
+	}
+}
+
+func TestStateExtensionCarriesLifecycles(t *testing.T) {
+	s := testState(3, 4)
+	s.Config = NewConfig(1000, Preset{DynamicValidators: true})
+	s.Validators[1].ExitEpoch = 5
+	s.Validators[2].Slashed = true
+	data, err := s.MarshalSSZ()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != s.SizeSSZ() {
+		t.Fatalf("encoded %d bytes, SizeSSZ %d", len(data), s.SizeSSZ())
+	}
+	got := new(State)
+	if err := got.UnmarshalSSZ(data); err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range got.Validators {
+		if *v != *s.Validators[i] {
+			t.Fatalf("validator %d decoded as %+v, want %+v", i, v, s.Validators[i])
+		}
+	}
+	root, err := s.HashTreeRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := serialRoot(t, s); root != want {
+		t.Fatalf("root %x, want %x", root, want)
+	}
+
+	// The spec validator container ignores the lifecycle.
+	if r1, _ := s.Validators[1].HashTreeRoot(); r1 != mustRoot(t, &Validator{Pubkey: s.Validators[1].Pubkey, Index: 1}) {
+		t.Fatal("lifecycle changes the spec validator root")
+	}
+
+	// A lifecycle list must cover the registry.
+	short := append([]byte(nil), data[:len(data)-lifecycleSize]...)
+	if err := new(State).UnmarshalSSZ(short); err == nil {
+		t.Fatal("decoded lifecycles for fewer validators than the registry")
+	}
+}
+
+func mustRoot(t *testing.T, v *Validator) [32]byte {
+	t.Helper()
+	r, err := v.HashTreeRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
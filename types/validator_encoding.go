@@ -0,0 +1,78 @@
+package types
+
+import (
+	ssz "github.com/ferranbt/fastssz"
+)
+
+// Validator is excluded from sszgen (see generate.go): it encodes as the
+// reference spec Validator, Pubkey and Index only. The lifecycle fields are
+// encoded with the state; see state_extension.go. The methods below are the
+// sszgen output for the spec Validator.
+
+// validatorSize is the size of an encoded Validator.
+const validatorSize = 60
+
+// MarshalSSZ ssz marshals the Validator object
+func (v *Validator) MarshalSSZ() ([]byte, error) {
+	return ssz.MarshalSSZ(v)
+}
+
+// MarshalSSZTo ssz marshals the Validator object to a target array
+func (v *Validator) MarshalSSZTo(buf []byte) (dst []byte, err error) {
+	dst = buf
+
+	// Field (0) 'Pubkey'
+	dst = append(dst, v.Pubkey[:]...)
+
+	// Field (1) 'Index'
+	dst = ssz.MarshalUint64(dst, v.Index)
+
+	return
+}
+
+// UnmarshalSSZ ssz unmarshals the Validator object
+func (v *Validator) UnmarshalSSZ(buf []byte) error {
+	var err error
+	size := uint64(len(buf))
+	if size != validatorSize {
+		return ssz.ErrSize
+	}
+
+	// Field (0) 'Pubkey'
+	copy(v.Pubkey[:], buf[0:52])
+
+	// Field (1) 'Index'
+	v.Index = ssz.UnmarshallUint64(buf[52:60])
+
+	return err
+}
+
+// SizeSSZ returns the ssz encoded size in bytes for the Validator object
+func (v *Validator) SizeSSZ() (size int) {
+	size = validatorSize
+	return
+}
+
+// HashTreeRoot ssz hashes the Validator object
+func (v *Validator) HashTreeRoot() ([32]byte, error) {
+	return ssz.HashWithDefaultHasher(v)
+}
+
+// HashTreeRootWith ssz hashes the Validator object with a hasher
+func (v *Validator) HashTreeRootWith(hh ssz.HashWalker) (err error) {
+	indx := hh.Index()
+
+	// Field (0) 'Pubkey'
+	hh.PutBytes(v.Pubkey[:])
+
+	// Field (1) 'Index'
+	hh.PutUint64(v.Index)
+
+	hh.Merkleize(indx)
+	return
+}
+
+// GetTree ssz hashes the Validator object
+func (v *Validator) GetTree() (*ssz.Node, error) {
+	return ssz.ProofTree(v)
+}
@@ -162,6 +162,48 @@ enum LeansigResult leansig_verify(const uint8_t *pk_data,
                                   const uint8_t *sig_data,
                                   size_t sig_len);
 
+// Verify `count` signatures in one call, spread over the available cores.
+//
+// Public keys and signatures are passed concatenated: the i-th public key is
+// the next `pk_lens[i]` bytes of `pk_data`, and likewise for signatures.
+// The i-th message is the 32 bytes at `messages + 32 * i`.
+//
+// # Arguments
+// * `count` - Number of signatures.
+// * `pk_data` - Concatenated SSZ-serialized public keys.
+// * `pk_lens` - Length of each public key.
+// * `epochs` - Epoch of each signature.
+// * `messages` - Concatenated 32-byte messages.
+// * `sig_data` - Concatenated SSZ-serialized signatures.
+// * `sig_lens` - Length of each signature.
+// * `out_results` - Receives the result of each verification, as
+//   `leansig_verify` would return it.
+//
+// # Returns
+// `LeansigResult::Ok` once every result is written, whether or not the
+// signatures are valid, or `NullPointer` if an argument is null.
+enum LeansigResult leansig_verify_batch(size_t count,
+                                        const uint8_t *pk_data,
+                                        const size_t *pk_lens,
+                                        const uint32_t *epochs,
+                                        const uint8_t *messages,
+                                        const uint8_t *sig_data,
+                                        const size_t *sig_lens,
+                                        enum LeansigResult *out_results);
+
+// Size in bytes of an SSZ-serialized signature.
+size_t leansig_signature_size(void);
+
+// Size in bytes of an SSZ-serialized public key.
+size_t leansig_pubkey_size(void);
+
+// Check that bytes decode as a signature, without verifying it.
+//
+// # Returns
+// `LeansigResult::Ok` if the signature decodes, `InvalidLength` if it is not
+// `leansig_signature_size()` bytes, `DeserializationFailed` otherwise.
+enum LeansigResult leansig_signature_validate(const uint8_t *sig_data, size_t sig_len);
+
 // Verify a signature using the public key from a keypair handle.
 //
 // Convenience wrapper that avoids serialization/deserialization of the public key.
@@ -367,6 +367,11 @@ pub unsafe extern "C" fn leansig_verify(
     let sig_bytes = unsafe { slice::from_raw_parts(sig_data, sig_len) };
     let msg: &[u8; 32] = unsafe { &*(message as *const [u8; 32]) };
 
+    verify_bytes(pk_bytes, epoch, msg, sig_bytes)
+}
+
+/// Deserialize a public key and signature and verify the signature.
+fn verify_bytes(pk_bytes: &[u8], epoch: u32, msg: &[u8; 32], sig_bytes: &[u8]) -> LeansigResult {
     let pk = match PublicKey::from_bytes(pk_bytes) {
         Ok(pk) => pk,
         Err(_) => return LeansigResult::DeserializationFailed,
@@ -384,6 +389,129 @@ pub unsafe extern "C" fn leansig_verify(
     }
 }
 
+// ---------------------------------------------------------------------------
+// Batch verification
+// ---------------------------------------------------------------------------
+
+/// Verify `count` signatures in one call, spread over the available cores.
+///
+/// Public keys and signatures are passed concatenated: the i-th public key is
+/// the next `pk_lens[i]` bytes of `pk_data`, and likewise for signatures.
+/// The i-th message is the 32 bytes at `messages + 32 * i`.
+///
+/// # Arguments
+/// * `count` - Number of signatures.
+/// * `pk_data` - Concatenated SSZ-serialized public keys.
+/// * `pk_lens` - Length of each public key.
+/// * `epochs` - Epoch of each signature.
+/// * `messages` - Concatenated 32-byte messages.
+/// * `sig_data` - Concatenated SSZ-serialized signatures.
+/// * `sig_lens` - Length of each signature.
+/// * `out_results` - Receives the result of each verification, as
+///   `leansig_verify` would return it.
+///
+/// # Returns
+/// `LeansigResult::Ok` once every result is written, whether or not the
+/// signatures are valid, or `NullPointer` if an argument is null.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn leansig_verify_batch(
+    count: usize,
+    pk_data: *const u8,
+    pk_lens: *const usize,
+    epochs: *const u32,
+    messages: *const u8,
+    sig_data: *const u8,
+    sig_lens: *const usize,
+    out_results: *mut LeansigResult,
+) -> LeansigResult {
+    if count == 0 {
+        return LeansigResult::Ok;
+    }
+    if pk_data.is_null()
+        || pk_lens.is_null()
+        || epochs.is_null()
+        || messages.is_null()
+        || sig_data.is_null()
+        || sig_lens.is_null()
+        || out_results.is_null()
+    {
+        return LeansigResult::NullPointer;
+    }
+
+    let pk_lens = unsafe { slice::from_raw_parts(pk_lens, count) };
+    let sig_lens = unsafe { slice::from_raw_parts(sig_lens, count) };
+    let epochs = unsafe { slice::from_raw_parts(epochs, count) };
+    let messages = unsafe { slice::from_raw_parts(messages as *const [u8; 32], count) };
+    let pk_data = unsafe { slice::from_raw_parts(pk_data, pk_lens.iter().sum()) };
+    let sig_data = unsafe { slice::from_raw_parts(sig_data, sig_lens.iter().sum()) };
+    let results = unsafe { slice::from_raw_parts_mut(out_results, count) };
+
+    let mut items = Vec::with_capacity(count);
+    let (mut pk_off, mut sig_off) = (0, 0);
+    for i in 0..count {
+        let pk = &pk_data[pk_off..pk_off + pk_lens[i]];
+        let sig = &sig_data[sig_off..sig_off + sig_lens[i]];
+        items.push((pk, epochs[i], &messages[i], sig));
+        pk_off += pk_lens[i];
+        sig_off += sig_lens[i];
+    }
+
+    let threads = std::thread::available_parallelism().map_or(1, |n| n.get()).min(count);
+    let chunk = count.div_ceil(threads);
+    std::thread::scope(|scope| {
+        for (items, results) in items.chunks(chunk).zip(results.chunks_mut(chunk)) {
+            scope.spawn(move || {
+                for ((pk, epoch, msg, sig), result) in items.iter().zip(results.iter_mut()) {
+                    *result = verify_bytes(pk, *epoch, msg, sig);
+                }
+            });
+        }
+    });
+    LeansigResult::Ok
+}
+
+// ---------------------------------------------------------------------------
+// Encoding sizes and validation
+// ---------------------------------------------------------------------------
+
+/// SSZ-serialized size of a devnet-1 signature.
+const SIGNATURE_SIZE: usize = 3112;
+
+/// SSZ-serialized size of a devnet-1 public key.
+const PUBLIC_KEY_SIZE: usize = 52;
+
+/// Size in bytes of an SSZ-serialized signature.
+#[unsafe(no_mangle)]
+pub extern "C" fn leansig_signature_size() -> usize {
+    SIGNATURE_SIZE
+}
+
+/// Size in bytes of an SSZ-serialized public key.
+#[unsafe(no_mangle)]
+pub extern "C" fn leansig_pubkey_size() -> usize {
+    PUBLIC_KEY_SIZE
+}
+
+/// Check that bytes decode as a signature, without verifying it.
+///
+/// # Returns
+/// `LeansigResult::Ok` if the signature decodes, `InvalidLength` if it is not
+/// `leansig_signature_size()` bytes, `DeserializationFailed` otherwise.
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn leansig_signature_validate(sig_data: *const u8, sig_len: usize) -> LeansigResult {
+    if sig_data.is_null() {
+        return LeansigResult::NullPointer;
+    }
+    if sig_len != SIGNATURE_SIZE {
+        return LeansigResult::InvalidLength;
+    }
+    let sig_bytes = unsafe { slice::from_raw_parts(sig_data, sig_len) };
+    match Signature::from_bytes(sig_bytes) {
+        Ok(_) => LeansigResult::Ok,
+        Err(_) => LeansigResult::DeserializationFailed,
+    }
+}
+
 // ---------------------------------------------------------------------------
 // Verify using keypair (convenience for testing)
 // ---------------------------------------------------------------------------
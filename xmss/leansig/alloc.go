@@ -0,0 +1,115 @@
+package leansig
+
+import (
+	"log/slog"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/geanlabs/gean/observability/logging"
+	"github.com/geanlabs/gean/observability/metrics"
+)
+
+// Allocation accounting. Every keypair handle and every byte buffer the
+// library returns is counted until it is freed, so a missed Free or
+// leansig_bytes_free shows up in the lean_leansig_* metrics. With
+// TrackAllocations on, the stack that allocated each keypair is kept too,
+// to find the caller that forgot to free it.
+
+var (
+	liveKeypairs  atomic.Int64
+	liveBuffers   atomic.Int64
+	leakedKeypair atomic.Uint64
+
+	tracking atomic.Bool
+	stacksMu sync.Mutex
+	stacks   = make(map[uintptr]allocation) // by handle address
+	allocSeq uint64
+
+	leakLog = sync.OnceValue(func() *slog.Logger { return logging.NewComponentLogger(logging.CompCrypto) })
+)
+
+type allocation struct {
+	seq   uint64
+	stack string
+}
+
+// AllocStats is a snapshot of the allocation accounting.
+type AllocStats struct {
+	Keypairs       int64  // keypair handles not yet freed
+	Buffers        int64  // byte buffers not yet freed
+	LeakedKeypairs uint64 // handles released by a finalizer instead of Free
+}
+
+// Allocations returns the current allocation counts.
+func Allocations() AllocStats {
+	return AllocStats{
+		Keypairs:       liveKeypairs.Load(),
+		Buffers:        liveBuffers.Load(),
+		LeakedKeypairs: leakedKeypair.Load(),
+	}
+}
+
+// TrackAllocations turns recording of keypair allocation stacks on or off.
+// Recording captures a stack per keypair, so it is meant for debugging.
+// Keypairs allocated while it is off have no stack.
+func TrackAllocations(on bool) {
+	tracking.Store(on)
+	if !on {
+		stacksMu.Lock()
+		clear(stacks)
+		stacksMu.Unlock()
+	}
+}
+
+// OutstandingKeypairs returns the allocation stacks of the tracked keypairs
+// that are not yet freed, oldest first.
+func OutstandingKeypairs() []string {
+	stacksMu.Lock()
+	allocs := make([]allocation, 0, len(stacks))
+	for _, a := range stacks {
+		allocs = append(allocs, a)
+	}
+	stacksMu.Unlock()
+	sort.Slice(allocs, func(i, j int) bool { return allocs[i].seq < allocs[j].seq })
+	out := make([]string, len(allocs))
+	for i, a := range allocs {
+		out[i] = a.stack
+	}
+	return out
+}
+
+func keypairAllocated(handle uintptr) {
+	metrics.LeansigKeypairs.Set(float64(liveKeypairs.Add(1)))
+	if !tracking.Load() {
+		return
+	}
+	stack := string(debug.Stack())
+	stacksMu.Lock()
+	allocSeq++
+	stacks[handle] = allocation{seq: allocSeq, stack: stack}
+	stacksMu.Unlock()
+}
+
+// keypairFreed accounts for a released handle. leaked is set when the
+// finalizer released it because Free was never called.
+func keypairFreed(handle uintptr, leaked bool) {
+	metrics.LeansigKeypairs.Set(float64(liveKeypairs.Add(-1)))
+	stacksMu.Lock()
+	alloc, tracked := stacks[handle]
+	delete(stacks, handle)
+	stacksMu.Unlock()
+	if !leaked {
+		return
+	}
+	leakedKeypair.Add(1)
+	metrics.LeansigKeypairsLeaked.Inc()
+	if tracked {
+		leakLog().Warn("leansig keypair released without Free", "allocated_at", alloc.stack)
+	}
+}
+
+func bufferAllocated() { metrics.LeansigBuffers.Set(float64(liveBuffers.Add(1))) }
+
+func bufferFreed() { metrics.LeansigBuffers.Set(float64(liveBuffers.Add(-1))) }
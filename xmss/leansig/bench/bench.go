@@ -0,0 +1,198 @@
+// Package bench measures leansig key generation, signing and verification
+// latency and reports it in a JSON form meant for comparison across
+// clients.
+package bench
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"slices"
+	"time"
+
+	"github.com/geanlabs/gean/xmss/leansig"
+)
+
+// Scheme is the XMSS instantiation leansig is built for.
+const Scheme = "SIGTopLevelTargetSumLifetime32Dim64Base8"
+
+// Operations measured by Run.
+const (
+	OpKeygen      = "keygen"
+	OpSign        = "sign"
+	OpVerify      = "verify"
+	OpVerifyBatch = "verify_batch"
+)
+
+// Config selects what Run measures.
+type Config struct {
+	// ActiveEpochs are the key lifetimes to measure, one key each.
+	ActiveEpochs []uint64
+	// Iterations is the number of signatures and verifications per key. It
+	// is capped at the key's prepared window, as each signature uses a
+	// fresh epoch.
+	Iterations int
+	// KeygenIterations is the number of keys generated per lifetime to
+	// time key generation; the first is the one measured further.
+	KeygenIterations int
+	// BatchSize is the number of signatures per batch verification; 0
+	// skips batch verification.
+	BatchSize int
+	// Seed seeds key generation.
+	Seed uint64
+}
+
+// Result is the latency distribution of one operation at one key lifetime.
+// For verify_batch each sample is a whole batch of BatchSize signatures.
+type Result struct {
+	Operation    string `json:"operation"`
+	ActiveEpochs uint64 `json:"active_epochs"`
+	BatchSize    int    `json:"batch_size,omitempty"`
+	Samples      int    `json:"samples"`
+	MeanNs       int64  `json:"mean_ns"`
+	MinNs        int64  `json:"min_ns"`
+	P50Ns        int64  `json:"p50_ns"`
+	P99Ns        int64  `json:"p99_ns"`
+	MaxNs        int64  `json:"max_ns"`
+}
+
+// Report is the outcome of Run.
+type Report struct {
+	Client    string    `json:"client"`
+	Version   string    `json:"version,omitempty"`
+	Scheme    string    `json:"scheme"`
+	GoVersion string    `json:"go_version"`
+	OS        string    `json:"os"`
+	Arch      string    `json:"arch"`
+	CPUs      int       `json:"cpus"`
+	Time      time.Time `json:"time"`
+	Results   []Result  `json:"results"`
+}
+
+// Run measures every operation at every lifetime in cfg.
+func Run(cfg Config) (*Report, error) {
+	if len(cfg.ActiveEpochs) == 0 {
+		return nil, errors.New("no key lifetimes to measure")
+	}
+	if cfg.Iterations <= 0 {
+		return nil, fmt.Errorf("iterations must be positive, got %d", cfg.Iterations)
+	}
+	report := &Report{
+		Client:    "gean",
+		Scheme:    Scheme,
+		GoVersion: runtime.Version(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		CPUs:      runtime.NumCPU(),
+		Time:      time.Now().UTC(),
+	}
+	for _, epochs := range cfg.ActiveEpochs {
+		results, err := runLifetime(cfg, epochs)
+		if err != nil {
+			return nil, fmt.Errorf("%d active epochs: %w", epochs, err)
+		}
+		report.Results = append(report.Results, results...)
+	}
+	return report, nil
+}
+
+func runLifetime(cfg Config, epochs uint64) ([]Result, error) {
+	var kp *leansig.Keypair
+	var keygen []time.Duration
+	for i := range max(cfg.KeygenIterations, 1) {
+		start := time.Now()
+		k, err := leansig.GenerateKeypair(cfg.Seed+uint64(i), 0, epochs)
+		if err != nil {
+			return nil, err
+		}
+		keygen = append(keygen, time.Since(start))
+		if kp == nil {
+			kp = k
+		} else {
+			k.Free()
+		}
+	}
+	defer kp.Free()
+
+	pk, err := kp.PublicKeyBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	first := kp.PreparedStart()
+	n := min(uint64(cfg.Iterations), kp.PreparedEnd()-first)
+	epochList := make([]uint32, n)
+	messages := make([][leansig.MessageLength]byte, n)
+	sigs := make([][]byte, n)
+	var sign, verify []time.Duration
+	for i := range n {
+		epochList[i] = uint32(first + i)
+		messages[i][0], messages[i][1] = byte(i), byte(i>>8)
+		start := time.Now()
+		if sigs[i], err = kp.Sign(epochList[i], messages[i]); err != nil {
+			return nil, fmt.Errorf("sign at epoch %d: %w", epochList[i], err)
+		}
+		sign = append(sign, time.Since(start))
+	}
+	for i := range n {
+		start := time.Now()
+		if err := leansig.Verify(pk, epochList[i], messages[i], sigs[i]); err != nil {
+			return nil, fmt.Errorf("verify at epoch %d: %w", epochList[i], err)
+		}
+		verify = append(verify, time.Since(start))
+	}
+
+	results := []Result{
+		summarize(OpKeygen, epochs, 0, keygen),
+		summarize(OpSign, epochs, 0, sign),
+		summarize(OpVerify, epochs, 0, verify),
+	}
+
+	if cfg.BatchSize > 0 && n > 0 {
+		pubkeys := make([][]byte, cfg.BatchSize)
+		for i := range pubkeys {
+			pubkeys[i] = pk
+		}
+		var batch []time.Duration
+		for round := range max(int(n)/cfg.BatchSize, 1) {
+			bEpochs := make([]uint32, cfg.BatchSize)
+			bMessages := make([][leansig.MessageLength]byte, cfg.BatchSize)
+			bSigs := make([][]byte, cfg.BatchSize)
+			for i := range cfg.BatchSize {
+				j := (round*cfg.BatchSize + i) % int(n)
+				bEpochs[i], bMessages[i], bSigs[i] = epochList[j], messages[j], sigs[j]
+			}
+			start := time.Now()
+			valid, err := leansig.VerifyBatch(pubkeys, bEpochs, bMessages, bSigs)
+			if err != nil {
+				return nil, fmt.Errorf("batch verify: %w", err)
+			}
+			batch = append(batch, time.Since(start))
+			if i := slices.Index(valid, false); i >= 0 {
+				return nil, fmt.Errorf("batch verify rejected signature at epoch %d", bEpochs[i])
+			}
+		}
+		results = append(results, summarize(OpVerifyBatch, epochs, cfg.BatchSize, batch))
+	}
+	return results, nil
+}
+
+// summarize returns the distribution of samples.
+func summarize(op string, epochs uint64, batchSize int, samples []time.Duration) Result {
+	r := Result{Operation: op, ActiveEpochs: epochs, BatchSize: batchSize, Samples: len(samples)}
+	if len(samples) == 0 {
+		return r
+	}
+	sorted := slices.Clone(samples)
+	slices.Sort(sorted)
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	r.MeanNs = int64(total) / int64(len(sorted))
+	r.MinNs = int64(sorted[0])
+	r.P50Ns = int64(sorted[len(sorted)/2])
+	r.P99Ns = int64(sorted[(len(sorted)*99)/100])
+	r.MaxNs = int64(sorted[len(sorted)-1])
+	return r
+}
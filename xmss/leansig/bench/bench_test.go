@@ -0,0 +1,139 @@
+package bench_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/geanlabs/gean/xmss/leansig"
+	"github.com/geanlabs/gean/xmss/leansig/bench"
+)
+
+// benchEpochs is the key lifetime of the Go benchmarks, matching the
+// devnet-1 keys of the leansig tests.
+const benchEpochs = 262144
+
+func TestRunReportsEveryOperation(t *testing.T) {
+	if testing.Short() {
+		t.Skip("generates a full-lifetime key")
+	}
+	report, err := bench.Run(bench.Config{
+		ActiveEpochs: []uint64{benchEpochs},
+		Iterations:   4,
+		BatchSize:    2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{bench.OpKeygen, bench.OpSign, bench.OpVerify, bench.OpVerifyBatch}
+	if len(report.Results) != len(want) {
+		t.Fatalf("got %d results, want %d", len(report.Results), len(want))
+	}
+	for i, r := range report.Results {
+		if r.Operation != want[i] || r.ActiveEpochs != benchEpochs || r.Samples == 0 {
+			t.Errorf("result %d = %+v, want %s over %d epochs", i, r, want[i], benchEpochs)
+		}
+		if r.MinNs > r.P50Ns || r.P50Ns > r.MaxNs {
+			t.Errorf("%s: unordered distribution %+v", r.Operation, r)
+		}
+	}
+	if report.Results[3].BatchSize != 2 || report.Results[3].Samples != 2 {
+		t.Errorf("verify_batch = %+v, want 2 batches of 2", report.Results[3])
+	}
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded["scheme"] != bench.Scheme || decoded["client"] != "gean" {
+		t.Fatalf("report header = %v", decoded)
+	}
+}
+
+func TestRunRejectsEmptyConfig(t *testing.T) {
+	if _, err := bench.Run(bench.Config{Iterations: 1}); err == nil {
+		t.Error("Run without lifetimes succeeded")
+	}
+	if _, err := bench.Run(bench.Config{ActiveEpochs: []uint64{benchEpochs}}); err == nil {
+		t.Error("Run without iterations succeeded")
+	}
+}
+
+func newBenchKey(b *testing.B) (*leansig.Keypair, []byte) {
+	b.Helper()
+	kp, err := leansig.GenerateKeypair(1, 0, benchEpochs)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(kp.Free)
+	pk, err := kp.PublicKeyBytes()
+	if err != nil {
+		b.Fatal(err)
+	}
+	return kp, pk
+}
+
+func BenchmarkKeygen(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		kp, err := leansig.GenerateKeypair(uint64(i), 0, benchEpochs)
+		if err != nil {
+			b.Fatal(err)
+		}
+		kp.Free()
+	}
+}
+
+func BenchmarkSign(b *testing.B) {
+	kp, _ := newBenchKey(b)
+	start, window := kp.PreparedStart(), kp.PreparedEnd()-kp.PreparedStart()
+	var msg [leansig.MessageLength]byte
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := kp.Sign(uint32(start+uint64(i)%window), msg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkVerify(b *testing.B) {
+	kp, pk := newBenchKey(b)
+	epoch := uint32(kp.PreparedStart())
+	var msg [leansig.MessageLength]byte
+	sig, err := kp.Sign(epoch, msg)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := leansig.Verify(pk, epoch, msg, sig); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkVerifyBatch64(b *testing.B) {
+	const size = 64
+	kp, pk := newBenchKey(b)
+	pubkeys := make([][]byte, size)
+	epochs := make([]uint32, size)
+	messages := make([][leansig.MessageLength]byte, size)
+	sigs := make([][]byte, size)
+	for i := range size {
+		pubkeys[i] = pk
+		epochs[i] = uint32(kp.PreparedStart()) + uint32(i)
+		messages[i][0] = byte(i)
+		var err error
+		if sigs[i], err = kp.Sign(epochs[i], messages[i]); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := leansig.VerifyBatch(pubkeys, epochs, messages, sigs); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
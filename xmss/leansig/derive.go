@@ -0,0 +1,53 @@
+package leansig
+
+import (
+	"crypto/hkdf"
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ValidatorKeyPath returns the well-known derivation path of validator
+// index's key.
+func ValidatorKeyPath(index uint64) string {
+	return fmt.Sprintf("m/lean/xmss/%d", index)
+}
+
+// MnemonicSeed returns the 64-byte master seed of a seed phrase, computed as
+// in BIP-39: PBKDF2-HMAC-SHA512 over the phrase with salt "mnemonic" plus
+// passphrase and 2048 iterations. Words are separated by single spaces
+// before hashing. The words are not checked against a wordlist, but only
+// ASCII phrases are accepted, so no Unicode normalization is needed.
+func MnemonicSeed(mnemonic, passphrase string) ([]byte, error) {
+	words := strings.Fields(mnemonic)
+	if len(words) == 0 {
+		return nil, errors.New("mnemonic is empty")
+	}
+	phrase := strings.Join(words, " ")
+	for _, s := range []string{phrase, passphrase} {
+		for i := 0; i < len(s); i++ {
+			if s[i] >= 0x80 {
+				return nil, errors.New("mnemonic and passphrase must be ASCII")
+			}
+		}
+	}
+	return pbkdf2.Key(sha512.New, phrase, []byte("mnemonic"+passphrase), 2048, 64)
+}
+
+// DeriveKeySeed derives the key generation seed at path from a master seed
+// with HKDF-SHA256. GenerateKeypair takes a 64-bit seed, so that is all the
+// derived entropy that reaches a key.
+func DeriveKeySeed(master []byte, path string) (uint64, error) {
+	if len(master) < 16 {
+		return 0, fmt.Errorf("master seed is %d bytes, want at least 16", len(master))
+	}
+	out, err := hkdf.Key(sha256.New, master, []byte("gean-xmss-keygen"), path, 8)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(out), nil
+}
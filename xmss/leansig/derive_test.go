@@ -0,0 +1,77 @@
+package leansig_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/geanlabs/gean/xmss/leansig"
+)
+
+func TestMnemonicSeedMatchesBIP39(t *testing.T) {
+	// Test vector from the BIP-39 reference implementation.
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	want := "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04"
+
+	seed, err := leansig.MnemonicSeed(mnemonic, "TREZOR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hex.EncodeToString(seed); got != want {
+		t.Fatalf("seed = %s, want %s", got, want)
+	}
+
+	// Extra whitespace does not change the seed.
+	spaced, err := leansig.MnemonicSeed("  "+mnemonic[:7]+"\n "+mnemonic[8:]+" ", "TREZOR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hex.EncodeToString(spaced) != want {
+		t.Fatal("whitespace changed the seed")
+	}
+}
+
+func TestMnemonicSeedRejectsInvalid(t *testing.T) {
+	for _, tc := range []struct{ mnemonic, passphrase string }{
+		{"", ""},
+		{"  ", ""},
+		{"abandon", "pässphrase"},
+		{"äbandon", ""},
+	} {
+		if _, err := leansig.MnemonicSeed(tc.mnemonic, tc.passphrase); err == nil {
+			t.Errorf("MnemonicSeed(%q, %q) succeeded", tc.mnemonic, tc.passphrase)
+		}
+	}
+}
+
+func TestDeriveKeySeed(t *testing.T) {
+	master, err := leansig.MnemonicSeed("abandon abandon about", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[uint64]uint64)
+	for i := uint64(0); i < 16; i++ {
+		seed, err := leansig.DeriveKeySeed(master, leansig.ValidatorKeyPath(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		again, _ := leansig.DeriveKeySeed(master, leansig.ValidatorKeyPath(i))
+		if seed != again {
+			t.Fatalf("validator %d: derivation is not deterministic", i)
+		}
+		if j, ok := seen[seed]; ok {
+			t.Fatalf("validators %d and %d derived the same seed", j, i)
+		}
+		seen[seed] = i
+	}
+
+	other, _ := leansig.MnemonicSeed("abandon abandon about", "other")
+	a, _ := leansig.DeriveKeySeed(master, leansig.ValidatorKeyPath(0))
+	b, _ := leansig.DeriveKeySeed(other, leansig.ValidatorKeyPath(0))
+	if a == b {
+		t.Fatal("different passphrases derived the same seed")
+	}
+
+	if _, err := leansig.DeriveKeySeed(master[:8], leansig.ValidatorKeyPath(0)); err == nil {
+		t.Fatal("short master seed accepted")
+	}
+}
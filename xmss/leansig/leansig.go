@@ -12,10 +12,55 @@ package leansig
 #cgo LDFLAGS: ${SRCDIR}/../leansig-ffi/target/release/deps/libleansig_ffi.a -lm -ldl -lpthread
 #include "leansig_ffi.h"
 #include <stdlib.h>
+
+// leansig_verify_batch is weak so the bindings still link against builds of
+// leansig-ffi that predate it; those verify the batch one by one, still in a
+// single call from Go.
+#pragma weak leansig_verify_batch
+
+static enum LeansigResult gean_verify_batch(size_t count,
+                                            const uint8_t *pk_data, const size_t *pk_lens,
+                                            const uint32_t *epochs, const uint8_t *messages,
+                                            const uint8_t *sig_data, const size_t *sig_lens,
+                                            enum LeansigResult *out_results) {
+	if (leansig_verify_batch) {
+		return leansig_verify_batch(count, pk_data, pk_lens, epochs, messages, sig_data, sig_lens, out_results);
+	}
+	for (size_t i = 0; i < count; i++) {
+		out_results[i] = leansig_verify(pk_data, pk_lens[i], epochs[i], messages + 32 * i, sig_data, sig_lens[i]);
+		pk_data += pk_lens[i];
+		sig_data += sig_lens[i];
+	}
+	return LEANSIG_RESULT_OK;
+}
+
+// The encoding helpers are weak for the same reason. Builds without them
+// use the devnet-1 sizes and only check the signature length.
+#pragma weak leansig_signature_size
+#pragma weak leansig_pubkey_size
+#pragma weak leansig_signature_validate
+
+static size_t gean_signature_size(void) {
+	return leansig_signature_size ? leansig_signature_size() : 3112;
+}
+
+static size_t gean_pubkey_size(void) {
+	return leansig_pubkey_size ? leansig_pubkey_size() : 52;
+}
+
+static enum LeansigResult gean_signature_validate(const uint8_t *sig_data, size_t sig_len) {
+	if (leansig_signature_validate) {
+		return leansig_signature_validate(sig_data, sig_len);
+	}
+	return sig_len == gean_signature_size() ? LEANSIG_RESULT_OK : LEANSIG_RESULT_INVALID_LENGTH;
+}
 */
 import "C"
 import (
+	"errors"
 	"fmt"
+	"runtime"
+	"sync"
 	"unsafe"
 )
 
@@ -33,9 +78,106 @@ const (
 	ResultEpochNotPrepared      = C.LEANSIG_RESULT_EPOCH_NOT_PREPARED
 )
 
+// ErrMalformedSignature is returned for bytes that do not decode as a
+// signature.
+var ErrMalformedSignature = errors.New("malformed signature")
+
+var (
+	signatureSize = int(C.gean_signature_size())
+	publicKeySize = int(C.gean_pubkey_size())
+)
+
+// SignatureSize returns the size in bytes of an SSZ-serialized signature.
+func SignatureSize() int { return signatureSize }
+
+// PublicKeySize returns the size in bytes of an SSZ-serialized public key.
+func PublicKeySize() int { return publicKeySize }
+
+// ValidateSignatureEncoding checks that sig decodes as a signature without
+// verifying it, so malformed signatures can be dropped before the expensive
+// verification.
+func ValidateSignatureEncoding(sig []byte) error {
+	if len(sig) != signatureSize {
+		return fmt.Errorf("%w: %d bytes, want %d", ErrMalformedSignature, len(sig), signatureSize)
+	}
+	result := C.gean_signature_validate((*C.uint8_t)(unsafe.Pointer(&sig[0])), C.size_t(len(sig)))
+	if result != ResultOK {
+		return fmt.Errorf("%w: leansig_signature_validate failed with code %d", ErrMalformedSignature, result)
+	}
+	return nil
+}
+
+// ErrKeypairClosed is returned by a Keypair whose handle was released.
+var ErrKeypairClosed = errors.New("keypair is closed")
+
 // Keypair wraps an opaque leansig keypair handle.
+//
+// A Keypair is safe for concurrent use. Calls that only read the key run in
+// parallel; Sign, AdvancePreparation and the release of the handle run one
+// at a time, so concurrent duties signing with one key are serialized
+// rather than racing through the FFI. The lock does not stop two signatures
+// at the same epoch: callers must still sign each epoch once. For that
+// reason a Keypair cannot be copied; holders share one through Retain.
+//
+// A Keypair starts with one reference; Retain adds one for each additional
+// holder and Free drops one, releasing the handle with the last. After that,
+// methods return ErrKeypairClosed (or 0 for the interval getters) instead of
+// touching freed memory. A handle that is never freed is released by a
+// finalizer once the Keypair is unreachable.
 type Keypair struct {
-	ptr *C.LeansigKeypair
+	// mu is held for reading across calls that only read the key, and for
+	// writing to sign, advance the preparation or release the handle.
+	mu   sync.RWMutex
+	ptr  *C.LeansigKeypair
+	refs int
+}
+
+// newKeypair wraps ptr and arranges for it to be released if the Keypair is
+// dropped without Free.
+func newKeypair(ptr *C.LeansigKeypair) *Keypair {
+	kp := &Keypair{ptr: ptr, refs: 1}
+	keypairAllocated(uintptr(unsafe.Pointer(ptr)))
+	runtime.SetFinalizer(kp, (*Keypair).release)
+	return kp
+}
+
+// release frees the handle regardless of outstanding references. It only
+// runs as the finalizer, so a handle it frees was leaked.
+func (kp *Keypair) release() {
+	kp.mu.Lock()
+	defer kp.mu.Unlock()
+	if kp.ptr != nil {
+		kp.freeLocked(true)
+	}
+}
+
+// freeLocked frees the handle. kp.mu must be held for writing.
+func (kp *Keypair) freeLocked(leaked bool) {
+	handle := uintptr(unsafe.Pointer(kp.ptr))
+	C.leansig_keypair_free(kp.ptr)
+	kp.ptr = nil
+	keypairFreed(handle, leaked)
+}
+
+// takeBytes copies a buffer returned by the library into Go memory and
+// frees it.
+func takeBytes(data *C.uint8_t, dataLen C.size_t) []byte {
+	bufferAllocated()
+	goBytes := C.GoBytes(unsafe.Pointer(data), C.int(dataLen))
+	C.leansig_bytes_free(data, dataLen)
+	bufferFreed()
+	return goBytes
+}
+
+// rlock read-locks kp for a call into leansig. On success the caller must
+// call kp.mu.RUnlock.
+func (kp *Keypair) rlock() error {
+	kp.mu.RLock()
+	if kp.ptr == nil {
+		kp.mu.RUnlock()
+		return ErrKeypairClosed
+	}
+	return nil
 }
 
 // GenerateKeypair creates a new XMSS keypair.
@@ -55,7 +197,7 @@ func GenerateKeypair(seed uint64, activationEpoch uint64, numActiveEpochs uint64
 	if result != ResultOK {
 		return nil, fmt.Errorf("leansig_keypair_generate failed with code %d", result)
 	}
-	return &Keypair{ptr: ptr}, nil
+	return newKeypair(ptr), nil
 }
 
 // RestoreKeypair reconstructs a Keypair from serialized public and secret keys.
@@ -79,87 +221,109 @@ func RestoreKeypair(pkBytes []byte, skBytes []byte) (*Keypair, error) {
 		return nil, fmt.Errorf("leansig_keypair_restore failed with code %d", result)
 	}
 
-	return &Keypair{ptr: kpPtr}, nil
+	return newKeypair(kpPtr), nil
+}
+
+// Retain adds a reference to kp, so it stays usable until Free is called
+// once more. It fails if kp is already closed.
+func (kp *Keypair) Retain() error {
+	kp.mu.Lock()
+	defer kp.mu.Unlock()
+	if kp.ptr == nil {
+		return ErrKeypairClosed
+	}
+	kp.refs++
+	return nil
 }
 
-// Free releases the memory associated with this keypair.
-// The keypair must not be used after calling Free.
+// Free drops a reference to kp and releases the handle when it was the
+// last one. Calls in flight finish first; freeing a closed keypair does
+// nothing.
 func (kp *Keypair) Free() {
-	if kp.ptr != nil {
-		C.leansig_keypair_free(kp.ptr)
-		kp.ptr = nil
+	kp.mu.Lock()
+	defer kp.mu.Unlock()
+	if kp.ptr == nil {
+		return
 	}
+	if kp.refs--; kp.refs > 0 {
+		return
+	}
+	kp.freeLocked(false)
+	runtime.SetFinalizer(kp, nil)
 }
 
 // PublicKeyBytes returns the SSZ-serialized public key.
 func (kp *Keypair) PublicKeyBytes() ([]byte, error) {
-	if kp.ptr == nil {
-		return nil, fmt.Errorf("keypair is nil")
+	if err := kp.rlock(); err != nil {
+		return nil, err
 	}
+	defer kp.mu.RUnlock()
 	var data *C.uint8_t
 	var dataLen C.size_t
 	result := C.leansig_pubkey_serialize(kp.ptr, &data, &dataLen)
 	if result != ResultOK {
 		return nil, fmt.Errorf("leansig_pubkey_serialize failed with code %d", result)
 	}
-	// Copy the data to a Go-managed slice
-	goBytes := C.GoBytes(unsafe.Pointer(data), C.int(dataLen))
-	C.leansig_bytes_free(data, dataLen)
-	return goBytes, nil
+	return takeBytes(data, dataLen), nil
 }
 
 // SecretKeyBytes returns the SSZ-serialized secret key.
 func (kp *Keypair) SecretKeyBytes() ([]byte, error) {
-	if kp.ptr == nil {
-		return nil, fmt.Errorf("keypair is nil")
+	if err := kp.rlock(); err != nil {
+		return nil, err
 	}
+	defer kp.mu.RUnlock()
 	var data *C.uint8_t
 	var dataLen C.size_t
 	result := C.leansig_seckey_serialize(kp.ptr, &data, &dataLen)
 	if result != ResultOK {
 		return nil, fmt.Errorf("leansig_seckey_serialize failed with code %d", result)
 	}
-	goBytes := C.GoBytes(unsafe.Pointer(data), C.int(dataLen))
-	C.leansig_bytes_free(data, dataLen)
-	return goBytes, nil
+	return takeBytes(data, dataLen), nil
 }
 
 // ActivationStart returns the start of the activation interval.
 func (kp *Keypair) ActivationStart() uint64 {
-	if kp.ptr == nil {
+	if kp.rlock() != nil {
 		return 0
 	}
+	defer kp.mu.RUnlock()
 	return uint64(C.leansig_sk_activation_start(kp.ptr))
 }
 
 // ActivationEnd returns the end (exclusive) of the activation interval.
 func (kp *Keypair) ActivationEnd() uint64 {
-	if kp.ptr == nil {
+	if kp.rlock() != nil {
 		return 0
 	}
+	defer kp.mu.RUnlock()
 	return uint64(C.leansig_sk_activation_end(kp.ptr))
 }
 
 // PreparedStart returns the start of the currently prepared signing window.
 func (kp *Keypair) PreparedStart() uint64 {
-	if kp.ptr == nil {
+	if kp.rlock() != nil {
 		return 0
 	}
+	defer kp.mu.RUnlock()
 	return uint64(C.leansig_sk_prepared_start(kp.ptr))
 }
 
 // PreparedEnd returns the end (exclusive) of the currently prepared signing window.
 func (kp *Keypair) PreparedEnd() uint64 {
-	if kp.ptr == nil {
+	if kp.rlock() != nil {
 		return 0
 	}
+	defer kp.mu.RUnlock()
 	return uint64(C.leansig_sk_prepared_end(kp.ptr))
 }
 
 // AdvancePreparation advances the secret key's prepared interval to the next window.
 func (kp *Keypair) AdvancePreparation() error {
+	kp.mu.Lock()
+	defer kp.mu.Unlock()
 	if kp.ptr == nil {
-		return fmt.Errorf("keypair is nil")
+		return ErrKeypairClosed
 	}
 	result := C.leansig_sk_advance_preparation(kp.ptr)
 	if result != ResultOK {
@@ -172,8 +336,10 @@ func (kp *Keypair) AdvancePreparation() error {
 // The epoch must be within the key's prepared interval.
 // Returns the SSZ-serialized signature bytes.
 func (kp *Keypair) Sign(epoch uint32, message [MessageLength]byte) ([]byte, error) {
+	kp.mu.Lock()
+	defer kp.mu.Unlock()
 	if kp.ptr == nil {
-		return nil, fmt.Errorf("keypair is nil")
+		return nil, ErrKeypairClosed
 	}
 	var sigData *C.uint8_t
 	var sigLen C.size_t
@@ -187,9 +353,7 @@ func (kp *Keypair) Sign(epoch uint32, message [MessageLength]byte) ([]byte, erro
 	if result != ResultOK {
 		return nil, fmt.Errorf("leansig_sign failed with code %d", result)
 	}
-	goBytes := C.GoBytes(unsafe.Pointer(sigData), C.int(sigLen))
-	C.leansig_bytes_free(sigData, sigLen)
-	return goBytes, nil
+	return takeBytes(sigData, sigLen), nil
 }
 
 // Verify checks an XMSS signature against a serialized public key, epoch, and message.
@@ -215,12 +379,61 @@ func Verify(pubkeyBytes []byte, epoch uint32, message [MessageLength]byte, sigBy
 	return fmt.Errorf("leansig_verify failed with code %d", result)
 }
 
+// VerifyBatch checks n signatures, given as parallel slices, crossing into the
+// library once; it verifies them in parallel. The i-th result reports
+// whether sigs[i] is a valid signature of messages[i] at epochs[i] under
+// pubkeys[i]. It returns an error only for malformed arguments.
+func VerifyBatch(pubkeys [][]byte, epochs []uint32, messages [][MessageLength]byte, sigs [][]byte) ([]bool, error) {
+	n := len(pubkeys)
+	if len(epochs) != n || len(messages) != n || len(sigs) != n {
+		return nil, fmt.Errorf("batch lengths differ: %d pubkeys, %d epochs, %d messages, %d signatures",
+			n, len(epochs), len(messages), len(sigs))
+	}
+	if n == 0 {
+		return nil, nil
+	}
+
+	// Keys and signatures are passed concatenated, as cgo may not pass Go
+	// memory that holds pointers to other Go memory.
+	pkLens := make([]C.size_t, n)
+	sigLens := make([]C.size_t, n)
+	var pkData, sigData []byte
+	for i := range n {
+		if len(pubkeys[i]) == 0 || len(sigs[i]) == 0 {
+			return nil, fmt.Errorf("empty pubkey or signature bytes at index %d", i)
+		}
+		pkLens[i], sigLens[i] = C.size_t(len(pubkeys[i])), C.size_t(len(sigs[i]))
+		pkData = append(pkData, pubkeys[i]...)
+		sigData = append(sigData, sigs[i]...)
+	}
+	results := make([]C.enum_LeansigResult, n)
+	result := C.gean_verify_batch(
+		C.size_t(n),
+		(*C.uint8_t)(unsafe.Pointer(&pkData[0])),
+		&pkLens[0],
+		(*C.uint32_t)(unsafe.Pointer(&epochs[0])),
+		(*C.uint8_t)(unsafe.Pointer(&messages[0][0])),
+		(*C.uint8_t)(unsafe.Pointer(&sigData[0])),
+		&sigLens[0],
+		&results[0],
+	)
+	if result != ResultOK {
+		return nil, fmt.Errorf("leansig_verify_batch failed with code %d", result)
+	}
+	valid := make([]bool, n)
+	for i, r := range results {
+		valid[i] = r == ResultOK
+	}
+	return valid, nil
+}
+
 // VerifyWithKeypair checks an XMSS signature using the public key from a keypair.
 // Convenience wrapper that avoids public key serialization/deserialization.
 func (kp *Keypair) VerifyWithKeypair(epoch uint32, message [MessageLength]byte, sigBytes []byte) error {
-	if kp.ptr == nil {
-		return fmt.Errorf("keypair is nil")
+	if err := kp.rlock(); err != nil {
+		return err
 	}
+	defer kp.mu.RUnlock()
 	if len(sigBytes) == 0 {
 		return fmt.Errorf("empty signature bytes")
 	}
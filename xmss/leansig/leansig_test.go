@@ -2,10 +2,16 @@ package leansig_test
 
 import (
 	"crypto/rand"
+	"errors"
 	"fmt"
 	"os"
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/geanlabs/gean/types"
 	"github.com/geanlabs/gean/xmss/leansig"
 )
 
@@ -138,6 +144,60 @@ func TestVerifyRejectsWrongEpoch(t *testing.T) {
 	}
 }
 
+// signBatch signs one message per epoch in [0, n) with the shared keypair.
+func signBatch(t *testing.T, n int) ([][]byte, []uint32, [][leansig.MessageLength]byte, [][]byte) {
+	t.Helper()
+	pkBytes, err := sharedKP.PublicKeyBytes()
+	if err != nil {
+		t.Fatalf("PublicKeyBytes failed: %v", err)
+	}
+	pubkeys := make([][]byte, n)
+	epochs := make([]uint32, n)
+	msgs := make([][leansig.MessageLength]byte, n)
+	sigs := make([][]byte, n)
+	for i := range n {
+		pubkeys[i], epochs[i] = pkBytes, uint32(i)
+		copy(msgs[i][:], fmt.Sprintf("batch message %d", i))
+		if sigs[i], err = sharedKP.Sign(epochs[i], msgs[i]); err != nil {
+			t.Fatalf("Sign failed: %v", err)
+		}
+	}
+	return pubkeys, epochs, msgs, sigs
+}
+
+func TestVerifyBatch(t *testing.T) {
+	pubkeys, epochs, msgs, sigs := signBatch(t, 4)
+	valid, err := leansig.VerifyBatch(pubkeys, epochs, msgs, sigs)
+	if err != nil {
+		t.Fatalf("VerifyBatch failed: %v", err)
+	}
+	for i, ok := range valid {
+		if !ok {
+			t.Errorf("signature %d rejected", i)
+		}
+	}
+
+	if _, err := leansig.VerifyBatch(pubkeys, epochs[:3], msgs, sigs); err == nil {
+		t.Error("expected an error for mismatched batch lengths")
+	}
+	if valid, err := leansig.VerifyBatch(nil, nil, nil, nil); err != nil || len(valid) != 0 {
+		t.Errorf("empty batch = %v, %v", valid, err)
+	}
+}
+
+func TestVerifyBatchRejectsBadSignatures(t *testing.T) {
+	pubkeys, epochs, msgs, sigs := signBatch(t, 4)
+	copy(msgs[1][:], "wrong message!!")
+	epochs[2]++
+	valid, err := leansig.VerifyBatch(pubkeys, epochs, msgs, sigs)
+	if err != nil {
+		t.Fatalf("VerifyBatch failed: %v", err)
+	}
+	if want := []bool{true, false, false, true}; fmt.Sprint(valid) != fmt.Sprint(want) {
+		t.Fatalf("valid = %v, want %v", valid, want)
+	}
+}
+
 func TestAdvancePreparation(t *testing.T) {
 	// We need > 131072 epochs to trigger window advancement.
 	// 200000 epochs roughly covers 1.5 windows.
@@ -169,3 +229,191 @@ func TestAdvancePreparation(t *testing.T) {
 		t.Errorf("prepared end did not advance: before=%d after=%d", endBefore, endAfter)
 	}
 }
+
+// restoreShared returns an independent copy of the shared keypair.
+func restoreShared(t *testing.T) *leansig.Keypair {
+	t.Helper()
+	pk, err := sharedKP.PublicKeyBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sk, err := sharedKP.SecretKeyBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	kp, err := leansig.RestoreKeypair(pk, sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return kp
+}
+
+func TestKeypairUseAfterFree(t *testing.T) {
+	kp := restoreShared(t)
+	kp.Free()
+	kp.Free() // freeing twice is harmless
+
+	var msg [leansig.MessageLength]byte
+	if _, err := kp.Sign(0, msg); !errors.Is(err, leansig.ErrKeypairClosed) {
+		t.Errorf("Sign after Free: err = %v, want ErrKeypairClosed", err)
+	}
+	if _, err := kp.PublicKeyBytes(); !errors.Is(err, leansig.ErrKeypairClosed) {
+		t.Errorf("PublicKeyBytes after Free: err = %v, want ErrKeypairClosed", err)
+	}
+	if err := kp.AdvancePreparation(); !errors.Is(err, leansig.ErrKeypairClosed) {
+		t.Errorf("AdvancePreparation after Free: err = %v, want ErrKeypairClosed", err)
+	}
+	if err := kp.VerifyWithKeypair(0, msg, []byte{1}); !errors.Is(err, leansig.ErrKeypairClosed) {
+		t.Errorf("VerifyWithKeypair after Free: err = %v, want ErrKeypairClosed", err)
+	}
+	if kp.PreparedEnd() != 0 || kp.ActivationEnd() != 0 {
+		t.Errorf("intervals after Free = %d, %d, want 0", kp.PreparedEnd(), kp.ActivationEnd())
+	}
+	if err := kp.Retain(); !errors.Is(err, leansig.ErrKeypairClosed) {
+		t.Errorf("Retain after Free: err = %v, want ErrKeypairClosed", err)
+	}
+}
+
+func TestKeypairRetain(t *testing.T) {
+	kp := restoreShared(t)
+	if err := kp.Retain(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first Free drops the extra reference; the key keeps signing.
+	kp.Free()
+	var msg [leansig.MessageLength]byte
+	if _, err := kp.Sign(uint32(kp.PreparedStart()), msg); err != nil {
+		t.Fatalf("Sign with a reference left: %v", err)
+	}
+
+	kp.Free()
+	if _, err := kp.Sign(uint32(kp.PreparedStart()), msg); !errors.Is(err, leansig.ErrKeypairClosed) {
+		t.Fatalf("Sign after last Free: err = %v, want ErrKeypairClosed", err)
+	}
+}
+
+func TestKeypairFreeWaitsForSigners(t *testing.T) {
+	kp := restoreShared(t)
+	var msg [leansig.MessageLength]byte
+	var wg sync.WaitGroup
+	for i := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			msg := msg
+			msg[0] = byte(i)
+			if _, err := kp.Sign(uint32(kp.PreparedStart())+uint32(i), msg); err != nil && !errors.Is(err, leansig.ErrKeypairClosed) {
+				t.Errorf("Sign: %v", err)
+			}
+		}()
+	}
+	kp.Free()
+	wg.Wait()
+}
+
+func TestEncodingSizes(t *testing.T) {
+	if got := leansig.SignatureSize(); got != types.XMSSSignatureSize {
+		t.Errorf("SignatureSize() = %d, want %d", got, types.XMSSSignatureSize)
+	}
+	if got := leansig.PublicKeySize(); got != types.XMSSPubkeySize {
+		t.Errorf("PublicKeySize() = %d, want %d", got, types.XMSSPubkeySize)
+	}
+	pk, err := sharedKP.PublicKeyBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pk) != leansig.PublicKeySize() {
+		t.Errorf("public key is %d bytes, want %d", len(pk), leansig.PublicKeySize())
+	}
+}
+
+func TestValidateSignatureEncoding(t *testing.T) {
+	var msg [leansig.MessageLength]byte
+	sig, err := sharedKP.Sign(uint32(sharedKP.PreparedStart()), msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sig) != leansig.SignatureSize() {
+		t.Fatalf("signature is %d bytes, want %d", len(sig), leansig.SignatureSize())
+	}
+	if err := leansig.ValidateSignatureEncoding(sig); err != nil {
+		t.Fatalf("valid signature rejected: %v", err)
+	}
+	for _, bad := range [][]byte{nil, sig[:len(sig)-1], append(sig, 0)} {
+		if err := leansig.ValidateSignatureEncoding(bad); !errors.Is(err, leansig.ErrMalformedSignature) {
+			t.Errorf("%d-byte signature: err = %v, want ErrMalformedSignature", len(bad), err)
+		}
+	}
+}
+
+func TestKeypairConcurrentUse(t *testing.T) {
+	kp := restoreShared(t)
+	defer kp.Free()
+	start := uint32(kp.PreparedStart())
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var msg [leansig.MessageLength]byte
+			msg[0] = byte(i)
+			switch i % 4 {
+			case 0:
+				if _, err := kp.Sign(start+uint32(i), msg); err != nil {
+					t.Errorf("Sign: %v", err)
+				}
+			case 1:
+				if _, err := kp.PublicKeyBytes(); err != nil {
+					t.Errorf("PublicKeyBytes: %v", err)
+				}
+			case 2:
+				kp.PreparedEnd()
+			case 3:
+				if err := kp.Retain(); err != nil {
+					t.Errorf("Retain: %v", err)
+					return
+				}
+				kp.Free()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestAllocationAccounting(t *testing.T) {
+	leansig.TrackAllocations(true)
+	defer leansig.TrackAllocations(false)
+	before := leansig.Allocations()
+
+	kp := restoreShared(t)
+	if got := leansig.Allocations().Keypairs; got != before.Keypairs+1 {
+		t.Fatalf("live keypairs = %d after restore, want %d", got, before.Keypairs+1)
+	}
+	if stacks := leansig.OutstandingKeypairs(); len(stacks) != 1 || !strings.Contains(stacks[0], "restoreShared") {
+		t.Fatalf("outstanding stacks = %q, want the restore", stacks)
+	}
+	if _, err := kp.PublicKeyBytes(); err != nil {
+		t.Fatal(err)
+	}
+	kp.Free()
+	after := leansig.Allocations()
+	if after.Keypairs != before.Keypairs || after.Buffers != 0 {
+		t.Fatalf("after Free: %+v, want %d keypairs and no buffers", after, before.Keypairs)
+	}
+	if len(leansig.OutstandingKeypairs()) != 0 {
+		t.Fatal("freed keypair still tracked")
+	}
+
+	// A keypair dropped without Free is counted as leaked by its finalizer.
+	restoreShared(t)
+	for range 10 {
+		runtime.GC()
+		if leansig.Allocations().LeakedKeypairs > before.LeakedKeypairs {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("dropped keypair was not reported as leaked")
+}
@@ -0,0 +1,183 @@
+// Package remote implements a signer that asks a remote signing service for
+// XMSS signatures, so validator keys can live outside the node process.
+//
+// A request is an HTTP POST of a JSON object to <URL>/sign:
+//
+//	{"slot": 12, "message": "0x<32-byte root>", "pubkey": "0x<validator pubkey>"}
+//
+// and a successful response is 200 with {"signature": "0x<signature>"}.
+// Requests that fail to reach the signer, time out, or get a 429 or 5xx
+// response are retried; other responses are final. The remote signer must
+// answer a repeated request for the same slot and message with the same
+// signature, as a retried request may already have been signed.
+package remote
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Defaults used for zero Config fields.
+const (
+	DefaultTimeout      = 2 * time.Second
+	DefaultRetries      = 2
+	DefaultRetryBackoff = 100 * time.Millisecond
+)
+
+// maxResponseSize bounds the response body read from the signer.
+const maxResponseSize = 64 << 10
+
+var (
+	// ErrRejected is returned when the remote signer refuses a request.
+	ErrRejected = errors.New("remote signer rejected request")
+	// ErrUnavailable is returned when the remote signer could not be reached
+	// or kept failing within the configured retries.
+	ErrUnavailable = errors.New("remote signer unavailable")
+)
+
+// Config configures a remote Signer.
+type Config struct {
+	URL          string        // base URL of the signing service
+	Pubkey       []byte        // public key of the validator the signer signs for
+	Timeout      time.Duration // per attempt; 0 uses DefaultTimeout
+	Retries      int           // attempts after the first; 0 uses DefaultRetries, negative disables retrying
+	RetryBackoff time.Duration // wait before the first retry, doubled on each further one; 0 uses DefaultRetryBackoff
+	Client       *http.Client  // nil uses http.DefaultClient
+}
+
+// Signer signs for one validator through a remote signing service. It
+// satisfies forkchoice.Signer.
+type Signer struct {
+	endpoint string
+	pubkey   string
+	timeout  time.Duration
+	retries  int
+	backoff  time.Duration
+	client   *http.Client
+}
+
+// New returns a Signer for cfg.
+func New(cfg Config) (*Signer, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("remote signer URL is empty")
+	}
+	if !strings.HasPrefix(cfg.URL, "http://") && !strings.HasPrefix(cfg.URL, "https://") {
+		return nil, fmt.Errorf("remote signer URL %q is not http(s)", cfg.URL)
+	}
+	if len(cfg.Pubkey) == 0 {
+		return nil, errors.New("remote signer pubkey is empty")
+	}
+	s := &Signer{
+		endpoint: strings.TrimSuffix(cfg.URL, "/") + "/sign",
+		pubkey:   "0x" + hex.EncodeToString(cfg.Pubkey),
+		timeout:  cfg.Timeout,
+		retries:  cfg.Retries,
+		backoff:  cfg.RetryBackoff,
+		client:   cfg.Client,
+	}
+	if s.timeout <= 0 {
+		s.timeout = DefaultTimeout
+	}
+	if s.retries == 0 {
+		s.retries = DefaultRetries
+	} else if s.retries < 0 {
+		s.retries = 0
+	}
+	if s.backoff <= 0 {
+		s.backoff = DefaultRetryBackoff
+	}
+	if s.client == nil {
+		s.client = http.DefaultClient
+	}
+	return s, nil
+}
+
+type signRequest struct {
+	Slot    uint32 `json:"slot"`
+	Message string `json:"message"`
+	Pubkey  string `json:"pubkey"`
+}
+
+type signResponse struct {
+	Signature string `json:"signature"`
+}
+
+// Sign requests the signature of message at signingSlot.
+func (s *Signer) Sign(signingSlot uint32, message [32]byte) ([]byte, error) {
+	body, err := json.Marshal(signRequest{
+		Slot:    signingSlot,
+		Message: "0x" + hex.EncodeToString(message[:]),
+		Pubkey:  s.pubkey,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	backoff := s.backoff
+	for attempt := 0; ; attempt++ {
+		sig, retry, err := s.attempt(body)
+		if err == nil {
+			return sig, nil
+		}
+		if !retry {
+			return nil, err
+		}
+		if attempt >= s.retries {
+			return nil, fmt.Errorf("%w after %d attempts: %v", ErrUnavailable, attempt+1, err)
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// attempt sends one signing request and reports whether a failure may be
+// retried.
+func (s *Signer) attempt(body []byte) (sig []byte, retry bool, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, true, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, true, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return nil, true, fmt.Errorf("status %s", resp.Status)
+	default:
+		return nil, false, fmt.Errorf("%w: status %s: %s", ErrRejected, resp.Status, strings.TrimSpace(string(data)))
+	}
+
+	var out signResponse
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, false, fmt.Errorf("decode remote signer response: %w", err)
+	}
+	sig, err = hex.DecodeString(strings.TrimPrefix(out.Signature, "0x"))
+	if err != nil {
+		return nil, false, fmt.Errorf("decode remote signature: %w", err)
+	}
+	if len(sig) == 0 {
+		return nil, false, errors.New("remote signer returned an empty signature")
+	}
+	return sig, false, nil
+}
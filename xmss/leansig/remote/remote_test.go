@@ -0,0 +1,123 @@
+package remote_test
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/geanlabs/gean/xmss/leansig/remote"
+)
+
+func TestSignSendsRequest(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/sign" {
+			t.Errorf("request %s %s, want POST /sign", r.Method, r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+		w.Write([]byte(`{"signature":"0xaabb"}`))
+	}))
+	defer srv.Close()
+
+	s, err := remote.New(remote.Config{URL: srv.URL + "/", Pubkey: []byte{1, 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msg [32]byte
+	msg[0] = 0xff
+	sig, err := s.Sign(7, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hex.EncodeToString(sig) != "aabb" {
+		t.Fatalf("signature = %x, want aabb", sig)
+	}
+	if got["slot"] != float64(7) || got["pubkey"] != "0x0102" || got["message"] != "0x"+hex.EncodeToString(msg[:]) {
+		t.Fatalf("request = %v", got)
+	}
+}
+
+func TestSignRetriesServerErrors(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"signature":"0x01"}`))
+	}))
+	defer srv.Close()
+
+	s, _ := remote.New(remote.Config{URL: srv.URL, Pubkey: []byte{1}, Retries: 2, RetryBackoff: time.Millisecond})
+	if _, err := s.Sign(1, [32]byte{}); err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != 3 {
+		t.Fatalf("calls = %d, want 3", calls.Load())
+	}
+
+	calls.Store(-10)
+	if _, err := s.Sign(1, [32]byte{}); !errors.Is(err, remote.ErrUnavailable) {
+		t.Fatalf("err = %v, want ErrUnavailable", err)
+	}
+	if calls.Load() != -7 {
+		t.Fatalf("calls = %d, want 3 attempts", calls.Load()+10)
+	}
+}
+
+func TestSignDoesNotRetryRejections(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, "slashing protection", http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	s, _ := remote.New(remote.Config{URL: srv.URL, Pubkey: []byte{1}, RetryBackoff: time.Millisecond})
+	if _, err := s.Sign(1, [32]byte{}); !errors.Is(err, remote.ErrRejected) {
+		t.Fatalf("err = %v, want ErrRejected", err)
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("calls = %d, want 1", calls.Load())
+	}
+}
+
+func TestSignTimesOut(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	s, _ := remote.New(remote.Config{URL: srv.URL, Pubkey: []byte{1}, Timeout: 20 * time.Millisecond, Retries: -1})
+	start := time.Now()
+	if _, err := s.Sign(1, [32]byte{}); !errors.Is(err, remote.ErrUnavailable) {
+		t.Fatalf("err = %v, want ErrUnavailable", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("Sign took %v despite 20ms timeout", d)
+	}
+}
+
+func TestNewValidatesConfig(t *testing.T) {
+	for _, cfg := range []remote.Config{
+		{Pubkey: []byte{1}},
+		{URL: "ftp://signer", Pubkey: []byte{1}},
+		{URL: "http://signer"},
+	} {
+		if _, err := remote.New(cfg); err == nil {
+			t.Errorf("New(%+v) succeeded", cfg)
+		}
+	}
+}